@@ -0,0 +1,45 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"golang.org/x/sys/unix"
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/errorlist"
+)
+
+// CheckFreeSpace reports the space available to each requested path, and
+// which of them have less than the required bytes available. Every path is
+// checked so the hub can report all of a host's shortfalls at once. Paths that
+// cannot be checked are returned in an error alongside the reply for the
+// remaining paths.
+func (s *Server) CheckFreeSpace(ctx context.Context, in *idl.CheckFreeSpaceRequest) (*idl.CheckFreeSpaceReply, error) {
+	gplog.Info("agent received request to check free space")
+
+	reply := &idl.CheckFreeSpaceReply{}
+
+	var mErr error
+	for _, path := range in.GetPaths() {
+		var stat unix.Statfs_t
+		if err := utils.System.Statfs(path, &stat); err != nil {
+			mErr = errorlist.Append(mErr, xerrors.Errorf("checking available space for %q: %w", path, err))
+			continue
+		}
+
+		available := stat.Bavail * uint64(stat.Bsize)
+		reply.Paths = append(reply.Paths, &idl.CheckFreeSpaceReply_PathSpace{Path: path, Available: available})
+
+		if available < in.GetRequiredBytes() {
+			reply.Insufficient = append(reply.Insufficient, path)
+		}
+	}
+
+	return reply, mErr
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package agent_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/greenplum-db/gpupgrade/agent"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/errorlist"
+)
+
+func TestCheckFreeSpace(t *testing.T) {
+	testlog.SetupLogger()
+	server := agent.NewServer(agent.Config{})
+
+	// statfs reports the given available bytes for each path.
+	statfs := func(available map[string]uint64) func(string, *unix.Statfs_t) error {
+		return func(path string, buf *unix.Statfs_t) error {
+			buf.Bsize = 1
+			buf.Bavail = available[path]
+			return nil
+		}
+	}
+
+	t.Run("reports the available space and every path that is short", func(t *testing.T) {
+		utils.System.Statfs = statfs(map[string]uint64{
+			"/data/primary": 100,
+			"/data/mirror":  500,
+			"/data/standby": 99,
+		})
+		defer func() {
+			utils.System.Statfs = unix.Statfs
+		}()
+
+		reply, err := server.CheckFreeSpace(context.Background(), &idl.CheckFreeSpaceRequest{
+			Paths:         []string{"/data/primary", "/data/mirror", "/data/standby"},
+			RequiredBytes: 200,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error %#v", err)
+		}
+
+		expected := []*idl.CheckFreeSpaceReply_PathSpace{
+			{Path: "/data/primary", Available: 100},
+			{Path: "/data/mirror", Available: 500},
+			{Path: "/data/standby", Available: 99},
+		}
+		if !reflect.DeepEqual(reply.GetPaths(), expected) {
+			t.Errorf("got %v want %v", reply.GetPaths(), expected)
+		}
+
+		insufficient := []string{"/data/primary", "/data/standby"}
+		if !reflect.DeepEqual(reply.GetInsufficient(), insufficient) {
+			t.Errorf("got insufficient paths %q want %q", reply.GetInsufficient(), insufficient)
+		}
+	})
+
+	t.Run("reports no paths as short when there is enough space", func(t *testing.T) {
+		utils.System.Statfs = statfs(map[string]uint64{"/data/primary": 200})
+		defer func() {
+			utils.System.Statfs = unix.Statfs
+		}()
+
+		reply, err := server.CheckFreeSpace(context.Background(), &idl.CheckFreeSpaceRequest{
+			Paths:         []string{"/data/primary"},
+			RequiredBytes: 200,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error %#v", err)
+		}
+
+		if len(reply.GetInsufficient()) != 0 {
+			t.Errorf("got insufficient paths %q want none", reply.GetInsufficient())
+		}
+	})
+
+	t.Run("returns the paths that were checked along with the statfs failures", func(t *testing.T) {
+		expected := errors.New("permission denied")
+		utils.System.Statfs = func(path string, buf *unix.Statfs_t) error {
+			if path == "/data/mirror" {
+				return expected
+			}
+
+			return statfs(map[string]uint64{"/data/primary": 100})(path, buf)
+		}
+		defer func() {
+			utils.System.Statfs = unix.Statfs
+		}()
+
+		reply, err := server.CheckFreeSpace(context.Background(), &idl.CheckFreeSpaceRequest{
+			Paths:         []string{"/data/primary", "/data/mirror"},
+			RequiredBytes: 200,
+		})
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+
+		paths := []*idl.CheckFreeSpaceReply_PathSpace{{Path: "/data/primary", Available: 100}}
+		if !reflect.DeepEqual(reply.GetPaths(), paths) {
+			t.Errorf("got %v want %v", reply.GetPaths(), paths)
+		}
+
+		insufficient := []string{"/data/primary"}
+		if !reflect.DeepEqual(reply.GetInsufficient(), insufficient) {
+			t.Errorf("got insufficient paths %q want %q", reply.GetInsufficient(), insufficient)
+		}
+	})
+
+	t.Run("returns every statfs failure", func(t *testing.T) {
+		expected := errors.New("permission denied")
+		utils.System.Statfs = func(path string, buf *unix.Statfs_t) error {
+			return expected
+		}
+		defer func() {
+			utils.System.Statfs = unix.Statfs
+		}()
+
+		_, err := server.CheckFreeSpace(context.Background(), &idl.CheckFreeSpaceRequest{
+			Paths: []string{"/data/primary", "/data/mirror"},
+		})
+
+		var errs errorlist.Errors
+		if !errors.As(err, &errs) {
+			t.Fatalf("got error %#v want type %T", err, errs)
+		}
+
+		if len(errs) != 2 {
+			t.Errorf("got %d errors want 2", len(errs))
+		}
+
+		for _, err := range errs {
+			if !errors.Is(err, expected) {
+				t.Errorf("got error %#v want %#v", err, expected)
+			}
+		}
+	})
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// unlimitedMethods are not counted against Config.MaxConcurrent. They are
+// cheap, and the hub relies on them to check on and stop a busy agent.
+// TailLog only watches the other requests, and would otherwise hold a slot
+// for the whole operation.
+var unlimitedMethods = map[string]bool{
+	"/idl.Agent/Version":   true,
+	"/idl.Agent/Healthz":   true,
+	"/idl.Agent/StopAgent": true,
+	"/idl.Agent/TailLog":   true,
+}
+
+// concurrencyLimiter rejects requests beyond a fixed number running at once.
+// A nil concurrencyLimiter does not limit.
+type concurrencyLimiter struct {
+	slots chan struct{}
+}
+
+func newConcurrencyLimiter(max int) *concurrencyLimiter {
+	if max <= 0 {
+		return nil
+	}
+
+	return &concurrencyLimiter{slots: make(chan struct{}, max)}
+}
+
+// acquire reserves a slot for method, returning a ResourceExhausted error if
+// none are free. Callers must call the returned release function once the
+// request finishes.
+func (c *concurrencyLimiter) acquire(method string) (func(), error) {
+	if c == nil || unlimitedMethods[method] {
+		return func() {}, nil
+	}
+
+	select {
+	case c.slots <- struct{}{}:
+		return func() { <-c.slots }, nil
+	default:
+		return nil, status.Errorf(codes.ResourceExhausted,
+			"agent is already running the maximum of %d concurrent operations; rejecting %s", cap(c.slots), method)
+	}
+}
@@ -5,12 +5,19 @@ package agent
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
 
+	"github.com/blang/semver/v4"
 	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"golang.org/x/xerrors"
 
 	"github.com/greenplum-db/gpupgrade/idl"
 	"github.com/greenplum-db/gpupgrade/step"
 	"github.com/greenplum-db/gpupgrade/upgrade"
+	"github.com/greenplum-db/gpupgrade/utils/errorlist"
 )
 
 var DeleteDirectoriesFunc = upgrade.DeleteDirectories
@@ -27,10 +34,108 @@ func (s *Server) DeleteStateDirectory(ctx context.Context, in *idl.DeleteStateDi
 func (s *Server) DeleteDataDirectories(ctx context.Context, in *idl.DeleteDataDirectoriesRequest) (*idl.DeleteDataDirectoriesReply, error) {
 	gplog.Info("got a request to delete data directories from the hub")
 
-	err := DeleteDirectoriesFunc(in.Datadirs, upgrade.PostgresFiles, step.DevNullStream)
+	requiredPaths, err := requiredPathsFor(in.GetVersion())
+	if err != nil {
+		return &idl.DeleteDataDirectoriesReply{}, err
+	}
+
+	err = DeleteDirectoriesFunc(in.Datadirs, requiredPaths, step.DevNullStream)
 	return &idl.DeleteDataDirectoriesReply{}, err
 }
 
+// requiredPathsFor returns the paths identifying data directories of the
+// version sent by the hub. When the hub does not know the version, or is too
+// old to send it, only the paths common to all versions are required.
+func requiredPathsFor(version string) ([]string, error) {
+	if version == "" {
+		return upgrade.PostgresFiles, nil
+	}
+
+	v, err := semver.Parse(version)
+	if err != nil {
+		return nil, xerrors.Errorf("parsing data directory version: %w", err)
+	}
+
+	return upgrade.RequiredPathsFor(v), nil
+}
+
+// DeleteDataDirectoriesWithProgress is DeleteDataDirectories, but streams the
+// output of each deletion back to the hub along with the fraction of the
+// directories deleted so far.
+func (s *Server) DeleteDataDirectoriesWithProgress(in *idl.DeleteDataDirectoriesRequest, stream idl.Agent_DeleteDataDirectoriesWithProgressServer) error {
+	gplog.Info("got a request to delete data directories with progress from the hub")
+
+	requiredPaths, err := requiredPathsFor(in.GetVersion())
+	if err != nil {
+		return err
+	}
+
+	progress := newProgressStream(stream)
+	total := float64(len(in.Datadirs))
+
+	var mErr error
+	for i, dir := range in.Datadirs {
+		progress.fraction = float64(i) / total
+
+		err := DeleteDirectoriesFunc([]string{dir}, requiredPaths, progress)
+		if err != nil {
+			mErr = errorlist.Append(mErr, err)
+		}
+
+		progress.fraction = float64(i+1) / total
+		if err != nil {
+			progress.send(fmt.Sprintf("failed to delete %q: %v", dir, err))
+		} else {
+			progress.send(fmt.Sprintf("deleted %q", dir))
+		}
+	}
+
+	return mErr
+}
+
+// progressStream is a step.OutStreams that sends each write to the hub as a
+// ProgressEvent with the current fraction complete. Since the hub may
+// disconnect at any point, send errors are logged and otherwise ignored so
+// that the work itself is not interrupted. After the first send error, no
+// more attempts are made.
+type progressStream struct {
+	stream   idl.Agent_DeleteDataDirectoriesWithProgressServer
+	fraction float64
+	mutex    sync.Mutex
+}
+
+func newProgressStream(stream idl.Agent_DeleteDataDirectoriesWithProgressServer) *progressStream {
+	return &progressStream{stream: stream}
+}
+
+func (p *progressStream) Stdout() io.Writer {
+	return p
+}
+
+func (p *progressStream) Stderr() io.Writer {
+	return p
+}
+
+func (p *progressStream) Write(buf []byte) (int, error) {
+	p.send(strings.TrimRight(string(buf), "\n"))
+	return len(buf), nil
+}
+
+func (p *progressStream) send(message string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.stream == nil {
+		return
+	}
+
+	err := p.stream.Send(&idl.ProgressEvent{Message: message, Fraction: p.fraction})
+	if err != nil {
+		gplog.Info("halting progress stream: %v", err)
+		p.stream = nil
+	}
+}
+
 func (s *Server) DeleteTablespaceDirectories(ctx context.Context, in *idl.DeleteTablespaceRequest) (*idl.DeleteTablespaceReply, error) {
 	gplog.Info("got a request to delete tablespace directories from the hub")
 
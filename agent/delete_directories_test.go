@@ -6,9 +6,13 @@ package agent_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
 	"testing"
 
+	"github.com/blang/semver/v4"
+	"google.golang.org/grpc"
+
 	"github.com/greenplum-db/gpupgrade/agent"
 	"github.com/greenplum-db/gpupgrade/idl"
 	"github.com/greenplum-db/gpupgrade/step"
@@ -50,6 +54,38 @@ func TestDeleteDataDirectories(t *testing.T) {
 		}
 	})
 
+	t.Run("requires the paths of the requested version", func(t *testing.T) {
+		expected := upgrade.RequiredPathsFor(semver.MustParse("7.0.0"))
+		agent.DeleteDirectoriesFunc = func(directories []string, requiredPaths []string, streams step.OutStreams) error {
+			if !reflect.DeepEqual(requiredPaths, expected) {
+				t.Errorf("got required paths %q want %q", requiredPaths, expected)
+			}
+
+			return nil
+		}
+
+		server := agent.NewServer(agent.Config{})
+		req := &idl.DeleteDataDirectoriesRequest{Datadirs: []string{"/data/dbfast1/seg1"}, Version: "7.0.0"}
+		_, err := server.DeleteDataDirectories(context.Background(), req)
+		if err != nil {
+			t.Errorf("DeleteDataDirectories returned error %+v", err)
+		}
+	})
+
+	t.Run("errors on an invalid version", func(t *testing.T) {
+		agent.DeleteDirectoriesFunc = func(directories []string, requiredPaths []string, streams step.OutStreams) error {
+			t.Errorf("expected no directories to be deleted")
+			return nil
+		}
+
+		server := agent.NewServer(agent.Config{})
+		req := &idl.DeleteDataDirectoriesRequest{Datadirs: []string{"/data/dbfast1/seg1"}, Version: "seven"}
+		_, err := server.DeleteDataDirectories(context.Background(), req)
+		if err == nil {
+			t.Errorf("expected an error")
+		}
+	})
+
 	t.Run("returns error on failure", func(t *testing.T) {
 		expected := errors.New("error")
 		agent.DeleteDirectoriesFunc = func(directories []string, requiredPaths []string, streams step.OutStreams) error {
@@ -65,6 +101,106 @@ func TestDeleteDataDirectories(t *testing.T) {
 	})
 }
 
+// progressRecorder is an in-memory Agent_DeleteDataDirectoriesWithProgressServer
+// that records the events sent to it.
+type progressRecorder struct {
+	grpc.ServerStream
+	events []*idl.ProgressEvent
+	err    error
+}
+
+func (p *progressRecorder) Send(event *idl.ProgressEvent) error {
+	p.events = append(p.events, event)
+	return p.err
+}
+
+func TestDeleteDataDirectoriesWithProgress(t *testing.T) {
+	testlog.SetupLogger()
+
+	defer func() {
+		agent.DeleteDirectoriesFunc = upgrade.DeleteDirectories
+	}()
+
+	dirs := []string{"/data/dbfast_mirror1/seg1", "/data/dbfast_mirror2/seg2"}
+
+	t.Run("streams ordered progress events as directories are deleted", func(t *testing.T) {
+		agent.DeleteDirectoriesFunc = func(directories []string, requiredPaths []string, streams step.OutStreams) error {
+			if !reflect.DeepEqual(requiredPaths, upgrade.PostgresFiles) {
+				t.Errorf("got required paths %q want %q", requiredPaths, upgrade.PostgresFiles)
+			}
+
+			fmt.Fprintf(streams.Stdout(), "Deleting directory: %q\n", directories[0])
+			return nil
+		}
+
+		stream := &progressRecorder{}
+		server := agent.NewServer(agent.Config{})
+		err := server.DeleteDataDirectoriesWithProgress(&idl.DeleteDataDirectoriesRequest{Datadirs: dirs}, stream)
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+
+		expected := []*idl.ProgressEvent{
+			{Message: `Deleting directory: "/data/dbfast_mirror1/seg1"`, Fraction: 0},
+			{Message: `deleted "/data/dbfast_mirror1/seg1"`, Fraction: 0.5},
+			{Message: `Deleting directory: "/data/dbfast_mirror2/seg2"`, Fraction: 0.5},
+			{Message: `deleted "/data/dbfast_mirror2/seg2"`, Fraction: 1},
+		}
+		if !reflect.DeepEqual(stream.events, expected) {
+			t.Errorf("got events %v want %v", stream.events, expected)
+		}
+	})
+
+	t.Run("continues past failures and returns them", func(t *testing.T) {
+		expected := errors.New("permission denied")
+		agent.DeleteDirectoriesFunc = func(directories []string, requiredPaths []string, streams step.OutStreams) error {
+			if directories[0] == dirs[0] {
+				return expected
+			}
+
+			return nil
+		}
+
+		stream := &progressRecorder{}
+		server := agent.NewServer(agent.Config{})
+		err := server.DeleteDataDirectoriesWithProgress(&idl.DeleteDataDirectoriesRequest{Datadirs: dirs}, stream)
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+
+		expectedEvents := []*idl.ProgressEvent{
+			{Message: `failed to delete "/data/dbfast_mirror1/seg1": permission denied`, Fraction: 0.5},
+			{Message: `deleted "/data/dbfast_mirror2/seg2"`, Fraction: 1},
+		}
+		if !reflect.DeepEqual(stream.events, expectedEvents) {
+			t.Errorf("got events %v want %v", stream.events, expectedEvents)
+		}
+	})
+
+	t.Run("keeps deleting after the stream fails", func(t *testing.T) {
+		var deleted []string
+		agent.DeleteDirectoriesFunc = func(directories []string, requiredPaths []string, streams step.OutStreams) error {
+			deleted = append(deleted, directories...)
+			return nil
+		}
+
+		stream := &progressRecorder{err: errors.New("transport is closing")}
+		server := agent.NewServer(agent.Config{})
+		err := server.DeleteDataDirectoriesWithProgress(&idl.DeleteDataDirectoriesRequest{Datadirs: dirs}, stream)
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+
+		if !reflect.DeepEqual(deleted, dirs) {
+			t.Errorf("got deleted %q want %q", deleted, dirs)
+		}
+
+		if len(stream.events) != 1 {
+			t.Errorf("got %d events want only the first failed send", len(stream.events))
+		}
+	})
+}
+
 func TestDeleteStateDirectory(t *testing.T) {
 	testlog.SetupLogger()
 
@@ -98,6 +234,38 @@ func TestDeleteStateDirectory(t *testing.T) {
 		}
 	})
 
+	t.Run("requires the paths of the requested version", func(t *testing.T) {
+		expected := upgrade.RequiredPathsFor(semver.MustParse("7.0.0"))
+		agent.DeleteDirectoriesFunc = func(directories []string, requiredPaths []string, streams step.OutStreams) error {
+			if !reflect.DeepEqual(requiredPaths, expected) {
+				t.Errorf("got required paths %q want %q", requiredPaths, expected)
+			}
+
+			return nil
+		}
+
+		server := agent.NewServer(agent.Config{})
+		req := &idl.DeleteDataDirectoriesRequest{Datadirs: []string{"/data/dbfast1/seg1"}, Version: "7.0.0"}
+		_, err := server.DeleteDataDirectories(context.Background(), req)
+		if err != nil {
+			t.Errorf("DeleteDataDirectories returned error %+v", err)
+		}
+	})
+
+	t.Run("errors on an invalid version", func(t *testing.T) {
+		agent.DeleteDirectoriesFunc = func(directories []string, requiredPaths []string, streams step.OutStreams) error {
+			t.Errorf("expected no directories to be deleted")
+			return nil
+		}
+
+		server := agent.NewServer(agent.Config{})
+		req := &idl.DeleteDataDirectoriesRequest{Datadirs: []string{"/data/dbfast1/seg1"}, Version: "seven"}
+		_, err := server.DeleteDataDirectories(context.Background(), req)
+		if err == nil {
+			t.Errorf("expected an error")
+		}
+	})
+
 	t.Run("returns error on failure", func(t *testing.T) {
 		expected := errors.New("error")
 		agent.DeleteDirectoriesFunc = func(directories []string, requiredPaths []string, streams step.OutStreams) error {
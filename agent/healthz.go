@@ -0,0 +1,89 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/utils"
+)
+
+// ErrStateDirUnhealthy is returned by CheckStateDir when the agent cannot use
+// its state directory.
+var ErrStateDirUnhealthy = errors.New("state directory is unhealthy")
+
+// StateDirError is the backing error type for ErrStateDirUnhealthy.
+type StateDirError struct {
+	Dir string
+	Err error
+}
+
+func (s *StateDirError) Error() string {
+	return fmt.Sprintf("state directory %q is unusable: %v", s.Dir, s.Err)
+}
+
+func (s *StateDirError) Is(err error) bool {
+	return err == ErrStateDirUnhealthy
+}
+
+func (s *StateDirError) Unwrap() error {
+	return s.Err
+}
+
+// CheckStateDir ensures that dir exists and is writable by creating and
+// removing a probe file in it, so that a full disk or bad mount is found
+// before an operation fails partway through.
+func CheckStateDir(dir string) error {
+	info, err := utils.System.Stat(dir)
+	if err != nil {
+		return &StateDirError{Dir: dir, Err: err}
+	}
+
+	if !info.IsDir() {
+		return &StateDirError{Dir: dir, Err: errors.New("not a directory")}
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf(".healthz-%d", utils.System.Getpid()))
+	probe, err := utils.System.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return &StateDirError{Dir: dir, Err: err}
+	}
+
+	_, err = probe.Write([]byte("ok\n"))
+	if cErr := probe.Close(); err == nil {
+		err = cErr
+	}
+
+	if rErr := utils.System.Remove(path); err == nil {
+		err = rErr
+	}
+
+	if err != nil {
+		return &StateDirError{Dir: dir, Err: err}
+	}
+
+	return nil
+}
+
+// Healthz reports whether the agent can use its state directory. An unhealthy
+// agent is reported in the reply rather than as an error, so that the hub can
+// tell a sick host from one it cannot reach.
+func (s *Server) Healthz(ctx context.Context, in *idl.HealthzRequest) (*idl.HealthzReply, error) {
+	reply := &idl.HealthzReply{Healthy: true, StateDir: s.conf.StateDir}
+
+	if err := CheckStateDir(s.conf.StateDir); err != nil {
+		gplog.Error("health check failed: %v", err)
+		reply.Healthy = false
+		reply.Reason = err.Error()
+	}
+
+	return reply, nil
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package agent_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/greenplum-db/gpupgrade/agent"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+	"github.com/greenplum-db/gpupgrade/utils"
+)
+
+func TestHealthz(t *testing.T) {
+	testlog.SetupLogger()
+
+	// readOnly makes creating files fail as they would on a read-only mount,
+	// since tests running as root can write to a directory without write
+	// permission.
+	readOnly := func(t *testing.T) {
+		utils.System.OpenFile = func(name string, flag int, perm os.FileMode) (*os.File, error) {
+			return nil, &os.PathError{Op: "open", Path: name, Err: syscall.EROFS}
+		}
+		t.Cleanup(func() {
+			utils.System.OpenFile = os.OpenFile
+		})
+	}
+
+	t.Run("reports a writable state directory as healthy", func(t *testing.T) {
+		stateDir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, stateDir)
+
+		server := agent.NewServer(agent.Config{StateDir: stateDir})
+
+		reply, err := server.Healthz(context.Background(), &idl.HealthzRequest{})
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		expected := &idl.HealthzReply{Healthy: true, StateDir: stateDir}
+		if reply.String() != expected.String() {
+			t.Errorf("got reply %v want %v", reply, expected)
+		}
+
+		entries, err := ioutil.ReadDir(stateDir)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if len(entries) != 0 {
+			t.Errorf("expected the probe file to be removed, found %q", entries[0].Name())
+		}
+	})
+
+	t.Run("reports a read-only state directory as unhealthy", func(t *testing.T) {
+		stateDir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, stateDir)
+
+		readOnly(t)
+
+		server := agent.NewServer(agent.Config{StateDir: stateDir})
+
+		reply, err := server.Healthz(context.Background(), &idl.HealthzRequest{})
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if reply.GetHealthy() {
+			t.Error("expected agent to be unhealthy")
+		}
+
+		if reply.GetStateDir() != stateDir {
+			t.Errorf("got state directory %q want %q", reply.GetStateDir(), stateDir)
+		}
+
+		if !strings.Contains(reply.GetReason(), syscall.EROFS.Error()) {
+			t.Errorf("expected reason %q to contain %q", reply.GetReason(), syscall.EROFS.Error())
+		}
+	})
+
+	t.Run("reports a missing state directory as unhealthy", func(t *testing.T) {
+		stateDir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, stateDir)
+
+		server := agent.NewServer(agent.Config{StateDir: filepath.Join(stateDir, "missing")})
+
+		reply, err := server.Healthz(context.Background(), &idl.HealthzRequest{})
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if reply.GetHealthy() {
+			t.Error("expected agent to be unhealthy")
+		}
+	})
+
+	t.Run("CheckStateDir returns a StateDirError for a read-only state directory", func(t *testing.T) {
+		stateDir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, stateDir)
+
+		readOnly(t)
+
+		err := agent.CheckStateDir(stateDir)
+
+		var stateDirErr *agent.StateDirError
+		if !errors.As(err, &stateDirErr) {
+			t.Fatalf("got error %#v want type %T", err, stateDirErr)
+		}
+
+		if stateDirErr.Dir != stateDir {
+			t.Errorf("got directory %q want %q", stateDirErr.Dir, stateDir)
+		}
+
+		if !errors.Is(err, agent.ErrStateDirUnhealthy) {
+			t.Errorf("got error %#v want %#v", err, agent.ErrStateDirUnhealthy)
+		}
+
+		if !errors.Is(err, syscall.EROFS) {
+			t.Errorf("got error %#v want %#v", err, syscall.EROFS)
+		}
+	})
+
+	t.Run("the agent fails to start with a read-only state directory", func(t *testing.T) {
+		stateDir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, stateDir)
+
+		readOnly(t)
+
+		server := agent.NewServer(agent.Config{StateDir: stateDir})
+
+		err := server.Start()
+		if !errors.Is(err, agent.ErrStateDirUnhealthy) {
+			t.Errorf("got error %#v want %#v", err, agent.ErrStateDirUnhealthy)
+		}
+	})
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+
+	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/errorlist"
+)
+
+// idempotentRequest is implemented by the requests of mutating RPCs, which
+// carry a token identifying the operation across retries by the hub.
+type idempotentRequest interface {
+	GetIdempotencyToken() string
+}
+
+// operationStore records the replies of completed operations under the state
+// directory, keyed by method and idempotency token, so that a request retried
+// by the hub after a network timeout returns the original reply rather than
+// running a destructive operation twice. Failed operations are not recorded
+// and run again when retried. Records older than OperationRecordTTL are
+// removed by prune.
+type operationStore struct {
+	dir string
+
+	mu       sync.Mutex
+	inFlight map[string]*inFlightOperation
+}
+
+// inFlightOperation serializes the requests for one method and token. It is
+// removed from operationStore.inFlight once no request holds or awaits it.
+type inFlightOperation struct {
+	mu      sync.Mutex
+	waiters int
+}
+
+// OperationRecordTTL is how long the reply of a completed operation is kept.
+// The hub only retries an operation within a step, so it need only outlive
+// the longest step.
+var OperationRecordTTL = 7 * 24 * time.Hour
+
+// operationRecord is the file format of a recorded reply.
+type operationRecord struct {
+	Method string
+	Token  string
+	Type   string
+	Reply  []byte
+}
+
+func newOperationStore(stateDir string) *operationStore {
+	return &operationStore{
+		dir:      filepath.Join(stateDir, "operations"),
+		inFlight: make(map[string]*inFlightOperation),
+	}
+}
+
+// intercept is a grpc.UnaryServerInterceptor that returns the recorded reply
+// for a repeated idempotency token instead of calling handler. Requests
+// without a token are always handled. A retry that arrives while the original
+// is still running waits for it to finish.
+func (o *operationStore) intercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	r, ok := req.(idempotentRequest)
+	if !ok || r.GetIdempotencyToken() == "" {
+		return handler(ctx, req)
+	}
+
+	method, token := info.FullMethod, r.GetIdempotencyToken()
+	path := o.path(method, token)
+
+	unlock := o.lock(path)
+	defer unlock()
+
+	reply, err := o.load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if reply != nil {
+		gplog.Info("returning the recorded result of %s for repeated idempotency token %q", method, token)
+		return reply, nil
+	}
+
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+
+	// The operation has already succeeded, so failing to record it is only
+	// logged. A retry would then run it again as it did before tokens.
+	if msg, ok := resp.(proto.Message); ok {
+		if err := o.save(path, method, token, msg); err != nil {
+			gplog.Warn("recording result of %s for idempotency token %q: %v", method, token, err)
+		}
+	}
+
+	return resp, nil
+}
+
+// path returns the file recording the reply for the token. Tokens are hashed
+// since they are chosen by the hub and may not be valid file names.
+func (o *operationStore) path(method, token string) string {
+	sum := sha256.Sum256([]byte(method + "\x00" + token))
+	return filepath.Join(o.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// lock serializes operations with the same method and token.
+func (o *operationStore) lock(path string) func() {
+	o.mu.Lock()
+	op, ok := o.inFlight[path]
+	if !ok {
+		op = new(inFlightOperation)
+		o.inFlight[path] = op
+	}
+	op.waiters++
+	o.mu.Unlock()
+
+	op.mu.Lock()
+	return func() {
+		op.mu.Unlock()
+
+		o.mu.Lock()
+		defer o.mu.Unlock()
+
+		op.waiters--
+		if op.waiters == 0 {
+			delete(o.inFlight, path)
+		}
+	}
+}
+
+// prune removes the records last written more than OperationRecordTTL ago.
+// A missing store is not an error, as no operation has been recorded yet.
+func (o *operationStore) prune() error {
+	entries, err := ioutil.ReadDir(o.dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return xerrors.Errorf("reading recorded operations: %w", err)
+	}
+
+	cutoff := utils.System.Now().Add(-OperationRecordTTL)
+
+	var mErr error
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") || !entry.ModTime().Before(cutoff) {
+			continue
+		}
+
+		path := filepath.Join(o.dir, entry.Name())
+		if err := utils.System.Remove(path); err != nil && !os.IsNotExist(err) {
+			mErr = errorlist.Append(mErr, xerrors.Errorf("removing expired operation record: %w", err))
+		}
+	}
+
+	return mErr
+}
+
+// load returns the recorded reply at path, or nil if there is none.
+func (o *operationStore) load(path string) (proto.Message, error) {
+	data, err := utils.System.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("reading recorded operation: %w", err)
+	}
+
+	var record operationRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, xerrors.Errorf("parsing recorded operation %q: %w", path, err)
+	}
+
+	typ := proto.MessageType(record.Type)
+	if typ == nil {
+		return nil, xerrors.Errorf("recorded operation %q has unknown reply type %q", path, record.Type)
+	}
+
+	reply := reflect.New(typ.Elem()).Interface().(proto.Message)
+	if err := proto.Unmarshal(record.Reply, reply); err != nil {
+		return nil, xerrors.Errorf("parsing recorded operation %q: %w", path, err)
+	}
+
+	return reply, nil
+}
+
+func (o *operationStore) save(path, method, token string, reply proto.Message) error {
+	data, err := proto.Marshal(reply)
+	if err != nil {
+		return err
+	}
+
+	record, err := json.Marshal(operationRecord{
+		Method: method,
+		Token:  token,
+		Type:   proto.MessageName(reply),
+		Reply:  data,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := utils.System.MkdirAll(o.dir, 0700); err != nil {
+		return err
+	}
+
+	return utils.AtomicWriteFile(path, record, 0600)
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+)
+
+func TestOperationStore(t *testing.T) {
+	testlog.SetupLogger()
+
+	t.Run("forgets operations once they finish", func(t *testing.T) {
+		stateDir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, stateDir)
+
+		store := newOperationStore(stateDir)
+		info := &grpc.UnaryServerInfo{FullMethod: "/idl.Agent/DeleteDataDirectories"}
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return &idl.DeleteDataDirectoriesReply{}, nil
+		}
+
+		for _, token := range []string{"delete-1", "delete-2", "delete-1"} {
+			req := &idl.DeleteDataDirectoriesRequest{IdempotencyToken: token}
+			if _, err := store.intercept(context.Background(), req, info, handler); err != nil {
+				t.Fatalf("unexpected error: %#v", err)
+			}
+		}
+
+		if len(store.inFlight) != 0 {
+			t.Errorf("got %d in-flight operations want 0", len(store.inFlight))
+		}
+	})
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package agent_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/greenplum-db/gpupgrade/agent"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/step"
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+	"github.com/greenplum-db/gpupgrade/upgrade"
+	"github.com/greenplum-db/gpupgrade/utils"
+)
+
+func TestIdempotencyToken(t *testing.T) {
+	testlog.SetupLogger()
+
+	// Each revert reports a different host, so that a recorded reply can be
+	// told apart from a new one.
+	var restores int
+	var restoreErr error
+	agent.RestoreSource = func(source string, target string) error {
+		restores++
+		return restoreErr
+	}
+	agent.DeleteDirectoriesFunc = func(directories []string, requiredPaths []string, streams step.OutStreams) error {
+		return nil
+	}
+	utils.System.Hostname = func() (string, error) {
+		return fmt.Sprintf("sdw%d", restores+1), nil
+	}
+	defer func() {
+		agent.RestoreSource = upgrade.RestoreSource
+		agent.DeleteDirectoriesFunc = upgrade.DeleteDirectories
+		utils.System.Hostname = os.Hostname
+	}()
+
+	stateDir := testutils.GetTempDir(t, "")
+	defer testutils.MustRemoveAll(t, stateDir)
+
+	// start runs an agent using stateDir, returning a client connected to it.
+	start := func(t *testing.T) (idl.AgentClient, func()) {
+		t.Helper()
+
+		port := testutils.MustGetPort(t)
+		server := agent.NewServer(agent.Config{Port: port, StateDir: stateDir})
+		go server.Start()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		conn, err := grpc.DialContext(ctx, "localhost:"+strconv.Itoa(port), grpc.WithInsecure(), grpc.WithBlock())
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		return idl.NewAgentClient(conn), func() {
+			conn.Close()
+			server.Stop()
+		}
+	}
+
+	client, stop := start(t)
+	defer func() { stop() }()
+
+	revert := func(t *testing.T, token string) (*idl.RevertDataDirectoriesReply, error) {
+		t.Helper()
+
+		return client.RevertDataDirectories(context.Background(), &idl.RevertDataDirectoriesRequest{
+			Dirs:             []*idl.RevertDataDirectory{{Source: "/does/not/exist/source", Target: "/does/not/exist/target"}},
+			IdempotencyToken: token,
+		})
+	}
+
+	mustRevert := func(t *testing.T, token string, expectedHost string, expectedRestores int) {
+		t.Helper()
+
+		reply, err := revert(t, token)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if reply.GetHost() != expectedHost {
+			t.Errorf("got host %q want %q", reply.GetHost(), expectedHost)
+		}
+
+		if len(reply.GetResults()) != 1 || reply.GetResults()[0].GetSource() != "/does/not/exist/source" {
+			t.Errorf("got results %v want a result for the source", reply.GetResults())
+		}
+
+		if restores != expectedRestores {
+			t.Errorf("got %d restores want %d", restores, expectedRestores)
+		}
+	}
+
+	t.Run("a repeated token returns the original reply without running again", func(t *testing.T) {
+		restores = 0
+
+		mustRevert(t, "revert-1", "sdw1", 1)
+		mustRevert(t, "revert-1", "sdw1", 1)
+	})
+
+	t.Run("a new token runs the operation", func(t *testing.T) {
+		restores = 0
+
+		mustRevert(t, "revert-2", "sdw1", 1)
+		mustRevert(t, "revert-3", "sdw2", 2)
+	})
+
+	t.Run("requests without a token always run", func(t *testing.T) {
+		restores = 0
+
+		mustRevert(t, "", "sdw1", 1)
+		mustRevert(t, "", "sdw2", 2)
+	})
+
+	t.Run("failed operations are run again when retried", func(t *testing.T) {
+		restores = 0
+
+		restoreErr = errors.New("permission denied")
+		_, err := revert(t, "revert-4")
+		restoreErr = nil
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+
+		mustRevert(t, "revert-4", "sdw2", 2)
+	})
+
+	t.Run("recorded replies survive an agent restart", func(t *testing.T) {
+		restores = 0
+		mustRevert(t, "revert-5", "sdw1", 1)
+
+		stop()
+		client, stop = start(t)
+
+		mustRevert(t, "revert-5", "sdw1", 1)
+	})
+	t.Run("expired records are removed when the agent starts", func(t *testing.T) {
+		restores = 0
+		mustRevert(t, "revert-6", "sdw1", 1)
+
+		stop()
+
+		// Age every record past the TTL.
+		expired := time.Now().Add(-agent.OperationRecordTTL - time.Hour)
+		records, err := filepath.Glob(filepath.Join(stateDir, "operations", "*.json"))
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		for _, record := range records {
+			if err := os.Chtimes(record, expired, expired); err != nil {
+				t.Fatalf("unexpected error: %#v", err)
+			}
+		}
+
+		client, stop = start(t)
+
+		mustRevert(t, "revert-6", "sdw2", 2)
+	})
+}
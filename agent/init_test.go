@@ -7,6 +7,7 @@ import (
 	"os"
 
 	"github.com/greenplum-db/gpupgrade/testutils/exectest"
+	"github.com/greenplum-db/gpupgrade/utils/command"
 )
 
 // TODO: migrate to a shared exectest implementation of the simple
@@ -24,11 +25,24 @@ func FailedRsync() {
 	os.Exit(2)
 }
 
+// Prints to both streams, like pg_upgrade does when it runs.
+func PgUpgradeOutputMain() {
+	os.Stdout.WriteString("Performing Upgrade\n")
+	os.Stderr.WriteString("some warning\n")
+}
+
+func FailedPgUpgradeOutputMain() {
+	os.Stdout.WriteString("Performing Consistency Checks\n")
+	os.Exit(1)
+}
+
 func init() {
 	exectest.RegisterMains(
 		Success,
 		FailedMain,
 		FailedRsync,
+		PgUpgradeOutputMain,
+		FailedPgUpgradeOutputMain,
 	)
 }
 
@@ -38,3 +52,7 @@ func init() {
 func SetExecCommand(command exectest.Command) {
 	execCommand = command
 }
+
+func SetCommandRunner(runner command.Runner) {
+	commandRunner = runner
+}
@@ -13,17 +13,20 @@ import (
 	"github.com/greenplum-db/gpupgrade/utils/errorlist"
 )
 
-var ArchiveSource = upgrade.ArchiveSource
+var ArchiveSource = upgrade.ArchiveSourceWithRateLimit
 
 func (s *Server) RenameDirectories(ctx context.Context, in *idl.RenameDirectoriesRequest) (*idl.RenameDirectoriesReply, error) {
 	gplog.Info("agent received request to rename segment data directories")
 
 	var mErr error
 	for _, dir := range in.GetDirs() {
-		err := ArchiveSource(dir.GetSource(), dir.GetTarget(), dir.GetRenameTarget())
+		status, err := ArchiveSource(dir.GetSource(), dir.GetTarget(), dir.GetRenameTarget(), in.GetCopyRateLimit())
 		if err != nil {
 			mErr = errorlist.Append(mErr, err)
+			continue
 		}
+
+		gplog.Debug("archiving %q: %s", dir.GetSource(), status)
 	}
 
 	return &idl.RenameDirectoriesReply{}, mErr
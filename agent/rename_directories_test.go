@@ -11,6 +11,7 @@ import (
 	"github.com/greenplum-db/gpupgrade/agent"
 	"github.com/greenplum-db/gpupgrade/idl"
 	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+	"github.com/greenplum-db/gpupgrade/upgrade"
 )
 
 func TestRenameDirectories(t *testing.T) {
@@ -19,8 +20,8 @@ func TestRenameDirectories(t *testing.T) {
 
 	t.Run("bubbles up errors", func(t *testing.T) {
 		expected := errors.New("permission denied")
-		agent.ArchiveSource = func(source, target string, renameTarget bool) error {
-			return expected
+		agent.ArchiveSource = func(source, target string, renameTarget bool, bytesPerSecond int64) (upgrade.ArchiveStatus, error) {
+			return upgrade.ArchiveUnknown, expected
 		}
 
 		_, err := server.RenameDirectories(context.Background(), &idl.RenameDirectoriesRequest{Dirs: []*idl.RenameDirectories{{}}})
@@ -0,0 +1,59 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/step"
+	"github.com/greenplum-db/gpupgrade/upgrade"
+	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/errorlist"
+)
+
+var RestoreSource = upgrade.RestoreSource
+
+// RevertDataDirectories restores each archived source data directory and deletes the failed
+// target. When the archive does not exist and the source is already in place
+// there is nothing to restore, which is reported in the result rather than as
+// an error. The target is still deleted in that case so that retrying a revert
+// that failed to delete it succeeds.
+func (s *Server) RevertDataDirectories(ctx context.Context, in *idl.RevertDataDirectoriesRequest) (*idl.RevertDataDirectoriesReply, error) {
+	gplog.Info("agent received request to revert segment data directories")
+
+	host, err := utils.System.Hostname()
+	if err != nil {
+		return &idl.RevertDataDirectoriesReply{}, xerrors.Errorf("getting hostname: %w", err)
+	}
+
+	reply := &idl.RevertDataDirectoriesReply{Host: host}
+
+	var mErr error
+	for _, dir := range in.GetDirs() {
+		source, target := dir.GetSource(), dir.GetTarget()
+		result := &idl.RevertDataDirectoryResult{Source: source}
+
+		archive := upgrade.ArchivePathFor(target)
+		if !upgrade.PathExists(archive) && upgrade.PathExists(source) {
+			gplog.Info("nothing to revert on host %s: archive %q does not exist and source %q is in place", host, archive, source)
+			result.NothingToRevert = true
+		} else if err := RestoreSource(source, target); err != nil {
+			mErr = errorlist.Append(mErr, errorlist.WithHost(host, xerrors.Errorf("restoring %q: %w", source, err)))
+			continue
+		}
+
+		if err := DeleteDirectoriesFunc([]string{target}, upgrade.PostgresFiles, step.DevNullStream); err != nil {
+			mErr = errorlist.Append(mErr, xerrors.Errorf("deleting target %q: %w", target, err))
+			continue
+		}
+
+		reply.Results = append(reply.Results, result)
+	}
+
+	return reply, mErr
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package agent_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/greenplum-db/gpupgrade/agent"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+	"github.com/greenplum-db/gpupgrade/upgrade"
+	"github.com/greenplum-db/gpupgrade/utils"
+)
+
+func TestRevertDataDirectories(t *testing.T) {
+	testlog.SetupLogger()
+	server := agent.NewServer(agent.Config{})
+
+	utils.System = utils.InitializeSystemFunctions()
+	agent.DeleteDirectoriesFunc = upgrade.DeleteDirectories
+
+	host, err := utils.System.Hostname()
+	if err != nil {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+
+	// archive moves the source to its archive and the target to the source,
+	// as ArchiveSource does, marking the original source so that it can be
+	// recognized once restored.
+	archive := func(t *testing.T, source, target string) {
+		t.Helper()
+
+		testutils.MustWriteToFile(t, filepath.Join(source, "original"), "")
+
+		if err := os.Rename(source, upgrade.ArchivePathFor(target)); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if err := os.Rename(target, source); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	}
+
+	verifyReverted := func(t *testing.T, source, target string) {
+		t.Helper()
+
+		if !upgrade.PathExists(filepath.Join(source, "original")) {
+			t.Errorf("expected original source to be restored to %q", source)
+		}
+
+		for _, path := range []string{target, upgrade.ArchivePathFor(target)} {
+			if upgrade.PathExists(path) {
+				t.Errorf("expected %q to not exist", path)
+			}
+		}
+	}
+
+	t.Run("restores the archived source and deletes the target", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+		archive(t, source, target)
+
+		request := &idl.RevertDataDirectoriesRequest{Dirs: []*idl.RevertDataDirectory{{Source: source, Target: target}}}
+		reply, err := server.RevertDataDirectories(context.Background(), request)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		expected := &idl.RevertDataDirectoriesReply{
+			Host:    host,
+			Results: []*idl.RevertDataDirectoryResult{{Source: source}},
+		}
+		if !reflect.DeepEqual(reply, expected) {
+			t.Errorf("got reply %v want %v", reply, expected)
+		}
+
+		verifyReverted(t, source, target)
+	})
+
+	t.Run("reports nothing to revert when already reverted", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+		archive(t, source, target)
+
+		request := &idl.RevertDataDirectoriesRequest{Dirs: []*idl.RevertDataDirectory{{Source: source, Target: target}}}
+		if _, err := server.RevertDataDirectories(context.Background(), request); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		reply, err := server.RevertDataDirectories(context.Background(), request)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		expected := &idl.RevertDataDirectoriesReply{
+			Host:    host,
+			Results: []*idl.RevertDataDirectoryResult{{Source: source, NothingToRevert: true}},
+		}
+		if !reflect.DeepEqual(reply, expected) {
+			t.Errorf("got reply %v want %v", reply, expected)
+		}
+
+		verifyReverted(t, source, target)
+	})
+
+	t.Run("deletes a target left behind by a previous revert", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+		testutils.MustWriteToFile(t, filepath.Join(source, "original"), "")
+
+		request := &idl.RevertDataDirectoriesRequest{Dirs: []*idl.RevertDataDirectory{{Source: source, Target: target}}}
+		reply, err := server.RevertDataDirectories(context.Background(), request)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if len(reply.Results) != 1 || !reply.Results[0].NothingToRevert {
+			t.Errorf("got results %v want nothing to revert", reply.Results)
+		}
+
+		verifyReverted(t, source, target)
+	})
+
+	t.Run("returns restore errors naming the host", func(t *testing.T) {
+		expected := errors.New("permission denied")
+		agent.RestoreSource = func(source, target string) error {
+			return expected
+		}
+		defer func() {
+			agent.RestoreSource = upgrade.RestoreSource
+		}()
+
+		request := &idl.RevertDataDirectoriesRequest{Dirs: []*idl.RevertDataDirectory{{Source: "/does/not/exist", Target: "/does/not/exist.target"}}}
+		_, err := server.RevertDataDirectories(context.Background(), request)
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+
+		if !strings.Contains(err.Error(), host) {
+			t.Errorf("expected error %q to contain host %q", err.Error(), host)
+		}
+	})
+}
@@ -84,3 +84,20 @@ func rsyncRequestDirs(in *idl.RsyncRequest) error {
 
 	return err
 }
+
+// RsyncDirectory copies the contents of a directory on this host to a
+// directory on the destination host, so the hub can distribute a directory
+// such as the upgraded master data directory from the host it is on.
+func (s *Server) RsyncDirectory(ctx context.Context, in *idl.RsyncDirectoryRequest) (*idl.RsyncDirectoryReply, error) {
+	gplog.Info("agent received request to rsync %q to %s:%q", in.GetSource(), in.GetDestinationHost(), in.GetDestination())
+
+	opts := upgrade.RsyncOptions{
+		DestinationHost: in.GetDestinationHost(),
+		Delete:          in.GetDelete(),
+		Checksum:        in.GetChecksum(),
+		Compress:        in.GetCompress(),
+		Excludes:        in.GetExcludes(),
+	}
+
+	return &idl.RsyncDirectoryReply{}, upgrade.RsyncDir(in.GetSource(), in.GetDestination(), opts)
+}
@@ -266,3 +266,48 @@ func TestRsyncTablespaceDirectories(t *testing.T) {
 		}
 	})
 }
+
+func TestRsyncDirectory(t *testing.T) {
+	testlog.SetupLogger()
+	server := agent.NewServer(agent.Config{})
+
+	t.Run("rsyncs the directory to the destination host", func(t *testing.T) {
+		rsync.SetRsyncCommand(exectest.NewCommandWithVerifier(agent.Success, func(utility string, args ...string) {
+			expected := []string{
+				"--archive", "--hard-links", "--delete", "--compress",
+				"/data/qddir/demoDataDir-1/", "sdw1:/data/qddir/demoDataDir-1",
+				"--exclude", "postmaster.pid",
+			}
+			if !reflect.DeepEqual(args, expected) {
+				t.Errorf("got args %q want %q", args, expected)
+			}
+		}))
+		defer rsync.ResetRsyncCommand()
+
+		request := &idl.RsyncDirectoryRequest{
+			Source:          "/data/qddir/demoDataDir-1",
+			DestinationHost: "sdw1",
+			Destination:     "/data/qddir/demoDataDir-1",
+			Delete:          true,
+			Compress:        true,
+			Excludes:        []string{"postmaster.pid"},
+		}
+
+		_, err := server.RsyncDirectory(context.Background(), request)
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+	})
+
+	t.Run("returns a typed rsync error", func(t *testing.T) {
+		rsync.SetRsyncCommand(exectest.NewCommand(agent.FailedRsync))
+		defer rsync.ResetRsyncCommand()
+
+		request := &idl.RsyncDirectoryRequest{Source: "/source", Destination: "/target"}
+
+		_, err := server.RsyncDirectory(context.Background(), request)
+		if !errors.Is(err, upgrade.ErrRsyncProtocol) {
+			t.Errorf("got error %#v want %#v", err, upgrade.ErrRsyncProtocol)
+		}
+	})
+}
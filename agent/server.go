@@ -5,14 +5,19 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"os"
 	"strconv"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"golang.org/x/xerrors"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/reflection"
 
 	"github.com/greenplum-db/gpupgrade/idl"
@@ -20,25 +25,64 @@ import (
 	"github.com/greenplum-db/gpupgrade/utils/log"
 )
 
+// DefaultGracefulStopTimeout is how long GracefulStop waits for in-flight
+// requests before forcing the agent to stop.
+const DefaultGracefulStopTimeout = 30 * time.Second
+
 type Server struct {
 	conf Config
 
-	mu      sync.Mutex
-	server  *grpc.Server
-	lis     net.Listener
+	mu     sync.Mutex
+	server *grpc.Server
+	lis    net.Listener
+	daemon bool
+
+	// stopped is closed once Start returns, so that any number of Stop and
+	// GracefulStop calls can wait for it.
 	stopped chan struct{}
-	daemon  bool
+
+	// stopping is closed once the agent begins a graceful stop, so that
+	// long-lived streams such as TailLog end rather than holding it up.
+	stopping     chan struct{}
+	stoppingOnce sync.Once
+
+	operations *operationStore
 }
 
 type Config struct {
 	Port     int
 	StateDir string
+
+	// Version identifies the gpupgrade build the agent is running, and is
+	// reported to the hub so that mismatched binaries can be detected.
+	Version string
+
+	// Credentials secure the agent's listener with TLS. When nil the agent
+	// serves plaintext.
+	Credentials credentials.TransportCredentials
+
+	// MaxConcurrent bounds the number of requests the agent handles at once,
+	// so that a misbehaving hub cannot overload the segment host. Requests
+	// beyond it are rejected with codes.ResourceExhausted. Zero is unlimited.
+	MaxConcurrent int
+}
+
+// ValidatePort returns an error if port is not a valid port for the agent to
+// listen on.
+func ValidatePort(port int) error {
+	if port < 1 || port > 65535 {
+		return xerrors.Errorf("invalid agent port %d: must be between 1 and 65535", port)
+	}
+
+	return nil
 }
 
 func NewServer(conf Config) *Server {
 	return &Server{
-		conf:    conf,
-		stopped: make(chan struct{}, 1),
+		conf:       conf,
+		stopped:    make(chan struct{}),
+		stopping:   make(chan struct{}),
+		operations: newOperationStore(conf.StateDir),
 	}
 }
 
@@ -48,20 +92,105 @@ func (s *Server) MakeDaemon() {
 	s.daemon = true
 }
 
-func (s *Server) Start() {
-	createIfNotExists(s.conf.StateDir)
+// ErrAgentAlreadyRunning is returned by Start when another agent owns the PID
+// file or the port.
+var ErrAgentAlreadyRunning = errors.New("agent already running")
+
+// AgentAlreadyRunningError is the backing error type for
+// ErrAgentAlreadyRunning. PID is zero when the process holding the port is
+// not known from the PID file.
+type AgentAlreadyRunningError struct {
+	Port int
+	PID  int
+}
+
+func (a *AgentAlreadyRunningError) Error() string {
+	if a.PID == 0 {
+		return fmt.Sprintf("port %d is already in use, possibly by another agent. "+
+			"Stop the other process or start the agent with a different --port.", a.Port)
+	}
+
+	return fmt.Sprintf("agent is already running on port %d (pid %d)", a.Port, a.PID)
+}
+
+func (a *AgentAlreadyRunningError) Is(err error) bool {
+	return err == ErrAgentAlreadyRunning
+}
+
+// Start serves agent requests, blocking until the agent is stopped. If another
+// agent is already running it returns an AgentAlreadyRunningError. The state
+// directory must already exist, such as by utils.EnsureStateDirAt.
+func (s *Server) Start() error {
+	// Fail now rather than partway through the first operation.
+	if err := CheckStateDir(s.conf.StateDir); err != nil {
+		return err
+	}
+
+	// Expired records only take up space, so failing to remove them should
+	// not keep the agent from starting.
+	if err := s.operations.prune(); err != nil {
+		gplog.Warn("pruning recorded operations: %v", err)
+	}
+
+	if s.daemon {
+		err := daemon.WritePIDFile(s.conf.StateDir)
+
+		var running *daemon.AlreadyRunningError
+		if errors.As(err, &running) {
+			return &AgentAlreadyRunningError{Port: s.conf.Port, PID: running.PID}
+		}
+
+		if err != nil {
+			return xerrors.Errorf("write PID file: %w", err)
+		}
+	}
+
 	lis, err := net.Listen("tcp", ":"+strconv.Itoa(s.conf.Port))
 	if err != nil {
-		gplog.Fatal(err, "failed to listen")
+		if s.daemon {
+			if err := daemon.RemovePIDFile(s.conf.StateDir); err != nil {
+				gplog.Error("failed to remove PID file: %v", err)
+			}
+		}
+
+		if errors.Is(err, syscall.EADDRINUSE) {
+			return &AgentAlreadyRunningError{Port: s.conf.Port, PID: daemon.RunningPID(s.conf.StateDir)}
+		}
+
+		return xerrors.Errorf("listen on port %d: %w", s.conf.Port, err)
 	}
 
-	// Set up an interceptor function to log any panics we get from request
-	// handlers.
+	// Set up interceptor functions to log any panics we get from request
+	// handlers, to limit the number of concurrent requests, and to return the
+	// recorded result of operations retried by the hub.
+	limiter := newConcurrencyLimiter(s.conf.MaxConcurrent)
 	interceptor := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
 		defer log.WritePanics()
-		return handler(ctx, req)
+
+		release, err := limiter.acquire(info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+
+		return s.operations.intercept(ctx, req, info, handler)
+	}
+	streamInterceptor := func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		defer log.WritePanics()
+
+		release, err := limiter.acquire(info.FullMethod)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		return handler(srv, stream)
 	}
-	server := grpc.NewServer(grpc.UnaryInterceptor(interceptor))
+	opts := []grpc.ServerOption{grpc.UnaryInterceptor(interceptor), grpc.StreamInterceptor(streamInterceptor)}
+	if s.conf.Credentials != nil {
+		opts = append(opts, grpc.Creds(s.conf.Credentials))
+	}
+	server := grpc.NewServer(opts...)
 
 	s.mu.Lock()
 	s.server = server
@@ -83,10 +212,18 @@ func (s *Server) Start() {
 
 	err = server.Serve(lis)
 	if err != nil {
-		gplog.Fatal(err, "failed to serve: %s", err)
+		err = xerrors.Errorf("serve: %w", err)
 	}
 
-	s.stopped <- struct{}{}
+	if s.daemon {
+		if err := daemon.RemovePIDFile(s.conf.StateDir); err != nil {
+			gplog.Error("failed to remove PID file: %v", err)
+		}
+	}
+
+	close(s.stopped)
+
+	return err
 }
 
 func (s *Server) StopAgent(ctx context.Context, in *idl.StopAgentRequest) (*idl.StopAgentReply, error) {
@@ -95,21 +232,50 @@ func (s *Server) StopAgent(ctx context.Context, in *idl.StopAgentRequest) (*idl.
 }
 
 func (s *Server) Stop() {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if s.server != nil {
-		s.server.Stop()
-		<-s.stopped
+	server := s.grpcServer()
+	if server == nil {
+		return
 	}
+
+	server.Stop()
+	<-s.stopped
 }
 
-func createIfNotExists(dir string) {
-	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+// GracefulStop stops accepting new requests and waits for in-flight requests
+// to finish, so the agent is not stopped partway through deleting or renaming
+// directories. If they do not finish within the timeout the agent is stopped
+// immediately as with Stop.
+func (s *Server) GracefulStop(timeout time.Duration) {
+	server := s.grpcServer()
+	if server == nil {
 		return
 	}
 
-	if err := os.Mkdir(dir, 0777); err != nil {
-		gplog.Fatal(err, "failed to create state directory %q", dir)
+	s.stoppingOnce.Do(func() { close(s.stopping) })
+
+	drained := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-time.After(timeout):
+		gplog.Warn("in-flight requests did not finish within %s, stopping agent", timeout)
+		server.Stop()
+		<-drained
 	}
+
+	<-s.stopped
+}
+
+// grpcServer returns the server that Start is serving, or nil if it has not
+// started. The lock is not held while stopping, so that concurrent and
+// repeated calls to Stop and GracefulStop do not wait on each other.
+func (s *Server) grpcServer() *grpc.Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.server
 }
@@ -4,22 +4,37 @@
 package agent_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
 	"os"
 	"path"
+	"path/filepath"
+	"reflect"
+	"strconv"
 	"testing"
 	"time"
 
-	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/greenplum-db/gpupgrade/agent"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/step"
 	"github.com/greenplum-db/gpupgrade/testutils"
 	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+	"github.com/greenplum-db/gpupgrade/upgrade"
+	"github.com/greenplum-db/gpupgrade/utils/certs"
+	"github.com/greenplum-db/gpupgrade/utils/daemon"
 )
 
 func TestServerStart(t *testing.T) {
 	testlog.SetupLogger()
 
-	t.Run("successfully starts and creates state directory if it does not exist", func(t *testing.T) {
+	t.Run("errors without creating the state directory if it does not exist", func(t *testing.T) {
 		tempDir := testutils.GetTempDir(t, "")
 		defer os.RemoveAll(tempDir)
 		stateDir := path.Join(tempDir, ".gpupgrade")
@@ -29,19 +44,17 @@ func TestServerStart(t *testing.T) {
 			StateDir: stateDir,
 		})
 
-		if pathExists(stateDir) {
-			t.Fatal("expected stateDir to not exist")
+		err := server.Start()
+		if !errors.Is(err, agent.ErrStateDirUnhealthy) {
+			t.Errorf("got error %#v want %#v", err, agent.ErrStateDirUnhealthy)
 		}
 
-		go server.Start()
-		defer server.Stop()
-
-		exists, err := doesPathEventuallyExist(stateDir)
-		if err != nil {
-			t.Fatalf("unexpected error: %#v", err)
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("got error %#v want %#v", err, os.ErrNotExist)
 		}
-		if !exists {
-			t.Error("expected stateDir to be created")
+
+		if pathExists(stateDir) {
+			t.Error("expected stateDir to not be created")
 		}
 	})
 
@@ -67,25 +80,438 @@ func TestServerStart(t *testing.T) {
 	})
 }
 
-func doesPathEventuallyExist(path string) (bool, error) {
-	startTime := time.Now()
-	timeout := 3 * time.Second
+func TestServerAlreadyRunning(t *testing.T) {
+	testlog.SetupLogger()
+
+	t.Run("errors when the port is already in use", func(t *testing.T) {
+		stateDir := testutils.GetTempDir(t, "")
+		defer os.RemoveAll(stateDir)
+
+		lis, err := net.Listen("tcp", ":0")
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		defer lis.Close()
+
+		port := lis.Addr().(*net.TCPAddr).Port
+		server := agent.NewServer(agent.Config{
+			Port:     port,
+			StateDir: stateDir,
+		})
+
+		err = server.Start()
+
+		var runningErr *agent.AgentAlreadyRunningError
+		if !errors.As(err, &runningErr) {
+			t.Fatalf("got error %#v want type %T", err, runningErr)
+		}
+
+		if !errors.Is(err, agent.ErrAgentAlreadyRunning) {
+			t.Errorf("got error %#v want %#v", err, agent.ErrAgentAlreadyRunning)
+		}
+
+		if runningErr.Port != port {
+			t.Errorf("got port %d want %d", runningErr.Port, port)
+		}
+	})
+
+	t.Run("reports the PID of the running agent from the PID file", func(t *testing.T) {
+		stateDir := testutils.GetTempDir(t, "")
+		defer os.RemoveAll(stateDir)
+
+		err := ioutil.WriteFile(filepath.Join(stateDir, daemon.PIDFileName), []byte(strconv.Itoa(os.Getppid())), 0600)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		port := testutils.MustGetPort(t)
+		server := agent.NewServer(agent.Config{
+			Port:     port,
+			StateDir: stateDir,
+		})
+		server.MakeDaemon()
+
+		err = server.Start()
+
+		expected := &agent.AgentAlreadyRunningError{Port: port, PID: os.Getppid()}
+		if !reflect.DeepEqual(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+	})
+}
+
+func TestServerListensOnConfiguredPort(t *testing.T) {
+	testlog.SetupLogger()
+
+	stateDir := testutils.GetTempDir(t, "")
+	defer os.RemoveAll(stateDir)
+
+	port := testutils.MustGetPort(t)
+	if port == upgrade.DefaultAgentPort {
+		t.Fatalf("expected a non-default port")
+	}
+
+	server := agent.NewServer(agent.Config{
+		Port:     port,
+		StateDir: stateDir,
+	})
+
+	go server.Start()
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, "localhost:"+strconv.Itoa(port), grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatalf("expected agent to listen on port %d: %#v", port, err)
+	}
+	defer conn.Close()
+}
+
+func TestServerTLS(t *testing.T) {
+	testlog.SetupLogger()
+
+	// start runs a server requiring mutual TLS and returns its address and
+	// certificates.
+	start := func(t *testing.T) (string, certs.Config, func()) {
+		t.Helper()
+
+		stateDir := testutils.GetTempDir(t, "")
+		conf := testutils.MustWriteCerts(t, stateDir)
+		conf.VerifyClient = true
 
-	for {
-		exists := pathExists(path)
-		if exists {
-			return true, nil
+		creds, err := certs.ServerCredentials(conf)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
 		}
 
-		if time.Since(startTime) > timeout {
-			return false, xerrors.Errorf("timeout exceeded")
+		port := testutils.MustGetPort(t)
+		server := agent.NewServer(agent.Config{
+			Port:        port,
+			StateDir:    stateDir,
+			Credentials: creds,
+		})
+		go server.Start()
+
+		return "localhost:" + strconv.Itoa(port), conf, func() {
+			server.Stop()
+			os.RemoveAll(stateDir)
+		}
+	}
+
+	// version dials address and makes a request, returning any error.
+	version := func(address string, opt grpc.DialOption) error {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		conn, err := grpc.DialContext(ctx, address, opt)
+		if err != nil {
+			return err
 		}
+		defer conn.Close()
 
-		time.Sleep(10 * time.Millisecond)
+		_, err = idl.NewAgentClient(conn).Version(ctx, &idl.VersionRequest{}, grpc.WaitForReady(true))
+		return err
 	}
+
+	t.Run("accepts a client presenting a certificate signed by the CA", func(t *testing.T) {
+		address, conf, cleanup := start(t)
+		defer cleanup()
+
+		opt, err := certs.DialOption(&conf)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if err := version(address, opt); err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+	})
+
+	t.Run("rejects a plaintext client when mutual TLS is enabled", func(t *testing.T) {
+		address, _, cleanup := start(t)
+		defer cleanup()
+
+		if err := version(address, grpc.WithInsecure()); err == nil {
+			t.Errorf("expected plaintext client to be rejected")
+		}
+	})
+
+	t.Run("rejects a TLS client without a certificate when mutual TLS is enabled", func(t *testing.T) {
+		address, conf, cleanup := start(t)
+		defer cleanup()
+
+		conf.CertFile = ""
+		conf.KeyFile = ""
+		opt, err := certs.DialOption(&conf)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if err := version(address, opt); err == nil {
+			t.Errorf("expected client without a certificate to be rejected")
+		}
+	})
+}
+
+func TestValidatePort(t *testing.T) {
+	cases := []struct {
+		port  int
+		valid bool
+	}{
+		{port: 1, valid: true},
+		{port: upgrade.DefaultAgentPort, valid: true},
+		{port: 65535, valid: true},
+		{port: 0, valid: false},
+		{port: -1, valid: false},
+		{port: 65536, valid: false},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("port %d", c.port), func(t *testing.T) {
+			err := agent.ValidatePort(c.port)
+			if c.valid && err != nil {
+				t.Errorf("unexpected error: %#v", err)
+			}
+
+			if !c.valid && err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestServerGracefulStop(t *testing.T) {
+	testlog.SetupLogger()
+
+	// start runs a server whose DeleteDataDirectories requests block until
+	// release is closed, and returns a client connected to it.
+	start := func(t *testing.T, started chan<- struct{}, release <-chan struct{}) (*agent.Server, idl.AgentClient, func()) {
+		t.Helper()
+
+		agent.DeleteDirectoriesFunc = func(directories []string, requiredPaths []string, streams step.OutStreams) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		}
+
+		stateDir := testutils.GetTempDir(t, "")
+		port := testutils.MustGetPort(t)
+		server := agent.NewServer(agent.Config{
+			Port:     port,
+			StateDir: stateDir,
+		})
+		go server.Start()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		conn, err := grpc.DialContext(ctx, "localhost:"+strconv.Itoa(port), grpc.WithInsecure(), grpc.WithBlock())
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		return server, idl.NewAgentClient(conn), func() {
+			conn.Close()
+			os.RemoveAll(stateDir)
+			agent.DeleteDirectoriesFunc = upgrade.DeleteDirectories
+		}
+	}
+
+	t.Run("waits for in-flight requests to finish", func(t *testing.T) {
+		started := make(chan struct{}, 1)
+		release := make(chan struct{})
+
+		server, client, cleanup := start(t, started, release)
+		defer cleanup()
+
+		errs := make(chan error, 1)
+		go func() {
+			_, err := client.DeleteDataDirectories(context.Background(), &idl.DeleteDataDirectoriesRequest{})
+			errs <- err
+		}()
+		<-started
+
+		stopped := make(chan struct{})
+		go func() {
+			server.GracefulStop(5 * time.Second)
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+			t.Fatal("expected GracefulStop to wait for the in-flight request")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		close(release)
+
+		if err := <-errs; err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+
+		<-stopped
+	})
+
+	t.Run("stops immediately when the timeout is exceeded", func(t *testing.T) {
+		started := make(chan struct{}, 1)
+		release := make(chan struct{})
+		defer close(release)
+
+		server, client, cleanup := start(t, started, release)
+		defer cleanup()
+
+		errs := make(chan error, 1)
+		go func() {
+			_, err := client.DeleteDataDirectories(context.Background(), &idl.DeleteDataDirectoriesRequest{})
+			errs <- err
+		}()
+		<-started
+
+		stopped := make(chan struct{})
+		go func() {
+			server.GracefulStop(10 * time.Millisecond)
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-time.After(3 * time.Second):
+			t.Fatal("expected GracefulStop to return once the timeout was exceeded")
+		}
+
+		if status.Code(<-errs) != codes.Unavailable {
+			t.Errorf("expected the in-flight request to be aborted")
+		}
+	})
+
+	// returnsWithin fails the test if stop does not return in time.
+	returnsWithin := func(t *testing.T, stop func()) {
+		t.Helper()
+
+		stopped := make(chan struct{})
+		go func() {
+			stop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-time.After(3 * time.Second):
+			t.Fatal("expected the agent to stop")
+		}
+	}
+
+	t.Run("returns when the agent was already stopped", func(t *testing.T) {
+		server, _, cleanup := start(t, make(chan struct{}, 1), make(chan struct{}))
+		defer cleanup()
+
+		returnsWithin(t, server.Stop)
+		returnsWithin(t, func() { server.GracefulStop(5 * time.Second) })
+	})
+
+	t.Run("returns when called more than once", func(t *testing.T) {
+		server, _, cleanup := start(t, make(chan struct{}, 1), make(chan struct{}))
+		defer cleanup()
+
+		returnsWithin(t, func() { server.GracefulStop(5 * time.Second) })
+		returnsWithin(t, func() { server.GracefulStop(5 * time.Second) })
+		returnsWithin(t, server.Stop)
+	})
+
+	t.Run("can be stopped while a graceful stop is draining", func(t *testing.T) {
+		started := make(chan struct{}, 1)
+		release := make(chan struct{})
+		defer close(release)
+
+		server, client, cleanup := start(t, started, release)
+		defer cleanup()
+
+		go client.DeleteDataDirectories(context.Background(), &idl.DeleteDataDirectoriesRequest{})
+		<-started
+
+		go server.GracefulStop(time.Minute)
+
+		returnsWithin(t, server.Stop)
+	})
 }
 
 func pathExists(path string) bool {
 	_, err := os.Stat(path)
 	return !os.IsNotExist(err)
 }
+
+func TestServerMaxConcurrent(t *testing.T) {
+	testlog.SetupLogger()
+
+	t.Run("rejects requests beyond the maximum while allowing version checks", func(t *testing.T) {
+		started := make(chan struct{}, 2)
+		release := make(chan struct{})
+
+		agent.DeleteDirectoriesFunc = func(directories []string, requiredPaths []string, streams step.OutStreams) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		}
+		defer func() {
+			agent.DeleteDirectoriesFunc = upgrade.DeleteDirectories
+		}()
+
+		stateDir := testutils.GetTempDir(t, "")
+		defer os.RemoveAll(stateDir)
+
+		port := testutils.MustGetPort(t)
+		server := agent.NewServer(agent.Config{
+			Port:          port,
+			StateDir:      stateDir,
+			MaxConcurrent: 2,
+		})
+		go server.Start()
+		defer server.Stop()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		conn, err := grpc.DialContext(ctx, "localhost:"+strconv.Itoa(port), grpc.WithInsecure(), grpc.WithBlock())
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		defer conn.Close()
+		client := idl.NewAgentClient(conn)
+
+		errs := make(chan error, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				_, err := client.DeleteDataDirectories(context.Background(), &idl.DeleteDataDirectoriesRequest{})
+				errs <- err
+			}()
+			<-started
+		}
+
+		_, err = client.DeleteDataDirectories(context.Background(), &idl.DeleteDataDirectoriesRequest{})
+		if status.Code(err) != codes.ResourceExhausted {
+			t.Errorf("got error %#v want code %s", err, codes.ResourceExhausted)
+		}
+
+		if _, err := client.Version(context.Background(), &idl.VersionRequest{}); err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+
+		close(release)
+		for i := 0; i < 2; i++ {
+			if err := <-errs; err != nil {
+				t.Errorf("unexpected error: %#v", err)
+			}
+		}
+
+		// Once the running requests finish their slots are available again.
+		agent.DeleteDirectoriesFunc = func(directories []string, requiredPaths []string, streams step.OutStreams) error {
+			return nil
+		}
+
+		if _, err := client.DeleteDataDirectories(context.Background(), &idl.DeleteDataDirectoriesRequest{}); err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+	})
+}
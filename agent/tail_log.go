@@ -0,0 +1,44 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+	"time"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/utils/log"
+)
+
+// LogFilePath returns the log file streamed by TailLog.
+var LogFilePath = gplog.GetLogFilePath
+
+// TailLogPollInterval is how often TailLog checks the log file for new lines.
+var TailLogPollInterval = 250 * time.Millisecond
+
+// TailLog streams each line appended to the agent's log file to the hub, so
+// that the current operation can be watched without logging in to the segment
+// host. The log is followed across rotations until the hub cancels the
+// stream, which it does once the operation completes, or until the agent is
+// stopping.
+func (s *Server) TailLog(in *idl.TailLogRequest, stream idl.Agent_TailLogServer) error {
+	gplog.Info("got a request to tail the log from the hub")
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-s.stopping:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return log.Follow(ctx, LogFilePath(), TailLogPollInterval, func(line string) error {
+		return stream.Send(&idl.LogLine{Line: line})
+	})
+}
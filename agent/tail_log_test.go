@@ -0,0 +1,134 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package agent_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"google.golang.org/grpc"
+
+	"github.com/greenplum-db/gpupgrade/agent"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+	"github.com/greenplum-db/gpupgrade/utils"
+)
+
+// logLineRecorder is an in-memory Agent_TailLogServer that passes on the
+// lines sent to it.
+type logLineRecorder struct {
+	grpc.ServerStream
+	ctx   context.Context
+	lines chan string
+}
+
+func (r *logLineRecorder) Context() context.Context {
+	return r.ctx
+}
+
+func (r *logLineRecorder) Send(line *idl.LogLine) error {
+	r.lines <- line.Line
+	return nil
+}
+
+func TestTailLog(t *testing.T) {
+	testlog.SetupLogger()
+
+	dir := testutils.GetTempDir(t, "")
+	defer testutils.MustRemoveAll(t, dir)
+
+	path := filepath.Join(dir, "gpupgrade_agent_20210304.log")
+	testutils.MustWriteToFile(t, path, "logged before the request\n")
+
+	agent.LogFilePath = func() string { return path }
+	agent.TailLogPollInterval = time.Millisecond
+	defer func() {
+		agent.LogFilePath = gplog.GetLogFilePath
+		agent.TailLogPollInterval = 250 * time.Millisecond
+	}()
+
+	// The log file is first statted once TailLog has read to its end, after
+	// which appended lines are streamed.
+	following := make(chan struct{})
+	var once sync.Once
+	utils.System.Stat = func(name string) (os.FileInfo, error) {
+		once.Do(func() { close(following) })
+		return os.Stat(name)
+	}
+	defer func() {
+		utils.System.Stat = os.Stat
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := &logLineRecorder{ctx: ctx, lines: make(chan string)}
+	errs := make(chan error, 1)
+
+	server := agent.NewServer(agent.Config{})
+	go func() {
+		errs <- server.TailLog(&idl.TailLogRequest{}, stream)
+	}()
+
+	select {
+	case <-following:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for TailLog to follow the log file")
+	}
+
+	mustAppend(t, path, "first\nsecond\npartial ")
+
+	// Rotate the log as RotatingFile does, finishing the partial line first.
+	mustAppend(t, path, "line\n")
+	if err := os.Rename(path, path+".20210304T120000.000000000"); err != nil {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+	mustAppend(t, path, "after rotation\n")
+
+	var lines []string
+	for len(lines) < 4 {
+		select {
+		case line := <-stream.lines:
+			lines = append(lines, line)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for log lines, got %q", lines)
+		}
+	}
+
+	expected := []string{"first", "second", "partial line", "after rotation"}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("got lines %q want %q", lines, expected)
+	}
+
+	cancel()
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for TailLog to return after the stream was cancelled")
+	}
+}
+
+func mustAppend(t *testing.T, path, contents string) {
+	t.Helper()
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(contents); err != nil {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+}
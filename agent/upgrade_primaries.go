@@ -12,8 +12,10 @@ import (
 	"golang.org/x/xerrors"
 
 	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/step"
 	"github.com/greenplum-db/gpupgrade/upgrade"
 	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/command"
 	"github.com/greenplum-db/gpupgrade/utils/errorlist"
 )
 
@@ -24,21 +26,35 @@ func (s *Server) UpgradePrimaries(ctx context.Context, request *idl.UpgradePrima
 		gplog.Info("agent starting %s", idl.Substep_UPGRADE_PRIMARIES)
 	}
 
-	err := UpgradePrimaries(s.conf.StateDir, request)
+	err := UpgradePrimaries(ctx, s.conf.StateDir, request, step.DevNullStream)
 
-	return &idl.UpgradePrimariesReply{}, err
+	logFiles := make(map[int32]string)
+	for _, pair := range request.DataDirPairs {
+		logFiles[pair.DBID] = upgrade.PgUpgradeLogPath(s.conf.StateDir, int(pair.DBID))
+	}
+
+	return &idl.UpgradePrimariesReply{LogFiles: logFiles}, err
 }
 
 // Allow exec.Command to be mocked out by exectest.NewCommand.
 var execCommand = exec.Command
 
+// commandRunner runs pg_upgrade when set, allowing tests to replace it with a
+// fake. When nil pg_upgrade is run with os/exec using execCommand.
+var commandRunner command.Runner
+
 type Segment struct {
 	*idl.DataDirPair
 
 	WorkDir string // the pg_upgrade working directory, where logs are stored
+	LogFile string // the file pg_upgrade's output is written to
 }
 
-func UpgradePrimaries(stateDir string, request *idl.UpgradePrimariesRequest) error {
+// UpgradePrimaries runs pg_upgrade for each primary in the request
+// concurrently. pg_upgrade is stopped if ctx is done, such as when the hub
+// cancels the request. Each segment's output is written to streams as well as
+// to its log file; writes to streams are serialized across segments.
+func UpgradePrimaries(ctx context.Context, stateDir string, request *idl.UpgradePrimariesRequest, streams step.OutStreams) error {
 	segments, err := buildSegments(request, stateDir)
 
 	if err != nil {
@@ -54,12 +70,13 @@ func UpgradePrimaries(stateDir string, request *idl.UpgradePrimariesRequest) err
 	// Upgrade each segment concurrently
 	//
 	upgradeResponse := make(chan error, len(segments))
+	streams = newSyncStreams(streams)
 
 	for _, segment := range segments {
 		segment := segment // capture the range variable
 
 		go func() {
-			upgradeResponse <- upgradeSegment(segment, request, host)
+			upgradeResponse <- upgradeSegment(ctx, segment, request, host, streams)
 		}()
 	}
 
@@ -98,6 +115,7 @@ func buildSegments(request *idl.UpgradePrimariesRequest, stateDir string) ([]Seg
 		segments = append(segments, Segment{
 			DataDirPair: dataPair,
 			WorkDir:     workdir,
+			LogFile:     upgrade.PgUpgradeLogPath(stateDir, int(dataPair.DBID)),
 		})
 	}
 
@@ -4,6 +4,7 @@
 package agent_test
 
 import (
+	"context"
 	"errors"
 	"io/ioutil"
 	"os"
@@ -14,8 +15,11 @@ import (
 
 	"github.com/greenplum-db/gpupgrade/agent"
 	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/step"
 	"github.com/greenplum-db/gpupgrade/testutils/exectest"
+	"github.com/greenplum-db/gpupgrade/testutils/fakerunner"
 	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+	"github.com/greenplum-db/gpupgrade/upgrade"
 	"github.com/greenplum-db/gpupgrade/utils"
 	"github.com/greenplum-db/gpupgrade/utils/errorlist"
 	"github.com/greenplum-db/gpupgrade/utils/rsync"
@@ -23,6 +27,7 @@ import (
 
 func ResetCommands() {
 	agent.SetExecCommand(nil)
+	agent.SetCommandRunner(nil)
 	rsync.SetRsyncCommand(nil)
 }
 
@@ -92,7 +97,7 @@ func TestUpgradePrimary(t *testing.T) {
 			UseLinkMode:   false,
 			TargetVersion: "6.15.0",
 		}
-		err := agent.UpgradePrimaries(tempDir, request)
+		err := agent.UpgradePrimaries(context.Background(), tempDir, request, step.DevNullStream)
 		if err == nil {
 			t.Fatal("UpgradeSegments() returned no error")
 		}
@@ -120,7 +125,7 @@ func TestUpgradePrimary(t *testing.T) {
 			CheckOnly:     false,
 			UseLinkMode:   false,
 			TargetVersion: "6.15.0"}
-		err := agent.UpgradePrimaries(tempDir, request)
+		err := agent.UpgradePrimaries(context.Background(), tempDir, request, step.DevNullStream)
 		if err == nil {
 			t.Fatal("UpgradeSegments() returned no error")
 		}
@@ -136,6 +141,185 @@ func TestUpgradePrimary(t *testing.T) {
 		}
 	})
 
+	t.Run("writes pg_upgrade output to a log file for each segment", func(t *testing.T) {
+		agent.SetExecCommand(exectest.NewCommand(agent.PgUpgradeOutputMain))
+		defer ResetCommands()
+
+		request := buildRequest(pairs)
+		request.CheckOnly = true
+
+		err := agent.UpgradePrimaries(context.Background(), tempDir, request, step.DevNullStream)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		for _, pair := range pairs {
+			path := upgrade.PgUpgradeLogPath(tempDir, int(pair.DBID))
+			defer os.Remove(path)
+
+			contents, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatalf("unexpected error: %#v", err)
+			}
+
+			for _, expected := range []string{"Performing Upgrade\n", "some warning\n"} {
+				if !strings.Contains(string(contents), expected) {
+					t.Errorf("log %q contains %q, want it to contain %q", path, contents, expected)
+				}
+			}
+		}
+	})
+
+	t.Run("also writes pg_upgrade output to the given streams", func(t *testing.T) {
+		agent.SetExecCommand(exectest.NewCommand(agent.PgUpgradeOutputMain))
+		defer ResetCommands()
+
+		request := buildRequest(pairs)
+		request.CheckOnly = true
+
+		streams := new(step.BufferedStreams)
+		err := agent.UpgradePrimaries(context.Background(), tempDir, request, streams)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		for _, pair := range pairs {
+			defer os.Remove(upgrade.PgUpgradeLogPath(tempDir, int(pair.DBID)))
+		}
+
+		stdout := streams.StdoutBuf.String()
+		if strings.Count(stdout, "Performing Upgrade\n") != len(pairs) {
+			t.Errorf("got stdout %q, want %q once for each segment", stdout, "Performing Upgrade\n")
+		}
+
+		stderr := streams.StderrBuf.String()
+		if strings.Count(stderr, "some warning\n") != len(pairs) {
+			t.Errorf("got stderr %q, want %q once for each segment", stderr, "some warning\n")
+		}
+	})
+
+	t.Run("runs pg_upgrade with the expected command line for each segment", func(t *testing.T) {
+		runner := fakerunner.New()
+		agent.SetCommandRunner(runner)
+		defer ResetCommands()
+
+		request := buildRequest(pairs)
+		request.CheckOnly = true
+
+		err := agent.UpgradePrimaries(context.Background(), tempDir, request, step.DevNullStream)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		invocations := runner.Invocations()
+		sort.Slice(invocations, func(i, j int) bool {
+			return strings.Join(invocations[i].Args, " ") < strings.Join(invocations[j].Args, " ")
+		})
+
+		expected := []fakerunner.Invocation{
+			{
+				Name: "/new/bin/pg_upgrade",
+				Args: []string{"--retain",
+					"--old-bindir", "/old/bin", "--new-bindir", "/new/bin",
+					"--old-datadir", "/data/old", "--new-datadir", "/data/new",
+					"--old-port", "15432", "--new-port", "15433",
+					"--mode", "segment", "--old-gp-dbid", "2", "--new-gp-dbid", "2", "--check"},
+			},
+			{
+				Name: "/new/bin/pg_upgrade",
+				Args: []string{"--retain",
+					"--old-bindir", "/old/bin", "--new-bindir", "/new/bin",
+					"--old-datadir", "/other/data/old", "--new-datadir", "/other/data/new",
+					"--old-port", "99999", "--new-port", "88888",
+					"--mode", "segment", "--old-gp-dbid", "6", "--new-gp-dbid", "6", "--check"},
+			},
+		}
+		if !reflect.DeepEqual(invocations, expected) {
+			t.Errorf("got invocations %q want %q", invocations, expected)
+		}
+	})
+
+	t.Run("stops pg_upgrade when the context is cancelled", func(t *testing.T) {
+		result := fakerunner.Result{Err: errors.New("signal: terminated"), Block: true}
+		agent.SetCommandRunner(fakerunner.New(result, result))
+		defer ResetCommands()
+
+		request := buildRequest(pairs)
+		request.CheckOnly = true
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := agent.UpgradePrimaries(ctx, tempDir, request, step.DevNullStream)
+		if !errors.Is(err, upgrade.ErrPgUpgradeCancelled) {
+			t.Errorf("got error %#v want %#v", err, upgrade.ErrPgUpgradeCancelled)
+		}
+
+		for _, pair := range pairs {
+			_ = os.Remove(upgrade.PgUpgradeLogPath(tempDir, int(pair.DBID)))
+		}
+	})
+
+	t.Run("writes the output of a failed pg_upgrade from the command runner to the log file", func(t *testing.T) {
+		expected := errors.New("exit status 1")
+		result := fakerunner.Result{Stdout: []byte("Performing Consistency Checks\n"), Err: expected}
+		agent.SetCommandRunner(fakerunner.New(result, result))
+		defer ResetCommands()
+
+		request := buildRequest(pairs)
+		request.CheckOnly = true
+
+		err := agent.UpgradePrimaries(context.Background(), tempDir, request, step.DevNullStream)
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+
+		for _, pair := range pairs {
+			path := upgrade.PgUpgradeLogPath(tempDir, int(pair.DBID))
+			defer os.Remove(path)
+
+			contents, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatalf("unexpected error: %#v", err)
+			}
+
+			if string(contents) != "Performing Consistency Checks\n" {
+				t.Errorf("got log contents %q want %q", contents, "Performing Consistency Checks\n")
+			}
+		}
+	})
+
+	t.Run("writes the log file and returns its path when pg_upgrade fails", func(t *testing.T) {
+		agent.SetExecCommand(exectest.NewCommand(agent.FailedPgUpgradeOutputMain))
+		defer ResetCommands()
+
+		request := buildRequest(pairs)
+		request.CheckOnly = true
+
+		err := agent.UpgradePrimaries(context.Background(), tempDir, request, step.DevNullStream)
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+
+		for _, pair := range pairs {
+			path := upgrade.PgUpgradeLogPath(tempDir, int(pair.DBID))
+			defer os.Remove(path)
+
+			contents, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatalf("unexpected error: %#v", err)
+			}
+
+			if string(contents) != "Performing Consistency Checks\n" {
+				t.Errorf("got log contents %q want %q", contents, "Performing Consistency Checks\n")
+			}
+		}
+
+		if !strings.Contains(err.Error(), upgrade.PgUpgradeLogPath(tempDir, int(pairs[0].DBID))) {
+			t.Errorf("expected error %q to contain the log path", err.Error())
+		}
+	})
+
 	t.Run("it does not perform a copy of the master backup directory when using check mode", func(t *testing.T) {
 		agent.SetExecCommand(exectest.NewCommand(agent.Success))
 
@@ -151,7 +335,7 @@ func TestUpgradePrimary(t *testing.T) {
 				}
 			}))
 
-		_ = agent.UpgradePrimaries(tempDir, request)
+		_ = agent.UpgradePrimaries(context.Background(), tempDir, request, step.DevNullStream)
 	})
 
 	t.Run("it returns errors in parallel if the copy step fails", func(t *testing.T) {
@@ -159,7 +343,7 @@ func TestUpgradePrimary(t *testing.T) {
 		agent.SetExecCommand(exectest.NewCommand(agent.Success))
 
 		request := buildRequest(pairs)
-		err = agent.UpgradePrimaries(tempDir, request)
+		err = agent.UpgradePrimaries(context.Background(), tempDir, request, step.DevNullStream)
 
 		// We expect each part of the request to return its own ExitError,
 		// containing the expected message from FailedRsync.
@@ -228,7 +412,7 @@ func TestUpgradePrimary(t *testing.T) {
 		request := buildRequest(pairs)
 		request.MasterBackupDir = "/some/master/backup/dir"
 
-		err := agent.UpgradePrimaries(tempDir, request)
+		err := agent.UpgradePrimaries(context.Background(), tempDir, request, step.DevNullStream)
 		if err != nil {
 			t.Error(err)
 		}
@@ -4,22 +4,27 @@
 package agent
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
+	"sync"
 
 	"github.com/blang/semver/v4"
 	"golang.org/x/xerrors"
 
 	"github.com/greenplum-db/gpupgrade/greenplum"
 	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/step"
 	"github.com/greenplum-db/gpupgrade/upgrade"
 	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/errorlist"
 	"github.com/greenplum-db/gpupgrade/utils/rsync"
 )
 
-func upgradeSegment(segment Segment, request *idl.UpgradePrimariesRequest, host string) error {
+func upgradeSegment(ctx context.Context, segment Segment, request *idl.UpgradePrimariesRequest, host string, streams step.OutStreams) error {
 	err := restoreBackup(request, segment)
 
 	if err != nil {
@@ -33,47 +38,111 @@ func upgradeSegment(segment Segment, request *idl.UpgradePrimariesRequest, host
 			host, segment.Content, err)
 	}
 
-	err = performUpgrade(segment, request)
+	err = performUpgradeWithLog(ctx, segment, request, streams)
 
 	if err != nil {
 		failedAction := "upgrade"
 		if request.CheckOnly {
 			failedAction = "check"
 		}
-		return xerrors.Errorf("%s primary on host %s with content %d: %w", failedAction, host, segment.Content, err)
+		return xerrors.Errorf("%s primary on host %s with content %d, see %q: %w", failedAction, host, segment.Content, segment.LogFile, err)
 	}
 
 	return nil
 }
 
-func performUpgrade(segment Segment, request *idl.UpgradePrimariesRequest) error {
+// performUpgradeWithLog runs pg_upgrade for the segment, writing its output to
+// streams and appending it to the segment's log file so that operators can
+// find it after a failure.
+func performUpgradeWithLog(ctx context.Context, segment Segment, request *idl.UpgradePrimariesRequest, streams step.OutStreams) (err error) {
+	log, err := utils.System.OpenFile(segment.LogFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return xerrors.Errorf("opening pg_upgrade log: %w", err)
+	}
+	defer func() {
+		if cErr := log.Close(); cErr != nil {
+			err = errorlist.Append(err, xerrors.Errorf("closing pg_upgrade log: %w", cErr))
+		}
+	}()
+
+	return performUpgrade(ctx, segment, request, &teeStreams{
+		stdout: io.MultiWriter(log, streams.Stdout()),
+		stderr: io.MultiWriter(log, streams.Stderr()),
+	})
+}
+
+// teeStreams is a step.OutStreams that writes stdout and stderr to separate
+// writers, such as ones shared with a log file.
+type teeStreams struct {
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func (t *teeStreams) Stdout() io.Writer {
+	return t.stdout
+}
+
+func (t *teeStreams) Stderr() io.Writer {
+	return t.stderr
+}
+
+// syncStreams is a step.OutStreams that serializes writes to the underlying
+// streams, so that segments upgraded concurrently can share them.
+type syncStreams struct {
+	mu     sync.Mutex
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func newSyncStreams(streams step.OutStreams) *syncStreams {
+	s := &syncStreams{}
+	s.stdout = &syncWriter{mu: &s.mu, writer: streams.Stdout()}
+	s.stderr = &syncWriter{mu: &s.mu, writer: streams.Stderr()}
+	return s
+}
+
+func (s *syncStreams) Stdout() io.Writer {
+	return s.stdout
+}
+
+func (s *syncStreams) Stderr() io.Writer {
+	return s.stderr
+}
+
+type syncWriter struct {
+	mu     *sync.Mutex
+	writer io.Writer
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.writer.Write(p)
+}
+
+func performUpgrade(ctx context.Context, segment Segment, request *idl.UpgradePrimariesRequest, streams step.OutStreams) error {
 	dbid := int(segment.DBID)
 	segmentPair := upgrade.SegmentPair{
 		Source: &upgrade.Segment{BinDir: request.SourceBinDir, DataDir: segment.SourceDataDir, DBID: dbid, Port: int(segment.SourcePort)},
 		Target: &upgrade.Segment{BinDir: request.TargetBinDir, DataDir: segment.TargetDataDir, DBID: dbid, Port: int(segment.TargetPort)},
 	}
 
+	if request.UseLinkMode {
+		if err := upgrade.VerifyLinkMode(segment.SourceDataDir, segment.TargetDataDir); err != nil {
+			return err
+		}
+	}
+
 	options := []upgrade.Option{
 		upgrade.WithExecCommand(execCommand),
+		upgrade.WithCommandRunner(commandRunner),
 		upgrade.WithWorkDir(segment.WorkDir),
-		upgrade.WithSegmentMode(),
+		upgrade.WithOutputStreams(streams.Stdout(), streams.Stderr()),
 	}
+	options = append(options, upgrade.PrimaryOptions(request.CheckOnly, request.UseLinkMode, request.TablespacesMappingFilePath)...)
 
-	if request.CheckOnly {
-		options = append(options, upgrade.WithCheckOnly())
-	} else {
-		// During gpupgrade execute, tablepace mapping file is copied after
-		// the master has been upgraded. So, don't pass this option during
-		// --check mode. There is no test in pg_upgrade which depends on the
-		// existence of this file.
-		options = append(options, upgrade.WithTablespaceFile(request.TablespacesMappingFilePath))
-	}
-
-	if request.UseLinkMode {
-		options = append(options, upgrade.WithLinkMode())
-	}
-
-	return upgrade.Run(segmentPair, semver.MustParse(request.TargetVersion), options...)
+	return upgrade.RunContext(ctx, segmentPair, semver.MustParse(request.TargetVersion), options...)
 }
 
 func restoreBackup(request *idl.UpgradePrimariesRequest, segment Segment) error {
@@ -81,21 +150,7 @@ func restoreBackup(request *idl.UpgradePrimariesRequest, segment Segment) error
 		return nil
 	}
 
-	options := []rsync.Option{
-		rsync.WithSources(request.MasterBackupDir + string(os.PathSeparator)),
-		rsync.WithDestination(segment.TargetDataDir),
-		rsync.WithOptions("--archive", "--delete"),
-		rsync.WithExcludedFiles(
-			"internal.auto.conf",
-			"postgresql.conf",
-			"pg_hba.conf",
-			"postmaster.opts",
-			"gp_dbid",
-			"gpssh.conf",
-			"gpperfmon"),
-	}
-
-	return rsync.Rsync(options...)
+	return rsync.Rsync(upgrade.MasterBackupRsyncOptions(request.MasterBackupDir, segment.TargetDataDir)...)
 }
 
 func RestoreTablespaces(request *idl.UpgradePrimariesRequest, segment Segment) error {
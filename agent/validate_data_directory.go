@@ -0,0 +1,34 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/upgrade"
+)
+
+// ValidateDataDirectory reports whether each directory passes the same checks
+// DeleteDataDirectories makes before deleting it, so the hub can preview a
+// destructive operation across the cluster without performing it.
+func (s *Server) ValidateDataDirectory(ctx context.Context, in *idl.ValidateDataDirectoryRequest) (*idl.ValidateDataDirectoryReply, error) {
+	gplog.Info("got a request to validate data directories from the hub")
+
+	reply := &idl.ValidateDataDirectoryReply{}
+	for _, dir := range in.GetDatadirs() {
+		result := &idl.DataDirectoryValidity{DataDir: dir, Valid: true}
+
+		if err := upgrade.VerifyDataDirectory(dir); err != nil {
+			result.Valid = false
+			result.Reason = err.Error()
+		}
+
+		reply.Results = append(reply.Results, result)
+	}
+
+	return reply, nil
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package agent_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/greenplum-db/gpupgrade/agent"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+	"github.com/greenplum-db/gpupgrade/upgrade"
+)
+
+func TestValidateDataDirectory(t *testing.T) {
+	testlog.SetupLogger()
+
+	t.Run("reports the validity of each data directory", func(t *testing.T) {
+		valid, invalid, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+
+		if err := os.Remove(filepath.Join(invalid, upgrade.PGVersion)); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		server := agent.NewServer(agent.Config{})
+		req := &idl.ValidateDataDirectoryRequest{Datadirs: []string{valid, invalid}}
+		reply, err := server.ValidateDataDirectory(context.Background(), req)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		results := reply.GetResults()
+		if len(results) != 2 {
+			t.Fatalf("got %d results want 2", len(results))
+		}
+
+		if results[0].GetDataDir() != valid || !results[0].GetValid() || results[0].GetReason() != "" {
+			t.Errorf("got result %+v want %q to be valid", results[0], valid)
+		}
+
+		if results[1].GetDataDir() != invalid || results[1].GetValid() {
+			t.Errorf("got result %+v want %q to be invalid", results[1], invalid)
+		}
+
+		expected := upgrade.VerifyDataDirectory(invalid).Error()
+		if results[1].GetReason() != expected {
+			t.Errorf("got reason %q want %q", results[1].GetReason(), expected)
+		}
+
+		if !strings.Contains(results[1].GetReason(), upgrade.PGVersion) {
+			t.Errorf("expected reason %q to name the missing %s", results[1].GetReason(), upgrade.PGVersion)
+		}
+	})
+}
@@ -0,0 +1,14 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+)
+
+func (s *Server) Version(ctx context.Context, in *idl.VersionRequest) (*idl.VersionReply, error) {
+	return &idl.VersionReply{Version: s.conf.Version}, nil
+}
@@ -0,0 +1,28 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package agent_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/greenplum-db/gpupgrade/agent"
+	"github.com/greenplum-db/gpupgrade/idl"
+)
+
+func TestVersion(t *testing.T) {
+	t.Run("returns the version the agent was configured with", func(t *testing.T) {
+		expected := "Version: 1.2.3 Commit: 5889c19 Release: Enterprise"
+		server := agent.NewServer(agent.Config{Version: expected})
+
+		reply, err := server.Version(context.Background(), &idl.VersionRequest{})
+		if err != nil {
+			t.Errorf("unexpected error %#v", err)
+		}
+
+		if reply.GetVersion() != expected {
+			t.Errorf("got version %q want %q", reply.GetVersion(), expected)
+		}
+	})
+}
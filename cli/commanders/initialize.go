@@ -4,16 +4,20 @@
 package commanders
 
 import (
-	"fmt"
+	"encoding/json"
 	"os"
 	"os/exec"
+	"time"
 
 	"github.com/greenplum-db/gp-common-go-libs/gplog"
 	"golang.org/x/xerrors"
 
+	"github.com/greenplum-db/gpupgrade/idl"
 	"github.com/greenplum-db/gpupgrade/step"
 	"github.com/greenplum-db/gpupgrade/upgrade"
 	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/certs"
+	"github.com/greenplum-db/gpupgrade/utils/log"
 )
 
 // introduce this variable to allow exec.Command to be mocked out in tests
@@ -24,6 +28,10 @@ var execCommandHubCount = exec.Command
 // at the same time.
 func CreateStateDir() (err error) {
 	stateDir := utils.GetStateDir()
+	if err := utils.ValidateStateDir(stateDir); err != nil {
+		return err
+	}
+
 	err = os.Mkdir(stateDir, 0700)
 	if os.IsExist(err) {
 		gplog.Debug("State directory %s already present...skipping", stateDir)
@@ -37,7 +45,36 @@ func CreateStateDir() (err error) {
 	return nil
 }
 
-func CreateInitialClusterConfigs(hubPort int) (err error) {
+// HubConfig holds the hub settings chosen by initialize. Its fields are named
+// after those of hub.Config, so that the hub loads them from the configuration
+// file written by CreateInitialClusterConfigs whenever it starts.
+type HubConfig struct {
+	Port int
+
+	// AgentTLS is set when the hub should connect to agents with TLS.
+	AgentTLS *certs.Config `json:",omitempty"`
+
+	// ConfirmSubsteps wait for the operator to run gpupgrade confirm or abort
+	// before starting, for up to ConfirmTimeout.
+	ConfirmSubsteps []idl.Substep `json:",omitempty"`
+	ConfirmTimeout  time.Duration `json:",omitempty"`
+
+	// StepBudget bounds the total time a step waits on agents.
+	StepBudget time.Duration `json:",omitempty"`
+
+	// MetricsAddr is the address the hub serves Prometheus metrics on.
+	MetricsAddr string `json:",omitempty"`
+
+	// AgentTimeout bounds each RPC the hub makes to an agent, other than
+	// long-running ones such as pg_upgrade.
+	AgentTimeout time.Duration `json:",omitempty"`
+
+	// CopyRateLimit bounds, in bytes per second, copying data directories
+	// across filesystems when archiving them.
+	CopyRateLimit int64 `json:",omitempty"`
+}
+
+func CreateInitialClusterConfigs(conf HubConfig) (err error) {
 	// if empty json configuration file exists, skip recreating it
 	filename := upgrade.GetConfigFile()
 	_, err = os.Stat(filename)
@@ -55,17 +92,22 @@ func CreateInitialClusterConfigs(hubPort int) (err error) {
 		return err
 	}
 
+	// Bootstrap with the port to enable the CLI helper function connectToHub to
+	// work with both initialize and all other CLI commands. This overloads the
+	// hub's persisted configuration with that of the CLI when ideally these
+	// would be separate.
+	contents, err := json.Marshal(conf) // the hub will fill the rest during initialization
+	if err != nil {
+		return err
+	}
+
 	file, err := os.Create(filename)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	// Bootstrap with the port to enable the CLI helper function connectToHub to
-	// work with both initialize and all other CLI commands. This overloads the
-	// hub's persisted configuration with that of the CLI when ideally these
-	// would be separate.
-	_, err = fmt.Fprintf(file, `{"Port": %d}`, hubPort) // the hub will fill the rest during initialization
+	_, err = file.Write(contents)
 	if err != nil {
 		return err
 	}
@@ -73,7 +115,9 @@ func CreateInitialClusterConfigs(hubPort int) (err error) {
 	return nil
 }
 
-func StartHub() (err error) {
+// StartHub starts the hub, forwarding the log rotation so that the hub and the
+// agents it starts rotate their logs as requested.
+func StartHub(rotation log.Rotation) (err error) {
 	running, err := IsHubRunning()
 	if err != nil {
 		gplog.Error("failed to determine if hub already running")
@@ -84,7 +128,8 @@ func StartHub() (err error) {
 		return step.Skip
 	}
 
-	cmd := execCommandHubStart("gpupgrade", "hub", "--daemonize")
+	args := append([]string{"hub", "--daemonize"}, rotation.Args()...)
+	cmd := execCommandHubStart("gpupgrade", args...)
 	stdout, cmdErr := cmd.Output()
 	if cmdErr != nil {
 		err := xerrors.Errorf("start hub: %w", cmdErr)
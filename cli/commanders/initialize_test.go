@@ -12,10 +12,14 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/greenplum-db/gpupgrade/hub"
 	"github.com/greenplum-db/gpupgrade/step"
 	"github.com/greenplum-db/gpupgrade/testutils/exectest"
 	"github.com/greenplum-db/gpupgrade/upgrade"
+	"github.com/greenplum-db/gpupgrade/utils/certs"
+	"github.com/greenplum-db/gpupgrade/utils/log"
 )
 
 // Streams the above stdout/err constants to the corresponding standard file
@@ -116,7 +120,25 @@ func TestStartHub_Succeeds(t *testing.T) {
 
 	execCommandHubCount = exectest.NewCommand(IsHubRunning_False)
 	execCommandHubStart = exectest.NewCommand(GpupgradeHub_good_Main)
-	err := StartHub()
+	err := StartHub(log.Rotation{})
+	if err != nil {
+		t.Errorf("unexpected error %#v", err)
+	}
+}
+
+func TestStartHub_ForwardsLogRotation(t *testing.T) {
+	setup(t)
+	defer teardown()
+
+	execCommandHubCount = exectest.NewCommand(IsHubRunning_False)
+	execCommandHubStart = exectest.NewCommandWithVerifier(GpupgradeHub_good_Main, func(name string, args ...string) {
+		expected := []string{"hub", "--daemonize", "--log-max-size", "10", "--log-max-age", "7"}
+		if !reflect.DeepEqual(args, expected) {
+			t.Errorf("got args %q want %q", args, expected)
+		}
+	})
+
+	err := StartHub(log.NewRotation(10, 7))
 	if err != nil {
 		t.Errorf("unexpected error %#v", err)
 	}
@@ -128,7 +150,7 @@ func TestStartHub_FailsToStartWhenHubIsRunningErrors(t *testing.T) {
 
 	execCommandHubCount = exectest.NewCommand(IsHubRunning_Error)
 	execCommandHubStart = exectest.NewCommand(GpupgradeHub_good_Main) // should not hit this, but fail it we do
-	err := StartHub()
+	err := StartHub(log.Rotation{})
 	var expected *exec.ExitError
 	if !errors.As(err, &expected) {
 		t.Errorf("returned error %#v want %#v", err, expected)
@@ -141,7 +163,7 @@ func TestStartHub_IsSkippedWhenHubIsRunning(t *testing.T) {
 
 	execCommandHubCount = exectest.NewCommand(IsHubRunning_True)
 	execCommandHubStart = exectest.NewCommand(GpupgradeHub_bad_Main) // should not hit this, but fail if we do
-	err := StartHub()
+	err := StartHub(log.Rotation{})
 
 	if !errors.Is(err, step.Skip) {
 		t.Errorf("unexpected error %#v", err)
@@ -154,7 +176,7 @@ func TestStartHub_FailsWhenStartingTheHubErrors(t *testing.T) {
 
 	execCommandHubCount = exectest.NewCommand(IsHubRunning_False)
 	execCommandHubStart = exectest.NewCommand(GpupgradeHub_bad_Main)
-	err := StartHub()
+	err := StartHub(log.Rotation{})
 	if err == nil {
 		t.Errorf("expected error %#v got nil", err)
 	}
@@ -255,7 +277,7 @@ func TestCreateInitialClusterConfigs(t *testing.T) {
 	t.Run("test idempotence", func(t *testing.T) {
 
 		{ // creates initial cluster config files if none exist or fails"
-			err = CreateInitialClusterConfigs(port)
+			err = CreateInitialClusterConfigs(HubConfig{Port: port})
 			if err != nil {
 				t.Fatalf("unexpected error %#v", err)
 			}
@@ -266,7 +288,7 @@ func TestCreateInitialClusterConfigs(t *testing.T) {
 		}
 
 		{ // creating cluster config files is idempotent
-			err = CreateInitialClusterConfigs(port)
+			err = CreateInitialClusterConfigs(HubConfig{Port: port})
 			if err != nil {
 				t.Fatalf("unexpected error %#v", err)
 			}
@@ -282,10 +304,35 @@ func TestCreateInitialClusterConfigs(t *testing.T) {
 		}
 
 		{ // creating cluster config files succeeds on multiple runs
-			err = CreateInitialClusterConfigs(port)
+			err = CreateInitialClusterConfigs(HubConfig{Port: port})
 			if err != nil {
 				t.Fatalf("unexpected error %#v", err)
 			}
 		}
 	})
+
+	t.Run("writes the hub settings for the hub to load", func(t *testing.T) {
+		if err := os.Remove(upgrade.GetConfigFile()); err != nil {
+			t.Fatalf("unexpected error %#v", err)
+		}
+
+		tlsConf := certs.StateDirConfig(stateDir)
+		tlsConf.VerifyClient = true
+
+		err = CreateInitialClusterConfigs(HubConfig{Port: port, AgentTLS: &tlsConf, StepBudget: 6 * time.Hour, MetricsAddr: ":9527", AgentTimeout: 10 * time.Minute, CopyRateLimit: 100 * 1024 * 1024})
+		if err != nil {
+			t.Fatalf("unexpected error %#v", err)
+		}
+
+		conf := &hub.Config{}
+		err = hub.LoadConfig(conf, upgrade.GetConfigFile())
+		if err != nil {
+			t.Fatalf("unexpected error %#v", err)
+		}
+
+		expected := &hub.Config{Port: port, AgentTLS: &tlsConf, StepBudget: 6 * time.Hour, MetricsAddr: ":9527", AgentTimeout: 10 * time.Minute, CopyRateLimit: 100 * 1024 * 1024}
+		if !reflect.DeepEqual(conf, expected) {
+			t.Errorf("got %+v want %+v", conf, expected)
+		}
+	})
 }
@@ -4,20 +4,36 @@
 package commands
 
 import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
 	"github.com/greenplum-db/gp-common-go-libs/gplog"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/xerrors"
 
 	"github.com/greenplum-db/gpupgrade/agent"
 	"github.com/greenplum-db/gpupgrade/upgrade"
 	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/certs"
 	"github.com/greenplum-db/gpupgrade/utils/daemon"
 	"github.com/greenplum-db/gpupgrade/utils/log"
+	"github.com/greenplum-db/gpupgrade/utils/metrics"
 )
 
 func Agent() *cobra.Command {
 	var port int
 	var statedir string
+	var logFormat string
+	var maxConcurrent int
+	var metricsAddr string
 	var shouldDaemonize bool
+	var useTLS bool
+	var tlsConf certs.Config
 
 	var cmd = &cobra.Command{
 		Use:    "agent",
@@ -26,16 +42,53 @@ func Agent() *cobra.Command {
 		Hidden: true,
 		Args:   cobra.MaximumNArgs(0), //no positional args allowed
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := agent.ValidatePort(port); err != nil {
+				return err
+			}
+
+			if err := utils.ValidateStateDir(statedir); err != nil {
+				return err
+			}
+
+			// Create the state directory before anything is written to it,
+			// such as the PID file when daemonizing.
+			if err := utils.EnsureStateDirAt(statedir); err != nil {
+				return err
+			}
+
 			logdir, err := utils.GetLogDir()
 			if err != nil {
 				return err
 			}
-			gplog.InitializeLogging("gpupgrade_agent", logdir)
+			if err := log.InitializeWithRotation("gpupgrade_agent", logdir, logFormat, logRotation()); err != nil {
+				return err
+			}
 			defer log.WritePanics()
 
 			conf := agent.Config{
-				Port:     port,
-				StateDir: statedir,
+				Port:          port,
+				StateDir:      statedir,
+				Version:       VersionString("oneline"),
+				MaxConcurrent: maxConcurrent,
+			}
+
+			// Any of the TLS flags implies TLS, rather than silently
+			// serving plaintext.
+			for _, flag := range []string{"tls-cert", "tls-key", "tls-ca", "tls-verify-client"} {
+				useTLS = useTLS || cmd.Flags().Changed(flag)
+			}
+
+			if useTLS {
+				conf.Credentials, err = certs.ServerCredentials(agentTLSConfig(cmd, statedir, tlsConf))
+				if err != nil {
+					return err
+				}
+			}
+
+			if metricsAddr != "" {
+				if _, err := metrics.Serve(metricsAddr); err != nil {
+					return err
+				}
 			}
 
 			agentServer := agent.NewServer(conf)
@@ -43,16 +96,115 @@ func Agent() *cobra.Command {
 				agentServer.MakeDaemon()
 			}
 
-			// blocking call
-			agentServer.Start()
+			// Drain in-flight requests rather than exiting midway through
+			// destructive work such as deleting directories. Restore the
+			// default handling once a signal is received so that a second
+			// signal stops the agent immediately.
+			signals := make(chan os.Signal, 1)
+			signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+			go func() {
+				sig := <-signals
+				signal.Stop(signals)
 
-			return nil
+				gplog.Info("received %s, stopping agent", sig)
+				agentServer.GracefulStop(agent.DefaultGracefulStopTimeout)
+			}()
+
+			// blocking call
+			return agentServer.Start()
 		},
 	}
 	cmd.Flags().IntVar(&port, "port", upgrade.DefaultAgentPort, "the port to listen for commands on")
 	cmd.Flags().StringVar(&statedir, "state-directory", utils.GetStateDir(), "Agent state directory")
+	cmd.Flags().StringVar(&logFormat, "log-format", log.TextFormat, "the format of log output, either text or json")
+	cmd.Flags().IntVar(&maxConcurrent, "max-concurrent", 0, "the maximum number of operations to run at once, rejecting any more; 0 is unlimited")
+	cmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "the address to serve Prometheus metrics on at /metrics, such as :9416; unset disables metrics")
+	cmd.Flags().BoolVar(&useTLS, "tls", false, "serve with TLS using the certificates in the state directory")
+	cmd.Flags().StringVar(&tlsConf.CertFile, "tls-cert", "", "the TLS certificate, overriding the one in the state directory")
+	cmd.Flags().StringVar(&tlsConf.KeyFile, "tls-key", "", "the TLS private key, overriding the one in the state directory")
+	cmd.Flags().StringVar(&tlsConf.CAFile, "tls-ca", "", "the TLS certificate authority, overriding the one in the state directory")
+	cmd.Flags().BoolVar(&tlsConf.VerifyClient, "tls-verify-client", false, "require the hub to present a certificate signed by the certificate authority")
+
+	// The restart subcommand takes the same configuration flags, which it
+	// passes along to the new agent.
+	restart := agentRestart()
+	restart.Flags().AddFlagSet(cmd.Flags())
+	cmd.AddCommand(restart)
 
 	daemon.MakeDaemonizable(cmd, &shouldDaemonize)
 
 	return cmd
 }
+
+// agentRestart stops the daemonized agent recorded in the state directory's
+// PID file, if any, and starts a fresh one with the same flags.
+func agentRestart() *cobra.Command {
+	var stopTimeout time.Duration
+
+	var cmd = &cobra.Command{
+		Use:    "restart",
+		Short:  "Restart the daemonized Command Listener",
+		Long:   `Restart the daemonized Command Listener`,
+		Hidden: true,
+		Args:   cobra.MaximumNArgs(0), //no positional args allowed
+		RunE: func(cmd *cobra.Command, args []string) error {
+			statedir, err := cmd.Flags().GetString("state-directory")
+			if err != nil {
+				return err
+			}
+
+			pid, err := daemon.StopPIDFileProcess(statedir, stopTimeout)
+			if err != nil {
+				return xerrors.Errorf("stopping agent: %w", err)
+			}
+
+			if pid != 0 {
+				fmt.Printf("Agent stopped (pid %d)\n", pid)
+			}
+
+			agentArgs := []string{"agent", "--daemonize"}
+			cmd.Flags().Visit(func(flag *pflag.Flag) {
+				if flag.Name == "stop-timeout" {
+					return
+				}
+
+				agentArgs = append(agentArgs, fmt.Sprintf("--%s=%s", flag.Name, flag.Value.String()))
+			})
+
+			agentCmd := exec.Command(os.Args[0], agentArgs...)
+			agentCmd.Stdout = os.Stdout
+			agentCmd.Stderr = os.Stderr
+
+			if err := agentCmd.Run(); err != nil {
+				return xerrors.Errorf("starting agent: %w", err)
+			}
+
+			return nil
+		},
+	}
+	// Allow the agent's own graceful stop to finish before giving up.
+	cmd.Flags().DurationVar(&stopTimeout, "stop-timeout", agent.DefaultGracefulStopTimeout+10*time.Second, "how long to wait for the running agent to exit")
+
+	return cmd
+}
+
+// agentTLSConfig returns the default certificates in the state directory,
+// overridden by any that were passed as flags.
+func agentTLSConfig(cmd *cobra.Command, stateDir string, flags certs.Config) certs.Config {
+	conf := certs.StateDirConfig(stateDir)
+	conf.VerifyClient = flags.VerifyClient
+
+	if cmd.Flags().Changed("tls-cert") {
+		conf.CertFile = flags.CertFile
+	}
+
+	if cmd.Flags().Changed("tls-key") {
+		conf.KeyFile = flags.KeyFile
+	}
+
+	if cmd.Flags().Changed("tls-ca") {
+		conf.CAFile = flags.CAFile
+	}
+
+	return conf
+}
@@ -50,8 +50,17 @@ import (
 	"github.com/greenplum-db/gpupgrade/idl"
 	"github.com/greenplum-db/gpupgrade/upgrade"
 	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/log"
 )
 
+// logMaxSize and logMaxAge hold the log rotation flags, in megabytes and days.
+var logMaxSize, logMaxAge int
+
+// logRotation returns the log rotation requested by the root command's flags.
+func logRotation() log.Rotation {
+	return log.NewRotation(logMaxSize, logMaxAge)
+}
+
 func BuildRootCommand() *cobra.Command {
 	var shouldPrintVersion bool
 	var format string
@@ -80,6 +89,8 @@ func BuildRootCommand() *cobra.Command {
 
 	root.Flags().BoolVarP(&shouldPrintVersion, "version", "V", false, "prints version")
 	root.Flags().StringVar(&format, "format", "", `specify the output format as either "multiline", "oneline", or "json". Default is multiline.`)
+	root.PersistentFlags().IntVar(&logMaxSize, "log-max-size", 0, "rotate the hub and agent log files once they exceed this many megabytes; 0 disables rotation")
+	root.PersistentFlags().IntVar(&logMaxAge, "log-max-age", 0, "remove hub and agent log files older than this many days once rotated; 0 keeps them")
 
 	root.AddCommand(config)
 	root.AddCommand(version())
@@ -89,6 +100,8 @@ func BuildRootCommand() *cobra.Command {
 	root.AddCommand(revert())
 	root.AddCommand(restartServices)
 	root.AddCommand(killServices)
+	root.AddCommand(confirm())
+	root.AddCommand(abort())
 	root.AddCommand(Agent())
 	root.AddCommand(Hub())
 
@@ -190,7 +203,7 @@ var restartServices = &cobra.Command{
 		}
 
 		if !running {
-			err = commanders.StartHub()
+			err = commanders.StartHub(logRotation())
 			if err != nil {
 				return err
 			}
@@ -215,6 +228,62 @@ var restartServices = &cobra.Command{
 	},
 }
 
+func confirm() *cobra.Command {
+	return &cobra.Command{
+		Use:   "confirm <substep>",
+		Short: "lets a substep waiting for confirmation run",
+		Long:  "Lets a substep given to --confirm-substeps, which is waiting for confirmation, run.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			substeps, err := parseSubsteps(args)
+			if err != nil {
+				return err
+			}
+
+			client, err := connectToHub()
+			if err != nil {
+				return err
+			}
+
+			_, err = client.Confirm(context.Background(), &idl.ConfirmRequest{Substep: substeps[0]})
+			if err != nil {
+				return xerrors.Errorf("confirming %s: %w", args[0], err)
+			}
+
+			fmt.Printf("Confirmed %s\n", args[0])
+			return nil
+		},
+	}
+}
+
+func abort() *cobra.Command {
+	return &cobra.Command{
+		Use:   "abort <substep>",
+		Short: "fails a substep waiting for confirmation without running it",
+		Long:  "Fails a substep given to --confirm-substeps, which is waiting for confirmation, without running it.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			substeps, err := parseSubsteps(args)
+			if err != nil {
+				return err
+			}
+
+			client, err := connectToHub()
+			if err != nil {
+				return err
+			}
+
+			_, err = client.Abort(context.Background(), &idl.AbortRequest{Substep: substeps[0]})
+			if err != nil {
+				return xerrors.Errorf("aborting %s: %w", args[0], err)
+			}
+
+			fmt.Printf("Aborted %s\n", args[0])
+			return nil
+		},
+	}
+}
+
 var killServices = &cobra.Command{
 	Use:   "kill-services",
 	Short: "Abruptly stops the hub and agents that are currently running.",
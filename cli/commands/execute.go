@@ -4,20 +4,27 @@
 package commands
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
 
 	"github.com/greenplum-db/gpupgrade/cli/commanders"
+	"github.com/greenplum-db/gpupgrade/hub"
 	"github.com/greenplum-db/gpupgrade/idl"
 	"github.com/greenplum-db/gpupgrade/step"
+	"github.com/greenplum-db/gpupgrade/upgrade"
 	"github.com/greenplum-db/gpupgrade/utils"
 )
 
 func execute() *cobra.Command {
 	var verbose bool
 	var nonInteractive bool
+	var plan bool
 
 	cmd := &cobra.Command{
 		Use:   "execute",
@@ -25,6 +32,11 @@ func execute() *cobra.Command {
 		Long:  ExecuteHelp,
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			cmd.SilenceUsage = true
+
+			if plan {
+				return printUpgradePlan(os.Stdout, upgrade.GetConfigFile(), utils.GetStateDir())
+			}
+
 			var response idl.ExecuteResponse
 
 			logdir, err := utils.GetLogDir()
@@ -87,6 +99,33 @@ To return the cluster to its original state, run "gpupgrade revert".`,
 	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "print the output stream from all substeps")
 	cmd.Flags().BoolVar(&nonInteractive, "non-interactive", false, "do not prompt for confirmation to proceed")
 	cmd.Flags().MarkHidden("non-interactive") //nolint
+	cmd.Flags().BoolVar(&plan, "plan", false, "print the actions execute and finalize will take on each host as JSON without running them")
 
 	return addHelpToCommand(cmd, ExecuteHelp)
 }
+
+// printUpgradePlan writes the plan of the execute and finalize phases as JSON,
+// computed from the hub configuration written by initialize.
+func printUpgradePlan(w io.Writer, configFile string, stateDir string) error {
+	conf := &hub.Config{}
+	if err := hub.LoadConfig(conf, configFile); err != nil {
+		return xerrors.Errorf("loading hub configuration: %w", err)
+	}
+
+	if conf.Source == nil || conf.Target == nil {
+		return errors.New(`the target cluster has not been created; run "gpupgrade initialize" first`)
+	}
+
+	plan, err := hub.NewUpgradePlan(conf, stateDir)
+	if err != nil {
+		return xerrors.Errorf("computing upgrade plan: %w", err)
+	}
+
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return xerrors.Errorf("marshaling upgrade plan: %w", err)
+	}
+
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/greenplum-db/gp-common-go-libs/dbconn"
+
+	"github.com/greenplum-db/gpupgrade/greenplum"
+	"github.com/greenplum-db/gpupgrade/hub"
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/upgrade"
+)
+
+func TestPrintUpgradePlan(t *testing.T) {
+	stateDir := testutils.GetTempDir(t, "")
+	defer testutils.MustRemoveAll(t, stateDir)
+
+	resetEnv := testutils.SetEnv(t, "GPUPGRADE_HOME", stateDir)
+	defer resetEnv()
+
+	configFile := upgrade.GetConfigFile()
+
+	t.Run("prints the execute and finalize plans as JSON", func(t *testing.T) {
+		source, err := greenplum.NewCluster([]greenplum.SegConfig{
+			{ContentID: -1, DbID: 1, Port: 15432, Hostname: "mdw", DataDir: "/data/qddir/seg-1", Role: greenplum.PrimaryRole},
+			{ContentID: 0, DbID: 2, Port: 25433, Hostname: "sdw1", DataDir: "/data/dbfast1/seg1", Role: greenplum.PrimaryRole},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		source.Version = dbconn.NewVersion("5.28.0")
+
+		target, err := greenplum.NewCluster([]greenplum.SegConfig{
+			{ContentID: -1, DbID: 1, Port: 15433, Hostname: "mdw", DataDir: "/data/qddir/seg-1_123ABC-1", Role: greenplum.PrimaryRole},
+			{ContentID: 0, DbID: 2, Port: 25435, Hostname: "sdw1", DataDir: "/data/dbfast1/seg1_123ABC", Role: greenplum.PrimaryRole},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		target.Version = dbconn.NewVersion("6.1.0")
+
+		conf := &hub.Config{
+			Source: source,
+			Target: target,
+			TargetInitializeConfig: hub.InitializeConfig{
+				Master:    target.Primaries[-1],
+				Primaries: []greenplum.SegConfig{target.Primaries[0]},
+			},
+		}
+
+		if err := hub.New(conf, nil, stateDir).SaveConfig(); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		var out bytes.Buffer
+		if err := printUpgradePlan(&out, configFile, stateDir); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		loaded := &hub.Config{}
+		if err := hub.LoadConfig(loaded, configFile); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		expected, err := hub.NewUpgradePlan(loaded, stateDir)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		var printed hub.UpgradePlan
+		if err := json.Unmarshal(out.Bytes(), &printed); err != nil {
+			t.Fatalf("unmarshaling plan %q: %v", out.String(), err)
+		}
+
+		if !reflect.DeepEqual(&printed, expected) {
+			t.Errorf("got plan %+v want %+v", printed, expected)
+		}
+	})
+
+	t.Run("errors before initialize has created the target cluster", func(t *testing.T) {
+		if err := hub.New(&hub.Config{Port: 12345}, nil, stateDir).SaveConfig(); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		var out bytes.Buffer
+		err := printUpgradePlan(&out, configFile, stateDir)
+		if err == nil {
+			t.Errorf("expected an error")
+		}
+
+		if out.Len() != 0 {
+			t.Errorf("expected nothing to be printed, got %q", out.String())
+		}
+	})
+}
@@ -94,7 +94,7 @@ NEXT ACTIONS
 Run the “post-finalize” data migration scripts, and recreate any additional tables,
 indexes, and roles that were dropped or altered to resolve migration issues.`,
 				response.GetTargetVersion(), response.GetTarget().GetPort(), response.GetTarget().GetMasterDataDirectory(),
-				fmt.Sprintf("%s.<contentID>%s", response.GetUpgradeID(), upgrade.OldSuffix),
+				fmt.Sprintf("%s.<contentID>%s", response.GetUpgradeID(), upgrade.ArchiveSuffix()),
 				response.GetArchivedSourceMasterDataDirectory(),
 				response.GetLogArchiveDirectory()))
 		},
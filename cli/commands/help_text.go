@@ -113,6 +113,8 @@ Usage: gpupgrade execute
 Optional Flags:
 
   -h, --help      displays help output for execute
+      --plan      prints the actions execute and finalize will take on each
+                  host as JSON, without running them
   -v, --verbose   outputs detailed logs for execute
 
 gpupgrade log files can be found on all hosts in %s
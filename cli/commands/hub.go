@@ -7,21 +7,35 @@ import (
 	"fmt"
 	"os"
 	"runtime/debug"
+	"strings"
+	"time"
 
-	"github.com/greenplum-db/gp-common-go-libs/gplog"
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
 
 	"github.com/greenplum-db/gpupgrade/hub"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/step"
 	"github.com/greenplum-db/gpupgrade/upgrade"
 	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/certs"
 	"github.com/greenplum-db/gpupgrade/utils/daemon"
 	"github.com/greenplum-db/gpupgrade/utils/log"
+	"github.com/greenplum-db/gpupgrade/utils/metrics"
 )
 
 func Hub() *cobra.Command {
 	var port int
+	var logFormat string
 	var shouldDaemonize bool
+	var agentTLS bool
+	var agentTLSVerifyClient bool
+	var stepBudget time.Duration
+	var confirmSubsteps []string
+	var confirmTimeout time.Duration
+	var metricsAddr string
+	var agentTimeout time.Duration
+	var copyRateLimit int64
 
 	var cmd = &cobra.Command{
 		Use:    "hub",
@@ -34,11 +48,17 @@ func Hub() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			gplog.InitializeLogging("gpupgrade_hub", logdir)
+			if err := log.InitializeWithRotation("gpupgrade_hub", logdir, logFormat, logRotation()); err != nil {
+				return err
+			}
 			debug.SetTraceback("all")
 			defer log.WritePanics()
 
 			stateDir := utils.GetStateDir()
+			if err := utils.ValidateStateDir(stateDir); err != nil {
+				return err
+			}
+
 			finfo, err := os.Stat(stateDir)
 			if os.IsNotExist(err) {
 				return fmt.Errorf("gpupgrade state dir (%s) does not exist. Did you run gpupgrade initialize?", stateDir)
@@ -68,7 +88,56 @@ func Hub() *cobra.Command {
 				conf.Port = port
 			}
 
+			if agentTLS || agentTLSVerifyClient {
+				tlsConf := certs.StateDirConfig(stateDir)
+				tlsConf.VerifyClient = agentTLSVerifyClient
+				conf.AgentTLS = &tlsConf
+			}
+
+			if cmd.Flag("confirm-substeps").Changed {
+				conf.ConfirmSubsteps, err = parseSubsteps(confirmSubsteps)
+				if err != nil {
+					return err
+				}
+			}
+
+			if cmd.Flag("confirm-timeout").Changed {
+				conf.ConfirmTimeout = confirmTimeout
+			}
+
+			if cmd.Flag("step-budget").Changed {
+				conf.StepBudget = stepBudget
+			}
+
+			if cmd.Flag("metrics-addr").Changed {
+				conf.MetricsAddr = metricsAddr
+			}
+
+			if cmd.Flag("agent-timeout").Changed {
+				conf.AgentTimeout = agentTimeout
+			}
+
+			if cmd.Flag("copy-rate-limit").Changed {
+				conf.CopyRateLimit = copyRateLimit
+			}
+
+			// Fail now rather than on first connecting to the agents.
+			if conf.AgentTLS != nil {
+				if _, err := certs.ClientCredentials(*conf.AgentTLS); err != nil {
+					return err
+				}
+			}
+
 			h := hub.New(conf, grpc.DialContext, stateDir)
+			h.Version = VersionString("oneline")
+
+			h.LogRotation = logRotation()
+
+			if conf.MetricsAddr != "" {
+				if _, err := metrics.Serve(conf.MetricsAddr); err != nil {
+					return err
+				}
+			}
 
 			if shouldDaemonize {
 				h.MakeDaemon()
@@ -84,8 +153,33 @@ func Hub() *cobra.Command {
 	}
 
 	cmd.Flags().IntVar(&port, "port", upgrade.DefaultHubPort, "the port to listen for commands on")
+	cmd.Flags().StringVar(&logFormat, "log-format", log.TextFormat, "the format of log output, either text or json")
+	cmd.Flags().BoolVar(&agentTLS, "agent-tls", false, "connect to agents with TLS using the certificates in the state directory")
+	cmd.Flags().BoolVar(&agentTLSVerifyClient, "agent-tls-verify-client", false, "start agents requiring the hub to present its certificate, implies --agent-tls")
+	cmd.Flags().DurationVar(&stepBudget, "step-budget", 0, "the total time a step may wait on agents before it is aborted, such as 6h; 0 is unlimited")
+	cmd.Flags().StringSliceVar(&confirmSubsteps, "confirm-substeps", nil, "substeps, such as delete_tablespaces, that wait for the operator to confirm or abort them before starting")
+	cmd.Flags().DurationVar(&confirmTimeout, "confirm-timeout", step.DefaultConfirmationTimeout, "how long a substep waits to be confirmed before it is aborted")
+
+	cmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "the address to serve Prometheus metrics on at /metrics, such as :9527; unset disables metrics")
+	cmd.Flags().DurationVar(&agentTimeout, "agent-timeout", hub.DefaultAgentTimeout, "how long to wait on an agent request, other than long-running ones such as pg_upgrade, before failing the step")
+	cmd.Flags().Int64Var(&copyRateLimit, "copy-rate-limit", 0, "the maximum rate, in bytes per second, at which data directories are copied when archiving them across filesystems; 0 is unlimited")
 
 	daemon.MakeDaemonizable(cmd, &shouldDaemonize)
 
 	return cmd
 }
+
+// parseSubsteps converts substep names, in any case, to substeps.
+func parseSubsteps(names []string) ([]idl.Substep, error) {
+	var substeps []idl.Substep
+	for _, name := range names {
+		value, ok := idl.Substep_value[strings.ToUpper(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown substep %q", name)
+		}
+
+		substeps = append(substeps, idl.Substep(value))
+	}
+
+	return substeps, nil
+}
@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -18,10 +19,12 @@ import (
 	"github.com/greenplum-db/gpupgrade/cli"
 	"github.com/greenplum-db/gpupgrade/cli/commanders"
 	"github.com/greenplum-db/gpupgrade/greenplum"
+	"github.com/greenplum-db/gpupgrade/hub"
 	"github.com/greenplum-db/gpupgrade/idl"
 	"github.com/greenplum-db/gpupgrade/step"
 	"github.com/greenplum-db/gpupgrade/upgrade"
 	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/certs"
 	"github.com/greenplum-db/gpupgrade/utils/errorlist"
 )
 
@@ -50,6 +53,14 @@ func initialize() *cobra.Command {
 	var ports string
 	var mode string
 	var useHbaHostnames bool
+	var agentTLS bool
+	var agentTLSVerifyClient bool
+	var confirmSubsteps []string
+	var confirmTimeout time.Duration
+	var stepBudget time.Duration
+	var metricsAddr string
+	var agentTimeout time.Duration
+	var copyRateLimit int64
 
 	subInit := &cobra.Command{
 		Use:   "initialize",
@@ -146,6 +157,11 @@ func initialize() *cobra.Command {
 				return err
 			}
 
+			substeps, err := parseSubsteps(confirmSubsteps)
+			if err != nil {
+				return err
+			}
+
 			logdir, err := utils.GetLogDir()
 			if err != nil {
 				return err
@@ -201,12 +217,27 @@ func initialize() *cobra.Command {
 				return nil
 			})
 
+			hubConf := commanders.HubConfig{
+				Port:            hubPort,
+				ConfirmSubsteps: substeps,
+				ConfirmTimeout:  confirmTimeout,
+				StepBudget:      stepBudget,
+				MetricsAddr:     metricsAddr,
+				AgentTimeout:    agentTimeout,
+				CopyRateLimit:   copyRateLimit,
+			}
+			if agentTLS || agentTLSVerifyClient {
+				tlsConf := certs.StateDirConfig(utils.GetStateDir())
+				tlsConf.VerifyClient = agentTLSVerifyClient
+				hubConf.AgentTLS = &tlsConf
+			}
+
 			st.RunInternalSubstep(func() error {
-				return commanders.CreateInitialClusterConfigs(hubPort)
+				return commanders.CreateInitialClusterConfigs(hubConf)
 			})
 
 			st.RunCLISubstep(idl.Substep_START_HUB, func(streams step.OutStreams) error {
-				return commanders.StartHub()
+				return commanders.StartHub(logRotation())
 			})
 
 			var client idl.CliToHubClient
@@ -277,6 +308,14 @@ To return the cluster to its original state, run "gpupgrade revert".`,
 	subInit.Flags().StringVar(&ports, "temp-port-range", "50432-65535", "set of ports to use when initializing the target cluster")
 	subInit.Flags().StringVar(&mode, "mode", "copy", "performs upgrade in either copy or link mode. Default is copy.")
 	subInit.Flags().BoolVar(&useHbaHostnames, "use-hba-hostnames", false, "use hostnames in pg_hba.conf")
+	subInit.Flags().BoolVar(&agentTLS, "agent-tls", false, "connect to agents with TLS using the certificates in the state directory")
+	subInit.Flags().BoolVar(&agentTLSVerifyClient, "agent-tls-verify-client", false, "start agents requiring the hub to present its certificate, implies --agent-tls")
+	subInit.Flags().StringSliceVar(&confirmSubsteps, "confirm-substeps", nil, `substeps, such as delete_tablespaces, that wait for "gpupgrade confirm" or "gpupgrade abort" before starting`)
+	subInit.Flags().DurationVar(&confirmTimeout, "confirm-timeout", step.DefaultConfirmationTimeout, "how long a substep waits to be confirmed before it is aborted")
+	subInit.Flags().DurationVar(&stepBudget, "step-budget", 0, "the total time a step may wait on agents before it is aborted, such as 6h; 0 is unlimited")
+	subInit.Flags().StringVar(&metricsAddr, "metrics-addr", "", "the address the hub serves Prometheus metrics on at /metrics, such as :9527; unset disables metrics")
+	subInit.Flags().DurationVar(&agentTimeout, "agent-timeout", hub.DefaultAgentTimeout, "how long the hub waits on an agent request, other than long-running ones such as pg_upgrade, before failing the step")
+	subInit.Flags().Int64Var(&copyRateLimit, "copy-rate-limit", 0, "the maximum rate, in bytes per second, at which data directories are copied when archiving them across filesystems; 0 is unlimited")
 	subInit.Flags().BoolVar(&skipVersionCheck, "skip-version-check", false, "disable source and target version check")
 	subInit.Flags().MarkHidden("skip-version-check") //nolint
 	return addHelpToCommand(subInit, InitializeHelp)
@@ -8,7 +8,6 @@ require (
 	github.com/cloudfoundry/gosigar v1.1.0
 	github.com/golang/mock v1.4.4
 	github.com/golang/protobuf v1.3.2
-	github.com/google/renameio v0.1.0
 	github.com/greenplum-db/gp-common-go-libs v1.0.4
 	github.com/jackc/pgx v3.2.0+incompatible
 	github.com/kballard/go-shellquote v0.0.0-20180428030007-95032a82bc51
@@ -294,7 +294,17 @@ func (c *Cluster) GetDirForContent(contentID int) string {
 }
 
 func (c *Cluster) Start(stream step.OutStreams) error {
-	return runStartStopCmd(stream, c.GPHome, fmt.Sprintf("gpstart -a -d %[1]s", c.MasterDataDir()), fmt.Sprintf("MASTER_DATA_DIRECTORY=%s", c.MasterDataDir()))
+	return runStartStopCmd(stream, c.StartCommand())
+}
+
+// StartCommand returns the bash script Start runs.
+func (c *Cluster) StartCommand() string {
+	return startStopCommand(c.GPHome, fmt.Sprintf("gpstart -a -d %[1]s", c.MasterDataDir()), fmt.Sprintf("MASTER_DATA_DIRECTORY=%s", c.MasterDataDir()))
+}
+
+// StopCommand returns the bash script Stop runs.
+func (c *Cluster) StopCommand() string {
+	return startStopCommand(c.GPHome, fmt.Sprintf("gpstop -a -d %[1]s", c.MasterDataDir()), fmt.Sprintf("MASTER_DATA_DIRECTORY=%s", c.MasterDataDir()))
 }
 
 func (c *Cluster) Stop(stream step.OutStreams) error {
@@ -311,11 +321,11 @@ func (c *Cluster) Stop(stream step.OutStreams) error {
 		return errors.New("master is already stopped")
 	}
 
-	return runStartStopCmd(stream, c.GPHome, fmt.Sprintf("gpstop -a -d %[1]s", c.MasterDataDir()), fmt.Sprintf("MASTER_DATA_DIRECTORY=%s", c.MasterDataDir()))
+	return runStartStopCmd(stream, c.StopCommand())
 }
 
 func (c *Cluster) StartMasterOnly(stream step.OutStreams) error {
-	return runStartStopCmd(stream, c.GPHome, fmt.Sprintf("gpstart -m -a -d %[1]s", c.MasterDataDir()), fmt.Sprintf("MASTER_DATA_DIRECTORY=%s", c.MasterDataDir()))
+	return runStartStopCmd(stream, startStopCommand(c.GPHome, fmt.Sprintf("gpstart -m -a -d %[1]s", c.MasterDataDir()), fmt.Sprintf("MASTER_DATA_DIRECTORY=%s", c.MasterDataDir())))
 }
 
 func (c *Cluster) StopMasterOnly(stream step.OutStreams) error {
@@ -332,15 +342,17 @@ func (c *Cluster) StopMasterOnly(stream step.OutStreams) error {
 		return errors.New("master is already stopped")
 	}
 
-	return runStartStopCmd(stream, c.GPHome, fmt.Sprintf("gpstop -m -a -d %[1]s", c.MasterDataDir()), fmt.Sprintf("MASTER_DATA_DIRECTORY=%s", c.MasterDataDir()))
+	return runStartStopCmd(stream, startStopCommand(c.GPHome, fmt.Sprintf("gpstop -m -a -d %[1]s", c.MasterDataDir()), fmt.Sprintf("MASTER_DATA_DIRECTORY=%s", c.MasterDataDir())))
 }
 
-func runStartStopCmd(stream step.OutStreams, gphome, command string, env string) error {
-	commandWithEnv := fmt.Sprintf("source %[1]s/greenplum_path.sh && %[2]s %[1]s/bin/%[3]s",
+func startStopCommand(gphome, command string, env string) string {
+	return fmt.Sprintf("source %[1]s/greenplum_path.sh && %[2]s %[1]s/bin/%[3]s",
 		gphome,
 		env,
 		command)
+}
 
+func runStartStopCmd(stream step.OutStreams, commandWithEnv string) error {
 	cmd := execCommand("bash", "-c", commandWithEnv)
 	gplog.Info("running command: %q", cmd)
 	cmd.Stdout = stream.Stdout()
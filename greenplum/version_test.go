@@ -30,6 +30,10 @@ func PostgresGPVersion_6_7_1() {
 	fmt.Println("postgres (Greenplum Database) 6.7.1 build commit:a21de286045072d8d1df64fa48752b7dfac8c1b7")
 }
 
+func PostgresGPVersion_7_0_0_alpha() {
+	fmt.Println("postgres (Greenplum Database) 7.0.0-alpha.0+dev.14495.g59b2a1cb65 build dev")
+}
+
 func PostgresGPVersion_11_341_31() {
 	fmt.Println("postgres (Greenplum Database) 11.341.31 build commit:a21de286045072d8d1df64fa48752b7dfac8c1b7")
 }
@@ -39,6 +43,7 @@ func init() {
 		PostgresGPVersion_5_27_0_beta,
 		PostgresGPVersion_6_dev,
 		PostgresGPVersion_6_7_1,
+		PostgresGPVersion_7_0_0_alpha,
 		PostgresGPVersion_11_341_31,
 	)
 	postgresPath = filepath.Join(gphome, "bin", "postgres")
@@ -59,6 +64,7 @@ func TestGPHomeVersion(t *testing.T) {
 		{"handles development versions", PostgresGPVersion_6_dev, "6.0.0"},
 		{"handles beta versions", PostgresGPVersion_5_27_0_beta, "5.27.0"},
 		{"handles release versions", PostgresGPVersion_6_7_1, "6.7.1"},
+		{"handles 7X development versions", PostgresGPVersion_7_0_0_alpha, "7.0.0"},
 		{"handles large versions", PostgresGPVersion_11_341_31, "11.341.31"},
 	}
 
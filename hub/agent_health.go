@@ -0,0 +1,76 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/utils/errorlist"
+)
+
+// ErrAgentUnhealthy is returned by EnsureAgentsHealthy when an agent reports
+// that it cannot use its state directory.
+var ErrAgentUnhealthy = errors.New("agent is unhealthy")
+
+// AgentUnhealthyError is the backing error type for ErrAgentUnhealthy.
+type AgentUnhealthyError struct {
+	Host     string
+	StateDir string
+	Reason   string
+}
+
+func (a *AgentUnhealthyError) Error() string {
+	return fmt.Sprintf("agent on host %q is unhealthy: %s", a.Host, a.Reason)
+}
+
+func (a *AgentUnhealthyError) Is(err error) bool {
+	return err == ErrAgentUnhealthy
+}
+
+// EnsureAgentsHealthy calls Healthz on every agent, so that a host whose
+// state directory is unwritable fails when first connecting rather than deep
+// into an operation. Every unhealthy or unreachable agent is returned, ordered
+// by host.
+func EnsureAgentsHealthy(conns []*Connection) error {
+	conns = append([]*Connection(nil), conns...)
+	sort.Slice(conns, func(i, j int) bool {
+		return conns[i].Hostname < conns[j].Hostname
+	})
+
+	errs := make([]error, len(conns))
+
+	var wg sync.WaitGroup
+	for i, conn := range conns {
+		i, conn := i, conn
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			reply, err := conn.AgentClient.Healthz(context.Background(), &idl.HealthzRequest{})
+			switch {
+			case err != nil:
+				errs[i] = xerrors.Errorf("agent health on host %q: %w", conn.Hostname, err)
+			case !reply.GetHealthy():
+				errs[i] = &AgentUnhealthyError{Host: conn.Hostname, StateDir: reply.GetStateDir(), Reason: reply.GetReason()}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	var mErr error
+	for _, err := range errs {
+		mErr = errorlist.Append(mErr, err)
+	}
+
+	return mErr
+}
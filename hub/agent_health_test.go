@@ -0,0 +1,98 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/greenplum-db/gpupgrade/hub"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/idl/mock_idl"
+	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+	"github.com/greenplum-db/gpupgrade/utils/errorlist"
+)
+
+func TestEnsureAgentsHealthy(t *testing.T) {
+	testlog.SetupLogger()
+
+	t.Run("succeeds when all agents are healthy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		sdw1 := mock_idl.NewMockAgentClient(ctrl)
+		sdw1.EXPECT().Healthz(gomock.Any(), &idl.HealthzRequest{}).
+			Return(&idl.HealthzReply{Healthy: true}, nil)
+
+		sdw2 := mock_idl.NewMockAgentClient(ctrl)
+		sdw2.EXPECT().Healthz(gomock.Any(), &idl.HealthzRequest{}).
+			Return(&idl.HealthzReply{Healthy: true}, nil)
+
+		conns := []*hub.Connection{
+			{nil, sdw1, "sdw1", nil},
+			{nil, sdw2, "sdw2", nil},
+		}
+
+		err := hub.EnsureAgentsHealthy(conns)
+		if err != nil {
+			t.Errorf("unexpected error %#v", err)
+		}
+	})
+
+	t.Run("reports every unhealthy or unreachable agent ordered by host", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		sdw1 := mock_idl.NewMockAgentClient(ctrl)
+		sdw1.EXPECT().Healthz(gomock.Any(), gomock.Any()).
+			Return(&idl.HealthzReply{Healthy: true}, nil)
+
+		sdw2 := mock_idl.NewMockAgentClient(ctrl)
+		sdw2.EXPECT().Healthz(gomock.Any(), gomock.Any()).
+			Return(&idl.HealthzReply{Healthy: false, StateDir: "/home/gpadmin/.gpupgrade", Reason: "read-only file system"}, nil)
+
+		expected := errors.New("connection refused")
+		sdw3 := mock_idl.NewMockAgentClient(ctrl)
+		sdw3.EXPECT().Healthz(gomock.Any(), gomock.Any()).
+			Return(nil, expected)
+
+		conns := []*hub.Connection{
+			{nil, sdw3, "sdw3", nil},
+			{nil, sdw1, "sdw1", nil},
+			{nil, sdw2, "sdw2", nil},
+		}
+
+		err := hub.EnsureAgentsHealthy(conns)
+
+		var errs errorlist.Errors
+		if !errors.As(err, &errs) {
+			t.Fatalf("got error %#v want type %T", err, errs)
+		}
+
+		if len(errs) != 2 {
+			t.Fatalf("got %d errors want 2: %v", len(errs), errs)
+		}
+
+		var unhealthy *hub.AgentUnhealthyError
+		if !errors.As(errs[0], &unhealthy) {
+			t.Fatalf("got error %#v want type %T", errs[0], unhealthy)
+		}
+
+		expectedUnhealthy := &hub.AgentUnhealthyError{Host: "sdw2", StateDir: "/home/gpadmin/.gpupgrade", Reason: "read-only file system"}
+		if !reflect.DeepEqual(unhealthy, expectedUnhealthy) {
+			t.Errorf("got error %+v want %+v", unhealthy, expectedUnhealthy)
+		}
+
+		if !errors.Is(errs[0], hub.ErrAgentUnhealthy) {
+			t.Errorf("got error %#v want %#v", errs[0], hub.ErrAgentUnhealthy)
+		}
+
+		if !errors.Is(errs[1], expected) {
+			t.Errorf("got error %#v want %#v", errs[1], expected)
+		}
+	})
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"context"
+
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+)
+
+// agentVersions obtains the versions of the running agents over their
+// existing connections, as opposed to the installed binaries checked during
+// initialize.
+type agentVersions struct {
+	hubVersion string
+	conns      []*Connection
+}
+
+func NewAgentVersions(hubVersion string, conns []*Connection) ObtainVersions {
+	return &agentVersions{hubVersion: hubVersion, conns: conns}
+}
+
+func (a *agentVersions) Local() (string, error) {
+	return a.hubVersion, nil
+}
+
+func (a *agentVersions) Remote(host string) (string, error) {
+	for _, conn := range a.conns {
+		if conn.Hostname != host {
+			continue
+		}
+
+		reply, err := conn.AgentClient.Version(context.Background(), &idl.VersionRequest{})
+		if err != nil {
+			return "", xerrors.Errorf("agent version on host %q: %w", host, err)
+		}
+
+		return reply.GetVersion(), nil
+	}
+
+	return "", xerrors.Errorf("no agent connection for host %q", host)
+}
+
+func (a *agentVersions) Description() string {
+	return "running gpupgrade agent"
+}
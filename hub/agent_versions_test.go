@@ -0,0 +1,104 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/greenplum-db/gpupgrade/hub"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/idl/mock_idl"
+	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+)
+
+func TestAgentVersions(t *testing.T) {
+	testlog.SetupLogger()
+
+	const hubVersion = "Version: 1.2.3 Commit: 5889c19 Release: Enterprise"
+
+	t.Run("succeeds when all running agents match the hub", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		sdw1 := mock_idl.NewMockAgentClient(ctrl)
+		sdw1.EXPECT().Version(gomock.Any(), &idl.VersionRequest{}).
+			Return(&idl.VersionReply{Version: hubVersion}, nil)
+
+		sdw2 := mock_idl.NewMockAgentClient(ctrl)
+		sdw2.EXPECT().Version(gomock.Any(), &idl.VersionRequest{}).
+			Return(&idl.VersionReply{Version: hubVersion}, nil)
+
+		conns := []*hub.Connection{
+			{nil, sdw1, "sdw1", nil},
+			{nil, sdw2, "sdw2", nil},
+		}
+
+		err := hub.EnsureVersionsMatch([]string{"sdw1", "sdw2"}, hub.NewAgentVersions(hubVersion, conns))
+		if err != nil {
+			t.Errorf("unexpected error %#v", err)
+		}
+	})
+
+	t.Run("reports the hosts running a mismatched agent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		sdw1 := mock_idl.NewMockAgentClient(ctrl)
+		sdw1.EXPECT().Version(gomock.Any(), gomock.Any()).
+			Return(&idl.VersionReply{Version: hubVersion}, nil)
+
+		sdw2 := mock_idl.NewMockAgentClient(ctrl)
+		sdw2.EXPECT().Version(gomock.Any(), gomock.Any()).
+			Return(&idl.VersionReply{Version: "Version: 1.0.0 Commit: 2d3e1f0 Release: Enterprise"}, nil)
+
+		conns := []*hub.Connection{
+			{nil, sdw1, "sdw1", nil},
+			{nil, sdw2, "sdw2", nil},
+		}
+
+		err := hub.EnsureVersionsMatch([]string{"sdw1", "sdw2"}, hub.NewAgentVersions(hubVersion, conns))
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+
+		expected := `"Version: 1.0.0 Commit: 2d3e1f0 Release: Enterprise": sdw2`
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error %q to contain %q", err.Error(), expected)
+		}
+
+		if strings.Contains(err.Error(), "sdw1") {
+			t.Errorf("expected error %q to not contain matching host sdw1", err.Error())
+		}
+	})
+
+	t.Run("errors when an agent fails to report its version", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expected := errors.New("unimplemented")
+		sdw1 := mock_idl.NewMockAgentClient(ctrl)
+		sdw1.EXPECT().Version(gomock.Any(), gomock.Any()).
+			Return(nil, expected)
+
+		conns := []*hub.Connection{
+			{nil, sdw1, "sdw1", nil},
+		}
+
+		err := hub.EnsureVersionsMatch([]string{"sdw1"}, hub.NewAgentVersions(hubVersion, conns))
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+	})
+
+	t.Run("errors when there is no connection to a host", func(t *testing.T) {
+		err := hub.EnsureVersionsMatch([]string{"sdw1"}, hub.NewAgentVersions(hubVersion, nil))
+		if err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
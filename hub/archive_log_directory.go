@@ -9,17 +9,22 @@ import (
 	"github.com/greenplum-db/gpupgrade/idl"
 )
 
-func ArchiveSegmentLogDirectories(agentConns []*Connection, excludeHostname, newDir string) error {
-	request := func(conn *Connection) error {
+func ArchiveSegmentLogDirectories(ctx context.Context, agentConns []*Connection, excludeHostname, newDir string) error {
+	token := NewIdempotencyToken()
+	request := func(ctx context.Context, conn *Connection) error {
 		if conn.Hostname == excludeHostname {
 			return nil
 		}
 
-		_, err := conn.AgentClient.ArchiveLogDirectory(context.Background(), &idl.ArchiveLogDirectoryRequest{
-			NewDir: newDir,
+		req := &idl.ArchiveLogDirectoryRequest{
+			NewDir:           newDir,
+			IdempotencyToken: token,
+		}
+		return retryAgentRequest(ctx, conn.Hostname, func(ctx context.Context) error {
+			_, err := conn.AgentClient.ArchiveLogDirectory(ctx, req)
+			return err
 		})
-		return err
 	}
 
-	return ExecuteRPC(agentConns, request)
+	return ExecuteRPCContext(ctx, agentConns, request)
 }
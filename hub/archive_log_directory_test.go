@@ -4,6 +4,7 @@
 package hub_test
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -27,14 +28,14 @@ func TestArchiveLogDirectories(t *testing.T) {
 		sdwClient := mock_idl.NewMockAgentClient(ctrl)
 		sdwClient.EXPECT().ArchiveLogDirectory(
 			gomock.Any(),
-			&idl.ArchiveLogDirectoryRequest{NewDir: newDir},
+			&idl.ArchiveLogDirectoryRequest{NewDir: newDir, IdempotencyToken: testIdempotencyToken},
 		).Return(&idl.ArchiveLogDirectoryReply{}, nil).Times(1)
 
 		agentConns := []*hub.Connection{
 			{nil, sdwClient, "sdw", nil},
 		}
 
-		err := hub.ArchiveSegmentLogDirectories(agentConns, "", newDir)
+		err := hub.ArchiveSegmentLogDirectories(context.Background(), agentConns, "", newDir)
 		if err != nil {
 			t.Errorf("unexpected err %#v", err)
 		}
@@ -55,7 +56,7 @@ func TestArchiveLogDirectories(t *testing.T) {
 			{nil, failedClient, "sdw", nil},
 		}
 
-		err := hub.ArchiveSegmentLogDirectories(agentConns, "", newDir)
+		err := hub.ArchiveSegmentLogDirectories(context.Background(), agentConns, "", newDir)
 		if !errors.Is(err, expected) {
 			t.Errorf("got error %#v, want %#v", err, expected)
 		}
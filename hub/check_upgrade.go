@@ -4,6 +4,7 @@
 package hub
 
 import (
+	"context"
 	"sync"
 
 	"golang.org/x/xerrors"
@@ -14,7 +15,7 @@ import (
 
 type UpgradeChecker interface {
 	UpgradeMaster(args UpgradeMasterArgs) error
-	UpgradePrimaries(args UpgradePrimaryArgs) error
+	UpgradePrimaries(ctx context.Context, args UpgradePrimaryArgs) error
 }
 
 type upgradeChecker struct{}
@@ -23,13 +24,13 @@ func (upgradeChecker) UpgradeMaster(args UpgradeMasterArgs) error {
 	return UpgradeMaster(args)
 }
 
-func (upgradeChecker) UpgradePrimaries(args UpgradePrimaryArgs) error {
-	return UpgradePrimaries(args)
+func (upgradeChecker) UpgradePrimaries(ctx context.Context, args UpgradePrimaryArgs) error {
+	return UpgradePrimaries(ctx, args)
 }
 
 var upgrader UpgradeChecker = upgradeChecker{}
 
-func (s *Server) CheckUpgrade(stream step.OutStreams, conns []*Connection) error {
+func (s *Server) CheckUpgrade(ctx context.Context, stream step.OutStreams, conns []*Connection) error {
 	var wg sync.WaitGroup
 	checkErrs := make(chan error, 2)
 
@@ -56,7 +57,7 @@ func (s *Server) CheckUpgrade(stream step.OutStreams, conns []*Connection) error
 			return
 		}
 
-		checkErrs <- upgrader.UpgradePrimaries(UpgradePrimaryArgs{
+		checkErrs <- upgrader.UpgradePrimaries(ctx, UpgradePrimaryArgs{
 			CheckOnly:       true,
 			MasterBackupDir: "",
 			AgentConns:      conns,
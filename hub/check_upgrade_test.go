@@ -4,6 +4,7 @@
 package hub
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"testing"
@@ -21,7 +22,7 @@ func (u upgraderMock) UpgradeMaster(args UpgradeMasterArgs) error {
 	return UpgradeMasterMock(args, u.s)
 }
 
-func (u upgraderMock) UpgradePrimaries(args UpgradePrimaryArgs) error {
+func (u upgraderMock) UpgradePrimaries(ctx context.Context, args UpgradePrimaryArgs) error {
 	return UpgradePrimariesMock(args, u.s)
 }
 
@@ -60,7 +61,7 @@ func TestMasterIsCheckedLinkModeTrue(t *testing.T) {
 			setUpgrader(testUpgraderMock)
 			defer resetUpgrader()
 
-			err := s.CheckUpgrade(nil, connections)
+			err := s.CheckUpgrade(context.Background(), nil, connections)
 
 			if err != nil {
 				t.Errorf("got error: %+v", err) // yes, '%+v'; '%#v' prints opaque multiple errors
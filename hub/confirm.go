@@ -0,0 +1,42 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"context"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/step"
+)
+
+// Confirm lets a substep listed in ConfirmSubsteps, which is waiting for the
+// operator, run.
+func (s *Server) Confirm(_ context.Context, in *idl.ConfirmRequest) (*idl.ConfirmReply, error) {
+	if err := s.confirmations.Confirm(in.GetSubstep()); err != nil {
+		return nil, err
+	}
+
+	return &idl.ConfirmReply{}, nil
+}
+
+// Abort fails a substep listed in ConfirmSubsteps, which is waiting for the
+// operator, without running it.
+func (s *Server) Abort(_ context.Context, in *idl.AbortRequest) (*idl.AbortReply, error) {
+	if err := s.confirmations.Abort(in.GetSubstep()); err != nil {
+		return nil, err
+	}
+
+	return &idl.AbortReply{}, nil
+}
+
+// beginStep begins a step whose ConfirmSubsteps wait for the operator.
+func (s *Server) beginStep(name idl.Step, sender idl.MessageSender) (*step.Step, error) {
+	st, err := step.Begin(name, sender)
+	if err != nil {
+		return nil, err
+	}
+
+	st.RequireConfirmation(s.confirmations, s.ConfirmTimeout, s.ConfirmSubsteps...)
+	return st, nil
+}
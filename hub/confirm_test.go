@@ -0,0 +1,107 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/step"
+)
+
+func TestConfirmAndAbort(t *testing.T) {
+	substep := idl.Substep_DELETE_TABLESPACES
+
+	// wait waits on the server's gate for substep, and returns the result of
+	// the wait once substep is pending.
+	wait := func(t *testing.T, s *Server) chan error {
+		t.Helper()
+
+		result := make(chan error, 1)
+		go func() {
+			result <- s.confirmations.Wait(substep, time.Minute)
+		}()
+
+		for !s.confirmations.Pending(substep) {
+			time.Sleep(time.Millisecond)
+		}
+
+		return result
+	}
+
+	t.Run("Confirm lets the waiting substep run", func(t *testing.T) {
+		s := New(&Config{}, nil, "")
+		result := wait(t, s)
+
+		_, err := s.Confirm(context.Background(), &idl.ConfirmRequest{Substep: substep})
+		if err != nil {
+			t.Fatalf("unexpected error: %+v", err)
+		}
+
+		if err := <-result; err != nil {
+			t.Errorf("unexpected error: %+v", err)
+		}
+	})
+
+	t.Run("Abort fails the waiting substep", func(t *testing.T) {
+		s := New(&Config{}, nil, "")
+		result := wait(t, s)
+
+		_, err := s.Abort(context.Background(), &idl.AbortRequest{Substep: substep})
+		if err != nil {
+			t.Fatalf("unexpected error: %+v", err)
+		}
+
+		err = <-result
+
+		var aborted *step.AbortedError
+		if !errors.As(err, &aborted) {
+			t.Fatalf("got error %#v want type %T", err, aborted)
+		}
+
+		if aborted.TimedOut {
+			t.Error("expected substep to have been aborted rather than timed out")
+		}
+	})
+
+	t.Run("errors when the substep is not waiting", func(t *testing.T) {
+		s := New(&Config{}, nil, "")
+
+		_, err := s.Confirm(context.Background(), &idl.ConfirmRequest{Substep: substep})
+		if !errors.Is(err, step.ErrNoPendingConfirmation) {
+			t.Errorf("got error %#v want %#v", err, step.ErrNoPendingConfirmation)
+		}
+
+		_, err = s.Abort(context.Background(), &idl.AbortRequest{Substep: substep})
+		if !errors.Is(err, step.ErrNoPendingConfirmation) {
+			t.Errorf("got error %#v want %#v", err, step.ErrNoPendingConfirmation)
+		}
+	})
+
+	t.Run("only the substep named is decided", func(t *testing.T) {
+		s := New(&Config{}, nil, "")
+		result := wait(t, s)
+
+		_, err := s.Confirm(context.Background(), &idl.ConfirmRequest{Substep: idl.Substep_DELETE_TARGET_CLUSTER_DATADIRS})
+		if !errors.Is(err, step.ErrNoPendingConfirmation) {
+			t.Errorf("got error %#v want %#v", err, step.ErrNoPendingConfirmation)
+		}
+
+		if !s.confirmations.Pending(substep) {
+			t.Errorf("expected %s to still be waiting", substep)
+		}
+
+		_, err = s.Confirm(context.Background(), &idl.ConfirmRequest{Substep: substep})
+		if err != nil {
+			t.Fatalf("unexpected error: %+v", err)
+		}
+
+		if err := <-result; err != nil {
+			t.Errorf("unexpected error: %+v", err)
+		}
+	})
+}
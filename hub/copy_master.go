@@ -39,14 +39,7 @@ func Copy(streams step.OutStreams, destinationDir string, sourceDirs, hosts []st
 
 			stream := &step.BufferedStreams{}
 
-			options := []rsync.Option{
-				rsync.WithSources(sourceDirs...),
-				rsync.WithDestinationHost(hostname),
-				rsync.WithDestination(destinationDir),
-				rsync.WithOptions("--archive", "--compress", "--delete", "--stats"),
-				rsync.WithStream(stream),
-			}
-
+			options := append(copyOptions(destinationDir, sourceDirs, hostname), rsync.WithStream(stream))
 			err := rsync.Rsync(options...)
 			if err != nil {
 				err = xerrors.Errorf("copying source %q to destination %q on host %s: %w", sourceDirs, destinationDir, hostname, err)
@@ -78,11 +71,27 @@ func Copy(streams step.OutStreams, destinationDir string, sourceDirs, hosts []st
 	return errs
 }
 
+// copyOptions returns the options with which Copy copies sourceDirs to
+// destinationDir on a host.
+func copyOptions(destinationDir string, sourceDirs []string, hostname string) []rsync.Option {
+	return []rsync.Option{
+		rsync.WithSources(sourceDirs...),
+		rsync.WithDestinationHost(hostname),
+		rsync.WithDestination(destinationDir),
+		rsync.WithOptions("--archive", "--compress", "--delete", "--stats"),
+	}
+}
+
 func (s *Server) CopyMasterDataDir(streams step.OutStreams, destination string) error {
+	return Copy(streams, destination, masterDataDirCopySources(s.Config), s.Target.PrimaryHostnames())
+}
+
+// masterDataDirCopySources returns the upgraded master data directory that is
+// copied to each primary host.
+func masterDataDirCopySources(conf *Config) []string {
 	// Make sure sourceDir ends with a trailing slash so that rsync will
 	// transfer the directory contents and not the directory itself.
-	source := []string{filepath.Clean(s.Target.MasterDataDir()) + string(filepath.Separator)}
-	return Copy(streams, destination, source, s.Target.PrimaryHostnames())
+	return []string{filepath.Clean(conf.Target.MasterDataDir()) + string(filepath.Separator)}
 }
 
 func (s *Server) CopyMasterTablespaces(streams step.OutStreams, destinationDir string) error {
@@ -90,9 +99,13 @@ func (s *Server) CopyMasterTablespaces(streams step.OutStreams, destinationDir s
 		return nil
 	}
 
-	// include tablespace mapping file which is used as a parameter to pg_upgrade
-	sourcePaths := []string{s.TablespacesMappingFilePath}
-	sourcePaths = append(sourcePaths, s.Tablespaces.GetMasterTablespaces().UserDefinedTablespacesLocations()...)
+	return Copy(streams, destinationDir, masterTablespaceCopySources(s.Config), s.Target.PrimaryHostnames())
+}
 
-	return Copy(streams, destinationDir, sourcePaths, s.Target.PrimaryHostnames())
+// masterTablespaceCopySources returns the tablespace mapping file and master
+// tablespace directories that are copied to each primary host.
+func masterTablespaceCopySources(conf *Config) []string {
+	// include tablespace mapping file which is used as a parameter to pg_upgrade
+	sourcePaths := []string{conf.TablespacesMappingFilePath}
+	return append(sourcePaths, conf.Tablespaces.GetMasterTablespaces().UserDefinedTablespacesLocations()...)
 }
@@ -7,6 +7,8 @@ import (
 	"context"
 	"sync"
 
+	"github.com/blang/semver/v4"
+
 	"github.com/greenplum-db/gpupgrade/greenplum"
 	"github.com/greenplum-db/gpupgrade/idl"
 	"github.com/greenplum-db/gpupgrade/step"
@@ -14,61 +16,64 @@ import (
 	"github.com/greenplum-db/gpupgrade/utils/errorlist"
 )
 
-func DeleteMirrorAndStandbyDataDirectories(agentConns []*Connection, cluster *greenplum.Cluster) error {
-	segs := cluster.SelectSegments(func(seg *greenplum.SegConfig) bool {
-		return seg.Role == greenplum.MirrorRole
-	})
-	return deleteDataDirectories(agentConns, segs)
+// DeleteMirrorAndStandbyDataDirectories deletes the source cluster data
+// directories of each host in the finalize plan.
+func DeleteMirrorAndStandbyDataDirectories(ctx context.Context, agentConns []*Connection, plan Plan, version semver.Version) error {
+	return deleteDataDirectories(ctx, agentConns, plan, version)
 }
 
-func DeleteMasterAndPrimaryDataDirectories(streams step.OutStreams, agentConns []*Connection, source InitializeConfig) error {
+// DeleteMasterAndPrimaryDataDirectories deletes the master data directories
+// of master and the primary data directories of each host in primaries, for
+// a cluster of the given version. A zero version, when the version is not
+// known, checks only the files common to all versions before deleting.
+func DeleteMasterAndPrimaryDataDirectories(ctx context.Context, streams step.OutStreams, agentConns []*Connection, master *HostPlan, primaries Plan, version semver.Version) error {
 	masterErr := make(chan error)
 	go func() {
-		masterErr <- upgrade.DeleteDirectories([]string{source.Master.DataDir}, upgrade.PostgresFiles, streams)
+		masterErr <- upgrade.DeleteDirectories(master.DeleteDataDirs, upgrade.RequiredPathsFor(version), streams)
 	}()
 
-	err := deleteDataDirectories(agentConns, source.Primaries)
+	err := deleteDataDirectories(ctx, agentConns, primaries, version)
 	err = errorlist.Append(err, <-masterErr)
 
 	return err
 }
 
-func deleteDataDirectories(agentConns []*Connection, segConfigs greenplum.SegConfigs) error {
-	request := func(conn *Connection) error {
-
-		segs := segConfigs.Select(func(seg *greenplum.SegConfig) bool {
-			return seg.Hostname == conn.Hostname
-		})
-
-		if len(segs) == 0 {
-			// This can happen if there are no segments matching the filter on a host
+func deleteDataDirectories(ctx context.Context, agentConns []*Connection, plan Plan, version semver.Version) error {
+	token := NewIdempotencyToken()
+	request := func(ctx context.Context, conn *Connection) error {
+		host, ok := plan[conn.Hostname]
+		if !ok || len(host.DeleteDataDirs) == 0 {
+			// This can happen if there are no segments to delete on a host
 			return nil
 		}
 
-		req := new(idl.DeleteDataDirectoriesRequest)
-		for _, seg := range segs {
-			datadir := seg.DataDir
-			req.Datadirs = append(req.Datadirs, datadir)
+		req := &idl.DeleteDataDirectoriesRequest{Datadirs: host.DeleteDataDirs, IdempotencyToken: token}
+		if !version.Equals(semver.Version{}) {
+			req.Version = version.String()
 		}
 
-		_, err := conn.AgentClient.DeleteDataDirectories(context.Background(), req)
-		return err
+		return retryAgentRequest(ctx, conn.Hostname, func(ctx context.Context) error {
+			_, err := conn.AgentClient.DeleteDataDirectories(ctx, req)
+			return err
+		})
 	}
 
-	return ExecuteRPC(agentConns, request)
+	return ExecuteRPCContext(ctx, agentConns, request)
 }
 
-func DeleteTargetTablespaces(streams step.OutStreams, agentConns []*Connection, target *greenplum.Cluster, targetCatalogVersion string, sourceTablespaces greenplum.Tablespaces) error {
+// DeleteTargetTablespaces deletes the target cluster tablespace directories of
+// master and of each host in primaries.
+func DeleteTargetTablespaces(ctx context.Context, streams step.OutStreams, agentConns []*Connection, master *HostPlan, primaries Plan) error {
 	var wg sync.WaitGroup
 	errs := make(chan error, 2)
 
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		errs <- DeleteTargetTablespacesOnMaster(streams, target, sourceTablespaces.GetMasterTablespaces(), targetCatalogVersion)
+		errs <- DeleteTargetTablespacesOnMaster(streams, master)
 	}()
 
-	errs <- DeleteTargetTablespacesOnPrimaries(agentConns, target, sourceTablespaces, targetCatalogVersion)
+	errs <- DeleteTargetTablespacesOnPrimaries(ctx, agentConns, primaries)
 
 	wg.Wait()
 	close(errs)
@@ -81,79 +86,57 @@ func DeleteTargetTablespaces(streams step.OutStreams, agentConns []*Connection,
 	return err
 }
 
-func DeleteTargetTablespacesOnMaster(streams step.OutStreams, target *greenplum.Cluster, masterTablespaces greenplum.SegmentTablespaces, catalogVersion string) error {
+func DeleteTargetTablespacesOnMaster(streams step.OutStreams, master *HostPlan) error {
+	return upgrade.DeleteNewTablespaceDirectories(streams, master.DeleteTablespaceDirs)
+}
+
+// targetTablespaceDirs returns the target cluster tablespace directories of the
+// user defined tablespaces for a single segment.
+func targetTablespaceDirs(segTablespaces greenplum.SegmentTablespaces, dbID int, majorVersion uint64, catalogVersion string) []string {
 	var dirs []string
-	for _, tsInfo := range masterTablespaces {
+	for _, tsInfo := range segTablespaces {
 		if !tsInfo.IsUserDefined() {
 			continue
 		}
 
-		path := upgrade.TablespacePath(tsInfo.Location, target.Master().DbID, target.Version.SemVer.Major, catalogVersion)
-		dirs = append(dirs, path)
+		dirs = append(dirs, upgrade.TablespacePath(tsInfo.Location, dbID, majorVersion, catalogVersion))
 	}
 
-	return upgrade.DeleteNewTablespaceDirectories(streams, dirs)
+	return dirs
 }
 
-func DeleteTargetTablespacesOnPrimaries(agentConns []*Connection, target *greenplum.Cluster, tablespaces greenplum.Tablespaces, catalogVersion string) error {
-	request := func(conn *Connection) error {
-		if target == nil {
+func DeleteTargetTablespacesOnPrimaries(ctx context.Context, agentConns []*Connection, primaries Plan) error {
+	token := NewIdempotencyToken()
+	request := func(ctx context.Context, conn *Connection) error {
+		host, ok := primaries[conn.Hostname]
+		if !ok || len(host.DeleteTablespaceDirs) == 0 {
 			return nil
 		}
 
-		primaries := target.SelectSegments(func(seg *greenplum.SegConfig) bool {
-			return seg.IsOnHost(conn.Hostname) && seg.IsPrimary() && !seg.IsMaster()
+		req := &idl.DeleteTablespaceRequest{Dirs: host.DeleteTablespaceDirs, IdempotencyToken: token}
+		return retryAgentRequest(ctx, conn.Hostname, func(ctx context.Context) error {
+			_, err := conn.AgentClient.DeleteTablespaceDirectories(ctx, req)
+			return err
 		})
-
-		if len(primaries) == 0 {
-			return nil
-		}
-
-		var dirs []string
-		for _, seg := range primaries {
-			segTablespaces := tablespaces[seg.DbID]
-			for _, tsInfo := range segTablespaces {
-				if !tsInfo.IsUserDefined() {
-					continue
-				}
-
-				path := upgrade.TablespacePath(tsInfo.Location, seg.DbID, target.Version.SemVer.Major, catalogVersion)
-				dirs = append(dirs, path)
-			}
-		}
-
-		req := &idl.DeleteTablespaceRequest{Dirs: dirs}
-		_, err := conn.AgentClient.DeleteTablespaceDirectories(context.Background(), req)
-		return err
 	}
 
-	return ExecuteRPC(agentConns, request)
+	return ExecuteRPCContext(ctx, agentConns, request)
 }
 
-func DeleteSourceTablespacesOnMirrorsAndStandby(agentConns []*Connection, source *greenplum.Cluster, tablespaces greenplum.Tablespaces) error {
-	request := func(conn *Connection) error {
-
-		segments := source.SelectSegments(func(seg *greenplum.SegConfig) bool {
-			return seg.IsOnHost(conn.Hostname) && (seg.IsMirror() || seg.IsStandby())
-		})
-
-		if len(segments) == 0 {
+func DeleteSourceTablespacesOnMirrorsAndStandby(ctx context.Context, agentConns []*Connection, plan Plan) error {
+	token := NewIdempotencyToken()
+	request := func(ctx context.Context, conn *Connection) error {
+		host, ok := plan[conn.Hostname]
+		if !ok || len(host.DeleteTablespaceDirs) == 0 {
 			return nil
 		}
 
-		var dirs []string
-		for _, seg := range segments {
-			dirs = append(dirs, tablespaces[seg.DbID].UserDefinedTablespacesLocations()...)
-		}
-
-		if len(dirs) == 0 {
-			return nil
-		}
-
-		req := &idl.DeleteTablespaceRequest{Dirs: dirs}
-		_, err := conn.AgentClient.DeleteSourceTablespaceDirectories(context.Background(), req)
-		return err
+		req := &idl.DeleteTablespaceRequest{Dirs: host.DeleteTablespaceDirs, IdempotencyToken: token}
+		return retryAgentRequest(ctx, conn.Hostname, func(ctx context.Context) error {
+			_, err := conn.AgentClient.DeleteSourceTablespaceDirectories(ctx, req)
+			return err
+		})
 	}
 
-	return ExecuteRPC(agentConns, request)
+	return ExecuteRPCContext(ctx, agentConns, request)
 }
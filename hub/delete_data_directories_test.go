@@ -4,15 +4,19 @@
 package hub_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"path/filepath"
 	"reflect"
 	"sort"
 	"testing"
+	"time"
 
+	"github.com/blang/semver/v4"
 	"github.com/golang/mock/gomock"
 	"github.com/greenplum-db/gp-common-go-libs/dbconn"
+	"google.golang.org/grpc"
 
 	"github.com/greenplum-db/gpupgrade/greenplum"
 	"github.com/greenplum-db/gpupgrade/hub"
@@ -47,6 +51,7 @@ func TestDeleteSegmentDataDirs(t *testing.T) {
 	segConfigs = append(segConfigs, mirrorSegConfigs...)
 
 	c := hub.MustCreateCluster(t, segConfigs)
+	c.Version = dbconn.NewVersion("6.20.0")
 
 	testlog.SetupLogger()
 
@@ -61,7 +66,7 @@ func TestDeleteSegmentDataDirs(t *testing.T) {
 				&idl.DeleteDataDirectoriesRequest{Datadirs: []string{
 					"/data/dbfast_mirror1/seg1",
 					"/data/dbfast_mirror1/seg3",
-				}},
+				}, Version: "6.20.0", IdempotencyToken: testIdempotencyToken},
 			).Return(&idl.DeleteDataDirectoriesReply{}, nil)
 
 			sdw2Client := mock_idl.NewMockAgentClient(ctrl)
@@ -70,13 +75,13 @@ func TestDeleteSegmentDataDirs(t *testing.T) {
 				&idl.DeleteDataDirectoriesRequest{Datadirs: []string{
 					"/data/dbfast_mirror2/seg2",
 					"/data/dbfast_mirror2/seg4",
-				}},
+				}, Version: "6.20.0", IdempotencyToken: testIdempotencyToken},
 			).Return(&idl.DeleteDataDirectoriesReply{}, nil)
 
 			standbyClient := mock_idl.NewMockAgentClient(ctrl)
 			standbyClient.EXPECT().DeleteDataDirectories(
 				gomock.Any(),
-				&idl.DeleteDataDirectoriesRequest{Datadirs: []string{"/data/standby"}},
+				&idl.DeleteDataDirectoriesRequest{Datadirs: []string{"/data/standby"}, Version: "6.20.0", IdempotencyToken: testIdempotencyToken},
 			).Return(&idl.DeleteDataDirectoriesReply{}, nil)
 
 			agentConns := []*hub.Connection{
@@ -85,7 +90,9 @@ func TestDeleteSegmentDataDirs(t *testing.T) {
 				{nil, standbyClient, "standby", nil},
 			}
 
-			err := hub.DeleteMirrorAndStandbyDataDirectories(agentConns, c)
+			plan := hub.FinalizePlan(&hub.Config{Source: c, UseLinkMode: true})
+
+			err := hub.DeleteMirrorAndStandbyDataDirectories(context.Background(), agentConns, plan, semver.MustParse(c.Version.SemVer.String()))
 			if err != nil {
 				t.Errorf("unexpected err %#v", err)
 			}
@@ -103,7 +110,7 @@ func TestDeleteSegmentDataDirs(t *testing.T) {
 				&idl.DeleteDataDirectoriesRequest{Datadirs: []string{
 					"/data/dbfast1/seg1",
 					"/data/dbfast1/seg3",
-				}},
+				}, Version: "7.0.0", IdempotencyToken: testIdempotencyToken},
 			).Return(&idl.DeleteDataDirectoriesReply{}, nil)
 
 			sdw2Client := mock_idl.NewMockAgentClient(ctrl)
@@ -112,7 +119,7 @@ func TestDeleteSegmentDataDirs(t *testing.T) {
 				&idl.DeleteDataDirectoriesRequest{Datadirs: []string{
 					"/data/dbfast2/seg2",
 					"/data/dbfast2/seg4",
-				}},
+				}, Version: "7.0.0", IdempotencyToken: testIdempotencyToken},
 			).Return(&idl.DeleteDataDirectoriesReply{}, nil)
 
 			standbyClient := mock_idl.NewMockAgentClient(ctrl)
@@ -124,12 +131,12 @@ func TestDeleteSegmentDataDirs(t *testing.T) {
 				{nil, standbyClient, "standby", nil},
 			}
 
-			source := hub.InitializeConfig{
-				Master:    greenplum.SegConfig{ContentID: -1, DbID: 0, Port: 25431, Hostname: "master", DataDir: "/data/qddir", Role: greenplum.PrimaryRole},
-				Primaries: primarySegConfigs,
+			primaries := hub.Plan{
+				"sdw1": {DeleteDataDirs: []string{"/data/dbfast1/seg1", "/data/dbfast1/seg3"}},
+				"sdw2": {DeleteDataDirs: []string{"/data/dbfast2/seg2", "/data/dbfast2/seg4"}},
 			}
 
-			err := hub.DeleteMasterAndPrimaryDataDirectories(step.DevNullStream, agentConns, source)
+			err := hub.DeleteMasterAndPrimaryDataDirectories(context.Background(), step.DevNullStream, agentConns, &hub.HostPlan{DeleteDataDirs: []string{"/data/qddir"}}, primaries, semver.MustParse("7.0.0"))
 			if err != nil {
 				t.Errorf("unexpected err %#v", err)
 			}
@@ -157,17 +164,62 @@ func TestDeleteSegmentDataDirs(t *testing.T) {
 				{nil, sdw2ClientFailed, "sdw2", nil},
 			}
 
-			source := hub.InitializeConfig{
-				Master:    greenplum.SegConfig{ContentID: -1, DbID: 0, Port: 25431, Hostname: "master", DataDir: "/data/qddir", Role: greenplum.PrimaryRole},
-				Primaries: primarySegConfigs,
+			primaries := hub.Plan{
+				"sdw1": {DeleteDataDirs: []string{"/data/dbfast1/seg1", "/data/dbfast1/seg3"}},
+				"sdw2": {DeleteDataDirs: []string{"/data/dbfast2/seg2", "/data/dbfast2/seg4"}},
 			}
 
-			err := hub.DeleteMasterAndPrimaryDataDirectories(step.DevNullStream, agentConns, source)
+			err := hub.DeleteMasterAndPrimaryDataDirectories(context.Background(), step.DevNullStream, agentConns, &hub.HostPlan{DeleteDataDirs: []string{"/data/qddir"}}, primaries, semver.Version{})
 
 			if !errors.Is(err, expected) {
 				t.Errorf("got error %#v, want %#v", err, expected)
 			}
 		})
+
+		t.Run("stops waiting on agents once the revert step budget expires", func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			sdw1Client := mock_idl.NewMockAgentClient(ctrl)
+			sdw1Client.EXPECT().DeleteDataDirectories(
+				gomock.Any(),
+				gomock.Any(),
+			).Return(&idl.DeleteDataDirectoriesReply{}, nil)
+
+			sdw2ClientHung := mock_idl.NewMockAgentClient(ctrl)
+			sdw2ClientHung.EXPECT().DeleteDataDirectories(
+				gomock.Any(),
+				gomock.Any(),
+			).DoAndReturn(func(ctx context.Context, _ *idl.DeleteDataDirectoriesRequest, _ ...grpc.CallOption) (*idl.DeleteDataDirectoriesReply, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			})
+
+			agentConns := []*hub.Connection{
+				{nil, sdw1Client, "sdw1", nil},
+				{nil, sdw2ClientHung, "sdw2", nil},
+			}
+
+			primaries := hub.Plan{
+				"sdw1": {DeleteDataDirs: []string{"/data/dbfast1/seg1"}},
+				"sdw2": {DeleteDataDirs: []string{"/data/dbfast2/seg2"}},
+			}
+
+			ctx, cancel := hub.WithStepBudget(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			err := hub.DeleteMasterAndPrimaryDataDirectories(ctx, step.DevNullStream, agentConns, &hub.HostPlan{}, primaries, semver.Version{})
+
+			var budgetErr *hub.StepBudgetExceededError
+			if !errors.As(err, &budgetErr) {
+				t.Fatalf("got error %#v want type %T", err, budgetErr)
+			}
+
+			expected := []string{"sdw2"}
+			if !reflect.DeepEqual(budgetErr.Hosts, expected) {
+				t.Errorf("got hosts %q want %q", budgetErr.Hosts, expected)
+			}
+		})
 	})
 }
 
@@ -207,7 +259,17 @@ func TestDeleteTablespaceDirectories(t *testing.T) {
 			},
 		}
 
-		err := hub.DeleteTargetTablespacesOnMaster(step.DevNullStream, target, masterTablespaces, "301908232")
+		plan := hub.RevertPlan(&hub.Config{
+			Target: target,
+			TargetInitializeConfig: hub.InitializeConfig{
+				Master:    target.Primaries[-1],
+				Primaries: []greenplum.SegConfig{target.Primaries[0], target.Primaries[1]},
+			},
+			Tablespaces:          greenplum.Tablespaces{1: masterTablespaces},
+			TargetCatalogVersion: "301908232",
+		})
+
+		err := hub.DeleteTargetTablespacesOnMaster(step.DevNullStream, plan["master"])
 		if err != nil {
 			t.Errorf("DeleteTargetTablespacesOnMaster returned error %+v", err)
 		}
@@ -293,7 +355,7 @@ func TestDeleteTablespaceDirectories(t *testing.T) {
 				Dirs: []string{
 					"/tmp/testfs/primary1/dbfast1/16386/2/GPDB_6_301908232",
 					"/tmp/testfs/primary1/dbfast1/16387/2/GPDB_6_301908232",
-				}}),
+				}, IdempotencyToken: testIdempotencyToken}),
 		).Return(&idl.DeleteTablespaceReply{}, nil)
 
 		sdw2 := mock_idl.NewMockAgentClient(ctrl)
@@ -303,7 +365,7 @@ func TestDeleteTablespaceDirectories(t *testing.T) {
 				Dirs: []string{
 					"/tmp/testfs/primary2/dbfast2/16386/4/GPDB_6_301908232",
 					"/tmp/testfs/primary2/dbfast2/16387/4/GPDB_6_301908232",
-				}}),
+				}, IdempotencyToken: testIdempotencyToken}),
 		).Return(&idl.DeleteTablespaceReply{}, nil)
 
 		master := mock_idl.NewMockAgentClient(ctrl)
@@ -316,7 +378,20 @@ func TestDeleteTablespaceDirectories(t *testing.T) {
 			{nil, standby, "standby", nil},
 		}
 
-		err := hub.DeleteTargetTablespacesOnPrimaries(agentConns, target, tablespaces, "301908232")
+		plan := hub.RevertPlan(&hub.Config{
+			Target: target,
+			TargetInitializeConfig: hub.InitializeConfig{
+				Master:    target.Primaries[-1],
+				Primaries: []greenplum.SegConfig{target.Primaries[0], target.Primaries[1]},
+			},
+			Tablespaces:          tablespaces,
+			TargetCatalogVersion: "301908232",
+		})
+
+		// The hub deletes the master tablespaces itself.
+		delete(plan, "master")
+
+		err := hub.DeleteTargetTablespacesOnPrimaries(context.Background(), agentConns, plan)
 		if err != nil {
 			t.Errorf("DeleteTargetTablespacesOnPrimaries returned error %+v", err)
 		}
@@ -344,7 +419,12 @@ func TestDeleteTablespaceDirectories(t *testing.T) {
 			{nil, failedClient, "sdw2", nil},
 		}
 
-		err := hub.DeleteTargetTablespacesOnPrimaries(agentConns, target, nil, "")
+		plan := hub.Plan{
+			"sdw1": {DeleteTablespaceDirs: []string{"/tmp/testfs/primary1/dbfast1/16386/2/GPDB_6_301908232"}},
+			"sdw2": {DeleteTablespaceDirs: []string{"/tmp/testfs/primary2/dbfast2/16386/4/GPDB_6_301908232"}},
+		}
+
+		err := hub.DeleteTargetTablespacesOnPrimaries(context.Background(), agentConns, plan)
 
 		if !errors.Is(err, expected) {
 			t.Errorf("got error %#v, want %#v", err, expected)
@@ -363,7 +443,7 @@ func TestDeleteTablespaceDirectories(t *testing.T) {
 			{nil, sdw2, "sdw2", nil},
 		}
 
-		err := hub.DeleteTargetTablespacesOnPrimaries(agentConns, nil, nil, "")
+		err := hub.DeleteTargetTablespacesOnPrimaries(context.Background(), agentConns, hub.RevertPlan(&hub.Config{}))
 		if err != nil {
 			t.Errorf("unexpected error %#v", err)
 		}
@@ -436,7 +516,7 @@ func TestDeleteTablespacesOnMirrorsAndStandby(t *testing.T) {
 				Dirs: []string{
 					"/tmp/testfs/standby/demoDataDir-1/16386",
 					"/tmp/testfs/standby/demoDataDir-1/16387",
-				}}),
+				}, IdempotencyToken: testIdempotencyToken}),
 		).Return(&idl.DeleteTablespaceReply{}, nil)
 
 		msdw1 := mock_idl.NewMockAgentClient(ctrl)
@@ -446,7 +526,7 @@ func TestDeleteTablespacesOnMirrorsAndStandby(t *testing.T) {
 				Dirs: []string{
 					"/tmp/testfs/mirror1/dbfast_mirror1/16386",
 					"/tmp/testfs/mirror1/dbfast_mirror1/16387",
-				}}),
+				}, IdempotencyToken: testIdempotencyToken}),
 		).Return(&idl.DeleteTablespaceReply{}, nil)
 
 		msdw2 := mock_idl.NewMockAgentClient(ctrl)
@@ -456,7 +536,7 @@ func TestDeleteTablespacesOnMirrorsAndStandby(t *testing.T) {
 				Dirs: []string{
 					"/tmp/testfs/mirror2/dbfast_mirror2/16386",
 					"/tmp/testfs/mirror2/dbfast_mirror2/16387",
-				}}),
+				}, IdempotencyToken: testIdempotencyToken}),
 		).Return(&idl.DeleteTablespaceReply{}, nil)
 
 		master := mock_idl.NewMockAgentClient(ctrl)
@@ -472,7 +552,9 @@ func TestDeleteTablespacesOnMirrorsAndStandby(t *testing.T) {
 			{nil, standby, "standby", nil},
 		}
 
-		err := hub.DeleteSourceTablespacesOnMirrorsAndStandby(agentConns, source, tablespaces)
+		plan := hub.FinalizePlan(&hub.Config{Source: source, Tablespaces: tablespaces, UseLinkMode: true})
+
+		err := hub.DeleteSourceTablespacesOnMirrorsAndStandby(context.Background(), agentConns, plan)
 		if err != nil {
 			t.Errorf("DeleteTablespacesOnMirrorsAndStandby returned error %+v", err)
 		}
@@ -490,7 +572,7 @@ func TestDeleteTablespacesOnMirrorsAndStandby(t *testing.T) {
 				Dirs: []string{
 					"/tmp/testfs/mirror1/dbfast_mirror1/16386",
 					"/tmp/testfs/mirror1/dbfast_mirror1/16387",
-				}}),
+				}, IdempotencyToken: testIdempotencyToken}),
 		).Return(&idl.DeleteTablespaceReply{}, nil)
 
 		expected := errors.New("permission denied")
@@ -501,7 +583,7 @@ func TestDeleteTablespacesOnMirrorsAndStandby(t *testing.T) {
 				Dirs: []string{
 					"/tmp/testfs/mirror2/dbfast_mirror2/16386",
 					"/tmp/testfs/mirror2/dbfast_mirror2/16387",
-				}}),
+				}, IdempotencyToken: testIdempotencyToken}),
 		).Return(nil, expected)
 
 		agentConns := []*hub.Connection{
@@ -509,7 +591,9 @@ func TestDeleteTablespacesOnMirrorsAndStandby(t *testing.T) {
 			{nil, failedClient, "msdw2", nil},
 		}
 
-		err := hub.DeleteSourceTablespacesOnMirrorsAndStandby(agentConns, source, tablespaces)
+		plan := hub.FinalizePlan(&hub.Config{Source: source, Tablespaces: tablespaces, UseLinkMode: true})
+
+		err := hub.DeleteSourceTablespacesOnMirrorsAndStandby(context.Background(), agentConns, plan)
 
 		if !errors.Is(err, expected) {
 			t.Errorf("got error %#v, want %#v", err, expected)
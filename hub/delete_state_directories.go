@@ -9,15 +9,15 @@ import (
 	"github.com/greenplum-db/gpupgrade/idl"
 )
 
-func DeleteStateDirectories(agentConns []*Connection, excludeHostname string) error {
-	request := func(conn *Connection) error {
+func DeleteStateDirectories(ctx context.Context, agentConns []*Connection, excludeHostname string) error {
+	request := func(ctx context.Context, conn *Connection) error {
 		if conn.Hostname == excludeHostname {
 			return nil
 		}
 
-		_, err := conn.AgentClient.DeleteStateDirectory(context.Background(), &idl.DeleteStateDirectoryRequest{})
+		_, err := conn.AgentClient.DeleteStateDirectory(ctx, &idl.DeleteStateDirectoryRequest{})
 		return err
 	}
 
-	return ExecuteRPC(agentConns, request)
+	return ExecuteRPCContext(ctx, agentConns, request)
 }
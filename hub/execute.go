@@ -4,6 +4,7 @@
 package hub
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -22,11 +23,14 @@ const executeMasterBackupName = "upgraded-master.bak"
 func (s *Server) Execute(request *idl.ExecuteRequest, stream idl.CliToHub_ExecuteServer) (err error) {
 	upgradedMasterBackupDir := filepath.Join(s.StateDir, executeMasterBackupName)
 
-	st, err := step.Begin(idl.Step_EXECUTE, stream)
+	st, err := s.beginStep(idl.Step_EXECUTE, stream)
 	if err != nil {
 		return err
 	}
 
+	ctx, cancel := WithStepBudget(context.Background(), s.StepBudget)
+	defer cancel()
+
 	defer func() {
 		if ferr := st.Finish(); ferr != nil {
 			err = errorlist.Append(err, ferr)
@@ -37,6 +41,17 @@ func (s *Server) Execute(request *idl.ExecuteRequest, stream idl.CliToHub_Execut
 		}
 	}()
 
+	// Compute the plan before running any substep so that execute stops
+	// before touching the cluster if it cannot say what it will do.
+	plan, err := ExecutePlan(s.Config, s.StateDir)
+	if err != nil {
+		return xerrors.Errorf("planning execute: %w", err)
+	}
+
+	if err := SaveExecutePlan(plan, s.StateDir); err != nil {
+		return err
+	}
+
 	st.Run(idl.Substep_SHUTDOWN_SOURCE_CLUSTER, func(streams step.OutStreams) error {
 		err := s.Source.Stop(streams)
 
@@ -86,7 +101,7 @@ func (s *Server) Execute(request *idl.ExecuteRequest, stream idl.CliToHub_Execut
 			return xerrors.Errorf("get source and target primary data directories: %w", err)
 		}
 
-		return UpgradePrimaries(UpgradePrimaryArgs{
+		return UpgradePrimaries(ctx, UpgradePrimaryArgs{
 			CheckOnly:              false,
 			MasterBackupDir:        upgradedMasterBackupDir,
 			AgentConns:             agentConns,
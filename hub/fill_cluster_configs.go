@@ -27,9 +27,6 @@ func FillConfiguration(config *Config, conn *sql.DB, _ step.OutStreams, request
 	config.AgentPort = int(request.AgentPort)
 	config.UseHbaHostnames = request.UseHbaHostnames
 
-	// Assign a new universal upgrade identifier.
-	config.UpgradeID = upgrade.NewID()
-
 	if err := CheckSourceClusterConfiguration(conn); err != nil {
 		return err
 	}
@@ -43,6 +40,14 @@ func FillConfiguration(config *Config, conn *sql.DB, _ step.OutStreams, request
 	}
 
 	config.Source = source
+
+	// Assign a new universal upgrade identifier. Only the master data directory
+	// is local, so it is the only one checked for leftovers from a prior run.
+	config.UpgradeID, err = upgrade.NewUniqueID([]string{source.MasterDataDir()})
+	if err != nil {
+		return err
+	}
+
 	config.TargetGPHome = request.TargetGPHome
 	config.UseLinkMode = request.UseLinkMode
 
@@ -142,6 +147,9 @@ func AssignDatadirsAndPorts(source *greenplum.Cluster, ports []int, upgradeID up
 			segment.Port = ports[nextPortIndex]
 			portIndexByHost[segment.Hostname] = nextPortIndex + 1
 		}
+		if err := upgrade.VerifySegmentPrefix(segment.DataDir, segPrefix); err != nil {
+			return InitializeConfig{}, err
+		}
 		segment.DataDir = upgrade.TempDataDir(segment.DataDir, segPrefix, upgradeID)
 
 		targetInitializeConfig.Primaries = append(targetInitializeConfig.Primaries, segment)
@@ -167,6 +175,9 @@ func AssignDatadirsAndPorts(source *greenplum.Cluster, ports []int, upgradeID up
 				segment.Port = ports[nextPortIndex]
 				portIndexByHost[segment.Hostname] = nextPortIndex + 1
 			}
+			if err := upgrade.VerifySegmentPrefix(segment.DataDir, segPrefix); err != nil {
+				return InitializeConfig{}, err
+			}
 			segment.DataDir = upgrade.TempDataDir(segment.DataDir, segPrefix, upgradeID)
 
 			targetInitializeConfig.Mirrors = append(targetInitializeConfig.Mirrors, segment)
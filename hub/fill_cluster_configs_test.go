@@ -232,6 +232,21 @@ func TestAssignDataDirsAndPorts(t *testing.T) {
 			{ContentID: 0, DbID: 3, Hostname: "sdw1", DataDir: "/data/dbfast1/seg1", Role: "p"},
 		}),
 		ports: []int{15433},
+	}, {
+		name: "errors when a primary does not match the segment prefix",
+		cluster: MustCreateCluster(t, []greenplum.SegConfig{
+			{ContentID: -1, DbID: 1, Hostname: "mdw", DataDir: "/data/qddir/gpseg-1", Role: "p"},
+			{ContentID: 0, DbID: 2, Hostname: "sdw1", DataDir: "/data/dbfast1/seg0", Role: "p"},
+		}),
+		ports: []int{15433, 15434},
+	}, {
+		name: "errors when a mirror does not match the segment prefix",
+		cluster: MustCreateCluster(t, []greenplum.SegConfig{
+			{ContentID: -1, DbID: 1, Hostname: "mdw", DataDir: "/data/qddir/gpseg-1", Role: "p"},
+			{ContentID: 0, DbID: 2, Hostname: "sdw1", DataDir: "/data/dbfast1/gpseg0", Role: "p"},
+			{ContentID: 0, DbID: 3, Hostname: "sdw2", DataDir: "/data/dbfast_mirror1/seg0", Role: "m"},
+		}),
+		ports: []int{15433, 15434, 15435},
 	}}
 
 	for _, c := range errCases {
@@ -4,6 +4,7 @@
 package hub
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/blang/semver/v4"
@@ -19,11 +20,14 @@ import (
 )
 
 func (s *Server) Finalize(_ *idl.FinalizeRequest, stream idl.CliToHub_FinalizeServer) (err error) {
-	st, err := step.Begin(idl.Step_FINALIZE, stream)
+	st, err := s.beginStep(idl.Step_FINALIZE, stream)
 	if err != nil {
 		return err
 	}
 
+	ctx, cancel := WithStepBudget(context.Background(), s.StepBudget)
+	defer cancel()
+
 	defer func() {
 		if ferr := st.Finish(); ferr != nil {
 			err = errorlist.Append(err, ferr)
@@ -49,7 +53,7 @@ func (s *Server) Finalize(_ *idl.FinalizeRequest, stream idl.CliToHub_FinalizeSe
 	})
 
 	st.Run(idl.Substep_UPDATE_DATA_DIRECTORIES, func(_ step.OutStreams) error {
-		return s.UpdateDataDirectories()
+		return s.UpdateDataDirectories(ctx)
 	})
 
 	st.Run(idl.Substep_UPDATE_TARGET_CONF_FILES, func(streams step.OutStreams) error {
@@ -113,18 +117,18 @@ func (s *Server) Finalize(_ *idl.FinalizeRequest, stream idl.CliToHub_FinalizeSe
 			return err
 		}
 
-		return ArchiveSegmentLogDirectories(s.agentConns, s.Config.Target.MasterHostname(), logArchiveDir)
+		return ArchiveSegmentLogDirectories(ctx, s.agentConns, s.Config.Target.MasterHostname(), logArchiveDir)
 	})
 
 	st.Run(idl.Substep_DELETE_SEGMENT_STATEDIRS, func(_ step.OutStreams) error {
-		return DeleteStateDirectories(s.agentConns, s.Source.MasterHostname())
+		return DeleteStateDirectories(ctx, s.agentConns, s.Source.MasterHostname())
 	})
 
 	message := &idl.Message{Contents: &idl.Message_Response{Response: &idl.Response{Contents: &idl.Response_FinalizeResponse{
 		FinalizeResponse: &idl.FinalizeResponse{
 			TargetVersion:                     s.Target.Version.VersionString,
 			LogArchiveDirectory:               logArchiveDir,
-			ArchivedSourceMasterDataDirectory: s.Config.TargetInitializeConfig.Master.DataDir + upgrade.OldSuffix,
+			ArchivedSourceMasterDataDirectory: upgrade.ArchivePathFor(s.Config.TargetInitializeConfig.Master.DataDir),
 			UpgradeID:                         s.Config.UpgradeID.String(),
 			Target: &idl.Cluster{
 				Port:                int32(s.Target.MasterPort()),
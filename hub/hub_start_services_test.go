@@ -13,7 +13,9 @@ import (
 	"os/exec"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/test/bufconn"
@@ -24,7 +26,9 @@ import (
 	"github.com/greenplum-db/gpupgrade/testutils"
 	"github.com/greenplum-db/gpupgrade/testutils/exectest"
 	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+	"github.com/greenplum-db/gpupgrade/utils/certs"
 	"github.com/greenplum-db/gpupgrade/utils/errorlist"
+	upgradelog "github.com/greenplum-db/gpupgrade/utils/log"
 )
 
 func gpupgrade_agent() {
@@ -68,7 +72,7 @@ func TestRestartAgent(t *testing.T) {
 			return listener.Dial()
 		}
 
-		restartedHosts, err := hub.RestartAgents(ctx, dialer, hostnames, port, stateDir)
+		restartedHosts, err := hub.RestartAgents(ctx, dialer, hostnames, port, stateDir, nil, upgradelog.Rotation{})
 		if err != nil {
 			t.Errorf("returned %#v", err)
 		}
@@ -88,7 +92,7 @@ func TestRestartAgent(t *testing.T) {
 			return listener.Dial()
 		}
 
-		restartedHosts, err := hub.RestartAgents(ctx, dialer, hostnames, port, stateDir)
+		restartedHosts, err := hub.RestartAgents(ctx, dialer, hostnames, port, stateDir, nil, upgradelog.Rotation{})
 		if err != nil {
 			t.Errorf("returned %#v", err)
 		}
@@ -111,7 +115,7 @@ func TestRestartAgent(t *testing.T) {
 			return nil, immediateFailure{}
 		}
 
-		restartedHosts, err := hub.RestartAgents(ctx, dialer, hostnames, port, stateDir)
+		restartedHosts, err := hub.RestartAgents(ctx, dialer, hostnames, port, stateDir, nil, upgradelog.Rotation{})
 		if err == nil {
 			t.Errorf("expected restart agents to fail")
 		}
@@ -162,11 +166,120 @@ func TestRestartAgent(t *testing.T) {
 			return listener.Dial()
 		}
 
-		_, err := hub.RestartAgents(ctx, dialer, hostnames, port, stateDir)
+		_, err := hub.RestartAgents(ctx, dialer, hostnames, port, stateDir, nil, upgradelog.Rotation{})
 		if err != nil {
 			t.Errorf("unexpected errr %#v", err)
 		}
 	})
+
+	t.Run("starts agents with TLS when connecting to agents with TLS", func(t *testing.T) {
+		host := "host1"
+
+		certDir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, certDir)
+
+		tlsConf := testutils.MustWriteCerts(t, certDir)
+		tlsConf.VerifyClient = true
+
+		execCmd := exectest.NewCommandWithVerifier(gpupgrade_agent, func(name string, args ...string) {
+			cmd := fmt.Sprintf("bash -c \"%s/gpupgrade agent --daemonize --port %d --state-directory %s --tls --tls-verify-client\"", testutils.MustGetExecutablePath(t), port, stateDir)
+			expected := []string{host, cmd}
+			if !reflect.DeepEqual(args, expected) {
+				t.Errorf("got %q want %q", args, expected)
+			}
+		})
+		hub.SetExecCommand(execCmd)
+		defer hub.ResetExecCommand()
+
+		dialer := func(ctx context.Context, address string) (net.Conn, error) {
+			return nil, immediateFailure{}
+		}
+
+		_, err := hub.RestartAgents(ctx, dialer, []string{host}, port, stateDir, &tlsConf, upgradelog.Rotation{})
+		if err != nil {
+			t.Errorf("unexpected errr %#v", err)
+		}
+	})
+
+	t.Run("starts agents with the hub's log rotation", func(t *testing.T) {
+		host := "host1"
+
+		execCmd := exectest.NewCommandWithVerifier(gpupgrade_agent, func(name string, args ...string) {
+			cmd := fmt.Sprintf("bash -c \"%s/gpupgrade agent --daemonize --port %d --state-directory %s --log-max-size 10 --log-max-age 7\"", testutils.MustGetExecutablePath(t), port, stateDir)
+			expected := []string{host, cmd}
+			if !reflect.DeepEqual(args, expected) {
+				t.Errorf("got %q want %q", args, expected)
+			}
+		})
+		hub.SetExecCommand(execCmd)
+		defer hub.ResetExecCommand()
+
+		dialer := func(ctx context.Context, address string) (net.Conn, error) {
+			return nil, immediateFailure{}
+		}
+
+		_, err := hub.RestartAgents(ctx, dialer, []string{host}, port, stateDir, nil, upgradelog.NewRotation(10, 7))
+		if err != nil {
+			t.Errorf("unexpected error %#v", err)
+		}
+	})
+
+	t.Run("errors when the TLS certificates cannot be loaded", func(t *testing.T) {
+		tlsConf := certs.StateDirConfig("/does/not/exist")
+
+		_, err := hub.RestartAgents(ctx, nil, hostnames, port, stateDir, &tlsConf, upgradelog.Rotation{})
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("got error %#v want %#v", err, os.ErrNotExist)
+		}
+	})
+
+	t.Run("limits the number of hosts started concurrently", func(t *testing.T) {
+		hub.SetExecCommand(exectest.NewCommand(gpupgrade_agent))
+		defer hub.ResetExecCommand()
+
+		previous := hub.MaxConcurrentAgentStarts
+		hub.MaxConcurrentAgentStarts = 2
+		defer func() {
+			hub.MaxConcurrentAgentStarts = previous
+		}()
+
+		var mu sync.Mutex
+		var inFlight, peak int
+		dialer := func(ctx context.Context, address string) (net.Conn, error) {
+			mu.Lock()
+			inFlight++
+			if inFlight > peak {
+				peak = inFlight
+			}
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+
+			return nil, immediateFailure{}
+		}
+
+		hosts := []string{"sdw1", "sdw2", "sdw3", "sdw4", "sdw5", "sdw6"}
+		restartedHosts, err := hub.RestartAgents(ctx, dialer, hosts, port, stateDir, nil, upgradelog.Rotation{})
+		if err != nil {
+			t.Errorf("unexpected error %#v", err)
+		}
+
+		if len(restartedHosts) != len(hosts) {
+			t.Errorf("got %d restarted hosts want %d", len(restartedHosts), len(hosts))
+		}
+
+		if peak > 2 {
+			t.Errorf("got peak concurrency %d want at most 2", peak)
+		}
+
+		if peak < 2 {
+			t.Errorf("got peak concurrency %d, expected hosts to be started in parallel", peak)
+		}
+	})
 }
 
 // immediateFailure is an error that is explicitly marked non-temporary for
@@ -0,0 +1,58 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/greenplum-db/gpupgrade/upgrade"
+)
+
+// NewIdempotencyToken returns a token identifying one operation on the
+// agents, such as deleting the data directories of a step. Tests may replace
+// it to make requests predictable.
+var NewIdempotencyToken = func() string {
+	return upgrade.NewID().String()
+}
+
+// AgentRetries is the number of times a mutating request is resent after the
+// agent could not be reached or did not respond in time. Each retry carries
+// the request's original idempotency token, so an agent that already finished
+// the operation returns its recorded reply rather than running it again.
+var AgentRetries = 3
+
+// AgentRetryDelay is how long to wait before resending a request.
+var AgentRetryDelay = 5 * time.Second
+
+// retryAgentRequest calls request until it succeeds, fails with an error that
+// a retry will not fix, ctx is done, or AgentRetries retries have been made.
+// request must send the same idempotency token on every call.
+func retryAgentRequest(ctx context.Context, host string, request func(ctx context.Context) error) error {
+	for retry := 0; ; retry++ {
+		err := request(ctx)
+		if err == nil || !isTransientAgentError(err) || retry >= AgentRetries || ctx.Err() != nil {
+			return err
+		}
+
+		gplog.Warn("retrying request to agent on host %q (%d of %d): %v", host, retry+1, AgentRetries, err)
+
+		select {
+		case <-time.After(AgentRetryDelay):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// isTransientAgentError returns whether err is from an agent that could not be
+// reached or did not respond in time, rather than one that failed the request.
+func isTransientAgentError(err error) bool {
+	return status.Code(err) == codes.Unavailable || errors.Is(err, ErrAgentTimeout)
+}
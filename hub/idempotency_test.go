@@ -0,0 +1,110 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/greenplum-db/gpupgrade/hub"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/idl/mock_idl"
+	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+)
+
+func TestAgentRequestRetries(t *testing.T) {
+	testlog.SetupLogger()
+
+	renames := hub.RenameMap{
+		"sdw1": {{Source: "/data/dbfast1/seg1_123ABC", Target: "/data/dbfast1/seg1"}},
+	}
+
+	resetDelay := hub.AgentRetryDelay
+	hub.AgentRetryDelay = 0
+	defer func() { hub.AgentRetryDelay = resetDelay }()
+
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+
+	t.Run("resends the same token when the agent is unavailable and uses a new token for the next operation", func(t *testing.T) {
+		resetToken := hub.NewIdempotencyToken
+		defer func() { hub.NewIdempotencyToken = resetToken }()
+
+		var count int
+		hub.NewIdempotencyToken = func() string {
+			count++
+			return fmt.Sprintf("token-%d", count)
+		}
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		var tokens []string
+		client := mock_idl.NewMockAgentClient(ctrl)
+		client.EXPECT().RenameDirectories(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req *idl.RenameDirectoriesRequest, _ ...interface{}) (*idl.RenameDirectoriesReply, error) {
+				tokens = append(tokens, req.GetIdempotencyToken())
+				if len(tokens) == 1 {
+					return nil, unavailable
+				}
+				return &idl.RenameDirectoriesReply{}, nil
+			}).Times(3)
+
+		agentConns := []*hub.Connection{{nil, client, "sdw1", nil}}
+
+		for i := 0; i < 2; i++ {
+			err := hub.RenameSegmentDataDirs(context.Background(), agentConns, renames, 0)
+			if err != nil {
+				t.Fatalf("unexpected error: %#v", err)
+			}
+		}
+
+		expected := []string{"token-1", "token-1", "token-2"}
+		if !reflect.DeepEqual(tokens, expected) {
+			t.Errorf("sent tokens %q, want %q", tokens, expected)
+		}
+	})
+
+	t.Run("does not retry requests that the agent failed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expected := errors.New("permission denied")
+
+		client := mock_idl.NewMockAgentClient(ctrl)
+		client.EXPECT().RenameDirectories(gomock.Any(), gomock.Any()).
+			Return(nil, expected).
+			Times(1)
+
+		agentConns := []*hub.Connection{{nil, client, "sdw1", nil}}
+
+		err := hub.RenameSegmentDataDirs(context.Background(), agentConns, renames, 0)
+		if !errors.Is(err, expected) {
+			t.Errorf("returned error %#v, want %#v", err, expected)
+		}
+	})
+
+	t.Run("gives up once the retries are used", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock_idl.NewMockAgentClient(ctrl)
+		client.EXPECT().RenameDirectories(gomock.Any(), gomock.Any()).
+			Return(nil, unavailable).
+			Times(hub.AgentRetries + 1)
+
+		agentConns := []*hub.Connection{{nil, client, "sdw1", nil}}
+
+		err := hub.RenameSegmentDataDirs(context.Background(), agentConns, renames, 0)
+		if !errors.Is(err, unavailable) {
+			t.Errorf("returned error %#v, want %#v", err, unavailable)
+		}
+	})
+}
@@ -6,9 +6,9 @@ package hub
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
@@ -23,6 +23,7 @@ import (
 	"github.com/greenplum-db/gpupgrade/db"
 	"github.com/greenplum-db/gpupgrade/greenplum"
 	"github.com/greenplum-db/gpupgrade/step"
+	"github.com/greenplum-db/gpupgrade/utils"
 )
 
 var ErrUnknownCatalogVersion = errors.New("pg_controldata output is missing catalog version")
@@ -61,7 +62,7 @@ func (s *Server) writeConf(sourceDBConn *dbconn.DBConn) error {
 	return WriteInitsystemFile(gpinitsystemConfig, s.initsystemConfPath())
 }
 
-func (s *Server) RemoveTargetCluster(streams step.OutStreams) error {
+func (s *Server) RemoveTargetCluster(ctx context.Context, streams step.OutStreams) error {
 	if s.Target == nil {
 		return nil
 	}
@@ -77,7 +78,8 @@ func (s *Server) RemoveTargetCluster(streams step.OutStreams) error {
 		}
 	}
 
-	err = DeleteMasterAndPrimaryDataDirectories(streams, s.agentConns, s.TargetInitializeConfig)
+	master, primaries := revertPlan(s.Config)
+	err = DeleteMasterAndPrimaryDataDirectories(ctx, streams, s.agentConns, master, primaries, semver.MustParse(s.Target.Version.SemVer.String()))
 	if err != nil {
 		return xerrors.Errorf("deleting target cluster data directories: %w", err)
 	}
@@ -159,7 +161,7 @@ func CreateInitialInitsystemConfig(targetMasterDataDir string, useHbaHostnames b
 func WriteInitsystemFile(gpinitsystemConfig []string, gpinitsystemFilepath string) error {
 	gpinitsystemContents := []byte(strings.Join(gpinitsystemConfig, "\n"))
 
-	err := ioutil.WriteFile(gpinitsystemFilepath, gpinitsystemContents, 0644)
+	err := utils.AtomicWriteFile(gpinitsystemFilepath, gpinitsystemContents, 0644)
 	if err != nil {
 		return xerrors.Errorf("write gpinitsystem_config file: %w", err)
 	}
@@ -21,7 +21,7 @@ import (
 )
 
 func (s *Server) Initialize(in *idl.InitializeRequest, stream idl.CliToHub_InitializeServer) (err error) {
-	st, err := step.Begin(idl.Step_INITIALIZE, stream)
+	st, err := s.beginStep(idl.Step_INITIALIZE, stream)
 	if err != nil {
 		return err
 	}
@@ -84,7 +84,7 @@ func (s *Server) Initialize(in *idl.InitializeRequest, stream idl.CliToHub_Initi
 	})
 
 	st.Run(idl.Substep_START_AGENTS, func(_ step.OutStreams) error {
-		_, err := RestartAgents(context.Background(), nil, AgentHosts(s.Source), s.AgentPort, s.StateDir)
+		_, err := RestartAgents(context.Background(), nil, AgentHosts(s.Source), s.AgentPort, s.StateDir, s.AgentTLS, s.LogRotation)
 		return err
 	})
 
@@ -101,11 +101,14 @@ func (s *Server) Initialize(in *idl.InitializeRequest, stream idl.CliToHub_Initi
 }
 
 func (s *Server) InitializeCreateCluster(in *idl.InitializeCreateClusterRequest, stream idl.CliToHub_InitializeCreateClusterServer) (err error) {
-	st, err := step.Begin(idl.Step_INITIALIZE, stream)
+	st, err := s.beginStep(idl.Step_INITIALIZE, stream)
 	if err != nil {
 		return err
 	}
 
+	ctx, cancel := WithStepBudget(context.Background(), s.StepBudget)
+	defer cancel()
+
 	defer func() {
 		if ferr := st.Finish(); ferr != nil {
 			err = errorlist.Append(err, ferr)
@@ -121,7 +124,7 @@ func (s *Server) InitializeCreateCluster(in *idl.InitializeCreateClusterRequest,
 	})
 
 	st.Run(idl.Substep_INIT_TARGET_CLUSTER, func(stream step.OutStreams) error {
-		err := s.RemoveTargetCluster(stream)
+		err := s.RemoveTargetCluster(ctx, stream)
 		if err != nil {
 			return err
 		}
@@ -166,7 +169,7 @@ func (s *Server) InitializeCreateCluster(in *idl.InitializeCreateClusterRequest,
 			return err
 		}
 
-		return s.CheckUpgrade(stream, conns)
+		return s.CheckUpgrade(ctx, stream, conns)
 	})
 
 	message := &idl.Message{Contents: &idl.Message_Response{Response: &idl.Response{Contents: &idl.Response_InitializeResponse{
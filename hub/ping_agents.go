@@ -0,0 +1,101 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+)
+
+// MaxConcurrentPings bounds the number of agent pings PingAllAgents has in
+// flight at once.
+var MaxConcurrentPings = 32
+
+// HostStatus is the result of pinging the agent on a single host. Err is nil
+// when the agent is reachable.
+type HostStatus struct {
+	Hostname string
+	Err      error
+}
+
+func (h HostStatus) Reachable() bool {
+	return h.Err == nil
+}
+
+// UnreachableAgentsError is returned from PingAllAgents when one or more
+// agents did not respond before the timeout.
+type UnreachableAgentsError struct {
+	Hosts []string
+}
+
+func (u *UnreachableAgentsError) Error() string {
+	return "agents are unreachable on hosts: " + strings.Join(u.Hosts, ", ")
+}
+
+// PingAllAgents calls Version on the agent behind each connection, and reports
+// the status of each host sorted by hostname. An UnreachableAgentsError
+// listing the failed hosts is returned if any agent did not reply within the
+// timeout. Unlike the state of a connection, a reply shows the agent is still
+// serving requests, so AgentConns pings before reusing its connections rather
+// than discovering a dead host midway through a step.
+func PingAllAgents(conns []*Connection, timeout time.Duration) ([]HostStatus, error) {
+	conns = append([]*Connection(nil), conns...)
+	sort.Slice(conns, func(i, j int) bool {
+		return conns[i].Hostname < conns[j].Hostname
+	})
+
+	statuses := make([]HostStatus, len(conns))
+	sem := make(chan struct{}, MaxConcurrentPings)
+
+	var wg sync.WaitGroup
+	for i, conn := range conns {
+		i, conn := i, conn
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			statuses[i] = HostStatus{Hostname: conn.Hostname, Err: pingAgent(conn, timeout)}
+		}()
+	}
+
+	wg.Wait()
+
+	var unreachable []string
+	for _, status := range statuses {
+		if !status.Reachable() {
+			gplog.Debug("failed to ping agent on %s: %+v", status.Hostname, status.Err)
+			unreachable = append(unreachable, status.Hostname)
+		}
+	}
+
+	if len(unreachable) > 0 {
+		return statuses, &UnreachableAgentsError{Hosts: unreachable}
+	}
+
+	return statuses, nil
+}
+
+func pingAgent(conn *Connection, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	_, err := conn.AgentClient.Version(ctx, &idl.VersionRequest{})
+	if err != nil {
+		return xerrors.Errorf("ping agent on host %s: %w", conn.Hostname, err)
+	}
+
+	return nil
+}
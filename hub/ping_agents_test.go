@@ -0,0 +1,131 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/greenplum-db/gpupgrade/hub"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/idl/mock_idl"
+	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+)
+
+func TestPingAllAgents(t *testing.T) {
+	testlog.SetupLogger()
+
+	t.Run("reports all agents as reachable ordered by host", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		sdw1 := mock_idl.NewMockAgentClient(ctrl)
+		sdw1.EXPECT().Version(gomock.Any(), &idl.VersionRequest{}).
+			Return(&idl.VersionReply{}, nil)
+
+		sdw2 := mock_idl.NewMockAgentClient(ctrl)
+		sdw2.EXPECT().Version(gomock.Any(), &idl.VersionRequest{}).
+			Return(&idl.VersionReply{}, nil)
+
+		conns := []*hub.Connection{
+			{nil, sdw2, "sdw2", nil},
+			{nil, sdw1, "sdw1", nil},
+		}
+
+		statuses, err := hub.PingAllAgents(conns, time.Second)
+		if err != nil {
+			t.Errorf("unexpected error %#v", err)
+		}
+
+		expected := []hub.HostStatus{{Hostname: "sdw1"}, {Hostname: "sdw2"}}
+		if !reflect.DeepEqual(statuses, expected) {
+			t.Errorf("got %v want %v", statuses, expected)
+		}
+	})
+
+	t.Run("returns the unreachable hosts", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		sdw1 := mock_idl.NewMockAgentClient(ctrl)
+		sdw1.EXPECT().Version(gomock.Any(), gomock.Any()).
+			Return(&idl.VersionReply{}, nil)
+
+		expected := errors.New("connection refused")
+		sdw2 := mock_idl.NewMockAgentClient(ctrl)
+		sdw2.EXPECT().Version(gomock.Any(), gomock.Any()).
+			Return(nil, expected)
+
+		conns := []*hub.Connection{
+			{nil, sdw1, "sdw1", nil},
+			{nil, sdw2, "sdw2", nil},
+		}
+
+		statuses, err := hub.PingAllAgents(conns, time.Second)
+
+		var unreachableErr *hub.UnreachableAgentsError
+		if !errors.As(err, &unreachableErr) {
+			t.Fatalf("got error %#v want type %T", err, unreachableErr)
+		}
+
+		if !reflect.DeepEqual(unreachableErr.Hosts, []string{"sdw2"}) {
+			t.Errorf("got unreachable hosts %q want %q", unreachableErr.Hosts, []string{"sdw2"})
+		}
+
+		if len(statuses) != 2 {
+			t.Fatalf("got %d statuses want 2", len(statuses))
+		}
+
+		if !statuses[0].Reachable() {
+			t.Errorf("expected %q to be reachable", statuses[0].Hostname)
+		}
+
+		if !errors.Is(statuses[1].Err, expected) {
+			t.Errorf("got error %#v want %#v", statuses[1].Err, expected)
+		}
+	})
+
+	t.Run("times out when an agent does not respond", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		unresponsive := func(ctx context.Context, in *idl.VersionRequest, opts ...interface{}) (*idl.VersionReply, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+
+		sdw1 := mock_idl.NewMockAgentClient(ctrl)
+		sdw1.EXPECT().Version(gomock.Any(), gomock.Any()).DoAndReturn(unresponsive)
+
+		sdw2 := mock_idl.NewMockAgentClient(ctrl)
+		sdw2.EXPECT().Version(gomock.Any(), gomock.Any()).DoAndReturn(unresponsive)
+
+		conns := []*hub.Connection{
+			{nil, sdw1, "sdw1", nil},
+			{nil, sdw2, "sdw2", nil},
+		}
+
+		statuses, err := hub.PingAllAgents(conns, 10*time.Millisecond)
+
+		var unreachableErr *hub.UnreachableAgentsError
+		if !errors.As(err, &unreachableErr) {
+			t.Fatalf("got error %#v want type %T", err, unreachableErr)
+		}
+
+		if !reflect.DeepEqual(unreachableErr.Hosts, []string{"sdw1", "sdw2"}) {
+			t.Errorf("got unreachable hosts %q want %q", unreachableErr.Hosts, []string{"sdw1", "sdw2"})
+		}
+
+		for _, status := range statuses {
+			if !errors.Is(status.Err, context.DeadlineExceeded) {
+				t.Errorf("got error %#v want %#v", status.Err, context.DeadlineExceeded)
+			}
+		}
+	})
+}
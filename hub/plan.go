@@ -0,0 +1,281 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/blang/semver/v4"
+	"github.com/kballard/go-shellquote"
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/greenplum"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/upgrade"
+	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/rsync"
+)
+
+// ExecutePlanFile is the name of the file in the state directory holding the
+// plan of the last execute.
+const ExecutePlanFile = "execute-plan.json"
+
+// HostPlan is the destructive work a single host performs during a phase.
+type HostPlan struct {
+	// DeleteDataDirs are data directories that will be removed.
+	DeleteDataDirs []string
+
+	// DeleteTablespaceDirs are tablespace directories that will be removed.
+	DeleteTablespaceDirs []string
+
+	// Archives are the source data directories that will be archived, and
+	// optionally replaced by their target data directories.
+	Archives []*idl.RenameDirectories
+
+	// Commands are the commands that will be run, in order, as shell-quoted
+	// command lines.
+	Commands []string
+}
+
+// Plan maps each hostname to the work it performs during a phase. A Plan can
+// be serialized to JSON so that it can be reviewed or diffed against a prior
+// run before anything is executed.
+type Plan map[string]*HostPlan
+
+// UpgradePlan is the work each host performs during the execute and finalize
+// phases, which together carry out the upgrade.
+type UpgradePlan struct {
+	Execute  Plan
+	Finalize Plan
+}
+
+// NewUpgradePlan computes the plans for the execute and finalize phases.
+func NewUpgradePlan(conf *Config, stateDir string) (*UpgradePlan, error) {
+	execute, err := ExecutePlan(conf, stateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UpgradePlan{
+		Execute:  execute,
+		Finalize: FinalizePlan(conf),
+	}, nil
+}
+
+func (p Plan) host(hostname string) *HostPlan {
+	if p[hostname] == nil {
+		p[hostname] = &HostPlan{}
+	}
+
+	return p[hostname]
+}
+
+func (h *HostPlan) addCommand(name string, args ...string) {
+	h.Commands = append(h.Commands, shellquote.Join(append([]string{name}, args...)...))
+}
+
+func (h *HostPlan) addRsync(options ...rsync.Option) error {
+	args, err := rsync.Args(options...)
+	if err != nil {
+		return err
+	}
+
+	h.addCommand("rsync", args...)
+	return nil
+}
+
+// ExecutePlan computes the commands run on each host during execute, in the
+// order of its substeps. The agents' restores of the copied master tablespaces
+// into the primary tablespace directories are not listed.
+func ExecutePlan(conf *Config, stateDir string) (Plan, error) {
+	plan := make(Plan)
+	master := plan.host(conf.Source.MasterHostname())
+
+	// SHUTDOWN_SOURCE_CLUSTER
+	master.addCommand("bash", "-c", conf.Source.StopCommand())
+
+	// UPGRADE_MASTER
+	backupDir := filepath.Join(stateDir, originalMasterBackupName)
+	if err := master.addRsync(masterDataDirRsyncOptions(backupDir, conf.Target.MasterDataDir())...); err != nil {
+		return nil, err
+	}
+
+	// FIXME: conf.Target.Version comes from gp-common-go-libs, which uses a
+	//  deprecated version of semver.
+	targetVersion := semver.MustParse(conf.Target.Version.SemVer.String())
+
+	path, args := upgrade.Command(masterSegmentPair(conf.Source, conf.Target), targetVersion, masterUpgradeOptions(conf.Source, false, conf.UseLinkMode)...)
+	master.addCommand(path, args...)
+
+	// COPY_MASTER
+	upgradedMasterBackupDir := filepath.Join(stateDir, executeMasterBackupName)
+	for _, hostname := range conf.Target.PrimaryHostnames() {
+		if err := master.addRsync(copyOptions(upgradedMasterBackupDir, masterDataDirCopySources(conf), hostname)...); err != nil {
+			return nil, err
+		}
+
+		if conf.Tablespaces != nil {
+			tablespaceDir := utils.GetTablespaceDir() + string(os.PathSeparator)
+			if err := master.addRsync(copyOptions(tablespaceDir, masterTablespaceCopySources(conf), hostname)...); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// UPGRADE_PRIMARIES
+	dataDirPairs, err := conf.GetDataDirPairs()
+	if err != nil {
+		return nil, err
+	}
+
+	for hostname, pairs := range dataDirPairs {
+		host := plan.host(hostname)
+
+		for _, pair := range pairs {
+			if err := host.addRsync(upgrade.MasterBackupRsyncOptions(upgradedMasterBackupDir, pair.TargetDataDir)...); err != nil {
+				return nil, err
+			}
+
+			dbid := int(pair.DBID)
+			segmentPair := upgrade.SegmentPair{
+				Source: &upgrade.Segment{BinDir: filepath.Join(conf.Source.GPHome, "bin"), DataDir: pair.SourceDataDir, DBID: dbid, Port: int(pair.SourcePort)},
+				Target: &upgrade.Segment{BinDir: filepath.Join(conf.Target.GPHome, "bin"), DataDir: pair.TargetDataDir, DBID: dbid, Port: int(pair.TargetPort)},
+			}
+
+			path, args := upgrade.Command(segmentPair, targetVersion, upgrade.PrimaryOptions(false, conf.UseLinkMode, conf.TablespacesMappingFilePath)...)
+			host.addCommand(path, args...)
+		}
+	}
+
+	// START_TARGET_CLUSTER
+	master.addCommand("bash", "-c", conf.Target.StartCommand())
+
+	return plan, nil
+}
+
+// SaveExecutePlan writes the plan of the execute phase to the state directory
+// before anything is executed, so that it can be diffed against the output of
+// "gpupgrade execute --plan" or a prior run.
+func SaveExecutePlan(plan Plan, stateDir string) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return xerrors.Errorf("marshaling execute plan: %w", err)
+	}
+
+	return utils.AtomicWriteFile(filepath.Join(stateDir, ExecutePlanFile), data, 0600)
+}
+
+// FinalizePlan computes the data directories that are archived and deleted
+// during the finalize UPDATE_DATA_DIRECTORIES substep.
+func FinalizePlan(conf *Config) Plan {
+	master, segments := finalizePlan(conf)
+	return segments.withMaster(conf.Source.MasterHostname(), master)
+}
+
+// finalizePlan is FinalizePlan with the work on the master data directory,
+// which the hub performs itself, split from the work of the agents.
+func finalizePlan(conf *Config) (*HostPlan, Plan) {
+	master := &HostPlan{
+		Archives: []*idl.RenameDirectories{{
+			Source:       conf.Source.MasterDataDir(),
+			Target:       conf.TargetInitializeConfig.Master.DataDir,
+			RenameTarget: true,
+		}},
+	}
+
+	segments := make(Plan)
+	if conf.UseLinkMode {
+		mirrorsAndStandby := conf.Source.SelectSegments(func(seg *greenplum.SegConfig) bool {
+			return seg.IsMirror() || seg.IsStandby()
+		})
+
+		for _, seg := range mirrorsAndStandby {
+			host := segments.host(seg.Hostname)
+			host.DeleteDataDirs = append(host.DeleteDataDirs, seg.DataDir)
+			host.DeleteTablespaceDirs = append(host.DeleteTablespaceDirs, conf.Tablespaces[seg.DbID].UserDefinedTablespacesLocations()...)
+		}
+	}
+
+	for hostname, renames := range getRenameMap(conf.Source, conf.TargetInitializeConfig, conf.UseLinkMode) {
+		host := segments.host(hostname)
+		host.Archives = append(host.Archives, renames...)
+	}
+
+	return master, segments
+}
+
+// RevertPlan computes the target cluster data and tablespace directories that
+// are deleted during revert.
+func RevertPlan(conf *Config) Plan {
+	master, primaries := revertPlan(conf)
+	return primaries.withMaster(conf.TargetInitializeConfig.Master.Hostname, master)
+}
+
+// revertPlan is RevertPlan with the work on the master, which the hub performs
+// itself, split from the work of the agents.
+func revertPlan(conf *Config) (*HostPlan, Plan) {
+	master := &HostPlan{}
+	primaries := make(Plan)
+
+	if conf.TargetInitializeConfig.Primaries == nil || conf.TargetInitializeConfig.Master.DataDir == "" {
+		return master, primaries
+	}
+
+	master.DeleteDataDirs = append(master.DeleteDataDirs, conf.TargetInitializeConfig.Master.DataDir)
+	for _, seg := range conf.TargetInitializeConfig.Primaries {
+		host := primaries.host(seg.Hostname)
+		host.DeleteDataDirs = append(host.DeleteDataDirs, seg.DataDir)
+	}
+
+	if conf.Target == nil {
+		return master, primaries
+	}
+
+	major := conf.Target.Version.SemVer.Major
+
+	master.DeleteTablespaceDirs = targetTablespaceDirs(conf.Tablespaces.GetMasterTablespaces(), conf.Target.Master().DbID, major, conf.TargetCatalogVersion)
+
+	targetPrimaries := conf.Target.SelectSegments(func(seg *greenplum.SegConfig) bool {
+		return seg.IsPrimary() && !seg.IsMaster()
+	})
+
+	for _, seg := range targetPrimaries {
+		if dirs := targetTablespaceDirs(conf.Tablespaces[seg.DbID], seg.DbID, major, conf.TargetCatalogVersion); len(dirs) > 0 {
+			host := primaries.host(seg.Hostname)
+			host.DeleteTablespaceDirs = append(host.DeleteTablespaceDirs, dirs...)
+		}
+	}
+
+	return master, primaries
+}
+
+// withMaster returns the plan with the work on the master listed first on its
+// host.
+func (p Plan) withMaster(hostname string, master *HostPlan) Plan {
+	if len(master.DeleteDataDirs) == 0 && len(master.DeleteTablespaceDirs) == 0 && len(master.Archives) == 0 && len(master.Commands) == 0 {
+		return p
+	}
+
+	host := p.host(hostname)
+	host.DeleteDataDirs = append(append([]string(nil), master.DeleteDataDirs...), host.DeleteDataDirs...)
+	host.DeleteTablespaceDirs = append(append([]string(nil), master.DeleteTablespaceDirs...), host.DeleteTablespaceDirs...)
+	host.Archives = append(append([]*idl.RenameDirectories(nil), master.Archives...), host.Archives...)
+	host.Commands = append(append([]string(nil), master.Commands...), host.Commands...)
+
+	return p
+}
+
+// renames returns the archives of each host in the plan.
+func (p Plan) renames() RenameMap {
+	m := make(RenameMap)
+	for hostname, host := range p {
+		if len(host.Archives) > 0 {
+			m[hostname] = host.Archives
+		}
+	}
+
+	return m
+}
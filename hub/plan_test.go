@@ -0,0 +1,223 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/greenplum-db/gp-common-go-libs/dbconn"
+
+	"github.com/greenplum-db/gpupgrade/greenplum"
+	"github.com/greenplum-db/gpupgrade/hub"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/testutils"
+)
+
+func TestPlans(t *testing.T) {
+	source := hub.MustCreateCluster(t, []greenplum.SegConfig{
+		{ContentID: -1, DbID: 1, Port: 15432, Hostname: "mdw", DataDir: "/data/qddir/seg-1", Role: greenplum.PrimaryRole},
+		{ContentID: -1, DbID: 2, Port: 16432, Hostname: "smdw", DataDir: "/data/standby", Role: greenplum.MirrorRole},
+		{ContentID: 0, DbID: 3, Port: 25433, Hostname: "sdw1", DataDir: "/data/dbfast1/seg1", Role: greenplum.PrimaryRole},
+		{ContentID: 0, DbID: 4, Port: 25434, Hostname: "sdw2", DataDir: "/data/dbfast_mirror1/seg1", Role: greenplum.MirrorRole},
+	})
+
+	target := hub.MustCreateCluster(t, []greenplum.SegConfig{
+		{ContentID: -1, DbID: 1, Port: 15433, Hostname: "mdw", DataDir: "/data/qddir/seg-1_123ABC-1", Role: greenplum.PrimaryRole},
+		{ContentID: 0, DbID: 3, Port: 25435, Hostname: "sdw1", DataDir: "/data/dbfast1/seg1_123ABC", Role: greenplum.PrimaryRole},
+	})
+	target.Version = dbconn.NewVersion("6.1.0")
+	target.GPHome = "/usr/local/gpdb6"
+
+	source.Version = dbconn.NewVersion("5.28.0")
+	source.GPHome = "/usr/local/gpdb5"
+
+	conf := &hub.Config{
+		Source: source,
+		Target: target,
+		TargetInitializeConfig: hub.InitializeConfig{
+			Master:    target.Primaries[-1],
+			Primaries: []greenplum.SegConfig{target.Primaries[0]},
+		},
+		Tablespaces: greenplum.Tablespaces{
+			1: {16386: {Location: "/tmp/master/1/16386", UserDefined: 1}},
+			3: {16386: {Location: "/tmp/primary1/3/16386", UserDefined: 1}},
+			4: {16386: {Location: "/tmp/mirror1/4/16386", UserDefined: 1}},
+		},
+		TablespacesMappingFilePath: "/home/gpadmin/.gpupgrade/tablespaces.txt",
+		TargetCatalogVersion:       "301908232",
+		UseLinkMode:                true,
+	}
+
+	t.Run("FinalizePlan archives data directories and deletes mirrors in link mode", func(t *testing.T) {
+		plan := hub.FinalizePlan(conf)
+
+		expected := hub.Plan{
+			"mdw": {
+				Archives: []*idl.RenameDirectories{
+					{Source: "/data/qddir/seg-1", Target: "/data/qddir/seg-1_123ABC-1", RenameTarget: true},
+				},
+			},
+			"smdw": {
+				DeleteDataDirs: []string{"/data/standby"},
+			},
+			"sdw1": {
+				Archives: []*idl.RenameDirectories{
+					{Source: "/data/dbfast1/seg1", Target: "/data/dbfast1/seg1_123ABC", RenameTarget: true},
+				},
+			},
+			"sdw2": {
+				DeleteDataDirs:       []string{"/data/dbfast_mirror1/seg1"},
+				DeleteTablespaceDirs: []string{"/tmp/mirror1/4/16386"},
+			},
+		}
+
+		if !reflect.DeepEqual(plan, expected) {
+			t.Errorf("got %+v want %+v", plan, expected)
+		}
+	})
+
+	t.Run("RevertPlan deletes target data and tablespace directories", func(t *testing.T) {
+		plan := hub.RevertPlan(conf)
+
+		expected := hub.Plan{
+			"mdw": {
+				DeleteDataDirs:       []string{"/data/qddir/seg-1_123ABC-1"},
+				DeleteTablespaceDirs: []string{"/tmp/master/1/16386/1/GPDB_6_301908232"},
+			},
+			"sdw1": {
+				DeleteDataDirs:       []string{"/data/dbfast1/seg1_123ABC"},
+				DeleteTablespaceDirs: []string{"/tmp/primary1/3/16386/3/GPDB_6_301908232"},
+			},
+		}
+
+		if !reflect.DeepEqual(plan, expected) {
+			t.Errorf("got %+v want %+v", plan, expected)
+		}
+	})
+
+	t.Run("RevertPlan is empty when the target cluster was never initialized", func(t *testing.T) {
+		plan := hub.RevertPlan(&hub.Config{})
+
+		if len(plan) != 0 {
+			t.Errorf("got %+v want an empty plan", plan)
+		}
+	})
+
+	t.Run("ExecutePlan lists the commands run on each host", func(t *testing.T) {
+		resetEnv := testutils.SetEnv(t, "GPUPGRADE_HOME", "/home/gpadmin/.gpupgrade")
+		defer resetEnv()
+
+		plan, err := hub.ExecutePlan(conf, "/home/gpadmin/.gpupgrade")
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		expected := hub.Plan{
+			"mdw": {
+				Commands: []string{
+					"bash -c 'source /usr/local/gpdb5/greenplum_path.sh && MASTER_DATA_DIRECTORY=/data/qddir/seg-1 /usr/local/gpdb5/bin/gpstop -a -d /data/qddir/seg-1'",
+					"rsync --archive --delete /home/gpadmin/.gpupgrade/master.bak/ /data/qddir/seg-1_123ABC-1 --exclude pg_log/\\*",
+					"/usr/local/gpdb6/bin/pg_upgrade --retain --old-bindir /usr/local/gpdb5/bin --new-bindir /usr/local/gpdb6/bin " +
+						"--old-datadir /data/qddir/seg-1 --new-datadir /data/qddir/seg-1_123ABC-1 --old-port 15432 --new-port 15433 " +
+						"--mode dispatcher --old-gp-dbid 1 --new-gp-dbid 1 --link --old-options '-x 2'",
+					"rsync --archive --compress --delete --stats /data/qddir/seg-1_123ABC-1/ sdw1:/home/gpadmin/.gpupgrade/upgraded-master.bak",
+					"rsync --archive --compress --delete --stats /home/gpadmin/.gpupgrade/tablespaces.txt /tmp/master/1/16386 sdw1:/home/gpadmin/.gpupgrade/tablespaces/",
+					"bash -c 'source /usr/local/gpdb6/greenplum_path.sh && MASTER_DATA_DIRECTORY=/data/qddir/seg-1_123ABC-1 /usr/local/gpdb6/bin/gpstart -a -d /data/qddir/seg-1_123ABC-1'",
+				},
+			},
+			"sdw1": {
+				Commands: []string{
+					"rsync --archive --delete /home/gpadmin/.gpupgrade/upgraded-master.bak/ /data/dbfast1/seg1_123ABC " +
+						"--exclude internal.auto.conf --exclude postgresql.conf --exclude pg_hba.conf --exclude postmaster.opts " +
+						"--exclude gp_dbid --exclude gpssh.conf --exclude gpperfmon",
+					"/usr/local/gpdb6/bin/pg_upgrade --retain --old-bindir /usr/local/gpdb5/bin --new-bindir /usr/local/gpdb6/bin " +
+						"--old-datadir /data/dbfast1/seg1 --new-datadir /data/dbfast1/seg1_123ABC --old-port 25433 --new-port 25435 " +
+						"--mode segment --old-gp-dbid 3 --new-gp-dbid 3 --link --old-tablespaces-file /home/gpadmin/.gpupgrade/tablespaces.txt",
+				},
+			},
+		}
+
+		if !reflect.DeepEqual(plan, expected) {
+			t.Errorf("got %+v want %+v", plan, expected)
+		}
+
+		// The plan can be serialized for review.
+		buf, err := json.Marshal(plan)
+		if err != nil {
+			t.Fatalf("marshaling plan: %v", err)
+		}
+
+		var decoded hub.Plan
+		if err := json.Unmarshal(buf, &decoded); err != nil {
+			t.Fatalf("unmarshaling plan: %v", err)
+		}
+
+		if !reflect.DeepEqual(decoded, expected) {
+			t.Errorf("got decoded plan %+v want %+v", decoded, expected)
+		}
+	})
+
+	t.Run("ExecutePlan errors when the source and target clusters do not match", func(t *testing.T) {
+		mismatched := *conf
+		mismatched.Target = hub.MustCreateCluster(t, []greenplum.SegConfig{
+			{ContentID: -1, DbID: 1, Port: 15433, Hostname: "mdw", DataDir: "/data/qddir/seg-1_123ABC-1", Role: greenplum.PrimaryRole},
+		})
+		mismatched.Target.Version = target.Version
+
+		_, err := hub.ExecutePlan(&mismatched, "/home/gpadmin/.gpupgrade")
+		if !errors.Is(err, hub.ErrInvalidCluster) {
+			t.Errorf("got error %#v want %#v", err, hub.ErrInvalidCluster)
+		}
+	})
+
+	t.Run("NewUpgradePlan includes the execute and finalize plans", func(t *testing.T) {
+		plan, err := hub.NewUpgradePlan(conf, "/home/gpadmin/.gpupgrade")
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		execute, err := hub.ExecutePlan(conf, "/home/gpadmin/.gpupgrade")
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		expected := &hub.UpgradePlan{
+			Execute:  execute,
+			Finalize: hub.FinalizePlan(conf),
+		}
+
+		if !reflect.DeepEqual(plan, expected) {
+			t.Errorf("got %+v want %+v", plan, expected)
+		}
+	})
+
+	t.Run("SaveExecutePlan writes the plan to the state directory", func(t *testing.T) {
+		stateDir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, stateDir)
+
+		plan := hub.Plan{"mdw": {Commands: []string{"gpstop -a"}}}
+		if err := hub.SaveExecutePlan(plan, stateDir); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		buf, err := ioutil.ReadFile(filepath.Join(stateDir, hub.ExecutePlanFile))
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		var saved hub.Plan
+		if err := json.Unmarshal(buf, &saved); err != nil {
+			t.Fatalf("unmarshaling plan: %v", err)
+		}
+
+		if !reflect.DeepEqual(saved, plan) {
+			t.Errorf("got saved plan %+v want %+v", saved, plan)
+		}
+	})
+}
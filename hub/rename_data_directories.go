@@ -6,6 +6,8 @@ package hub
 import (
 	"context"
 
+	"github.com/blang/semver/v4"
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
 	"golang.org/x/xerrors"
 
 	"github.com/greenplum-db/gpupgrade/greenplum"
@@ -13,35 +15,38 @@ import (
 	"github.com/greenplum-db/gpupgrade/upgrade"
 )
 
-var ArchiveSource = upgrade.ArchiveSource
+var ArchiveSource = upgrade.ArchiveSourceWithRateLimit
 
 type RenameMap = map[string][]*idl.RenameDirectories
 
-func (s *Server) UpdateDataDirectories() error {
-	return UpdateDataDirectories(s.Config, s.agentConns)
+func (s *Server) UpdateDataDirectories(ctx context.Context) error {
+	return UpdateDataDirectories(ctx, s.Config, s.agentConns)
 }
 
-func UpdateDataDirectories(conf *Config, agentConns []*Connection) error {
-	source := conf.Source.MasterDataDir()
-	target := conf.TargetInitializeConfig.Master.DataDir
-	if err := ArchiveSource(source, target, true); err != nil {
-		return xerrors.Errorf("renaming master data directories: %w", err)
-	}
+func UpdateDataDirectories(ctx context.Context, conf *Config, agentConns []*Connection) error {
+	master, segments := finalizePlan(conf)
 
-	// in link mode, remove the source mirror and standby data directories; otherwise we create a second copy
-	//  of them for the target cluster. That might take too much disk space.
-	if conf.UseLinkMode {
-		if err := DeleteMirrorAndStandbyDataDirectories(agentConns, conf.Source); err != nil {
-			return xerrors.Errorf("removing source cluster standby and mirror segment data directories: %w", err)
+	for _, dirs := range master.Archives {
+		status, err := ArchiveSource(dirs.Source, dirs.Target, dirs.RenameTarget, conf.CopyRateLimit)
+		if err != nil {
+			return xerrors.Errorf("renaming master data directories: %w", err)
 		}
+		gplog.Debug("archiving master data directory %q: %s", dirs.Source, status)
+	}
 
-		if err := DeleteSourceTablespacesOnMirrorsAndStandby(agentConns, conf.Source, conf.Tablespaces); err != nil {
-			return xerrors.Errorf("removing source cluster standby and mirror tablespace data directories: %w", err)
-		}
+	// In link mode the plan removes the source mirror and standby data
+	// directories; otherwise we create a second copy of them for the target
+	// cluster. That might take too much disk space.
+	version := semver.MustParse(conf.Source.Version.SemVer.String())
+	if err := DeleteMirrorAndStandbyDataDirectories(ctx, agentConns, segments, version); err != nil {
+		return xerrors.Errorf("removing source cluster standby and mirror segment data directories: %w", err)
 	}
 
-	renameMap := getRenameMap(conf.Source, conf.TargetInitializeConfig, conf.UseLinkMode)
-	if err := RenameSegmentDataDirs(agentConns, renameMap); err != nil {
+	if err := DeleteSourceTablespacesOnMirrorsAndStandby(ctx, agentConns, segments); err != nil {
+		return xerrors.Errorf("removing source cluster standby and mirror tablespace data directories: %w", err)
+	}
+
+	if err := RenameSegmentDataDirs(ctx, agentConns, segments.renames(), conf.CopyRateLimit); err != nil {
 		return xerrors.Errorf("renaming segment data directories: %w", err)
 	}
 
@@ -84,16 +89,23 @@ func getRenameMap(source *greenplum.Cluster, target InitializeConfig, onlyRename
 
 // e.g. for source /data/dbfast1/demoDataDir0 becomes /data/dbfast1/demoDataDir0_old
 // e.g. for target /data/dbfast1/demoDataDir0_123ABC becomes /data/dbfast1/demoDataDir0
-func RenameSegmentDataDirs(agentConns []*Connection, renames RenameMap) error {
-	request := func(conn *Connection) error {
+func RenameSegmentDataDirs(ctx context.Context, agentConns []*Connection, renames RenameMap, copyRateLimit int64) error {
+	token := NewIdempotencyToken()
+	request := func(ctx context.Context, conn *Connection) error {
 		if len(renames[conn.Hostname]) == 0 {
 			return nil
 		}
 
-		req := &idl.RenameDirectoriesRequest{Dirs: renames[conn.Hostname]}
-		_, err := conn.AgentClient.RenameDirectories(context.Background(), req)
-		return err
+		req := &idl.RenameDirectoriesRequest{
+			Dirs:             renames[conn.Hostname],
+			CopyRateLimit:    copyRateLimit,
+			IdempotencyToken: token,
+		}
+		return retryAgentRequest(ctx, conn.Hostname, func(ctx context.Context) error {
+			_, err := conn.AgentClient.RenameDirectories(ctx, req)
+			return err
+		})
 	}
 
-	return ExecuteRPC(agentConns, request)
+	return ExecuteRPCContext(ctx, agentConns, request)
 }
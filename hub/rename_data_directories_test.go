@@ -4,10 +4,14 @@
 package hub_test
 
 import (
+	"context"
 	"errors"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"google.golang.org/grpc"
 
 	"github.com/greenplum-db/gpupgrade/greenplum"
 	"github.com/greenplum-db/gpupgrade/hub"
@@ -59,6 +63,7 @@ func TestRenameSegmentDataDirs(t *testing.T) {
 					Source: "/data/dbfast1/seg3_123ABC",
 					Target: "/data/dbfast1/seg3",
 				}},
+				IdempotencyToken: testIdempotencyToken,
 			},
 		).Return(&idl.RenameDirectoriesReply{}, nil)
 
@@ -73,6 +78,7 @@ func TestRenameSegmentDataDirs(t *testing.T) {
 					Source: "/data/dbfast2/seg4_123ABC",
 					Target: "/data/dbfast2/seg4",
 				}},
+				IdempotencyToken: testIdempotencyToken,
 			},
 		).Return(&idl.RenameDirectoriesReply{}, nil)
 
@@ -85,7 +91,31 @@ func TestRenameSegmentDataDirs(t *testing.T) {
 			{nil, client3, "standby", nil},
 		}
 
-		err := hub.RenameSegmentDataDirs(agentConns, m)
+		err := hub.RenameSegmentDataDirs(context.Background(), agentConns, m, 0)
+		if err != nil {
+			t.Errorf("unexpected err %#v", err)
+		}
+	})
+
+	t.Run("sends the copy rate limit to the agents", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock_idl.NewMockAgentClient(ctrl)
+		client.EXPECT().RenameDirectories(
+			gomock.Any(),
+			&idl.RenameDirectoriesRequest{
+				Dirs:             m["sdw1"],
+				CopyRateLimit:    1024,
+				IdempotencyToken: testIdempotencyToken,
+			},
+		).Return(&idl.RenameDirectoriesReply{}, nil)
+
+		agentConns := []*hub.Connection{
+			{nil, client, "sdw1", nil},
+		}
+
+		err := hub.RenameSegmentDataDirs(context.Background(), agentConns, m, 1024)
 		if err != nil {
 			t.Errorf("unexpected err %#v", err)
 		}
@@ -113,12 +143,52 @@ func TestRenameSegmentDataDirs(t *testing.T) {
 			{nil, failedClient, "sdw2", nil},
 		}
 
-		err := hub.RenameSegmentDataDirs(agentConns, m)
+		err := hub.RenameSegmentDataDirs(context.Background(), agentConns, m, 0)
 
 		if !errors.Is(err, expected) {
 			t.Errorf("got error %#v, want %#v", err, expected)
 		}
 	})
+
+	t.Run("stops waiting on agents once the finalize step budget expires", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock_idl.NewMockAgentClient(ctrl)
+		client.EXPECT().RenameDirectories(
+			gomock.Any(),
+			gomock.Any(),
+		).Return(&idl.RenameDirectoriesReply{}, nil)
+
+		hungClient := mock_idl.NewMockAgentClient(ctrl)
+		hungClient.EXPECT().RenameDirectories(
+			gomock.Any(),
+			gomock.Any(),
+		).DoAndReturn(func(ctx context.Context, _ *idl.RenameDirectoriesRequest, _ ...grpc.CallOption) (*idl.RenameDirectoriesReply, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+
+		agentConns := []*hub.Connection{
+			{nil, client, "sdw1", nil},
+			{nil, hungClient, "sdw2", nil},
+		}
+
+		ctx, cancel := hub.WithStepBudget(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		err := hub.RenameSegmentDataDirs(ctx, agentConns, m, 0)
+
+		var budgetErr *hub.StepBudgetExceededError
+		if !errors.As(err, &budgetErr) {
+			t.Fatalf("got error %#v want type %T", err, budgetErr)
+		}
+
+		expected := []string{"sdw2"}
+		if !reflect.DeepEqual(budgetErr.Hosts, expected) {
+			t.Errorf("got hosts %q want %q", budgetErr.Hosts, expected)
+		}
+	})
 }
 
 func TestUpdateDataDirectories(t *testing.T) {
@@ -165,8 +235,8 @@ func TestUpdateDataDirectories(t *testing.T) {
 		},
 	}
 
-	hub.ArchiveSource = func(source, target string, renameTarget bool) error {
-		return nil
+	hub.ArchiveSource = func(source, target string, renameTarget bool, bytesPerSecond int64) (upgrade.ArchiveStatus, error) {
+		return upgrade.Archived, nil
 	}
 
 	t.Run("renames master data directories", func(t *testing.T) {
@@ -185,14 +255,14 @@ func TestUpdateDataDirectories(t *testing.T) {
 			},
 		}
 
-		hub.ArchiveSource = upgrade.ArchiveSource
+		hub.ArchiveSource = upgrade.ArchiveSourceWithRateLimit
 		defer func() {
-			hub.ArchiveSource = func(source, target string, onlyArchive bool) error {
-				return nil
+			hub.ArchiveSource = func(source, target string, onlyArchive bool, bytesPerSecond int64) (upgrade.ArchiveStatus, error) {
+				return upgrade.Archived, nil
 			}
 		}()
 
-		err := hub.UpdateDataDirectories(conf, nil)
+		err := hub.UpdateDataDirectories(context.Background(), conf, nil)
 		if err != nil {
 			t.Errorf("UpdateDataDirectories() returned error: %+v", err)
 		}
@@ -202,16 +272,16 @@ func TestUpdateDataDirectories(t *testing.T) {
 
 	t.Run("returns error when renaming master data directories fails", func(t *testing.T) {
 		expected := errors.New("permission denied")
-		hub.ArchiveSource = func(source, target string, onlyArchive bool) error {
-			return expected
+		hub.ArchiveSource = func(source, target string, onlyArchive bool, bytesPerSecond int64) (upgrade.ArchiveStatus, error) {
+			return upgrade.ArchiveUnknown, expected
 		}
 		defer func() {
-			hub.ArchiveSource = func(source, target string, onlyArchive bool) error {
-				return nil
+			hub.ArchiveSource = func(source, target string, onlyArchive bool, bytesPerSecond int64) (upgrade.ArchiveStatus, error) {
+				return upgrade.Archived, nil
 			}
 		}()
 
-		err := hub.UpdateDataDirectories(conf, nil)
+		err := hub.UpdateDataDirectories(context.Background(), conf, nil)
 		if !errors.Is(err, expected) {
 			t.Errorf("got %#v want %#v", err, expected)
 		}
@@ -277,7 +347,7 @@ func TestUpdateDataDirectories(t *testing.T) {
 			{nil, standby, "standby", nil},
 		}
 
-		err := hub.UpdateDataDirectories(conf, agentConns)
+		err := hub.UpdateDataDirectories(context.Background(), conf, agentConns)
 		if err != nil {
 			t.Errorf("UpdateDataDirectories() returned error: %+v", err)
 		}
@@ -332,7 +402,7 @@ func TestUpdateDataDirectories(t *testing.T) {
 			{nil, standby, "standby", nil},
 		}
 
-		err := hub.UpdateDataDirectories(conf, agentConns)
+		err := hub.UpdateDataDirectories(context.Background(), conf, agentConns)
 		if err != nil {
 			t.Errorf("UpdateDataDirectories() returned error: %+v", err)
 		}
@@ -344,7 +414,7 @@ func TestUpdateDataDirectories(t *testing.T) {
 func expectRenames(client *mock_idl.MockAgentClient, pairs []*idl.RenameDirectories) {
 	client.EXPECT().RenameDirectories(
 		gomock.Any(),
-		&idl.RenameDirectoriesRequest{Dirs: pairs},
+		&idl.RenameDirectoriesRequest{Dirs: pairs, IdempotencyToken: testIdempotencyToken},
 	).Return(&idl.RenameDirectoriesReply{}, nil)
 }
 
@@ -353,6 +423,6 @@ func expectRenames(client *mock_idl.MockAgentClient, pairs []*idl.RenameDirector
 func expectDeletes(client *mock_idl.MockAgentClient, datadirs []string) {
 	client.EXPECT().DeleteDataDirectories(
 		gomock.Any(),
-		&idl.DeleteDataDirectoriesRequest{Datadirs: datadirs},
+		&idl.DeleteDataDirectoriesRequest{Datadirs: datadirs, IdempotencyToken: testIdempotencyToken},
 	).Return(&idl.DeleteDataDirectoriesReply{}, nil)
 }
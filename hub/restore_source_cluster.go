@@ -30,7 +30,7 @@ var Excludes = []string{
 	"gp_dbid", "postgresql.conf", "backup_label.old", "postmaster.pid", "recovery.conf",
 }
 
-func RsyncMasterAndPrimaries(stream step.OutStreams, agentConns []*Connection, source *greenplum.Cluster) error {
+func RsyncMasterAndPrimaries(ctx context.Context, stream step.OutStreams, agentConns []*Connection, source *greenplum.Cluster) error {
 	if !source.HasAllMirrorsAndStandby() {
 		return errors.New("Source cluster does not have mirrors and/or standby. Cannot restore source cluster. Please contact support.")
 	}
@@ -44,7 +44,7 @@ func RsyncMasterAndPrimaries(stream step.OutStreams, agentConns []*Connection, s
 		errs <- RsyncMaster(stream, source.Standby(), source.Master())
 	}()
 
-	errs <- RsyncPrimaries(agentConns, source)
+	errs <- RsyncPrimaries(ctx, agentConns, source)
 
 	wg.Wait()
 	close(errs)
@@ -57,7 +57,7 @@ func RsyncMasterAndPrimaries(stream step.OutStreams, agentConns []*Connection, s
 	return err
 }
 
-func RsyncMasterAndPrimariesTablespaces(stream step.OutStreams, agentConns []*Connection, source *greenplum.Cluster, tablespaces greenplum.Tablespaces) error {
+func RsyncMasterAndPrimariesTablespaces(ctx context.Context, stream step.OutStreams, agentConns []*Connection, source *greenplum.Cluster, tablespaces greenplum.Tablespaces) error {
 	if !source.HasAllMirrorsAndStandby() {
 		return ErrMissingMirrorsAndStandby
 	}
@@ -71,7 +71,7 @@ func RsyncMasterAndPrimariesTablespaces(stream step.OutStreams, agentConns []*Co
 		errs <- RsyncMasterTablespaces(stream, source.StandbyHostname(), tablespaces[source.Master().DbID], tablespaces[source.Standby().DbID])
 	}()
 
-	errs <- RsyncPrimariesTablespaces(agentConns, source, tablespaces)
+	errs <- RsyncPrimariesTablespaces(ctx, agentConns, source, tablespaces)
 
 	wg.Wait()
 	close(errs)
@@ -145,8 +145,9 @@ func RsyncMasterTablespaces(stream step.OutStreams, standbyHostname string, mast
 	return nil
 }
 
-func RsyncPrimaries(agentConns []*Connection, source *greenplum.Cluster) error {
-	request := func(conn *Connection) error {
+func RsyncPrimaries(ctx context.Context, agentConns []*Connection, source *greenplum.Cluster) error {
+	token := NewIdempotencyToken()
+	request := func(ctx context.Context, conn *Connection) error {
 		mirrors := source.SelectSegments(func(seg *greenplum.SegConfig) bool {
 			return seg.IsOnHost(conn.Hostname) && !seg.IsStandby() && seg.IsMirror()
 		})
@@ -166,20 +167,24 @@ func RsyncPrimaries(agentConns []*Connection, source *greenplum.Cluster) error {
 		}
 
 		req := &idl.RsyncRequest{
-			Options:  Options,
-			Excludes: Excludes,
-			Pairs:    pairs,
+			Options:          Options,
+			Excludes:         Excludes,
+			Pairs:            pairs,
+			IdempotencyToken: token,
 		}
 
-		_, err := conn.AgentClient.RsyncDataDirectories(context.Background(), req)
-		return err
+		return retryAgentRequest(ctx, conn.Hostname, func(ctx context.Context) error {
+			_, err := conn.AgentClient.RsyncDataDirectories(ctx, req)
+			return err
+		})
 	}
 
-	return ExecuteRPC(agentConns, request)
+	return ExecuteRPCContext(ctx, agentConns, request)
 }
 
-func RsyncPrimariesTablespaces(agentConns []*Connection, source *greenplum.Cluster, tablespaces greenplum.Tablespaces) error {
-	request := func(conn *Connection) error {
+func RsyncPrimariesTablespaces(ctx context.Context, agentConns []*Connection, source *greenplum.Cluster, tablespaces greenplum.Tablespaces) error {
+	token := NewIdempotencyToken()
+	request := func(ctx context.Context, conn *Connection) error {
 		mirrors := source.SelectSegments(func(seg *greenplum.SegConfig) bool {
 			return seg.IsOnHost(conn.Hostname) && !seg.IsStandby() && seg.IsMirror()
 		})
@@ -209,19 +214,22 @@ func RsyncPrimariesTablespaces(agentConns []*Connection, source *greenplum.Clust
 		}
 
 		req := &idl.RsyncRequest{
-			Options:  Options,
-			Excludes: Excludes,
-			Pairs:    pairs,
+			Options:          Options,
+			Excludes:         Excludes,
+			Pairs:            pairs,
+			IdempotencyToken: token,
 		}
 
-		_, err := conn.AgentClient.RsyncTablespaceDirectories(context.Background(), req)
-		return err
+		return retryAgentRequest(ctx, conn.Hostname, func(ctx context.Context) error {
+			_, err := conn.AgentClient.RsyncTablespaceDirectories(ctx, req)
+			return err
+		})
 	}
 
-	return ExecuteRPC(agentConns, request)
+	return ExecuteRPCContext(ctx, agentConns, request)
 }
 
-func RestoreMasterAndPrimariesPgControl(streams step.OutStreams, agentConns []*Connection, source *greenplum.Cluster) error {
+func RestoreMasterAndPrimariesPgControl(ctx context.Context, streams step.OutStreams, agentConns []*Connection, source *greenplum.Cluster) error {
 	var wg sync.WaitGroup
 	errs := make(chan error, 2)
 
@@ -231,7 +239,7 @@ func RestoreMasterAndPrimariesPgControl(streams step.OutStreams, agentConns []*C
 		errs <- upgrade.RestorePgControl(source.MasterDataDir(), streams)
 	}()
 
-	errs <- restorePrimariesPgControl(agentConns, source)
+	errs <- restorePrimariesPgControl(ctx, agentConns, source)
 
 	wg.Wait()
 	close(errs)
@@ -244,8 +252,9 @@ func RestoreMasterAndPrimariesPgControl(streams step.OutStreams, agentConns []*C
 	return err
 }
 
-func restorePrimariesPgControl(agentConns []*Connection, source *greenplum.Cluster) error {
-	request := func(conn *Connection) error {
+func restorePrimariesPgControl(ctx context.Context, agentConns []*Connection, source *greenplum.Cluster) error {
+	token := NewIdempotencyToken()
+	request := func(ctx context.Context, conn *Connection) error {
 		primaries := source.SelectSegments(func(seg *greenplum.SegConfig) bool {
 			return seg.IsOnHost(conn.Hostname) && !seg.IsStandby() && seg.IsPrimary()
 		})
@@ -260,12 +269,15 @@ func restorePrimariesPgControl(agentConns []*Connection, source *greenplum.Clust
 		}
 
 		req := &idl.RestorePgControlRequest{
-			Datadirs: dataDirs,
+			Datadirs:         dataDirs,
+			IdempotencyToken: token,
 		}
 
-		_, err := conn.AgentClient.RestorePrimariesPgControl(context.Background(), req)
-		return err
+		return retryAgentRequest(ctx, conn.Hostname, func(ctx context.Context) error {
+			_, err := conn.AgentClient.RestorePrimariesPgControl(ctx, req)
+			return err
+		})
 	}
 
-	return ExecuteRPC(agentConns, request)
+	return ExecuteRPCContext(ctx, agentConns, request)
 }
@@ -4,6 +4,7 @@
 package hub_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -93,7 +94,7 @@ func TestRsyncMasterAndPrimaries(t *testing.T) {
 			{ContentID: 1, Hostname: "sdw2", DataDir: "/data/dbfast2/seg2", Role: greenplum.PrimaryRole},
 		})
 
-		err := hub.RsyncMasterAndPrimariesTablespaces(&testutils.DevNullWithClose{}, []*hub.Connection{}, cluster, nil)
+		err := hub.RsyncMasterAndPrimariesTablespaces(context.Background(), &testutils.DevNullWithClose{}, []*hub.Connection{}, cluster, nil)
 		if !errors.Is(err, hub.ErrMissingMirrorsAndStandby) {
 			t.Errorf("got error %#v want %#v", err, hub.ErrMissingMirrorsAndStandby)
 		}
@@ -188,6 +189,7 @@ func TestRsyncMasterAndPrimaries(t *testing.T) {
 					DestinationHost: "sdw1",
 					Destination:     "/data/dbfast1/seg1",
 				}},
+				IdempotencyToken: testIdempotencyToken,
 			},
 		).Return(&idl.RsyncReply{}, nil)
 
@@ -202,6 +204,7 @@ func TestRsyncMasterAndPrimaries(t *testing.T) {
 					DestinationHost: "sdw2",
 					Destination:     "/data/dbfast2/seg2",
 				}},
+				IdempotencyToken: testIdempotencyToken,
 			},
 		).Return(&idl.RsyncReply{}, nil)
 
@@ -213,7 +216,7 @@ func TestRsyncMasterAndPrimaries(t *testing.T) {
 			{nil, standby, "standby", nil},
 		}
 
-		err := hub.RsyncPrimaries(agentConns, cluster)
+		err := hub.RsyncPrimaries(context.Background(), agentConns, cluster)
 		if err != nil {
 			t.Errorf("unexpected err %#v", err)
 		}
@@ -234,6 +237,7 @@ func TestRsyncMasterAndPrimaries(t *testing.T) {
 					DestinationHost: "sdw1",
 					Destination:     "/tmp/user_ts/p1/16384",
 				}},
+				IdempotencyToken: testIdempotencyToken,
 			},
 		).Return(&idl.RsyncReply{}, nil)
 
@@ -248,6 +252,7 @@ func TestRsyncMasterAndPrimaries(t *testing.T) {
 					DestinationHost: "sdw2",
 					Destination:     "/tmp/user_ts/p2/16384",
 				}},
+				IdempotencyToken: testIdempotencyToken,
 			},
 		).Return(&idl.RsyncReply{}, nil)
 
@@ -259,7 +264,7 @@ func TestRsyncMasterAndPrimaries(t *testing.T) {
 			{nil, standby, "standby", nil},
 		}
 
-		err := hub.RsyncPrimariesTablespaces(agentConns, cluster, tablespaces)
+		err := hub.RsyncPrimariesTablespaces(context.Background(), agentConns, cluster, tablespaces)
 		if err != nil {
 			t.Errorf("unexpected err %#v", err)
 		}
@@ -273,7 +278,7 @@ func TestRsyncMasterAndPrimaries(t *testing.T) {
 			{ContentID: 1, Hostname: "sdw2", DataDir: "/data/dbfast2/seg2", Role: greenplum.PrimaryRole},
 		})
 
-		err := hub.RsyncMasterAndPrimaries(&testutils.DevNullWithClose{}, []*hub.Connection{}, cluster)
+		err := hub.RsyncMasterAndPrimaries(context.Background(), &testutils.DevNullWithClose{}, []*hub.Connection{}, cluster)
 		if err == nil {
 			t.Error("unexpected nil error")
 		}
@@ -332,7 +337,7 @@ func TestRsyncMasterAndPrimaries(t *testing.T) {
 			{nil, failedClient, "msdw2", nil},
 		}
 
-		err := hub.RsyncPrimaries(agentConns, cluster)
+		err := hub.RsyncPrimaries(context.Background(), agentConns, cluster)
 
 		if !errors.Is(err, expected) {
 			t.Errorf("got error %#v, want %#v", err, expected)
@@ -361,7 +366,7 @@ func TestRsyncMasterAndPrimaries(t *testing.T) {
 			{nil, failedClient, "msdw2", nil},
 		}
 
-		err := hub.RsyncPrimariesTablespaces(agentConns, cluster, tablespaces)
+		err := hub.RsyncPrimariesTablespaces(context.Background(), agentConns, cluster, tablespaces)
 
 		if !errors.Is(err, expected) {
 			t.Errorf("got error %#v, want %#v", err, expected)
@@ -410,7 +415,7 @@ func TestRestoreMasterAndPrimariesPgControl(t *testing.T) {
 			{nil, failedClient, "sdw2", nil},
 		}
 
-		err := hub.RestoreMasterAndPrimariesPgControl(step.DevNullStream, agentConns, cluster)
+		err := hub.RestoreMasterAndPrimariesPgControl(context.Background(), step.DevNullStream, agentConns, cluster)
 
 		var errs errorlist.Errors
 		if !errors.As(err, &errs) {
@@ -462,7 +467,8 @@ func TestRestoreMasterAndPrimariesPgControl(t *testing.T) {
 		sdw1.EXPECT().RestorePrimariesPgControl(
 			gomock.Any(),
 			&idl.RestorePgControlRequest{
-				Datadirs: []string{"/data/dbfast1/seg1", "/data/dbfast2/seg2"},
+				Datadirs:         []string{"/data/dbfast1/seg1", "/data/dbfast2/seg2"},
+				IdempotencyToken: testIdempotencyToken,
 			},
 		).Return(&idl.RestorePgControlReply{}, nil)
 
@@ -470,7 +476,8 @@ func TestRestoreMasterAndPrimariesPgControl(t *testing.T) {
 		sdw2.EXPECT().RestorePrimariesPgControl(
 			gomock.Any(),
 			&idl.RestorePgControlRequest{
-				Datadirs: []string{"/data/dbfast3/seg3", "/data/dbfast4/seg4"},
+				Datadirs:         []string{"/data/dbfast3/seg3", "/data/dbfast4/seg4"},
+				IdempotencyToken: testIdempotencyToken,
 			},
 		).Return(&idl.RestorePgControlReply{}, nil)
 
@@ -479,7 +486,7 @@ func TestRestoreMasterAndPrimariesPgControl(t *testing.T) {
 			{nil, sdw2, "sdw2", nil},
 		}
 
-		err = hub.RestoreMasterAndPrimariesPgControl(step.DevNullStream, agentConns, cluster)
+		err = hub.RestoreMasterAndPrimariesPgControl(context.Background(), step.DevNullStream, agentConns, cluster)
 		if err != nil {
 			t.Errorf("unexpected err %#v", err)
 		}
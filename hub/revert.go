@@ -4,9 +4,11 @@
 package hub
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
 
+	"github.com/blang/semver/v4"
 	"github.com/greenplum-db/gp-common-go-libs/gplog"
 	"github.com/pkg/errors"
 	"golang.org/x/xerrors"
@@ -20,11 +22,14 @@ import (
 var ErrMissingMirrorsAndStandby = errors.New("Source cluster does not have mirrors and/or standby. Cannot restore source cluster. Please contact support.")
 
 func (s *Server) Revert(_ *idl.RevertRequest, stream idl.CliToHub_RevertServer) (err error) {
-	st, err := step.Begin(idl.Step_REVERT, stream)
+	st, err := s.beginStep(idl.Step_REVERT, stream)
 	if err != nil {
 		return err
 	}
 
+	ctx, cancel := WithStepBudget(context.Background(), s.StepBudget)
+	defer cancel()
+
 	defer func() {
 		if ferr := st.Finish(); ferr != nil {
 			err = errorlist.Append(err, ferr)
@@ -65,16 +70,24 @@ func (s *Server) Revert(_ *idl.RevertRequest, stream idl.CliToHub_RevertServer)
 		})
 	}
 
+	master, primaries := revertPlan(s.Config)
+
 	st.RunConditionally(idl.Substep_DELETE_TARGET_CLUSTER_DATADIRS,
 		s.TargetInitializeConfig.Primaries != nil && s.TargetInitializeConfig.Master.DataDir != "",
 		func(streams step.OutStreams) error {
-			return DeleteMasterAndPrimaryDataDirectories(streams, s.agentConns, s.TargetInitializeConfig)
+			// The target cluster is unknown if gpinitsystem failed partway.
+			var version semver.Version
+			if s.Target != nil {
+				version = semver.MustParse(s.Target.Version.SemVer.String())
+			}
+
+			return DeleteMasterAndPrimaryDataDirectories(ctx, streams, s.agentConns, master, primaries, version)
 		})
 
 	st.RunConditionally(idl.Substep_DELETE_TABLESPACES,
 		s.TargetInitializeConfig.Primaries != nil && s.TargetInitializeConfig.Master.DataDir != "",
 		func(streams step.OutStreams) error {
-			return DeleteTargetTablespaces(streams, s.agentConns, s.Config.Target, s.TargetCatalogVersion, s.Tablespaces)
+			return DeleteTargetTablespaces(ctx, streams, s.agentConns, master, primaries)
 		})
 
 	// For any of the link-mode cases described in the "Reverting to old
@@ -84,7 +97,7 @@ func (s *Server) Revert(_ *idl.RevertRequest, stream idl.CliToHub_RevertServer)
 	// substep to clean up the pg_control.old file, since the rsync will not
 	// remove it.
 	st.RunConditionally(idl.Substep_RESTORE_PGCONTROL, s.UseLinkMode, func(streams step.OutStreams) error {
-		return RestoreMasterAndPrimariesPgControl(streams, s.agentConns, s.Source)
+		return RestoreMasterAndPrimariesPgControl(ctx, streams, s.agentConns, s.Source)
 	})
 
 	// if the target cluster has been started at any point, we must restore the source
@@ -95,11 +108,11 @@ func (s *Server) Revert(_ *idl.RevertRequest, stream idl.CliToHub_RevertServer)
 	}
 
 	st.RunConditionally(idl.Substep_RESTORE_SOURCE_CLUSTER, s.UseLinkMode && targetStarted, func(stream step.OutStreams) error {
-		if err := RsyncMasterAndPrimaries(stream, s.agentConns, s.Source); err != nil {
+		if err := RsyncMasterAndPrimaries(ctx, stream, s.agentConns, s.Source); err != nil {
 			return err
 		}
 
-		return RsyncMasterAndPrimariesTablespaces(stream, s.agentConns, s.Source, s.Tablespaces)
+		return RsyncMasterAndPrimariesTablespaces(ctx, stream, s.agentConns, s.Source, s.Tablespaces)
 	})
 
 	handleMirrorStartupFailure, err := s.expectMirrorFailure()
@@ -155,11 +168,11 @@ func (s *Server) Revert(_ *idl.RevertRequest, stream idl.CliToHub_RevertServer)
 			return err
 		}
 
-		return ArchiveSegmentLogDirectories(s.agentConns, s.Config.Source.MasterHostname(), logArchiveDir)
+		return ArchiveSegmentLogDirectories(ctx, s.agentConns, s.Config.Source.MasterHostname(), logArchiveDir)
 	})
 
 	st.Run(idl.Substep_DELETE_SEGMENT_STATEDIRS, func(_ step.OutStreams) error {
-		return DeleteStateDirectories(s.agentConns, s.Source.MasterHostname())
+		return DeleteStateDirectories(ctx, s.agentConns, s.Source.MasterHostname())
 	})
 
 	message := &idl.Message{Contents: &idl.Message_Response{Response: &idl.Response{Contents: &idl.Response_RevertResponse{
@@ -4,15 +4,157 @@
 package hub
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
 	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/greenplum-db/gpupgrade/utils/errorlist"
 )
 
+// DefaultAgentTimeout bounds each unary RPC the hub makes to an agent when
+// Config.AgentTimeout is not set. Its purpose is to fail a step within minutes
+// when an agent stops responding rather than hang it.
+const DefaultAgentTimeout = 10 * time.Minute
+
+// LongRunningAgentTimeout bounds the RPCs in longRunningMethods in place of
+// the agent timeout, since their duration grows with the size of the cluster.
+// They are still bounded by the step budget.
+const LongRunningAgentTimeout = 12 * time.Hour
+
+// longRunningMethods are the agent RPCs that process whole data directories,
+// such as running pg_upgrade or copying and deleting segments.
+var longRunningMethods = map[string]bool{
+	"/idl.Agent/UpgradePrimaries":                  true,
+	"/idl.Agent/CheckDiskSpace":                    true,
+	"/idl.Agent/RenameDirectories":                 true,
+	"/idl.Agent/RevertDataDirectories":             true,
+	"/idl.Agent/RsyncDataDirectories":              true,
+	"/idl.Agent/RsyncTablespaceDirectories":        true,
+	"/idl.Agent/RsyncDirectory":                    true,
+	"/idl.Agent/DeleteDataDirectories":             true,
+	"/idl.Agent/DeleteTablespaceDirectories":       true,
+	"/idl.Agent/DeleteSourceTablespaceDirectories": true,
+}
+
+// ErrAgentTimeout is returned when an agent does not respond to an RPC within
+// its timeout.
+var ErrAgentTimeout = errors.New("agent did not respond in time")
+
+// AgentTimeoutError is the backing error type for ErrAgentTimeout. It also
+// matches context.DeadlineExceeded.
+type AgentTimeoutError struct {
+	Host    string
+	Method  string
+	Timeout time.Duration
+	Err     error
+}
+
+func (a *AgentTimeoutError) Error() string {
+	return fmt.Sprintf("agent on host %q did not respond to %s within %s: %v", a.Host, a.Method, a.Timeout, a.Err)
+}
+
+func (a *AgentTimeoutError) Is(err error) bool {
+	return err == ErrAgentTimeout || err == context.DeadlineExceeded
+}
+
+func (a *AgentTimeoutError) Unwrap() error {
+	return a.Err
+}
+
+// agentTimeoutInterceptor applies timeout to each unary RPC made to the agent
+// on host, or LongRunningAgentTimeout to those in longRunningMethods. Since
+// every call has its own deadline a slow host cannot hold up ExecuteRPC past
+// the timeout.
+func agentTimeoutInterceptor(host string, timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		timeout := timeout
+		if longRunningMethods[method] {
+			timeout = LongRunningAgentTimeout
+		}
+
+		// When the caller's own deadline, such as a step budget, expired
+		// first the agent is not at fault.
+		parent := ctx
+		ctx, cancel := context.WithTimeout(parent, timeout)
+		defer cancel()
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if status.Code(err) == codes.DeadlineExceeded && parent.Err() == nil {
+			return &AgentTimeoutError{Host: host, Method: method, Timeout: timeout, Err: err}
+		}
+
+		return err
+	}
+}
+
+// ErrStepBudgetExceeded is returned by ExecuteRPCContext when the step's
+// budget set by WithStepBudget expires before every agent has finished.
+var ErrStepBudgetExceeded = errors.New("step did not finish within its time budget")
+
+// StepBudgetExceededError is the backing error type for
+// ErrStepBudgetExceeded. Hosts lists, sorted, the hosts whose requests had
+// not finished when the budget expired, and Err holds the errors from all
+// hosts. It also matches context.DeadlineExceeded.
+type StepBudgetExceededError struct {
+	Budget time.Duration
+	Hosts  []string
+	Err    error
+}
+
+func (s *StepBudgetExceededError) Error() string {
+	return fmt.Sprintf("step did not finish within its budget of %s, still waiting on hosts %q: %v", s.Budget, s.Hosts, s.Err)
+}
+
+func (s *StepBudgetExceededError) Is(err error) bool {
+	return err == ErrStepBudgetExceeded || err == context.DeadlineExceeded
+}
+
+func (s *StepBudgetExceededError) Unwrap() error {
+	return s.Err
+}
+
+type stepBudgetKey struct{}
+
+// WithStepBudget returns a context that expires once budget has passed, to
+// bound the total time a step spends waiting on agents across all of its
+// calls to ExecuteRPCContext. It composes with the agent timeout, which still
+// bounds each RPC. A budget of zero is unlimited.
+func WithStepBudget(ctx context.Context, budget time.Duration) (context.Context, context.CancelFunc) {
+	if budget <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	ctx = context.WithValue(ctx, stepBudgetKey{}, budget)
+	return context.WithTimeout(ctx, budget)
+}
+
 func ExecuteRPC(agentConns []*Connection, executeRequest func(conn *Connection) error) error {
+	return ExecuteRPCContext(context.Background(), agentConns, func(_ context.Context, conn *Connection) error {
+		return executeRequest(conn)
+	})
+}
+
+// ExecuteRPCContext is ExecuteRPC, but passes ctx to each request so that
+// the agent calls are abandoned once it is done. When ctx is a step budget
+// from WithStepBudget that expires, a StepBudgetExceededError names the
+// hosts that had not finished.
+func ExecuteRPCContext(ctx context.Context, agentConns []*Connection, executeRequest func(ctx context.Context, conn *Connection) error) error {
 	var wg sync.WaitGroup
 	errs := make(chan error, len(agentConns))
 
+	// A host that finishes at or after the deadline is one the step was
+	// still waiting on when the deadline passed.
+	deadline, hasDeadline := ctx.Deadline()
+	var mu sync.Mutex
+	var unfinished []string
+
 	for _, conn := range agentConns {
 		conn := conn
 
@@ -20,7 +162,13 @@ func ExecuteRPC(agentConns []*Connection, executeRequest func(conn *Connection)
 		go func() {
 			defer wg.Done()
 
-			err := executeRequest(conn)
+			err := executeRequest(ctx, conn)
+			if hasDeadline && !time.Now().Before(deadline) {
+				mu.Lock()
+				unfinished = append(unfinished, conn.Hostname)
+				mu.Unlock()
+			}
+
 			errs <- err
 		}()
 	}
@@ -33,5 +181,11 @@ func ExecuteRPC(agentConns []*Connection, executeRequest func(conn *Connection)
 		err = errorlist.Append(err, e)
 	}
 
+	budget, ok := ctx.Value(stepBudgetKey{}).(time.Duration)
+	if ok && len(unfinished) > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		sort.Strings(unfinished)
+		return &StepBudgetExceededError{Budget: budget, Hosts: unfinished, Err: err}
+	}
+
 	return err
 }
@@ -4,12 +4,22 @@
 package hub_test
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
+	"time"
 
+	"google.golang.org/grpc"
+
+	"github.com/greenplum-db/gpupgrade/greenplum"
 	"github.com/greenplum-db/gpupgrade/hub"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/testutils/testlog"
 )
 
 func TestExecuteRPC(t *testing.T) {
@@ -66,3 +76,336 @@ func TestExecuteRPC(t *testing.T) {
 		}
 	})
 }
+
+// slowAgent is an agent whose ValidateDataDirectory and UpgradePrimaries RPCs
+// do not return until the caller gives up.
+type slowAgent struct {
+	idl.UnimplementedAgentServer
+}
+
+func (s *slowAgent) Version(context.Context, *idl.VersionRequest) (*idl.VersionReply, error) {
+	return &idl.VersionReply{}, nil
+}
+
+func (s *slowAgent) Healthz(context.Context, *idl.HealthzRequest) (*idl.HealthzReply, error) {
+	return &idl.HealthzReply{Healthy: true}, nil
+}
+
+func (s *slowAgent) ValidateDataDirectory(ctx context.Context, in *idl.ValidateDataDirectoryRequest) (*idl.ValidateDataDirectoryReply, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (s *slowAgent) UpgradePrimaries(ctx context.Context, in *idl.UpgradePrimariesRequest) (*idl.UpgradePrimariesReply, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// fastAgent is an agent whose ValidateDataDirectory RPC returns immediately.
+type fastAgent struct {
+	slowAgent
+}
+
+func (f *fastAgent) ValidateDataDirectory(context.Context, *idl.ValidateDataDirectoryRequest) (*idl.ValidateDataDirectoryReply, error) {
+	return &idl.ValidateDataDirectoryReply{}, nil
+}
+
+// serveAgent starts a gRPC server for agent and returns its address.
+func serveAgent(t *testing.T, agent idl.AgentServer) (string, func()) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+
+	server := grpc.NewServer()
+	idl.RegisterAgentServer(server, agent)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+
+	return lis.Addr().String(), server.Stop
+}
+
+func TestAgentTimeout(t *testing.T) {
+	testlog.SetupLogger()
+
+	t.Run("names the host that did not respond in time without waiting on it", func(t *testing.T) {
+		slowAddr, stopSlow := serveAgent(t, &slowAgent{})
+		defer stopSlow()
+
+		fastAddr, stopFast := serveAgent(t, &fastAgent{})
+		defer stopFast()
+
+		// Route the slow host to the slow agent and all others to the fast one.
+		dialer := func(ctx context.Context, target string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+			addr := fastAddr
+			if strings.HasPrefix(target, "sdw1:") {
+				addr = slowAddr
+			}
+
+			return grpc.DialContext(ctx, addr, opts...)
+		}
+
+		source := hub.MustCreateCluster(t, []greenplum.SegConfig{
+			{ContentID: -1, DbID: 1, Port: 15432, Hostname: "mdw", DataDir: "/data/qddir/seg-1", Role: greenplum.PrimaryRole},
+			{ContentID: 0, DbID: 2, Port: 25432, Hostname: "sdw1", DataDir: "/data/dbfast1/seg1", Role: greenplum.PrimaryRole},
+			{ContentID: 1, DbID: 3, Port: 25433, Hostname: "sdw2", DataDir: "/data/dbfast2/seg2", Role: greenplum.PrimaryRole},
+		})
+
+		timeout := 100 * time.Millisecond
+		h := hub.New(&hub.Config{Source: source, AgentPort: 6416, AgentTimeout: timeout}, dialer, "")
+		defer h.Stop(true)
+
+		agentConns, err := h.AgentConns()
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		hosts := make(chan string, len(agentConns))
+		start := time.Now()
+		err = hub.ExecuteRPC(agentConns, func(conn *hub.Connection) error {
+			_, err := conn.AgentClient.ValidateDataDirectory(context.Background(), &idl.ValidateDataDirectoryRequest{})
+			if err == nil {
+				hosts <- conn.Hostname
+			}
+
+			return err
+		})
+		elapsed := time.Since(start)
+		close(hosts)
+
+		var timeoutErr *hub.AgentTimeoutError
+		if !errors.As(err, &timeoutErr) {
+			t.Fatalf("got error %#v want type %T", err, timeoutErr)
+		}
+
+		if !errors.Is(err, hub.ErrAgentTimeout) {
+			t.Errorf("got error %#v want %#v", err, hub.ErrAgentTimeout)
+		}
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got error %#v want %#v", err, context.DeadlineExceeded)
+		}
+
+		if timeoutErr.Host != "sdw1" {
+			t.Errorf("got host %q want %q", timeoutErr.Host, "sdw1")
+		}
+
+		if !strings.Contains(err.Error(), fmt.Sprintf("%q", "sdw1")) {
+			t.Errorf("expected error %q to name the host", err.Error())
+		}
+
+		var responded []string
+		for host := range hosts {
+			responded = append(responded, host)
+		}
+
+		expected := []string{"sdw2"}
+		if !reflect.DeepEqual(responded, expected) {
+			t.Errorf("got responding hosts %v want %v", responded, expected)
+		}
+
+		if elapsed > 10*timeout {
+			t.Errorf("took %s want about %s", elapsed, timeout)
+		}
+	})
+
+	t.Run("does not apply the agent timeout to long-running requests", func(t *testing.T) {
+		addr, stop := serveAgent(t, &slowAgent{})
+		defer stop()
+
+		dialer := func(ctx context.Context, target string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+			return grpc.DialContext(ctx, addr, opts...)
+		}
+
+		source := hub.MustCreateCluster(t, []greenplum.SegConfig{
+			{ContentID: -1, DbID: 1, Port: 15432, Hostname: "mdw", DataDir: "/data/qddir/seg-1", Role: greenplum.PrimaryRole},
+			{ContentID: 0, DbID: 2, Port: 25432, Hostname: "sdw1", DataDir: "/data/dbfast1/seg1", Role: greenplum.PrimaryRole},
+		})
+
+		h := hub.New(&hub.Config{Source: source, AgentPort: 6416, AgentTimeout: 10 * time.Millisecond}, dialer, "")
+		defer h.Stop(true)
+
+		agentConns, err := h.AgentConns()
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		// The step budget, rather than the agent timeout, ends the upgrade.
+		ctx, cancel := hub.WithStepBudget(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		err = hub.ExecuteRPCContext(ctx, agentConns, func(ctx context.Context, conn *hub.Connection) error {
+			_, err := conn.AgentClient.UpgradePrimaries(ctx, &idl.UpgradePrimariesRequest{})
+			return err
+		})
+
+		if !errors.Is(err, hub.ErrStepBudgetExceeded) {
+			t.Errorf("got error %#v want %#v", err, hub.ErrStepBudgetExceeded)
+		}
+
+		var timeoutErr *hub.AgentTimeoutError
+		if errors.As(err, &timeoutErr) {
+			t.Errorf("got error %#v want no %T", err, timeoutErr)
+		}
+	})
+}
+
+func TestExecuteRPCContext(t *testing.T) {
+	testlog.SetupLogger()
+
+	t.Run("names the hosts that had not finished when the step budget expired", func(t *testing.T) {
+		agentConns := []*hub.Connection{
+			{nil, nil, "mdw", nil},
+			{nil, nil, "sdw1", nil},
+			{nil, nil, "sdw2", nil},
+			{nil, nil, "sdw3", nil},
+		}
+
+		// Each host makes several calls that are quick on their own, but
+		// together take longer than the budget on the last two hosts.
+		calls := map[string]int{"mdw": 1, "sdw1": 2, "sdw2": 20, "sdw3": 20}
+		call := func(ctx context.Context) error {
+			select {
+			case <-time.After(20 * time.Millisecond):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		budget := 200 * time.Millisecond
+		ctx, cancel := hub.WithStepBudget(context.Background(), budget)
+		defer cancel()
+
+		start := time.Now()
+		err := hub.ExecuteRPCContext(ctx, agentConns, func(ctx context.Context, conn *hub.Connection) error {
+			for i := 0; i < calls[conn.Hostname]; i++ {
+				if err := call(ctx); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+		elapsed := time.Since(start)
+
+		var budgetErr *hub.StepBudgetExceededError
+		if !errors.As(err, &budgetErr) {
+			t.Fatalf("got error %#v want type %T", err, budgetErr)
+		}
+
+		if !errors.Is(err, hub.ErrStepBudgetExceeded) {
+			t.Errorf("got error %#v want %#v", err, hub.ErrStepBudgetExceeded)
+		}
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got error %#v want %#v", err, context.DeadlineExceeded)
+		}
+
+		expected := []string{"sdw2", "sdw3"}
+		if !reflect.DeepEqual(budgetErr.Hosts, expected) {
+			t.Errorf("got hosts %q want %q", budgetErr.Hosts, expected)
+		}
+
+		if budgetErr.Budget != budget {
+			t.Errorf("got budget %s want %s", budgetErr.Budget, budget)
+		}
+
+		if elapsed > 5*budget {
+			t.Errorf("took %s want about %s", elapsed, budget)
+		}
+	})
+
+	t.Run("is not reported as an agent timeout", func(t *testing.T) {
+		slowAddr, stopSlow := serveAgent(t, &slowAgent{})
+		defer stopSlow()
+
+		fastAddr, stopFast := serveAgent(t, &fastAgent{})
+		defer stopFast()
+
+		dialer := func(ctx context.Context, target string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+			addr := fastAddr
+			if strings.HasPrefix(target, "sdw1:") {
+				addr = slowAddr
+			}
+
+			return grpc.DialContext(ctx, addr, opts...)
+		}
+
+		source := hub.MustCreateCluster(t, []greenplum.SegConfig{
+			{ContentID: -1, DbID: 1, Port: 15432, Hostname: "mdw", DataDir: "/data/qddir/seg-1", Role: greenplum.PrimaryRole},
+			{ContentID: 0, DbID: 2, Port: 25432, Hostname: "sdw1", DataDir: "/data/dbfast1/seg1", Role: greenplum.PrimaryRole},
+			{ContentID: 1, DbID: 3, Port: 25433, Hostname: "sdw2", DataDir: "/data/dbfast2/seg2", Role: greenplum.PrimaryRole},
+		})
+
+		h := hub.New(&hub.Config{Source: source, AgentPort: 6416}, dialer, "")
+		defer h.Stop(true)
+
+		agentConns, err := h.AgentConns()
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		ctx, cancel := hub.WithStepBudget(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		err = hub.ExecuteRPCContext(ctx, agentConns, func(ctx context.Context, conn *hub.Connection) error {
+			_, err := conn.AgentClient.ValidateDataDirectory(ctx, &idl.ValidateDataDirectoryRequest{})
+			return err
+		})
+
+		var budgetErr *hub.StepBudgetExceededError
+		if !errors.As(err, &budgetErr) {
+			t.Fatalf("got error %#v want type %T", err, budgetErr)
+		}
+
+		expected := []string{"sdw1"}
+		if !reflect.DeepEqual(budgetErr.Hosts, expected) {
+			t.Errorf("got hosts %q want %q", budgetErr.Hosts, expected)
+		}
+
+		var timeoutErr *hub.AgentTimeoutError
+		if errors.As(err, &timeoutErr) {
+			t.Errorf("got error %#v want no %T", err, timeoutErr)
+		}
+	})
+
+	t.Run("returns only the request errors when every host finishes within the budget", func(t *testing.T) {
+		agentConns := []*hub.Connection{
+			{nil, nil, "mdw", nil},
+			{nil, nil, "sdw1", nil},
+		}
+
+		ctx, cancel := hub.WithStepBudget(context.Background(), time.Hour)
+		defer cancel()
+
+		expected := errors.New("permission denied")
+		err := hub.ExecuteRPCContext(ctx, agentConns, func(_ context.Context, conn *hub.Connection) error {
+			if conn.Hostname == "sdw1" {
+				return expected
+			}
+
+			return nil
+		})
+
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+
+		if errors.Is(err, hub.ErrStepBudgetExceeded) {
+			t.Errorf("got error %#v want no %#v", err, hub.ErrStepBudgetExceeded)
+		}
+	})
+
+	t.Run("a zero budget is unlimited", func(t *testing.T) {
+		ctx, cancel := hub.WithStepBudget(context.Background(), 0)
+		defer cancel()
+
+		if _, ok := ctx.Deadline(); ok {
+			t.Errorf("expected no deadline")
+		}
+	})
+}
@@ -29,8 +29,10 @@ import (
 	"github.com/greenplum-db/gpupgrade/db/connURI"
 	"github.com/greenplum-db/gpupgrade/greenplum"
 	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/step"
 	"github.com/greenplum-db/gpupgrade/upgrade"
 	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/certs"
 	"github.com/greenplum-db/gpupgrade/utils/daemon"
 	"github.com/greenplum-db/gpupgrade/utils/errorlist"
 	"github.com/greenplum-db/gpupgrade/utils/log"
@@ -48,6 +50,15 @@ type Server struct {
 
 	StateDir string
 
+	// Version identifies the gpupgrade build the hub is running. Agents
+	// reporting a different version are rejected when first connecting.
+	Version string
+
+	// LogRotation is forwarded to the agents the hub starts.
+	LogRotation log.Rotation
+
+	confirmations *step.Gate
+
 	agentConns []*Connection
 	grpcDialer Dialer
 
@@ -76,10 +87,11 @@ type Connection struct {
 
 func New(conf *Config, grpcDialer Dialer, stateDir string) *Server {
 	h := &Server{
-		Config:     conf,
-		StateDir:   stateDir,
-		stopped:    make(chan struct{}, 1),
-		grpcDialer: grpcDialer,
+		Config:        conf,
+		StateDir:      stateDir,
+		stopped:       make(chan struct{}, 1),
+		grpcDialer:    grpcDialer,
+		confirmations: step.NewGate(),
 	}
 
 	return h
@@ -192,30 +204,51 @@ func (s *Server) Stop(closeAgentConns bool) {
 }
 
 func (s *Server) RestartAgents(ctx context.Context, in *idl.RestartAgentsRequest) (*idl.RestartAgentsReply, error) {
-	restartedHosts, err := RestartAgents(ctx, nil, AgentHosts(s.Source), s.AgentPort, s.StateDir)
+	restartedHosts, err := RestartAgents(ctx, nil, AgentHosts(s.Source), s.AgentPort, s.StateDir, s.AgentTLS, s.LogRotation)
 	return &idl.RestartAgentsReply{AgentHosts: restartedHosts}, err
 }
 
+// MaxConcurrentAgentStarts bounds the number of hosts RestartAgents dials and
+// connects to over ssh at once, so that large clusters do not exhaust file
+// descriptors or trip sshd rate limits.
+var MaxConcurrentAgentStarts = 32
+
 func RestartAgents(ctx context.Context,
 	dialer func(context.Context, string) (net.Conn, error),
 	hostnames []string,
 	port int,
-	stateDir string) ([]string, error) {
+	stateDir string,
+	agentTLS *certs.Config,
+	rotation log.Rotation) ([]string, error) {
+
+	transport, err := certs.DialOption(agentTLS)
+	if err != nil {
+		return nil, err
+	}
 
 	var wg sync.WaitGroup
 	restartedHosts := make(chan string, len(hostnames))
 	errs := make(chan error, len(hostnames))
 
+	limit := MaxConcurrentAgentStarts
+	if limit < 1 {
+		limit = 1
+	}
+	sem := make(chan struct{}, limit)
+
 	for _, host := range hostnames {
 		wg.Add(1)
 		go func(host string) {
 			defer wg.Done()
 
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
 			address := host + ":" + strconv.Itoa(port)
 			timeoutCtx, cancelFunc := context.WithTimeout(ctx, 3*time.Second)
 			opts := []grpc.DialOption{
 				grpc.WithBlock(),
-				grpc.WithInsecure(),
+				transport,
 				grpc.FailOnNonTempDialError(true),
 			}
 			if dialer != nil {
@@ -239,8 +272,13 @@ func RestartAgents(ctx context.Context,
 				errs <- err
 				return
 			}
+			args := certs.AgentArgs(agentTLS)
+			if rotation := rotation.Args(); len(rotation) > 0 {
+				args += " " + strings.Join(rotation, " ")
+			}
+
 			cmd := execCommand("ssh", host,
-				fmt.Sprintf("bash -c \"%s agent --daemonize --port %d --state-directory %s\"", path, port, stateDir))
+				fmt.Sprintf("bash -c \"%s agent --daemonize --port %d --state-directory %s%s\"", path, port, stateDir, args))
 			stdout, err := cmd.Output()
 			if err != nil {
 				errs <- err
@@ -261,12 +299,22 @@ func RestartAgents(ctx context.Context,
 		hosts = append(hosts, h)
 	}
 
-	var err error
+	var mErr error
 	for e := range errs {
-		err = errorlist.Append(err, e)
+		mErr = errorlist.Append(mErr, e)
+	}
+
+	return hosts, mErr
+}
+
+// agentTimeout returns the configured AgentTimeout, or DefaultAgentTimeout
+// when it is not set.
+func (s *Server) agentTimeout() time.Duration {
+	if s.AgentTimeout <= 0 {
+		return DefaultAgentTimeout
 	}
 
-	return hosts, err
+	return s.AgentTimeout
 }
 
 func (s *Server) AgentConns() ([]*Connection, error) {
@@ -285,15 +333,26 @@ func (s *Server) AgentConns() ([]*Connection, error) {
 			return nil, err
 		}
 
+		if _, err := PingAllAgents(s.agentConns, DialTimeout); err != nil {
+			gplog.Error(err.Error())
+			return nil, err
+		}
+
 		return s.agentConns, nil
 	}
 
+	transport, err := certs.DialOption(s.AgentTLS)
+	if err != nil {
+		return nil, err
+	}
+
 	hostnames := AgentHosts(s.Source)
 	for _, host := range hostnames {
 		ctx, cancelFunc := context.WithTimeout(context.Background(), DialTimeout)
 		conn, err := s.grpcDialer(ctx,
 			host+":"+strconv.Itoa(s.AgentPort),
-			grpc.WithInsecure(), grpc.WithBlock())
+			transport, grpc.WithBlock(),
+			grpc.WithUnaryInterceptor(agentTimeoutInterceptor(host, s.agentTimeout())))
 		if err != nil {
 			err = xerrors.Errorf("grpcDialer failed: %w", err)
 			gplog.Error(err.Error())
@@ -308,6 +367,20 @@ func (s *Server) AgentConns() ([]*Connection, error) {
 		})
 	}
 
+	if err := EnsureVersionsMatch(hostnames, NewAgentVersions(s.Version, s.agentConns)); err != nil {
+		gplog.Error(err.Error())
+		s.closeAgentConns()
+		s.agentConns = nil
+		return nil, err
+	}
+
+	if err := EnsureAgentsHealthy(s.agentConns); err != nil {
+		gplog.Error(err.Error())
+		s.closeAgentConns()
+		s.agentConns = nil
+		return nil, err
+	}
+
 	return s.agentConns, nil
 }
 
@@ -384,6 +457,36 @@ type Config struct {
 	Tablespaces                greenplum.Tablespaces
 	TablespacesMappingFilePath string
 	TargetCatalogVersion       string
+
+	// CopyRateLimit is the maximum rate, in bytes per second, at which data
+	// directories are copied when archiving them requires copying across
+	// filesystems. Zero is unlimited.
+	CopyRateLimit int64
+
+	// AgentTLS secures connections to agents with TLS, and starts agents
+	// serving TLS. When nil agents are connected to without TLS. It is set by
+	// initialize so that every start of the hub uses the same choice.
+	AgentTLS *certs.Config
+
+	// ConfirmSubsteps lists the substeps, such as the deletes of revert, that
+	// wait before starting for the operator to call Confirm or Abort. A
+	// substep not confirmed within ConfirmTimeout is aborted; zero uses
+	// step.DefaultConfirmationTimeout.
+	ConfirmSubsteps []idl.Substep
+	ConfirmTimeout  time.Duration
+
+	// StepBudget bounds the total time a step waits on agents. Zero is
+	// unlimited.
+	StepBudget time.Duration
+
+	// MetricsAddr is the address the hub serves Prometheus metrics on. Empty
+	// disables metrics.
+	MetricsAddr string
+
+	// AgentTimeout bounds each RPC to an agent other than long-running ones,
+	// such as pg_upgrade, which are bounded by LongRunningAgentTimeout. Zero
+	// uses DefaultAgentTimeout.
+	AgentTimeout time.Duration
 }
 
 func (c *Config) Load(r io.Reader) error {
@@ -404,7 +507,7 @@ func (s *Server) SaveConfig() (err error) {
 		return xerrors.Errorf("save config: %w", err)
 	}
 
-	return utils.AtomicallyWrite(upgrade.GetConfigFile(), buffer.Bytes())
+	return utils.AtomicWriteFile(upgrade.GetConfigFile(), buffer.Bytes(), 0600)
 }
 
 func (s *Server) GetLogArchiveDir() (string, error) {
@@ -417,7 +520,7 @@ func (s *Server) GetLogArchiveDir() (string, error) {
 		return "", err
 	}
 
-	s.LogArchiveDir = filepath.Join(filepath.Dir(logDir), upgrade.GetArchiveDirectoryName(s.UpgradeID, time.Now()))
+	s.LogArchiveDir = filepath.Join(filepath.Dir(logDir), upgrade.GetArchiveDirectoryName(s.UpgradeID, utils.System.Now()))
 	err = s.SaveConfig()
 	if err != nil {
 		return "", err
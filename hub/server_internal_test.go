@@ -7,11 +7,14 @@ import (
 	"bytes"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/greenplum-db/gpupgrade/db/connURI"
 	"github.com/greenplum-db/gpupgrade/greenplum"
+	"github.com/greenplum-db/gpupgrade/idl"
 	"github.com/greenplum-db/gpupgrade/testutils"
 	"github.com/greenplum-db/gpupgrade/upgrade"
+	"github.com/greenplum-db/gpupgrade/utils/certs"
 )
 
 func TestConfig(t *testing.T) {
@@ -43,6 +46,18 @@ func TestConfig(t *testing.T) {
 				}}}, // Tablespaces
 			greenplum.TablespacesMappingFile, // TablespacesMappingFilePath
 			"301908232",                      // TargetCatalogVersion
+			1024 * 1024,                      // CopyRateLimit
+			&certs.Config{
+				CertFile:     "/home/gpadmin/.gpupgrade/tls/cert.pem",
+				KeyFile:      "/home/gpadmin/.gpupgrade/tls/key.pem",
+				CAFile:       "/home/gpadmin/.gpupgrade/tls/ca.pem",
+				VerifyClient: true,
+			}, // AgentTLS
+			[]idl.Substep{idl.Substep_DELETE_TABLESPACES}, // ConfirmSubsteps
+			time.Hour,        // ConfirmTimeout
+			6 * time.Hour,    // StepBudget
+			":9527",          // MetricsAddr
+			10 * time.Minute, // AgentTimeout
 		}
 
 		buf := new(bytes.Buffer)
@@ -7,10 +7,19 @@ import (
 	"os"
 	"testing"
 
+	"github.com/greenplum-db/gpupgrade/hub"
 	"github.com/greenplum-db/gpupgrade/testutils/exectest"
 )
 
+// testIdempotencyToken is sent with every mutating agent request so that
+// tests can match requests exactly.
+const testIdempotencyToken = "test-idempotency-token"
+
 // Enable exectest.NewCommand mocking.
 func TestMain(m *testing.M) {
+	hub.NewIdempotencyToken = func() string {
+		return testIdempotencyToken
+	}
+
 	os.Exit(exectest.Run(m))
 }
@@ -52,9 +52,12 @@ func UpgradeMaster(args UpgradeMasterArgs) error {
 		return err
 	}
 
-	pair := upgrade.SegmentPair{
-		Source: masterSegmentFromCluster(args.Source),
-		Target: masterSegmentFromCluster(args.Target),
+	pair := masterSegmentPair(args.Source, args.Target)
+
+	if args.UseLinkMode {
+		if err := upgrade.VerifyLinkMode(pair.Source.DataDir, pair.Target.DataDir); err != nil {
+			return err
+		}
 	}
 
 	// Buffer stdout to add context to errors.
@@ -66,21 +69,7 @@ func UpgradeMaster(args UpgradeMasterArgs) error {
 		upgrade.WithWorkDir(wd),
 		upgrade.WithOutputStreams(tee, args.Stream.Stderr()),
 	}
-
-	if args.CheckOnly {
-		options = append(options, upgrade.WithCheckOnly())
-	}
-
-	if args.UseLinkMode {
-		options = append(options, upgrade.WithLinkMode())
-	}
-
-	// When upgrading from 5 the master must be provided with its standby's dbid to allow WAL to sync.
-	if args.Source.Version.Before("6") {
-		if args.Source.HasStandby() {
-			options = append(options, upgrade.WithOldOptions(fmt.Sprintf("-x %d", args.Source.Standby().DbID)))
-		}
-	}
+	options = append(options, masterUpgradeOptions(args.Source, args.CheckOnly, args.UseLinkMode)...)
 
 	// FIXME: args.Target.Version comes from gp-common-go-libs, which uses a deprecated version of semver.
 	//   It is not compatible with the semver v4 we use in gpupgrade.
@@ -122,6 +111,37 @@ func UpgradeMaster(args UpgradeMasterArgs) error {
 	return nil
 }
 
+func masterSegmentPair(source, target *greenplum.Cluster) upgrade.SegmentPair {
+	return upgrade.SegmentPair{
+		Source: masterSegmentFromCluster(source),
+		Target: masterSegmentFromCluster(target),
+	}
+}
+
+// masterUpgradeOptions returns the Options that configure pg_upgrade for the
+// master. Callers using link mode should first check the data directories with
+// upgrade.VerifyLinkMode.
+func masterUpgradeOptions(source *greenplum.Cluster, checkOnly, useLinkMode bool) []upgrade.Option {
+	var options []upgrade.Option
+
+	if checkOnly {
+		options = append(options, upgrade.WithCheckOnly())
+	}
+
+	if useLinkMode {
+		options = append(options, upgrade.WithLinkMode())
+	}
+
+	// When upgrading from 5 the master must be provided with its standby's dbid to allow WAL to sync.
+	if source.Version.Before("6") {
+		if source.HasStandby() {
+			options = append(options, upgrade.WithOldOptions(fmt.Sprintf("-x %d", source.Standby().DbID)))
+		}
+	}
+
+	return options
+}
+
 type UpgradeMasterError struct {
 	FailedAction string
 	ErrorText    string
@@ -181,14 +201,7 @@ func fileEntries(root string) ([]string, error) {
 func RsyncMasterDataDir(stream step.OutStreams, sourceDir, targetDir string) error {
 	sourceDirRsync := filepath.Clean(sourceDir) + string(os.PathSeparator)
 
-	options := []rsync.Option{
-		rsync.WithSources(sourceDirRsync),
-		rsync.WithDestination(targetDir),
-		rsync.WithOptions("--archive", "--delete"),
-		rsync.WithExcludedFiles("pg_log/*"),
-		rsync.WithStream(stream),
-	}
-
+	options := append(masterDataDirRsyncOptions(sourceDir, targetDir), rsync.WithStream(stream))
 	err := rsync.Rsync(options...)
 	if err != nil {
 		return xerrors.Errorf("rsync %q to %q: %w", sourceDirRsync, targetDir, err)
@@ -196,3 +209,14 @@ func RsyncMasterDataDir(stream step.OutStreams, sourceDir, targetDir string) err
 
 	return nil
 }
+
+// masterDataDirRsyncOptions returns the options with which RsyncMasterDataDir
+// refreshes the target master data directory from the original backup.
+func masterDataDirRsyncOptions(sourceDir, targetDir string) []rsync.Option {
+	return []rsync.Option{
+		rsync.WithSources(filepath.Clean(sourceDir) + string(os.PathSeparator)),
+		rsync.WithDestination(targetDir),
+		rsync.WithOptions("--archive", "--delete"),
+		rsync.WithExcludedFiles("pg_log/*"),
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"sort"
 
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
 	"github.com/pkg/errors"
 	"golang.org/x/xerrors"
 
@@ -27,9 +28,12 @@ type UpgradePrimaryArgs struct {
 	TablespacesMappingFile string
 }
 
-func UpgradePrimaries(args UpgradePrimaryArgs) error {
-	request := func(conn *Connection) error {
-		_, err := conn.AgentClient.UpgradePrimaries(context.Background(), &idl.UpgradePrimariesRequest{
+// UpgradePrimaries upgrades the primaries on each agent, abandoning the
+// agents' upgrades once ctx is done.
+func UpgradePrimaries(ctx context.Context, args UpgradePrimaryArgs) error {
+	token := NewIdempotencyToken()
+	request := func(ctx context.Context, conn *Connection) error {
+		req := &idl.UpgradePrimariesRequest{
 			SourceBinDir:               filepath.Join(args.Source.GPHome, "bin"),
 			TargetBinDir:               filepath.Join(args.Target.GPHome, "bin"),
 			TargetVersion:              args.Target.Version.SemVer.String(),
@@ -38,6 +42,14 @@ func UpgradePrimaries(args UpgradePrimaryArgs) error {
 			UseLinkMode:                args.UseLinkMode,
 			MasterBackupDir:            args.MasterBackupDir,
 			TablespacesMappingFilePath: args.TablespacesMappingFile,
+			IdempotencyToken:           token,
+		}
+
+		var reply *idl.UpgradePrimariesReply
+		err := retryAgentRequest(ctx, conn.Hostname, func(ctx context.Context) error {
+			var err error
+			reply, err = conn.AgentClient.UpgradePrimaries(ctx, req)
+			return err
 		})
 		if err != nil {
 			failedAction := "upgrade"
@@ -47,21 +59,25 @@ func UpgradePrimaries(args UpgradePrimaryArgs) error {
 			return xerrors.Errorf("%s primary segment on host %s: %w", failedAction, conn.Hostname, err)
 		}
 
+		for dbID, path := range reply.GetLogFiles() {
+			gplog.Debug("pg_upgrade output for dbid %d is in %q on host %s", dbID, path, conn.Hostname)
+		}
+
 		return nil
 	}
 
-	return ExecuteRPC(args.AgentConns, request)
+	return ExecuteRPCContext(ctx, args.AgentConns, request)
 }
 
 // ErrInvalidCluster is returned by GetDataDirPairs if the source and target
 // clusters content id's clusters do not match.
 var ErrInvalidCluster = errors.New("Source and target clusters do not match")
 
-func (s *Server) GetDataDirPairs() (map[string][]*idl.DataDirPair, error) {
+func (c *Config) GetDataDirPairs() (map[string][]*idl.DataDirPair, error) {
 	dataDirPairMap := make(map[string][]*idl.DataDirPair)
 
-	sourceContents := s.Source.ContentIDs
-	targetContents := s.Target.ContentIDs
+	sourceContents := c.Source.ContentIDs
+	targetContents := c.Target.ContentIDs
 	if len(sourceContents) != len(targetContents) {
 		return nil, newInvalidClusterError("Source cluster has %d segments, and target cluster has %d segments.", len(sourceContents), len(targetContents))
 	}
@@ -73,12 +89,12 @@ func (s *Server) GetDataDirPairs() (map[string][]*idl.DataDirPair, error) {
 		}
 	}
 
-	for _, contentID := range s.Source.ContentIDs {
+	for _, contentID := range c.Source.ContentIDs {
 		if contentID == -1 {
 			continue
 		}
-		sourceSeg := s.Source.Primaries[contentID]
-		targetSeg := s.Target.Primaries[contentID]
+		sourceSeg := c.Source.Primaries[contentID]
+		targetSeg := c.Target.Primaries[contentID]
 		if sourceSeg.Hostname != targetSeg.Hostname {
 			return nil, newInvalidClusterError(
 				"hostnames do not match between source and target cluster with content ID %d. "+
@@ -93,7 +109,7 @@ func (s *Server) GetDataDirPairs() (map[string][]*idl.DataDirPair, error) {
 			TargetPort:    int32(targetSeg.Port),
 			Content:       int32(contentID),
 			DBID:          int32(sourceSeg.DbID),
-			Tablespaces:   getProtoTablespaceMap(s.Tablespaces, targetSeg.DbID),
+			Tablespaces:   getProtoTablespaceMap(c.Tablespaces, targetSeg.DbID),
 		}
 
 		dataDirPairMap[sourceSeg.Hostname] = append(dataDirPairMap[sourceSeg.Hostname], dataPair)
@@ -4,6 +4,7 @@
 package hub_test
 
 import (
+	"context"
 	"errors"
 	"strings"
 	"testing"
@@ -81,6 +82,7 @@ func TestUpgradePrimaries(t *testing.T) {
 				UseLinkMode:                false,
 				MasterBackupDir:            "",
 				TablespacesMappingFilePath: "/tmp/tablespaces_mapping.txt",
+				IdempotencyToken:           testIdempotencyToken,
 			},
 		).Return(&idl.UpgradePrimariesReply{}, nil)
 
@@ -96,6 +98,7 @@ func TestUpgradePrimaries(t *testing.T) {
 				UseLinkMode:                false,
 				MasterBackupDir:            "",
 				TablespacesMappingFilePath: "/tmp/tablespaces_mapping.txt",
+				IdempotencyToken:           testIdempotencyToken,
 			},
 		).Return(&idl.UpgradePrimariesReply{}, nil)
 
@@ -104,7 +107,7 @@ func TestUpgradePrimaries(t *testing.T) {
 			{nil, client2, "sdw2", nil},
 		}
 
-		err := hub.UpgradePrimaries(hub.UpgradePrimaryArgs{
+		err := hub.UpgradePrimaries(context.Background(), hub.UpgradePrimaryArgs{
 			CheckOnly:              false,
 			MasterBackupDir:        "",
 			AgentConns:             agentConns,
@@ -146,13 +149,14 @@ func TestUpgradePrimaries(t *testing.T) {
 				client1.EXPECT().UpgradePrimaries(
 					gomock.Any(),
 					&idl.UpgradePrimariesRequest{
-						SourceBinDir:    "/usr/local/greenplum-db/bin",
-						TargetBinDir:    "/usr/local/greenplum-db-new/bin",
-						TargetVersion:   dbconn.NewVersion("6.0.0").VersionString,
-						DataDirPairs:    pairs["sdw1"],
-						CheckOnly:       c.CheckOnly,
-						UseLinkMode:     false,
-						MasterBackupDir: "",
+						SourceBinDir:     "/usr/local/greenplum-db/bin",
+						TargetBinDir:     "/usr/local/greenplum-db-new/bin",
+						TargetVersion:    dbconn.NewVersion("6.0.0").VersionString,
+						DataDirPairs:     pairs["sdw1"],
+						CheckOnly:        c.CheckOnly,
+						UseLinkMode:      false,
+						MasterBackupDir:  "",
+						IdempotencyToken: testIdempotencyToken,
 					},
 				).Return(&idl.UpgradePrimariesReply{}, nil)
 
@@ -169,6 +173,7 @@ func TestUpgradePrimaries(t *testing.T) {
 						UseLinkMode:                false,
 						MasterBackupDir:            "",
 						TablespacesMappingFilePath: "",
+						IdempotencyToken:           testIdempotencyToken,
 					},
 				).Return(&idl.UpgradePrimariesReply{}, expected)
 
@@ -177,7 +182,7 @@ func TestUpgradePrimaries(t *testing.T) {
 					{nil, failedClient, "sdw2", nil},
 				}
 
-				err := hub.UpgradePrimaries(hub.UpgradePrimaryArgs{
+				err := hub.UpgradePrimaries(context.Background(), hub.UpgradePrimaryArgs{
 					CheckOnly:              c.CheckOnly,
 					MasterBackupDir:        "",
 					AgentConns:             agentConns,
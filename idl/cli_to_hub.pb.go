@@ -229,7 +229,7 @@ func (x Chunk_Type) String() string {
 }
 
 func (Chunk_Type) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{12, 0}
+	return fileDescriptor_631e66a01873be02, []int{16, 0}
 }
 
 type InitializeRequest struct {
@@ -583,6 +583,146 @@ func (m *StopServicesReply) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_StopServicesReply proto.InternalMessageInfo
 
+type ConfirmRequest struct {
+	Substep              Substep  `protobuf:"varint,1,opt,name=substep,proto3,enum=idl.Substep" json:"substep,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ConfirmRequest) Reset()         { *m = ConfirmRequest{} }
+func (m *ConfirmRequest) String() string { return proto.CompactTextString(m) }
+func (*ConfirmRequest) ProtoMessage()    {}
+func (*ConfirmRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{9}
+}
+
+func (m *ConfirmRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ConfirmRequest.Unmarshal(m, b)
+}
+func (m *ConfirmRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ConfirmRequest.Marshal(b, m, deterministic)
+}
+func (m *ConfirmRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ConfirmRequest.Merge(m, src)
+}
+func (m *ConfirmRequest) XXX_Size() int {
+	return xxx_messageInfo_ConfirmRequest.Size(m)
+}
+func (m *ConfirmRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ConfirmRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ConfirmRequest proto.InternalMessageInfo
+
+func (m *ConfirmRequest) GetSubstep() Substep {
+	if m != nil {
+		return m.Substep
+	}
+	return Substep_UNKNOWN_SUBSTEP
+}
+
+type ConfirmReply struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ConfirmReply) Reset()         { *m = ConfirmReply{} }
+func (m *ConfirmReply) String() string { return proto.CompactTextString(m) }
+func (*ConfirmReply) ProtoMessage()    {}
+func (*ConfirmReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{10}
+}
+
+func (m *ConfirmReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ConfirmReply.Unmarshal(m, b)
+}
+func (m *ConfirmReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ConfirmReply.Marshal(b, m, deterministic)
+}
+func (m *ConfirmReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ConfirmReply.Merge(m, src)
+}
+func (m *ConfirmReply) XXX_Size() int {
+	return xxx_messageInfo_ConfirmReply.Size(m)
+}
+func (m *ConfirmReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_ConfirmReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ConfirmReply proto.InternalMessageInfo
+
+type AbortRequest struct {
+	Substep              Substep  `protobuf:"varint,1,opt,name=substep,proto3,enum=idl.Substep" json:"substep,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AbortRequest) Reset()         { *m = AbortRequest{} }
+func (m *AbortRequest) String() string { return proto.CompactTextString(m) }
+func (*AbortRequest) ProtoMessage()    {}
+func (*AbortRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{11}
+}
+
+func (m *AbortRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AbortRequest.Unmarshal(m, b)
+}
+func (m *AbortRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AbortRequest.Marshal(b, m, deterministic)
+}
+func (m *AbortRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AbortRequest.Merge(m, src)
+}
+func (m *AbortRequest) XXX_Size() int {
+	return xxx_messageInfo_AbortRequest.Size(m)
+}
+func (m *AbortRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AbortRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AbortRequest proto.InternalMessageInfo
+
+func (m *AbortRequest) GetSubstep() Substep {
+	if m != nil {
+		return m.Substep
+	}
+	return Substep_UNKNOWN_SUBSTEP
+}
+
+type AbortReply struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AbortReply) Reset()         { *m = AbortReply{} }
+func (m *AbortReply) String() string { return proto.CompactTextString(m) }
+func (*AbortReply) ProtoMessage()    {}
+func (*AbortReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{12}
+}
+
+func (m *AbortReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AbortReply.Unmarshal(m, b)
+}
+func (m *AbortReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AbortReply.Marshal(b, m, deterministic)
+}
+func (m *AbortReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AbortReply.Merge(m, src)
+}
+func (m *AbortReply) XXX_Size() int {
+	return xxx_messageInfo_AbortReply.Size(m)
+}
+func (m *AbortReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_AbortReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AbortReply proto.InternalMessageInfo
+
 type SubstepStatus struct {
 	Step                 Substep  `protobuf:"varint,1,opt,name=step,proto3,enum=idl.Substep" json:"step,omitempty"`
 	Status               Status   `protobuf:"varint,2,opt,name=status,proto3,enum=idl.Status" json:"status,omitempty"`
@@ -595,7 +735,7 @@ func (m *SubstepStatus) Reset()         { *m = SubstepStatus{} }
 func (m *SubstepStatus) String() string { return proto.CompactTextString(m) }
 func (*SubstepStatus) ProtoMessage()    {}
 func (*SubstepStatus) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{9}
+	return fileDescriptor_631e66a01873be02, []int{13}
 }
 
 func (m *SubstepStatus) XXX_Unmarshal(b []byte) error {
@@ -640,7 +780,7 @@ func (m *PrepareInitClusterRequest) Reset()         { *m = PrepareInitClusterReq
 func (m *PrepareInitClusterRequest) String() string { return proto.CompactTextString(m) }
 func (*PrepareInitClusterRequest) ProtoMessage()    {}
 func (*PrepareInitClusterRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{10}
+	return fileDescriptor_631e66a01873be02, []int{14}
 }
 
 func (m *PrepareInitClusterRequest) XXX_Unmarshal(b []byte) error {
@@ -671,7 +811,7 @@ func (m *PrepareInitClusterReply) Reset()         { *m = PrepareInitClusterReply
 func (m *PrepareInitClusterReply) String() string { return proto.CompactTextString(m) }
 func (*PrepareInitClusterReply) ProtoMessage()    {}
 func (*PrepareInitClusterReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{11}
+	return fileDescriptor_631e66a01873be02, []int{15}
 }
 
 func (m *PrepareInitClusterReply) XXX_Unmarshal(b []byte) error {
@@ -704,7 +844,7 @@ func (m *Chunk) Reset()         { *m = Chunk{} }
 func (m *Chunk) String() string { return proto.CompactTextString(m) }
 func (*Chunk) ProtoMessage()    {}
 func (*Chunk) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{12}
+	return fileDescriptor_631e66a01873be02, []int{16}
 }
 
 func (m *Chunk) XXX_Unmarshal(b []byte) error {
@@ -754,7 +894,7 @@ func (m *Message) Reset()         { *m = Message{} }
 func (m *Message) String() string { return proto.CompactTextString(m) }
 func (*Message) ProtoMessage()    {}
 func (*Message) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{13}
+	return fileDescriptor_631e66a01873be02, []int{17}
 }
 
 func (m *Message) XXX_Unmarshal(b []byte) error {
@@ -850,7 +990,7 @@ func (m *Response) Reset()         { *m = Response{} }
 func (m *Response) String() string { return proto.CompactTextString(m) }
 func (*Response) ProtoMessage()    {}
 func (*Response) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{14}
+	return fileDescriptor_631e66a01873be02, []int{18}
 }
 
 func (m *Response) XXX_Unmarshal(b []byte) error {
@@ -956,7 +1096,7 @@ func (m *InitializeResponse) Reset()         { *m = InitializeResponse{} }
 func (m *InitializeResponse) String() string { return proto.CompactTextString(m) }
 func (*InitializeResponse) ProtoMessage()    {}
 func (*InitializeResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{15}
+	return fileDescriptor_631e66a01873be02, []int{19}
 }
 
 func (m *InitializeResponse) XXX_Unmarshal(b []byte) error {
@@ -1003,7 +1143,7 @@ func (m *Cluster) Reset()         { *m = Cluster{} }
 func (m *Cluster) String() string { return proto.CompactTextString(m) }
 func (*Cluster) ProtoMessage()    {}
 func (*Cluster) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{16}
+	return fileDescriptor_631e66a01873be02, []int{20}
 }
 
 func (m *Cluster) XXX_Unmarshal(b []byte) error {
@@ -1049,7 +1189,7 @@ func (m *ExecuteResponse) Reset()         { *m = ExecuteResponse{} }
 func (m *ExecuteResponse) String() string { return proto.CompactTextString(m) }
 func (*ExecuteResponse) ProtoMessage()    {}
 func (*ExecuteResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{17}
+	return fileDescriptor_631e66a01873be02, []int{21}
 }
 
 func (m *ExecuteResponse) XXX_Unmarshal(b []byte) error {
@@ -1092,7 +1232,7 @@ func (m *FinalizeResponse) Reset()         { *m = FinalizeResponse{} }
 func (m *FinalizeResponse) String() string { return proto.CompactTextString(m) }
 func (*FinalizeResponse) ProtoMessage()    {}
 func (*FinalizeResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{18}
+	return fileDescriptor_631e66a01873be02, []int{22}
 }
 
 func (m *FinalizeResponse) XXX_Unmarshal(b []byte) error {
@@ -1161,7 +1301,7 @@ func (m *RevertResponse) Reset()         { *m = RevertResponse{} }
 func (m *RevertResponse) String() string { return proto.CompactTextString(m) }
 func (*RevertResponse) ProtoMessage()    {}
 func (*RevertResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{19}
+	return fileDescriptor_631e66a01873be02, []int{23}
 }
 
 func (m *RevertResponse) XXX_Unmarshal(b []byte) error {
@@ -1214,7 +1354,7 @@ func (m *GetConfigRequest) Reset()         { *m = GetConfigRequest{} }
 func (m *GetConfigRequest) String() string { return proto.CompactTextString(m) }
 func (*GetConfigRequest) ProtoMessage()    {}
 func (*GetConfigRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{20}
+	return fileDescriptor_631e66a01873be02, []int{24}
 }
 
 func (m *GetConfigRequest) XXX_Unmarshal(b []byte) error {
@@ -1253,7 +1393,7 @@ func (m *GetConfigReply) Reset()         { *m = GetConfigReply{} }
 func (m *GetConfigReply) String() string { return proto.CompactTextString(m) }
 func (*GetConfigReply) ProtoMessage()    {}
 func (*GetConfigReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{21}
+	return fileDescriptor_631e66a01873be02, []int{25}
 }
 
 func (m *GetConfigReply) XXX_Unmarshal(b []byte) error {
@@ -1295,6 +1435,10 @@ func init() {
 	proto.RegisterType((*RestartAgentsReply)(nil), "idl.RestartAgentsReply")
 	proto.RegisterType((*StopServicesRequest)(nil), "idl.StopServicesRequest")
 	proto.RegisterType((*StopServicesReply)(nil), "idl.StopServicesReply")
+	proto.RegisterType((*ConfirmRequest)(nil), "idl.ConfirmRequest")
+	proto.RegisterType((*ConfirmReply)(nil), "idl.ConfirmReply")
+	proto.RegisterType((*AbortRequest)(nil), "idl.AbortRequest")
+	proto.RegisterType((*AbortReply)(nil), "idl.AbortReply")
 	proto.RegisterType((*SubstepStatus)(nil), "idl.SubstepStatus")
 	proto.RegisterType((*PrepareInitClusterRequest)(nil), "idl.PrepareInitClusterRequest")
 	proto.RegisterType((*PrepareInitClusterReply)(nil), "idl.PrepareInitClusterReply")
@@ -1313,102 +1457,106 @@ func init() {
 func init() { proto.RegisterFile("cli_to_hub.proto", fileDescriptor_631e66a01873be02) }
 
 var fileDescriptor_631e66a01873be02 = []byte{
-	// 1509 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x57, 0x5f, 0x6f, 0xe2, 0xca,
-	0x15, 0x87, 0x04, 0x08, 0x1c, 0xfe, 0x4d, 0x06, 0x36, 0x21, 0xd9, 0xbd, 0x2b, 0xea, 0xbd, 0xba,
-	0x42, 0x7b, 0xaf, 0xa2, 0x55, 0x5a, 0xb5, 0xea, 0x43, 0xa5, 0x3a, 0xf6, 0x80, 0xad, 0x80, 0x6d,
-	0x8d, 0x4d, 0xda, 0xdb, 0x17, 0xe4, 0x90, 0xd9, 0xac, 0xb5, 0x5c, 0xcc, 0xb5, 0xcd, 0xaa, 0xe9,
-	0x87, 0xe8, 0x53, 0xbf, 0x40, 0x1f, 0xaa, 0x7e, 0xbd, 0x7e, 0x80, 0x3e, 0x54, 0x33, 0x1e, 0x13,
-	0xe3, 0xb0, 0x6a, 0xfb, 0x86, 0x7f, 0xbf, 0x73, 0xce, 0x9c, 0x7f, 0x73, 0xe6, 0x00, 0x68, 0xb9,
-	0x0a, 0x16, 0x49, 0xb8, 0xf8, 0xb4, 0xbd, 0xbf, 0xda, 0x44, 0x61, 0x12, 0xe2, 0xe3, 0xe0, 0x61,
-	0xa5, 0xfc, 0xfd, 0x08, 0x4e, 0xcd, 0x75, 0x90, 0x04, 0xfe, 0x2a, 0xf8, 0x0b, 0xa3, 0xec, 0xe7,
-	0x2d, 0x8b, 0x13, 0xfc, 0x06, 0x1a, 0xfe, 0x23, 0x5b, 0x27, 0x4e, 0x18, 0x25, 0x83, 0xf2, 0xb0,
-	0x3c, 0xaa, 0xd2, 0x67, 0x00, 0x2b, 0xd0, 0x8a, 0xc3, 0x6d, 0xb4, 0x64, 0x13, 0xc7, 0x08, 0x7f,
-	0x62, 0x83, 0xa3, 0x61, 0x79, 0xd4, 0xa0, 0x7b, 0x18, 0x97, 0x49, 0xfc, 0xe8, 0x91, 0x25, 0x52,
-	0xe6, 0x38, 0x95, 0xc9, 0x63, 0xf8, 0x2d, 0x40, 0xaa, 0x23, 0x8e, 0xa9, 0x88, 0x63, 0x72, 0x08,
-	0x1e, 0x42, 0x73, 0x1b, 0xb3, 0x69, 0xb0, 0xfe, 0x3c, 0x0b, 0x1f, 0xd8, 0xa0, 0x3a, 0x2c, 0x8f,
-	0xea, 0x34, 0x0f, 0xe1, 0x11, 0x74, 0xb7, 0x31, 0x33, 0xee, 0x7d, 0x23, 0x8c, 0x93, 0xb5, 0xff,
-	0x13, 0x8b, 0x07, 0x35, 0x21, 0x55, 0x84, 0x71, 0x1f, 0xaa, 0x9b, 0x30, 0x4a, 0xe2, 0xc1, 0xc9,
-	0xf0, 0x78, 0xd4, 0xa6, 0xe9, 0x07, 0xfe, 0x16, 0xda, 0x0f, 0x41, 0xfc, 0x79, 0x1c, 0x31, 0x46,
-	0xfd, 0x24, 0x08, 0x07, 0xf5, 0x61, 0x79, 0x54, 0xa6, 0xfb, 0xa0, 0x32, 0x84, 0xb7, 0xcf, 0x29,
-	0xd2, 0x22, 0xe6, 0x27, 0x4c, 0x5b, 0x6d, 0xe3, 0x84, 0x45, 0x32, 0x5f, 0x0a, 0x82, 0x0e, 0xf9,
-	0x33, 0x5b, 0x6e, 0x93, 0x2c, 0x83, 0xca, 0x29, 0x74, 0xc7, 0xc1, 0x3a, 0x9f, 0x54, 0xa5, 0x0b,
-	0x6d, 0xca, 0xbe, 0xb0, 0x28, 0xc9, 0x80, 0x33, 0xe8, 0x53, 0x16, 0x27, 0x7e, 0x94, 0xa8, 0x3c,
-	0xb7, 0x71, 0x86, 0xff, 0x0a, 0x70, 0x01, 0xdf, 0xac, 0x9e, 0x78, 0xb6, 0x44, 0x09, 0x78, 0x4c,
-	0xf1, 0xa0, 0x3c, 0x3c, 0x1e, 0x35, 0x68, 0x0e, 0x51, 0x5e, 0x41, 0xcf, 0x4d, 0xc2, 0x8d, 0xcb,
-	0xa2, 0x2f, 0xc1, 0x92, 0xed, 0x8c, 0xf5, 0xe0, 0x74, 0x1f, 0xde, 0xac, 0x9e, 0x94, 0x3b, 0x68,
-	0xbb, 0xdb, 0xfb, 0x38, 0x61, 0x1b, 0x37, 0xf1, 0x93, 0x6d, 0x8c, 0x87, 0x50, 0xe1, 0x5f, 0xa2,
-	0xd6, 0x9d, 0xeb, 0xd6, 0x55, 0xf0, 0xb0, 0xba, 0x92, 0x12, 0x54, 0x30, 0xf8, 0x1d, 0xd4, 0x62,
-	0x21, 0x2b, 0xca, 0xdd, 0xb9, 0x6e, 0xa6, 0x32, 0x02, 0xa2, 0x92, 0x52, 0x5e, 0xc3, 0x85, 0x13,
-	0xb1, 0x8d, 0x1f, 0x31, 0x9e, 0xb0, 0x42, 0x92, 0x2e, 0xe0, 0xfc, 0x10, 0xc9, 0xfd, 0xf9, 0x19,
-	0xaa, 0xda, 0xa7, 0xed, 0xfa, 0x33, 0x3e, 0x83, 0xda, 0xfd, 0xf6, 0xe3, 0x47, 0x16, 0x09, 0x4f,
-	0x5a, 0x54, 0x7e, 0xe1, 0x77, 0x50, 0x49, 0x9e, 0x36, 0x4c, 0x9e, 0xdd, 0x15, 0x67, 0x0b, 0x8d,
-	0x2b, 0xef, 0x69, 0xc3, 0xa8, 0x20, 0x95, 0xef, 0xa1, 0xc2, 0xbf, 0x70, 0x13, 0x4e, 0xe6, 0xd6,
-	0xad, 0x65, 0xff, 0xc1, 0x42, 0x25, 0x0c, 0x50, 0x73, 0x3d, 0xdd, 0x9e, 0x7b, 0xa8, 0x2c, 0x7f,
-	0x13, 0x4a, 0xd1, 0x91, 0xf2, 0xb7, 0x32, 0x9c, 0xcc, 0x58, 0x1c, 0xfb, 0x8f, 0xbc, 0x59, 0xab,
-	0x4b, 0x6e, 0x4c, 0x1c, 0xda, 0xbc, 0x86, 0x67, 0xf3, 0x46, 0x89, 0xa6, 0x14, 0xfe, 0x61, 0x2f,
-	0xfe, 0xe6, 0x35, 0xce, 0xe7, 0x28, 0x4d, 0x83, 0x51, 0xca, 0x12, 0x81, 0xbf, 0x87, 0x7a, 0xc4,
-	0xe2, 0x4d, 0xb8, 0x8e, 0xd3, 0xd6, 0x6f, 0x5e, 0xb7, 0x85, 0x3c, 0x95, 0xa0, 0x51, 0xa2, 0x3b,
-	0x81, 0x1b, 0x80, 0xfa, 0x32, 0x5c, 0x27, 0xbc, 0xd4, 0xca, 0x3f, 0x8f, 0xa0, 0x9e, 0x09, 0x61,
-	0x13, 0x70, 0x90, 0xbb, 0x9b, 0x7b, 0xf6, 0xce, 0x85, 0x3d, 0xf3, 0x05, 0x6d, 0x94, 0xe8, 0x01,
-	0x25, 0xfc, 0x7b, 0xe8, 0xb2, 0xac, 0x43, 0xa5, 0x9d, 0x8a, 0xb0, 0xd3, 0x17, 0x76, 0xc8, 0x3e,
-	0x67, 0x94, 0x68, 0x51, 0x1c, 0x6b, 0x80, 0x3e, 0xee, 0x3a, 0x5a, 0x9a, 0xa8, 0x0a, 0x13, 0xaf,
-	0x84, 0x89, 0x71, 0x81, 0x34, 0x4a, 0xf4, 0x85, 0x02, 0xfe, 0x1d, 0x74, 0x22, 0x79, 0x07, 0xa4,
-	0x89, 0x9a, 0x30, 0xd1, 0x93, 0xd9, 0xc9, 0x53, 0x46, 0x89, 0x16, 0x84, 0xf7, 0x32, 0xe5, 0x01,
-	0x7e, 0x19, 0x3d, 0xbf, 0x25, 0x86, 0x1f, 0xcf, 0x82, 0x28, 0x0a, 0xa3, 0x58, 0xd4, 0xb3, 0x4e,
-	0x73, 0x88, 0xe4, 0xdd, 0xc4, 0x5f, 0x3f, 0xdc, 0x3f, 0x89, 0x52, 0xa6, 0xbc, 0x44, 0x14, 0x1b,
-	0x4e, 0x64, 0x67, 0x62, 0x0c, 0x95, 0xdc, 0xfc, 0x13, 0xbf, 0xf1, 0x07, 0xe8, 0xcd, 0x7c, 0xce,
-	0xea, 0x7e, 0xe2, 0xeb, 0x41, 0xc4, 0x96, 0x49, 0x18, 0x3d, 0xc9, 0x09, 0x78, 0x88, 0x52, 0x7e,
-	0x03, 0xdd, 0x42, 0x72, 0xf1, 0xb7, 0x50, 0x4b, 0xe7, 0xa0, 0xec, 0xb7, 0xf4, 0xba, 0x65, 0x17,
-	0x42, 0x72, 0xca, 0xbf, 0xcb, 0x80, 0x8a, 0x39, 0xfd, 0xdf, 0x54, 0xf9, 0x58, 0xf3, 0xc4, 0xaf,
-	0x3b, 0x16, 0xc5, 0x41, 0xb8, 0x96, 0xfe, 0xed, 0x83, 0x3c, 0x96, 0x69, 0xf8, 0xa8, 0x46, 0xcb,
-	0x4f, 0xc1, 0x17, 0xf6, 0x1c, 0x4b, 0x3a, 0xa9, 0x0f, 0x51, 0x78, 0x0a, 0xbf, 0x90, 0xd8, 0x83,
-	0x2b, 0xc6, 0xf4, 0xa1, 0x5c, 0x54, 0x84, 0xfe, 0x7f, 0x17, 0xe4, 0x8f, 0xcc, 0x7c, 0xf3, 0x18,
-	0xf9, 0x0f, 0xcc, 0xd4, 0x45, 0x27, 0x35, 0xe8, 0x33, 0xa0, 0xfc, 0xb5, 0x0c, 0x9d, 0xfd, 0x7e,
-	0xe0, 0xc1, 0xa7, 0xaf, 0xc3, 0xe1, 0xe0, 0x53, 0x8e, 0x07, 0x9f, 0x9e, 0x59, 0x08, 0x7e, 0x0f,
-	0xfc, 0xff, 0x83, 0x57, 0xbe, 0x03, 0x34, 0x61, 0x89, 0x16, 0xae, 0x3f, 0x06, 0x8f, 0xd9, 0x3b,
-	0x89, 0xa1, 0xc2, 0x9f, 0x17, 0xe1, 0x4f, 0x83, 0x8a, 0xdf, 0xca, 0x77, 0xd0, 0xc9, 0xc9, 0xf1,
-	0xc9, 0xdd, 0x87, 0xea, 0x17, 0x7f, 0xb5, 0xcd, 0xc4, 0xd2, 0x8f, 0xf7, 0x36, 0x54, 0x5c, 0x3e,
-	0x58, 0x11, 0xb4, 0xe4, 0xb4, 0x5a, 0xb8, 0x1e, 0x71, 0x50, 0x09, 0x77, 0x00, 0x4c, 0xcb, 0xf4,
-	0x4c, 0x75, 0x6a, 0xfe, 0x89, 0xa0, 0x32, 0x9f, 0x67, 0xe4, 0x8f, 0x44, 0x9b, 0x7b, 0x04, 0x1d,
-	0xe1, 0x16, 0xd4, 0xc7, 0xa6, 0x95, 0x52, 0xc7, 0x7c, 0xa2, 0x51, 0x72, 0x47, 0xa8, 0x87, 0x2a,
-	0xef, 0xff, 0x51, 0x83, 0x13, 0x39, 0x8f, 0x70, 0x0f, 0xba, 0x3b, 0xa3, 0xf3, 0x1b, 0x69, 0x77,
-	0x08, 0x6f, 0x5c, 0xf5, 0xce, 0xb4, 0x26, 0x0b, 0xd7, 0x9e, 0x53, 0x8d, 0x2c, 0xb4, 0xe9, 0xdc,
-	0xf5, 0x08, 0x5d, 0x68, 0xb6, 0x35, 0x36, 0x27, 0xa8, 0x8c, 0xdb, 0xd0, 0x70, 0x3d, 0x95, 0x7a,
-	0x0b, 0x63, 0x7e, 0x83, 0x8e, 0xb8, 0x6b, 0xe9, 0xa7, 0x3a, 0x21, 0x96, 0xe7, 0xa2, 0x63, 0xdc,
-	0x07, 0xa4, 0x19, 0x44, 0xbb, 0x5d, 0xe8, 0xa6, 0x7b, 0xbb, 0x70, 0x1d, 0x55, 0x23, 0xa8, 0x82,
-	0x2f, 0xe1, 0x6c, 0x42, 0x2c, 0x42, 0x55, 0x8f, 0x2c, 0x3c, 0x95, 0x4e, 0x88, 0x97, 0x99, 0xac,
-	0xe2, 0x73, 0xe8, 0xf1, 0x60, 0x76, 0x78, 0x7a, 0x24, 0xaa, 0xe1, 0xd7, 0x70, 0xee, 0x1a, 0x73,
-	0x4f, 0xe7, 0x3e, 0x16, 0xc8, 0x13, 0x3c, 0x80, 0xfe, 0x8d, 0xaa, 0xdd, 0xce, 0x9d, 0x8c, 0x9a,
-	0xa9, 0x82, 0xa9, 0xe3, 0x53, 0x68, 0xa7, 0x1e, 0xcc, 0x9d, 0x09, 0x55, 0x75, 0x82, 0x1a, 0x7b,
-	0x96, 0xf6, 0x23, 0x43, 0x80, 0x31, 0x74, 0xa4, 0x64, 0x66, 0xa3, 0x89, 0xbb, 0xd0, 0xd4, 0x6c,
-	0xe7, 0xc7, 0x0c, 0x68, 0xe1, 0x57, 0x70, 0x9a, 0x09, 0x39, 0xd4, 0x9c, 0xa9, 0xd4, 0x24, 0x2e,
-	0x6a, 0x73, 0x2f, 0xd2, 0xf8, 0x0b, 0xfe, 0x75, 0xf0, 0x0f, 0x30, 0x9a, 0x3b, 0x7a, 0x3e, 0x5e,
-	0xd5, 0x53, 0xa7, 0xf6, 0x64, 0xa1, 0x5a, 0x7a, 0x31, 0xad, 0x5d, 0xee, 0xa0, 0x94, 0xd6, 0x55,
-	0x4f, 0x5d, 0xe8, 0x26, 0x25, 0x9a, 0x67, 0x8b, 0x43, 0x10, 0x7e, 0x03, 0x83, 0x82, 0x29, 0xdb,
-	0x1a, 0x2f, 0xc6, 0xe6, 0x94, 0xb8, 0xe8, 0x54, 0x14, 0x52, 0x7a, 0xe6, 0x7a, 0xaa, 0xa5, 0xdf,
-	0xfc, 0x88, 0x70, 0x1e, 0x9c, 0x99, 0x94, 0xda, 0xd4, 0x45, 0x3d, 0x7c, 0x06, 0x58, 0x27, 0x53,
-	0x22, 0xec, 0xdc, 0x4c, 0x89, 0xa8, 0x8d, 0x8b, 0xfa, 0x58, 0x81, 0xb7, 0x3b, 0x3c, 0x1f, 0x85,
-	0xf0, 0x45, 0x37, 0xa9, 0x8b, 0x5e, 0x71, 0x1f, 0xa4, 0x8c, 0x4b, 0x26, 0x33, 0x62, 0x79, 0xfc,
-	0x30, 0x8f, 0x08, 0xf6, 0x8c, 0x97, 0xd0, 0xf5, 0x6c, 0x87, 0x37, 0x85, 0x88, 0x4f, 0x76, 0xc3,
-	0x39, 0xaf, 0xbb, 0x54, 0x4b, 0x33, 0xb9, 0xd3, 0x42, 0x03, 0x1e, 0xb3, 0x4a, 0x35, 0xc3, 0xbc,
-	0x23, 0x0b, 0x9e, 0x97, 0x7c, 0xcc, 0x17, 0x5c, 0x91, 0x12, 0xd7, 0xb3, 0x29, 0x29, 0x16, 0xec,
-	0xf2, 0x39, 0xe9, 0x05, 0xe6, 0x35, 0xaf, 0x52, 0xa6, 0xe5, 0x4c, 0x34, 0xdb, 0xf2, 0xa8, 0x3d,
-	0x45, 0x6f, 0xf0, 0x37, 0x70, 0x41, 0x89, 0x66, 0xdf, 0x11, 0xea, 0x92, 0x62, 0x6b, 0xa3, 0x6f,
-	0x78, 0xb1, 0x79, 0xff, 0x0b, 0xdf, 0xe6, 0x2e, 0x7a, 0xfb, 0xde, 0x81, 0x9a, 0xdc, 0x7a, 0x78,
-	0x6f, 0xec, 0xae, 0x9e, 0x60, 0x4b, 0xfc, 0xb2, 0xd1, 0xb9, 0x65, 0x99, 0x16, 0xbf, 0x0f, 0x2d,
-	0xa8, 0x6b, 0xf6, 0xcc, 0xe1, 0x21, 0xa2, 0x23, 0x7e, 0xd9, 0xc6, 0xaa, 0x39, 0x25, 0x3a, 0x3a,
-	0xe6, 0x62, 0xee, 0xad, 0xe9, 0x38, 0x44, 0x47, 0x95, 0xeb, 0x7f, 0x1d, 0x43, 0x5d, 0x5b, 0x05,
-	0x5e, 0x68, 0x6c, 0xef, 0xf1, 0xaf, 0x01, 0x9e, 0xdf, 0x25, 0x7c, 0xf6, 0xe2, 0x99, 0x16, 0x93,
-	0xe3, 0x32, 0x9d, 0x5d, 0x72, 0x01, 0x51, 0x4a, 0x1f, 0xca, 0xd8, 0x81, 0xf3, 0xaf, 0x6c, 0x99,
-	0xf8, 0x5d, 0xc1, 0xc8, 0xa1, 0x1d, 0xf4, 0x80, 0xc5, 0x0f, 0x70, 0x22, 0x9f, 0x1e, 0xdc, 0xdb,
-	0x7f, 0xe5, 0xbf, 0xa6, 0x71, 0x0d, 0xf5, 0xec, 0xc9, 0xc1, 0xfd, 0xc2, 0xab, 0xfe, 0x35, 0x9d,
-	0x2b, 0xa8, 0xa5, 0x73, 0x1a, 0xe3, 0xbd, 0x47, 0xfc, 0x6b, 0xf2, 0xbf, 0x85, 0xc6, 0x6e, 0x3e,
-	0xe2, 0x74, 0x75, 0x28, 0xce, 0xd5, 0xcb, 0x5e, 0x11, 0xe6, 0x4b, 0x62, 0x09, 0x13, 0xbe, 0x41,
-	0xe7, 0x16, 0x63, 0x7c, 0x91, 0x2d, 0x55, 0x2f, 0x96, 0xe8, 0xcb, 0xf3, 0x43, 0x54, 0x6a, 0xe6,
-	0x06, 0x5a, 0xf9, 0x95, 0x18, 0x0f, 0xe4, 0x2a, 0xfb, 0x62, 0x79, 0xbe, 0x3c, 0x3b, 0xc0, 0x08,
-	0x1b, 0xf7, 0x35, 0xf1, 0x1f, 0xea, 0x97, 0xff, 0x09, 0x00, 0x00, 0xff, 0xff, 0xb4, 0xc8, 0x6d,
-	0x54, 0x57, 0x0d, 0x00, 0x00,
+	// 1574 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x57, 0xdd, 0x6e, 0xdb, 0xc8,
+	0x15, 0x96, 0x6c, 0xfd, 0xf9, 0x58, 0x3f, 0xe3, 0x91, 0x63, 0xcb, 0x4e, 0x36, 0x50, 0x99, 0x45,
+	0x60, 0x64, 0xb7, 0x46, 0xe0, 0x2d, 0xb6, 0xed, 0x45, 0x81, 0xd2, 0xe4, 0x58, 0x24, 0x2c, 0x91,
+	0xc4, 0x90, 0x72, 0xbb, 0xbd, 0x11, 0x68, 0x79, 0xe2, 0x10, 0x51, 0x44, 0x2d, 0x49, 0x05, 0x75,
+	0x1f, 0xa2, 0x57, 0x7d, 0x81, 0x5e, 0x14, 0x7d, 0xa1, 0x3e, 0x4e, 0x2f, 0x8a, 0x19, 0x0e, 0x65,
+	0x92, 0x96, 0xd1, 0xcd, 0x9d, 0xf8, 0x7d, 0xe7, 0x7c, 0x73, 0xce, 0x99, 0x99, 0x33, 0x47, 0x80,
+	0xe6, 0x8b, 0x60, 0x96, 0x84, 0xb3, 0x8f, 0xeb, 0xdb, 0xf3, 0x55, 0x14, 0x26, 0x21, 0xde, 0x0d,
+	0xee, 0x16, 0xca, 0x3f, 0x77, 0xe0, 0xc0, 0x5c, 0x06, 0x49, 0xe0, 0x2f, 0x82, 0xbf, 0x31, 0xca,
+	0x7e, 0x5e, 0xb3, 0x38, 0xc1, 0xaf, 0x60, 0xcf, 0xbf, 0x67, 0xcb, 0xc4, 0x09, 0xa3, 0x64, 0x50,
+	0x1d, 0x56, 0xcf, 0xea, 0xf4, 0x11, 0xc0, 0x0a, 0xb4, 0xe3, 0x70, 0x1d, 0xcd, 0xd9, 0xc8, 0x31,
+	0xc2, 0xcf, 0x6c, 0xb0, 0x33, 0xac, 0x9e, 0xed, 0xd1, 0x02, 0xc6, 0x6d, 0x12, 0x3f, 0xba, 0x67,
+	0x89, 0xb4, 0xd9, 0x4d, 0x6d, 0xf2, 0x18, 0x7e, 0x0d, 0x90, 0xfa, 0x88, 0x65, 0x6a, 0x62, 0x99,
+	0x1c, 0x82, 0x87, 0xb0, 0xbf, 0x8e, 0xd9, 0x38, 0x58, 0x7e, 0x9a, 0x84, 0x77, 0x6c, 0x50, 0x1f,
+	0x56, 0xcf, 0x5a, 0x34, 0x0f, 0xe1, 0x33, 0xe8, 0xad, 0x63, 0x66, 0xdc, 0xfa, 0x46, 0x18, 0x27,
+	0x4b, 0xff, 0x33, 0x8b, 0x07, 0x0d, 0x61, 0x55, 0x86, 0xf1, 0x21, 0xd4, 0x57, 0x61, 0x94, 0xc4,
+	0x83, 0xe6, 0x70, 0xf7, 0xac, 0x43, 0xd3, 0x0f, 0xfc, 0x2d, 0x74, 0xee, 0x82, 0xf8, 0xd3, 0x55,
+	0xc4, 0x18, 0xf5, 0x93, 0x20, 0x1c, 0xb4, 0x86, 0xd5, 0xb3, 0x2a, 0x2d, 0x82, 0xca, 0x10, 0x5e,
+	0x3f, 0x96, 0x48, 0x8b, 0x98, 0x9f, 0x30, 0x6d, 0xb1, 0x8e, 0x13, 0x16, 0xc9, 0x7a, 0x29, 0x08,
+	0xba, 0xe4, 0xaf, 0x6c, 0xbe, 0x4e, 0xb2, 0x0a, 0x2a, 0x07, 0xd0, 0xbb, 0x0a, 0x96, 0xf9, 0xa2,
+	0x2a, 0x3d, 0xe8, 0x50, 0xf6, 0x85, 0x45, 0x49, 0x06, 0x1c, 0xc1, 0x21, 0x65, 0x71, 0xe2, 0x47,
+	0x89, 0xca, 0x6b, 0x1b, 0x67, 0xf8, 0x6f, 0x00, 0x97, 0xf0, 0xd5, 0xe2, 0x81, 0x57, 0x4b, 0x6c,
+	0x01, 0xcf, 0x29, 0x1e, 0x54, 0x87, 0xbb, 0x67, 0x7b, 0x34, 0x87, 0x28, 0x2f, 0xa0, 0xef, 0x26,
+	0xe1, 0xca, 0x65, 0xd1, 0x97, 0x60, 0xce, 0x36, 0x62, 0x7d, 0x38, 0x28, 0xc2, 0xab, 0xc5, 0x83,
+	0xf2, 0x3b, 0xe8, 0x6a, 0xe1, 0xf2, 0x43, 0x10, 0x7d, 0xce, 0x76, 0xfc, 0x2d, 0x34, 0xe3, 0xf5,
+	0x6d, 0x9c, 0xb0, 0x95, 0xd8, 0xef, 0xee, 0x45, 0xfb, 0x3c, 0xb8, 0x5b, 0x9c, 0xbb, 0x29, 0x46,
+	0x33, 0x52, 0xe9, 0x42, 0x7b, 0xe3, 0xc9, 0x95, 0x7e, 0x84, 0xb6, 0x7a, 0x1b, 0x46, 0xc9, 0xd7,
+	0xea, 0xb4, 0x01, 0xa4, 0x1f, 0x57, 0xb9, 0x81, 0x8e, 0xb4, 0x70, 0x13, 0x3f, 0x59, 0xc7, 0x78,
+	0x08, 0xb5, 0x67, 0x35, 0x04, 0x83, 0xdf, 0x40, 0x23, 0x16, 0xb6, 0xe2, 0xf8, 0x75, 0x2f, 0xf6,
+	0x53, 0x1b, 0x01, 0x51, 0x49, 0x29, 0x2f, 0xe1, 0xc4, 0x89, 0xd8, 0xca, 0x8f, 0x18, 0xdf, 0xc0,
+	0xd2, 0xa6, 0x9d, 0xc0, 0xf1, 0x36, 0x92, 0xc7, 0xf3, 0x33, 0xd4, 0xb5, 0x8f, 0xeb, 0xe5, 0x27,
+	0x7c, 0x04, 0x8d, 0xdb, 0xf5, 0x87, 0x0f, 0x2c, 0x12, 0x91, 0xb4, 0xa9, 0xfc, 0xc2, 0x6f, 0xa0,
+	0x96, 0x3c, 0xac, 0x98, 0x5c, 0xbb, 0x27, 0xd6, 0x16, 0x1e, 0xe7, 0xde, 0xc3, 0x8a, 0x51, 0x41,
+	0x2a, 0xdf, 0x41, 0x8d, 0x7f, 0xe1, 0x7d, 0x68, 0x4e, 0xad, 0x6b, 0xcb, 0xfe, 0x93, 0x85, 0x2a,
+	0x18, 0xa0, 0xe1, 0x7a, 0xba, 0x3d, 0xf5, 0x50, 0x55, 0xfe, 0x26, 0x94, 0xa2, 0x1d, 0xe5, 0x1f,
+	0x55, 0x68, 0x4e, 0x58, 0x1c, 0xfb, 0xf7, 0xfc, 0xf2, 0xd4, 0xe7, 0x5c, 0x4c, 0x2c, 0xba, 0x7f,
+	0x01, 0x8f, 0xf2, 0x46, 0x85, 0xa6, 0x14, 0xfe, 0xbe, 0x90, 0xff, 0xfe, 0x05, 0xce, 0xd7, 0x28,
+	0x2d, 0x83, 0x51, 0xc9, 0x0a, 0x81, 0xbf, 0x83, 0x56, 0xc4, 0xe2, 0x55, 0xb8, 0x8c, 0xd3, 0xab,
+	0xb8, 0x7f, 0xd1, 0x11, 0xf6, 0x54, 0x82, 0x46, 0x85, 0x6e, 0x0c, 0x2e, 0x01, 0x5a, 0xf3, 0x70,
+	0x99, 0xf0, 0xa3, 0xa7, 0xfc, 0x7b, 0x07, 0x5a, 0x99, 0x11, 0x36, 0x01, 0x07, 0xb9, 0x5e, 0x51,
+	0xd0, 0x3b, 0x16, 0x7a, 0xe6, 0x13, 0xda, 0xa8, 0xd0, 0x2d, 0x4e, 0xf8, 0x8f, 0xd0, 0x63, 0xd9,
+	0x8d, 0x91, 0x3a, 0x35, 0xa1, 0x73, 0x28, 0x74, 0x48, 0x91, 0x33, 0x2a, 0xb4, 0x6c, 0x8e, 0x35,
+	0x40, 0x1f, 0x36, 0x37, 0x4c, 0x4a, 0xd4, 0x85, 0xc4, 0x0b, 0x21, 0x71, 0x55, 0x22, 0x8d, 0x0a,
+	0x7d, 0xe2, 0x80, 0xff, 0x00, 0xdd, 0x48, 0xde, 0x49, 0x29, 0xd1, 0x10, 0x12, 0x7d, 0x59, 0x9d,
+	0x3c, 0x65, 0x54, 0x68, 0xc9, 0xb8, 0x50, 0x29, 0x0f, 0xf0, 0xd3, 0xec, 0xf9, 0xad, 0x35, 0xfc,
+	0x78, 0x12, 0x44, 0x51, 0x18, 0xc5, 0x62, 0x3f, 0x5b, 0x34, 0x87, 0x48, 0xde, 0x4d, 0xfc, 0xe5,
+	0xdd, 0xed, 0xc3, 0x60, 0x67, 0xc3, 0x4b, 0x44, 0xb1, 0xa1, 0x29, 0x4f, 0x26, 0xc6, 0x50, 0xcb,
+	0xf5, 0x63, 0xf1, 0x1b, 0xbf, 0x87, 0xfe, 0xc4, 0xe7, 0xac, 0xee, 0x27, 0xbe, 0x1e, 0x44, 0x6c,
+	0x9e, 0x84, 0xd1, 0x83, 0xec, 0xc8, 0xdb, 0x28, 0xe5, 0xb7, 0xd0, 0x2b, 0x15, 0x17, 0x7f, 0x0b,
+	0x8d, 0xb4, 0x2f, 0xcb, 0xf3, 0x96, 0x5e, 0xb7, 0xec, 0x42, 0x48, 0x4e, 0xf9, 0x6f, 0x15, 0x50,
+	0xb9, 0xa6, 0xbf, 0xcc, 0x95, 0xb7, 0x59, 0x4f, 0xfc, 0xba, 0x61, 0x51, 0x1c, 0x84, 0x4b, 0x19,
+	0x5f, 0x11, 0xe4, 0xb9, 0x8c, 0xc3, 0x7b, 0x35, 0x9a, 0x7f, 0x0c, 0xbe, 0xb0, 0xc7, 0x5c, 0xd2,
+	0x97, 0x63, 0x1b, 0x85, 0xc7, 0xf0, 0x2b, 0x89, 0xdd, 0xb9, 0xe2, 0xd9, 0xd8, 0x56, 0x8b, 0x9a,
+	0xf0, 0xff, 0xff, 0x86, 0xfc, 0xd1, 0x9b, 0xae, 0xee, 0x23, 0xff, 0x8e, 0x99, 0xba, 0x38, 0x49,
+	0x7b, 0xf4, 0x11, 0x50, 0xfe, 0x5e, 0x85, 0x6e, 0xf1, 0x3c, 0xf0, 0xe4, 0xd3, 0xd7, 0x6a, 0x7b,
+	0xf2, 0x29, 0xc7, 0x93, 0x4f, 0xd7, 0x2c, 0x25, 0x5f, 0x00, 0xbf, 0x3e, 0x79, 0xe5, 0x2d, 0xa0,
+	0x11, 0x4b, 0x44, 0x33, 0xbe, 0xcf, 0xba, 0x2f, 0x86, 0x1a, 0x7f, 0xee, 0x44, 0x3c, 0x7b, 0x54,
+	0xfc, 0x56, 0xde, 0x42, 0x37, 0x67, 0xc7, 0x5f, 0x92, 0x43, 0xa8, 0x7f, 0xf1, 0x17, 0xeb, 0xcc,
+	0x2c, 0xfd, 0x78, 0x67, 0x43, 0xcd, 0xe5, 0x8d, 0x15, 0x41, 0x5b, 0x76, 0xab, 0x99, 0xeb, 0x11,
+	0x07, 0x55, 0x70, 0x17, 0xc0, 0xb4, 0x4c, 0xcf, 0x54, 0xc7, 0xe6, 0x5f, 0x08, 0xaa, 0xf2, 0x7e,
+	0x46, 0xfe, 0x4c, 0xb4, 0xa9, 0x47, 0xd0, 0x0e, 0x6e, 0x43, 0xeb, 0xca, 0xb4, 0x52, 0x6a, 0x97,
+	0x77, 0x34, 0x4a, 0x6e, 0x08, 0xf5, 0x50, 0xed, 0xdd, 0xbf, 0x1a, 0xd0, 0x94, 0xfd, 0x08, 0xf7,
+	0xa1, 0xb7, 0x11, 0x9d, 0x5e, 0x4a, 0xdd, 0x21, 0xbc, 0x72, 0xd5, 0x1b, 0xd3, 0x1a, 0xcd, 0x5c,
+	0x7b, 0x4a, 0x35, 0x32, 0xd3, 0xc6, 0x53, 0xd7, 0x23, 0x74, 0xa6, 0xd9, 0xd6, 0x95, 0x39, 0x42,
+	0x55, 0xdc, 0x81, 0x3d, 0xd7, 0x53, 0xa9, 0x37, 0x33, 0xa6, 0x97, 0x68, 0x87, 0x87, 0x96, 0x7e,
+	0xaa, 0x23, 0x62, 0x79, 0x2e, 0xda, 0xc5, 0x87, 0x80, 0x34, 0x83, 0x68, 0xd7, 0x33, 0xdd, 0x74,
+	0xaf, 0x67, 0xae, 0xa3, 0x6a, 0x04, 0xd5, 0xf0, 0x29, 0x1c, 0x8d, 0x88, 0x45, 0xa8, 0xea, 0x91,
+	0x99, 0xa7, 0xd2, 0x11, 0xf1, 0x32, 0xc9, 0x3a, 0x3e, 0x86, 0x3e, 0x4f, 0x66, 0x83, 0xa7, 0x4b,
+	0xa2, 0x06, 0x7e, 0x09, 0xc7, 0xae, 0x31, 0xf5, 0x74, 0x1e, 0x63, 0x89, 0x6c, 0xe2, 0x01, 0x1c,
+	0x5e, 0xaa, 0xda, 0xf5, 0xd4, 0xc9, 0xa8, 0x89, 0x2a, 0x98, 0x16, 0x3e, 0x80, 0x4e, 0x1a, 0xc1,
+	0xd4, 0x19, 0x51, 0x55, 0x27, 0x68, 0xaf, 0xa0, 0x54, 0xcc, 0x0c, 0x01, 0xc6, 0xd0, 0x95, 0x96,
+	0x99, 0xc6, 0x3e, 0xee, 0xc1, 0xbe, 0x66, 0x3b, 0x3f, 0x65, 0x40, 0x1b, 0xbf, 0x80, 0x83, 0xcc,
+	0xc8, 0xa1, 0xe6, 0x44, 0xa5, 0x26, 0x71, 0x51, 0x87, 0x47, 0x91, 0xe6, 0x5f, 0x8a, 0xaf, 0x8b,
+	0xbf, 0x87, 0xb3, 0xa9, 0xa3, 0xe7, 0xf3, 0x55, 0x3d, 0x75, 0x6c, 0x8f, 0x66, 0xaa, 0xa5, 0x97,
+	0xcb, 0xda, 0xe3, 0x01, 0x4a, 0x6b, 0x5d, 0xf5, 0xd4, 0x99, 0x6e, 0x52, 0xa2, 0x79, 0xb6, 0x58,
+	0x04, 0xe1, 0x57, 0x30, 0x28, 0x49, 0xd9, 0xd6, 0xd5, 0xec, 0xca, 0x1c, 0x13, 0x17, 0x1d, 0x88,
+	0x8d, 0x94, 0x91, 0xb9, 0x9e, 0x6a, 0xe9, 0x97, 0x3f, 0x21, 0x9c, 0x07, 0x27, 0x26, 0xa5, 0x36,
+	0x75, 0x51, 0x1f, 0x1f, 0x01, 0xd6, 0xc9, 0x98, 0x08, 0x9d, 0xcb, 0x31, 0x11, 0x7b, 0xe3, 0xa2,
+	0x43, 0xac, 0xc0, 0xeb, 0x0d, 0x9e, 0xcf, 0x42, 0xc4, 0xa2, 0x9b, 0xd4, 0x45, 0x2f, 0x78, 0x0c,
+	0xd2, 0xc6, 0x25, 0xa3, 0x09, 0xb1, 0x3c, 0xbe, 0x98, 0x47, 0x04, 0x7b, 0xc4, 0xb7, 0xd0, 0xf5,
+	0x6c, 0x87, 0x1f, 0x0a, 0x91, 0x9f, 0x3c, 0x0d, 0xc7, 0x7c, 0xdf, 0xa5, 0x5b, 0x5a, 0xc9, 0x8d,
+	0x17, 0x1a, 0xf0, 0x9c, 0x55, 0xaa, 0x19, 0xe6, 0x0d, 0x99, 0xf1, 0xba, 0xe4, 0x73, 0x3e, 0xe1,
+	0x8e, 0x94, 0xb8, 0x9e, 0x4d, 0x49, 0x79, 0xc3, 0x4e, 0x1f, 0x8b, 0x5e, 0x62, 0x5e, 0xf2, 0x5d,
+	0xca, 0xbc, 0x9c, 0x91, 0x66, 0x5b, 0x1e, 0xb5, 0xc7, 0xe8, 0x15, 0xfe, 0x06, 0x4e, 0x28, 0xd1,
+	0xec, 0x1b, 0x42, 0x5d, 0x52, 0x3e, 0xda, 0xe8, 0x1b, 0xbe, 0xd9, 0xfc, 0xfc, 0x8b, 0xd8, 0xa6,
+	0x2e, 0x7a, 0xfd, 0xce, 0x81, 0x86, 0x9c, 0x7a, 0xf8, 0xd9, 0xd8, 0x5c, 0x3d, 0xc1, 0x56, 0xf8,
+	0x65, 0xa3, 0x53, 0xcb, 0x32, 0x2d, 0x7e, 0x1f, 0xda, 0xd0, 0xd2, 0xec, 0x89, 0xc3, 0x53, 0x44,
+	0x3b, 0xfc, 0xb2, 0x5d, 0xa9, 0xe6, 0x98, 0xe8, 0x68, 0x97, 0x9b, 0xb9, 0xd7, 0xa6, 0xe3, 0x10,
+	0x1d, 0xd5, 0x2e, 0xfe, 0x53, 0x83, 0x96, 0xb6, 0x08, 0xbc, 0xd0, 0x58, 0xdf, 0xe2, 0x1f, 0x01,
+	0x1e, 0xdf, 0x25, 0x7c, 0xf4, 0xe4, 0x99, 0x16, 0x9d, 0xe3, 0x34, 0xed, 0x5d, 0x72, 0x00, 0x51,
+	0x2a, 0xef, 0xab, 0xd8, 0x81, 0xe3, 0x67, 0xa6, 0x5e, 0xfc, 0xa6, 0x24, 0xb2, 0x6d, 0x26, 0xde,
+	0xa2, 0xf8, 0x1e, 0x9a, 0xf2, 0xe9, 0xc1, 0xfd, 0xe2, 0x2b, 0xff, 0x9c, 0xc7, 0x05, 0xb4, 0xb2,
+	0x27, 0x07, 0x1f, 0x96, 0x5e, 0xf5, 0xe7, 0x7c, 0xce, 0xa1, 0x91, 0xf6, 0x69, 0x8c, 0x0b, 0x8f,
+	0xf8, 0x73, 0xf6, 0xbf, 0x87, 0xbd, 0x4d, 0x7f, 0xc4, 0xe9, 0xe8, 0x50, 0xee, 0xab, 0xa7, 0xfd,
+	0x32, 0xcc, 0x87, 0xc4, 0x0a, 0x26, 0xd0, 0x29, 0x0c, 0xea, 0xf8, 0x44, 0xae, 0xf8, 0x74, 0xa8,
+	0x3f, 0x3d, 0xde, 0x46, 0xa5, 0x32, 0x97, 0xd0, 0xce, 0x8f, 0xe8, 0x78, 0x20, 0x47, 0xd9, 0x27,
+	0xc3, 0xfc, 0xe9, 0xd1, 0x16, 0x26, 0xd5, 0xf8, 0x01, 0x9a, 0x72, 0x2e, 0x97, 0xb5, 0x2d, 0xce,
+	0xf7, 0xa7, 0x07, 0x45, 0x30, 0x75, 0xfa, 0x35, 0xd4, 0xc5, 0x10, 0x8e, 0x53, 0x36, 0x3f, 0xc8,
+	0x9f, 0xf6, 0xf2, 0x90, 0x30, 0xbf, 0x6d, 0x88, 0xff, 0x8d, 0x3f, 0xfc, 0x6f, 0x00, 0x45, 0x0a,
+	0xb4, 0x51, 0x4b, 0x0e, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1431,6 +1579,8 @@ type CliToHubClient interface {
 	GetConfig(ctx context.Context, in *GetConfigRequest, opts ...grpc.CallOption) (*GetConfigReply, error)
 	RestartAgents(ctx context.Context, in *RestartAgentsRequest, opts ...grpc.CallOption) (*RestartAgentsReply, error)
 	StopServices(ctx context.Context, in *StopServicesRequest, opts ...grpc.CallOption) (*StopServicesReply, error)
+	Confirm(ctx context.Context, in *ConfirmRequest, opts ...grpc.CallOption) (*ConfirmReply, error)
+	Abort(ctx context.Context, in *AbortRequest, opts ...grpc.CallOption) (*AbortReply, error)
 }
 
 type cliToHubClient struct {
@@ -1628,6 +1778,24 @@ func (c *cliToHubClient) StopServices(ctx context.Context, in *StopServicesReque
 	return out, nil
 }
 
+func (c *cliToHubClient) Confirm(ctx context.Context, in *ConfirmRequest, opts ...grpc.CallOption) (*ConfirmReply, error) {
+	out := new(ConfirmReply)
+	err := c.cc.Invoke(ctx, "/idl.CliToHub/Confirm", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cliToHubClient) Abort(ctx context.Context, in *AbortRequest, opts ...grpc.CallOption) (*AbortReply, error) {
+	out := new(AbortReply)
+	err := c.cc.Invoke(ctx, "/idl.CliToHub/Abort", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CliToHubServer is the server API for CliToHub service.
 type CliToHubServer interface {
 	Initialize(*InitializeRequest, CliToHub_InitializeServer) error
@@ -1638,6 +1806,8 @@ type CliToHubServer interface {
 	GetConfig(context.Context, *GetConfigRequest) (*GetConfigReply, error)
 	RestartAgents(context.Context, *RestartAgentsRequest) (*RestartAgentsReply, error)
 	StopServices(context.Context, *StopServicesRequest) (*StopServicesReply, error)
+	Confirm(context.Context, *ConfirmRequest) (*ConfirmReply, error)
+	Abort(context.Context, *AbortRequest) (*AbortReply, error)
 }
 
 // UnimplementedCliToHubServer can be embedded to have forward compatible implementations.
@@ -1668,6 +1838,12 @@ func (*UnimplementedCliToHubServer) RestartAgents(ctx context.Context, req *Rest
 func (*UnimplementedCliToHubServer) StopServices(ctx context.Context, req *StopServicesRequest) (*StopServicesReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method StopServices not implemented")
 }
+func (*UnimplementedCliToHubServer) Confirm(ctx context.Context, req *ConfirmRequest) (*ConfirmReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Confirm not implemented")
+}
+func (*UnimplementedCliToHubServer) Abort(ctx context.Context, req *AbortRequest) (*AbortReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Abort not implemented")
+}
 
 func RegisterCliToHubServer(s *grpc.Server, srv CliToHubServer) {
 	s.RegisterService(&_CliToHub_serviceDesc, srv)
@@ -1832,6 +2008,42 @@ func _CliToHub_StopServices_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CliToHub_Confirm_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfirmRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CliToHubServer).Confirm(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/idl.CliToHub/Confirm",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CliToHubServer).Confirm(ctx, req.(*ConfirmRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CliToHub_Abort_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AbortRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CliToHubServer).Abort(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/idl.CliToHub/Abort",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CliToHubServer).Abort(ctx, req.(*AbortRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _CliToHub_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "idl.CliToHub",
 	HandlerType: (*CliToHubServer)(nil),
@@ -1848,6 +2060,14 @@ var _CliToHub_serviceDesc = grpc.ServiceDesc{
 			MethodName: "StopServices",
 			Handler:    _CliToHub_StopServices_Handler,
 		},
+		{
+			MethodName: "Confirm",
+			Handler:    _CliToHub_Confirm_Handler,
+		},
+		{
+			MethodName: "Abort",
+			Handler:    _CliToHub_Abort_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -88,6 +88,7 @@ type UpgradePrimariesRequest struct {
 	UseLinkMode                bool           `protobuf:"varint,6,opt,name=UseLinkMode,proto3" json:"UseLinkMode,omitempty"`
 	MasterBackupDir            string         `protobuf:"bytes,7,opt,name=MasterBackupDir,proto3" json:"MasterBackupDir,omitempty"`
 	TablespacesMappingFilePath string         `protobuf:"bytes,8,opt,name=TablespacesMappingFilePath,proto3" json:"TablespacesMappingFilePath,omitempty"`
+	IdempotencyToken           string         `protobuf:"bytes,9,opt,name=IdempotencyToken,proto3" json:"IdempotencyToken,omitempty"`
 	XXX_NoUnkeyedLiteral       struct{}       `json:"-"`
 	XXX_unrecognized           []byte         `json:"-"`
 	XXX_sizecache              int32          `json:"-"`
@@ -174,6 +175,13 @@ func (m *UpgradePrimariesRequest) GetTablespacesMappingFilePath() string {
 	return ""
 }
 
+func (m *UpgradePrimariesRequest) GetIdempotencyToken() string {
+	if m != nil {
+		return m.IdempotencyToken
+	}
+	return ""
+}
+
 type DataDirPair struct {
 	SourceDataDir        string                    `protobuf:"bytes,1,opt,name=SourceDataDir,proto3" json:"SourceDataDir,omitempty"`
 	TargetDataDir        string                    `protobuf:"bytes,2,opt,name=TargetDataDir,proto3" json:"TargetDataDir,omitempty"`
@@ -262,9 +270,10 @@ func (m *DataDirPair) GetTablespaces() map[int32]*TablespaceInfo {
 }
 
 type UpgradePrimariesReply struct {
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	LogFiles             map[int32]string `protobuf:"bytes,1,rep,name=LogFiles,proto3" json:"LogFiles,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
 }
 
 func (m *UpgradePrimariesReply) Reset()         { *m = UpgradePrimariesReply{} }
@@ -292,8 +301,17 @@ func (m *UpgradePrimariesReply) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_UpgradePrimariesReply proto.InternalMessageInfo
 
+func (m *UpgradePrimariesReply) GetLogFiles() map[int32]string {
+	if m != nil {
+		return m.LogFiles
+	}
+	return nil
+}
+
 type DeleteDataDirectoriesRequest struct {
 	Datadirs             []string `protobuf:"bytes,1,rep,name=datadirs,proto3" json:"datadirs,omitempty"`
+	Version              string   `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	IdempotencyToken     string   `protobuf:"bytes,3,opt,name=IdempotencyToken,proto3" json:"IdempotencyToken,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -331,6 +349,20 @@ func (m *DeleteDataDirectoriesRequest) GetDatadirs() []string {
 	return nil
 }
 
+func (m *DeleteDataDirectoriesRequest) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *DeleteDataDirectoriesRequest) GetIdempotencyToken() string {
+	if m != nil {
+		return m.IdempotencyToken
+	}
+	return ""
+}
+
 type DeleteDataDirectoriesReply struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -362,6 +394,186 @@ func (m *DeleteDataDirectoriesReply) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_DeleteDataDirectoriesReply proto.InternalMessageInfo
 
+type ValidateDataDirectoryRequest struct {
+	Datadirs             []string `protobuf:"bytes,1,rep,name=datadirs,proto3" json:"datadirs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ValidateDataDirectoryRequest) Reset()         { *m = ValidateDataDirectoryRequest{} }
+func (m *ValidateDataDirectoryRequest) String() string { return proto.CompactTextString(m) }
+func (*ValidateDataDirectoryRequest) ProtoMessage()    {}
+func (*ValidateDataDirectoryRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{6}
+}
+
+func (m *ValidateDataDirectoryRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ValidateDataDirectoryRequest.Unmarshal(m, b)
+}
+func (m *ValidateDataDirectoryRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ValidateDataDirectoryRequest.Marshal(b, m, deterministic)
+}
+func (m *ValidateDataDirectoryRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ValidateDataDirectoryRequest.Merge(m, src)
+}
+func (m *ValidateDataDirectoryRequest) XXX_Size() int {
+	return xxx_messageInfo_ValidateDataDirectoryRequest.Size(m)
+}
+func (m *ValidateDataDirectoryRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ValidateDataDirectoryRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ValidateDataDirectoryRequest proto.InternalMessageInfo
+
+func (m *ValidateDataDirectoryRequest) GetDatadirs() []string {
+	if m != nil {
+		return m.Datadirs
+	}
+	return nil
+}
+
+type ValidateDataDirectoryReply struct {
+	Results              []*DataDirectoryValidity `protobuf:"bytes,1,rep,name=Results,proto3" json:"Results,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                 `json:"-"`
+	XXX_unrecognized     []byte                   `json:"-"`
+	XXX_sizecache        int32                    `json:"-"`
+}
+
+func (m *ValidateDataDirectoryReply) Reset()         { *m = ValidateDataDirectoryReply{} }
+func (m *ValidateDataDirectoryReply) String() string { return proto.CompactTextString(m) }
+func (*ValidateDataDirectoryReply) ProtoMessage()    {}
+func (*ValidateDataDirectoryReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{7}
+}
+
+func (m *ValidateDataDirectoryReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ValidateDataDirectoryReply.Unmarshal(m, b)
+}
+func (m *ValidateDataDirectoryReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ValidateDataDirectoryReply.Marshal(b, m, deterministic)
+}
+func (m *ValidateDataDirectoryReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ValidateDataDirectoryReply.Merge(m, src)
+}
+func (m *ValidateDataDirectoryReply) XXX_Size() int {
+	return xxx_messageInfo_ValidateDataDirectoryReply.Size(m)
+}
+func (m *ValidateDataDirectoryReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_ValidateDataDirectoryReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ValidateDataDirectoryReply proto.InternalMessageInfo
+
+func (m *ValidateDataDirectoryReply) GetResults() []*DataDirectoryValidity {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+type DataDirectoryValidity struct {
+	DataDir              string   `protobuf:"bytes,1,opt,name=DataDir,proto3" json:"DataDir,omitempty"`
+	Valid                bool     `protobuf:"varint,2,opt,name=Valid,proto3" json:"Valid,omitempty"`
+	Reason               string   `protobuf:"bytes,3,opt,name=Reason,proto3" json:"Reason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DataDirectoryValidity) Reset()         { *m = DataDirectoryValidity{} }
+func (m *DataDirectoryValidity) String() string { return proto.CompactTextString(m) }
+func (*DataDirectoryValidity) ProtoMessage()    {}
+func (*DataDirectoryValidity) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{8}
+}
+
+func (m *DataDirectoryValidity) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DataDirectoryValidity.Unmarshal(m, b)
+}
+func (m *DataDirectoryValidity) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DataDirectoryValidity.Marshal(b, m, deterministic)
+}
+func (m *DataDirectoryValidity) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DataDirectoryValidity.Merge(m, src)
+}
+func (m *DataDirectoryValidity) XXX_Size() int {
+	return xxx_messageInfo_DataDirectoryValidity.Size(m)
+}
+func (m *DataDirectoryValidity) XXX_DiscardUnknown() {
+	xxx_messageInfo_DataDirectoryValidity.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DataDirectoryValidity proto.InternalMessageInfo
+
+func (m *DataDirectoryValidity) GetDataDir() string {
+	if m != nil {
+		return m.DataDir
+	}
+	return ""
+}
+
+func (m *DataDirectoryValidity) GetValid() bool {
+	if m != nil {
+		return m.Valid
+	}
+	return false
+}
+
+func (m *DataDirectoryValidity) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+type ProgressEvent struct {
+	Message              string   `protobuf:"bytes,1,opt,name=Message,proto3" json:"Message,omitempty"`
+	Fraction             float64  `protobuf:"fixed64,2,opt,name=Fraction,proto3" json:"Fraction,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ProgressEvent) Reset()         { *m = ProgressEvent{} }
+func (m *ProgressEvent) String() string { return proto.CompactTextString(m) }
+func (*ProgressEvent) ProtoMessage()    {}
+func (*ProgressEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{9}
+}
+
+func (m *ProgressEvent) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProgressEvent.Unmarshal(m, b)
+}
+func (m *ProgressEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProgressEvent.Marshal(b, m, deterministic)
+}
+func (m *ProgressEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProgressEvent.Merge(m, src)
+}
+func (m *ProgressEvent) XXX_Size() int {
+	return xxx_messageInfo_ProgressEvent.Size(m)
+}
+func (m *ProgressEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProgressEvent.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProgressEvent proto.InternalMessageInfo
+
+func (m *ProgressEvent) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *ProgressEvent) GetFraction() float64 {
+	if m != nil {
+		return m.Fraction
+	}
+	return 0
+}
+
 type DeleteStateDirectoryRequest struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -372,7 +584,7 @@ func (m *DeleteStateDirectoryRequest) Reset()         { *m = DeleteStateDirector
 func (m *DeleteStateDirectoryRequest) String() string { return proto.CompactTextString(m) }
 func (*DeleteStateDirectoryRequest) ProtoMessage()    {}
 func (*DeleteStateDirectoryRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{6}
+	return fileDescriptor_9e73bb06acc917d8, []int{10}
 }
 
 func (m *DeleteStateDirectoryRequest) XXX_Unmarshal(b []byte) error {
@@ -403,7 +615,7 @@ func (m *DeleteStateDirectoryReply) Reset()         { *m = DeleteStateDirectoryR
 func (m *DeleteStateDirectoryReply) String() string { return proto.CompactTextString(m) }
 func (*DeleteStateDirectoryReply) ProtoMessage()    {}
 func (*DeleteStateDirectoryReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{7}
+	return fileDescriptor_9e73bb06acc917d8, []int{11}
 }
 
 func (m *DeleteStateDirectoryReply) XXX_Unmarshal(b []byte) error {
@@ -426,6 +638,7 @@ var xxx_messageInfo_DeleteStateDirectoryReply proto.InternalMessageInfo
 
 type DeleteTablespaceRequest struct {
 	Dirs                 []string `protobuf:"bytes,1,rep,name=dirs,proto3" json:"dirs,omitempty"`
+	IdempotencyToken     string   `protobuf:"bytes,2,opt,name=IdempotencyToken,proto3" json:"IdempotencyToken,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -435,7 +648,7 @@ func (m *DeleteTablespaceRequest) Reset()         { *m = DeleteTablespaceRequest
 func (m *DeleteTablespaceRequest) String() string { return proto.CompactTextString(m) }
 func (*DeleteTablespaceRequest) ProtoMessage()    {}
 func (*DeleteTablespaceRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{8}
+	return fileDescriptor_9e73bb06acc917d8, []int{12}
 }
 
 func (m *DeleteTablespaceRequest) XXX_Unmarshal(b []byte) error {
@@ -463,6 +676,13 @@ func (m *DeleteTablespaceRequest) GetDirs() []string {
 	return nil
 }
 
+func (m *DeleteTablespaceRequest) GetIdempotencyToken() string {
+	if m != nil {
+		return m.IdempotencyToken
+	}
+	return ""
+}
+
 type DeleteTablespaceReply struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -473,7 +693,7 @@ func (m *DeleteTablespaceReply) Reset()         { *m = DeleteTablespaceReply{} }
 func (m *DeleteTablespaceReply) String() string { return proto.CompactTextString(m) }
 func (*DeleteTablespaceReply) ProtoMessage()    {}
 func (*DeleteTablespaceReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{9}
+	return fileDescriptor_9e73bb06acc917d8, []int{13}
 }
 
 func (m *DeleteTablespaceReply) XXX_Unmarshal(b []byte) error {
@@ -496,6 +716,7 @@ var xxx_messageInfo_DeleteTablespaceReply proto.InternalMessageInfo
 
 type ArchiveLogDirectoryRequest struct {
 	NewDir               string   `protobuf:"bytes,1,opt,name=NewDir,proto3" json:"NewDir,omitempty"`
+	IdempotencyToken     string   `protobuf:"bytes,2,opt,name=IdempotencyToken,proto3" json:"IdempotencyToken,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -505,7 +726,7 @@ func (m *ArchiveLogDirectoryRequest) Reset()         { *m = ArchiveLogDirectoryR
 func (m *ArchiveLogDirectoryRequest) String() string { return proto.CompactTextString(m) }
 func (*ArchiveLogDirectoryRequest) ProtoMessage()    {}
 func (*ArchiveLogDirectoryRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{10}
+	return fileDescriptor_9e73bb06acc917d8, []int{14}
 }
 
 func (m *ArchiveLogDirectoryRequest) XXX_Unmarshal(b []byte) error {
@@ -533,6 +754,13 @@ func (m *ArchiveLogDirectoryRequest) GetNewDir() string {
 	return ""
 }
 
+func (m *ArchiveLogDirectoryRequest) GetIdempotencyToken() string {
+	if m != nil {
+		return m.IdempotencyToken
+	}
+	return ""
+}
+
 type ArchiveLogDirectoryReply struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -543,7 +771,7 @@ func (m *ArchiveLogDirectoryReply) Reset()         { *m = ArchiveLogDirectoryRep
 func (m *ArchiveLogDirectoryReply) String() string { return proto.CompactTextString(m) }
 func (*ArchiveLogDirectoryReply) ProtoMessage()    {}
 func (*ArchiveLogDirectoryReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{11}
+	return fileDescriptor_9e73bb06acc917d8, []int{15}
 }
 
 func (m *ArchiveLogDirectoryReply) XXX_Unmarshal(b []byte) error {
@@ -577,7 +805,7 @@ func (m *RenameDirectories) Reset()         { *m = RenameDirectories{} }
 func (m *RenameDirectories) String() string { return proto.CompactTextString(m) }
 func (*RenameDirectories) ProtoMessage()    {}
 func (*RenameDirectories) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{12}
+	return fileDescriptor_9e73bb06acc917d8, []int{16}
 }
 
 func (m *RenameDirectories) XXX_Unmarshal(b []byte) error {
@@ -621,6 +849,8 @@ func (m *RenameDirectories) GetRenameTarget() bool {
 
 type RenameDirectoriesRequest struct {
 	Dirs                 []*RenameDirectories `protobuf:"bytes,1,rep,name=Dirs,proto3" json:"Dirs,omitempty"`
+	CopyRateLimit        int64                `protobuf:"varint,2,opt,name=CopyRateLimit,proto3" json:"CopyRateLimit,omitempty"`
+	IdempotencyToken     string               `protobuf:"bytes,3,opt,name=IdempotencyToken,proto3" json:"IdempotencyToken,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
 	XXX_unrecognized     []byte               `json:"-"`
 	XXX_sizecache        int32                `json:"-"`
@@ -630,7 +860,7 @@ func (m *RenameDirectoriesRequest) Reset()         { *m = RenameDirectoriesReque
 func (m *RenameDirectoriesRequest) String() string { return proto.CompactTextString(m) }
 func (*RenameDirectoriesRequest) ProtoMessage()    {}
 func (*RenameDirectoriesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{13}
+	return fileDescriptor_9e73bb06acc917d8, []int{17}
 }
 
 func (m *RenameDirectoriesRequest) XXX_Unmarshal(b []byte) error {
@@ -658,6 +888,20 @@ func (m *RenameDirectoriesRequest) GetDirs() []*RenameDirectories {
 	return nil
 }
 
+func (m *RenameDirectoriesRequest) GetCopyRateLimit() int64 {
+	if m != nil {
+		return m.CopyRateLimit
+	}
+	return 0
+}
+
+func (m *RenameDirectoriesRequest) GetIdempotencyToken() string {
+	if m != nil {
+		return m.IdempotencyToken
+	}
+	return ""
+}
+
 type RenameDirectoriesReply struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -668,7 +912,7 @@ func (m *RenameDirectoriesReply) Reset()         { *m = RenameDirectoriesReply{}
 func (m *RenameDirectoriesReply) String() string { return proto.CompactTextString(m) }
 func (*RenameDirectoriesReply) ProtoMessage()    {}
 func (*RenameDirectoriesReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{14}
+	return fileDescriptor_9e73bb06acc917d8, []int{18}
 }
 
 func (m *RenameDirectoriesReply) XXX_Unmarshal(b []byte) error {
@@ -689,127 +933,315 @@ func (m *RenameDirectoriesReply) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_RenameDirectoriesReply proto.InternalMessageInfo
 
-type StopAgentRequest struct {
+type RevertDataDirectory struct {
+	Source               string   `protobuf:"bytes,1,opt,name=Source,proto3" json:"Source,omitempty"`
+	Target               string   `protobuf:"bytes,2,opt,name=Target,proto3" json:"Target,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *StopAgentRequest) Reset()         { *m = StopAgentRequest{} }
-func (m *StopAgentRequest) String() string { return proto.CompactTextString(m) }
-func (*StopAgentRequest) ProtoMessage()    {}
-func (*StopAgentRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{15}
+func (m *RevertDataDirectory) Reset()         { *m = RevertDataDirectory{} }
+func (m *RevertDataDirectory) String() string { return proto.CompactTextString(m) }
+func (*RevertDataDirectory) ProtoMessage()    {}
+func (*RevertDataDirectory) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{19}
 }
 
-func (m *StopAgentRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_StopAgentRequest.Unmarshal(m, b)
+func (m *RevertDataDirectory) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RevertDataDirectory.Unmarshal(m, b)
 }
-func (m *StopAgentRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_StopAgentRequest.Marshal(b, m, deterministic)
+func (m *RevertDataDirectory) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RevertDataDirectory.Marshal(b, m, deterministic)
 }
-func (m *StopAgentRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_StopAgentRequest.Merge(m, src)
+func (m *RevertDataDirectory) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RevertDataDirectory.Merge(m, src)
 }
-func (m *StopAgentRequest) XXX_Size() int {
-	return xxx_messageInfo_StopAgentRequest.Size(m)
+func (m *RevertDataDirectory) XXX_Size() int {
+	return xxx_messageInfo_RevertDataDirectory.Size(m)
 }
-func (m *StopAgentRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_StopAgentRequest.DiscardUnknown(m)
+func (m *RevertDataDirectory) XXX_DiscardUnknown() {
+	xxx_messageInfo_RevertDataDirectory.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_StopAgentRequest proto.InternalMessageInfo
+var xxx_messageInfo_RevertDataDirectory proto.InternalMessageInfo
 
-type StopAgentReply struct {
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+func (m *RevertDataDirectory) GetSource() string {
+	if m != nil {
+		return m.Source
+	}
+	return ""
 }
 
-func (m *StopAgentReply) Reset()         { *m = StopAgentReply{} }
-func (m *StopAgentReply) String() string { return proto.CompactTextString(m) }
-func (*StopAgentReply) ProtoMessage()    {}
-func (*StopAgentReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{16}
+func (m *RevertDataDirectory) GetTarget() string {
+	if m != nil {
+		return m.Target
+	}
+	return ""
 }
 
-func (m *StopAgentReply) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_StopAgentReply.Unmarshal(m, b)
+type RevertDataDirectoriesRequest struct {
+	Dirs                 []*RevertDataDirectory `protobuf:"bytes,1,rep,name=Dirs,proto3" json:"Dirs,omitempty"`
+	IdempotencyToken     string                 `protobuf:"bytes,2,opt,name=IdempotencyToken,proto3" json:"IdempotencyToken,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
+	XXX_unrecognized     []byte                 `json:"-"`
+	XXX_sizecache        int32                  `json:"-"`
 }
-func (m *StopAgentReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_StopAgentReply.Marshal(b, m, deterministic)
+
+func (m *RevertDataDirectoriesRequest) Reset()         { *m = RevertDataDirectoriesRequest{} }
+func (m *RevertDataDirectoriesRequest) String() string { return proto.CompactTextString(m) }
+func (*RevertDataDirectoriesRequest) ProtoMessage()    {}
+func (*RevertDataDirectoriesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{20}
 }
-func (m *StopAgentReply) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_StopAgentReply.Merge(m, src)
+
+func (m *RevertDataDirectoriesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RevertDataDirectoriesRequest.Unmarshal(m, b)
 }
-func (m *StopAgentReply) XXX_Size() int {
-	return xxx_messageInfo_StopAgentReply.Size(m)
+func (m *RevertDataDirectoriesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RevertDataDirectoriesRequest.Marshal(b, m, deterministic)
 }
-func (m *StopAgentReply) XXX_DiscardUnknown() {
-	xxx_messageInfo_StopAgentReply.DiscardUnknown(m)
+func (m *RevertDataDirectoriesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RevertDataDirectoriesRequest.Merge(m, src)
+}
+func (m *RevertDataDirectoriesRequest) XXX_Size() int {
+	return xxx_messageInfo_RevertDataDirectoriesRequest.Size(m)
+}
+func (m *RevertDataDirectoriesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RevertDataDirectoriesRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_StopAgentReply proto.InternalMessageInfo
+var xxx_messageInfo_RevertDataDirectoriesRequest proto.InternalMessageInfo
 
-type CheckSegmentDiskSpaceRequest struct {
-	DiskFreeRatio        float64  `protobuf:"fixed64,1,opt,name=diskFreeRatio,proto3" json:"diskFreeRatio,omitempty"`
-	Dirs                 []string `protobuf:"bytes,2,rep,name=dirs,proto3" json:"dirs,omitempty"`
+func (m *RevertDataDirectoriesRequest) GetDirs() []*RevertDataDirectory {
+	if m != nil {
+		return m.Dirs
+	}
+	return nil
+}
+
+func (m *RevertDataDirectoriesRequest) GetIdempotencyToken() string {
+	if m != nil {
+		return m.IdempotencyToken
+	}
+	return ""
+}
+
+type RevertDataDirectoryResult struct {
+	Source               string   `protobuf:"bytes,1,opt,name=Source,proto3" json:"Source,omitempty"`
+	NothingToRevert      bool     `protobuf:"varint,2,opt,name=NothingToRevert,proto3" json:"NothingToRevert,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *CheckSegmentDiskSpaceRequest) Reset()         { *m = CheckSegmentDiskSpaceRequest{} }
-func (m *CheckSegmentDiskSpaceRequest) String() string { return proto.CompactTextString(m) }
-func (*CheckSegmentDiskSpaceRequest) ProtoMessage()    {}
-func (*CheckSegmentDiskSpaceRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{17}
+func (m *RevertDataDirectoryResult) Reset()         { *m = RevertDataDirectoryResult{} }
+func (m *RevertDataDirectoryResult) String() string { return proto.CompactTextString(m) }
+func (*RevertDataDirectoryResult) ProtoMessage()    {}
+func (*RevertDataDirectoryResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{21}
 }
 
-func (m *CheckSegmentDiskSpaceRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_CheckSegmentDiskSpaceRequest.Unmarshal(m, b)
+func (m *RevertDataDirectoryResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RevertDataDirectoryResult.Unmarshal(m, b)
 }
-func (m *CheckSegmentDiskSpaceRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_CheckSegmentDiskSpaceRequest.Marshal(b, m, deterministic)
+func (m *RevertDataDirectoryResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RevertDataDirectoryResult.Marshal(b, m, deterministic)
 }
-func (m *CheckSegmentDiskSpaceRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_CheckSegmentDiskSpaceRequest.Merge(m, src)
+func (m *RevertDataDirectoryResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RevertDataDirectoryResult.Merge(m, src)
 }
-func (m *CheckSegmentDiskSpaceRequest) XXX_Size() int {
-	return xxx_messageInfo_CheckSegmentDiskSpaceRequest.Size(m)
+func (m *RevertDataDirectoryResult) XXX_Size() int {
+	return xxx_messageInfo_RevertDataDirectoryResult.Size(m)
 }
-func (m *CheckSegmentDiskSpaceRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_CheckSegmentDiskSpaceRequest.DiscardUnknown(m)
+func (m *RevertDataDirectoryResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_RevertDataDirectoryResult.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_CheckSegmentDiskSpaceRequest proto.InternalMessageInfo
+var xxx_messageInfo_RevertDataDirectoryResult proto.InternalMessageInfo
 
-func (m *CheckSegmentDiskSpaceRequest) GetDiskFreeRatio() float64 {
+func (m *RevertDataDirectoryResult) GetSource() string {
 	if m != nil {
-		return m.DiskFreeRatio
+		return m.Source
 	}
-	return 0
+	return ""
 }
 
-func (m *CheckSegmentDiskSpaceRequest) GetDirs() []string {
+func (m *RevertDataDirectoryResult) GetNothingToRevert() bool {
 	if m != nil {
-		return m.Dirs
+		return m.NothingToRevert
 	}
-	return nil
+	return false
 }
 
-type CheckDiskSpaceReply struct {
-	Usage                []*CheckDiskSpaceReply_DiskUsage `protobuf:"bytes,1,rep,name=usage,proto3" json:"usage,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}                         `json:"-"`
-	XXX_unrecognized     []byte                           `json:"-"`
-	XXX_sizecache        int32                            `json:"-"`
+type RevertDataDirectoriesReply struct {
+	Host                 string                       `protobuf:"bytes,1,opt,name=Host,proto3" json:"Host,omitempty"`
+	Results              []*RevertDataDirectoryResult `protobuf:"bytes,2,rep,name=Results,proto3" json:"Results,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                     `json:"-"`
+	XXX_unrecognized     []byte                       `json:"-"`
+	XXX_sizecache        int32                        `json:"-"`
 }
 
-func (m *CheckDiskSpaceReply) Reset()         { *m = CheckDiskSpaceReply{} }
-func (m *CheckDiskSpaceReply) String() string { return proto.CompactTextString(m) }
+func (m *RevertDataDirectoriesReply) Reset()         { *m = RevertDataDirectoriesReply{} }
+func (m *RevertDataDirectoriesReply) String() string { return proto.CompactTextString(m) }
+func (*RevertDataDirectoriesReply) ProtoMessage()    {}
+func (*RevertDataDirectoriesReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{22}
+}
+
+func (m *RevertDataDirectoriesReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RevertDataDirectoriesReply.Unmarshal(m, b)
+}
+func (m *RevertDataDirectoriesReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RevertDataDirectoriesReply.Marshal(b, m, deterministic)
+}
+func (m *RevertDataDirectoriesReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RevertDataDirectoriesReply.Merge(m, src)
+}
+func (m *RevertDataDirectoriesReply) XXX_Size() int {
+	return xxx_messageInfo_RevertDataDirectoriesReply.Size(m)
+}
+func (m *RevertDataDirectoriesReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_RevertDataDirectoriesReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RevertDataDirectoriesReply proto.InternalMessageInfo
+
+func (m *RevertDataDirectoriesReply) GetHost() string {
+	if m != nil {
+		return m.Host
+	}
+	return ""
+}
+
+func (m *RevertDataDirectoriesReply) GetResults() []*RevertDataDirectoryResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+type StopAgentRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StopAgentRequest) Reset()         { *m = StopAgentRequest{} }
+func (m *StopAgentRequest) String() string { return proto.CompactTextString(m) }
+func (*StopAgentRequest) ProtoMessage()    {}
+func (*StopAgentRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{23}
+}
+
+func (m *StopAgentRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StopAgentRequest.Unmarshal(m, b)
+}
+func (m *StopAgentRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StopAgentRequest.Marshal(b, m, deterministic)
+}
+func (m *StopAgentRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StopAgentRequest.Merge(m, src)
+}
+func (m *StopAgentRequest) XXX_Size() int {
+	return xxx_messageInfo_StopAgentRequest.Size(m)
+}
+func (m *StopAgentRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StopAgentRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StopAgentRequest proto.InternalMessageInfo
+
+type StopAgentReply struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StopAgentReply) Reset()         { *m = StopAgentReply{} }
+func (m *StopAgentReply) String() string { return proto.CompactTextString(m) }
+func (*StopAgentReply) ProtoMessage()    {}
+func (*StopAgentReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{24}
+}
+
+func (m *StopAgentReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StopAgentReply.Unmarshal(m, b)
+}
+func (m *StopAgentReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StopAgentReply.Marshal(b, m, deterministic)
+}
+func (m *StopAgentReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StopAgentReply.Merge(m, src)
+}
+func (m *StopAgentReply) XXX_Size() int {
+	return xxx_messageInfo_StopAgentReply.Size(m)
+}
+func (m *StopAgentReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_StopAgentReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StopAgentReply proto.InternalMessageInfo
+
+type CheckSegmentDiskSpaceRequest struct {
+	DiskFreeRatio        float64  `protobuf:"fixed64,1,opt,name=diskFreeRatio,proto3" json:"diskFreeRatio,omitempty"`
+	Dirs                 []string `protobuf:"bytes,2,rep,name=dirs,proto3" json:"dirs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CheckSegmentDiskSpaceRequest) Reset()         { *m = CheckSegmentDiskSpaceRequest{} }
+func (m *CheckSegmentDiskSpaceRequest) String() string { return proto.CompactTextString(m) }
+func (*CheckSegmentDiskSpaceRequest) ProtoMessage()    {}
+func (*CheckSegmentDiskSpaceRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{25}
+}
+
+func (m *CheckSegmentDiskSpaceRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CheckSegmentDiskSpaceRequest.Unmarshal(m, b)
+}
+func (m *CheckSegmentDiskSpaceRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CheckSegmentDiskSpaceRequest.Marshal(b, m, deterministic)
+}
+func (m *CheckSegmentDiskSpaceRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CheckSegmentDiskSpaceRequest.Merge(m, src)
+}
+func (m *CheckSegmentDiskSpaceRequest) XXX_Size() int {
+	return xxx_messageInfo_CheckSegmentDiskSpaceRequest.Size(m)
+}
+func (m *CheckSegmentDiskSpaceRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CheckSegmentDiskSpaceRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CheckSegmentDiskSpaceRequest proto.InternalMessageInfo
+
+func (m *CheckSegmentDiskSpaceRequest) GetDiskFreeRatio() float64 {
+	if m != nil {
+		return m.DiskFreeRatio
+	}
+	return 0
+}
+
+func (m *CheckSegmentDiskSpaceRequest) GetDirs() []string {
+	if m != nil {
+		return m.Dirs
+	}
+	return nil
+}
+
+type CheckDiskSpaceReply struct {
+	Usage                []*CheckDiskSpaceReply_DiskUsage `protobuf:"bytes,1,rep,name=usage,proto3" json:"usage,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                         `json:"-"`
+	XXX_unrecognized     []byte                           `json:"-"`
+	XXX_sizecache        int32                            `json:"-"`
+}
+
+func (m *CheckDiskSpaceReply) Reset()         { *m = CheckDiskSpaceReply{} }
+func (m *CheckDiskSpaceReply) String() string { return proto.CompactTextString(m) }
 func (*CheckDiskSpaceReply) ProtoMessage()    {}
 func (*CheckDiskSpaceReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{18}
+	return fileDescriptor_9e73bb06acc917d8, []int{26}
 }
 
 func (m *CheckDiskSpaceReply) XXX_Unmarshal(b []byte) error {
@@ -851,7 +1283,7 @@ func (m *CheckDiskSpaceReply_DiskUsage) Reset()         { *m = CheckDiskSpaceRep
 func (m *CheckDiskSpaceReply_DiskUsage) String() string { return proto.CompactTextString(m) }
 func (*CheckDiskSpaceReply_DiskUsage) ProtoMessage()    {}
 func (*CheckDiskSpaceReply_DiskUsage) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{18, 0}
+	return fileDescriptor_9e73bb06acc917d8, []int{26, 0}
 }
 
 func (m *CheckDiskSpaceReply_DiskUsage) XXX_Unmarshal(b []byte) error {
@@ -900,6 +1332,147 @@ func (m *CheckDiskSpaceReply_DiskUsage) GetRequired() uint64 {
 	return 0
 }
 
+type CheckFreeSpaceRequest struct {
+	Paths                []string `protobuf:"bytes,1,rep,name=Paths,proto3" json:"Paths,omitempty"`
+	RequiredBytes        uint64   `protobuf:"varint,2,opt,name=RequiredBytes,proto3" json:"RequiredBytes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CheckFreeSpaceRequest) Reset()         { *m = CheckFreeSpaceRequest{} }
+func (m *CheckFreeSpaceRequest) String() string { return proto.CompactTextString(m) }
+func (*CheckFreeSpaceRequest) ProtoMessage()    {}
+func (*CheckFreeSpaceRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{27}
+}
+
+func (m *CheckFreeSpaceRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CheckFreeSpaceRequest.Unmarshal(m, b)
+}
+func (m *CheckFreeSpaceRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CheckFreeSpaceRequest.Marshal(b, m, deterministic)
+}
+func (m *CheckFreeSpaceRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CheckFreeSpaceRequest.Merge(m, src)
+}
+func (m *CheckFreeSpaceRequest) XXX_Size() int {
+	return xxx_messageInfo_CheckFreeSpaceRequest.Size(m)
+}
+func (m *CheckFreeSpaceRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CheckFreeSpaceRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CheckFreeSpaceRequest proto.InternalMessageInfo
+
+func (m *CheckFreeSpaceRequest) GetPaths() []string {
+	if m != nil {
+		return m.Paths
+	}
+	return nil
+}
+
+func (m *CheckFreeSpaceRequest) GetRequiredBytes() uint64 {
+	if m != nil {
+		return m.RequiredBytes
+	}
+	return 0
+}
+
+type CheckFreeSpaceReply struct {
+	Paths                []*CheckFreeSpaceReply_PathSpace `protobuf:"bytes,1,rep,name=Paths,proto3" json:"Paths,omitempty"`
+	Insufficient         []string                         `protobuf:"bytes,2,rep,name=Insufficient,proto3" json:"Insufficient,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                         `json:"-"`
+	XXX_unrecognized     []byte                           `json:"-"`
+	XXX_sizecache        int32                            `json:"-"`
+}
+
+func (m *CheckFreeSpaceReply) Reset()         { *m = CheckFreeSpaceReply{} }
+func (m *CheckFreeSpaceReply) String() string { return proto.CompactTextString(m) }
+func (*CheckFreeSpaceReply) ProtoMessage()    {}
+func (*CheckFreeSpaceReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{28}
+}
+
+func (m *CheckFreeSpaceReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CheckFreeSpaceReply.Unmarshal(m, b)
+}
+func (m *CheckFreeSpaceReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CheckFreeSpaceReply.Marshal(b, m, deterministic)
+}
+func (m *CheckFreeSpaceReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CheckFreeSpaceReply.Merge(m, src)
+}
+func (m *CheckFreeSpaceReply) XXX_Size() int {
+	return xxx_messageInfo_CheckFreeSpaceReply.Size(m)
+}
+func (m *CheckFreeSpaceReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_CheckFreeSpaceReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CheckFreeSpaceReply proto.InternalMessageInfo
+
+func (m *CheckFreeSpaceReply) GetPaths() []*CheckFreeSpaceReply_PathSpace {
+	if m != nil {
+		return m.Paths
+	}
+	return nil
+}
+
+func (m *CheckFreeSpaceReply) GetInsufficient() []string {
+	if m != nil {
+		return m.Insufficient
+	}
+	return nil
+}
+
+type CheckFreeSpaceReply_PathSpace struct {
+	Path                 string   `protobuf:"bytes,1,opt,name=Path,proto3" json:"Path,omitempty"`
+	Available            uint64   `protobuf:"varint,2,opt,name=Available,proto3" json:"Available,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CheckFreeSpaceReply_PathSpace) Reset()         { *m = CheckFreeSpaceReply_PathSpace{} }
+func (m *CheckFreeSpaceReply_PathSpace) String() string { return proto.CompactTextString(m) }
+func (*CheckFreeSpaceReply_PathSpace) ProtoMessage()    {}
+func (*CheckFreeSpaceReply_PathSpace) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{28, 0}
+}
+
+func (m *CheckFreeSpaceReply_PathSpace) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CheckFreeSpaceReply_PathSpace.Unmarshal(m, b)
+}
+func (m *CheckFreeSpaceReply_PathSpace) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CheckFreeSpaceReply_PathSpace.Marshal(b, m, deterministic)
+}
+func (m *CheckFreeSpaceReply_PathSpace) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CheckFreeSpaceReply_PathSpace.Merge(m, src)
+}
+func (m *CheckFreeSpaceReply_PathSpace) XXX_Size() int {
+	return xxx_messageInfo_CheckFreeSpaceReply_PathSpace.Size(m)
+}
+func (m *CheckFreeSpaceReply_PathSpace) XXX_DiscardUnknown() {
+	xxx_messageInfo_CheckFreeSpaceReply_PathSpace.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CheckFreeSpaceReply_PathSpace proto.InternalMessageInfo
+
+func (m *CheckFreeSpaceReply_PathSpace) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *CheckFreeSpaceReply_PathSpace) GetAvailable() uint64 {
+	if m != nil {
+		return m.Available
+	}
+	return 0
+}
+
 type RsyncPair struct {
 	Source               string   `protobuf:"bytes,1,opt,name=Source,proto3" json:"Source,omitempty"`
 	DestinationHost      string   `protobuf:"bytes,2,opt,name=DestinationHost,proto3" json:"DestinationHost,omitempty"`
@@ -913,7 +1486,7 @@ func (m *RsyncPair) Reset()         { *m = RsyncPair{} }
 func (m *RsyncPair) String() string { return proto.CompactTextString(m) }
 func (*RsyncPair) ProtoMessage()    {}
 func (*RsyncPair) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{19}
+	return fileDescriptor_9e73bb06acc917d8, []int{29}
 }
 
 func (m *RsyncPair) XXX_Unmarshal(b []byte) error {
@@ -959,6 +1532,7 @@ type RsyncRequest struct {
 	Options              []string     `protobuf:"bytes,1,rep,name=Options,proto3" json:"Options,omitempty"`
 	Excludes             []string     `protobuf:"bytes,2,rep,name=Excludes,proto3" json:"Excludes,omitempty"`
 	Pairs                []*RsyncPair `protobuf:"bytes,3,rep,name=Pairs,proto3" json:"Pairs,omitempty"`
+	IdempotencyToken     string       `protobuf:"bytes,4,opt,name=IdempotencyToken,proto3" json:"IdempotencyToken,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
 	XXX_unrecognized     []byte       `json:"-"`
 	XXX_sizecache        int32        `json:"-"`
@@ -968,7 +1542,7 @@ func (m *RsyncRequest) Reset()         { *m = RsyncRequest{} }
 func (m *RsyncRequest) String() string { return proto.CompactTextString(m) }
 func (*RsyncRequest) ProtoMessage()    {}
 func (*RsyncRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{20}
+	return fileDescriptor_9e73bb06acc917d8, []int{30}
 }
 
 func (m *RsyncRequest) XXX_Unmarshal(b []byte) error {
@@ -1010,6 +1584,13 @@ func (m *RsyncRequest) GetPairs() []*RsyncPair {
 	return nil
 }
 
+func (m *RsyncRequest) GetIdempotencyToken() string {
+	if m != nil {
+		return m.IdempotencyToken
+	}
+	return ""
+}
+
 type RsyncReply struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -1020,7 +1601,7 @@ func (m *RsyncReply) Reset()         { *m = RsyncReply{} }
 func (m *RsyncReply) String() string { return proto.CompactTextString(m) }
 func (*RsyncReply) ProtoMessage()    {}
 func (*RsyncReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{21}
+	return fileDescriptor_9e73bb06acc917d8, []int{31}
 }
 
 func (m *RsyncReply) XXX_Unmarshal(b []byte) error {
@@ -1032,84 +1613,444 @@ func (m *RsyncReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
 func (m *RsyncReply) XXX_Merge(src proto.Message) {
 	xxx_messageInfo_RsyncReply.Merge(m, src)
 }
-func (m *RsyncReply) XXX_Size() int {
-	return xxx_messageInfo_RsyncReply.Size(m)
+func (m *RsyncReply) XXX_Size() int {
+	return xxx_messageInfo_RsyncReply.Size(m)
+}
+func (m *RsyncReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_RsyncReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RsyncReply proto.InternalMessageInfo
+
+type RsyncDirectoryRequest struct {
+	Source               string   `protobuf:"bytes,1,opt,name=Source,proto3" json:"Source,omitempty"`
+	DestinationHost      string   `protobuf:"bytes,2,opt,name=DestinationHost,proto3" json:"DestinationHost,omitempty"`
+	Destination          string   `protobuf:"bytes,3,opt,name=Destination,proto3" json:"Destination,omitempty"`
+	Delete               bool     `protobuf:"varint,4,opt,name=Delete,proto3" json:"Delete,omitempty"`
+	Checksum             bool     `protobuf:"varint,5,opt,name=Checksum,proto3" json:"Checksum,omitempty"`
+	Compress             bool     `protobuf:"varint,6,opt,name=Compress,proto3" json:"Compress,omitempty"`
+	Excludes             []string `protobuf:"bytes,7,rep,name=Excludes,proto3" json:"Excludes,omitempty"`
+	IdempotencyToken     string   `protobuf:"bytes,8,opt,name=IdempotencyToken,proto3" json:"IdempotencyToken,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RsyncDirectoryRequest) Reset()         { *m = RsyncDirectoryRequest{} }
+func (m *RsyncDirectoryRequest) String() string { return proto.CompactTextString(m) }
+func (*RsyncDirectoryRequest) ProtoMessage()    {}
+func (*RsyncDirectoryRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{32}
+}
+
+func (m *RsyncDirectoryRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RsyncDirectoryRequest.Unmarshal(m, b)
+}
+func (m *RsyncDirectoryRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RsyncDirectoryRequest.Marshal(b, m, deterministic)
+}
+func (m *RsyncDirectoryRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RsyncDirectoryRequest.Merge(m, src)
+}
+func (m *RsyncDirectoryRequest) XXX_Size() int {
+	return xxx_messageInfo_RsyncDirectoryRequest.Size(m)
+}
+func (m *RsyncDirectoryRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RsyncDirectoryRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RsyncDirectoryRequest proto.InternalMessageInfo
+
+func (m *RsyncDirectoryRequest) GetSource() string {
+	if m != nil {
+		return m.Source
+	}
+	return ""
+}
+
+func (m *RsyncDirectoryRequest) GetDestinationHost() string {
+	if m != nil {
+		return m.DestinationHost
+	}
+	return ""
+}
+
+func (m *RsyncDirectoryRequest) GetDestination() string {
+	if m != nil {
+		return m.Destination
+	}
+	return ""
+}
+
+func (m *RsyncDirectoryRequest) GetDelete() bool {
+	if m != nil {
+		return m.Delete
+	}
+	return false
+}
+
+func (m *RsyncDirectoryRequest) GetChecksum() bool {
+	if m != nil {
+		return m.Checksum
+	}
+	return false
+}
+
+func (m *RsyncDirectoryRequest) GetCompress() bool {
+	if m != nil {
+		return m.Compress
+	}
+	return false
+}
+
+func (m *RsyncDirectoryRequest) GetExcludes() []string {
+	if m != nil {
+		return m.Excludes
+	}
+	return nil
+}
+
+func (m *RsyncDirectoryRequest) GetIdempotencyToken() string {
+	if m != nil {
+		return m.IdempotencyToken
+	}
+	return ""
+}
+
+type RsyncDirectoryReply struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RsyncDirectoryReply) Reset()         { *m = RsyncDirectoryReply{} }
+func (m *RsyncDirectoryReply) String() string { return proto.CompactTextString(m) }
+func (*RsyncDirectoryReply) ProtoMessage()    {}
+func (*RsyncDirectoryReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{33}
+}
+
+func (m *RsyncDirectoryReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RsyncDirectoryReply.Unmarshal(m, b)
+}
+func (m *RsyncDirectoryReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RsyncDirectoryReply.Marshal(b, m, deterministic)
+}
+func (m *RsyncDirectoryReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RsyncDirectoryReply.Merge(m, src)
+}
+func (m *RsyncDirectoryReply) XXX_Size() int {
+	return xxx_messageInfo_RsyncDirectoryReply.Size(m)
+}
+func (m *RsyncDirectoryReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_RsyncDirectoryReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RsyncDirectoryReply proto.InternalMessageInfo
+
+type RestorePgControlRequest struct {
+	Datadirs             []string `protobuf:"bytes,1,rep,name=datadirs,proto3" json:"datadirs,omitempty"`
+	IdempotencyToken     string   `protobuf:"bytes,2,opt,name=IdempotencyToken,proto3" json:"IdempotencyToken,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RestorePgControlRequest) Reset()         { *m = RestorePgControlRequest{} }
+func (m *RestorePgControlRequest) String() string { return proto.CompactTextString(m) }
+func (*RestorePgControlRequest) ProtoMessage()    {}
+func (*RestorePgControlRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{34}
+}
+
+func (m *RestorePgControlRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RestorePgControlRequest.Unmarshal(m, b)
+}
+func (m *RestorePgControlRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RestorePgControlRequest.Marshal(b, m, deterministic)
+}
+func (m *RestorePgControlRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RestorePgControlRequest.Merge(m, src)
+}
+func (m *RestorePgControlRequest) XXX_Size() int {
+	return xxx_messageInfo_RestorePgControlRequest.Size(m)
+}
+func (m *RestorePgControlRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RestorePgControlRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RestorePgControlRequest proto.InternalMessageInfo
+
+func (m *RestorePgControlRequest) GetDatadirs() []string {
+	if m != nil {
+		return m.Datadirs
+	}
+	return nil
+}
+
+func (m *RestorePgControlRequest) GetIdempotencyToken() string {
+	if m != nil {
+		return m.IdempotencyToken
+	}
+	return ""
+}
+
+type RestorePgControlReply struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RestorePgControlReply) Reset()         { *m = RestorePgControlReply{} }
+func (m *RestorePgControlReply) String() string { return proto.CompactTextString(m) }
+func (*RestorePgControlReply) ProtoMessage()    {}
+func (*RestorePgControlReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{35}
+}
+
+func (m *RestorePgControlReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RestorePgControlReply.Unmarshal(m, b)
+}
+func (m *RestorePgControlReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RestorePgControlReply.Marshal(b, m, deterministic)
+}
+func (m *RestorePgControlReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RestorePgControlReply.Merge(m, src)
+}
+func (m *RestorePgControlReply) XXX_Size() int {
+	return xxx_messageInfo_RestorePgControlReply.Size(m)
+}
+func (m *RestorePgControlReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_RestorePgControlReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RestorePgControlReply proto.InternalMessageInfo
+
+type VersionRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *VersionRequest) Reset()         { *m = VersionRequest{} }
+func (m *VersionRequest) String() string { return proto.CompactTextString(m) }
+func (*VersionRequest) ProtoMessage()    {}
+func (*VersionRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{36}
+}
+
+func (m *VersionRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VersionRequest.Unmarshal(m, b)
+}
+func (m *VersionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VersionRequest.Marshal(b, m, deterministic)
+}
+func (m *VersionRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VersionRequest.Merge(m, src)
+}
+func (m *VersionRequest) XXX_Size() int {
+	return xxx_messageInfo_VersionRequest.Size(m)
+}
+func (m *VersionRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_VersionRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VersionRequest proto.InternalMessageInfo
+
+type VersionReply struct {
+	Version              string   `protobuf:"bytes,1,opt,name=Version,proto3" json:"Version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *VersionReply) Reset()         { *m = VersionReply{} }
+func (m *VersionReply) String() string { return proto.CompactTextString(m) }
+func (*VersionReply) ProtoMessage()    {}
+func (*VersionReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{37}
+}
+
+func (m *VersionReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VersionReply.Unmarshal(m, b)
+}
+func (m *VersionReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VersionReply.Marshal(b, m, deterministic)
+}
+func (m *VersionReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VersionReply.Merge(m, src)
+}
+func (m *VersionReply) XXX_Size() int {
+	return xxx_messageInfo_VersionReply.Size(m)
+}
+func (m *VersionReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_VersionReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VersionReply proto.InternalMessageInfo
+
+func (m *VersionReply) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+type HealthzRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HealthzRequest) Reset()         { *m = HealthzRequest{} }
+func (m *HealthzRequest) String() string { return proto.CompactTextString(m) }
+func (*HealthzRequest) ProtoMessage()    {}
+func (*HealthzRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{38}
+}
+
+func (m *HealthzRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HealthzRequest.Unmarshal(m, b)
+}
+func (m *HealthzRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HealthzRequest.Marshal(b, m, deterministic)
+}
+func (m *HealthzRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HealthzRequest.Merge(m, src)
+}
+func (m *HealthzRequest) XXX_Size() int {
+	return xxx_messageInfo_HealthzRequest.Size(m)
+}
+func (m *HealthzRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_HealthzRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HealthzRequest proto.InternalMessageInfo
+
+type HealthzReply struct {
+	Healthy              bool     `protobuf:"varint,1,opt,name=Healthy,proto3" json:"Healthy,omitempty"`
+	StateDir             string   `protobuf:"bytes,2,opt,name=StateDir,proto3" json:"StateDir,omitempty"`
+	Reason               string   `protobuf:"bytes,3,opt,name=Reason,proto3" json:"Reason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HealthzReply) Reset()         { *m = HealthzReply{} }
+func (m *HealthzReply) String() string { return proto.CompactTextString(m) }
+func (*HealthzReply) ProtoMessage()    {}
+func (*HealthzReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{39}
+}
+
+func (m *HealthzReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HealthzReply.Unmarshal(m, b)
+}
+func (m *HealthzReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HealthzReply.Marshal(b, m, deterministic)
+}
+func (m *HealthzReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HealthzReply.Merge(m, src)
+}
+func (m *HealthzReply) XXX_Size() int {
+	return xxx_messageInfo_HealthzReply.Size(m)
+}
+func (m *HealthzReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_HealthzReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HealthzReply proto.InternalMessageInfo
+
+func (m *HealthzReply) GetHealthy() bool {
+	if m != nil {
+		return m.Healthy
+	}
+	return false
 }
-func (m *RsyncReply) XXX_DiscardUnknown() {
-	xxx_messageInfo_RsyncReply.DiscardUnknown(m)
+
+func (m *HealthzReply) GetStateDir() string {
+	if m != nil {
+		return m.StateDir
+	}
+	return ""
 }
 
-var xxx_messageInfo_RsyncReply proto.InternalMessageInfo
+func (m *HealthzReply) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
 
-type RestorePgControlRequest struct {
-	Datadirs             []string `protobuf:"bytes,1,rep,name=datadirs,proto3" json:"datadirs,omitempty"`
+type TailLogRequest struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *RestorePgControlRequest) Reset()         { *m = RestorePgControlRequest{} }
-func (m *RestorePgControlRequest) String() string { return proto.CompactTextString(m) }
-func (*RestorePgControlRequest) ProtoMessage()    {}
-func (*RestorePgControlRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{22}
+func (m *TailLogRequest) Reset()         { *m = TailLogRequest{} }
+func (m *TailLogRequest) String() string { return proto.CompactTextString(m) }
+func (*TailLogRequest) ProtoMessage()    {}
+func (*TailLogRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{40}
 }
 
-func (m *RestorePgControlRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_RestorePgControlRequest.Unmarshal(m, b)
+func (m *TailLogRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TailLogRequest.Unmarshal(m, b)
 }
-func (m *RestorePgControlRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_RestorePgControlRequest.Marshal(b, m, deterministic)
+func (m *TailLogRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TailLogRequest.Marshal(b, m, deterministic)
 }
-func (m *RestorePgControlRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_RestorePgControlRequest.Merge(m, src)
+func (m *TailLogRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TailLogRequest.Merge(m, src)
 }
-func (m *RestorePgControlRequest) XXX_Size() int {
-	return xxx_messageInfo_RestorePgControlRequest.Size(m)
+func (m *TailLogRequest) XXX_Size() int {
+	return xxx_messageInfo_TailLogRequest.Size(m)
 }
-func (m *RestorePgControlRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_RestorePgControlRequest.DiscardUnknown(m)
+func (m *TailLogRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_TailLogRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_RestorePgControlRequest proto.InternalMessageInfo
-
-func (m *RestorePgControlRequest) GetDatadirs() []string {
-	if m != nil {
-		return m.Datadirs
-	}
-	return nil
-}
+var xxx_messageInfo_TailLogRequest proto.InternalMessageInfo
 
-type RestorePgControlReply struct {
+type LogLine struct {
+	Line                 string   `protobuf:"bytes,1,opt,name=Line,proto3" json:"Line,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *RestorePgControlReply) Reset()         { *m = RestorePgControlReply{} }
-func (m *RestorePgControlReply) String() string { return proto.CompactTextString(m) }
-func (*RestorePgControlReply) ProtoMessage()    {}
-func (*RestorePgControlReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{23}
+func (m *LogLine) Reset()         { *m = LogLine{} }
+func (m *LogLine) String() string { return proto.CompactTextString(m) }
+func (*LogLine) ProtoMessage()    {}
+func (*LogLine) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{41}
 }
 
-func (m *RestorePgControlReply) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_RestorePgControlReply.Unmarshal(m, b)
+func (m *LogLine) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LogLine.Unmarshal(m, b)
 }
-func (m *RestorePgControlReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_RestorePgControlReply.Marshal(b, m, deterministic)
+func (m *LogLine) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LogLine.Marshal(b, m, deterministic)
 }
-func (m *RestorePgControlReply) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_RestorePgControlReply.Merge(m, src)
+func (m *LogLine) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LogLine.Merge(m, src)
 }
-func (m *RestorePgControlReply) XXX_Size() int {
-	return xxx_messageInfo_RestorePgControlReply.Size(m)
+func (m *LogLine) XXX_Size() int {
+	return xxx_messageInfo_LogLine.Size(m)
 }
-func (m *RestorePgControlReply) XXX_DiscardUnknown() {
-	xxx_messageInfo_RestorePgControlReply.DiscardUnknown(m)
+func (m *LogLine) XXX_DiscardUnknown() {
+	xxx_messageInfo_LogLine.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_RestorePgControlReply proto.InternalMessageInfo
+var xxx_messageInfo_LogLine proto.InternalMessageInfo
+
+func (m *LogLine) GetLine() string {
+	if m != nil {
+		return m.Line
+	}
+	return ""
+}
 
 func init() {
 	proto.RegisterType((*TablespaceInfo)(nil), "idl.TablespaceInfo")
@@ -1117,8 +2058,13 @@ func init() {
 	proto.RegisterType((*DataDirPair)(nil), "idl.DataDirPair")
 	proto.RegisterMapType((map[int32]*TablespaceInfo)(nil), "idl.DataDirPair.TablespacesEntry")
 	proto.RegisterType((*UpgradePrimariesReply)(nil), "idl.UpgradePrimariesReply")
+	proto.RegisterMapType((map[int32]string)(nil), "idl.UpgradePrimariesReply.LogFilesEntry")
 	proto.RegisterType((*DeleteDataDirectoriesRequest)(nil), "idl.DeleteDataDirectoriesRequest")
 	proto.RegisterType((*DeleteDataDirectoriesReply)(nil), "idl.DeleteDataDirectoriesReply")
+	proto.RegisterType((*ValidateDataDirectoryRequest)(nil), "idl.ValidateDataDirectoryRequest")
+	proto.RegisterType((*ValidateDataDirectoryReply)(nil), "idl.ValidateDataDirectoryReply")
+	proto.RegisterType((*DataDirectoryValidity)(nil), "idl.DataDirectoryValidity")
+	proto.RegisterType((*ProgressEvent)(nil), "idl.ProgressEvent")
 	proto.RegisterType((*DeleteStateDirectoryRequest)(nil), "idl.DeleteStateDirectoryRequest")
 	proto.RegisterType((*DeleteStateDirectoryReply)(nil), "idl.DeleteStateDirectoryReply")
 	proto.RegisterType((*DeleteTablespaceRequest)(nil), "idl.DeleteTablespaceRequest")
@@ -1128,90 +2074,146 @@ func init() {
 	proto.RegisterType((*RenameDirectories)(nil), "idl.RenameDirectories")
 	proto.RegisterType((*RenameDirectoriesRequest)(nil), "idl.RenameDirectoriesRequest")
 	proto.RegisterType((*RenameDirectoriesReply)(nil), "idl.RenameDirectoriesReply")
+	proto.RegisterType((*RevertDataDirectory)(nil), "idl.RevertDataDirectory")
+	proto.RegisterType((*RevertDataDirectoriesRequest)(nil), "idl.RevertDataDirectoriesRequest")
+	proto.RegisterType((*RevertDataDirectoryResult)(nil), "idl.RevertDataDirectoryResult")
+	proto.RegisterType((*RevertDataDirectoriesReply)(nil), "idl.RevertDataDirectoriesReply")
 	proto.RegisterType((*StopAgentRequest)(nil), "idl.StopAgentRequest")
 	proto.RegisterType((*StopAgentReply)(nil), "idl.StopAgentReply")
 	proto.RegisterType((*CheckSegmentDiskSpaceRequest)(nil), "idl.CheckSegmentDiskSpaceRequest")
 	proto.RegisterType((*CheckDiskSpaceReply)(nil), "idl.CheckDiskSpaceReply")
 	proto.RegisterType((*CheckDiskSpaceReply_DiskUsage)(nil), "idl.CheckDiskSpaceReply.DiskUsage")
+	proto.RegisterType((*CheckFreeSpaceRequest)(nil), "idl.CheckFreeSpaceRequest")
+	proto.RegisterType((*CheckFreeSpaceReply)(nil), "idl.CheckFreeSpaceReply")
+	proto.RegisterType((*CheckFreeSpaceReply_PathSpace)(nil), "idl.CheckFreeSpaceReply.PathSpace")
 	proto.RegisterType((*RsyncPair)(nil), "idl.RsyncPair")
 	proto.RegisterType((*RsyncRequest)(nil), "idl.RsyncRequest")
 	proto.RegisterType((*RsyncReply)(nil), "idl.RsyncReply")
+	proto.RegisterType((*RsyncDirectoryRequest)(nil), "idl.RsyncDirectoryRequest")
+	proto.RegisterType((*RsyncDirectoryReply)(nil), "idl.RsyncDirectoryReply")
 	proto.RegisterType((*RestorePgControlRequest)(nil), "idl.RestorePgControlRequest")
 	proto.RegisterType((*RestorePgControlReply)(nil), "idl.RestorePgControlReply")
+	proto.RegisterType((*VersionRequest)(nil), "idl.VersionRequest")
+	proto.RegisterType((*VersionReply)(nil), "idl.VersionReply")
+	proto.RegisterType((*HealthzRequest)(nil), "idl.HealthzRequest")
+	proto.RegisterType((*HealthzReply)(nil), "idl.HealthzReply")
+	proto.RegisterType((*TailLogRequest)(nil), "idl.TailLogRequest")
+	proto.RegisterType((*LogLine)(nil), "idl.LogLine")
 }
 
 func init() { proto.RegisterFile("hub_to_agent.proto", fileDescriptor_9e73bb06acc917d8) }
 
 var fileDescriptor_9e73bb06acc917d8 = []byte{
-	// 1076 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0x5b, 0x4f, 0x1b, 0x47,
-	0x14, 0xc6, 0x37, 0x2e, 0xc7, 0x04, 0x9c, 0x21, 0xc0, 0x66, 0x31, 0xa9, 0x59, 0xf1, 0x40, 0x2b,
-	0x95, 0x07, 0x9a, 0x4a, 0x69, 0x54, 0x55, 0x0a, 0x38, 0xa8, 0x91, 0xb8, 0x75, 0x1d, 0x9a, 0xb6,
-	0x52, 0x85, 0x06, 0xfb, 0x60, 0x6f, 0xbd, 0xec, 0x6e, 0x66, 0xc7, 0xb4, 0xfe, 0x6b, 0xfd, 0x37,
-	0x79, 0xea, 0xdf, 0xa8, 0xe6, 0xe6, 0x1d, 0xdb, 0xbb, 0x28, 0x0f, 0x79, 0xdb, 0xf3, 0x9d, 0xef,
-	0x9c, 0x99, 0x73, 0x9d, 0x05, 0x32, 0x18, 0xdd, 0xde, 0xf0, 0xf8, 0x86, 0xf6, 0x31, 0xe2, 0x87,
-	0x09, 0x8b, 0x79, 0x4c, 0x2a, 0x41, 0x2f, 0xf4, 0x6e, 0x61, 0xed, 0x3d, 0xbd, 0x0d, 0x31, 0x4d,
-	0x68, 0x17, 0xdf, 0x45, 0x77, 0x31, 0x21, 0x50, 0xbd, 0xa0, 0xf7, 0xe8, 0x54, 0x5a, 0xa5, 0x83,
-	0x15, 0x5f, 0x7e, 0x13, 0x17, 0x96, 0xcf, 0xe2, 0x2e, 0xe5, 0x41, 0x1c, 0x39, 0x55, 0x89, 0x4f,
-	0x64, 0xd2, 0x82, 0xfa, 0x75, 0x8a, 0xac, 0x8d, 0x77, 0x41, 0x84, 0x3d, 0xa7, 0xd6, 0x2a, 0x1d,
-	0x2c, 0xfb, 0x36, 0xe4, 0xfd, 0x57, 0x86, 0xed, 0xeb, 0xa4, 0xcf, 0x68, 0x0f, 0xaf, 0x58, 0x70,
-	0x4f, 0x59, 0x80, 0xa9, 0x8f, 0x1f, 0x47, 0x98, 0x72, 0xe2, 0xc1, 0x6a, 0x27, 0x1e, 0xb1, 0x2e,
-	0x1e, 0x07, 0x51, 0x3b, 0x60, 0x4e, 0x49, 0x7a, 0x9f, 0xc2, 0x04, 0xe7, 0x3d, 0x65, 0x7d, 0xe4,
-	0x9a, 0x53, 0x56, 0x1c, 0x1b, 0x23, 0xfb, 0xf0, 0x44, 0xc9, 0xbf, 0x22, 0x4b, 0xc5, 0x35, 0xd5,
-	0xf5, 0xa7, 0x41, 0xf2, 0x12, 0x56, 0xdb, 0x94, 0xd3, 0x76, 0xc0, 0xae, 0x68, 0xc0, 0x52, 0xa7,
-	0xda, 0xaa, 0x1c, 0xd4, 0x8f, 0x1a, 0x87, 0x41, 0x2f, 0x3c, 0xb4, 0x14, 0xfe, 0x14, 0x8b, 0x34,
-	0x61, 0xe5, 0x64, 0x80, 0xdd, 0xe1, 0x65, 0x14, 0x8e, 0x75, 0x7c, 0x19, 0xa0, 0xe3, 0x3f, 0x0b,
-	0xa2, 0xe1, 0x79, 0xdc, 0x43, 0x67, 0x71, 0x12, 0xbf, 0x81, 0xc8, 0x01, 0xac, 0x9f, 0xd3, 0x94,
-	0x23, 0x3b, 0xa6, 0xdd, 0xe1, 0x28, 0x11, 0x21, 0x2c, 0xc9, 0xdb, 0xcd, 0xc2, 0xe4, 0x27, 0x70,
-	0xb3, 0x6a, 0xa4, 0xe7, 0x34, 0x49, 0x82, 0xa8, 0x7f, 0x1a, 0x84, 0x78, 0x45, 0xf9, 0xc0, 0x59,
-	0x96, 0x46, 0x8f, 0x30, 0xbc, 0x4f, 0x65, 0xa8, 0x5b, 0x57, 0x17, 0x59, 0x51, 0x99, 0xd4, 0xa0,
-	0x4e, 0xef, 0x34, 0x98, 0xe5, 0xce, 0xb0, 0xca, 0x76, 0xee, 0x0c, 0xeb, 0x05, 0x80, 0x32, 0xbb,
-	0x8a, 0x19, 0x97, 0xe9, 0xad, 0xf9, 0x16, 0x22, 0xf4, 0xca, 0x40, 0xea, 0xab, 0x4a, 0x9f, 0x21,
-	0xc4, 0x81, 0xa5, 0x93, 0x38, 0xe2, 0x18, 0x71, 0x99, 0xc3, 0x9a, 0x6f, 0x44, 0xd1, 0x71, 0xed,
-	0xe3, 0x77, 0x6d, 0x99, 0xba, 0x9a, 0x2f, 0xbf, 0xc9, 0x09, 0xd4, 0xad, 0x38, 0x9d, 0x25, 0x59,
-	0xa8, 0xbd, 0xd9, 0x42, 0x1d, 0x5a, 0x9c, 0xb7, 0x11, 0x67, 0x63, 0xdf, 0xb6, 0x72, 0x3b, 0xd0,
-	0x98, 0x25, 0x90, 0x06, 0x54, 0x86, 0x38, 0x96, 0x89, 0xa8, 0xf9, 0xe2, 0x93, 0x7c, 0x0d, 0xb5,
-	0x07, 0x1a, 0x8e, 0x50, 0x86, 0x5d, 0x3f, 0xda, 0x90, 0x87, 0x4c, 0x0f, 0x85, 0xaf, 0x18, 0xaf,
-	0xcb, 0xaf, 0x4a, 0xde, 0x36, 0x6c, 0xce, 0x37, 0x73, 0x12, 0x8e, 0xbd, 0xd7, 0xd0, 0x6c, 0x63,
-	0x88, 0xdc, 0xe4, 0x15, 0xbb, 0x3c, 0xb6, 0x5b, 0xdd, 0x85, 0xe5, 0x1e, 0xe5, 0xb4, 0x27, 0x1a,
-	0xaf, 0xd4, 0xaa, 0x88, 0x21, 0x32, 0xb2, 0xd7, 0x04, 0xb7, 0xc0, 0x56, 0x78, 0xde, 0x85, 0x1d,
-	0xa5, 0xed, 0x70, 0xca, 0xd1, 0xa8, 0xc7, 0xda, 0xb1, 0xb7, 0x03, 0xcf, 0xf3, 0xd5, 0xc2, 0xf6,
-	0x5b, 0xd8, 0x56, 0xca, 0x2c, 0x22, 0x73, 0x21, 0x02, 0x55, 0xeb, 0x32, 0xf2, 0x5b, 0x44, 0x37,
-	0x4f, 0x17, 0x7e, 0x5e, 0x82, 0xfb, 0x86, 0x75, 0x07, 0xc1, 0x03, 0x9e, 0xc5, 0xfd, 0xd9, 0x2b,
-	0x90, 0x2d, 0x58, 0xbc, 0xc0, 0xbf, 0xb3, 0x0e, 0xd3, 0x92, 0xe7, 0x82, 0x93, 0x6b, 0x25, 0x3c,
-	0xf6, 0xe1, 0xa9, 0x8f, 0x11, 0xbd, 0x47, 0x2b, 0x5e, 0xe1, 0x48, 0xf5, 0x94, 0x71, 0xa4, 0x24,
-	0x81, 0xab, 0x5e, 0xd2, 0xcd, 0xa9, 0x25, 0xb1, 0x1b, 0x94, 0x13, 0xad, 0xad, 0xc8, 0xf1, 0x9b,
-	0xc2, 0xbc, 0x53, 0x70, 0xe6, 0x0e, 0x32, 0x17, 0xff, 0x06, 0xaa, 0x6d, 0x93, 0x83, 0xfa, 0xd1,
-	0x96, 0xac, 0xfd, 0x3c, 0x59, 0x72, 0x3c, 0x07, 0xb6, 0x72, 0xfc, 0x88, 0x50, 0x08, 0x34, 0x3a,
-	0x3c, 0x4e, 0xde, 0x88, 0xed, 0x6a, 0xaa, 0xd2, 0x80, 0x35, 0x0b, 0x13, 0xac, 0xdf, 0xa0, 0x29,
-	0xd7, 0x46, 0x07, 0xfb, 0xf7, 0x18, 0xf1, 0x76, 0x90, 0x0e, 0x3b, 0x76, 0x3d, 0xf6, 0xe1, 0x49,
-	0x2f, 0x48, 0x87, 0xa7, 0x0c, 0xd1, 0x17, 0xbb, 0x55, 0xa6, 0xa0, 0xe4, 0x4f, 0x83, 0x93, 0xaa,
-	0x95, 0xad, 0xaa, 0xfd, 0x5b, 0x82, 0x0d, 0xe9, 0xda, 0xf2, 0x99, 0x84, 0x63, 0xf2, 0x0a, 0x6a,
-	0xa3, 0x94, 0xf6, 0x51, 0x87, 0xe7, 0xc9, 0xf0, 0x72, 0x88, 0x87, 0x42, 0xbc, 0x16, 0x4c, 0x5f,
-	0x19, 0xb8, 0x01, 0xac, 0x4c, 0x30, 0xb2, 0x06, 0xe5, 0xbb, 0x54, 0x17, 0xa4, 0x7c, 0x97, 0x8a,
-	0x2b, 0x0c, 0xe2, 0xd4, 0x94, 0x42, 0x7e, 0x8b, 0x25, 0x49, 0x1f, 0x68, 0x10, 0x8a, 0xb6, 0x91,
-	0x55, 0xa8, 0xfa, 0x19, 0x20, 0x7a, 0x9f, 0xe1, 0xc7, 0x51, 0xc0, 0xb0, 0x27, 0x57, 0x43, 0xd5,
-	0x9f, 0xc8, 0x5e, 0x0c, 0x2b, 0x7e, 0x3a, 0x8e, 0xba, 0x72, 0x63, 0x15, 0xd5, 0xff, 0x00, 0xd6,
-	0xdb, 0x98, 0xf2, 0x20, 0x92, 0x8f, 0xce, 0xcf, 0xd9, 0xe9, 0xb3, 0xb0, 0xd8, 0xc7, 0x16, 0xa4,
-	0xdf, 0x01, 0x1b, 0xf2, 0xfe, 0x82, 0x55, 0x79, 0xa0, 0xc9, 0xbb, 0x03, 0x4b, 0x97, 0x89, 0xd0,
-	0x98, 0x51, 0x30, 0xa2, 0xb8, 0xf6, 0xdb, 0x7f, 0xba, 0xe1, 0xa8, 0x87, 0x26, 0xdf, 0x13, 0x99,
-	0xec, 0x43, 0x4d, 0x3d, 0x22, 0x15, 0x99, 0xdb, 0x35, 0xd5, 0x3a, 0x26, 0x10, 0x5f, 0x29, 0xbd,
-	0x55, 0x00, 0x7d, 0x96, 0xe8, 0x80, 0xef, 0x61, 0xdb, 0xc7, 0x94, 0xc7, 0x0c, 0xaf, 0xfa, 0x62,
-	0xfb, 0xb1, 0x38, 0xfc, 0x9c, 0xed, 0xb0, 0x0d, 0x9b, 0xf3, 0x66, 0x49, 0x38, 0x3e, 0xfa, 0xb4,
-	0x04, 0x35, 0xd9, 0x60, 0xe4, 0x12, 0xd6, 0xa6, 0xeb, 0x4a, 0xf6, 0xb2, 0x62, 0x17, 0x34, 0x9c,
-	0xeb, 0x14, 0xf5, 0x83, 0xb7, 0x40, 0x2e, 0xa0, 0x31, 0xbb, 0xe6, 0x48, 0x53, 0xf2, 0x0b, 0x9e,
-	0x72, 0xd7, 0x2d, 0xd0, 0x2a, 0x7f, 0xbf, 0xe4, 0x4d, 0xfb, 0x6e, 0xc1, 0xbc, 0x69, 0x8f, 0x3b,
-	0x45, 0x6a, 0xe5, 0xf2, 0x07, 0x58, 0x99, 0x4c, 0x18, 0xd9, 0x94, 0xdc, 0xd9, 0x29, 0x74, 0x37,
-	0x66, 0x61, 0x65, 0xfa, 0xa7, 0x59, 0x73, 0x33, 0xfb, 0x56, 0x67, 0xed, 0xb1, 0x3d, 0xee, 0x7e,
-	0xf5, 0x18, 0x45, 0xb9, 0xff, 0x03, 0x9e, 0xe5, 0x6d, 0x64, 0xd2, 0xb2, 0x4c, 0x73, 0x77, 0xb9,
-	0xfb, 0xe2, 0x11, 0x86, 0xf2, 0xfd, 0xbb, 0x79, 0x0c, 0xb2, 0x0d, 0x6d, 0x07, 0xd0, 0xb4, 0x1c,
-	0xcc, 0xad, 0x7c, 0x5d, 0xa3, 0xfc, 0x0d, 0xbf, 0x40, 0x6e, 0x60, 0x4f, 0x9f, 0x2c, 0x87, 0xee,
-	0xcb, 0x1f, 0xf0, 0x01, 0x36, 0x72, 0x9e, 0x03, 0xa2, 0x32, 0x5a, 0xfc, 0xbc, 0xb8, 0xbb, 0xc5,
-	0x04, 0xe5, 0xf8, 0x47, 0x78, 0x26, 0xc7, 0x6c, 0xb6, 0x9c, 0x4f, 0xb3, 0xa9, 0x34, 0xbe, 0xd6,
-	0x6d, 0x48, 0x59, 0x1f, 0x83, 0x2b, 0xe5, 0xfc, 0x80, 0x3f, 0xcf, 0xc7, 0x07, 0x78, 0x6e, 0x66,
-	0xd4, 0xb4, 0xfe, 0x64, 0x58, 0x75, 0xce, 0x0a, 0x46, 0x5f, 0xe7, 0x2c, 0x77, 0xc2, 0xbd, 0x85,
-	0xdb, 0x45, 0xf9, 0xb7, 0xfe, 0xdd, 0xff, 0x01, 0x00, 0x00, 0xff, 0xff, 0xdf, 0xeb, 0x01, 0xf7,
-	0xc3, 0x0b, 0x00, 0x00,
+	// 1741 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x58, 0x6d, 0x4f, 0x1b, 0xcf,
+	0x11, 0xc7, 0x4f, 0xd8, 0x1e, 0x8c, 0x63, 0x16, 0x0c, 0x97, 0xc3, 0x24, 0x70, 0xca, 0x0b, 0xfa,
+	0x57, 0x85, 0xfe, 0x22, 0x79, 0x91, 0xa6, 0x0f, 0x52, 0xc0, 0x44, 0x20, 0x01, 0xa1, 0x0b, 0x49,
+	0x9a, 0xaa, 0x29, 0x3d, 0xec, 0xc5, 0xbe, 0x72, 0xbe, 0x73, 0xee, 0xd6, 0x34, 0xee, 0xab, 0x7e,
+	0x87, 0x48, 0x55, 0xd5, 0x2f, 0x51, 0xa9, 0x2f, 0xfb, 0x6d, 0xfa, 0x4d, 0xaa, 0xd9, 0x87, 0xf3,
+	0x9d, 0x7d, 0x67, 0x81, 0xd4, 0xff, 0x2b, 0xdf, 0xcc, 0xce, 0xce, 0xce, 0xfc, 0x76, 0xe7, 0xc9,
+	0x40, 0xfa, 0xa3, 0x9b, 0x6b, 0xee, 0x5f, 0xdb, 0x3d, 0xe6, 0xf1, 0xbd, 0x61, 0xe0, 0x73, 0x9f,
+	0x14, 0x9c, 0xae, 0x6b, 0xdd, 0x40, 0xfd, 0xca, 0xbe, 0x71, 0x59, 0x38, 0xb4, 0x3b, 0xec, 0xc4,
+	0xbb, 0xf5, 0x09, 0x81, 0xe2, 0xb9, 0x3d, 0x60, 0x46, 0x61, 0x3b, 0xb7, 0x5b, 0xa5, 0xe2, 0x9b,
+	0x98, 0x50, 0x39, 0xf5, 0x3b, 0x36, 0x77, 0x7c, 0xcf, 0x28, 0x0a, 0x7e, 0x44, 0x93, 0x6d, 0x58,
+	0xfa, 0x10, 0xb2, 0xa0, 0xcd, 0x6e, 0x1d, 0x8f, 0x75, 0x8d, 0xd2, 0x76, 0x6e, 0xb7, 0x42, 0xe3,
+	0x2c, 0xeb, 0x7b, 0x01, 0x36, 0x3e, 0x0c, 0x7b, 0x81, 0xdd, 0x65, 0x17, 0x81, 0x33, 0xb0, 0x03,
+	0x87, 0x85, 0x94, 0x7d, 0x1d, 0xb1, 0x90, 0x13, 0x0b, 0x6a, 0x97, 0xfe, 0x28, 0xe8, 0xb0, 0x03,
+	0xc7, 0x6b, 0x3b, 0x81, 0x91, 0x13, 0xda, 0x13, 0x3c, 0x94, 0xb9, 0xb2, 0x83, 0x1e, 0xe3, 0x4a,
+	0x26, 0x2f, 0x65, 0xe2, 0x3c, 0xf2, 0x02, 0x96, 0x25, 0xfd, 0x91, 0x05, 0x21, 0x9a, 0x29, 0xcd,
+	0x4f, 0x32, 0xc9, 0x2b, 0xa8, 0xb5, 0x6d, 0x6e, 0xb7, 0x9d, 0xe0, 0xc2, 0x76, 0x82, 0xd0, 0x28,
+	0x6e, 0x17, 0x76, 0x97, 0xf6, 0x1b, 0x7b, 0x4e, 0xd7, 0xdd, 0x8b, 0x2d, 0xd0, 0x84, 0x14, 0x69,
+	0x41, 0xf5, 0xb0, 0xcf, 0x3a, 0x77, 0xef, 0x3d, 0x77, 0xac, 0xfc, 0x9b, 0x30, 0x94, 0xff, 0xa7,
+	0x8e, 0x77, 0x77, 0xe6, 0x77, 0x99, 0xb1, 0x18, 0xf9, 0xaf, 0x59, 0x64, 0x17, 0x9e, 0x9c, 0xd9,
+	0x21, 0x67, 0xc1, 0x81, 0xdd, 0xb9, 0x1b, 0x0d, 0xd1, 0x85, 0xb2, 0xb0, 0x6e, 0x9a, 0x4d, 0x7e,
+	0x03, 0xe6, 0xe4, 0x36, 0xc2, 0x33, 0x7b, 0x38, 0x74, 0xbc, 0xde, 0x3b, 0xc7, 0x65, 0x17, 0x36,
+	0xef, 0x1b, 0x15, 0xb1, 0x69, 0x8e, 0x04, 0xf9, 0x01, 0x1a, 0x27, 0x5d, 0x36, 0x18, 0xfa, 0x9c,
+	0x79, 0x9d, 0xf1, 0x95, 0x7f, 0xc7, 0x3c, 0xa3, 0x2a, 0x76, 0xcd, 0xf0, 0xad, 0xff, 0xe6, 0x61,
+	0x29, 0xe6, 0x26, 0x22, 0x28, 0x51, 0x57, 0x4c, 0x75, 0x15, 0x49, 0xe6, 0x04, 0x67, 0x2d, 0x95,
+	0x8f, 0xe3, 0xac, 0xa5, 0x9e, 0x01, 0xc8, 0x6d, 0x17, 0x7e, 0xc0, 0xc5, 0x55, 0x94, 0x68, 0x8c,
+	0x83, 0xeb, 0x72, 0x83, 0x58, 0x2f, 0xca, 0xf5, 0x09, 0x87, 0x18, 0x50, 0x3e, 0xf4, 0x3d, 0xce,
+	0x3c, 0x2e, 0xf0, 0x2e, 0x51, 0x4d, 0xe2, 0xeb, 0x6c, 0x1f, 0x9c, 0xb4, 0x05, 0xcc, 0x25, 0x2a,
+	0xbe, 0xc9, 0x21, 0x2c, 0xc5, 0x30, 0x31, 0xca, 0xe2, 0x52, 0x77, 0xa6, 0x2f, 0x75, 0x2f, 0x26,
+	0x73, 0xe4, 0xf1, 0x60, 0x4c, 0xe3, 0xbb, 0xcc, 0x4b, 0x68, 0x4c, 0x0b, 0x90, 0x06, 0x14, 0xee,
+	0xd8, 0x58, 0x00, 0x51, 0xa2, 0xf8, 0x49, 0x7e, 0x06, 0xa5, 0x7b, 0xdb, 0x1d, 0x31, 0xe1, 0xf6,
+	0xd2, 0xfe, 0xaa, 0x38, 0x24, 0x19, 0x40, 0x54, 0x4a, 0xbc, 0xc9, 0xbf, 0xce, 0x59, 0xff, 0xcc,
+	0x41, 0x73, 0xf6, 0xe5, 0x0f, 0xdd, 0x31, 0x69, 0x63, 0x44, 0x89, 0x8b, 0x0b, 0x8d, 0x9c, 0x30,
+	0x78, 0x57, 0xe8, 0x4a, 0x95, 0xde, 0xd3, 0xa2, 0xd2, 0xee, 0x68, 0xa7, 0xf9, 0x4b, 0x58, 0x4e,
+	0x2c, 0xa5, 0x58, 0xbc, 0x16, 0xb7, 0xb8, 0x1a, 0x37, 0xee, 0x6f, 0x39, 0x68, 0xb5, 0x99, 0xcb,
+	0xb8, 0xbe, 0x5c, 0xd6, 0xe1, 0x7e, 0x3c, 0x36, 0x4d, 0xa8, 0x74, 0x6d, 0x6e, 0x77, 0x9d, 0x40,
+	0xda, 0x58, 0xa5, 0x11, 0x8d, 0x37, 0x74, 0xaf, 0x22, 0x4d, 0x2a, 0xd6, 0x64, 0xea, 0x1b, 0x2c,
+	0x64, 0xbc, 0xc1, 0x16, 0x98, 0x19, 0x16, 0x0c, 0xdd, 0xb1, 0xf5, 0x06, 0x5a, 0x1f, 0x6d, 0xd7,
+	0xe9, 0xda, 0xc9, 0xf5, 0xf1, 0x03, 0xec, 0xb3, 0x28, 0x98, 0x19, 0x7b, 0x11, 0xfd, 0x57, 0x50,
+	0xa6, 0x2c, 0x1c, 0xb9, 0x5c, 0x83, 0x6f, 0xc6, 0x5f, 0x8b, 0x94, 0x14, 0xdb, 0x1d, 0x3e, 0xa6,
+	0x5a, 0xd4, 0xba, 0x86, 0x66, 0xaa, 0x04, 0x82, 0x91, 0x0c, 0x1a, 0x4d, 0x22, 0xfa, 0x42, 0x4a,
+	0x80, 0x54, 0xa1, 0x92, 0x20, 0xeb, 0xb0, 0x48, 0x99, 0x1d, 0x46, 0x59, 0x4a, 0x51, 0xd6, 0x11,
+	0x2c, 0x5f, 0x04, 0x7e, 0x2f, 0x60, 0x61, 0x78, 0x74, 0x8f, 0xaf, 0xdd, 0x80, 0xf2, 0x19, 0x0b,
+	0x43, 0xbb, 0xc7, 0xb4, 0x62, 0x45, 0xa2, 0xef, 0xef, 0x02, 0xbb, 0xc3, 0xf5, 0x05, 0xe4, 0x68,
+	0x44, 0x5b, 0x5b, 0xb0, 0x29, 0x51, 0xbd, 0xe4, 0xe8, 0xfe, 0x14, 0x6c, 0xd6, 0x26, 0x3c, 0x4d,
+	0x5f, 0x46, 0xcc, 0x3f, 0xc3, 0x86, 0x5c, 0x9c, 0x3c, 0x6a, 0x0d, 0x37, 0x81, 0x62, 0x0c, 0x6a,
+	0xf1, 0x9d, 0x7a, 0xd9, 0xf9, 0x8c, 0xcb, 0xde, 0x80, 0xe6, 0xac, 0x6a, 0x3c, 0xf3, 0x4f, 0x60,
+	0xbe, 0x0d, 0x3a, 0x7d, 0xe7, 0x9e, 0x9d, 0xfa, 0xbd, 0x99, 0x5b, 0x5e, 0x87, 0xc5, 0x73, 0xf6,
+	0x97, 0x09, 0xb6, 0x8a, 0x7a, 0xd4, 0xd1, 0x26, 0x18, 0xa9, 0x27, 0xe0, 0xe9, 0x3d, 0x58, 0xa1,
+	0xcc, 0xb3, 0x07, 0x2c, 0xf6, 0xfe, 0xf0, 0x50, 0x99, 0xae, 0xf4, 0xa1, 0x92, 0x42, 0xbe, 0x4c,
+	0x53, 0xea, 0x28, 0x45, 0x61, 0x89, 0x92, 0x4a, 0xd4, 0x6a, 0x41, 0x5c, 0x77, 0x82, 0x67, 0xfd,
+	0x23, 0x07, 0xc6, 0xcc, 0x49, 0xda, 0xcb, 0x1f, 0xa0, 0xd8, 0xd6, 0xe0, 0x2e, 0xed, 0xaf, 0x8b,
+	0xe7, 0x38, 0x2b, 0x2c, 0x64, 0x30, 0x07, 0x1f, 0xfa, 0xc3, 0x31, 0xb5, 0x39, 0x3b, 0x75, 0x06,
+	0x8e, 0xb4, 0xa5, 0x40, 0x93, 0xcc, 0x47, 0xc5, 0xa1, 0x01, 0xeb, 0x29, 0x96, 0x21, 0x3a, 0x47,
+	0xb0, 0x4a, 0xd9, 0x3d, 0x0b, 0x78, 0xe2, 0xe5, 0x3f, 0x16, 0x1f, 0xeb, 0x1b, 0xb4, 0x66, 0xd5,
+	0xc4, 0xdc, 0xff, 0x79, 0xc2, 0x7d, 0x43, 0xb9, 0x3f, 0x73, 0xae, 0x02, 0xe0, 0x31, 0x57, 0xff,
+	0x05, 0x9e, 0xa6, 0x29, 0x12, 0x21, 0x9d, 0xe9, 0xc6, 0x2e, 0x3c, 0x39, 0xf7, 0x79, 0xdf, 0xf1,
+	0x7a, 0x57, 0xbe, 0xdc, 0xad, 0x02, 0x78, 0x9a, 0x6d, 0xfd, 0x19, 0xcc, 0x0c, 0xc7, 0x30, 0xcf,
+	0x10, 0x28, 0x1e, 0xfb, 0x21, 0x57, 0xda, 0xc5, 0x37, 0x79, 0x3d, 0xc9, 0x3d, 0x79, 0xe1, 0xed,
+	0xb3, 0x4c, 0x6f, 0x85, 0xd8, 0x24, 0xff, 0x10, 0x68, 0x5c, 0x72, 0x7f, 0xf8, 0x16, 0x7b, 0x38,
+	0x1d, 0xcc, 0x0d, 0xa8, 0xc7, 0x78, 0x78, 0x63, 0xbf, 0x83, 0x96, 0x68, 0x4e, 0x2e, 0x59, 0x6f,
+	0xc0, 0x3c, 0xde, 0x76, 0xc2, 0xbb, 0xcb, 0x78, 0x18, 0xbf, 0x80, 0xe5, 0xae, 0x13, 0xde, 0xbd,
+	0x0b, 0x18, 0xa3, 0xd8, 0xc1, 0x09, 0xe3, 0x72, 0x34, 0xc9, 0x8c, 0x82, 0x3d, 0x3f, 0x09, 0x76,
+	0xeb, 0x3f, 0x39, 0x58, 0x15, 0xaa, 0x63, 0x3a, 0xd1, 0xcb, 0xd7, 0x50, 0x1a, 0xa9, 0x1c, 0x85,
+	0xfe, 0x58, 0xc2, 0x9f, 0x14, 0xc1, 0x3d, 0x24, 0x3f, 0xa0, 0x24, 0x95, 0x1b, 0x4c, 0x07, 0xaa,
+	0x11, 0x8f, 0xd4, 0x21, 0x7f, 0x1b, 0x2a, 0xa8, 0xf2, 0xb7, 0x21, 0x9a, 0xd0, 0xf7, 0x43, 0x89,
+	0x7c, 0x95, 0x8a, 0x6f, 0x6c, 0xc5, 0xec, 0x7b, 0xdb, 0x71, 0x31, 0x83, 0x88, 0xd7, 0x5c, 0xa4,
+	0x13, 0x06, 0x26, 0xc5, 0x80, 0x7d, 0x1d, 0x39, 0x01, 0xeb, 0x8a, 0xa6, 0xa2, 0x48, 0x23, 0xda,
+	0xba, 0x84, 0xa6, 0x30, 0x09, 0x5d, 0x4c, 0xe0, 0xb1, 0x06, 0x25, 0xec, 0x9d, 0x74, 0x5e, 0x93,
+	0x04, 0xa2, 0x44, 0xd5, 0xd6, 0x83, 0x31, 0x67, 0xa1, 0xb0, 0xa2, 0x48, 0x93, 0x4c, 0xeb, 0xdf,
+	0x1a, 0x91, 0x98, 0x56, 0x85, 0xc8, 0x44, 0x67, 0x02, 0x91, 0xa4, 0xe0, 0x1e, 0x4a, 0x49, 0x52,
+	0x9d, 0x6b, 0x41, 0xed, 0xc4, 0x0b, 0x47, 0xb7, 0xb7, 0x4e, 0xc7, 0xc1, 0xf6, 0x47, 0xe2, 0x9f,
+	0xe0, 0x99, 0xbf, 0x86, 0x6a, 0xb4, 0x0f, 0x51, 0x42, 0x42, 0x3f, 0x31, 0xfc, 0x46, 0x94, 0xde,
+	0x46, 0x28, 0x49, 0xc3, 0x27, 0x0c, 0xcb, 0x87, 0x2a, 0x0d, 0xc7, 0x5e, 0x47, 0x74, 0x7d, 0x73,
+	0x22, 0xa0, 0xcd, 0x42, 0xee, 0x78, 0xa2, 0xc9, 0x3f, 0x9e, 0xdc, 0xc3, 0x34, 0x1b, 0xfb, 0xdf,
+	0x18, 0x4b, 0xa5, 0x98, 0x38, 0xcb, 0xfa, 0x7b, 0x0e, 0x6a, 0xe2, 0x44, 0x0d, 0xb9, 0x01, 0xe5,
+	0xf7, 0x43, 0x5c, 0xd2, 0xa0, 0x6b, 0x12, 0x6f, 0xf0, 0xe8, 0x5b, 0xc7, 0x1d, 0x75, 0x99, 0x7e,
+	0x7a, 0x11, 0x4d, 0x5e, 0x20, 0xa8, 0xf8, 0x26, 0x0b, 0x02, 0xd4, 0xba, 0x0c, 0x1b, 0xed, 0x09,
+	0x95, 0x8b, 0xa9, 0xb9, 0xa1, 0x98, 0x91, 0x1b, 0x6a, 0x00, 0xca, 0x2e, 0x0c, 0x9c, 0xef, 0x79,
+	0x68, 0x0a, 0x32, 0xad, 0x04, 0xfd, 0xd4, 0x20, 0xe1, 0x19, 0xb2, 0x3a, 0x0a, 0x6b, 0x2b, 0x54,
+	0x51, 0x88, 0x88, 0x78, 0x38, 0xe1, 0x68, 0xa0, 0x66, 0x8f, 0x88, 0x16, 0x6b, 0xfe, 0x60, 0x18,
+	0xb0, 0x30, 0x54, 0x73, 0x47, 0x44, 0x27, 0x90, 0x2c, 0x4f, 0x21, 0x99, 0x86, 0x51, 0x25, 0x03,
+	0xa3, 0x26, 0xac, 0x4e, 0x83, 0x82, 0x60, 0xd9, 0xb0, 0x41, 0x59, 0xc8, 0xfd, 0x80, 0x5d, 0xf4,
+	0xb0, 0x37, 0x0f, 0x7c, 0xf7, 0x21, 0x6d, 0xe3, 0x23, 0xfb, 0x85, 0xd9, 0x23, 0xf0, 0xec, 0x06,
+	0xd4, 0xd5, 0x40, 0xa7, 0xb3, 0xe0, 0x2e, 0xd4, 0x22, 0x0e, 0xc6, 0x9f, 0x01, 0x65, 0x45, 0xeb,
+	0xbe, 0x49, 0x91, 0xb8, 0xf7, 0x98, 0xd9, 0x2e, 0xef, 0xff, 0x55, 0xef, 0xfd, 0x03, 0xd4, 0x22,
+	0x8e, 0xda, 0x2b, 0x69, 0xd9, 0x46, 0x57, 0xa8, 0x26, 0xd1, 0x31, 0xdd, 0x32, 0x29, 0xa3, 0x23,
+	0x3a, 0xb3, 0xa5, 0x6b, 0xe0, 0x7c, 0xed, 0xb8, 0xa7, 0x7e, 0x4f, 0x9f, 0xb7, 0x05, 0xe5, 0x53,
+	0xbf, 0x77, 0xea, 0x78, 0x22, 0x76, 0xf1, 0x57, 0xc7, 0x2e, 0x7e, 0xef, 0xff, 0xab, 0x06, 0x25,
+	0x91, 0xcd, 0xc9, 0x7b, 0xa8, 0x27, 0x93, 0x28, 0xd9, 0x99, 0xe4, 0x91, 0x8c, 0xec, 0x6e, 0x1a,
+	0x59, 0xc9, 0xd7, 0x5a, 0x20, 0xc7, 0x50, 0x4f, 0xe6, 0x20, 0x62, 0xa6, 0x26, 0xa6, 0x19, 0x4d,
+	0xc9, 0xa4, 0x65, 0x2d, 0x90, 0x73, 0x68, 0x4c, 0x0f, 0x2a, 0xa4, 0x95, 0x31, 0xbf, 0x48, 0x6d,
+	0x66, 0xf6, 0x74, 0x63, 0x2d, 0x90, 0xdf, 0xa6, 0xf5, 0x60, 0x5b, 0x19, 0x4d, 0x90, 0xd2, 0xb8,
+	0x99, 0xb5, 0x2c, 0x55, 0x7e, 0x81, 0xe6, 0x6c, 0x49, 0x45, 0xb5, 0x3b, 0x19, 0xe5, 0x36, 0xa6,
+	0xfa, 0xf9, 0x3c, 0x11, 0xa9, 0xfe, 0x17, 0x50, 0x8d, 0xea, 0x2e, 0x69, 0x0a, 0xf9, 0xe9, 0xda,
+	0x6c, 0xae, 0x4e, 0xb3, 0x23, 0xcb, 0x52, 0x87, 0x1e, 0x65, 0xd9, 0xbc, 0x91, 0xcc, 0x7c, 0x3e,
+	0x4f, 0x44, 0xaa, 0xff, 0x23, 0xec, 0xa4, 0xae, 0x7f, 0x72, 0x78, 0x5f, 0x4f, 0x17, 0x0f, 0x39,
+	0x8a, 0x08, 0x91, 0xc4, 0x3c, 0x62, 0x2d, 0xfc, 0x98, 0x43, 0xf3, 0x53, 0x27, 0x2b, 0xa5, 0x73,
+	0xde, 0xc4, 0x66, 0x3e, 0x9f, 0x27, 0x22, 0xcd, 0xff, 0x3d, 0xac, 0xa5, 0x4d, 0x27, 0x64, 0x3b,
+	0x66, 0x71, 0xea, 0x5c, 0x63, 0x3e, 0x9b, 0x23, 0x21, 0x75, 0x7f, 0x86, 0xcd, 0xe9, 0x09, 0x24,
+	0x8e, 0x7f, 0x2b, 0xa6, 0x60, 0x66, 0xfc, 0x31, 0xcd, 0x8c, 0x55, 0xa9, 0xfa, 0x5a, 0xa3, 0x2e,
+	0x4b, 0xc3, 0xff, 0xff, 0x80, 0x4f, 0xb0, 0x9a, 0x32, 0xc2, 0x10, 0x89, 0x68, 0xf6, 0xf8, 0x64,
+	0x6e, 0x65, 0x0b, 0x48, 0xc5, 0xbf, 0x82, 0x35, 0x99, 0xe0, 0xa7, 0x5e, 0xe3, 0xca, 0xa4, 0xbe,
+	0x6a, 0x5d, 0x4f, 0xe2, 0x2c, 0xb9, 0xfb, 0x00, 0x4c, 0x41, 0xa7, 0x3b, 0xfc, 0x30, 0x1d, 0xc7,
+	0x50, 0x4f, 0x96, 0x18, 0x95, 0x97, 0x52, 0x8b, 0xb1, 0x69, 0xa4, 0xae, 0x69, 0x90, 0x9e, 0xea,
+	0x92, 0xa1, 0x53, 0x4c, 0x54, 0x3b, 0x14, 0xfa, 0x19, 0x55, 0xcb, 0x34, 0x33, 0x56, 0xa5, 0xe2,
+	0x97, 0x51, 0x41, 0x21, 0x32, 0xaa, 0x93, 0x05, 0xc8, 0x5c, 0x49, 0x32, 0xa3, 0x4d, 0xaa, 0xb2,
+	0xa8, 0x4d, 0xc9, 0xca, 0x63, 0xae, 0x24, 0x99, 0x72, 0xd3, 0x8f, 0x50, 0x56, 0x05, 0x83, 0xe8,
+	0x7f, 0x97, 0xe2, 0xe5, 0xc3, 0xac, 0x09, 0xa6, 0xaa, 0x20, 0x18, 0x90, 0x37, 0x8b, 0xe2, 0xef,
+	0xdc, 0x97, 0xff, 0x1b, 0x00, 0xdb, 0xf4, 0xf1, 0x09, 0xe4, 0x15, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1227,17 +2229,25 @@ const _ = grpc.SupportPackageIsVersion4
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type AgentClient interface {
 	CheckDiskSpace(ctx context.Context, in *CheckSegmentDiskSpaceRequest, opts ...grpc.CallOption) (*CheckDiskSpaceReply, error)
+	CheckFreeSpace(ctx context.Context, in *CheckFreeSpaceRequest, opts ...grpc.CallOption) (*CheckFreeSpaceReply, error)
 	UpgradePrimaries(ctx context.Context, in *UpgradePrimariesRequest, opts ...grpc.CallOption) (*UpgradePrimariesReply, error)
 	RenameDirectories(ctx context.Context, in *RenameDirectoriesRequest, opts ...grpc.CallOption) (*RenameDirectoriesReply, error)
+	RevertDataDirectories(ctx context.Context, in *RevertDataDirectoriesRequest, opts ...grpc.CallOption) (*RevertDataDirectoriesReply, error)
 	StopAgent(ctx context.Context, in *StopAgentRequest, opts ...grpc.CallOption) (*StopAgentReply, error)
 	DeleteDataDirectories(ctx context.Context, in *DeleteDataDirectoriesRequest, opts ...grpc.CallOption) (*DeleteDataDirectoriesReply, error)
+	DeleteDataDirectoriesWithProgress(ctx context.Context, in *DeleteDataDirectoriesRequest, opts ...grpc.CallOption) (Agent_DeleteDataDirectoriesWithProgressClient, error)
+	ValidateDataDirectory(ctx context.Context, in *ValidateDataDirectoryRequest, opts ...grpc.CallOption) (*ValidateDataDirectoryReply, error)
 	DeleteStateDirectory(ctx context.Context, in *DeleteStateDirectoryRequest, opts ...grpc.CallOption) (*DeleteStateDirectoryReply, error)
 	DeleteTablespaceDirectories(ctx context.Context, in *DeleteTablespaceRequest, opts ...grpc.CallOption) (*DeleteTablespaceReply, error)
 	DeleteSourceTablespaceDirectories(ctx context.Context, in *DeleteTablespaceRequest, opts ...grpc.CallOption) (*DeleteTablespaceReply, error)
 	ArchiveLogDirectory(ctx context.Context, in *ArchiveLogDirectoryRequest, opts ...grpc.CallOption) (*ArchiveLogDirectoryReply, error)
 	RsyncDataDirectories(ctx context.Context, in *RsyncRequest, opts ...grpc.CallOption) (*RsyncReply, error)
 	RsyncTablespaceDirectories(ctx context.Context, in *RsyncRequest, opts ...grpc.CallOption) (*RsyncReply, error)
+	RsyncDirectory(ctx context.Context, in *RsyncDirectoryRequest, opts ...grpc.CallOption) (*RsyncDirectoryReply, error)
 	RestorePrimariesPgControl(ctx context.Context, in *RestorePgControlRequest, opts ...grpc.CallOption) (*RestorePgControlReply, error)
+	Version(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*VersionReply, error)
+	Healthz(ctx context.Context, in *HealthzRequest, opts ...grpc.CallOption) (*HealthzReply, error)
+	TailLog(ctx context.Context, in *TailLogRequest, opts ...grpc.CallOption) (Agent_TailLogClient, error)
 }
 
 type agentClient struct {
@@ -1257,6 +2267,15 @@ func (c *agentClient) CheckDiskSpace(ctx context.Context, in *CheckSegmentDiskSp
 	return out, nil
 }
 
+func (c *agentClient) CheckFreeSpace(ctx context.Context, in *CheckFreeSpaceRequest, opts ...grpc.CallOption) (*CheckFreeSpaceReply, error) {
+	out := new(CheckFreeSpaceReply)
+	err := c.cc.Invoke(ctx, "/idl.Agent/CheckFreeSpace", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *agentClient) UpgradePrimaries(ctx context.Context, in *UpgradePrimariesRequest, opts ...grpc.CallOption) (*UpgradePrimariesReply, error) {
 	out := new(UpgradePrimariesReply)
 	err := c.cc.Invoke(ctx, "/idl.Agent/UpgradePrimaries", in, out, opts...)
@@ -1275,6 +2294,15 @@ func (c *agentClient) RenameDirectories(ctx context.Context, in *RenameDirectori
 	return out, nil
 }
 
+func (c *agentClient) RevertDataDirectories(ctx context.Context, in *RevertDataDirectoriesRequest, opts ...grpc.CallOption) (*RevertDataDirectoriesReply, error) {
+	out := new(RevertDataDirectoriesReply)
+	err := c.cc.Invoke(ctx, "/idl.Agent/RevertDataDirectories", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *agentClient) StopAgent(ctx context.Context, in *StopAgentRequest, opts ...grpc.CallOption) (*StopAgentReply, error) {
 	out := new(StopAgentReply)
 	err := c.cc.Invoke(ctx, "/idl.Agent/StopAgent", in, out, opts...)
@@ -1293,6 +2321,47 @@ func (c *agentClient) DeleteDataDirectories(ctx context.Context, in *DeleteDataD
 	return out, nil
 }
 
+func (c *agentClient) DeleteDataDirectoriesWithProgress(ctx context.Context, in *DeleteDataDirectoriesRequest, opts ...grpc.CallOption) (Agent_DeleteDataDirectoriesWithProgressClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Agent_serviceDesc.Streams[0], "/idl.Agent/DeleteDataDirectoriesWithProgress", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &agentDeleteDataDirectoriesWithProgressClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Agent_DeleteDataDirectoriesWithProgressClient interface {
+	Recv() (*ProgressEvent, error)
+	grpc.ClientStream
+}
+
+type agentDeleteDataDirectoriesWithProgressClient struct {
+	grpc.ClientStream
+}
+
+func (x *agentDeleteDataDirectoriesWithProgressClient) Recv() (*ProgressEvent, error) {
+	m := new(ProgressEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *agentClient) ValidateDataDirectory(ctx context.Context, in *ValidateDataDirectoryRequest, opts ...grpc.CallOption) (*ValidateDataDirectoryReply, error) {
+	out := new(ValidateDataDirectoryReply)
+	err := c.cc.Invoke(ctx, "/idl.Agent/ValidateDataDirectory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *agentClient) DeleteStateDirectory(ctx context.Context, in *DeleteStateDirectoryRequest, opts ...grpc.CallOption) (*DeleteStateDirectoryReply, error) {
 	out := new(DeleteStateDirectoryReply)
 	err := c.cc.Invoke(ctx, "/idl.Agent/DeleteStateDirectory", in, out, opts...)
@@ -1347,6 +2416,15 @@ func (c *agentClient) RsyncTablespaceDirectories(ctx context.Context, in *RsyncR
 	return out, nil
 }
 
+func (c *agentClient) RsyncDirectory(ctx context.Context, in *RsyncDirectoryRequest, opts ...grpc.CallOption) (*RsyncDirectoryReply, error) {
+	out := new(RsyncDirectoryReply)
+	err := c.cc.Invoke(ctx, "/idl.Agent/RsyncDirectory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *agentClient) RestorePrimariesPgControl(ctx context.Context, in *RestorePgControlRequest, opts ...grpc.CallOption) (*RestorePgControlReply, error) {
 	out := new(RestorePgControlReply)
 	err := c.cc.Invoke(ctx, "/idl.Agent/RestorePrimariesPgControl", in, out, opts...)
@@ -1356,20 +2434,78 @@ func (c *agentClient) RestorePrimariesPgControl(ctx context.Context, in *Restore
 	return out, nil
 }
 
+func (c *agentClient) Version(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*VersionReply, error) {
+	out := new(VersionReply)
+	err := c.cc.Invoke(ctx, "/idl.Agent/Version", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentClient) Healthz(ctx context.Context, in *HealthzRequest, opts ...grpc.CallOption) (*HealthzReply, error) {
+	out := new(HealthzReply)
+	err := c.cc.Invoke(ctx, "/idl.Agent/Healthz", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentClient) TailLog(ctx context.Context, in *TailLogRequest, opts ...grpc.CallOption) (Agent_TailLogClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Agent_serviceDesc.Streams[1], "/idl.Agent/TailLog", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &agentTailLogClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Agent_TailLogClient interface {
+	Recv() (*LogLine, error)
+	grpc.ClientStream
+}
+
+type agentTailLogClient struct {
+	grpc.ClientStream
+}
+
+func (x *agentTailLogClient) Recv() (*LogLine, error) {
+	m := new(LogLine)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // AgentServer is the server API for Agent service.
 type AgentServer interface {
 	CheckDiskSpace(context.Context, *CheckSegmentDiskSpaceRequest) (*CheckDiskSpaceReply, error)
+	CheckFreeSpace(context.Context, *CheckFreeSpaceRequest) (*CheckFreeSpaceReply, error)
 	UpgradePrimaries(context.Context, *UpgradePrimariesRequest) (*UpgradePrimariesReply, error)
 	RenameDirectories(context.Context, *RenameDirectoriesRequest) (*RenameDirectoriesReply, error)
+	RevertDataDirectories(context.Context, *RevertDataDirectoriesRequest) (*RevertDataDirectoriesReply, error)
 	StopAgent(context.Context, *StopAgentRequest) (*StopAgentReply, error)
 	DeleteDataDirectories(context.Context, *DeleteDataDirectoriesRequest) (*DeleteDataDirectoriesReply, error)
+	DeleteDataDirectoriesWithProgress(*DeleteDataDirectoriesRequest, Agent_DeleteDataDirectoriesWithProgressServer) error
+	ValidateDataDirectory(context.Context, *ValidateDataDirectoryRequest) (*ValidateDataDirectoryReply, error)
 	DeleteStateDirectory(context.Context, *DeleteStateDirectoryRequest) (*DeleteStateDirectoryReply, error)
 	DeleteTablespaceDirectories(context.Context, *DeleteTablespaceRequest) (*DeleteTablespaceReply, error)
 	DeleteSourceTablespaceDirectories(context.Context, *DeleteTablespaceRequest) (*DeleteTablespaceReply, error)
 	ArchiveLogDirectory(context.Context, *ArchiveLogDirectoryRequest) (*ArchiveLogDirectoryReply, error)
 	RsyncDataDirectories(context.Context, *RsyncRequest) (*RsyncReply, error)
 	RsyncTablespaceDirectories(context.Context, *RsyncRequest) (*RsyncReply, error)
+	RsyncDirectory(context.Context, *RsyncDirectoryRequest) (*RsyncDirectoryReply, error)
 	RestorePrimariesPgControl(context.Context, *RestorePgControlRequest) (*RestorePgControlReply, error)
+	Version(context.Context, *VersionRequest) (*VersionReply, error)
+	Healthz(context.Context, *HealthzRequest) (*HealthzReply, error)
+	TailLog(*TailLogRequest, Agent_TailLogServer) error
 }
 
 // UnimplementedAgentServer can be embedded to have forward compatible implementations.
@@ -1379,18 +2515,30 @@ type UnimplementedAgentServer struct {
 func (*UnimplementedAgentServer) CheckDiskSpace(ctx context.Context, req *CheckSegmentDiskSpaceRequest) (*CheckDiskSpaceReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CheckDiskSpace not implemented")
 }
+func (*UnimplementedAgentServer) CheckFreeSpace(ctx context.Context, req *CheckFreeSpaceRequest) (*CheckFreeSpaceReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckFreeSpace not implemented")
+}
 func (*UnimplementedAgentServer) UpgradePrimaries(ctx context.Context, req *UpgradePrimariesRequest) (*UpgradePrimariesReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpgradePrimaries not implemented")
 }
 func (*UnimplementedAgentServer) RenameDirectories(ctx context.Context, req *RenameDirectoriesRequest) (*RenameDirectoriesReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RenameDirectories not implemented")
 }
+func (*UnimplementedAgentServer) RevertDataDirectories(ctx context.Context, req *RevertDataDirectoriesRequest) (*RevertDataDirectoriesReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevertDataDirectories not implemented")
+}
 func (*UnimplementedAgentServer) StopAgent(ctx context.Context, req *StopAgentRequest) (*StopAgentReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method StopAgent not implemented")
 }
 func (*UnimplementedAgentServer) DeleteDataDirectories(ctx context.Context, req *DeleteDataDirectoriesRequest) (*DeleteDataDirectoriesReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteDataDirectories not implemented")
 }
+func (*UnimplementedAgentServer) DeleteDataDirectoriesWithProgress(req *DeleteDataDirectoriesRequest, srv Agent_DeleteDataDirectoriesWithProgressServer) error {
+	return status.Errorf(codes.Unimplemented, "method DeleteDataDirectoriesWithProgress not implemented")
+}
+func (*UnimplementedAgentServer) ValidateDataDirectory(ctx context.Context, req *ValidateDataDirectoryRequest) (*ValidateDataDirectoryReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateDataDirectory not implemented")
+}
 func (*UnimplementedAgentServer) DeleteStateDirectory(ctx context.Context, req *DeleteStateDirectoryRequest) (*DeleteStateDirectoryReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteStateDirectory not implemented")
 }
@@ -1409,9 +2557,21 @@ func (*UnimplementedAgentServer) RsyncDataDirectories(ctx context.Context, req *
 func (*UnimplementedAgentServer) RsyncTablespaceDirectories(ctx context.Context, req *RsyncRequest) (*RsyncReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RsyncTablespaceDirectories not implemented")
 }
+func (*UnimplementedAgentServer) RsyncDirectory(ctx context.Context, req *RsyncDirectoryRequest) (*RsyncDirectoryReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RsyncDirectory not implemented")
+}
 func (*UnimplementedAgentServer) RestorePrimariesPgControl(ctx context.Context, req *RestorePgControlRequest) (*RestorePgControlReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RestorePrimariesPgControl not implemented")
 }
+func (*UnimplementedAgentServer) Version(ctx context.Context, req *VersionRequest) (*VersionReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Version not implemented")
+}
+func (*UnimplementedAgentServer) Healthz(ctx context.Context, req *HealthzRequest) (*HealthzReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Healthz not implemented")
+}
+func (*UnimplementedAgentServer) TailLog(req *TailLogRequest, srv Agent_TailLogServer) error {
+	return status.Errorf(codes.Unimplemented, "method TailLog not implemented")
+}
 
 func RegisterAgentServer(s *grpc.Server, srv AgentServer) {
 	s.RegisterService(&_Agent_serviceDesc, srv)
@@ -1435,6 +2595,24 @@ func _Agent_CheckDiskSpace_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Agent_CheckFreeSpace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckFreeSpaceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServer).CheckFreeSpace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/idl.Agent/CheckFreeSpace",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServer).CheckFreeSpace(ctx, req.(*CheckFreeSpaceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Agent_UpgradePrimaries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(UpgradePrimariesRequest)
 	if err := dec(in); err != nil {
@@ -1471,6 +2649,24 @@ func _Agent_RenameDirectories_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Agent_RevertDataDirectories_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevertDataDirectoriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServer).RevertDataDirectories(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/idl.Agent/RevertDataDirectories",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServer).RevertDataDirectories(ctx, req.(*RevertDataDirectoriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Agent_StopAgent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(StopAgentRequest)
 	if err := dec(in); err != nil {
@@ -1507,6 +2703,45 @@ func _Agent_DeleteDataDirectories_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Agent_DeleteDataDirectoriesWithProgress_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DeleteDataDirectoriesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AgentServer).DeleteDataDirectoriesWithProgress(m, &agentDeleteDataDirectoriesWithProgressServer{stream})
+}
+
+type Agent_DeleteDataDirectoriesWithProgressServer interface {
+	Send(*ProgressEvent) error
+	grpc.ServerStream
+}
+
+type agentDeleteDataDirectoriesWithProgressServer struct {
+	grpc.ServerStream
+}
+
+func (x *agentDeleteDataDirectoriesWithProgressServer) Send(m *ProgressEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Agent_ValidateDataDirectory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateDataDirectoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServer).ValidateDataDirectory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/idl.Agent/ValidateDataDirectory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServer).ValidateDataDirectory(ctx, req.(*ValidateDataDirectoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Agent_DeleteStateDirectory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DeleteStateDirectoryRequest)
 	if err := dec(in); err != nil {
@@ -1615,6 +2850,24 @@ func _Agent_RsyncTablespaceDirectories_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Agent_RsyncDirectory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RsyncDirectoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServer).RsyncDirectory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/idl.Agent/RsyncDirectory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServer).RsyncDirectory(ctx, req.(*RsyncDirectoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Agent_RestorePrimariesPgControl_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(RestorePgControlRequest)
 	if err := dec(in); err != nil {
@@ -1633,6 +2886,63 @@ func _Agent_RestorePrimariesPgControl_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Agent_Version_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServer).Version(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/idl.Agent/Version",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServer).Version(ctx, req.(*VersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Agent_Healthz_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealthzRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServer).Healthz(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/idl.Agent/Healthz",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServer).Healthz(ctx, req.(*HealthzRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Agent_TailLog_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TailLogRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AgentServer).TailLog(m, &agentTailLogServer{stream})
+}
+
+type Agent_TailLogServer interface {
+	Send(*LogLine) error
+	grpc.ServerStream
+}
+
+type agentTailLogServer struct {
+	grpc.ServerStream
+}
+
+func (x *agentTailLogServer) Send(m *LogLine) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _Agent_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "idl.Agent",
 	HandlerType: (*AgentServer)(nil),
@@ -1641,6 +2951,10 @@ var _Agent_serviceDesc = grpc.ServiceDesc{
 			MethodName: "CheckDiskSpace",
 			Handler:    _Agent_CheckDiskSpace_Handler,
 		},
+		{
+			MethodName: "CheckFreeSpace",
+			Handler:    _Agent_CheckFreeSpace_Handler,
+		},
 		{
 			MethodName: "UpgradePrimaries",
 			Handler:    _Agent_UpgradePrimaries_Handler,
@@ -1649,6 +2963,10 @@ var _Agent_serviceDesc = grpc.ServiceDesc{
 			MethodName: "RenameDirectories",
 			Handler:    _Agent_RenameDirectories_Handler,
 		},
+		{
+			MethodName: "RevertDataDirectories",
+			Handler:    _Agent_RevertDataDirectories_Handler,
+		},
 		{
 			MethodName: "StopAgent",
 			Handler:    _Agent_StopAgent_Handler,
@@ -1657,6 +2975,10 @@ var _Agent_serviceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteDataDirectories",
 			Handler:    _Agent_DeleteDataDirectories_Handler,
 		},
+		{
+			MethodName: "ValidateDataDirectory",
+			Handler:    _Agent_ValidateDataDirectory_Handler,
+		},
 		{
 			MethodName: "DeleteStateDirectory",
 			Handler:    _Agent_DeleteStateDirectory_Handler,
@@ -1681,11 +3003,34 @@ var _Agent_serviceDesc = grpc.ServiceDesc{
 			MethodName: "RsyncTablespaceDirectories",
 			Handler:    _Agent_RsyncTablespaceDirectories_Handler,
 		},
+		{
+			MethodName: "RsyncDirectory",
+			Handler:    _Agent_RsyncDirectory_Handler,
+		},
 		{
 			MethodName: "RestorePrimariesPgControl",
 			Handler:    _Agent_RestorePrimariesPgControl_Handler,
 		},
+		{
+			MethodName: "Version",
+			Handler:    _Agent_Version_Handler,
+		},
+		{
+			MethodName: "Healthz",
+			Handler:    _Agent_Healthz_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "DeleteDataDirectoriesWithProgress",
+			Handler:       _Agent_DeleteDataDirectoriesWithProgress_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "TailLog",
+			Handler:       _Agent_TailLog_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "hub_to_agent.proto",
 }
@@ -36,6 +36,46 @@ func (m *MockCliToHubClient) EXPECT() *MockCliToHubClientMockRecorder {
 	return m.recorder
 }
 
+// Abort mocks base method
+func (m *MockCliToHubClient) Abort(arg0 context.Context, arg1 *idl.AbortRequest, arg2 ...grpc.CallOption) (*idl.AbortReply, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Abort", varargs...)
+	ret0, _ := ret[0].(*idl.AbortReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Abort indicates an expected call of Abort
+func (mr *MockCliToHubClientMockRecorder) Abort(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Abort", reflect.TypeOf((*MockCliToHubClient)(nil).Abort), varargs...)
+}
+
+// Confirm mocks base method
+func (m *MockCliToHubClient) Confirm(arg0 context.Context, arg1 *idl.ConfirmRequest, arg2 ...grpc.CallOption) (*idl.ConfirmReply, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Confirm", varargs...)
+	ret0, _ := ret[0].(*idl.ConfirmReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Confirm indicates an expected call of Confirm
+func (mr *MockCliToHubClientMockRecorder) Confirm(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Confirm", reflect.TypeOf((*MockCliToHubClient)(nil).Confirm), varargs...)
+}
+
 // Execute mocks base method
 func (m *MockCliToHubClient) Execute(arg0 context.Context, arg1 *idl.ExecuteRequest, arg2 ...grpc.CallOption) (idl.CliToHub_ExecuteClient, error) {
 	m.ctrl.T.Helper()
@@ -219,6 +259,36 @@ func (m *MockCliToHubServer) EXPECT() *MockCliToHubServerMockRecorder {
 	return m.recorder
 }
 
+// Abort mocks base method
+func (m *MockCliToHubServer) Abort(arg0 context.Context, arg1 *idl.AbortRequest) (*idl.AbortReply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Abort", arg0, arg1)
+	ret0, _ := ret[0].(*idl.AbortReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Abort indicates an expected call of Abort
+func (mr *MockCliToHubServerMockRecorder) Abort(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Abort", reflect.TypeOf((*MockCliToHubServer)(nil).Abort), arg0, arg1)
+}
+
+// Confirm mocks base method
+func (m *MockCliToHubServer) Confirm(arg0 context.Context, arg1 *idl.ConfirmRequest) (*idl.ConfirmReply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Confirm", arg0, arg1)
+	ret0, _ := ret[0].(*idl.ConfirmReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Confirm indicates an expected call of Confirm
+func (mr *MockCliToHubServerMockRecorder) Confirm(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Confirm", reflect.TypeOf((*MockCliToHubServer)(nil).Confirm), arg0, arg1)
+}
+
 // Execute mocks base method
 func (m *MockCliToHubServer) Execute(arg0 *idl.ExecuteRequest, arg1 idl.CliToHub_ExecuteServer) error {
 	m.ctrl.T.Helper()
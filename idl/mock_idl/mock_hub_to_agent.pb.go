@@ -9,6 +9,7 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	idl "github.com/greenplum-db/gpupgrade/idl"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
 	reflect "reflect"
 )
 
@@ -55,6 +56,26 @@ func (mr *MockAgentClientMockRecorder) CheckDiskSpace(ctx, in interface{}, opts
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckDiskSpace", reflect.TypeOf((*MockAgentClient)(nil).CheckDiskSpace), varargs...)
 }
 
+// CheckFreeSpace mocks base method
+func (m *MockAgentClient) CheckFreeSpace(ctx context.Context, in *idl.CheckFreeSpaceRequest, opts ...grpc.CallOption) (*idl.CheckFreeSpaceReply, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CheckFreeSpace", varargs...)
+	ret0, _ := ret[0].(*idl.CheckFreeSpaceReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckFreeSpace indicates an expected call of CheckFreeSpace
+func (mr *MockAgentClientMockRecorder) CheckFreeSpace(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckFreeSpace", reflect.TypeOf((*MockAgentClient)(nil).CheckFreeSpace), varargs...)
+}
+
 // UpgradePrimaries mocks base method
 func (m *MockAgentClient) UpgradePrimaries(ctx context.Context, in *idl.UpgradePrimariesRequest, opts ...grpc.CallOption) (*idl.UpgradePrimariesReply, error) {
 	m.ctrl.T.Helper()
@@ -95,6 +116,26 @@ func (mr *MockAgentClientMockRecorder) RenameDirectories(ctx, in interface{}, op
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameDirectories", reflect.TypeOf((*MockAgentClient)(nil).RenameDirectories), varargs...)
 }
 
+// RevertDataDirectories mocks base method
+func (m *MockAgentClient) RevertDataDirectories(ctx context.Context, in *idl.RevertDataDirectoriesRequest, opts ...grpc.CallOption) (*idl.RevertDataDirectoriesReply, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RevertDataDirectories", varargs...)
+	ret0, _ := ret[0].(*idl.RevertDataDirectoriesReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevertDataDirectories indicates an expected call of RevertDataDirectories
+func (mr *MockAgentClientMockRecorder) RevertDataDirectories(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevertDataDirectories", reflect.TypeOf((*MockAgentClient)(nil).RevertDataDirectories), varargs...)
+}
+
 // StopAgent mocks base method
 func (m *MockAgentClient) StopAgent(ctx context.Context, in *idl.StopAgentRequest, opts ...grpc.CallOption) (*idl.StopAgentReply, error) {
 	m.ctrl.T.Helper()
@@ -135,6 +176,46 @@ func (mr *MockAgentClientMockRecorder) DeleteDataDirectories(ctx, in interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDataDirectories", reflect.TypeOf((*MockAgentClient)(nil).DeleteDataDirectories), varargs...)
 }
 
+// DeleteDataDirectoriesWithProgress mocks base method
+func (m *MockAgentClient) DeleteDataDirectoriesWithProgress(ctx context.Context, in *idl.DeleteDataDirectoriesRequest, opts ...grpc.CallOption) (idl.Agent_DeleteDataDirectoriesWithProgressClient, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteDataDirectoriesWithProgress", varargs...)
+	ret0, _ := ret[0].(idl.Agent_DeleteDataDirectoriesWithProgressClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteDataDirectoriesWithProgress indicates an expected call of DeleteDataDirectoriesWithProgress
+func (mr *MockAgentClientMockRecorder) DeleteDataDirectoriesWithProgress(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDataDirectoriesWithProgress", reflect.TypeOf((*MockAgentClient)(nil).DeleteDataDirectoriesWithProgress), varargs...)
+}
+
+// ValidateDataDirectory mocks base method
+func (m *MockAgentClient) ValidateDataDirectory(ctx context.Context, in *idl.ValidateDataDirectoryRequest, opts ...grpc.CallOption) (*idl.ValidateDataDirectoryReply, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ValidateDataDirectory", varargs...)
+	ret0, _ := ret[0].(*idl.ValidateDataDirectoryReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateDataDirectory indicates an expected call of ValidateDataDirectory
+func (mr *MockAgentClientMockRecorder) ValidateDataDirectory(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateDataDirectory", reflect.TypeOf((*MockAgentClient)(nil).ValidateDataDirectory), varargs...)
+}
+
 // DeleteStateDirectory mocks base method
 func (m *MockAgentClient) DeleteStateDirectory(ctx context.Context, in *idl.DeleteStateDirectoryRequest, opts ...grpc.CallOption) (*idl.DeleteStateDirectoryReply, error) {
 	m.ctrl.T.Helper()
@@ -255,6 +336,26 @@ func (mr *MockAgentClientMockRecorder) RsyncTablespaceDirectories(ctx, in interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RsyncTablespaceDirectories", reflect.TypeOf((*MockAgentClient)(nil).RsyncTablespaceDirectories), varargs...)
 }
 
+// RsyncDirectory mocks base method
+func (m *MockAgentClient) RsyncDirectory(ctx context.Context, in *idl.RsyncDirectoryRequest, opts ...grpc.CallOption) (*idl.RsyncDirectoryReply, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RsyncDirectory", varargs...)
+	ret0, _ := ret[0].(*idl.RsyncDirectoryReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RsyncDirectory indicates an expected call of RsyncDirectory
+func (mr *MockAgentClientMockRecorder) RsyncDirectory(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RsyncDirectory", reflect.TypeOf((*MockAgentClient)(nil).RsyncDirectory), varargs...)
+}
+
 // RestorePrimariesPgControl mocks base method
 func (m *MockAgentClient) RestorePrimariesPgControl(ctx context.Context, in *idl.RestorePgControlRequest, opts ...grpc.CallOption) (*idl.RestorePgControlReply, error) {
 	m.ctrl.T.Helper()
@@ -275,6 +376,312 @@ func (mr *MockAgentClientMockRecorder) RestorePrimariesPgControl(ctx, in interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestorePrimariesPgControl", reflect.TypeOf((*MockAgentClient)(nil).RestorePrimariesPgControl), varargs...)
 }
 
+// Version mocks base method
+func (m *MockAgentClient) Version(ctx context.Context, in *idl.VersionRequest, opts ...grpc.CallOption) (*idl.VersionReply, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Version", varargs...)
+	ret0, _ := ret[0].(*idl.VersionReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Version indicates an expected call of Version
+func (mr *MockAgentClientMockRecorder) Version(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockAgentClient)(nil).Version), varargs...)
+}
+
+// Healthz mocks base method
+func (m *MockAgentClient) Healthz(ctx context.Context, in *idl.HealthzRequest, opts ...grpc.CallOption) (*idl.HealthzReply, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Healthz", varargs...)
+	ret0, _ := ret[0].(*idl.HealthzReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Healthz indicates an expected call of Healthz
+func (mr *MockAgentClientMockRecorder) Healthz(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Healthz", reflect.TypeOf((*MockAgentClient)(nil).Healthz), varargs...)
+}
+
+// TailLog mocks base method
+func (m *MockAgentClient) TailLog(ctx context.Context, in *idl.TailLogRequest, opts ...grpc.CallOption) (idl.Agent_TailLogClient, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "TailLog", varargs...)
+	ret0, _ := ret[0].(idl.Agent_TailLogClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TailLog indicates an expected call of TailLog
+func (mr *MockAgentClientMockRecorder) TailLog(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TailLog", reflect.TypeOf((*MockAgentClient)(nil).TailLog), varargs...)
+}
+
+// MockAgent_DeleteDataDirectoriesWithProgressClient is a mock of Agent_DeleteDataDirectoriesWithProgressClient interface
+type MockAgent_DeleteDataDirectoriesWithProgressClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockAgent_DeleteDataDirectoriesWithProgressClientMockRecorder
+}
+
+// MockAgent_DeleteDataDirectoriesWithProgressClientMockRecorder is the mock recorder for MockAgent_DeleteDataDirectoriesWithProgressClient
+type MockAgent_DeleteDataDirectoriesWithProgressClientMockRecorder struct {
+	mock *MockAgent_DeleteDataDirectoriesWithProgressClient
+}
+
+// NewMockAgent_DeleteDataDirectoriesWithProgressClient creates a new mock instance
+func NewMockAgent_DeleteDataDirectoriesWithProgressClient(ctrl *gomock.Controller) *MockAgent_DeleteDataDirectoriesWithProgressClient {
+	mock := &MockAgent_DeleteDataDirectoriesWithProgressClient{ctrl: ctrl}
+	mock.recorder = &MockAgent_DeleteDataDirectoriesWithProgressClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockAgent_DeleteDataDirectoriesWithProgressClient) EXPECT() *MockAgent_DeleteDataDirectoriesWithProgressClientMockRecorder {
+	return m.recorder
+}
+
+// Recv mocks base method
+func (m *MockAgent_DeleteDataDirectoriesWithProgressClient) Recv() (*idl.ProgressEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Recv")
+	ret0, _ := ret[0].(*idl.ProgressEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Recv indicates an expected call of Recv
+func (mr *MockAgent_DeleteDataDirectoriesWithProgressClientMockRecorder) Recv() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recv", reflect.TypeOf((*MockAgent_DeleteDataDirectoriesWithProgressClient)(nil).Recv))
+}
+
+// Header mocks base method
+func (m *MockAgent_DeleteDataDirectoriesWithProgressClient) Header() (metadata.MD, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Header")
+	ret0, _ := ret[0].(metadata.MD)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Header indicates an expected call of Header
+func (mr *MockAgent_DeleteDataDirectoriesWithProgressClientMockRecorder) Header() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Header", reflect.TypeOf((*MockAgent_DeleteDataDirectoriesWithProgressClient)(nil).Header))
+}
+
+// Trailer mocks base method
+func (m *MockAgent_DeleteDataDirectoriesWithProgressClient) Trailer() metadata.MD {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Trailer")
+	ret0, _ := ret[0].(metadata.MD)
+	return ret0
+}
+
+// Trailer indicates an expected call of Trailer
+func (mr *MockAgent_DeleteDataDirectoriesWithProgressClientMockRecorder) Trailer() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trailer", reflect.TypeOf((*MockAgent_DeleteDataDirectoriesWithProgressClient)(nil).Trailer))
+}
+
+// CloseSend mocks base method
+func (m *MockAgent_DeleteDataDirectoriesWithProgressClient) CloseSend() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseSend")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseSend indicates an expected call of CloseSend
+func (mr *MockAgent_DeleteDataDirectoriesWithProgressClientMockRecorder) CloseSend() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseSend", reflect.TypeOf((*MockAgent_DeleteDataDirectoriesWithProgressClient)(nil).CloseSend))
+}
+
+// Context mocks base method
+func (m *MockAgent_DeleteDataDirectoriesWithProgressClient) Context() context.Context {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Context")
+	ret0, _ := ret[0].(context.Context)
+	return ret0
+}
+
+// Context indicates an expected call of Context
+func (mr *MockAgent_DeleteDataDirectoriesWithProgressClientMockRecorder) Context() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockAgent_DeleteDataDirectoriesWithProgressClient)(nil).Context))
+}
+
+// SendMsg mocks base method
+func (m_2 *MockAgent_DeleteDataDirectoriesWithProgressClient) SendMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "SendMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendMsg indicates an expected call of SendMsg
+func (mr *MockAgent_DeleteDataDirectoriesWithProgressClientMockRecorder) SendMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMsg", reflect.TypeOf((*MockAgent_DeleteDataDirectoriesWithProgressClient)(nil).SendMsg), m)
+}
+
+// RecvMsg mocks base method
+func (m_2 *MockAgent_DeleteDataDirectoriesWithProgressClient) RecvMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "RecvMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecvMsg indicates an expected call of RecvMsg
+func (mr *MockAgent_DeleteDataDirectoriesWithProgressClientMockRecorder) RecvMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecvMsg", reflect.TypeOf((*MockAgent_DeleteDataDirectoriesWithProgressClient)(nil).RecvMsg), m)
+}
+
+// MockAgent_TailLogClient is a mock of Agent_TailLogClient interface
+type MockAgent_TailLogClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockAgent_TailLogClientMockRecorder
+}
+
+// MockAgent_TailLogClientMockRecorder is the mock recorder for MockAgent_TailLogClient
+type MockAgent_TailLogClientMockRecorder struct {
+	mock *MockAgent_TailLogClient
+}
+
+// NewMockAgent_TailLogClient creates a new mock instance
+func NewMockAgent_TailLogClient(ctrl *gomock.Controller) *MockAgent_TailLogClient {
+	mock := &MockAgent_TailLogClient{ctrl: ctrl}
+	mock.recorder = &MockAgent_TailLogClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockAgent_TailLogClient) EXPECT() *MockAgent_TailLogClientMockRecorder {
+	return m.recorder
+}
+
+// Recv mocks base method
+func (m *MockAgent_TailLogClient) Recv() (*idl.LogLine, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Recv")
+	ret0, _ := ret[0].(*idl.LogLine)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Recv indicates an expected call of Recv
+func (mr *MockAgent_TailLogClientMockRecorder) Recv() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recv", reflect.TypeOf((*MockAgent_TailLogClient)(nil).Recv))
+}
+
+// Header mocks base method
+func (m *MockAgent_TailLogClient) Header() (metadata.MD, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Header")
+	ret0, _ := ret[0].(metadata.MD)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Header indicates an expected call of Header
+func (mr *MockAgent_TailLogClientMockRecorder) Header() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Header", reflect.TypeOf((*MockAgent_TailLogClient)(nil).Header))
+}
+
+// Trailer mocks base method
+func (m *MockAgent_TailLogClient) Trailer() metadata.MD {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Trailer")
+	ret0, _ := ret[0].(metadata.MD)
+	return ret0
+}
+
+// Trailer indicates an expected call of Trailer
+func (mr *MockAgent_TailLogClientMockRecorder) Trailer() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trailer", reflect.TypeOf((*MockAgent_TailLogClient)(nil).Trailer))
+}
+
+// CloseSend mocks base method
+func (m *MockAgent_TailLogClient) CloseSend() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseSend")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseSend indicates an expected call of CloseSend
+func (mr *MockAgent_TailLogClientMockRecorder) CloseSend() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseSend", reflect.TypeOf((*MockAgent_TailLogClient)(nil).CloseSend))
+}
+
+// Context mocks base method
+func (m *MockAgent_TailLogClient) Context() context.Context {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Context")
+	ret0, _ := ret[0].(context.Context)
+	return ret0
+}
+
+// Context indicates an expected call of Context
+func (mr *MockAgent_TailLogClientMockRecorder) Context() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockAgent_TailLogClient)(nil).Context))
+}
+
+// SendMsg mocks base method
+func (m_2 *MockAgent_TailLogClient) SendMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "SendMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendMsg indicates an expected call of SendMsg
+func (mr *MockAgent_TailLogClientMockRecorder) SendMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMsg", reflect.TypeOf((*MockAgent_TailLogClient)(nil).SendMsg), m)
+}
+
+// RecvMsg mocks base method
+func (m_2 *MockAgent_TailLogClient) RecvMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "RecvMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecvMsg indicates an expected call of RecvMsg
+func (mr *MockAgent_TailLogClientMockRecorder) RecvMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecvMsg", reflect.TypeOf((*MockAgent_TailLogClient)(nil).RecvMsg), m)
+}
+
 // MockAgentServer is a mock of AgentServer interface
 type MockAgentServer struct {
 	ctrl     *gomock.Controller
@@ -313,6 +720,21 @@ func (mr *MockAgentServerMockRecorder) CheckDiskSpace(arg0, arg1 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckDiskSpace", reflect.TypeOf((*MockAgentServer)(nil).CheckDiskSpace), arg0, arg1)
 }
 
+// CheckFreeSpace mocks base method
+func (m *MockAgentServer) CheckFreeSpace(arg0 context.Context, arg1 *idl.CheckFreeSpaceRequest) (*idl.CheckFreeSpaceReply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckFreeSpace", arg0, arg1)
+	ret0, _ := ret[0].(*idl.CheckFreeSpaceReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckFreeSpace indicates an expected call of CheckFreeSpace
+func (mr *MockAgentServerMockRecorder) CheckFreeSpace(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckFreeSpace", reflect.TypeOf((*MockAgentServer)(nil).CheckFreeSpace), arg0, arg1)
+}
+
 // UpgradePrimaries mocks base method
 func (m *MockAgentServer) UpgradePrimaries(arg0 context.Context, arg1 *idl.UpgradePrimariesRequest) (*idl.UpgradePrimariesReply, error) {
 	m.ctrl.T.Helper()
@@ -343,6 +765,21 @@ func (mr *MockAgentServerMockRecorder) RenameDirectories(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameDirectories", reflect.TypeOf((*MockAgentServer)(nil).RenameDirectories), arg0, arg1)
 }
 
+// RevertDataDirectories mocks base method
+func (m *MockAgentServer) RevertDataDirectories(arg0 context.Context, arg1 *idl.RevertDataDirectoriesRequest) (*idl.RevertDataDirectoriesReply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevertDataDirectories", arg0, arg1)
+	ret0, _ := ret[0].(*idl.RevertDataDirectoriesReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevertDataDirectories indicates an expected call of RevertDataDirectories
+func (mr *MockAgentServerMockRecorder) RevertDataDirectories(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevertDataDirectories", reflect.TypeOf((*MockAgentServer)(nil).RevertDataDirectories), arg0, arg1)
+}
+
 // StopAgent mocks base method
 func (m *MockAgentServer) StopAgent(arg0 context.Context, arg1 *idl.StopAgentRequest) (*idl.StopAgentReply, error) {
 	m.ctrl.T.Helper()
@@ -373,6 +810,35 @@ func (mr *MockAgentServerMockRecorder) DeleteDataDirectories(arg0, arg1 interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDataDirectories", reflect.TypeOf((*MockAgentServer)(nil).DeleteDataDirectories), arg0, arg1)
 }
 
+// DeleteDataDirectoriesWithProgress mocks base method
+func (m *MockAgentServer) DeleteDataDirectoriesWithProgress(arg0 *idl.DeleteDataDirectoriesRequest, arg1 idl.Agent_DeleteDataDirectoriesWithProgressServer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDataDirectoriesWithProgress", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDataDirectoriesWithProgress indicates an expected call of DeleteDataDirectoriesWithProgress
+func (mr *MockAgentServerMockRecorder) DeleteDataDirectoriesWithProgress(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDataDirectoriesWithProgress", reflect.TypeOf((*MockAgentServer)(nil).DeleteDataDirectoriesWithProgress), arg0, arg1)
+}
+
+// ValidateDataDirectory mocks base method
+func (m *MockAgentServer) ValidateDataDirectory(arg0 context.Context, arg1 *idl.ValidateDataDirectoryRequest) (*idl.ValidateDataDirectoryReply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateDataDirectory", arg0, arg1)
+	ret0, _ := ret[0].(*idl.ValidateDataDirectoryReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateDataDirectory indicates an expected call of ValidateDataDirectory
+func (mr *MockAgentServerMockRecorder) ValidateDataDirectory(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateDataDirectory", reflect.TypeOf((*MockAgentServer)(nil).ValidateDataDirectory), arg0, arg1)
+}
+
 // DeleteStateDirectory mocks base method
 func (m *MockAgentServer) DeleteStateDirectory(arg0 context.Context, arg1 *idl.DeleteStateDirectoryRequest) (*idl.DeleteStateDirectoryReply, error) {
 	m.ctrl.T.Helper()
@@ -463,6 +929,21 @@ func (mr *MockAgentServerMockRecorder) RsyncTablespaceDirectories(arg0, arg1 int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RsyncTablespaceDirectories", reflect.TypeOf((*MockAgentServer)(nil).RsyncTablespaceDirectories), arg0, arg1)
 }
 
+// RsyncDirectory mocks base method
+func (m *MockAgentServer) RsyncDirectory(arg0 context.Context, arg1 *idl.RsyncDirectoryRequest) (*idl.RsyncDirectoryReply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RsyncDirectory", arg0, arg1)
+	ret0, _ := ret[0].(*idl.RsyncDirectoryReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RsyncDirectory indicates an expected call of RsyncDirectory
+func (mr *MockAgentServerMockRecorder) RsyncDirectory(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RsyncDirectory", reflect.TypeOf((*MockAgentServer)(nil).RsyncDirectory), arg0, arg1)
+}
+
 // RestorePrimariesPgControl mocks base method
 func (m *MockAgentServer) RestorePrimariesPgControl(arg0 context.Context, arg1 *idl.RestorePgControlRequest) (*idl.RestorePgControlReply, error) {
 	m.ctrl.T.Helper()
@@ -477,3 +958,285 @@ func (mr *MockAgentServerMockRecorder) RestorePrimariesPgControl(arg0, arg1 inte
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestorePrimariesPgControl", reflect.TypeOf((*MockAgentServer)(nil).RestorePrimariesPgControl), arg0, arg1)
 }
+
+// Version mocks base method
+func (m *MockAgentServer) Version(arg0 context.Context, arg1 *idl.VersionRequest) (*idl.VersionReply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Version", arg0, arg1)
+	ret0, _ := ret[0].(*idl.VersionReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Version indicates an expected call of Version
+func (mr *MockAgentServerMockRecorder) Version(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockAgentServer)(nil).Version), arg0, arg1)
+}
+
+// Healthz mocks base method
+func (m *MockAgentServer) Healthz(arg0 context.Context, arg1 *idl.HealthzRequest) (*idl.HealthzReply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Healthz", arg0, arg1)
+	ret0, _ := ret[0].(*idl.HealthzReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Healthz indicates an expected call of Healthz
+func (mr *MockAgentServerMockRecorder) Healthz(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Healthz", reflect.TypeOf((*MockAgentServer)(nil).Healthz), arg0, arg1)
+}
+
+// TailLog mocks base method
+func (m *MockAgentServer) TailLog(arg0 *idl.TailLogRequest, arg1 idl.Agent_TailLogServer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TailLog", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TailLog indicates an expected call of TailLog
+func (mr *MockAgentServerMockRecorder) TailLog(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TailLog", reflect.TypeOf((*MockAgentServer)(nil).TailLog), arg0, arg1)
+}
+
+// MockAgent_DeleteDataDirectoriesWithProgressServer is a mock of Agent_DeleteDataDirectoriesWithProgressServer interface
+type MockAgent_DeleteDataDirectoriesWithProgressServer struct {
+	ctrl     *gomock.Controller
+	recorder *MockAgent_DeleteDataDirectoriesWithProgressServerMockRecorder
+}
+
+// MockAgent_DeleteDataDirectoriesWithProgressServerMockRecorder is the mock recorder for MockAgent_DeleteDataDirectoriesWithProgressServer
+type MockAgent_DeleteDataDirectoriesWithProgressServerMockRecorder struct {
+	mock *MockAgent_DeleteDataDirectoriesWithProgressServer
+}
+
+// NewMockAgent_DeleteDataDirectoriesWithProgressServer creates a new mock instance
+func NewMockAgent_DeleteDataDirectoriesWithProgressServer(ctrl *gomock.Controller) *MockAgent_DeleteDataDirectoriesWithProgressServer {
+	mock := &MockAgent_DeleteDataDirectoriesWithProgressServer{ctrl: ctrl}
+	mock.recorder = &MockAgent_DeleteDataDirectoriesWithProgressServerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockAgent_DeleteDataDirectoriesWithProgressServer) EXPECT() *MockAgent_DeleteDataDirectoriesWithProgressServerMockRecorder {
+	return m.recorder
+}
+
+// Send mocks base method
+func (m *MockAgent_DeleteDataDirectoriesWithProgressServer) Send(arg0 *idl.ProgressEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send
+func (mr *MockAgent_DeleteDataDirectoriesWithProgressServerMockRecorder) Send(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockAgent_DeleteDataDirectoriesWithProgressServer)(nil).Send), arg0)
+}
+
+// SetHeader mocks base method
+func (m *MockAgent_DeleteDataDirectoriesWithProgressServer) SetHeader(arg0 metadata.MD) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetHeader", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetHeader indicates an expected call of SetHeader
+func (mr *MockAgent_DeleteDataDirectoriesWithProgressServerMockRecorder) SetHeader(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHeader", reflect.TypeOf((*MockAgent_DeleteDataDirectoriesWithProgressServer)(nil).SetHeader), arg0)
+}
+
+// SendHeader mocks base method
+func (m *MockAgent_DeleteDataDirectoriesWithProgressServer) SendHeader(arg0 metadata.MD) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendHeader", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendHeader indicates an expected call of SendHeader
+func (mr *MockAgent_DeleteDataDirectoriesWithProgressServerMockRecorder) SendHeader(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendHeader", reflect.TypeOf((*MockAgent_DeleteDataDirectoriesWithProgressServer)(nil).SendHeader), arg0)
+}
+
+// SetTrailer mocks base method
+func (m *MockAgent_DeleteDataDirectoriesWithProgressServer) SetTrailer(arg0 metadata.MD) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetTrailer", arg0)
+}
+
+// SetTrailer indicates an expected call of SetTrailer
+func (mr *MockAgent_DeleteDataDirectoriesWithProgressServerMockRecorder) SetTrailer(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTrailer", reflect.TypeOf((*MockAgent_DeleteDataDirectoriesWithProgressServer)(nil).SetTrailer), arg0)
+}
+
+// Context mocks base method
+func (m *MockAgent_DeleteDataDirectoriesWithProgressServer) Context() context.Context {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Context")
+	ret0, _ := ret[0].(context.Context)
+	return ret0
+}
+
+// Context indicates an expected call of Context
+func (mr *MockAgent_DeleteDataDirectoriesWithProgressServerMockRecorder) Context() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockAgent_DeleteDataDirectoriesWithProgressServer)(nil).Context))
+}
+
+// SendMsg mocks base method
+func (m_2 *MockAgent_DeleteDataDirectoriesWithProgressServer) SendMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "SendMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendMsg indicates an expected call of SendMsg
+func (mr *MockAgent_DeleteDataDirectoriesWithProgressServerMockRecorder) SendMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMsg", reflect.TypeOf((*MockAgent_DeleteDataDirectoriesWithProgressServer)(nil).SendMsg), m)
+}
+
+// RecvMsg mocks base method
+func (m_2 *MockAgent_DeleteDataDirectoriesWithProgressServer) RecvMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "RecvMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecvMsg indicates an expected call of RecvMsg
+func (mr *MockAgent_DeleteDataDirectoriesWithProgressServerMockRecorder) RecvMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecvMsg", reflect.TypeOf((*MockAgent_DeleteDataDirectoriesWithProgressServer)(nil).RecvMsg), m)
+}
+
+// MockAgent_TailLogServer is a mock of Agent_TailLogServer interface
+type MockAgent_TailLogServer struct {
+	ctrl     *gomock.Controller
+	recorder *MockAgent_TailLogServerMockRecorder
+}
+
+// MockAgent_TailLogServerMockRecorder is the mock recorder for MockAgent_TailLogServer
+type MockAgent_TailLogServerMockRecorder struct {
+	mock *MockAgent_TailLogServer
+}
+
+// NewMockAgent_TailLogServer creates a new mock instance
+func NewMockAgent_TailLogServer(ctrl *gomock.Controller) *MockAgent_TailLogServer {
+	mock := &MockAgent_TailLogServer{ctrl: ctrl}
+	mock.recorder = &MockAgent_TailLogServerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockAgent_TailLogServer) EXPECT() *MockAgent_TailLogServerMockRecorder {
+	return m.recorder
+}
+
+// Send mocks base method
+func (m *MockAgent_TailLogServer) Send(arg0 *idl.LogLine) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send
+func (mr *MockAgent_TailLogServerMockRecorder) Send(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockAgent_TailLogServer)(nil).Send), arg0)
+}
+
+// SetHeader mocks base method
+func (m *MockAgent_TailLogServer) SetHeader(arg0 metadata.MD) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetHeader", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetHeader indicates an expected call of SetHeader
+func (mr *MockAgent_TailLogServerMockRecorder) SetHeader(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHeader", reflect.TypeOf((*MockAgent_TailLogServer)(nil).SetHeader), arg0)
+}
+
+// SendHeader mocks base method
+func (m *MockAgent_TailLogServer) SendHeader(arg0 metadata.MD) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendHeader", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendHeader indicates an expected call of SendHeader
+func (mr *MockAgent_TailLogServerMockRecorder) SendHeader(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendHeader", reflect.TypeOf((*MockAgent_TailLogServer)(nil).SendHeader), arg0)
+}
+
+// SetTrailer mocks base method
+func (m *MockAgent_TailLogServer) SetTrailer(arg0 metadata.MD) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetTrailer", arg0)
+}
+
+// SetTrailer indicates an expected call of SetTrailer
+func (mr *MockAgent_TailLogServerMockRecorder) SetTrailer(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTrailer", reflect.TypeOf((*MockAgent_TailLogServer)(nil).SetTrailer), arg0)
+}
+
+// Context mocks base method
+func (m *MockAgent_TailLogServer) Context() context.Context {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Context")
+	ret0, _ := ret[0].(context.Context)
+	return ret0
+}
+
+// Context indicates an expected call of Context
+func (mr *MockAgent_TailLogServerMockRecorder) Context() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockAgent_TailLogServer)(nil).Context))
+}
+
+// SendMsg mocks base method
+func (m_2 *MockAgent_TailLogServer) SendMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "SendMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendMsg indicates an expected call of SendMsg
+func (mr *MockAgent_TailLogServerMockRecorder) SendMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMsg", reflect.TypeOf((*MockAgent_TailLogServer)(nil).SendMsg), m)
+}
+
+// RecvMsg mocks base method
+func (m_2 *MockAgent_TailLogServer) RecvMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "RecvMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecvMsg indicates an expected call of RecvMsg
+func (mr *MockAgent_TailLogServerMockRecorder) RecvMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecvMsg", reflect.TypeOf((*MockAgent_TailLogServer)(nil).RecvMsg), m)
+}
@@ -115,7 +115,7 @@ func TestHub(t *testing.T) {
 			t.Errorf("unexpected error got %+v", err)
 		}
 
-		err = commanders.CreateInitialClusterConfigs(upgrade.DefaultHubPort)
+		err = commanders.CreateInitialClusterConfigs(commanders.HubConfig{Port: upgrade.DefaultHubPort})
 		if err != nil {
 			t.Errorf("unexpected error got %+v", err)
 		}
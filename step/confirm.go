@@ -0,0 +1,138 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package step
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+)
+
+// DefaultConfirmationTimeout is how long a substep requiring confirmation
+// waits for the operator before it is aborted.
+const DefaultConfirmationTimeout = time.Hour
+
+// ErrAborted is returned by Gate.Wait when the operator aborts the substep, or
+// does not confirm it in time.
+var ErrAborted = errors.New("substep was not confirmed")
+
+// AbortedError is the backing error type for ErrAborted. TimedOut is set when
+// the substep was aborted for lack of a confirmation.
+type AbortedError struct {
+	Substep  idl.Substep
+	TimedOut bool
+	Timeout  time.Duration
+}
+
+func (a *AbortedError) Error() string {
+	if a.TimedOut {
+		return fmt.Sprintf("%s was not confirmed within %s and was aborted", a.Substep, a.Timeout)
+	}
+
+	return fmt.Sprintf("%s was aborted by the operator", a.Substep)
+}
+
+func (a *AbortedError) Is(err error) bool {
+	return err == ErrAborted
+}
+
+// ErrNoPendingConfirmation is returned by Gate.Confirm and Gate.Abort when the
+// substep is not waiting for confirmation.
+var ErrNoPendingConfirmation = errors.New("substep is not waiting for confirmation")
+
+// Gate holds substeps waiting for an operator to confirm or abort them. A
+// step waits on the gate through RequireConfirmation, while Confirm and Abort
+// are called by the hub's RPCs of the same names.
+type Gate struct {
+	mu      sync.Mutex
+	pending map[idl.Substep]chan bool
+}
+
+func NewGate() *Gate {
+	return &Gate{pending: make(map[idl.Substep]chan bool)}
+}
+
+// Wait blocks until substep is confirmed or aborted. A substep not confirmed
+// within timeout is aborted.
+func (g *Gate) Wait(substep idl.Substep, timeout time.Duration) error {
+	decision := make(chan bool, 1)
+
+	g.mu.Lock()
+	if _, ok := g.pending[substep]; ok {
+		g.mu.Unlock()
+		return xerrors.Errorf("%s is already waiting for confirmation", substep)
+	}
+	g.pending[substep] = decision
+	g.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case confirmed := <-decision:
+		return decided(substep, confirmed)
+
+	case <-timer.C:
+		g.mu.Lock()
+		waiting := g.pending[substep] == decision
+		if waiting {
+			delete(g.pending, substep)
+		}
+		g.mu.Unlock()
+
+		// A substep no longer waiting was decided along with the timeout, and
+		// its decision has already been sent; honor it.
+		if !waiting {
+			return decided(substep, <-decision)
+		}
+
+		return &AbortedError{Substep: substep, TimedOut: true, Timeout: timeout}
+	}
+}
+
+func decided(substep idl.Substep, confirmed bool) error {
+	if !confirmed {
+		return &AbortedError{Substep: substep}
+	}
+
+	return nil
+}
+
+// Confirm lets the waiting substep run.
+func (g *Gate) Confirm(substep idl.Substep) error {
+	return g.decide(substep, true)
+}
+
+// Abort fails the waiting substep without running it.
+func (g *Gate) Abort(substep idl.Substep) error {
+	return g.decide(substep, false)
+}
+
+// Pending returns whether substep is waiting for confirmation.
+func (g *Gate) Pending(substep idl.Substep) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	_, ok := g.pending[substep]
+	return ok
+}
+
+func (g *Gate) decide(substep idl.Substep, confirmed bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	decision, ok := g.pending[substep]
+	if !ok {
+		return xerrors.Errorf("%s: %w", substep, ErrNoPendingConfirmation)
+	}
+
+	delete(g.pending, substep)
+	decision <- confirmed
+	return nil
+}
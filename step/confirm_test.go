@@ -0,0 +1,229 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package step_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/idl/mock_idl"
+	"github.com/greenplum-db/gpupgrade/step"
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+)
+
+func TestRequireConfirmation(t *testing.T) {
+	testlog.SetupLogger()
+
+	substep := idl.Substep_DELETE_TABLESPACES
+
+	expectStatus := func(server *mock_idl.MockCliToHub_ExecuteServer, statuses ...idl.Status) {
+		var calls []*gomock.Call
+		for _, status := range statuses {
+			calls = append(calls, server.EXPECT().
+				Send(&idl.Message{Contents: &idl.Message_Status{Status: &idl.SubstepStatus{
+					Step:   substep,
+					Status: status,
+				}}}))
+		}
+		gomock.InOrder(calls...)
+	}
+
+	// decide calls fn once substep is waiting on gate.
+	decide := func(t *testing.T, gate *step.Gate, fn func(idl.Substep) error) {
+		go func() {
+			for !gate.Pending(substep) {
+				time.Sleep(time.Millisecond)
+			}
+
+			if err := fn(substep); err != nil {
+				t.Errorf("unexpected error: %+v", err)
+			}
+		}()
+	}
+
+	t.Run("runs a confirmed substep", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		server := mock_idl.NewMockCliToHub_ExecuteServer(ctrl)
+		expectStatus(server, idl.Status_RUNNING, idl.Status_COMPLETE)
+
+		store := &TestSubstepStore{}
+		s := step.New(idl.Step_REVERT, server, store, &testutils.DevNullWithClose{})
+
+		gate := step.NewGate()
+		s.RequireConfirmation(gate, time.Minute, substep)
+		decide(t, gate, gate.Confirm)
+
+		var called bool
+		s.Run(substep, func(streams step.OutStreams) error {
+			called = true
+			return nil
+		})
+
+		if err := s.Err(); err != nil {
+			t.Errorf("unexpected error: %+v", err)
+		}
+
+		if !called {
+			t.Error("expected substep to be called")
+		}
+
+		if store.Status != idl.Status_COMPLETE {
+			t.Errorf("substep status was %s, want %s", store.Status, idl.Status_COMPLETE)
+		}
+	})
+
+	t.Run("aborts a substep that is not confirmed in time without running it", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		server := mock_idl.NewMockCliToHub_ExecuteServer(ctrl)
+		expectStatus(server, idl.Status_FAILED)
+
+		store := &TestSubstepStore{}
+		s := step.New(idl.Step_REVERT, server, store, &testutils.DevNullWithClose{})
+
+		gate := step.NewGate()
+		s.RequireConfirmation(gate, 10*time.Millisecond, substep)
+
+		var called bool
+		s.Run(substep, func(streams step.OutStreams) error {
+			called = true
+			return nil
+		})
+
+		if called {
+			t.Error("expected substep to not be called")
+		}
+
+		var aborted *step.AbortedError
+		if !errors.As(s.Err(), &aborted) {
+			t.Fatalf("got error %#v want type %T", s.Err(), aborted)
+		}
+
+		if !aborted.TimedOut {
+			t.Error("expected substep to have timed out")
+		}
+
+		if !errors.Is(s.Err(), step.ErrAborted) {
+			t.Errorf("got error %#v want %#v", s.Err(), step.ErrAborted)
+		}
+
+		if store.Status != idl.Status_UNKNOWN_STATUS {
+			t.Errorf("substep status was %s, want %s", store.Status, idl.Status_UNKNOWN_STATUS)
+		}
+
+		if gate.Pending(substep) {
+			t.Error("expected substep to no longer be pending")
+		}
+	})
+
+	t.Run("does not run an aborted substep", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		server := mock_idl.NewMockCliToHub_ExecuteServer(ctrl)
+		expectStatus(server, idl.Status_FAILED)
+
+		s := step.New(idl.Step_REVERT, server, &TestSubstepStore{}, &testutils.DevNullWithClose{})
+
+		gate := step.NewGate()
+		s.RequireConfirmation(gate, time.Minute, substep)
+		decide(t, gate, gate.Abort)
+
+		var called bool
+		s.Run(substep, func(streams step.OutStreams) error {
+			called = true
+			return nil
+		})
+
+		if called {
+			t.Error("expected substep to not be called")
+		}
+
+		var aborted *step.AbortedError
+		if !errors.As(s.Err(), &aborted) {
+			t.Fatalf("got error %#v want type %T", s.Err(), aborted)
+		}
+
+		if aborted.TimedOut {
+			t.Error("expected substep to have been aborted rather than timed out")
+		}
+	})
+
+	t.Run("does not wait on substeps that do not require confirmation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		server := mock_idl.NewMockCliToHub_ExecuteServer(ctrl)
+		server.EXPECT().Send(gomock.Any()).Times(2)
+
+		s := step.New(idl.Step_REVERT, server, &TestSubstepStore{}, &testutils.DevNullWithClose{})
+		s.RequireConfirmation(step.NewGate(), time.Minute, substep)
+
+		var called bool
+		s.Run(idl.Substep_RESTORE_PGCONTROL, func(streams step.OutStreams) error {
+			called = true
+			return nil
+		})
+
+		if err := s.Err(); err != nil {
+			t.Errorf("unexpected error: %+v", err)
+		}
+
+		if !called {
+			t.Error("expected substep to be called")
+		}
+	})
+}
+
+func TestGate(t *testing.T) {
+	t.Run("errors when no substep is waiting", func(t *testing.T) {
+		gate := step.NewGate()
+
+		err := gate.Confirm(idl.Substep_DELETE_TABLESPACES)
+		if !errors.Is(err, step.ErrNoPendingConfirmation) {
+			t.Errorf("got error %#v want %#v", err, step.ErrNoPendingConfirmation)
+		}
+
+		err = gate.Abort(idl.Substep_DELETE_TABLESPACES)
+		if !errors.Is(err, step.ErrNoPendingConfirmation) {
+			t.Errorf("got error %#v want %#v", err, step.ErrNoPendingConfirmation)
+		}
+	})
+
+	t.Run("reports a substep aborted as it times out as aborted", func(t *testing.T) {
+		substep := idl.Substep_DELETE_TABLESPACES
+
+		// Race the abort against a short timeout. Whenever the abort is
+		// accepted the substep must be reported as aborted by the operator.
+		for i := 0; i < 100; i++ {
+			gate := step.NewGate()
+
+			result := make(chan error, 1)
+			go func() {
+				result <- gate.Wait(substep, time.Millisecond)
+			}()
+
+			time.Sleep(time.Millisecond)
+
+			accepted := gate.Abort(substep) == nil
+
+			var aborted *step.AbortedError
+			if err := <-result; !errors.As(err, &aborted) {
+				t.Fatalf("got error %#v want type %T", err, aborted)
+			}
+
+			if accepted && aborted.TimedOut {
+				t.Fatalf("got a timeout for a substep that was aborted")
+			}
+		}
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/greenplum-db/gp-common-go-libs/gplog"
 	"github.com/greenplum-db/gp-common-go-libs/operating"
@@ -17,6 +18,7 @@ import (
 	"github.com/greenplum-db/gpupgrade/idl"
 	"github.com/greenplum-db/gpupgrade/utils"
 	"github.com/greenplum-db/gpupgrade/utils/errorlist"
+	"github.com/greenplum-db/gpupgrade/utils/metrics"
 	"github.com/greenplum-db/gpupgrade/utils/stopwatch"
 )
 
@@ -28,6 +30,10 @@ type Step struct {
 	substepStore SubstepStore      // persistent substep status storage
 	streams      OutStreamsCloser  // writes substep stdout/err
 	err          error
+
+	gate        *Gate                // set by RequireConfirmation
+	gateTimeout time.Duration        // how long to wait on gate
+	confirm     map[idl.Substep]bool // substeps that wait on gate
 }
 
 func New(name idl.Step, sender idl.MessageSender, substepStore SubstepStore, streams OutStreamsCloser) *Step {
@@ -101,6 +107,24 @@ func (s *Step) Err() error {
 	return s.err
 }
 
+// RequireConfirmation makes each of substeps wait, before it starts, for an
+// operator to confirm it through gate. A substep that is aborted, or is not
+// confirmed within timeout, fails without running and is not marked on disk,
+// so that it is asked for again when the step is rerun. A zero timeout uses
+// DefaultConfirmationTimeout.
+func (s *Step) RequireConfirmation(gate *Gate, timeout time.Duration, substeps ...idl.Substep) {
+	if timeout <= 0 {
+		timeout = DefaultConfirmationTimeout
+	}
+
+	s.gate = gate
+	s.gateTimeout = timeout
+	s.confirm = make(map[idl.Substep]bool, len(substeps))
+	for _, substep := range substeps {
+		s.confirm[substep] = true
+	}
+}
+
 func (s *Step) RunInternalSubstep(f func() error) {
 	if s.err != nil {
 		return
@@ -160,9 +184,20 @@ func (s *Step) run(substep idl.Substep, f func(OutStreams) error, alwaysRun bool
 		return
 	}
 
+	if s.confirm[substep] {
+		err = s.waitForConfirmation(substep)
+		if err != nil {
+			s.sendStatus(substep, idl.Status_FAILED)
+			return
+		}
+	}
+
 	timer := stopwatch.Start()
 	defer func() {
-		if pErr := s.printDuration(substep, timer.Stop()); pErr != nil {
+		timer.Stop()
+		metrics.ObserveStep(substep.String(), timer.Elapsed(), err)
+
+		if pErr := s.printDuration(substep, timer); pErr != nil {
 			err = errorlist.Append(err, pErr)
 		}
 	}()
@@ -195,6 +230,17 @@ func (s *Step) run(substep idl.Substep, f func(OutStreams) error, alwaysRun bool
 	err = s.write(substep, idl.Status_COMPLETE)
 }
 
+func (s *Step) waitForConfirmation(substep idl.Substep) error {
+	gplog.Info("waiting up to %s for confirmation to start %s", s.gateTimeout, substep)
+
+	_, err := fmt.Fprintf(s.streams.Stdout(), "\nWaiting up to %s for confirmation to start %s...\n", s.gateTimeout, substep)
+	if err != nil {
+		return err
+	}
+
+	return s.gate.Wait(substep, s.gateTimeout)
+}
+
 func (s *Step) write(substep idl.Substep, status idl.Status) error {
 	storeStatus := status
 	if status == idl.Status_SKIPPED {
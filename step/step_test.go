@@ -6,6 +6,7 @@ package step_test
 import (
 	"errors"
 	"io/ioutil"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -19,6 +20,7 @@ import (
 	"github.com/greenplum-db/gpupgrade/idl/mock_idl"
 	"github.com/greenplum-db/gpupgrade/step"
 	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/utils/metrics"
 )
 
 func TestStepRun(t *testing.T) {
@@ -239,6 +241,33 @@ func TestStepRun(t *testing.T) {
 		}
 	})
 
+	t.Run("records the duration and outcome of each substep to the metrics", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		server := mock_idl.NewMockCliToHub_ExecuteServer(ctrl)
+		server.EXPECT().Send(gomock.Any()).AnyTimes()
+
+		s := step.New(idl.Step_EXECUTE, server, &TestSubstepStore{}, &testutils.DevNullWithClose{})
+		s.Run(idl.Substep_UPGRADE_PRIMARIES, func(streams step.OutStreams) error {
+			return errors.New("oops")
+		})
+
+		recorder := httptest.NewRecorder()
+		metrics.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+		body := recorder.Body.String()
+
+		expected := `gpupgrade_step_duration_seconds_count{step="UPGRADE_PRIMARIES"} 1`
+		if !strings.Contains(body, expected) {
+			t.Errorf("metrics %q do not contain %q", body, expected)
+		}
+
+		expected = `gpupgrade_step_total{step="UPGRADE_PRIMARIES",outcome="failure"} 1`
+		if !strings.Contains(body, expected) {
+			t.Errorf("metrics %q do not contain %q", body, expected)
+		}
+	})
+
 	t.Run("returns an error when MarkInProgress fails", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -113,5 +113,5 @@ func (f *SubstepFileStore) Write(step idl.Step, substep idl.Substep, status idl.
 		return err
 	}
 
-	return utils.AtomicallyWrite(f.path, data)
+	return utils.AtomicWriteFile(f.path, data, 0600)
 }
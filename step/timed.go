@@ -0,0 +1,36 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package step
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+
+	"github.com/greenplum-db/gpupgrade/utils/errorlist"
+	"github.com/greenplum-db/gpupgrade/utils/metrics"
+	"github.com/greenplum-db/gpupgrade/utils/stopwatch"
+)
+
+// Timed runs fn and reports how long it took to the stream's stdout, and its
+// start and end times to the log. The duration is reported whether or not fn
+// fails, and fn's error is returned. The duration and outcome are also
+// recorded to the metrics package.
+func Timed(name string, streams OutStreams, fn func() error) error {
+	start := time.Now()
+	timer := stopwatch.Start()
+
+	err := fn()
+
+	timer.Stop()
+	metrics.ObserveStep(name, timer.Elapsed(), err)
+	gplog.Debug("%s started at %s and finished at %s, took %s", name, start.Format(time.RFC3339Nano), time.Now().Format(time.RFC3339Nano), timer.String())
+
+	if _, pErr := fmt.Fprintf(streams.Stdout(), "%s took %s\n", name, timer.String()); pErr != nil {
+		err = errorlist.Append(err, pErr)
+	}
+
+	return err
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package step_test
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/greenplum-db/gpupgrade/step"
+	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+	"github.com/greenplum-db/gpupgrade/utils/metrics"
+)
+
+func TestTimed(t *testing.T) {
+	// durationPattern matches the output for the named substep.
+	durationPattern := regexp.MustCompile(`^deleting directories took \d+(\.\d+)?(ns|µs|ms|s)\n$`)
+
+	t.Run("reports the duration of the function", func(t *testing.T) {
+		_, _, log := testlog.SetupLogger()
+
+		called := false
+		streams := new(step.BufferedStreams)
+		err := step.Timed("deleting directories", streams, func() error {
+			called = true
+			return nil
+		})
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+
+		if !called {
+			t.Errorf("expected function to be called")
+		}
+
+		if !durationPattern.MatchString(streams.StdoutBuf.String()) {
+			t.Errorf("got stdout %q want it to match %q", streams.StdoutBuf.String(), durationPattern)
+		}
+
+		expected := "deleting directories started at "
+		if !strings.Contains(string(log.Bytes()), expected) {
+			t.Errorf("log %q does not contain %q", string(log.Bytes()), expected)
+		}
+	})
+
+	t.Run("reports the duration of a failing function and returns its error", func(t *testing.T) {
+		testlog.SetupLogger()
+
+		expected := errors.New("permission denied")
+		streams := new(step.BufferedStreams)
+		err := step.Timed("deleting directories", streams, func() error {
+			return expected
+		})
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+
+		if !durationPattern.MatchString(streams.StdoutBuf.String()) {
+			t.Errorf("got stdout %q want it to match %q", streams.StdoutBuf.String(), durationPattern)
+		}
+	})
+
+	t.Run("records the duration and outcome to the metrics", func(t *testing.T) {
+		testlog.SetupLogger()
+
+		err := step.Timed("timed metrics test", new(step.BufferedStreams), func() error {
+			time.Sleep(time.Millisecond)
+			return errors.New("permission denied")
+		})
+		if err == nil {
+			t.Errorf("expected an error")
+		}
+
+		recorder := httptest.NewRecorder()
+		metrics.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+		body := recorder.Body.String()
+
+		expected := `gpupgrade_step_duration_seconds_count{step="timed metrics test"} 1`
+		if !strings.Contains(body, expected) {
+			t.Errorf("metrics %q do not contain %q", body, expected)
+		}
+
+		sum := regexp.MustCompile(`gpupgrade_step_duration_seconds_sum\{step="timed metrics test"\} (\S+)`).FindStringSubmatch(body)
+		if sum == nil || sum[1] == "0" {
+			t.Errorf("got metrics %q want a non-zero duration sum", body)
+		}
+
+		expected = `gpupgrade_step_total{step="timed metrics test",outcome="failure"} 1`
+		if !strings.Contains(body, expected) {
+			t.Errorf("metrics %q do not contain %q", body, expected)
+		}
+	})
+
+	t.Run("returns both errors when reporting the duration fails", func(t *testing.T) {
+		testlog.SetupLogger()
+
+		expected := errors.New("permission denied")
+		err := step.Timed("deleting directories", failingStreams{}, func() error {
+			return expected
+		})
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+
+		if !errors.Is(err, errWrite) {
+			t.Errorf("got error %#v want %#v", err, errWrite)
+		}
+	})
+}
+
+var errWrite = errors.New("no space left on device")
+
+// failingStreams is an OutStreams for which all writes fail.
+type failingStreams struct{}
+
+func (failingStreams) Stdout() io.Writer {
+	return &failingWriter{}
+}
+
+func (failingStreams) Stderr() io.Writer {
+	return &failingWriter{}
+}
+
+type failingWriter struct{}
+
+func (*failingWriter) Write(_ []byte) (int, error) {
+	return 0, errWrite
+}
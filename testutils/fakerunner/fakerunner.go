@@ -0,0 +1,73 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+// Package fakerunner provides a command.Runner that records the commands it is
+// asked to run and returns scripted results instead of running them.
+package fakerunner
+
+import (
+	"context"
+	"sync"
+)
+
+// Invocation is a command passed to Runner.Run.
+type Invocation struct {
+	Name string
+	Args []string
+}
+
+// Result is the output and error returned for an Invocation.
+type Result struct {
+	Stdout, Stderr []byte
+	Err            error
+
+	// Block simulates a long-running command that only exits once stopped:
+	// Run waits for its context to be done before returning the Result.
+	Block bool
+}
+
+// Runner records each Invocation and returns the scripted Results in order.
+// Once the Results are used up, or if none were given, commands succeed with
+// no output. It is safe for concurrent use.
+type Runner struct {
+	mu          sync.Mutex
+	results     []Result
+	invocations []Invocation
+}
+
+// New returns a Runner that returns the given Results in order.
+func New(results ...Result) *Runner {
+	return &Runner{results: results}
+}
+
+func (r *Runner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	result := r.next(name, args)
+	if result.Block {
+		<-ctx.Done()
+	}
+
+	return result.Stdout, result.Stderr, result.Err
+}
+
+func (r *Runner) next(name string, args []string) Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.invocations = append(r.invocations, Invocation{Name: name, Args: append([]string(nil), args...)})
+
+	if len(r.results) == 0 {
+		return Result{}
+	}
+
+	result := r.results[0]
+	r.results = r.results[1:]
+	return result
+}
+
+// Invocations returns the commands run so far, in order.
+func (r *Runner) Invocations() []Invocation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Invocation(nil), r.invocations...)
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+// Package memfs provides an in-memory implementation of upgrade.FileSystem
+// for unit tests that would otherwise need real temporary directories.
+package memfs
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+type entry struct {
+	mode    os.FileMode
+	data    []byte
+	modTime time.Time
+}
+
+// FS is an in-memory filesystem rooted at "/". Paths are cleaned before use,
+// and relative paths are treated as relative to the root. The zero value is
+// not usable; use New.
+type FS struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+// New returns an FS containing only the root directory.
+func New() *FS {
+	return &FS{entries: map[string]*entry{
+		"/": {mode: os.ModeDir | 0755, modTime: time.Now()},
+	}}
+}
+
+// Paths returns every path in the filesystem other than the root, sorted.
+func (f *FS) Paths() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var paths []string
+	for path := range f.entries {
+		if path != "/" {
+			paths = append(paths, path)
+		}
+	}
+
+	sort.Strings(paths)
+	return paths
+}
+
+func (f *FS) Stat(name string) (os.FileInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := clean(name)
+	e, ok := f.entries[path]
+	if !ok {
+		return nil, &os.PathError{Op: "stat", Path: name, Err: syscall.ENOENT}
+	}
+
+	return &fileInfo{name: filepath.Base(path), entry: *e}, nil
+}
+
+// Rename follows the semantics of rename(2): a directory may only replace an
+// empty directory, and a file may only replace a file.
+func (f *FS) Rename(oldpath, newpath string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	src, dst := clean(oldpath), clean(newpath)
+	linkErr := func(err error) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: err}
+	}
+
+	srcEntry, ok := f.entries[src]
+	if !ok {
+		return linkErr(syscall.ENOENT)
+	}
+
+	if src == dst {
+		return nil
+	}
+
+	if isWithin(dst, src) {
+		return linkErr(syscall.EINVAL)
+	}
+
+	if err := f.checkParent(dst); err != nil {
+		return linkErr(err)
+	}
+
+	if dstEntry, ok := f.entries[dst]; ok {
+		switch {
+		case srcEntry.mode.IsDir() && !dstEntry.mode.IsDir():
+			return linkErr(syscall.ENOTDIR)
+		case !srcEntry.mode.IsDir() && dstEntry.mode.IsDir():
+			return linkErr(syscall.EISDIR)
+		case dstEntry.mode.IsDir() && f.hasChildren(dst):
+			return linkErr(syscall.ENOTEMPTY)
+		}
+	}
+
+	moved := make(map[string]*entry)
+	for path, e := range f.entries {
+		if path == src || isWithin(path, src) {
+			moved[dst+strings.TrimPrefix(path, src)] = e
+			delete(f.entries, path)
+		}
+	}
+
+	for path, e := range moved {
+		f.entries[path] = e
+	}
+
+	return nil
+}
+
+func (f *FS) RemoveAll(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := clean(name)
+	for p := range f.entries {
+		if p != "/" && (p == path || isWithin(p, path)) {
+			delete(f.entries, p)
+		}
+	}
+
+	return nil
+}
+
+func (f *FS) MkdirAll(name string, perm os.FileMode) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := clean(name)
+	var missing []string
+	for p := path; ; p = filepath.Dir(p) {
+		if e, ok := f.entries[p]; ok {
+			if !e.mode.IsDir() {
+				return &os.PathError{Op: "mkdir", Path: name, Err: syscall.ENOTDIR}
+			}
+
+			break
+		}
+
+		missing = append(missing, p)
+	}
+
+	for _, p := range missing {
+		f.entries[p] = &entry{mode: os.ModeDir | perm.Perm(), modTime: time.Now()}
+	}
+
+	return nil
+}
+
+// WriteFile creates or replaces the file name with data. The parent
+// directory must already exist.
+func (f *FS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := clean(name)
+	if err := f.checkParent(path); err != nil {
+		return &os.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	if e, ok := f.entries[path]; ok && e.mode.IsDir() {
+		return &os.PathError{Op: "open", Path: name, Err: syscall.EISDIR}
+	}
+
+	contents := make([]byte, len(data))
+	copy(contents, data)
+	f.entries[path] = &entry{mode: perm.Perm(), data: contents, modTime: time.Now()}
+
+	return nil
+}
+
+// checkParent returns an error unless the parent of path is an existing
+// directory. It must be called with the lock held.
+func (f *FS) checkParent(path string) error {
+	parent, ok := f.entries[filepath.Dir(path)]
+	if !ok {
+		return syscall.ENOENT
+	}
+
+	if !parent.mode.IsDir() {
+		return syscall.ENOTDIR
+	}
+
+	return nil
+}
+
+// hasChildren must be called with the lock held.
+func (f *FS) hasChildren(dir string) bool {
+	for path := range f.entries {
+		if isWithin(path, dir) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func clean(name string) string {
+	return filepath.Join("/", name)
+}
+
+// isWithin returns whether path is strictly beneath dir.
+func isWithin(path, dir string) bool {
+	if dir == "/" {
+		return path != "/"
+	}
+
+	return strings.HasPrefix(path, dir+"/")
+}
+
+type fileInfo struct {
+	name  string
+	entry entry
+}
+
+func (i *fileInfo) Name() string       { return i.name }
+func (i *fileInfo) Size() int64        { return int64(len(i.entry.data)) }
+func (i *fileInfo) Mode() os.FileMode  { return i.entry.mode }
+func (i *fileInfo) ModTime() time.Time { return i.entry.modTime }
+func (i *fileInfo) IsDir() bool        { return i.entry.mode.IsDir() }
+func (i *fileInfo) Sys() interface{}   { return nil }
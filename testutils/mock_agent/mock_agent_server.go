@@ -68,6 +68,12 @@ func (m *MockAgentServer) CheckDiskSpace(context.Context, *idl.CheckSegmentDiskS
 	return &idl.CheckDiskSpaceReply{}, nil
 }
 
+func (m *MockAgentServer) CheckFreeSpace(context.Context, *idl.CheckFreeSpaceRequest) (*idl.CheckFreeSpaceReply, error) {
+	m.increaseCalls()
+
+	return &idl.CheckFreeSpaceReply{}, nil
+}
+
 func (m *MockAgentServer) UpgradePrimaries(ctx context.Context, in *idl.UpgradePrimariesRequest) (*idl.UpgradePrimariesReply, error) {
 	m.increaseCalls()
 
@@ -88,6 +94,11 @@ func (m *MockAgentServer) RenameDirectories(context.Context, *idl.RenameDirector
 	return &idl.RenameDirectoriesReply{}, nil
 }
 
+func (m *MockAgentServer) RevertDataDirectories(context.Context, *idl.RevertDataDirectoriesRequest) (*idl.RevertDataDirectoriesReply, error) {
+	m.increaseCalls()
+	return &idl.RevertDataDirectoriesReply{}, nil
+}
+
 func (m *MockAgentServer) DeleteDataDirectories(context.Context, *idl.DeleteDataDirectoriesRequest) (*idl.DeleteDataDirectoriesReply, error) {
 	m.increaseCalls()
 	return &idl.DeleteDataDirectoriesReply{}, nil
@@ -118,6 +129,11 @@ func (m *MockAgentServer) RsyncDataDirectories(context.Context, *idl.RsyncReques
 	return &idl.RsyncReply{}, nil
 }
 
+func (m *MockAgentServer) RsyncDirectory(context.Context, *idl.RsyncDirectoryRequest) (*idl.RsyncDirectoryReply, error) {
+	m.increaseCalls()
+	return &idl.RsyncDirectoryReply{}, nil
+}
+
 func (m *MockAgentServer) StopAgent(ctx context.Context, in *idl.StopAgentRequest) (*idl.StopAgentReply, error) {
 	return &idl.StopAgentReply{}, nil
 }
@@ -148,3 +164,25 @@ func (m *MockAgentServer) DeleteSourceTablespaceDirectories(context.Context, *id
 	m.increaseCalls()
 	return &idl.DeleteTablespaceReply{}, nil
 }
+
+func (m *MockAgentServer) Version(context.Context, *idl.VersionRequest) (*idl.VersionReply, error) {
+	return &idl.VersionReply{}, nil
+}
+
+func (m *MockAgentServer) Healthz(context.Context, *idl.HealthzRequest) (*idl.HealthzReply, error) {
+	return &idl.HealthzReply{Healthy: true}, nil
+}
+
+func (m *MockAgentServer) ValidateDataDirectory(context.Context, *idl.ValidateDataDirectoryRequest) (*idl.ValidateDataDirectoryReply, error) {
+	m.increaseCalls()
+	return &idl.ValidateDataDirectoryReply{}, nil
+}
+
+func (m *MockAgentServer) DeleteDataDirectoriesWithProgress(*idl.DeleteDataDirectoriesRequest, idl.Agent_DeleteDataDirectoriesWithProgressServer) error {
+	m.increaseCalls()
+	return nil
+}
+
+func (m *MockAgentServer) TailLog(*idl.TailLogRequest, idl.Agent_TailLogServer) error {
+	return nil
+}
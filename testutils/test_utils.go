@@ -215,7 +215,7 @@ func VerifyRename(t *testing.T, source, target string) {
 		t.Errorf("expected source %q to exist", source)
 	}
 
-	archive := target + upgrade.OldSuffix
+	archive := upgrade.ArchivePathFor(target)
 	if !upgrade.PathExists(archive) {
 		t.Errorf("expected archive %q to exist", archive)
 	}
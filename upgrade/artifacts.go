@@ -0,0 +1,243 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/errorlist"
+)
+
+// Artifact is a directory left behind by an upgrade run, identified by the
+// upgrade ID embedded in its name.
+type Artifact struct {
+	Path string
+	ID   ID
+
+	// Created is taken from the timestamp in archive directory names, and
+	// otherwise from the modification time of the directory.
+	Created time.Time
+}
+
+// ListStateArtifacts returns the directories in dir whose names embed an
+// upgrade ID. These are the archive directories named by
+// GetArchiveDirectoryName, and the temporary and archived data directories
+// named by TempDataDir and ArchivePathFor. Since only the exact names built
+// from a data directory are recognized, temporary and archived data
+// directories are only listed alongside the data directory they were made
+// from.
+func ListStateArtifacts(dir string) ([]Artifact, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, xerrors.Errorf("listing upgrade artifacts: %w", err)
+	}
+
+	var bases []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			bases = append(bases, entry.Name())
+		}
+	}
+
+	var artifacts []Artifact
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+
+		if id, created, ok := parseArchiveDirectoryName(entry.Name()); ok {
+			artifacts = append(artifacts, Artifact{Path: path, ID: id, Created: created})
+			continue
+		}
+
+		name := strings.TrimSuffix(entry.Name(), ArchiveSuffix())
+		for _, base := range bases {
+			if id, ok := parseTempDataDirName(name, base); ok {
+				artifacts = append(artifacts, Artifact{Path: path, ID: id, Created: entry.ModTime()})
+				break
+			}
+		}
+	}
+
+	return artifacts, nil
+}
+
+// CleanStateArtifacts removes the artifacts in dir that were created before
+// olderThan. Removal continues past failures, all of which are returned.
+func CleanStateArtifacts(dir string, olderThan time.Time) error {
+	artifacts, err := ListStateArtifacts(dir)
+	if err != nil {
+		return err
+	}
+
+	var mErr error
+	for _, artifact := range artifacts {
+		if !artifact.Created.Before(olderThan) {
+			continue
+		}
+
+		if err := utils.System.RemoveAll(artifact.Path); err != nil {
+			mErr = errorlist.Append(mErr, xerrors.Errorf("removing upgrade artifact %q: %w", artifact.Path, err))
+		}
+	}
+
+	return mErr
+}
+
+// CleanStaleStateArtifacts removes the artifacts in dir that are older than
+// maxAge, as measured by utils.System.Now.
+func CleanStaleStateArtifacts(dir string, maxAge time.Duration) error {
+	return CleanStateArtifacts(dir, utils.System.Now().Add(-maxAge))
+}
+
+// FindOrphanedTempDirs returns the temporary data directories alongside the
+// given data directories whose embedded upgrade ID is not activeID. These are
+// left behind by aborted upgrades. Only names that TempDataDir builds from one
+// of the given data directories are reported. Archived source data
+// directories, and the intermediate copies made when moving across
+// filesystems, also embed an ID but are never reported, since they may hold
+// the only copy of the source.
+func FindOrphanedTempDirs(dataDirs []string, activeID ID) ([]string, error) {
+	var orphans []string
+	bases := make(map[string][]string)
+	var parents []string
+
+	for _, dataDir := range dataDirs {
+		parent, base := filepath.Split(filepath.Clean(dataDir))
+		parent = filepath.Clean(parent)
+		if _, ok := bases[parent]; !ok {
+			parents = append(parents, parent)
+		}
+		bases[parent] = append(bases[parent], base)
+	}
+
+	for _, parent := range parents {
+		entries, err := ioutil.ReadDir(parent)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, xerrors.Errorf("finding orphaned temporary data directories: %w", err)
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+
+			for _, base := range bases[parent] {
+				if id, ok := parseTempDataDirName(entry.Name(), base); ok && id != activeID {
+					orphans = append(orphans, filepath.Join(parent, entry.Name()))
+					break
+				}
+			}
+		}
+	}
+
+	return orphans, nil
+}
+
+// RemoveOrphanedTempDirs removes the directories found by
+// FindOrphanedTempDirs. Removal continues past failures, all of which are
+// returned.
+func RemoveOrphanedTempDirs(dataDirs []string, activeID ID) error {
+	orphans, err := FindOrphanedTempDirs(dataDirs, activeID)
+	if err != nil {
+		return err
+	}
+
+	var mErr error
+	for _, orphan := range orphans {
+		if err := utils.System.RemoveAll(orphan); err != nil {
+			mErr = errorlist.Append(mErr, xerrors.Errorf("removing orphaned temporary data directory %q: %w", orphan, err))
+		}
+	}
+
+	return mErr
+}
+
+// archiveTimeFormats are the timestamp formats GetArchiveDirectoryName has
+// used. Archive directories named before seconds were added to
+// ArchiveTimeFormat are only precise to the minute.
+var archiveTimeFormats = []string{ArchiveTimeFormat, "2006-01-02T15:04"}
+
+// parseArchiveDirectoryName reverses GetArchiveDirectoryName.
+func parseArchiveDirectoryName(name string) (ID, time.Time, bool) {
+	const prefix = "gpupgrade-"
+	if !strings.HasPrefix(name, prefix) {
+		return 0, time.Time{}, false
+	}
+
+	for _, format := range archiveTimeFormats {
+		if len(name) < len(prefix)+len(format)+1 {
+			continue
+		}
+
+		// IDs may themselves contain dashes, so split off the fixed width
+		// timestamp from the end rather than splitting on dashes.
+		split := len(name) - len(format)
+		if name[split-1] != '-' {
+			continue
+		}
+
+		created, err := time.ParseInLocation(format, name[split:], time.Local)
+		if err != nil {
+			continue
+		}
+
+		id, err := ParseID(name[len(prefix) : split-1])
+		if err != nil {
+			continue
+		}
+
+		return id, created, true
+	}
+
+	return 0, time.Time{}, false
+}
+
+// parseTempDataDirName returns the ID of name if it is exactly a name
+// TempDataDir builds from a data directory named base. That is either
+// "<base>.<ID>", or "<segPrefix>.<ID>.<contentID>" where base is the segment
+// prefix followed by the content ID.
+func parseTempDataDirName(name, base string) (ID, bool) {
+	if strings.HasPrefix(name, base+".") {
+		if id, err := ParseID(strings.TrimPrefix(name, base+".")); err == nil {
+			return id, true
+		}
+	}
+
+	// IDs never contain dots, so the ID is the element between the last two
+	// dots, and the content ID is everything after it.
+	last := strings.LastIndex(name, ".")
+	if last < 0 {
+		return 0, false
+	}
+
+	first := strings.LastIndex(name[:last], ".")
+	if first < 0 {
+		return 0, false
+	}
+
+	segPrefix, contentID := name[:first], name[last+1:]
+	if _, err := strconv.Atoi(contentID); err != nil || segPrefix+contentID != base {
+		return 0, false
+	}
+
+	id, err := ParseID(name[first+1 : last])
+	if err != nil {
+		return 0, false
+	}
+
+	return id, true
+}
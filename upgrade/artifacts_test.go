@@ -0,0 +1,280 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/upgrade"
+	"github.com/greenplum-db/gpupgrade/utils"
+)
+
+func TestStateArtifacts(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	old := now.Add(-48 * time.Hour)
+
+	currentID := upgrade.NewID()
+	oldID := upgrade.NewID()
+
+	// setup creates the artifacts of an old and a current run, along with
+	// entries that are not artifacts.
+	setup := func(t *testing.T) (string, map[string]string) {
+		t.Helper()
+
+		dir := testutils.GetTempDir(t, "")
+
+		paths := map[string]string{
+			"currentArchive": filepath.Join(dir, upgrade.GetArchiveDirectoryName(currentID, now)),
+			"oldArchive":     filepath.Join(dir, upgrade.GetArchiveDirectoryName(oldID, old)),
+			"currentTemp":    upgrade.TempDataDir(filepath.Join(dir, "seg1"), "seg", currentID),
+			"oldTemp":        upgrade.TempDataDir(filepath.Join(dir, "seg1"), "seg", oldID),
+			"legacyArchive":  filepath.Join(dir, "gpupgrade-"+oldID.String()+"-"+old.Format("2006-01-02T15:04")),
+			"unrelated":      filepath.Join(dir, "seg1"),
+			"lookalike":      filepath.Join(dir, "backup."+oldID.String()),
+		}
+
+		for _, path := range paths {
+			if err := os.Mkdir(path, 0700); err != nil {
+				t.Fatalf("unexpected error: %#v", err)
+			}
+		}
+
+		if err := os.Chtimes(paths["oldTemp"], old, old); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		testutils.MustWriteToFile(t, filepath.Join(dir, "config.json"), "{}")
+
+		return dir, paths
+	}
+
+	t.Run("lists the directories that embed an upgrade ID", func(t *testing.T) {
+		dir, paths := setup(t)
+		defer testutils.MustRemoveAll(t, dir)
+
+		artifacts, err := upgrade.ListStateArtifacts(dir)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		ids := make(map[string]upgrade.ID)
+		for _, artifact := range artifacts {
+			ids[artifact.Path] = artifact.ID
+		}
+
+		expected := map[string]upgrade.ID{
+			paths["currentArchive"]: currentID,
+			paths["oldArchive"]:     oldID,
+			paths["legacyArchive"]:  oldID,
+			paths["currentTemp"]:    currentID,
+			paths["oldTemp"]:        oldID,
+		}
+		if !reflect.DeepEqual(ids, expected) {
+			t.Errorf("got artifacts %v want %v", ids, expected)
+		}
+
+		for _, artifact := range artifacts {
+			if artifact.Path == paths["oldArchive"] && !artifact.Created.Equal(old) {
+				t.Errorf("got created %s want %s", artifact.Created, old)
+			}
+
+			if artifact.Path == paths["legacyArchive"] && !artifact.Created.Equal(old.Truncate(time.Minute)) {
+				t.Errorf("got created %s want %s", artifact.Created, old.Truncate(time.Minute))
+			}
+		}
+	})
+
+	t.Run("removes artifacts older than the cutoff and preserves the current run's", func(t *testing.T) {
+		dir, paths := setup(t)
+		defer testutils.MustRemoveAll(t, dir)
+
+		err := upgrade.CleanStateArtifacts(dir, now.Add(-24*time.Hour))
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		var remaining []string
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		for _, entry := range entries {
+			remaining = append(remaining, filepath.Join(dir, entry.Name()))
+		}
+
+		expected := []string{
+			paths["currentArchive"],
+			paths["currentTemp"],
+			paths["unrelated"],
+			paths["lookalike"],
+			filepath.Join(dir, "config.json"),
+		}
+		sort.Strings(expected)
+
+		if !reflect.DeepEqual(remaining, expected) {
+			t.Errorf("got remaining entries %q want %q", remaining, expected)
+		}
+	})
+
+	t.Run("measures the age of artifacts against the system clock", func(t *testing.T) {
+		dir, paths := setup(t)
+		defer testutils.MustRemoveAll(t, dir)
+
+		// With the clock frozen two days ahead, a maximum age of three days
+		// excludes the old run but not the current one.
+		utils.System.Now = func() time.Time {
+			return now.Add(48 * time.Hour)
+		}
+		defer func() {
+			utils.System.Now = time.Now
+		}()
+
+		err := upgrade.CleanStaleStateArtifacts(dir, 72*time.Hour)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		for _, name := range []string{"oldArchive", "legacyArchive", "oldTemp"} {
+			if _, err := os.Stat(paths[name]); !os.IsNotExist(err) {
+				t.Errorf("expected %s %q to be removed: %v", name, paths[name], err)
+			}
+		}
+
+		for _, name := range []string{"currentArchive", "currentTemp"} {
+			if _, err := os.Stat(paths[name]); err != nil {
+				t.Errorf("expected %s %q to be kept: %v", name, paths[name], err)
+			}
+		}
+	})
+
+	t.Run("returns every removal failure", func(t *testing.T) {
+		dir, _ := setup(t)
+		defer testutils.MustRemoveAll(t, dir)
+
+		expected := errors.New("permission denied")
+		utils.System.RemoveAll = func(path string) error {
+			return expected
+		}
+		defer func() {
+			utils.System.RemoveAll = os.RemoveAll
+		}()
+
+		err := upgrade.CleanStateArtifacts(dir, now.Add(time.Hour))
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+	})
+
+	t.Run("errors when the directory cannot be read", func(t *testing.T) {
+		_, err := upgrade.ListStateArtifacts("/does/not/exist")
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("got error %#v want %#v", err, os.ErrNotExist)
+		}
+	})
+}
+
+func TestOrphanedTempDirs(t *testing.T) {
+	activeID := upgrade.NewID()
+	staleID := upgrade.NewID()
+
+	// setup creates the data directory along with temporary data directories
+	// of the active and a stale upgrade, and the archive of a stale upgrade.
+	setup := func(t *testing.T) (string, map[string]string) {
+		t.Helper()
+
+		dir := testutils.GetTempDir(t, "")
+		dataDir := filepath.Join(dir, "seg1")
+
+		staleTemp := upgrade.TempDataDir(dataDir, "seg", staleID)
+		paths := map[string]string{
+			"dataDir":      dataDir,
+			"activeTemp":   upgrade.TempDataDir(dataDir, "seg", activeID),
+			"staleTemp":    staleTemp,
+			"staleArchive": upgrade.ArchivePathFor(staleTemp),
+			"staleCopying": staleTemp + ".copying",
+			"staleCopied":  upgrade.CopiedPath(dataDir, staleTemp),
+			"otherSegment": upgrade.TempDataDir(filepath.Join(dir, "seg2"), "seg", staleID),
+			"lookalike":    filepath.Join(dir, "backup."+staleID.String()+".1"),
+		}
+
+		for _, path := range paths {
+			if err := os.Mkdir(path, 0700); err != nil {
+				t.Fatalf("unexpected error: %#v", err)
+			}
+		}
+
+		return dir, paths
+	}
+
+	t.Run("reports temporary data directories of other upgrades", func(t *testing.T) {
+		dir, paths := setup(t)
+		defer testutils.MustRemoveAll(t, dir)
+
+		orphans, err := upgrade.FindOrphanedTempDirs([]string{paths["dataDir"], paths["dataDir"]}, activeID)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		expected := []string{paths["staleTemp"]}
+		if !reflect.DeepEqual(orphans, expected) {
+			t.Errorf("got orphans %q want %q", orphans, expected)
+		}
+	})
+
+	t.Run("ignores data directories whose parent does not exist", func(t *testing.T) {
+		orphans, err := upgrade.FindOrphanedTempDirs([]string{"/does/not/exist/seg1"}, activeID)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if len(orphans) != 0 {
+			t.Errorf("got orphans %q want none", orphans)
+		}
+	})
+
+	t.Run("removes only the orphaned temporary data directories", func(t *testing.T) {
+		dir, paths := setup(t)
+		defer testutils.MustRemoveAll(t, dir)
+
+		err := upgrade.RemoveOrphanedTempDirs([]string{paths["dataDir"]}, activeID)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if _, err := os.Stat(paths["staleTemp"]); !os.IsNotExist(err) {
+			t.Errorf("expected stale temporary data directory %q to be removed: %v", paths["staleTemp"], err)
+		}
+
+		for _, name := range []string{"dataDir", "activeTemp", "staleArchive", "staleCopying", "staleCopied", "otherSegment", "lookalike"} {
+			if _, err := os.Stat(paths[name]); err != nil {
+				t.Errorf("expected %s %q to be kept: %v", name, paths[name], err)
+			}
+		}
+	})
+
+	t.Run("returns every removal failure", func(t *testing.T) {
+		dir, paths := setup(t)
+		defer testutils.MustRemoveAll(t, dir)
+
+		expected := errors.New("permission denied")
+		utils.System.RemoveAll = func(path string) error {
+			return expected
+		}
+		defer func() {
+			utils.System.RemoveAll = os.RemoveAll
+		}()
+
+		err := upgrade.RemoveOrphanedTempDirs([]string{paths["dataDir"]}, activeID)
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+	})
+}
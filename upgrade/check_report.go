@@ -0,0 +1,156 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/blang/semver/v4"
+)
+
+// CheckCategory groups pg_upgrade check failures by what the user has to do
+// to resolve them.
+type CheckCategory string
+
+const (
+	CheckIncompatibleDataTypes CheckCategory = "incompatible data types"
+	CheckMissingLibraries      CheckCategory = "missing libraries"
+	CheckPreparedTransactions  CheckCategory = "prepared transactions"
+	CheckOther                 CheckCategory = "other"
+)
+
+// CheckFinding is a single pg_upgrade check that did not pass.
+type CheckFinding struct {
+	// Check is the description pg_upgrade prints for the check, such as
+	// "Checking for presence of required libraries".
+	Check    string
+	Category CheckCategory
+
+	// Status is either "fatal" or "warning".
+	Status string
+
+	// Details is pg_upgrade's explanation of the failure.
+	Details string
+
+	// File is the file pg_upgrade wrote listing the problem objects, relative
+	// to its working directory. It is empty if no file was written.
+	File string
+}
+
+// CheckReport is the result of running pg_upgrade --check.
+type CheckReport struct {
+	Findings []CheckFinding
+
+	// Stdout and Stderr hold the raw pg_upgrade output.
+	Stdout string
+	Stderr string
+}
+
+// RunPgUpgradeCheck runs pg_upgrade --check for the given pair of Segments and
+// parses the checks that did not pass into a CheckReport. The report is
+// returned even when pg_upgrade fails, since a failing check causes a non-zero
+// exit. Output is still written to any streams passed via WithOutputStreams.
+func RunPgUpgradeCheck(p SegmentPair, targetVersion semver.Version, options ...Option) (CheckReport, error) {
+	opts := newOptionList(options)
+
+	var stdout, stderr bytes.Buffer
+	options = append(options,
+		WithCheckOnly(),
+		WithOutputStreams(tee(&stdout, opts.Stdout), tee(&stderr, opts.Stderr)))
+
+	err := Run(p, targetVersion, options...)
+
+	report := CheckReport{
+		Findings: parseCheckOutput(stdout.String()),
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+	}
+
+	return report, err
+}
+
+func tee(buf *bytes.Buffer, w io.Writer) io.Writer {
+	if w == nil {
+		return buf
+	}
+
+	return io.MultiWriter(buf, w)
+}
+
+// checkLine matches the status line pg_upgrade prints for each check, which is
+// the description padded to a fixed width and followed by the result.
+var checkLine = regexp.MustCompile(`^(Checking .*?)\s+(ok|fatal|warning)$`)
+
+// parseCheckOutput returns a CheckFinding for each check in pg_upgrade's
+// output that is not "ok". The explanation pg_upgrade prints after a failed
+// check runs until the next check or the final "Failure, exiting".
+func parseCheckOutput(output string) []CheckFinding {
+	var findings []CheckFinding
+	var current *CheckFinding
+	var details []string
+
+	finish := func() {
+		if current == nil {
+			return
+		}
+
+		current.Details = strings.TrimSpace(strings.Join(details, "\n"))
+		findings = append(findings, *current)
+		current = nil
+		details = nil
+	}
+
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r \t")
+
+		if match := checkLine.FindStringSubmatch(line); match != nil {
+			finish()
+
+			if match[2] != "ok" {
+				current = &CheckFinding{
+					Check:    match[1],
+					Category: categorizeCheck(match[1]),
+					Status:   match[2],
+				}
+			}
+
+			continue
+		}
+
+		if strings.HasPrefix(line, "Failure, exiting") {
+			finish()
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		details = append(details, line)
+
+		if strings.HasSuffix(line, "in the file:") && i+1 < len(lines) {
+			current.File = strings.TrimSpace(lines[i+1])
+		}
+	}
+
+	finish()
+	return findings
+}
+
+func categorizeCheck(check string) CheckCategory {
+	switch {
+	case strings.Contains(check, "required libraries"):
+		return CheckMissingLibraries
+	case strings.Contains(check, "prepared transactions"):
+		return CheckPreparedTransactions
+	case strings.Contains(check, "data type"), strings.Contains(check, "columns"):
+		return CheckIncompatibleDataTypes
+	default:
+		return CheckOther
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"github.com/greenplum-db/gpupgrade/testutils/exectest"
+	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+	"github.com/greenplum-db/gpupgrade/upgrade"
+)
+
+const passingCheckOutput = `Performing Consistency Checks on Old Live Server
+------------------------------------------------
+Checking cluster versions                                   ok
+Checking database user is a superuser                       ok
+Checking for prepared transactions                          ok
+Checking for reg* system OID user data types                ok
+Checking for contrib/isn with bigint-passing mismatch       ok
+Checking for presence of required libraries                 ok
+
+*Clusters are compatible*
+`
+
+const dataTypeCheckOutput = `Performing Consistency Checks on Old Live Server
+------------------------------------------------
+Checking cluster versions                                   ok
+Checking database user is a superuser                       ok
+Checking for prepared transactions                          ok
+Checking for reg* system OID user data types                fatal
+
+Your installation contains one of the reg* data types in user tables.
+These data types reference system OIDs that are not preserved by
+pg_upgrade, so this cluster cannot currently be upgraded.  You can
+remove the problem tables and restart the upgrade.  A list of the problem
+columns is in the file:
+    tables_using_reg.txt
+
+Failure, exiting
+`
+
+const missingLibrariesCheckOutput = `Performing Consistency Checks on Old Live Server
+------------------------------------------------
+Checking cluster versions                                   ok
+Checking for prepared transactions                          ok
+Checking for presence of required libraries                 fatal
+
+Your installation references loadable libraries that are missing from the
+new installation.  You can add these libraries to the new installation,
+or remove the functions using them from the old installation.  A list of
+problem libraries is in the file:
+    loadable_libraries.txt
+
+Failure, exiting
+`
+
+func PassingCheckMain() {
+	fmt.Print(passingCheckOutput)
+}
+
+func DataTypeCheckMain() {
+	fmt.Print(dataTypeCheckOutput)
+	fmt.Fprint(os.Stderr, "could not complete checks\n")
+	os.Exit(1)
+}
+
+func MissingLibrariesCheckMain() {
+	fmt.Print(missingLibrariesCheckOutput)
+	os.Exit(1)
+}
+
+func init() {
+	exectest.RegisterMains(
+		PassingCheckMain,
+		DataTypeCheckMain,
+		MissingLibrariesCheckMain,
+	)
+}
+
+func TestRunPgUpgradeCheck(t *testing.T) {
+	testlog.SetupLogger()
+
+	pair := upgrade.SegmentPair{
+		Source: &upgrade.Segment{BinDir: "/old/bin", DataDir: "/old/data", DBID: 1, Port: 15432},
+		Target: &upgrade.Segment{BinDir: "/new/bin", DataDir: "/new/data", DBID: 1, Port: 15433},
+	}
+
+	t.Run("passes --check to pg_upgrade", func(t *testing.T) {
+		var check bool
+		cmd := exectest.NewCommandWithVerifier(PassingCheckMain, func(_ string, args ...string) {
+			for _, arg := range args {
+				check = check || arg == "--check"
+			}
+		})
+
+		upgrade.SetExecCommand(cmd)
+		defer upgrade.ResetExecCommand()
+
+		_, err := upgrade.RunPgUpgradeCheck(pair, version)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if !check {
+			t.Errorf("expected pg_upgrade to be called with --check")
+		}
+	})
+
+	t.Run("reports no findings when all checks pass", func(t *testing.T) {
+		upgrade.SetExecCommand(exectest.NewCommand(PassingCheckMain))
+		defer upgrade.ResetExecCommand()
+
+		report, err := upgrade.RunPgUpgradeCheck(pair, version)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if len(report.Findings) != 0 {
+			t.Errorf("got findings %+v want none", report.Findings)
+		}
+
+		if report.Stdout != passingCheckOutput {
+			t.Errorf("got stdout %q want %q", report.Stdout, passingCheckOutput)
+		}
+	})
+
+	t.Run("parses incompatible data types", func(t *testing.T) {
+		upgrade.SetExecCommand(exectest.NewCommand(DataTypeCheckMain))
+		defer upgrade.ResetExecCommand()
+
+		report, err := upgrade.RunPgUpgradeCheck(pair, version)
+
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			t.Errorf("got error %#v want type %T", err, exitErr)
+		}
+
+		expected := []upgrade.CheckFinding{{
+			Check:    "Checking for reg* system OID user data types",
+			Category: upgrade.CheckIncompatibleDataTypes,
+			Status:   "fatal",
+			Details: `Your installation contains one of the reg* data types in user tables.
+These data types reference system OIDs that are not preserved by
+pg_upgrade, so this cluster cannot currently be upgraded.  You can
+remove the problem tables and restart the upgrade.  A list of the problem
+columns is in the file:
+    tables_using_reg.txt`,
+			File: "tables_using_reg.txt",
+		}}
+		if !reflect.DeepEqual(report.Findings, expected) {
+			t.Errorf("got findings %+v want %+v", report.Findings, expected)
+		}
+
+		if report.Stdout != dataTypeCheckOutput {
+			t.Errorf("got stdout %q want %q", report.Stdout, dataTypeCheckOutput)
+		}
+
+		if report.Stderr != "could not complete checks\n" {
+			t.Errorf("got stderr %q want %q", report.Stderr, "could not complete checks\n")
+		}
+	})
+
+	t.Run("parses missing libraries", func(t *testing.T) {
+		upgrade.SetExecCommand(exectest.NewCommand(MissingLibrariesCheckMain))
+		defer upgrade.ResetExecCommand()
+
+		report, err := upgrade.RunPgUpgradeCheck(pair, version)
+		if err == nil {
+			t.Errorf("expected error, got nil")
+		}
+
+		if len(report.Findings) != 1 {
+			t.Fatalf("got %d findings want 1", len(report.Findings))
+		}
+
+		finding := report.Findings[0]
+		if finding.Category != upgrade.CheckMissingLibraries {
+			t.Errorf("got category %q want %q", finding.Category, upgrade.CheckMissingLibraries)
+		}
+
+		if finding.File != "loadable_libraries.txt" {
+			t.Errorf("got file %q want %q", finding.File, "loadable_libraries.txt")
+		}
+	})
+
+	t.Run("also writes the output to the caller's streams", func(t *testing.T) {
+		upgrade.SetExecCommand(exectest.NewCommand(DataTypeCheckMain))
+		defer upgrade.ResetExecCommand()
+
+		stdout := new(bytes.Buffer)
+		stderr := new(bytes.Buffer)
+		_, _ = upgrade.RunPgUpgradeCheck(pair, version, upgrade.WithOutputStreams(stdout, stderr))
+
+		if stdout.String() != dataTypeCheckOutput {
+			t.Errorf("got stdout %q want %q", stdout.String(), dataTypeCheckOutput)
+		}
+
+		if stderr.String() != "could not complete checks\n" {
+			t.Errorf("got stderr %q want %q", stderr.String(), "could not complete checks\n")
+		}
+	})
+}
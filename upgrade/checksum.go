@@ -0,0 +1,183 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/greenplum-db/gpupgrade/utils"
+)
+
+// ErrChecksumMismatch is returned by VerifyDirChecksum when the copied
+// directory differs from its source.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ChecksumMismatchError is the backing error type for ErrChecksumMismatch.
+// Path is relative to the directories being compared.
+type ChecksumMismatchError struct {
+	Path   string
+	Reason string
+}
+
+func (c *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("copied file %q does not match its source: %s", c.Path, c.Reason)
+}
+
+func (c *ChecksumMismatchError) Is(err error) bool {
+	return err == ErrChecksumMismatch
+}
+
+// VerifyDirChecksum ensures that dst is an exact copy of src by comparing the
+// file types, sizes, and contents of every entry in both trees. Symlinks are
+// compared by their targets rather than followed. The first mismatch found is
+// returned as a ChecksumMismatchError.
+func VerifyDirChecksum(src, dst string) error {
+	err := walkEntries(src, func(path, rel string, info os.FileInfo) error {
+		dstInfo, err := utils.System.Lstat(filepath.Join(dst, rel))
+		if os.IsNotExist(err) {
+			return &ChecksumMismatchError{Path: rel, Reason: "missing from copy"}
+		}
+		if err != nil {
+			return err
+		}
+
+		if info.Mode().Type() != dstInfo.Mode().Type() {
+			return &ChecksumMismatchError{Path: rel, Reason: fmt.Sprintf("file type %s differs from %s", dstInfo.Mode().Type(), info.Mode().Type())}
+		}
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			return compareLinks(path, filepath.Join(dst, rel), rel)
+
+		case info.Mode().IsRegular():
+			if info.Size() != dstInfo.Size() {
+				return &ChecksumMismatchError{Path: rel, Reason: fmt.Sprintf("size %d differs from %d", dstInfo.Size(), info.Size())}
+			}
+
+			return compareChecksums(path, filepath.Join(dst, rel), rel)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Walk the copy to find any entries that are not in the source.
+	return walkEntries(dst, func(path, rel string, info os.FileInfo) error {
+		_, err := utils.System.Lstat(filepath.Join(src, rel))
+		if os.IsNotExist(err) {
+			return &ChecksumMismatchError{Path: rel, Reason: "not present in source"}
+		}
+
+		return err
+	})
+}
+
+// walkEntries calls fn for root and every entry beneath it in lexical order,
+// with the entry's path relative to root. Like dirSize it uses
+// utils.System.Lstat, so symlinks are visited rather than followed. The walk
+// stops at the first error.
+func walkEntries(root string, fn func(path, rel string, info os.FileInfo) error) error {
+	info, err := utils.System.Lstat(root)
+	if err != nil {
+		return err
+	}
+
+	var walk func(path, rel string, info os.FileInfo) error
+	walk = func(path, rel string, info os.FileInfo) error {
+		if err := fn(path, rel, info); err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			return nil
+		}
+
+		names, err := readDirNames(path)
+		if err != nil {
+			return err
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			child := filepath.Join(path, name)
+
+			info, err := utils.System.Lstat(child)
+			if err != nil {
+				return err
+			}
+
+			if err := walk(child, filepath.Join(rel, name), info); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return walk(root, ".", info)
+}
+
+func compareLinks(src, dst, rel string) error {
+	srcLink, err := utils.System.Readlink(src)
+	if err != nil {
+		return err
+	}
+
+	dstLink, err := utils.System.Readlink(dst)
+	if err != nil {
+		return err
+	}
+
+	if srcLink != dstLink {
+		return &ChecksumMismatchError{Path: rel, Reason: fmt.Sprintf("symlink target %q differs from %q", dstLink, srcLink)}
+	}
+
+	return nil
+}
+
+func compareChecksums(src, dst, rel string) error {
+	srcSum, err := checksum(src)
+	if err != nil {
+		return err
+	}
+
+	dstSum, err := checksum(dst)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(srcSum, dstSum) {
+		return &ChecksumMismatchError{Path: rel, Reason: "contents differ"}
+	}
+
+	return nil
+}
+
+func checksum(path string) (_ []byte, err error) {
+	file, err := utils.System.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cErr := file.Close(); cErr != nil && err == nil {
+			err = cErr
+		}
+	}()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, err
+	}
+
+	return hash.Sum(nil), nil
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/upgrade"
+	"github.com/greenplum-db/gpupgrade/utils"
+)
+
+func TestVerifyDirChecksum(t *testing.T) {
+	// tree creates a data directory with nested files and a symlink, and
+	// returns its path.
+	tree := func(t *testing.T, parent, name string) string {
+		t.Helper()
+
+		dir := filepath.Join(parent, name)
+		if err := os.MkdirAll(filepath.Join(dir, "base", "1"), 0700); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		testutils.MustWriteToFile(t, filepath.Join(dir, "postgresql.conf"), "port = 15432")
+		testutils.MustWriteToFile(t, filepath.Join(dir, "base", "1", "16384"), "relation data")
+		if err := os.Symlink("/tmp/tablespace", filepath.Join(dir, "16386")); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		return dir
+	}
+
+	mismatch := func(t *testing.T, err error, path string) {
+		t.Helper()
+
+		var mismatchErr *upgrade.ChecksumMismatchError
+		if !errors.As(err, &mismatchErr) {
+			t.Fatalf("got error %#v want type %T", err, mismatchErr)
+		}
+
+		if !errors.Is(err, upgrade.ErrChecksumMismatch) {
+			t.Errorf("got error %#v want %#v", err, upgrade.ErrChecksumMismatch)
+		}
+
+		if mismatchErr.Path != path {
+			t.Errorf("got mismatched path %q want %q", mismatchErr.Path, path)
+		}
+	}
+
+	t.Run("succeeds when the trees match", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		src, dst := tree(t, dir, "src"), tree(t, dir, "dst")
+
+		err := upgrade.VerifyDirChecksum(src, dst)
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+	})
+
+	t.Run("errors when a file differs by a byte", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		src, dst := tree(t, dir, "src"), tree(t, dir, "dst")
+		testutils.MustWriteToFile(t, filepath.Join(dst, "base", "1", "16384"), "relation dara")
+
+		err := upgrade.VerifyDirChecksum(src, dst)
+		mismatch(t, err, filepath.Join("base", "1", "16384"))
+	})
+
+	t.Run("errors when a file is truncated", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		src, dst := tree(t, dir, "src"), tree(t, dir, "dst")
+		testutils.MustWriteToFile(t, filepath.Join(dst, "postgresql.conf"), "port =")
+
+		err := upgrade.VerifyDirChecksum(src, dst)
+		mismatch(t, err, "postgresql.conf")
+	})
+
+	t.Run("errors when a file is missing from the copy", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		src, dst := tree(t, dir, "src"), tree(t, dir, "dst")
+		if err := os.Remove(filepath.Join(dst, "postgresql.conf")); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		err := upgrade.VerifyDirChecksum(src, dst)
+		mismatch(t, err, "postgresql.conf")
+	})
+
+	t.Run("errors when the copy has an extra file", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		src, dst := tree(t, dir, "src"), tree(t, dir, "dst")
+		testutils.MustWriteToFile(t, filepath.Join(dst, "base", "extra"), "")
+
+		err := upgrade.VerifyDirChecksum(src, dst)
+		mismatch(t, err, filepath.Join("base", "extra"))
+	})
+
+	t.Run("errors when a symlink target differs", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		src, dst := tree(t, dir, "src"), tree(t, dir, "dst")
+		if err := os.Remove(filepath.Join(dst, "16386")); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		if err := os.Symlink("/tmp/other", filepath.Join(dst, "16386")); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		err := upgrade.VerifyDirChecksum(src, dst)
+		mismatch(t, err, "16386")
+	})
+
+	t.Run("errors when a file cannot be read", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		src, dst := tree(t, dir, "src"), tree(t, dir, "dst")
+
+		expected := errors.New("permission denied")
+		utils.System.Open = func(name string) (*os.File, error) {
+			return nil, expected
+		}
+		defer func() {
+			utils.System.Open = os.Open
+		}()
+
+		err := upgrade.VerifyDirChecksum(src, dst)
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+	})
+
+	t.Run("errors when an entry cannot be stat'd", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		src, dst := tree(t, dir, "src"), tree(t, dir, "dst")
+
+		expected := errors.New("permission denied")
+		utils.System.Lstat = func(name string) (os.FileInfo, error) {
+			if name != src && strings.HasPrefix(name, src) {
+				return nil, expected
+			}
+
+			return os.Lstat(name)
+		}
+		defer func() {
+			utils.System.Lstat = os.Lstat
+		}()
+
+		err := upgrade.VerifyDirChecksum(src, dst)
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+	})
+}
@@ -4,6 +4,7 @@
 package upgrade
 
 import (
+	"io/ioutil"
 	"os"
 	"testing"
 
@@ -22,6 +23,34 @@ func ResetExecCommand() {
 	execCommand = nil
 }
 
+// CopiedPath exposes the name of a complete cross-filesystem copy so that
+// tests can simulate an interrupted move.
+func CopiedPath(src, dst string) string {
+	return copiedPath(src, dst)
+}
+
+func SetNewID(idFunc func() ID) {
+	newID = idFunc
+}
+
+func ResetNewID() {
+	newID = NewID
+}
+
+// SetXattrFuncs replaces the functions used to copy extended attributes.
+func SetXattrFuncs(list func(string, []byte) (int, error), get func(string, string, []byte) (int, error), set func(string, string, []byte, int) error) {
+	llistxattr, lgetxattr, lsetxattr = list, get, set
+}
+
+// SetReadDir replaces the function used to read directories.
+func SetReadDir(f func(string) ([]os.FileInfo, error)) {
+	readDir = f
+}
+
+func ResetReadDir() {
+	readDir = ioutil.ReadDir
+}
+
 // NewOptionList is a public version of upgrade.newOptionList for testing
 // purposes.
 func NewOptionList(opts []Option) *optionList {
@@ -0,0 +1,465 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"golang.org/x/sys/unix"
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/utils"
+)
+
+// When a rename crosses filesystems the directory is first copied to
+// dst+copyingSuffix. Once the copy is complete it is renamed to the marker
+// returned by copiedPath, the original is removed, and finally it is renamed to
+// dst. The intermediate names allow a re-run to tell a partial copy, which is
+// discarded, from a complete one, which only needs to be finished.
+const (
+	copyingSuffix = ".copying"
+	copiedSuffix  = ".copied"
+)
+
+// copiedPath returns the name of a complete copy of src that is to become
+// dst. The name identifies src as well as dst, since both ArchiveSource and
+// RestoreSource move other directories to the source data directory, and
+// finishing a move removes its src. A copy is only ever finished, and its src
+// removed, by a move from that same src.
+func copiedPath(src, dst string) string {
+	sum := sha256.Sum256([]byte(filepath.Clean(src)))
+	return dst + copiedSuffix + "." + hex.EncodeToString(sum[:])[:12]
+}
+
+// moveAcrossFilesystems moves src to dst by recursively copying and then
+// removing src. It is used as a fallback when renaming returns EXDEV. File
+// contents are copied at no more than bytesPerSecond, unless it is zero, and
+// entries matching exclude are not copied.
+func moveAcrossFilesystems(src, dst string, bytesPerSecond int64, exclude []string) error {
+	copying := dst + copyingSuffix
+	if err := utils.System.RemoveAll(copying); err != nil {
+		return xerrors.Errorf("removing partial copy: %w", err)
+	}
+
+	if err := checkArchiveSpace(src, filepath.Dir(dst), exclude); err != nil {
+		return err
+	}
+
+	gplog.Debug("copying %q to %q since they are on different filesystems", src, copying)
+	opts := CopyOptions{PreserveOwnership: true, BytesPerSecond: bytesPerSecond, Exclude: exclude}
+	if err := copyTree(src, copying, opts); err != nil {
+		return err
+	}
+
+	if err := utils.System.Rename(copying, copiedPath(src, dst)); err != nil {
+		return err
+	}
+
+	return finishInterruptedMove(OSFileSystem{}, src, dst)
+}
+
+// CopyOptions controls how CopyDir copies a directory.
+type CopyOptions struct {
+	// PreserveOwnership copies the owner and group of each file. Only root
+	// can give files away to other users, so this is typically only set
+	// when running as root.
+	PreserveOwnership bool
+
+	// BytesPerSecond limits the rate at which file contents are copied.
+	// Zero is unlimited.
+	BytesPerSecond int64
+
+	// Exclude lists glob patterns, relative to src, of entries that are not
+	// copied. Excluding a directory excludes everything beneath it.
+	Exclude []string
+}
+
+// CopyDir recursively copies src to dst preserving permissions, modification
+// times, symlinks, hard links within src, and optionally ownership. The copy
+// is made to a temporary sibling of dst which is renamed to dst only once it
+// is complete, so an interrupted copy never leaves a partial dst. dst must not
+// already exist.
+func CopyDir(src, dst string, opts CopyOptions) error {
+	exist, err := PathExist(dst)
+	if err != nil {
+		return err
+	}
+
+	if exist {
+		return xerrors.Errorf("copying %q to %q: %w", src, dst, os.ErrExist)
+	}
+
+	tmp := dst + copyingSuffix
+	if err := utils.System.RemoveAll(tmp); err != nil {
+		return xerrors.Errorf("removing partial copy: %w", err)
+	}
+
+	if err := copyTree(src, tmp, opts); err != nil {
+		if rErr := utils.System.RemoveAll(tmp); rErr != nil {
+			gplog.Debug("removing partial copy %q: %v", tmp, rErr)
+		}
+
+		return err
+	}
+
+	return utils.System.Rename(tmp, dst)
+}
+
+// ErrInsufficientDiskSpace is returned by CheckArchiveSpace when the target
+// filesystem cannot hold a copy of the source.
+var ErrInsufficientDiskSpace = errors.New("insufficient disk space")
+
+// InsufficientDiskSpaceError is the backing error type for
+// ErrInsufficientDiskSpace.
+type InsufficientDiskSpaceError struct {
+	Source    string
+	Target    string
+	Required  uint64
+	Available uint64
+}
+
+func (i *InsufficientDiskSpaceError) Error() string {
+	return fmt.Sprintf("insufficient disk space to copy %q to %q: requires %d bytes but only %d are available, short by %d bytes",
+		i.Source, i.Target, i.Required, i.Available, i.Required-i.Available)
+}
+
+func (i *InsufficientDiskSpaceError) Is(err error) bool {
+	return err == ErrInsufficientDiskSpace
+}
+
+// CheckArchiveSpace ensures the filesystem containing targetParent has enough
+// available space to hold a copy of source.
+func CheckArchiveSpace(source, targetParent string) error {
+	return checkArchiveSpace(source, targetParent, nil)
+}
+
+func checkArchiveSpace(source, targetParent string, exclude []string) error {
+	// Count the bytes copyTree writes: it recreates symlinks rather than
+	// copying what they point to, and preserves hard links. Entries that
+	// cannot be read fail the copy itself, so check the space for the rest.
+	size, err := dirSize(source, dirSizeOptions{exclude: exclude, symlinks: true})
+	if err != nil && !errors.Is(err, ErrIncompleteDirSize) {
+		return xerrors.Errorf("computing size of %q: %w", source, err)
+	}
+	required := uint64(size)
+
+	var stat unix.Statfs_t
+	if err := utils.System.Statfs(targetParent, &stat); err != nil {
+		return xerrors.Errorf("checking available space for %q: %w", targetParent, err)
+	}
+
+	available := stat.Bavail * uint64(stat.Bsize)
+	if required > available {
+		return &InsufficientDiskSpaceError{Source: source, Target: targetParent, Required: required, Available: available}
+	}
+
+	return nil
+}
+
+// skipExcluded reports whether path, found while walking root, matches one
+// of the exclude patterns. When it does and path is a directory it returns
+// filepath.SkipDir so that nothing beneath it is walked.
+func skipExcluded(root, path string, info os.FileInfo, exclude []string) (bool, error) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return false, err
+	}
+
+	for _, pattern := range exclude {
+		matched, err := filepath.Match(pattern, rel)
+		if err != nil {
+			return false, xerrors.Errorf("exclude pattern %q: %w", pattern, err)
+		}
+
+		if !matched {
+			continue
+		}
+
+		gplog.Debug("excluding %q from copy of %q", rel, root)
+		if info.IsDir() {
+			return true, filepath.SkipDir
+		}
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// verifyExcludePatterns ensures each pattern is a valid glob, so that a bad
+// pattern is reported before anything is moved.
+func verifyExcludePatterns(exclude []string) error {
+	for _, pattern := range exclude {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return xerrors.Errorf("exclude pattern %q: %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+func getFileID(info os.FileInfo) (fileID, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || stat.Nlink < 2 {
+		return fileID{}, false
+	}
+
+	return fileID{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}, true
+}
+
+// finishInterruptedMove completes a previous moveAcrossFilesystems of src to
+// dst that finished copying but did not finish removing src. It is a no-op
+// if there is no completed copy of src, including when there is a completed
+// copy of another directory to dst.
+func finishInterruptedMove(fs FileSystem, src, dst string) error {
+	copied := copiedPath(src, dst)
+
+	exist, err := PathExistFS(fs, copied)
+	if err != nil {
+		return err
+	}
+
+	if !exist {
+		return nil
+	}
+
+	if err := fs.RemoveAll(src); err != nil {
+		return err
+	}
+
+	return fs.Rename(copied, dst)
+}
+
+// copyTree recursively copies src to dst preserving permissions, modification
+// times, symlinks, hard links within src, extended attributes, and ownership
+// if requested. Extended attributes that cannot be preserved, for example
+// because dst's filesystem does not support them, are logged as a warning
+// rather than failing the copy.
+func copyTree(src, dst string, opts CopyOptions) error {
+	// Directory permissions are applied after their contents are copied so
+	// read-only directories can still be populated.
+	var dirs []string
+	var infos []os.FileInfo
+
+	links := make(map[fileID]string)
+
+	// The limiter is shared across files so the rate applies to the copy as
+	// a whole.
+	limiter := newRateLimiter(opts.BytesPerSecond)
+
+	var xattrErr error
+	var xattrFailures int
+	copyAttrs := func(path, target string) {
+		if !xattrsSupported {
+			return
+		}
+
+		if err := copyXattrs(path, target); err != nil {
+			xattrFailures++
+			if xattrErr == nil {
+				xattrErr = err
+			}
+		}
+	}
+
+	if !xattrsSupported {
+		gplog.Warn("extended attributes and ACLs are not preserved on this platform when copying %q to %q", src, dst)
+	}
+
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		if skip, err := skipExcluded(src, path, info, opts.Exclude); skip || err != nil {
+			return err
+		}
+
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir():
+			if err := utils.System.Mkdir(target, 0700); err != nil {
+				return err
+			}
+
+			copyAttrs(path, target)
+			dirs = append(dirs, target)
+			infos = append(infos, info)
+			return nil
+
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := utils.System.Readlink(path)
+			if err != nil {
+				return err
+			}
+
+			if err := utils.System.Symlink(link, target); err != nil {
+				return err
+			}
+
+			copyAttrs(path, target)
+			return chown(target, info, opts)
+
+		case info.Mode().IsRegular():
+			if id, ok := getFileID(info); ok {
+				if link, ok := links[id]; ok {
+					return os.Link(link, target)
+				}
+				links[id] = target
+			}
+
+			if err := copyFile(path, target, info, opts, limiter, copyAttrs); err != nil {
+				return err
+			}
+
+			return os.Chtimes(target, info.ModTime(), info.ModTime())
+
+		default:
+			return xerrors.Errorf("copying %q: unsupported file type %s", path, info.Mode().Type())
+		}
+	})
+	if err != nil {
+		return xerrors.Errorf("copying %q to %q: %w", src, dst, err)
+	}
+
+	if xattrFailures > 0 {
+		gplog.Warn("could not preserve the extended attributes or ACLs of %d entries when copying %q to %q: %v", xattrFailures, src, dst, xattrErr)
+	}
+
+	// Apply in reverse so children are updated while their parents are still
+	// accessible.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Chmod(dirs[i], infos[i].Mode().Perm()); err != nil {
+			return err
+		}
+
+		if err := chown(dirs[i], infos[i], opts); err != nil {
+			return err
+		}
+
+		if err := os.Chtimes(dirs[i], infos[i].ModTime(), infos[i].ModTime()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyFile copies the contents of src to dst, calling copyAttrs before
+// applying src's permissions since setting extended attributes requires
+// write permission.
+func copyFile(src, dst string, info os.FileInfo, opts CopyOptions, limiter *rateLimiter, copyAttrs func(src, dst string)) (err error) {
+	in, err := utils.System.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cErr := in.Close(); cErr != nil && err == nil {
+			err = cErr
+		}
+	}()
+
+	// Create the file writable by its owner regardless of src's permissions
+	// and the umask, and apply src's permissions once the copy is complete.
+	out, err := utils.System.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cErr := out.Close(); cErr != nil && err == nil {
+			err = cErr
+		}
+	}()
+
+	if _, err := io.Copy(limiter.Writer(out), in); err != nil {
+		return err
+	}
+
+	copyAttrs(src, dst)
+
+	if err := out.Chmod(info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	return chown(dst, info, opts)
+}
+
+// rateLimiter limits the average rate of writes through its Writers. Rather
+// than polling, it sleeps until the bytes written so far are within the
+// limit, so the rate stays accurate over long copies. A nil rateLimiter does
+// not limit.
+type rateLimiter struct {
+	bytesPerSecond int64
+	start          time.Time
+	written        int64
+}
+
+func newRateLimiter(bytesPerSecond int64) *rateLimiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+
+	return &rateLimiter{bytesPerSecond: bytesPerSecond, start: time.Now()}
+}
+
+func (r *rateLimiter) Writer(w io.Writer) io.Writer {
+	if r == nil {
+		return w
+	}
+
+	return &limitedWriter{w: w, limiter: r}
+}
+
+func (r *rateLimiter) wait(n int) {
+	r.written += int64(n)
+
+	expected := time.Duration(float64(r.written) / float64(r.bytesPerSecond) * float64(time.Second))
+	if delay := expected - time.Since(r.start); delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+type limitedWriter struct {
+	w       io.Writer
+	limiter *rateLimiter
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	n, err := l.w.Write(p)
+	l.limiter.wait(n)
+	return n, err
+}
+
+func chown(path string, info os.FileInfo, opts CopyOptions) error {
+	if !opts.PreserveOwnership {
+		return nil
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+
+	return os.Lchown(path, int(stat.Uid), int(stat.Gid))
+}
@@ -0,0 +1,466 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+	"github.com/greenplum-db/gpupgrade/upgrade"
+	"github.com/greenplum-db/gpupgrade/utils"
+)
+
+func TestCheckArchiveSpace(t *testing.T) {
+	testlog.SetupLogger()
+
+	// statfs returns a Statfs function reporting the given available bytes.
+	statfs := func(available uint64) func(string, *unix.Statfs_t) error {
+		return func(path string, buf *unix.Statfs_t) error {
+			buf.Bsize = 1
+			buf.Bavail = available
+			return nil
+		}
+	}
+
+	// source creates a directory containing 100 bytes of files, a hard link
+	// to one of them, and a symlink to a file outside of it.
+	source := func(t *testing.T) (string, func(*testing.T)) {
+		t.Helper()
+
+		dir := testutils.GetTempDir(t, "")
+		source := filepath.Join(dir, "source")
+		if err := os.MkdirAll(filepath.Join(source, "base"), 0700); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		testutils.MustWriteToFile(t, filepath.Join(source, "postgresql.conf"), strings.Repeat("a", 60))
+		testutils.MustWriteToFile(t, filepath.Join(source, "base", "16384"), strings.Repeat("b", 40))
+		if err := os.Link(filepath.Join(source, "base", "16384"), filepath.Join(source, "base", "16385")); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		outside := filepath.Join(dir, "outside")
+		testutils.MustWriteToFile(t, outside, strings.Repeat("c", 1000))
+		if err := os.Symlink(outside, filepath.Join(source, "link")); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		return source, func(t *testing.T) {
+			testutils.MustRemoveAll(t, dir)
+		}
+	}
+
+	symlinkSize := func(t *testing.T, source string) uint64 {
+		t.Helper()
+
+		info, err := os.Lstat(filepath.Join(source, "link"))
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		return uint64(info.Size())
+	}
+
+	t.Run("succeeds when there is enough space", func(t *testing.T) {
+		source, cleanup := source(t)
+		defer cleanup(t)
+
+		utils.System.Statfs = statfs(100 + symlinkSize(t, source))
+		defer func() {
+			utils.System.Statfs = unix.Statfs
+		}()
+
+		err := upgrade.CheckArchiveSpace(source, "/target")
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+	})
+
+	t.Run("does not follow symlinks or double count hard links", func(t *testing.T) {
+		source, cleanup := source(t)
+		defer cleanup(t)
+
+		available := 100 + symlinkSize(t, source) - 1
+		utils.System.Statfs = statfs(available)
+		defer func() {
+			utils.System.Statfs = unix.Statfs
+		}()
+
+		err := upgrade.CheckArchiveSpace(source, "/target")
+
+		var spaceErr *upgrade.InsufficientDiskSpaceError
+		if !errors.As(err, &spaceErr) {
+			t.Fatalf("got error %#v want type %T", err, spaceErr)
+		}
+
+		if !errors.Is(err, upgrade.ErrInsufficientDiskSpace) {
+			t.Errorf("got error %#v want %#v", err, upgrade.ErrInsufficientDiskSpace)
+		}
+
+		if spaceErr.Required != available+1 {
+			t.Errorf("got required %d want %d", spaceErr.Required, available+1)
+		}
+
+		if !strings.Contains(err.Error(), "short by 1 bytes") {
+			t.Errorf("expected error %q to contain the shortfall", err.Error())
+		}
+	})
+
+	t.Run("checks the space for the entries that can be read", func(t *testing.T) {
+		source, cleanup := source(t)
+		defer cleanup(t)
+
+		unreadable := filepath.Join(source, "postgresql.conf")
+		utils.System.Lstat = func(name string) (os.FileInfo, error) {
+			if name == unreadable {
+				return nil, &os.PathError{Op: "lstat", Path: name, Err: os.ErrPermission}
+			}
+
+			return os.Lstat(name)
+		}
+		utils.System.Statfs = statfs(40 + symlinkSize(t, source))
+		defer func() {
+			utils.System.Lstat = os.Lstat
+			utils.System.Statfs = unix.Statfs
+		}()
+
+		err := upgrade.CheckArchiveSpace(source, "/target")
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+	})
+
+	t.Run("errors when statfs fails", func(t *testing.T) {
+		source, cleanup := source(t)
+		defer cleanup(t)
+
+		expected := errors.New("permission denied")
+		utils.System.Statfs = func(path string, buf *unix.Statfs_t) error {
+			return expected
+		}
+		defer func() {
+			utils.System.Statfs = unix.Statfs
+		}()
+
+		err := upgrade.CheckArchiveSpace(source, "/target")
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+	})
+
+	t.Run("errors when the source does not exist", func(t *testing.T) {
+		err := upgrade.CheckArchiveSpace("/does/not/exist", "/target")
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("got error %#v want %#v", err, os.ErrNotExist)
+		}
+	})
+
+	t.Run("ArchiveSource does not copy across filesystems without enough space", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+
+		testutils.MustWriteToFile(t, filepath.Join(source, "postgresql.conf"), "port = 15432")
+		archive := upgrade.ArchivePathFor(target)
+
+		utils.System.Rename = crossDeviceRename(source, archive)
+		utils.System.Statfs = statfs(0)
+		defer func() {
+			utils.System.Rename = os.Rename
+			utils.System.Statfs = unix.Statfs
+		}()
+
+		_, err := upgrade.ArchiveSource(source, target, true)
+		if !errors.Is(err, upgrade.ErrInsufficientDiskSpace) {
+			t.Errorf("got error %#v want %#v", err, upgrade.ErrInsufficientDiskSpace)
+		}
+
+		if !upgrade.PathExists(source) {
+			t.Errorf("expected source %q to exist", source)
+		}
+
+		for _, path := range []string{archive, archive + ".copying"} {
+			if upgrade.PathExists(path) {
+				t.Errorf("expected %q to not exist", path)
+			}
+		}
+	})
+}
+
+func TestArchiveSourceWithRateLimit(t *testing.T) {
+	testlog.SetupLogger()
+
+	t.Run("limits the rate of copies across filesystems", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+
+		// 20KB across two files at 100KB/s should take at least 200ms.
+		testutils.MustWriteToFile(t, filepath.Join(source, "base1"), strings.Repeat("a", 10*1024))
+		testutils.MustWriteToFile(t, filepath.Join(source, "base2"), strings.Repeat("b", 10*1024))
+		archive := upgrade.ArchivePathFor(target)
+
+		utils.System.Rename = crossDeviceRename(source, archive)
+		defer func() {
+			utils.System.Rename = os.Rename
+		}()
+
+		start := time.Now()
+		status, err := upgrade.ArchiveSourceWithRateLimit(source, target, false, 100*1024)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if status != upgrade.Archived {
+			t.Errorf("got status %s want %s", status, upgrade.Archived)
+		}
+
+		minimum := 200 * time.Millisecond
+		if elapsed := time.Since(start); elapsed < minimum {
+			t.Errorf("copy took %s want at least %s", elapsed, minimum)
+		}
+
+		if err := upgrade.VerifyDataDirectory(archive); err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+	})
+}
+
+func TestArchiveSourceWithOptions(t *testing.T) {
+	testlog.SetupLogger()
+
+	t.Run("leaves excluded entries out of a copy-mode archive", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+
+		if err := os.MkdirAll(filepath.Join(source, "pg_log"), 0700); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		testutils.MustWriteToFile(t, filepath.Join(source, "pg_log", "gpdb.csv"), "log")
+		testutils.MustWriteToFile(t, filepath.Join(source, "core.1234"), "dump")
+		testutils.MustWriteToFile(t, filepath.Join(source, "postgresql.conf"), "port = 15432")
+		archive := upgrade.ArchivePathFor(target)
+
+		utils.System.Rename = crossDeviceRename(source, archive)
+		defer func() {
+			utils.System.Rename = os.Rename
+		}()
+
+		opts := upgrade.ArchiveOptions{Exclude: []string{"pg_log", "core.*"}}
+		_, err := upgrade.ArchiveSourceWithOptions(source, target, false, opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		for _, excluded := range []string{"pg_log", "core.1234"} {
+			if upgrade.PathExists(filepath.Join(archive, excluded)) {
+				t.Errorf("expected %q to be excluded from the archive", excluded)
+			}
+		}
+
+		if !upgrade.PathExists(filepath.Join(archive, "postgresql.conf")) {
+			t.Errorf("expected postgresql.conf to be archived")
+		}
+
+		if upgrade.PathExists(source) {
+			t.Errorf("expected source %q to be removed", source)
+		}
+	})
+
+	t.Run("ignores excludes when the source is renamed", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+
+		if err := os.MkdirAll(filepath.Join(source, "pg_log"), 0700); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		archive := upgrade.ArchivePathFor(target)
+
+		opts := upgrade.ArchiveOptions{Exclude: []string{"pg_log"}}
+		_, err := upgrade.ArchiveSourceWithOptions(source, target, false, opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if !upgrade.PathExists(filepath.Join(archive, "pg_log")) {
+			t.Errorf("expected pg_log to be renamed along with the source")
+		}
+	})
+
+	t.Run("errors on an invalid exclude pattern before archiving", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+
+		opts := upgrade.ArchiveOptions{Exclude: []string{"["}}
+		_, err := upgrade.ArchiveSourceWithOptions(source, target, false, opts)
+		if !errors.Is(err, filepath.ErrBadPattern) {
+			t.Errorf("got error %#v want %#v", err, filepath.ErrBadPattern)
+		}
+
+		if !upgrade.PathExists(source) {
+			t.Errorf("expected source %q to not be archived", source)
+		}
+	})
+}
+
+func TestCopyDir(t *testing.T) {
+	testlog.SetupLogger()
+
+	// source creates a directory containing files and subdirectories with
+	// distinct permissions and modification times, and a symlink.
+	source := func(t *testing.T) (string, string, func(*testing.T)) {
+		t.Helper()
+
+		dir := testutils.GetTempDir(t, "")
+		source := filepath.Join(dir, "source")
+		if err := os.MkdirAll(filepath.Join(source, "base"), 0700); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		testutils.MustWriteToFile(t, filepath.Join(source, "postgresql.conf"), "port = 15432")
+		testutils.MustWriteToFile(t, filepath.Join(source, "base", "16384"), "data")
+
+		mtime := time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC)
+		for path, mode := range map[string]os.FileMode{
+			filepath.Join(source, "postgresql.conf"): 0640,
+			filepath.Join(source, "base", "16384"):   0400,
+			filepath.Join(source, "base"):            0750,
+		} {
+			if err := os.Chmod(path, mode); err != nil {
+				t.Fatalf("unexpected error: %#v", err)
+			}
+
+			if err := os.Chtimes(path, mtime, mtime); err != nil {
+				t.Fatalf("unexpected error: %#v", err)
+			}
+		}
+
+		if err := os.Symlink("postgresql.conf", filepath.Join(source, "link")); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		return source, filepath.Join(dir, "target"), func(t *testing.T) {
+			testutils.MustRemoveAll(t, dir)
+		}
+	}
+
+	t.Run("preserves permissions, modification times, and symlinks", func(t *testing.T) {
+		source, target, cleanup := source(t)
+		defer cleanup(t)
+
+		err := upgrade.CopyDir(source, target, upgrade.CopyOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		for _, rel := range []string{"postgresql.conf", filepath.Join("base", "16384"), "base"} {
+			expected, err := os.Stat(filepath.Join(source, rel))
+			if err != nil {
+				t.Fatalf("unexpected error: %#v", err)
+			}
+
+			actual, err := os.Stat(filepath.Join(target, rel))
+			if err != nil {
+				t.Fatalf("unexpected error: %#v", err)
+			}
+
+			if actual.Mode() != expected.Mode() {
+				t.Errorf("got mode %s for %q want %s", actual.Mode(), rel, expected.Mode())
+			}
+
+			if !actual.ModTime().Equal(expected.ModTime()) {
+				t.Errorf("got modification time %s for %q want %s", actual.ModTime(), rel, expected.ModTime())
+			}
+		}
+
+		link, err := os.Readlink(filepath.Join(target, "link"))
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if link != "postgresql.conf" {
+			t.Errorf("got link %q want %q", link, "postgresql.conf")
+		}
+
+		if err := upgrade.VerifyDirChecksum(source, target); err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+
+		if upgrade.PathExists(target + ".copying") {
+			t.Errorf("expected %q to not exist", target+".copying")
+		}
+	})
+
+	t.Run("removes the partial copy when the copy is interrupted", func(t *testing.T) {
+		source, target, cleanup := source(t)
+		defer cleanup(t)
+
+		expected := errors.New("input/output error")
+		utils.System.Open = func(name string) (*os.File, error) {
+			if filepath.Base(name) == "16384" {
+				return nil, expected
+			}
+
+			return os.Open(name)
+		}
+		defer func() {
+			utils.System.Open = os.Open
+		}()
+
+		err := upgrade.CopyDir(source, target, upgrade.CopyOptions{})
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+
+		for _, path := range []string{target, target + ".copying"} {
+			if upgrade.PathExists(path) {
+				t.Errorf("expected %q to not exist", path)
+			}
+		}
+	})
+
+	t.Run("discards a partial copy left by a previous run", func(t *testing.T) {
+		source, target, cleanup := source(t)
+		defer cleanup(t)
+
+		partial := target + ".copying"
+		if err := os.Mkdir(partial, 0700); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		testutils.MustWriteToFile(t, filepath.Join(partial, "stale"), "")
+
+		err := upgrade.CopyDir(source, target, upgrade.CopyOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if upgrade.PathExists(filepath.Join(target, "stale")) {
+			t.Errorf("expected the partial copy to be discarded")
+		}
+
+		if err := upgrade.VerifyDirChecksum(source, target); err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+	})
+
+	t.Run("errors when the destination already exists", func(t *testing.T) {
+		source, target, cleanup := source(t)
+		defer cleanup(t)
+
+		if err := os.Mkdir(target, 0700); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		err := upgrade.CopyDir(source, target, upgrade.CopyOptions{})
+		if !errors.Is(err, os.ErrExist) {
+			t.Errorf("got error %#v want %#v", err, os.ErrExist)
+		}
+	})
+}
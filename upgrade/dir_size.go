@@ -0,0 +1,153 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+
+	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/errorlist"
+)
+
+// ErrIncompleteDirSize is returned by DirSize when parts of the directory
+// could not be read.
+var ErrIncompleteDirSize = errors.New("directory size is incomplete")
+
+// IncompleteDirSizeError is the backing error type for ErrIncompleteDirSize.
+// Size is the total of the portion that could be read, and Err holds the
+// errors for the entries that could not.
+type IncompleteDirSizeError struct {
+	Dir  string
+	Size int64
+	Err  error
+}
+
+func (i *IncompleteDirSizeError) Error() string {
+	return fmt.Sprintf("computing size of %q: only %d bytes could be counted: %v", i.Dir, i.Size, i.Err)
+}
+
+func (i *IncompleteDirSizeError) Is(err error) bool {
+	return err == ErrIncompleteDirSize
+}
+
+func (i *IncompleteDirSizeError) Unwrap() error {
+	return i.Err
+}
+
+// DirSize returns the total size in bytes of the regular files in dir, for
+// estimating how long archiving or copying it will take. Symlinks are not
+// followed, so files outside of dir are not counted and files within it are
+// not counted twice. Entries that cannot be read are logged and skipped, and
+// an IncompleteDirSizeError is returned along with the size of the rest.
+func DirSize(dir string) (int64, error) {
+	return dirSize(dir, dirSizeOptions{})
+}
+
+type dirSizeOptions struct {
+	// exclude holds patterns, relative to the directory, of entries that are
+	// not counted.
+	exclude []string
+
+	// symlinks counts the size of the symlinks themselves, for copies that
+	// recreate them.
+	symlinks bool
+}
+
+// dirSize walks dir without following symlinks, counting files hard linked
+// within dir once. Only an error reading dir itself stops the walk; entries
+// below it that cannot be read are logged and skipped, and returned as an
+// IncompleteDirSizeError along with the size of the rest.
+func dirSize(dir string, opts dirSizeOptions) (int64, error) {
+	root, err := utils.System.Lstat(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var size int64
+	var skipped error
+	seen := make(map[fileID]bool)
+
+	skip := func(err error) {
+		gplog.Warn("skipping entry while computing size of %q: %v", dir, err)
+		skipped = errorlist.Append(skipped, err)
+	}
+
+	var walk func(path string, info os.FileInfo) error
+	walk = func(path string, info os.FileInfo) error {
+		if excluded, err := skipExcluded(dir, path, info, opts.exclude); excluded {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		switch {
+		case info.Mode().IsRegular():
+			if id, ok := getFileID(info); ok {
+				if seen[id] {
+					return nil
+				}
+				seen[id] = true
+			}
+
+			size += info.Size()
+
+		case info.Mode()&os.ModeSymlink != 0:
+			if opts.symlinks {
+				size += info.Size()
+			}
+
+		case info.IsDir():
+			names, err := readDirNames(path)
+			if err != nil {
+				skip(err)
+				return nil
+			}
+
+			for _, name := range names {
+				child := filepath.Join(path, name)
+
+				info, err := utils.System.Lstat(child)
+				if err != nil {
+					skip(err)
+					continue
+				}
+
+				if err := walk(child, info); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	if err := walk(dir, root); err != nil {
+		return size, err
+	}
+
+	if skipped != nil {
+		return size, &IncompleteDirSizeError{Dir: dir, Size: size, Err: skipped}
+	}
+
+	return size, nil
+}
+
+func readDirNames(dir string) (_ []string, err error) {
+	f, err := utils.System.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cErr := f.Close(); cErr != nil && err == nil {
+			err = cErr
+		}
+	}()
+
+	return f.Readdirnames(-1)
+}
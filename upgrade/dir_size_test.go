@@ -0,0 +1,142 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+	"github.com/greenplum-db/gpupgrade/upgrade"
+	"github.com/greenplum-db/gpupgrade/utils"
+)
+
+func TestDirSize(t *testing.T) {
+	testlog.SetupLogger()
+
+	// tree creates a directory containing 100 bytes of regular files in
+	// nested subdirectories, a symlink to a large file outside of it, and a
+	// symlink to a file within it.
+	tree := func(t *testing.T) (string, func(*testing.T)) {
+		t.Helper()
+
+		dir := testutils.GetTempDir(t, "")
+		root := filepath.Join(dir, "datadir")
+		if err := os.MkdirAll(filepath.Join(root, "base", "16384"), 0700); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		testutils.MustWriteToFile(t, filepath.Join(root, "postgresql.conf"), strings.Repeat("a", 60))
+		testutils.MustWriteToFile(t, filepath.Join(root, "base", "16384", "1259"), strings.Repeat("b", 40))
+
+		outside := filepath.Join(dir, "outside")
+		testutils.MustWriteToFile(t, outside, strings.Repeat("c", 1000))
+		if err := os.Symlink(outside, filepath.Join(root, "outside_link")); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if err := os.Symlink(filepath.Join(root, "base"), filepath.Join(root, "base_link")); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		return root, func(t *testing.T) {
+			testutils.MustRemoveAll(t, dir)
+		}
+	}
+
+	t.Run("counts regular files without following symlinks", func(t *testing.T) {
+		root, cleanup := tree(t)
+		defer cleanup(t)
+
+		size, err := upgrade.DirSize(root)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if size != 100 {
+			t.Errorf("got size %d want %d", size, 100)
+		}
+	})
+
+	t.Run("reports the partial size when a subtree cannot be read", func(t *testing.T) {
+		root, cleanup := tree(t)
+		defer cleanup(t)
+
+		denied := filepath.Join(root, "base", "16384")
+		utils.System.Open = func(name string) (*os.File, error) {
+			if name == denied {
+				return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrPermission}
+			}
+
+			return os.Open(name)
+		}
+		defer func() {
+			utils.System.Open = os.Open
+		}()
+
+		size, err := upgrade.DirSize(root)
+
+		var sizeErr *upgrade.IncompleteDirSizeError
+		if !errors.As(err, &sizeErr) {
+			t.Fatalf("got error %#v want type %T", err, sizeErr)
+		}
+
+		if !errors.Is(err, upgrade.ErrIncompleteDirSize) {
+			t.Errorf("got error %#v want %#v", err, upgrade.ErrIncompleteDirSize)
+		}
+
+		if !errors.Is(err, os.ErrPermission) {
+			t.Errorf("got error %#v want %#v", err, os.ErrPermission)
+		}
+
+		if size != 60 {
+			t.Errorf("got size %d want %d", size, 60)
+		}
+
+		if sizeErr.Size != size {
+			t.Errorf("got error size %d want %d", sizeErr.Size, size)
+		}
+	})
+
+	t.Run("skips entries that cannot be stat'd and counts the rest", func(t *testing.T) {
+		root, cleanup := tree(t)
+		defer cleanup(t)
+
+		expected := &os.PathError{Op: "lstat", Path: filepath.Join(root, "postgresql.conf"), Err: os.ErrPermission}
+		utils.System.Lstat = func(name string) (os.FileInfo, error) {
+			if name == expected.Path {
+				return nil, expected
+			}
+
+			return os.Lstat(name)
+		}
+		defer func() {
+			utils.System.Lstat = os.Lstat
+		}()
+
+		size, err := upgrade.DirSize(root)
+		if !errors.Is(err, upgrade.ErrIncompleteDirSize) {
+			t.Errorf("got error %#v want %#v", err, upgrade.ErrIncompleteDirSize)
+		}
+
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+
+		if size != 40 {
+			t.Errorf("got size %d want %d", size, 40)
+		}
+	})
+
+	t.Run("errors when the directory does not exist", func(t *testing.T) {
+		_, err := upgrade.DirSize("/does/not/exist")
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("got error %#v want %#v", err, os.ErrNotExist)
+		}
+	})
+}
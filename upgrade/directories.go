@@ -4,15 +4,21 @@
 package upgrade
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/user"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
+	"github.com/blang/semver/v4"
 	"github.com/greenplum-db/gp-common-go-libs/gplog"
 	"golang.org/x/xerrors"
 
@@ -25,9 +31,64 @@ const ConfigFileName = "config.json"
 const OldSuffix = ".old"
 const PGVersion = "PG_VERSION"
 
+var archiveSuffix = OldSuffix
+
+// TablespaceDeleteWorkers bounds the number of tablespace directories
+// DeleteNewTablespaceDirectories deletes in parallel.
+var TablespaceDeleteWorkers = runtime.NumCPU()
+
+// TablespaceRetryAttempts and TablespaceRetryDelay bound the retries of
+// transient failures, such as EAGAIN from a busy networked filesystem, when
+// DeleteNewTablespaceDirectories reads and removes parent dbID directories.
+var TablespaceRetryAttempts = 3
+var TablespaceRetryDelay = 500 * time.Millisecond
+
+// readDir allows tests to simulate failures reading directories.
+var readDir = ioutil.ReadDir
+
+// ArchiveSuffix returns the suffix appended to archived source data
+// directories. It defaults to OldSuffix.
+func ArchiveSuffix() string {
+	return archiveSuffix
+}
+
+// SetArchiveSuffix overrides the suffix used when archiving source data
+// directories, for environments where OldSuffix is already in use. It must be
+// set consistently in every process that archives or locates archived
+// directories.
+func SetArchiveSuffix(suffix string) error {
+	if suffix == "" {
+		return xerrors.New("archive suffix must not be empty")
+	}
+
+	if strings.ContainsRune(suffix, os.PathSeparator) {
+		return xerrors.Errorf("archive suffix %q must not contain a path separator", suffix)
+	}
+
+	archiveSuffix = suffix
+	return nil
+}
+
 var PostgresFiles = []string{"postgresql.conf", PGVersion}
 var StateDirectoryFiles = []string{"config.json", step.SubstepsFileName}
 
+// RequiredPathsFor returns the paths that identify a data directory of the
+// given Greenplum version. GPDB 7 is based on PostgreSQL 12, which renamed
+// pg_xlog and pg_clog to pg_wal and pg_xact. A zero version returns only the
+// PostgresFiles common to all versions.
+func RequiredPathsFor(version semver.Version) []string {
+	paths := append([]string{}, PostgresFiles...)
+
+	switch {
+	case version.Major == 0:
+		return paths
+	case version.Major < 7:
+		return append(paths, "pg_xlog", "pg_clog")
+	default:
+		return append(paths, "pg_wal", "pg_xact")
+	}
+}
+
 func GetConfigFile() string {
 	return filepath.Join(utils.GetStateDir(), ConfigFileName)
 }
@@ -47,6 +108,14 @@ func SegmentWorkingDirectory(stateDir string, contentID int) string {
 	return filepath.Join(pgUpgradeDirectory(stateDir), fmt.Sprintf("seg%d", contentID))
 }
 
+// PgUpgradeLogPath returns the path of the file that a segment's pg_upgrade
+// output is written to. The name includes the dbid so that each segment on a
+// host has its own log. Like pgUpgradeDirectory, it does not ensure that the
+// directory exists.
+func PgUpgradeLogPath(stateDir string, dbID int) string {
+	return filepath.Join(pgUpgradeDirectory(stateDir), fmt.Sprintf("pg_upgrade_dbid%d.log", dbID))
+}
+
 // MasterWorkingDirectory is a convenience method equivalent to
 // SegmentWorkingDirectory(stateDir, -1).
 func MasterWorkingDirectory(stateDir string) string {
@@ -83,10 +152,54 @@ func TempDataDir(datadir, segPrefix string, id ID) string {
 	return filepath.Join(dir, newBase)
 }
 
+// ErrSegmentPrefixMismatch is returned by VerifySegmentPrefix when a data
+// directory's basename does not start with the segment prefix.
+var ErrSegmentPrefixMismatch = errors.New("data directory does not match the segment prefix")
+
+// SegmentPrefixMismatchError is the backing error type for
+// ErrSegmentPrefixMismatch.
+type SegmentPrefixMismatchError struct {
+	DataDir   string
+	SegPrefix string
+}
+
+func (s *SegmentPrefixMismatchError) Error() string {
+	return fmt.Sprintf("data directory %q does not start with the segment prefix %q", s.DataDir, s.SegPrefix)
+}
+
+func (s *SegmentPrefixMismatchError) Is(err error) bool {
+	return err == ErrSegmentPrefixMismatch
+}
+
+// VerifySegmentPrefix ensures that the basename of a segment data directory
+// starts with the segment prefix, so that TempDataDir names its temporary
+// data directory after the segment rather than falling back to the rule it
+// uses for standby data directories, which are not named after the prefix.
+func VerifySegmentPrefix(datadir, segPrefix string) error {
+	base := filepath.Base(filepath.Clean(datadir))
+	if !strings.HasPrefix(base, segPrefix) {
+		return &SegmentPrefixMismatchError{DataDir: datadir, SegPrefix: segPrefix}
+	}
+
+	return nil
+}
+
+// ArchiveTimeFormat is the timestamp format used in archive directory names.
+// It includes seconds so that archives created within the same minute do not
+// collide. Colons are kept since they are valid in file names on the Linux
+// filesystems gpupgrade supports.
+const ArchiveTimeFormat = "2006-01-02T15:04:05"
+
 // GetArchiveDirectoryName returns the name of the file to be used to store logs
 //   from this run of gpupgrade during a revert.
 func GetArchiveDirectoryName(id ID, t time.Time) string {
-	return fmt.Sprintf("gpupgrade-%s-%s", id.String(), t.Format("2006-01-02T15:04"))
+	return fmt.Sprintf("gpupgrade-%s-%s", id.String(), t.Format(ArchiveTimeFormat))
+}
+
+// ArchivePathFor returns the directory ArchiveSource archives the source to for
+// the given target. It is the single source of truth for archive names.
+func ArchivePathFor(target string) string {
+	return target + ArchiveSuffix()
 }
 
 // ArchiveSource archives the source directory, and renames
@@ -97,45 +210,225 @@ func GetArchiveDirectoryName(id ID, t time.Time) string {
 // useful in link mode when the mirrors have been deleted to save disk space and
 // will upgraded later to their correct location. Thus, renameTarget is false in
 // link mode when there is only the source directory to archive.
-func ArchiveSource(source, target string, renameTarget bool) error {
+func ArchiveSource(source, target string, renameTarget bool) (ArchiveStatus, error) {
+	return ArchiveSourceWithRateLimit(source, target, renameTarget, 0)
+}
+
+// ArchiveSourceWithRateLimit is ArchiveSource, but limits the rate at which
+// data is copied when a directory is moved across filesystems, so the copy
+// does not starve a database running on the same host. A bytesPerSecond of
+// zero is unlimited.
+func ArchiveSourceWithRateLimit(source, target string, renameTarget bool, bytesPerSecond int64) (ArchiveStatus, error) {
+	return ArchiveSourceWithOptions(source, target, renameTarget, ArchiveOptions{BytesPerSecond: bytesPerSecond})
+}
+
+// ArchiveOptions controls how ArchiveSourceWithOptions moves directories
+// across filesystems.
+type ArchiveOptions struct {
+	// BytesPerSecond limits the rate at which data is copied. Zero is
+	// unlimited.
+	BytesPerSecond int64
+
+	// Exclude lists glob patterns, relative to the source data directory, of
+	// entries to leave out of the archive, such as "pg_log". Excludes only
+	// apply when the source is copied to the archive. A plain rename moves
+	// the directory as a whole, so they are ignored.
+	Exclude []string
+}
+
+// ArchiveSourceWithOptions is ArchiveSource, but copies across filesystems
+// according to opts.
+func ArchiveSourceWithOptions(source, target string, renameTarget bool, opts ArchiveOptions) (ArchiveStatus, error) {
+	return archiveSource(OSFileSystem{}, source, target, renameTarget, opts)
+}
+
+// ArchiveSourceFS is ArchiveSource operating on fs.
+func ArchiveSourceFS(fs FileSystem, source, target string, renameTarget bool) (ArchiveStatus, error) {
+	return archiveSource(fs, source, target, renameTarget, ArchiveOptions{})
+}
+
+// archiveSource times archiving the source. There are no output streams to
+// report to, so the timing is only logged and recorded to the metrics.
+func archiveSource(fs FileSystem, source, target string, renameTarget bool, opts ArchiveOptions) (ArchiveStatus, error) {
+	var status ArchiveStatus
+	err := step.Timed("archiving source directory", step.DevNullStream, func() error {
+		var err error
+		status, err = archiveSourceDirectory(fs, source, target, renameTarget, opts)
+		return err
+	})
+
+	return status, err
+}
+
+func archiveSourceDirectory(fs FileSystem, source, target string, renameTarget bool, opts ArchiveOptions) (ArchiveStatus, error) {
+	if err := verifyExcludePatterns(opts.Exclude); err != nil {
+		return ArchiveUnknown, err
+	}
+
+	if err := verifyArchivePair(source, target); err != nil {
+		return ArchiveUnknown, err
+	}
+
 	// Instead of manipulating the source to create the archive we append the
 	// old suffix to the target to achieve the same result.
-	archive := target + OldSuffix
-	if alreadyRenamed(archive, target) {
-		return nil
+	archive := ArchivePathFor(target)
+
+	// Finish any cross-filesystem moves interrupted by a previous run before
+	// inspecting the directories.
+	if err := finishInterruptedMove(fs, source, archive); err != nil {
+		return ArchiveUnknown, err
 	}
 
-	if PathExists(source) {
-		if err := renameDataDirectory(source, archive); err != nil {
-			return err
+	if renameTarget {
+		if err := finishInterruptedMove(fs, target, source); err != nil {
+			return ArchiveUnknown, err
+		}
+	}
+
+	if alreadyRenamed(fs, archive, target) {
+		return AlreadyArchived, nil
+	}
+
+	// Ensure the target is a complete data directory before archiving the
+	// source, otherwise a half-built target would be promoted in place of
+	// the source.
+	if renameTarget && pathExists(fs, source) {
+		if err := verifyDataDirectory(fs, target); err != nil {
+			return ArchiveUnknown, err
+		}
+	}
+
+	status := Archived
+	if pathExists(fs, source) {
+		if err := renameDataDirectory(fs, source, archive, opts.BytesPerSecond, opts.Exclude); err != nil {
+			return ArchiveUnknown, err
 		}
 	} else {
 		gplog.Debug("Source directory not found when renaming %q to %q. It was already renamed from a previous run.", source, archive)
+		status = SourceMissing
 	}
 
 	// In link mode mirrors have been deleted to save disk space, so there is
 	// no target to rename. Only archiving the source is needed.
 	if !renameTarget {
+		return status, nil
+	}
+
+	if err := renameDataDirectory(fs, target, source, opts.BytesPerSecond, nil); err != nil {
+		return ArchiveUnknown, err
+	}
+
+	return status, nil
+}
+
+// ArchiveStatus describes the work ArchiveSource performed. ArchiveSource
+// returns ArchiveUnknown whenever it returns an error.
+type ArchiveStatus int
+
+const (
+	// ArchiveUnknown means it is not known what work was performed, because
+	// archiving failed.
+	ArchiveUnknown ArchiveStatus = iota
+
+	// Archived means the source was renamed to the archive.
+	Archived
+
+	// AlreadyArchived means a previous run finished archiving, and nothing
+	// was renamed.
+	AlreadyArchived
+
+	// SourceMissing means the source was not found to archive, for example
+	// because a previous run archived it but did not finish renaming the
+	// target. The target is still renamed when requested.
+	SourceMissing
+)
+
+func (s ArchiveStatus) String() string {
+	switch s {
+	case ArchiveUnknown:
+		return "unknown"
+	case Archived:
+		return "archived"
+	case AlreadyArchived:
+		return "already archived"
+	case SourceMissing:
+		return "source missing"
+	default:
+		return fmt.Sprintf("ArchiveStatus(%d)", int(s))
+	}
+}
+
+// RestoreSource is the inverse of ArchiveSource. It renames source back to
+// target, and the archive back to source. For example:
+//   source '/data/dbfast1/demoDataDir0' becomes target '/data/dbfast1/demoDataDir.123ABC.0'
+//   archive '/data/dbfast1/demoDataDir.123ABC.0.old' becomes source '/data/dbfast1/demoDataDir0'
+// The failed target is left in its original location to be deleted along with
+// the other target data directories. When only the source was archived there
+// is nothing at source, and just the archive is restored.
+func RestoreSource(source, target string) error {
+	return step.Timed("restoring source directory", step.DevNullStream, func() error {
+		return restoreSource(source, target)
+	})
+}
+
+func restoreSource(source, target string) error {
+	archive := ArchivePathFor(target)
+
+	// An ArchiveSource that crossed filesystems may have been interrupted
+	// after copying the target to the source. Finish it first, so that the
+	// upgraded copy is moved back to the target below rather than left in
+	// place of the source.
+	fs := OSFileSystem{}
+	if err := finishInterruptedMove(fs, target, source); err != nil {
+		return err
+	}
+
+	if err := finishInterruptedMove(fs, source, target); err != nil {
+		return err
+	}
+
+	if err := finishInterruptedMove(fs, archive, source); err != nil {
+		return err
+	}
+
+	if alreadyRestored(archive, source) {
 		return nil
 	}
 
-	if err := renameDataDirectory(target, source); err != nil {
+	if PathExists(source) {
+		if err := renameDataDirectory(fs, source, target, 0, nil); err != nil {
+			return err
+		}
+	} else {
+		gplog.Debug("Source directory not found when renaming %q to %q. It was already renamed from a previous run.", source, target)
+	}
+
+	if err := renameDataDirectory(fs, archive, source, 0, nil); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func renameDataDirectory(src, dst string) error {
-	if err := VerifyDataDirectory(src); err != nil {
+func alreadyRestored(archive, source string) bool {
+	return !PathExists(archive) && PathExists(source)
+}
+
+func renameDataDirectory(fs FileSystem, src, dst string, bytesPerSecond int64, exclude []string) error {
+	if err := verifyDataDirectory(fs, src); err != nil {
 		return err
 	}
 
-	if err := utils.System.Rename(src, dst); err != nil {
-		return err
+	err := fs.Rename(src, dst)
+	if errors.Is(err, syscall.EXDEV) {
+		return moveAcrossFilesystems(src, dst, bytesPerSecond, exclude)
 	}
 
-	return nil
+	if err == nil && len(exclude) > 0 {
+		gplog.Debug("renamed %q to %q without copying, so the excludes %q were not applied", src, dst, exclude)
+	}
+
+	return err
 }
 
 // ErrInvalidDataDirectory is returned when a data directory does not look like
@@ -158,10 +451,14 @@ func (i *InvalidDataDirectoryError) Is(err error) bool {
 }
 
 func VerifyDataDirectory(path string) error {
+	return verifyDataDirectory(OSFileSystem{}, path)
+}
+
+func verifyDataDirectory(fs FileSystem, path string) error {
 	var err error
 
 	for _, f := range PostgresFiles {
-		if !PathExists(filepath.Join(path, f)) {
+		if !pathExists(fs, filepath.Join(path, f)) {
 			err = errorlist.Append(err, &InvalidDataDirectoryError{path, f})
 		}
 	}
@@ -169,9 +466,98 @@ func VerifyDataDirectory(path string) error {
 	return err
 }
 
+// ErrTargetNotEmpty is returned by EnsureEmptyTarget when the directory
+// already contains a postgres data directory.
+var ErrTargetNotEmpty = errors.New("target data directory is not empty")
+
+// TargetNotEmptyError is the backing error type for ErrTargetNotEmpty.
+type TargetNotEmptyError struct {
+	Dir   string
+	Found []string
+}
+
+func (t *TargetNotEmptyError) Error() string {
+	return fmt.Sprintf("target data directory %q already contains %q, possibly from a previous attempt; remove it before retrying", t.Dir, t.Found)
+}
+
+func (t *TargetNotEmptyError) Is(err error) bool {
+	return err == ErrTargetNotEmpty
+}
+
+// EnsureEmptyTarget ensures that dir does not already contain a postgres data
+// directory, as identified by PostgresFiles. A nonexistent or empty dir is
+// fine, as is one containing only unrelated files.
+func EnsureEmptyTarget(dir string) error {
+	exist, err := PathExist(dir)
+	if err != nil {
+		return err
+	}
+
+	if !exist {
+		return nil
+	}
+
+	var found []string
+	for _, f := range PostgresFiles {
+		exist, err := PathExist(filepath.Join(dir, f))
+		if err != nil {
+			return err
+		}
+
+		if exist {
+			found = append(found, f)
+		}
+	}
+
+	if len(found) > 0 {
+		return &TargetNotEmptyError{Dir: dir, Found: found}
+	}
+
+	return nil
+}
+
+// ErrInvalidArchivePair is returned by ArchiveSource when the source and
+// target are the same directory or one is nested within the other.
+var ErrInvalidArchivePair = errors.New("invalid archive source and target")
+
+// InvalidArchivePairError is the backing error type for ErrInvalidArchivePair.
+type InvalidArchivePairError struct {
+	Source string
+	Target string
+	Reason string
+}
+
+func (i *InvalidArchivePairError) Error() string {
+	return fmt.Sprintf("cannot archive %q to %q: %s", i.Source, i.Target, i.Reason)
+}
+
+func (i *InvalidArchivePairError) Is(err error) bool {
+	return err == ErrInvalidArchivePair
+}
+
+// verifyArchivePair guards against renaming a directory into itself.
+func verifyArchivePair(source, target string) error {
+	s := filepath.Clean(source)
+	t := filepath.Clean(target)
+
+	var reason string
+	switch {
+	case s == t:
+		reason = "they are the same directory"
+	case strings.HasPrefix(t, s+string(os.PathSeparator)):
+		reason = "the target is within the source"
+	case strings.HasPrefix(s, t+string(os.PathSeparator)):
+		reason = "the source is within the target"
+	default:
+		return nil
+	}
+
+	return &InvalidArchivePairError{Source: source, Target: target, Reason: reason}
+}
+
 // TODO: Remove alreadyRenamed and use AlreadyRenamed
-func alreadyRenamed(archive, target string) bool {
-	return PathExists(archive) && !PathExists(target)
+func alreadyRenamed(fs FileSystem, archive, target string) bool {
+	return pathExists(fs, archive) && !pathExists(fs, target)
 }
 
 // AlreadyRenamed infers if a successful rename has already occurred
@@ -192,12 +578,21 @@ func AlreadyRenamed(src, dst string) (bool, error) {
 
 // TODO: Remove PathExists and use PathExist
 func PathExists(path string) bool {
-	_, err := utils.System.Stat(path)
+	return pathExists(OSFileSystem{}, path)
+}
+
+func pathExists(fs FileSystem, path string) bool {
+	_, err := fs.Stat(path)
 	return err == nil
 }
 
 func PathExist(path string) (bool, error) {
-	_, err := utils.System.Stat(path)
+	return PathExistFS(OSFileSystem{}, path)
+}
+
+// PathExistFS is PathExist operating on fs.
+func PathExistFS(fs FileSystem, path string) (bool, error) {
+	_, err := fs.Stat(path)
 	if err == nil {
 		return true, nil
 	}
@@ -209,6 +604,28 @@ func PathExist(path string) (bool, error) {
 	return false, err
 }
 
+// PathsExist stats each path and returns those that do not exist. An error is
+// returned only for failures other than the path not existing, such as
+// permission errors, and includes every such failure.
+func PathsExist(paths []string) ([]string, error) {
+	var missing []string
+	var mErr error
+
+	for _, path := range paths {
+		exist, err := PathExist(path)
+		if err != nil {
+			mErr = errorlist.Append(mErr, err)
+			continue
+		}
+
+		if !exist {
+			missing = append(missing, path)
+		}
+	}
+
+	return missing, mErr
+}
+
 func verifyPathsExist(path string, files ...string) error {
 	var mErr error
 
@@ -223,41 +640,540 @@ func verifyPathsExist(path string, files ...string) error {
 	return mErr
 }
 
+// DeleteStatus describes what happened to a single directory passed to
+// DeleteDirectoriesWithResults.
+type DeleteStatus int
+
+const (
+	DirectoryDeleted DeleteStatus = iota
+	DirectoryAlreadyDeleted
+	DirectoryDeleteFailed
+	DirectoryWouldBeDeleted
+)
+
+func (s DeleteStatus) String() string {
+	switch s {
+	case DirectoryDeleted:
+		return "deleted"
+	case DirectoryAlreadyDeleted:
+		return "already removed"
+	case DirectoryDeleteFailed:
+		return "failed"
+	case DirectoryWouldBeDeleted:
+		return "would be deleted"
+	default:
+		return fmt.Sprintf("DeleteStatus(%d)", int(s))
+	}
+}
+
+// DeleteResult is the outcome of deleting a single directory. Err is set when
+// Status is DirectoryDeleteFailed.
+type DeleteResult struct {
+	Path   string
+	Status DeleteStatus
+	Err    error
+}
+
+// SummarizeDeleteResults returns a summary of the results suitable for
+// displaying to the user, such as "3 deleted, 1 already removed, 0 failed".
+func SummarizeDeleteResults(results []DeleteResult) string {
+	counts := make(map[DeleteStatus]int)
+	for _, result := range results {
+		counts[result.Status]++
+	}
+
+	summary := fmt.Sprintf("%d %s, %d %s, %d %s",
+		counts[DirectoryDeleted], DirectoryDeleted,
+		counts[DirectoryAlreadyDeleted], DirectoryAlreadyDeleted,
+		counts[DirectoryDeleteFailed], DirectoryDeleteFailed)
+
+	if counts[DirectoryWouldBeDeleted] > 0 {
+		summary += fmt.Sprintf(", %d %s", counts[DirectoryWouldBeDeleted], DirectoryWouldBeDeleted)
+	}
+
+	return summary
+}
+
 // Each directory in 'directories' is deleted only if every path in 'requiredPaths' exists
-// in that directory.
+// in that directory. Returned errors are annotated with an errorlist.HostError
+// naming the host they occurred on.
 func DeleteDirectories(directories []string, requiredPaths []string, streams step.OutStreams) error {
+	return DeleteDirectoriesContext(context.Background(), directories, requiredPaths, streams)
+}
+
+// DeleteDirectoriesContext is DeleteDirectories, but stops before the next
+// directory once ctx is done, returning the context's error. Directories that
+// were already deleted stay deleted and the remaining ones are untouched.
+func DeleteDirectoriesContext(ctx context.Context, directories []string, requiredPaths []string, streams step.OutStreams) error {
+	_, err := deleteDirectories(ctx, directories, RequiredPaths(requiredPaths...), streams, deleteOptions{})
+	return err
+}
+
+// DirectoryMatcher reports whether dir is safe to delete. A matcher may
+// return an error describing why the directory does not match, such as
+// VerifyDataDirectory's ErrInvalidDataDirectory, or for failures in checking.
+type DirectoryMatcher func(dir string) (bool, error)
+
+// ErrDirectoryNotMatched is returned by DeleteDirectoriesMatching when a
+// DirectoryMatcher rejects a directory without returning its own error.
+var ErrDirectoryNotMatched = errors.New("directory is not safe to delete")
+
+// RequiredPaths returns a DirectoryMatcher that matches directories
+// containing every one of paths.
+func RequiredPaths(paths ...string) DirectoryMatcher {
+	return func(dir string) (bool, error) {
+		if err := verifyPathsExist(dir, paths...); err != nil {
+			return false, err
+		}
+
+		return true, nil
+	}
+}
+
+// DataDirectoryMatcher matches directories that look like postgres data
+// directories, returning ErrInvalidDataDirectory for those that do not.
+func DataDirectoryMatcher(dir string) (bool, error) {
+	if err := VerifyDataDirectory(dir); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// TablespaceDirectoryMatcher matches the targets of tablespace symlinks. A
+// 6X or later target is DIR/<dbId> containing GPDB_<majorVersion>_<catalogVersion>,
+// while a 5X target is DIR/<fsname>/<datadir>/<tablespaceOid> containing
+// dbOid directories that each have a PG_VERSION file. Directories with
+// neither layout return ErrInvalidTablespaceDirectory.
+func TablespaceDirectoryMatcher(dir string) (bool, error) {
+	entries, err := readDir(dir)
+	if err != nil {
+		return false, xerrors.Errorf("reading tablespace directory: %w", err)
+	}
+
+	var dbOidDirs []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		if strings.HasPrefix(entry.Name(), "GPDB_") {
+			return true, nil
+		}
+
+		dbOidDirs = append(dbOidDirs, entry.Name())
+	}
+
+	if len(dbOidDirs) == 0 {
+		reason := fmt.Sprintf("missing GPDB_<majorVersion>_<catalogVersion> or <dbOid>/%s in %s", PGVersion, dir)
+		return false, newTablespaceDirectoryError("linked", reason)
+	}
+
+	for _, dbOidDir := range dbOidDirs {
+		path := filepath.Join(dir, dbOidDir, PGVersion)
+		if !PathExists(path) {
+			return false, newTablespaceDirectoryError("linked", "missing "+path)
+		}
+	}
+
+	return true, nil
+}
+
+// DeleteDirectoriesMatching is DeleteDirectories, but deletes each directory
+// only if matcher reports it is safe to delete.
+func DeleteDirectoriesMatching(directories []string, matcher DirectoryMatcher, streams step.OutStreams) error {
+	_, err := deleteDirectories(context.Background(), directories, matcher, streams, deleteOptions{})
+	return err
+}
+
+// DeleteDirectoriesWithResults is DeleteDirectories, but additionally reports
+// the outcome for each directory.
+func DeleteDirectoriesWithResults(directories []string, requiredPaths []string, streams step.OutStreams) ([]DeleteResult, error) {
+	return deleteDirectories(context.Background(), directories, RequiredPaths(requiredPaths...), streams, deleteOptions{})
+}
+
+// DeleteProgress controls how often DeleteDirectoriesWithProgress reports
+// progress. A zero value for either field disables that trigger.
+type DeleteProgress struct {
+	// Every reports progress after every Every directories.
+	Every int
+
+	// Interval reports progress once at least Interval has passed since the
+	// last report.
+	Interval time.Duration
+}
+
+// DeleteDirectoriesWithProgress is DeleteDirectories, but additionally
+// reports how many directories have been deleted so far. Directories that no
+// longer exist count as deleted so that reruns report accurate totals.
+func DeleteDirectoriesWithProgress(directories []string, requiredPaths []string, streams step.OutStreams, progress DeleteProgress) error {
+	_, err := deleteDirectories(context.Background(), directories, RequiredPaths(requiredPaths...), streams, deleteOptions{progress: progress})
+	return err
+}
+
+// DeleteDirectoriesDryRun performs the same validation and output as
+// DeleteDirectories without removing anything, so operators can review which
+// directories a destructive step would delete.
+func DeleteDirectoriesDryRun(directories []string, requiredPaths []string, streams step.OutStreams) error {
+	_, err := deleteDirectories(context.Background(), directories, RequiredPaths(requiredPaths...), streams, deleteOptions{dryRun: true})
+	return err
+}
+
+// DeleteDirectoriesToTrash is DeleteDirectories, but rather than removing
+// each directory it moves it into a unique, timestamped subdirectory of
+// trashDir so that it can be restored if the deletion was a mistake.
+func DeleteDirectoriesToTrash(directories []string, requiredPaths []string, trashDir string, streams step.OutStreams) error {
+	_, err := deleteDirectories(context.Background(), directories, RequiredPaths(requiredPaths...), streams, deleteOptions{trashDir: trashDir})
+	return err
+}
+
+// DeleteDirectoriesWithTablespaces is DeleteDirectories, but also deletes the
+// targets of the tablespace symlinks in each directory's pg_tblspc, which
+// would otherwise be left behind. Each target must be matched by
+// TablespaceDirectoryMatcher, or nothing is deleted for that directory.
+// Targets that no longer exist are skipped.
+func DeleteDirectoriesWithTablespaces(directories []string, requiredPaths []string, streams step.OutStreams) error {
+	_, err := deleteDirectories(context.Background(), directories, RequiredPaths(requiredPaths...), streams, deleteOptions{followTablespaces: true})
+	return err
+}
+
+type deleteOptions struct {
+	dryRun   bool
+	progress DeleteProgress
+
+	// trashDir, when set, is where directories are moved instead of being
+	// removed.
+	trashDir string
+
+	// followTablespaces also deletes the targets of tablespace symlinks.
+	followTablespaces bool
+}
+
+func deleteDirectories(ctx context.Context, directories []string, matcher DirectoryMatcher, streams step.OutStreams, opts deleteOptions) ([]DeleteResult, error) {
+	name := "deleting directories"
+	if opts.dryRun {
+		name = "checking directories to delete"
+	}
+
+	var results []DeleteResult
+	err := step.Timed(name, streams, func() error {
+		var err error
+		results, err = deleteEachDirectory(ctx, directories, matcher, streams, opts)
+		return err
+	})
+
+	return results, err
+}
+
+// UnknownHost is reported in place of the hostname when it cannot be
+// determined.
+const UnknownHost = "unknown-host"
+
+func deleteEachDirectory(ctx context.Context, directories []string, matcher DirectoryMatcher, streams step.OutStreams, opts deleteOptions) ([]DeleteResult, error) {
+	var results []DeleteResult
+	var mErr error
+
+	// The hostname only decorates the output, so failing to get it should not
+	// stop the deletions. The error is still returned so that it is visible.
 	hostname, err := utils.System.Hostname()
 	if err != nil {
+		hostname = UnknownHost
+		mErr = errorlist.Append(mErr, xerrors.Errorf("getting hostname: %w", err))
+	}
+
+	action := "Deleting"
+	switch {
+	case opts.dryRun:
+		action = "Would delete"
+	case opts.trashDir != "":
+		action = "Moving to trash"
+	}
+
+	// reportProgress is called before each directory is processed, and once
+	// more after all have been to report the final count.
+	lastReport := utils.System.Now()
+	reportProgress := func(final bool) error {
+		processed := len(results)
+		if processed == 0 {
+			return nil
+		}
+
+		due := opts.progress.Every > 0 && processed%opts.progress.Every == 0
+		due = due || (opts.progress.Interval > 0 && utils.System.Now().Sub(lastReport) >= opts.progress.Interval)
+		due = due || (final && (opts.progress.Every > 0 || opts.progress.Interval > 0))
+		if !due {
+			return nil
+		}
+
+		deleted := 0
+		for _, result := range results {
+			if result.Status == DirectoryDeleted || result.Status == DirectoryAlreadyDeleted {
+				deleted++
+			}
+		}
+
+		lastReport = utils.System.Now()
+		_, err := fmt.Fprintf(streams.Stdout(), "Deleted %d/%d directories on host %q\n", deleted, len(directories), hostname)
 		return err
 	}
 
-	var mErr error
 	for _, directory := range directories {
-		gplog.Debug("Deleting directory: %q on host %q\n", directory, hostname)
-		_, err = fmt.Fprintf(streams.Stdout(), "Deleting directory: %q on host %q\n", directory, hostname)
+		if err := ctx.Err(); err != nil {
+			err = errorlist.WithHost(hostname, xerrors.Errorf("deleting directories: %w", err))
+			return results, errorlist.Append(mErr, err)
+		}
+
+		if err := reportProgress(false); err != nil {
+			return results, err
+		}
+
+		gplog.Debug("%s directory: %q on host %q\n", action, directory, hostname)
+		_, err = fmt.Fprintf(streams.Stdout(), "%s directory: %q on host %q\n", action, directory, hostname)
 		if err != nil {
-			return err
+			return results, err
 		}
 
-		if !PathExists(directory) {
-			fmt.Fprintf(streams.Stdout(), "directory: %q does not exist on host %q\n", directory, hostname)
-			gplog.Debug("Directory: %q does not exist on host %q\n", directory, hostname)
+		// Check the directory itself before matching its contents so that a
+		// directory removed by a previous run is skipped, while a directory
+		// that still exists but does not match is an error.
+		exist, err := PathExist(directory)
+		if err != nil {
+			err = errorlist.WithHost(hostname, err)
+			mErr = errorlist.Append(mErr, err)
+			results = append(results, DeleteResult{Path: directory, Status: DirectoryDeleteFailed, Err: err})
 			continue
 		}
 
-		err = verifyPathsExist(directory, requiredPaths...)
+		if !exist {
+			_, err = fmt.Fprintf(streams.Stdout(), "directory: %q does not exist on host %q, skipping\n", directory, hostname)
+			if err != nil {
+				return results, err
+			}
+
+			gplog.Debug("Directory: %q does not exist on host %q, skipping\n", directory, hostname)
+			results = append(results, DeleteResult{Path: directory, Status: DirectoryAlreadyDeleted})
+			continue
+		}
+
+		matched, err := matcher(directory)
+		if err == nil && !matched {
+			err = xerrors.Errorf("%q: %w", directory, ErrDirectoryNotMatched)
+		}
+
 		if err != nil {
+			err = errorlist.WithHost(hostname, err)
 			mErr = errorlist.Append(mErr, err)
+			results = append(results, DeleteResult{Path: directory, Status: DirectoryDeleteFailed, Err: err})
 			continue
 		}
 
-		err = utils.System.RemoveAll(directory)
+		if err := verifyPostmasterNotRunning(directory); err != nil {
+			err = errorlist.WithHost(hostname, err)
+			mErr = errorlist.Append(mErr, err)
+			results = append(results, DeleteResult{Path: directory, Status: DirectoryDeleteFailed, Err: err})
+			continue
+		}
+
+		var targets []string
+		if opts.followTablespaces {
+			targets, err = checkTablespaceTargets(directory)
+			if err != nil {
+				err = errorlist.WithHost(hostname, err)
+				mErr = errorlist.Append(mErr, err)
+				results = append(results, DeleteResult{Path: directory, Status: DirectoryDeleteFailed, Err: err})
+				continue
+			}
+		}
+
+		for _, target := range targets {
+			_, err = fmt.Fprintf(streams.Stdout(), "%s tablespace directory: %q linked from %q on host %q\n", action, target, directory, hostname)
+			if err != nil {
+				return results, err
+			}
+		}
+
+		if opts.dryRun {
+			results = append(results, DeleteResult{Path: directory, Status: DirectoryWouldBeDeleted})
+			continue
+		}
+
+		// Targets are removed first, since their symlinks are lost along
+		// with the directory.
+		for _, path := range append(targets, directory) {
+			if opts.trashDir != "" {
+				err = moveToTrash(path, opts.trashDir)
+			} else {
+				err = utils.System.RemoveAll(path)
+			}
+			if err != nil {
+				break
+			}
+		}
 		if err != nil {
+			err = errorlist.WithHost(hostname, err)
 			mErr = errorlist.Append(mErr, err)
+			results = append(results, DeleteResult{Path: directory, Status: DirectoryDeleteFailed, Err: err})
+			continue
 		}
+
+		results = append(results, DeleteResult{Path: directory, Status: DirectoryDeleted})
 	}
 
-	return mErr
+	if err := reportProgress(true); err != nil {
+		return results, err
+	}
+
+	return results, mErr
+}
+
+// checkTablespaceTargets returns the existing targets of the tablespace
+// symlinks in dir, each of which must be matched by TablespaceDirectoryMatcher.
+func checkTablespaceTargets(dir string) ([]string, error) {
+	targets, err := tablespaceTargets(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing []string
+	for _, target := range targets {
+		exist, err := PathExist(target)
+		if err != nil {
+			return nil, err
+		}
+
+		if !exist {
+			gplog.Debug("tablespace directory %q linked from %q does not exist, skipping", target, dir)
+			continue
+		}
+
+		matched, err := TablespaceDirectoryMatcher(target)
+		if err == nil && !matched {
+			err = xerrors.Errorf("tablespace %q: %w", target, ErrDirectoryNotMatched)
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("tablespace directory linked from %q: %w", dir, err)
+		}
+
+		existing = append(existing, target)
+	}
+
+	return existing, nil
+}
+
+// tablespaceTargets resolves the symlinks in dir/pg_tblspc, which are named
+// by tablespace OID. Other entries are not tablespaces and are ignored, as is
+// a missing pg_tblspc. Relative targets are resolved against pg_tblspc.
+func tablespaceTargets(dir string) ([]string, error) {
+	tblspc := filepath.Join(dir, "pg_tblspc")
+
+	entries, err := readDir(tblspc)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("reading tablespace links: %w", err)
+	}
+
+	var targets []string
+	for _, entry := range entries {
+		if entry.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+
+		if _, err := strconv.ParseUint(entry.Name(), 10, 32); err != nil {
+			continue
+		}
+
+		link := filepath.Join(tblspc, entry.Name())
+		target, err := utils.System.Readlink(link)
+		if err != nil {
+			return nil, xerrors.Errorf("reading tablespace link: %w", err)
+		}
+
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(tblspc, target)
+		}
+
+		targets = append(targets, filepath.Clean(target))
+	}
+
+	return targets, nil
+}
+
+// ErrPostmasterRunning is returned when deleting a data directory whose
+// postmaster is still running, since doing so would corrupt the cluster.
+var ErrPostmasterRunning = errors.New("postmaster is running")
+
+// PostmasterRunningError is the backing error type for ErrPostmasterRunning.
+type PostmasterRunningError struct {
+	Dir string
+	PID int
+}
+
+func (p *PostmasterRunningError) Error() string {
+	return fmt.Sprintf("refusing to delete %q: postmaster is running with PID %d", p.Dir, p.PID)
+}
+
+func (p *PostmasterRunningError) Is(err error) bool {
+	return err == ErrPostmasterRunning
+}
+
+// verifyPostmasterNotRunning returns a PostmasterRunningError if dir has a
+// postmaster.pid naming a live process. A stale postmaster.pid left behind by
+// a postmaster that is no longer running is ignored.
+func verifyPostmasterNotRunning(dir string) error {
+	path := filepath.Join(dir, "postmaster.pid")
+	contents, err := utils.System.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return xerrors.Errorf("reading postmaster.pid: %w", err)
+	}
+
+	// The PID is on the first line of postmaster.pid.
+	line := strings.SplitN(string(contents), "\n", 2)[0]
+	pid, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil {
+		return xerrors.Errorf("parsing PID from %q: %w", path, err)
+	}
+
+	// Signal 0 checks for the process without signalling it. EPERM means the
+	// process exists but belongs to another user.
+	err = utils.System.Kill(pid, 0)
+	if errors.Is(err, syscall.ESRCH) {
+		gplog.Debug("ignoring stale %q with PID %d", path, pid)
+		return nil
+	}
+
+	if err != nil && !errors.Is(err, syscall.EPERM) {
+		return xerrors.Errorf("checking for postmaster process %d: %w", pid, err)
+	}
+
+	return &PostmasterRunningError{Dir: dir, PID: pid}
+}
+
+// moveToTrash moves dir into a new subdirectory of trashDir named after dir
+// and the current time. A counter is appended when the name is already taken.
+func moveToTrash(dir, trashDir string) error {
+	if err := utils.System.MkdirAll(trashDir, 0700); err != nil {
+		return xerrors.Errorf("creating trash directory: %w", err)
+	}
+
+	name := filepath.Join(trashDir, filepath.Base(dir)+"."+utils.System.Now().Format("20060102T150405"))
+	dst := name
+	for i := 1; PathExists(dst); i++ {
+		dst = fmt.Sprintf("%s.%d", name, i)
+	}
+
+	gplog.Debug("moving %q to trash at %q", dir, dst)
+	err := utils.System.Rename(dir, dst)
+	if errors.Is(err, syscall.EXDEV) {
+		return moveAcrossFilesystems(dir, dst, 0, nil)
+	}
+
+	return err
 }
 
 var ErrInvalidTablespaceDirectory = errors.New("invalid tablespace directory")
@@ -319,7 +1235,78 @@ func DeleteNewTablespaceDirectories(streams step.OutStreams, dirs []string) erro
 		return err
 	}
 
-	err := DeleteDirectories(dirs, []string{}, streams)
+	// Directories sharing a parent must not check and remove that parent
+	// concurrently, so each parent is guarded by its own lock.
+	parentLocks := make(map[string]*sync.Mutex)
+	for _, dir := range dirs {
+		parent := filepath.Dir(filepath.Clean(dir))
+		if parentLocks[parent] == nil {
+			parentLocks[parent] = &sync.Mutex{}
+		}
+	}
+
+	workers := TablespaceDeleteWorkers
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan string, len(dirs))
+	for _, dir := range dirs {
+		jobs <- dir
+	}
+	close(jobs)
+
+	return step.Timed("deleting tablespace directories", streams, func() error {
+		errs := make(chan error, len(dirs))
+
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				for dir := range jobs {
+					lock := parentLocks[filepath.Dir(filepath.Clean(dir))]
+					errs <- deleteNewTablespaceDirectory(streams, dir, lock)
+				}
+			}()
+		}
+
+		wg.Wait()
+		close(errs)
+
+		var mErr error
+		for err := range errs {
+			mErr = errorlist.Append(mErr, err)
+		}
+
+		return mErr
+	})
+}
+
+// deleteNewTablespaceDirectory deletes dir and then its parent dbID directory
+// if that is left empty. A previous run may have been interrupted at any
+// point, so each combination of states converges on the same end state:
+//
+//   tablespace dir   parent dbID dir   action
+//   --------------   ---------------   ---------------------------------------
+//   present          only dir          delete dir, then delete parent
+//   present          other entries     delete dir, keep parent
+//   absent           empty             delete parent (died before removing it)
+//   absent           other entries     keep parent
+//   absent           absent            nothing (already finished)
+func deleteNewTablespaceDirectory(streams step.OutStreams, dir string, parentLock *sync.Mutex) error {
+	parentLock.Lock()
+	defer parentLock.Unlock()
+
+	// Record who owned the directory for later audits, since it is about to
+	// be gone.
+	_, err := fmt.Fprintf(streams.Stdout(), "Tablespace directory: %q owned by %s\n", dir, directoryOwner(dir))
+	if err != nil {
+		return err
+	}
+
+	err = DeleteDirectories([]string{dir}, []string{}, streams)
 	if err != nil {
 		return err
 	}
@@ -329,34 +1316,93 @@ func DeleteNewTablespaceDirectories(streams step.OutStreams, dirs []string) erro
 	// has been deleted above. Now check that its parent directory
 	// can also be deleted by ensuring that its contents do not overlap with
 	// the tablespace of 5X.
-	for _, dir := range dirs {
-		parent := filepath.Dir(filepath.Clean(dir))
+	parent := filepath.Dir(filepath.Clean(dir))
+
+	var entries []os.FileInfo
+	err = retryTransient(func() error {
+		var rErr error
+		entries, rErr = readDir(parent)
+		return rErr
+	})
+	if os.IsNotExist(err) {
+		// directory may have been already removed during previous execution
+		return nil
+	} else if err != nil {
+		return err
+	}
 
-		entries, err := ioutil.ReadDir(parent)
-		if os.IsNotExist(err) {
-			// directory may have been already removed during previous execution
-			continue
-		} else if err != nil {
-			return err
-		}
+	// If the parent directory is not empty it contains files for the 5X
+	// tablespace, or another target tablespace that has not yet been deleted.
+	// For example, the oid for template1 is 1 which can conflict with the 6X
+	// tablespace directory which uses segment dbid's which is also 1. Thus, we
+	// do not want to delete the directory.
+	if len(entries) > 0 {
+		return nil
+	}
 
-		// If the parent directory is not empty it contains files for the 5X
-		// tablespace. For example, the oid for template1 is 1 which can conflict
-		// with the 6X tablespace directory which uses segment dbid's which is
-		// also 1. Thus, we do not want to delete the directory.
-		if len(entries) > 0 {
-			return nil
-		}
+	// If the directory is empty it 'only' contained the target cluster
+	// tablespace and is safe to delete.
+	err = retryTransient(func() error {
+		return utils.System.Remove(parent)
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
 
-		// If the directory is empty it 'only' contained the target cluster
-		// tablespace and is safe to delete.
-		// NOTE: Each directory passed in has a different parent.
-		if err := os.Remove(parent); err != nil {
+	return err
+}
+
+// retryTransient calls fn until it succeeds, fails with an error that is not
+// transient, or TablespaceRetryAttempts calls have been made. Hard failures
+// such as permission errors are returned immediately.
+func retryTransient(fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !isTransient(err) || attempt >= TablespaceRetryAttempts {
 			return err
 		}
+
+		gplog.Debug("retrying after transient error (attempt %d of %d): %v", attempt, TablespaceRetryAttempts, err)
+		time.Sleep(TablespaceRetryDelay)
+	}
+}
+
+func isTransient(err error) bool {
+	return errors.Is(err, syscall.EAGAIN) ||
+		errors.Is(err, syscall.EBUSY) ||
+		errors.Is(err, syscall.EINTR)
+}
+
+// directoryOwner describes the owner and group of path as "user:group
+// (uid:gid)", using the numeric IDs for names that cannot be resolved. It
+// returns "unknown" if path cannot be statted, so that failing to describe a
+// directory never prevents deleting it.
+func directoryOwner(path string) string {
+	info, err := utils.System.Lstat(path)
+	if err != nil {
+		gplog.Debug("getting owner of %q: %v", path, err)
+		return "unknown"
 	}
 
-	return nil
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "unknown"
+	}
+
+	uid := strconv.FormatUint(uint64(stat.Uid), 10)
+	gid := strconv.FormatUint(uint64(stat.Gid), 10)
+
+	owner := uid
+	if u, err := user.LookupId(uid); err == nil {
+		owner = u.Username
+	}
+
+	group := gid
+	if g, err := user.LookupGroupId(gid); err == nil {
+		group = g.Name
+	}
+
+	return fmt.Sprintf("%s:%s (%s:%s)", owner, group, uid, gid)
 }
 
 // VerifyTargetTablespaceDirectories checks tablespace directories on GPDB 6X
@@ -372,6 +1418,69 @@ func VerifyTargetTablespaceDirectories(dirs []string) error {
 	return nil
 }
 
+// VerifyTablespaceDirectories checks the source cluster tablespace location
+// directories using the layout for the source cluster's major version.
+func VerifyTablespaceDirectories(tsLocations []string, sourceVersion semver.Version) error {
+	switch {
+	case sourceVersion.Major == 5:
+		return Verify5XTablespaceDirectories(tsLocations)
+	case sourceVersion.Major >= 6:
+		return verify6XTablespaceDirectories(tsLocations, sourceVersion.Major)
+	default:
+		return xerrors.Errorf("verifying tablespace directories: unsupported source version %s", sourceVersion)
+	}
+}
+
+// verify6XTablespaceDirectories checks tablespace location directories of the
+// following format: DIR/<fsname>/<datadir>/<tablespaceOID>
+// It ensures that all dbID directories contain a GPDB_<major>_<catalogVersion>
+// directory.
+func verify6XTablespaceDirectories(tsLocations []string, majorVersion uint64) error {
+	prefix := fmt.Sprintf("GPDB_%d_", majorVersion)
+
+	var mErr error
+	for _, tsLocation := range tsLocations {
+		entries, err := ioutil.ReadDir(tsLocation)
+		if err != nil {
+			return xerrors.Errorf("reading %dX tablespace directory: %w", majorVersion, err)
+		}
+
+		for _, dbIDDir := range entries {
+			if !dbIDDir.IsDir() {
+				continue
+			}
+
+			path := filepath.Join(tsLocation, dbIDDir.Name())
+			found, err := containsPrefix(path, prefix)
+			if err != nil {
+				return xerrors.Errorf("reading %dX tablespace directory: %w", majorVersion, err)
+			}
+
+			if !found {
+				reason := fmt.Sprintf("missing %s<catalogVersion> in %s", prefix, path)
+				mErr = errorlist.Append(mErr, newTablespaceDirectoryError(fmt.Sprintf("%dX source cluster", majorVersion), reason))
+			}
+		}
+	}
+
+	return mErr
+}
+
+func containsPrefix(dir, prefix string) (bool, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return false, err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), prefix) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
 // Verify5XTablespaceDirectories checks tablespace location directories of the
 // following format: DIR/<fsname>/<datadir>/<tablespaceOID>
 // It ensures the PG_VERSION file is found in all dbOid directories.
@@ -380,26 +1489,66 @@ func VerifyTargetTablespaceDirectories(dirs []string) error {
 func Verify5XTablespaceDirectories(tsLocations []string) error {
 	var mErr error
 	for _, tsLocation := range tsLocations {
-		entries, err := ioutil.ReadDir(tsLocation)
+		missing, err := verify5XTablespaceLocation(tsLocation)
 		if err != nil {
 			return xerrors.Errorf("reading 5X tablespace directory: %w", err)
 		}
 
-		for _, dbOidDir := range entries {
-			if !dbOidDir.IsDir() {
-				continue
-			}
-
-			path := filepath.Join(tsLocation, dbOidDir.Name(), PGVersion)
-			if !PathExists(path) {
-				mErr = errorlist.Append(mErr, newTablespaceDirectoryError("5X source cluster", "missing "+path))
-			}
+		for _, err := range missing {
+			mErr = errorlist.Append(mErr, err)
 		}
 	}
 
 	return mErr
 }
 
+// VerifyAll5XTablespaceDirectories is Verify5XTablespaceDirectories, but a
+// location that cannot be read does not stop the remaining locations from
+// being checked. Every problem is returned in an errorlist.Errors, with read
+// errors naming their location, so they can all be fixed at once.
+func VerifyAll5XTablespaceDirectories(tsLocations []string) error {
+	var errs errorlist.Errors
+	for _, tsLocation := range tsLocations {
+		missing, err := verify5XTablespaceLocation(tsLocation)
+		if err != nil {
+			errs = append(errs, xerrors.Errorf("reading 5X tablespace directory %q: %w", tsLocation, err))
+			continue
+		}
+
+		errs = append(errs, missing...)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// verify5XTablespaceLocation returns a TablespaceDirectoryError for each dbOid
+// directory in tsLocation that is missing its PG_VERSION file, or an error if
+// tsLocation cannot be read.
+func verify5XTablespaceLocation(tsLocation string) ([]error, error) {
+	entries, err := ioutil.ReadDir(tsLocation)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []error
+	for _, dbOidDir := range entries {
+		if !dbOidDir.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(tsLocation, dbOidDir.Name(), PGVersion)
+		if !PathExists(path) {
+			missing = append(missing, newTablespaceDirectoryError("5X source cluster", "missing "+path))
+		}
+	}
+
+	return missing, nil
+}
+
 func TablespacePath(tablespaceLocation string, dbID int, majorVersion uint64, catalogVersion string) string {
 	return filepath.Join(
 		tablespaceLocation,
@@ -5,15 +5,23 @@ package upgrade_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/user"
 	"path/filepath"
+	"reflect"
 	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 
+	"github.com/blang/semver/v4"
+
 	"github.com/greenplum-db/gpupgrade/step"
 	"github.com/greenplum-db/gpupgrade/testutils"
 	"github.com/greenplum-db/gpupgrade/testutils/testlog"
@@ -48,6 +56,41 @@ func TestTempDataDir(t *testing.T) {
 	}
 }
 
+func TestVerifySegmentPrefix(t *testing.T) {
+	t.Run("succeeds when the basename starts with the prefix", func(t *testing.T) {
+		for _, datadir := range []string{"/data/gpseg3", "/data/gpseg3/", "/data/gpseg-1"} {
+			if err := upgrade.VerifySegmentPrefix(datadir, "gpseg"); err != nil {
+				t.Errorf("unexpected error for %q: %#v", datadir, err)
+			}
+		}
+	})
+
+	t.Run("errors when the basename does not start with the prefix", func(t *testing.T) {
+		err := upgrade.VerifySegmentPrefix("/data/seg3", "gpseg")
+
+		var mismatchErr *upgrade.SegmentPrefixMismatchError
+		if !errors.As(err, &mismatchErr) {
+			t.Fatalf("got error %#v want type %T", err, mismatchErr)
+		}
+
+		if !errors.Is(err, upgrade.ErrSegmentPrefixMismatch) {
+			t.Errorf("got error %#v want %#v", err, upgrade.ErrSegmentPrefixMismatch)
+		}
+
+		expected := upgrade.SegmentPrefixMismatchError{DataDir: "/data/seg3", SegPrefix: "gpseg"}
+		if *mismatchErr != expected {
+			t.Errorf("got %+v want %+v", *mismatchErr, expected)
+		}
+	})
+
+	t.Run("only the basename is considered", func(t *testing.T) {
+		err := upgrade.VerifySegmentPrefix("/data/gpseg/seg3", "gpseg")
+		if !errors.Is(err, upgrade.ErrSegmentPrefixMismatch) {
+			t.Errorf("got error %#v want %#v", err, upgrade.ErrSegmentPrefixMismatch)
+		}
+	})
+}
+
 func ExampleTempDataDir() {
 	var id upgrade.ID
 
@@ -72,10 +115,30 @@ func TestGetArchiveDirectoryName(t *testing.T) {
 	var id upgrade.ID
 	actual := upgrade.GetArchiveDirectoryName(id, stamp)
 
-	expected := fmt.Sprintf("gpupgrade-%s-2000-03-14T12:15", id.String())
+	expected := fmt.Sprintf("gpupgrade-%s-2000-03-14T12:15:45", id.String())
 	if actual != expected {
 		t.Errorf("GetArchiveDirectoryName() = %q, want %q", actual, expected)
 	}
+
+	t.Run("archives created within the same minute do not collide", func(t *testing.T) {
+		later := stamp.Add(time.Second)
+
+		if upgrade.GetArchiveDirectoryName(id, stamp) == upgrade.GetArchiveDirectoryName(id, later) {
+			t.Errorf("expected names for %v and %v to differ", stamp, later)
+		}
+	})
+
+	t.Run("is a single filesystem safe path component", func(t *testing.T) {
+		// Colons are deliberately kept; they are valid on the supported Linux
+		// filesystems. Only a separator or NUL would be unsafe.
+		if strings.ContainsAny(actual, "/\x00") {
+			t.Errorf("GetArchiveDirectoryName() = %q contains an unsafe character", actual)
+		}
+
+		if !strings.Contains(actual, "12:15:45") {
+			t.Errorf("GetArchiveDirectoryName() = %q, want colons between time fields", actual)
+		}
+	})
 }
 
 func TestArchiveSource(t *testing.T) {
@@ -85,20 +148,169 @@ func TestArchiveSource(t *testing.T) {
 		source, target, cleanup := testutils.MustCreateDataDirs(t)
 		defer cleanup(t)
 
-		err := upgrade.ArchiveSource(source, target, true)
+		status, err := upgrade.ArchiveSource(source, target, true)
 		if err != nil {
 			t.Errorf("unexpected error: %#v", err)
 		}
 
+		if status != upgrade.Archived {
+			t.Errorf("got status %q want %q", status, upgrade.Archived)
+		}
+
 		testutils.VerifyRename(t, source, target)
 	})
 
+	t.Run("logs how long archiving took", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+
+		_, err := upgrade.ArchiveSource(source, target, true)
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+
+		expected := "archiving source directory started at "
+		if !strings.Contains(string(log.Bytes()), expected) {
+			t.Errorf("log %q does not contain %q", string(log.Bytes()), expected)
+		}
+	})
+
+	t.Run("returns an unknown status when renaming the target fails after archiving the source", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+		defer testutils.MustRemoveAll(t, upgrade.ArchivePathFor(target))
+
+		expected := errors.New("permission denied")
+		utils.System.Rename = func(old, new string) error {
+			if old == target {
+				return expected
+			}
+
+			return os.Rename(old, new)
+		}
+		defer func() {
+			utils.System.Rename = os.Rename
+		}()
+
+		status, err := upgrade.ArchiveSource(source, target, true)
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+
+		if status != upgrade.ArchiveUnknown {
+			t.Errorf("got status %q want %q", status, upgrade.ArchiveUnknown)
+		}
+	})
+
+	t.Run("errors when the source and target are the same or nested", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+
+		called := false
+		utils.System.Rename = func(old, new string) error {
+			called = true
+			return nil
+		}
+		defer func() {
+			utils.System.Rename = os.Rename
+		}()
+
+		cases := []struct {
+			name   string
+			source string
+			target string
+		}{
+			{"same path", source, source},
+			{"same path after cleaning", source, source + "/"},
+			{"target within source", source, filepath.Join(source, "target")},
+			{"source within target", filepath.Join(target, "source"), target},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				_, err := upgrade.ArchiveSource(c.source, c.target, true)
+
+				var pairErr *upgrade.InvalidArchivePairError
+				if !errors.As(err, &pairErr) {
+					t.Fatalf("got error %#v want type %T", err, pairErr)
+				}
+
+				if !errors.Is(err, upgrade.ErrInvalidArchivePair) {
+					t.Errorf("got error %#v want %#v", err, upgrade.ErrInvalidArchivePair)
+				}
+			})
+		}
+
+		if called {
+			t.Errorf("expected rename to not be called")
+		}
+	})
+
+	t.Run("errors without touching the source when the target is missing required files", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+
+		if err := os.Remove(filepath.Join(target, upgrade.PGVersion)); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		called := false
+		utils.System.Rename = func(old, new string) error {
+			called = true
+			return os.Rename(old, new)
+		}
+		defer func() {
+			utils.System.Rename = os.Rename
+		}()
+
+		_, err := upgrade.ArchiveSource(source, target, true)
+
+		var invalid *upgrade.InvalidDataDirectoryError
+		if !errors.As(err, &invalid) {
+			t.Fatalf("got error %#v want type %T", err, invalid)
+		}
+
+		if !errors.Is(err, upgrade.ErrInvalidDataDirectory) {
+			t.Errorf("got error %#v want %#v", err, upgrade.ErrInvalidDataDirectory)
+		}
+
+		if called {
+			t.Errorf("expected rename to not be called")
+		}
+
+		if err := upgrade.VerifyDataDirectory(source); err != nil {
+			t.Errorf("expected source %q to be untouched: %v", source, err)
+		}
+
+		if upgrade.PathExists(upgrade.ArchivePathFor(target)) {
+			t.Errorf("expected archive %q to not exist", upgrade.ArchivePathFor(target))
+		}
+	})
+
+	t.Run("allows siblings sharing a name prefix", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+
+		sibling := source + "_123ABC"
+		if err := os.Rename(target, sibling); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		defer testutils.MustRemoveAll(t, upgrade.ArchivePathFor(sibling))
+
+		_, err := upgrade.ArchiveSource(source, sibling, true)
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+
+		testutils.VerifyRename(t, source, sibling)
+	})
+
 	t.Run("returns early if already renamed", func(t *testing.T) {
 		source, target, cleanup := testutils.MustCreateDataDirs(t)
 		defer cleanup(t)
 
 		// To return early create archive directory
-		archive := target + upgrade.OldSuffix
+		archive := upgrade.ArchivePathFor(target)
 		err := os.Rename(target, archive)
 		if err != nil {
 			t.Errorf("unexpected error: %#v", err)
@@ -115,11 +327,15 @@ func TestArchiveSource(t *testing.T) {
 
 		testutils.VerifyRename(t, source, target)
 
-		err = upgrade.ArchiveSource(source, target, true)
+		status, err := upgrade.ArchiveSource(source, target, true)
 		if err != nil {
 			t.Errorf("unexpected error: %#v", err)
 		}
 
+		if status != upgrade.AlreadyArchived {
+			t.Errorf("got status %q want %q", status, upgrade.AlreadyArchived)
+		}
+
 		if called {
 			t.Errorf("expected rename to not be called")
 		}
@@ -137,7 +353,7 @@ func TestArchiveSource(t *testing.T) {
 			utils.System.Rename = os.Rename
 		}()
 
-		err := upgrade.ArchiveSource(source, target, true)
+		_, err := upgrade.ArchiveSource(source, target, true)
 		if !errors.Is(err, expected) {
 			t.Errorf("got %#v want %#v", err, expected)
 		}
@@ -150,7 +366,7 @@ func TestArchiveSource(t *testing.T) {
 		target := testutils.GetTempDir(t, "target")
 		defer testutils.MustRemoveAll(t, target)
 
-		err := upgrade.ArchiveSource(source, target, true)
+		_, err := upgrade.ArchiveSource(source, target, true)
 
 		var errs errorlist.Errors
 		if !errors.As(err, &errs) {
@@ -165,170 +381,410 @@ func TestArchiveSource(t *testing.T) {
 		}
 	})
 
-	t.Run("only renames source to archive when renameTarget is false", func(t *testing.T) {
+	t.Run("falls back to copying when renaming across filesystems", func(t *testing.T) {
 		source, target, cleanup := testutils.MustCreateDataDirs(t)
 		defer cleanup(t)
 
-		archive := target + upgrade.OldSuffix
-
-		calls := 0
-		utils.System.Rename = func(old, new string) error {
-			calls++
-
-			if old != source {
-				t.Errorf("got %q want %q", old, source)
-			}
+		archive := upgrade.ArchivePathFor(target)
+		defer testutils.MustRemoveAll(t, archive)
 
-			if new != archive {
-				t.Errorf("got %q want %q", new, archive)
-			}
+		testutils.MustWriteToFile(t, filepath.Join(source, "pg_hba.conf"), "host all all")
+		if err := os.Chmod(filepath.Join(source, "pg_hba.conf"), 0640); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
 
-			return os.Rename(old, new)
+		if err := os.Symlink("/tmp/tablespace", filepath.Join(source, "16386")); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
 		}
+
+		utils.System.Rename = crossDeviceRename(source, archive)
 		defer func() {
 			utils.System.Rename = os.Rename
 		}()
 
-		err := upgrade.ArchiveSource(source, target, false)
+		_, err := upgrade.ArchiveSource(source, target, true)
 		if err != nil {
 			t.Errorf("unexpected error: %#v", err)
 		}
 
-		if calls != 1 {
-			t.Errorf("expected rename to be called once")
+		testutils.VerifyRename(t, source, target)
+
+		info, err := os.Stat(filepath.Join(archive, "pg_hba.conf"))
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
 		}
 
-		if upgrade.PathExists(source) {
-			t.Errorf("expected source %q to not exist", source)
+		if info.Mode().Perm() != 0640 {
+			t.Errorf("got mode %v want %v", info.Mode().Perm(), os.FileMode(0640))
 		}
 
-		if !upgrade.PathExists(archive) {
-			t.Errorf("expected archive %q to exist", archive)
+		link, err := os.Readlink(filepath.Join(archive, "16386"))
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if link != "/tmp/tablespace" {
+			t.Errorf("got symlink %q want %q", link, "/tmp/tablespace")
+		}
+
+		for _, path := range []string{archive + ".copying", upgrade.CopiedPath(source, archive)} {
+			if upgrade.PathExists(path) {
+				t.Errorf("expected %q to not exist", path)
+			}
 		}
 	})
 
-	t.Run("when renaming succeeds then a re-run succeeds", func(t *testing.T) {
+	t.Run("preserves a symlinked subdirectory when copying across filesystems", func(t *testing.T) {
 		source, target, cleanup := testutils.MustCreateDataDirs(t)
 		defer cleanup(t)
 
-		err := upgrade.ArchiveSource(source, target, true)
+		archive := upgrade.ArchivePathFor(target)
+		defer testutils.MustRemoveAll(t, archive)
+
+		xlog := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, xlog)
+		testutils.MustWriteToFile(t, filepath.Join(xlog, "000000010000000000000001"), "wal")
+
+		if err := os.Symlink(xlog, filepath.Join(source, "pg_xlog")); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		utils.System.Rename = crossDeviceRename(source, archive)
+		defer func() {
+			utils.System.Rename = os.Rename
+		}()
+
+		_, err := upgrade.ArchiveSource(source, target, true)
 		if err != nil {
 			t.Errorf("unexpected error: %#v", err)
 		}
 
-		testutils.VerifyRename(t, source, target)
+		info, err := os.Lstat(filepath.Join(archive, "pg_xlog"))
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if info.Mode()&os.ModeSymlink == 0 {
+			t.Fatalf("expected %q to be a symlink, got mode %v", filepath.Join(archive, "pg_xlog"), info.Mode())
+		}
 
-		err = upgrade.ArchiveSource(source, target, true)
+		link, err := os.Readlink(filepath.Join(archive, "pg_xlog"))
 		if err != nil {
-			t.Errorf("unexpected error: %#v", err)
+			t.Fatalf("unexpected error: %#v", err)
 		}
 
-		testutils.VerifyRename(t, source, target)
+		if link != xlog {
+			t.Errorf("got symlink %q want %q", link, xlog)
+		}
 
-		testlog.VerifyLogDoesNotContain(t, log, "Source directory does not exist")
+		if !upgrade.PathExists(filepath.Join(xlog, "000000010000000000000001")) {
+			t.Errorf("expected the symlink target contents to be left in place")
+		}
 	})
 
-	t.Run("when renaming the source fails then a re-run succeeds", func(t *testing.T) {
+	t.Run("errors when a symlink cannot be read while copying across filesystems", func(t *testing.T) {
 		source, target, cleanup := testutils.MustCreateDataDirs(t)
 		defer cleanup(t)
 
+		archive := upgrade.ArchivePathFor(target)
+
+		if err := os.Symlink("/tmp/tablespace", filepath.Join(source, "16386")); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
 		expected := errors.New("permission denied")
-		utils.System.Rename = func(old, new string) error {
-			if old == source {
-				return expected
-			}
-			return os.Rename(old, new)
+		utils.System.Readlink = func(name string) (string, error) {
+			return "", expected
 		}
+		utils.System.Rename = crossDeviceRename(source, archive)
+		defer func() {
+			utils.System.Readlink = os.Readlink
+			utils.System.Rename = os.Rename
+		}()
 
-		err := upgrade.ArchiveSource(source, target, true)
+		_, err := upgrade.ArchiveSource(source, target, true)
 		if !errors.Is(err, expected) {
-			t.Errorf("got %#v want %#v", err, expected)
+			t.Errorf("got error %#v want %#v", err, expected)
 		}
 
 		if !upgrade.PathExists(source) {
 			t.Errorf("expected source %q to exist", source)
 		}
+	})
 
-		archive := target + upgrade.OldSuffix
-		if upgrade.PathExists(archive) {
-			t.Errorf("expected archive %q to not exist", archive)
-		}
+	t.Run("discards a partial copy from a previous cross filesystem run", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
 
-		if !upgrade.PathExists(target) {
-			t.Errorf("expected target %q to exist", target)
+		archive := upgrade.ArchivePathFor(target)
+		defer testutils.MustRemoveAll(t, archive)
+
+		partial := archive + ".copying"
+		if err := os.Mkdir(partial, 0700); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
 		}
+		testutils.MustWriteToFile(t, filepath.Join(partial, "stale"), "")
 
-		utils.System.Rename = os.Rename
+		utils.System.Rename = crossDeviceRename(source, archive)
+		defer func() {
+			utils.System.Rename = os.Rename
+		}()
 
-		err = upgrade.ArchiveSource(source, target, true)
+		_, err := upgrade.ArchiveSource(source, target, true)
 		if err != nil {
 			t.Errorf("unexpected error: %#v", err)
 		}
 
 		testutils.VerifyRename(t, source, target)
 
-		testlog.VerifyLogDoesNotContain(t, log, "Source directory does not exist")
+		if upgrade.PathExists(filepath.Join(archive, "stale")) {
+			t.Errorf("expected partial copy to be discarded")
+		}
 	})
 
-	t.Run("when renaming the target fails then a re-run succeeds", func(t *testing.T) {
+	t.Run("finishes a complete copy from a previous cross filesystem run", func(t *testing.T) {
 		source, target, cleanup := testutils.MustCreateDataDirs(t)
 		defer cleanup(t)
 
-		expected := errors.New("permission denied")
-		utils.System.Rename = func(old, new string) error {
-			if old == target {
-				return expected
-			}
-			return os.Rename(old, new)
+		archive := upgrade.ArchivePathFor(target)
+		defer testutils.MustRemoveAll(t, archive)
+
+		// Simulate a run that finished copying the source but crashed while
+		// removing it.
+		copied := upgrade.CopiedPath(source, archive)
+		if err := os.Mkdir(copied, 0700); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
 		}
+		testutils.MustWriteToFile(t, filepath.Join(copied, "source"), "")
 
-		err := upgrade.ArchiveSource(source, target, true)
-		if !errors.Is(err, expected) {
-			t.Errorf("got %#v want %#v", err, expected)
+		if err := os.Remove(filepath.Join(source, upgrade.PostgresFiles[0])); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
 		}
 
-		if upgrade.PathExists(source) {
-			t.Errorf("expected source %q to not exist", source)
+		testutils.MustWriteToFile(t, filepath.Join(target, "target"), "")
+
+		_, err := upgrade.ArchiveSource(source, target, true)
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
 		}
 
-		archive := target + upgrade.OldSuffix
-		if !upgrade.PathExists(archive) {
-			t.Errorf("expected archive %q to exist", archive)
+		testutils.VerifyRename(t, source, target)
+
+		if !upgrade.PathExists(filepath.Join(archive, "source")) {
+			t.Errorf("expected archive to contain the copied source")
 		}
 
-		if !upgrade.PathExists(target) {
-			t.Errorf("expected target %q to exist", target)
+		if !upgrade.PathExists(filepath.Join(source, "target")) {
+			t.Errorf("expected source to contain the target")
 		}
+	})
 
-		utils.System.Rename = os.Rename
+	t.Run("honors a custom archive suffix", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+
+		if err := upgrade.SetArchiveSuffix(".archived"); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		defer func() {
+			if err := upgrade.SetArchiveSuffix(upgrade.OldSuffix); err != nil {
+				t.Errorf("unexpected error: %#v", err)
+			}
+		}()
+
+		archive := upgrade.ArchivePathFor(target)
+		if archive != target+".archived" {
+			t.Errorf("got archive %q want %q", archive, target+".archived")
+		}
+		defer testutils.MustRemoveAll(t, archive)
 
-		err = upgrade.ArchiveSource(source, target, true)
+		_, err := upgrade.ArchiveSource(source, target, true)
 		if err != nil {
 			t.Errorf("unexpected error: %#v", err)
 		}
 
 		testutils.VerifyRename(t, source, target)
 
-		testlog.VerifyLogContains(t, log, "Source directory not found")
-	})
-}
+		if upgrade.PathExists(target + upgrade.OldSuffix) {
+			t.Errorf("expected %q to not exist", target+upgrade.OldSuffix)
+		}
 
-func setup(t *testing.T) (teardown func(), directories []string, requiredPaths []string) {
-	requiredPaths = []string{"pg_file1", "pg_file2"}
-	var dataDirectories = []string{"/data/dbfast_mirror1/seg1", "/data/dbfast_mirror2/seg2"}
-	rootDir, directories := setupDirs(t, dataDirectories, requiredPaths)
-	teardown = func() {
-		err := os.RemoveAll(rootDir)
+		_, err = upgrade.ArchiveSource(source, target, true)
 		if err != nil {
-			t.Fatalf("error %#v when deleting directory %#v", err, rootDir)
+			t.Errorf("unexpected error during rerun: %#v", err)
 		}
-	}
 
-	return teardown, directories, requiredPaths
-}
+		testutils.VerifyRename(t, source, target)
+	})
 
-func TestDeleteDirectories(t *testing.T) {
-	testlog.SetupLogger()
+	t.Run("only renames source to archive when renameTarget is false", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+
+		archive := upgrade.ArchivePathFor(target)
+
+		calls := 0
+		utils.System.Rename = func(old, new string) error {
+			calls++
+
+			if old != source {
+				t.Errorf("got %q want %q", old, source)
+			}
+
+			if new != archive {
+				t.Errorf("got %q want %q", new, archive)
+			}
+
+			return os.Rename(old, new)
+		}
+		defer func() {
+			utils.System.Rename = os.Rename
+		}()
+
+		_, err := upgrade.ArchiveSource(source, target, false)
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+
+		if calls != 1 {
+			t.Errorf("expected rename to be called once")
+		}
+
+		if upgrade.PathExists(source) {
+			t.Errorf("expected source %q to not exist", source)
+		}
+
+		if !upgrade.PathExists(archive) {
+			t.Errorf("expected archive %q to exist", archive)
+		}
+	})
+
+	t.Run("when renaming succeeds then a re-run succeeds", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+
+		_, err := upgrade.ArchiveSource(source, target, true)
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+
+		testutils.VerifyRename(t, source, target)
+
+		_, err = upgrade.ArchiveSource(source, target, true)
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+
+		testutils.VerifyRename(t, source, target)
+
+		testlog.VerifyLogDoesNotContain(t, log, "Source directory does not exist")
+	})
+
+	t.Run("when renaming the source fails then a re-run succeeds", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+
+		expected := errors.New("permission denied")
+		utils.System.Rename = func(old, new string) error {
+			if old == source {
+				return expected
+			}
+			return os.Rename(old, new)
+		}
+
+		_, err := upgrade.ArchiveSource(source, target, true)
+		if !errors.Is(err, expected) {
+			t.Errorf("got %#v want %#v", err, expected)
+		}
+
+		if !upgrade.PathExists(source) {
+			t.Errorf("expected source %q to exist", source)
+		}
+
+		archive := upgrade.ArchivePathFor(target)
+		if upgrade.PathExists(archive) {
+			t.Errorf("expected archive %q to not exist", archive)
+		}
+
+		if !upgrade.PathExists(target) {
+			t.Errorf("expected target %q to exist", target)
+		}
+
+		utils.System.Rename = os.Rename
+
+		_, err = upgrade.ArchiveSource(source, target, true)
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+
+		testutils.VerifyRename(t, source, target)
+
+		testlog.VerifyLogDoesNotContain(t, log, "Source directory does not exist")
+	})
+
+	t.Run("when renaming the target fails then a re-run succeeds", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+
+		expected := errors.New("permission denied")
+		utils.System.Rename = func(old, new string) error {
+			if old == target {
+				return expected
+			}
+			return os.Rename(old, new)
+		}
+
+		_, err := upgrade.ArchiveSource(source, target, true)
+		if !errors.Is(err, expected) {
+			t.Errorf("got %#v want %#v", err, expected)
+		}
+
+		if upgrade.PathExists(source) {
+			t.Errorf("expected source %q to not exist", source)
+		}
+
+		archive := upgrade.ArchivePathFor(target)
+		if !upgrade.PathExists(archive) {
+			t.Errorf("expected archive %q to exist", archive)
+		}
+
+		if !upgrade.PathExists(target) {
+			t.Errorf("expected target %q to exist", target)
+		}
+
+		utils.System.Rename = os.Rename
+
+		status, err := upgrade.ArchiveSource(source, target, true)
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+
+		if status != upgrade.SourceMissing {
+			t.Errorf("got status %q want %q", status, upgrade.SourceMissing)
+		}
+
+		testutils.VerifyRename(t, source, target)
+
+		testlog.VerifyLogContains(t, log, "Source directory not found")
+	})
+}
+
+func setup(t *testing.T) (teardown func(), directories []string, requiredPaths []string) {
+	requiredPaths = []string{"pg_file1", "pg_file2"}
+	var dataDirectories = []string{"/data/dbfast_mirror1/seg1", "/data/dbfast_mirror2/seg2"}
+	rootDir, directories := setupDirs(t, dataDirectories, requiredPaths)
+	teardown = func() {
+		err := os.RemoveAll(rootDir)
+		if err != nil {
+			t.Fatalf("error %#v when deleting directory %#v", err, rootDir)
+		}
+	}
+
+	return teardown, directories, requiredPaths
+}
+
+func TestDeleteDirectories(t *testing.T) {
+	testlog.SetupLogger()
 
 	utils.System.Hostname = func() (string, error) {
 		return "localhost.local", nil
@@ -388,6 +844,76 @@ func TestDeleteDirectories(t *testing.T) {
 		}
 	})
 
+	t.Run("skips directories that do not exist without checking their required paths", func(t *testing.T) {
+		var buf bytes.Buffer
+		devNull := testutils.DevNullSpy{
+			OutStream: &buf,
+		}
+		teardown, directories, requiredPaths := setup(t)
+		defer teardown()
+
+		testutils.MustRemoveAll(t, directories[0])
+
+		var statted []string
+		utils.System.Stat = func(name string) (os.FileInfo, error) {
+			statted = append(statted, name)
+			return os.Stat(name)
+		}
+		defer func() {
+			utils.System.Stat = os.Stat
+		}()
+
+		results, err := upgrade.DeleteDirectoriesWithResults(directories, requiredPaths, devNull)
+		if err != nil {
+			t.Errorf("unexpected error got %+v", err)
+		}
+
+		expectedResults := []upgrade.DeleteResult{
+			{Path: directories[0], Status: upgrade.DirectoryAlreadyDeleted},
+			{Path: directories[1], Status: upgrade.DirectoryDeleted},
+		}
+		if !reflect.DeepEqual(results, expectedResults) {
+			t.Errorf("got results %+v want %+v", results, expectedResults)
+		}
+
+		for _, path := range statted {
+			if strings.HasPrefix(path, directories[0]+string(os.PathSeparator)) {
+				t.Errorf("unexpected stat of %q in a directory that does not exist", path)
+			}
+		}
+
+		expected := fmt.Sprintf("directory: %q does not exist on host %q, skipping\n", directories[0], "localhost.local")
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("expected stream output %q to contain %q", buf.String(), expected)
+		}
+	})
+
+	t.Run("errors when checking whether a directory exists fails", func(t *testing.T) {
+		teardown, directories, requiredPaths := setup(t)
+		defer teardown()
+
+		expected := errors.New("permission denied")
+		utils.System.Stat = func(name string) (os.FileInfo, error) {
+			if name == directories[0] {
+				return nil, expected
+			}
+
+			return os.Stat(name)
+		}
+		defer func() {
+			utils.System.Stat = os.Stat
+		}()
+
+		err := upgrade.DeleteDirectories(directories, requiredPaths, step.DevNullStream)
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+
+		if _, err := os.Stat(directories[0]); err != nil {
+			t.Errorf("dataDir should exist, stat error %+v", err)
+		}
+	})
+
 	t.Run("fails when the required paths are not in the directories", func(t *testing.T) {
 		teardown, directories, _ := setup(t)
 		defer teardown()
@@ -435,81 +961,1377 @@ func TestDeleteDirectories(t *testing.T) {
 		}
 	})
 
-	t.Run("errors when hostname fails", func(t *testing.T) {
+	t.Run("annotates errors with the host they occurred on", func(t *testing.T) {
 		teardown, directories, requiredPaths := setup(t)
 		defer teardown()
 
-		expected := errors.New("unable to resolve host name")
-		utils.System.Hostname = func() (string, error) {
-			return "", expected
+		fileToRemove := filepath.Join(directories[0], requiredPaths[0])
+		if err := os.Remove(fileToRemove); err != nil {
+			t.Errorf("unexpected error %+v", err)
+		}
+
+		err := upgrade.DeleteDirectories(directories, requiredPaths, step.DevNullStream)
+
+		var hostErr *errorlist.HostError
+		if !errors.As(err, &hostErr) {
+			t.Fatalf("got error %#v want type %T", err, hostErr)
+		}
+
+		if hostErr.Host != "localhost.local" {
+			t.Errorf("got host %q want %q", hostErr.Host, "localhost.local")
+		}
+
+		var pathErr *os.PathError
+		if !errors.As(err, &pathErr) {
+			t.Errorf("got error %#v want type %T", err, pathErr)
+		}
+
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("got error %#v want %#v", err, os.ErrNotExist)
+		}
+	})
+
+	t.Run("errors when removing a segment data directory fails", func(t *testing.T) {
+		teardown, directories, requiredPaths := setup(t)
+		defer teardown()
+
+		expected := errors.New("device or resource busy")
+		utils.System.RemoveAll = func(path string) error {
+			if path == directories[0] {
+				return expected
+			}
+
+			return os.RemoveAll(path)
 		}
 		defer func() {
-			utils.System.Hostname = os.Hostname
+			utils.System.RemoveAll = os.RemoveAll
 		}()
 
-		err := upgrade.DeleteDirectories(directories, requiredPaths, step.DevNullStream)
+		results, err := upgrade.DeleteDirectoriesWithResults(directories, requiredPaths, step.DevNullStream)
 		if !errors.Is(err, expected) {
 			t.Errorf("got error %#v want %#v", err, expected)
 		}
-	})
-}
 
-func TestTablespacePath(t *testing.T) {
-	t.Run("returns correct path", func(t *testing.T) {
-		path := upgrade.TablespacePath("/tmp/testfs/master/demoDataDir-1/16386", 1, 6, "301908232")
-		expected := "/tmp/testfs/master/demoDataDir-1/16386/1/GPDB_6_301908232"
-		if path != expected {
-			t.Errorf("got %q want %q", path, expected)
+		expectedResults := []upgrade.DeleteResult{
+			{Path: directories[0], Status: upgrade.DirectoryDeleteFailed, Err: &errorlist.HostError{Host: "localhost.local", Err: expected}},
+			{Path: directories[1], Status: upgrade.DirectoryDeleted},
+		}
+		if !reflect.DeepEqual(results, expectedResults) {
+			t.Errorf("got results %+v want %+v", results, expectedResults)
+		}
+
+		if !upgrade.PathExists(directories[0]) {
+			t.Errorf("expected directory %q to not be deleted", directories[0])
+		}
+
+		if upgrade.PathExists(directories[1]) {
+			t.Errorf("expected directory %q to be deleted", directories[1])
 		}
 	})
-}
 
-func TestPathExist(t *testing.T) {
-	t.Run("path exists", func(t *testing.T) {
+	t.Run("dry run reports the directories without deleting them", func(t *testing.T) {
+		var buf bytes.Buffer
+		devNull := testutils.DevNullSpy{
+			OutStream: &buf,
+		}
+		teardown, directories, requiredPaths := setup(t)
+		defer teardown()
+
+		err := upgrade.DeleteDirectoriesDryRun(directories, requiredPaths, devNull)
+		if err != nil {
+			t.Errorf("unexpected error got %+v", err)
+		}
+
+		for _, dataDir := range directories {
+			if _, err := os.Stat(dataDir); err != nil {
+				t.Errorf("dataDir should exist, stat error %+v", err)
+			}
+		}
+
+		expected := regexp.MustCompile(`Would delete directory: ".*/data/dbfast_mirror1/seg1" on host "localhost.local"\nWould delete directory: ".*/data/dbfast_mirror2/seg2" on host "localhost.local"`)
+
+		actual := buf.String()
+		if !expected.MatchString(actual) {
+			t.Errorf("got stream output %s want %s", actual, expected)
+		}
+	})
+
+	t.Run("dry run fails when the required paths are not in the directories", func(t *testing.T) {
+		teardown, directories, _ := setup(t)
+		defer teardown()
+
+		err := upgrade.DeleteDirectoriesDryRun(directories, []string{"a", "b"}, step.DevNullStream)
+
+		var errs errorlist.Errors
+		if !errors.As(err, &errs) {
+			t.Fatalf("got error %#v, want type %T", err, errs)
+		}
+
+		if len(errs) != 4 {
+			t.Errorf("received %d errors, want %d", len(errs), 4)
+		}
+	})
+
+	t.Run("still deletes the directories and returns the error when hostname fails", func(t *testing.T) {
+		teardown, directories, requiredPaths := setup(t)
+		defer teardown()
+
+		expected := errors.New("unable to resolve host name")
+		utils.System.Hostname = func() (string, error) {
+			return "", expected
+		}
+		defer func() {
+			utils.System.Hostname = os.Hostname
+		}()
+
+		var buf bytes.Buffer
+		devNull := testutils.DevNullSpy{
+			OutStream: &buf,
+		}
+
+		err := upgrade.DeleteDirectories(directories, requiredPaths, devNull)
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+
+		for _, dataDir := range directories {
+			if upgrade.PathExists(dataDir) {
+				t.Errorf("expected %q to be deleted", dataDir)
+			}
+		}
+
+		for _, dataDir := range directories {
+			expected := fmt.Sprintf("Deleting directory: %q on host %q\n", dataDir, upgrade.UnknownHost)
+			if !strings.Contains(buf.String(), expected) {
+				t.Errorf("expected stream output %q to contain %q", buf.String(), expected)
+			}
+		}
+	})
+}
+
+func TestRestoreSource(t *testing.T) {
+	_, _, log := testlog.SetupLogger()
+
+	// archive creates the directory layout left behind by ArchiveSource.
+	archive := func(t *testing.T) (string, string, func(*testing.T)) {
+		t.Helper()
+
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		if _, err := upgrade.ArchiveSource(source, target, true); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		return source, target, func(t *testing.T) {
+			cleanup(t)
+			testutils.MustRemoveAll(t, upgrade.ArchivePathFor(target))
+		}
+	}
+
+	t.Run("renames source to target, and archive to source", func(t *testing.T) {
+		source, target, cleanup := archive(t)
+		defer cleanup(t)
+
+		err := upgrade.RestoreSource(source, target)
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+
+		verifyRestore(t, source, target)
+	})
+
+	t.Run("logs how long restoring took", func(t *testing.T) {
+		source, target, cleanup := archive(t)
+		defer cleanup(t)
+
+		err := upgrade.RestoreSource(source, target)
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+
+		expected := "restoring source directory started at "
+		if !strings.Contains(string(log.Bytes()), expected) {
+			t.Errorf("log %q does not contain %q", string(log.Bytes()), expected)
+		}
+	})
+
+	t.Run("only restores the archive when the target was not renamed", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+
+		testutils.MustRemoveAll(t, target)
+		if _, err := upgrade.ArchiveSource(source, target, false); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		err := upgrade.RestoreSource(source, target)
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+
+		if !upgrade.PathExists(source) {
+			t.Errorf("expected source %q to exist", source)
+		}
+
+		if upgrade.PathExists(upgrade.ArchivePathFor(target)) {
+			t.Errorf("expected archive %q to not exist", upgrade.ArchivePathFor(target))
+		}
+	})
+
+	t.Run("returns early if already restored", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+
+		called := false
+		utils.System.Rename = func(old, new string) error {
+			called = true
+			return nil
+		}
+		defer func() {
+			utils.System.Rename = os.Rename
+		}()
+
+		err := upgrade.RestoreSource(source, target)
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+
+		if called {
+			t.Errorf("expected rename to not be called")
+		}
+	})
+
+	t.Run("bubbles up errors", func(t *testing.T) {
+		source, target, cleanup := archive(t)
+		defer cleanup(t)
+
+		expected := errors.New("permission denied")
+		utils.System.Rename = func(old, new string) error {
+			return expected
+		}
+		defer func() {
+			utils.System.Rename = os.Rename
+		}()
+
+		err := upgrade.RestoreSource(source, target)
+		if !errors.Is(err, expected) {
+			t.Errorf("got %#v want %#v", err, expected)
+		}
+	})
+
+	t.Run("errors when restoring a directory that is not like postgres", func(t *testing.T) {
+		source := testutils.GetTempDir(t, "source")
+		defer testutils.MustRemoveAll(t, source)
+
+		target := testutils.GetTempDir(t, "target")
+		defer testutils.MustRemoveAll(t, target)
+
+		archive := upgrade.ArchivePathFor(target)
+		if err := os.Rename(target, archive); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		defer testutils.MustRemoveAll(t, archive)
+
+		err := upgrade.RestoreSource(source, target)
+
+		var errs errorlist.Errors
+		if !errors.As(err, &errs) {
+			t.Fatalf("returned %#v want error type %T", err, errs)
+		}
+
+		for _, err := range errs {
+			expected := upgrade.ErrInvalidDataDirectory
+			if !errors.Is(err, expected) {
+				t.Errorf("returned error %#v want %#v", err, expected)
+			}
+		}
+	})
+
+	t.Run("when renaming the source fails then a re-run succeeds", func(t *testing.T) {
+		source, target, cleanup := archive(t)
+		defer cleanup(t)
+
+		expected := errors.New("permission denied")
+		utils.System.Rename = func(old, new string) error {
+			if old == source {
+				return expected
+			}
+			return os.Rename(old, new)
+		}
+
+		err := upgrade.RestoreSource(source, target)
+		if !errors.Is(err, expected) {
+			t.Errorf("got %#v want %#v", err, expected)
+		}
+
+		testutils.VerifyRename(t, source, target)
+
+		utils.System.Rename = os.Rename
+
+		err = upgrade.RestoreSource(source, target)
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+
+		verifyRestore(t, source, target)
+	})
+
+	t.Run("when renaming the archive fails then a re-run succeeds", func(t *testing.T) {
+		source, target, cleanup := archive(t)
+		defer cleanup(t)
+
+		archive := upgrade.ArchivePathFor(target)
+
+		expected := errors.New("permission denied")
+		utils.System.Rename = func(old, new string) error {
+			if old == archive {
+				return expected
+			}
+			return os.Rename(old, new)
+		}
+
+		err := upgrade.RestoreSource(source, target)
+		if !errors.Is(err, expected) {
+			t.Errorf("got %#v want %#v", err, expected)
+		}
+
+		if upgrade.PathExists(source) {
+			t.Errorf("expected source %q to not exist", source)
+		}
+
+		if !upgrade.PathExists(archive) {
+			t.Errorf("expected archive %q to exist", archive)
+		}
+
+		if !upgrade.PathExists(target) {
+			t.Errorf("expected target %q to exist", target)
+		}
+
+		utils.System.Rename = os.Rename
+
+		err = upgrade.RestoreSource(source, target)
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+
+		verifyRestore(t, source, target)
+	})
+
+	t.Run("restores the original source after an interrupted cross filesystem archive", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+
+		archive := upgrade.ArchivePathFor(target)
+		defer testutils.MustRemoveAll(t, archive)
+
+		testutils.MustWriteToFile(t, filepath.Join(source, "source"), "")
+		testutils.MustWriteToFile(t, filepath.Join(target, "target"), "")
+
+		// Simulate an archive that copied the target to the source across
+		// filesystems, but was interrupted before removing the target.
+		interrupted := errors.New("interrupted")
+		utils.System.Rename = crossDeviceRename(target, source)
+		utils.System.RemoveAll = func(path string) error {
+			if path == target {
+				return interrupted
+			}
+			return os.RemoveAll(path)
+		}
+
+		_, err := upgrade.ArchiveSource(source, target, true)
+		if !errors.Is(err, interrupted) {
+			t.Fatalf("got error %#v want %#v", err, interrupted)
+		}
+
+		if !upgrade.PathExists(upgrade.CopiedPath(target, source)) {
+			t.Fatalf("expected a complete copy of the target at %q", upgrade.CopiedPath(target, source))
+		}
+
+		utils.System.Rename = os.Rename
+		utils.System.RemoveAll = os.RemoveAll
+
+		err = upgrade.RestoreSource(source, target)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		verifyRestore(t, source, target)
+
+		if !upgrade.PathExists(filepath.Join(source, "source")) {
+			t.Errorf("expected source %q to contain the original source", source)
+		}
+
+		if !upgrade.PathExists(filepath.Join(target, "target")) {
+			t.Errorf("expected target %q to contain the upgraded target", target)
+		}
+
+		if upgrade.PathExists(upgrade.CopiedPath(target, source)) {
+			t.Errorf("expected copy %q to not exist", upgrade.CopiedPath(target, source))
+		}
+	})
+}
+
+func TestArchivePathFor(t *testing.T) {
+	archive := upgrade.ArchivePathFor("/data/dbfast1/demoDataDir.123ABC.0")
+	expected := "/data/dbfast1/demoDataDir.123ABC.0.old"
+	if archive != expected {
+		t.Errorf("got %q want %q", archive, expected)
+	}
+}
+
+func TestSetArchiveSuffix(t *testing.T) {
+	defer func() {
+		if err := upgrade.SetArchiveSuffix(upgrade.OldSuffix); err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+	}()
+
+	t.Run("defaults to the old suffix", func(t *testing.T) {
+		if upgrade.ArchiveSuffix() != upgrade.OldSuffix {
+			t.Errorf("got %q want %q", upgrade.ArchiveSuffix(), upgrade.OldSuffix)
+		}
+	})
+
+	t.Run("sets the suffix", func(t *testing.T) {
+		err := upgrade.SetArchiveSuffix(".gpupgrade")
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+
+		if upgrade.ArchiveSuffix() != ".gpupgrade" {
+			t.Errorf("got %q want %q", upgrade.ArchiveSuffix(), ".gpupgrade")
+		}
+	})
+
+	errCases := []struct {
+		name   string
+		suffix string
+	}{
+		{name: "errors when the suffix is empty", suffix: ""},
+		{name: "errors when the suffix contains a path separator", suffix: ".old/bad"},
+	}
+
+	for _, c := range errCases {
+		t.Run(c.name, func(t *testing.T) {
+			previous := upgrade.ArchiveSuffix()
+
+			err := upgrade.SetArchiveSuffix(c.suffix)
+			if err == nil {
+				t.Errorf("expected an error")
+			}
+
+			if upgrade.ArchiveSuffix() != previous {
+				t.Errorf("got %q want unchanged suffix %q", upgrade.ArchiveSuffix(), previous)
+			}
+		})
+	}
+}
+
+func TestDeleteDirectoriesMatching(t *testing.T) {
+	testlog.SetupLogger()
+
+	// requiresConfig only matches directories containing a postgresql.conf.
+	requiresConfig := func(dir string) (bool, error) {
+		return upgrade.PathExist(filepath.Join(dir, "postgresql.conf"))
+	}
+
+	t.Run("deletes directories the matcher accepts and rejects the rest", func(t *testing.T) {
+		rootDir, directories := setupDirs(t, []string{"matched", "unmatched"}, []string{"postgresql.conf"})
+		defer testutils.MustRemoveAll(t, rootDir)
+
+		unmatched := directories[1]
+		if err := os.Remove(filepath.Join(unmatched, "postgresql.conf")); err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+
+		err := upgrade.DeleteDirectoriesMatching(directories, requiresConfig, step.DevNullStream)
+		if !errors.Is(err, upgrade.ErrDirectoryNotMatched) {
+			t.Errorf("got error %#v want %#v", err, upgrade.ErrDirectoryNotMatched)
+		}
+
+		if upgrade.PathExists(directories[0]) {
+			t.Errorf("expected directory %q to be deleted", directories[0])
+		}
+
+		if !upgrade.PathExists(unmatched) {
+			t.Errorf("expected directory %q to not be deleted", unmatched)
+		}
+	})
+
+	t.Run("returns errors from the matcher", func(t *testing.T) {
+		rootDir, directories := setupDirs(t, []string{"dir"}, []string{"postgresql.conf"})
+		defer testutils.MustRemoveAll(t, rootDir)
+
+		expected := errors.New("permission denied")
+		matcher := func(dir string) (bool, error) {
+			return false, expected
+		}
+
+		err := upgrade.DeleteDirectoriesMatching(directories, matcher, step.DevNullStream)
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+
+		if !upgrade.PathExists(directories[0]) {
+			t.Errorf("expected directory %q to not be deleted", directories[0])
+		}
+	})
+
+	t.Run("DataDirectoryMatcher rejects directories that are not data directories", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+
+		if err := os.Remove(filepath.Join(target, "postgresql.conf")); err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+
+		err := upgrade.DeleteDirectoriesMatching([]string{source, target}, upgrade.DataDirectoryMatcher, step.DevNullStream)
+		if !errors.Is(err, upgrade.ErrInvalidDataDirectory) {
+			t.Errorf("got error %#v want %#v", err, upgrade.ErrInvalidDataDirectory)
+		}
+
+		if upgrade.PathExists(source) {
+			t.Errorf("expected directory %q to be deleted", source)
+		}
+
+		if !upgrade.PathExists(target) {
+			t.Errorf("expected directory %q to not be deleted", target)
+		}
+	})
+}
+
+func TestDeleteDirectoriesWithResults(t *testing.T) {
+	testlog.SetupLogger()
+
+	t.Run("reports the outcome for each directory", func(t *testing.T) {
+		rootDir, directories := setupDirs(t, []string{"deleted", "failed"}, []string{"pg_file1"})
+		defer testutils.MustRemoveAll(t, rootDir)
+
+		failed := directories[1]
+		if err := os.Remove(filepath.Join(failed, "pg_file1")); err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+
+		missing := filepath.Join(rootDir, "missing")
+		directories = append(directories, missing)
+
+		results, err := upgrade.DeleteDirectoriesWithResults(directories, []string{"pg_file1"}, step.DevNullStream)
+
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("got error %#v want %#v", err, os.ErrNotExist)
+		}
+
+		if len(results) != 3 {
+			t.Fatalf("got %d results want 3", len(results))
+		}
+
+		expected := []upgrade.DeleteResult{
+			{Path: directories[0], Status: upgrade.DirectoryDeleted},
+			{Path: failed, Status: upgrade.DirectoryDeleteFailed, Err: err},
+			{Path: missing, Status: upgrade.DirectoryAlreadyDeleted},
+		}
+
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("got results %+v want %+v", results, expected)
+		}
+
+		if upgrade.PathExists(directories[0]) {
+			t.Errorf("expected directory %q to be deleted", directories[0])
+		}
+
+		if !upgrade.PathExists(failed) {
+			t.Errorf("expected directory %q to not be deleted", failed)
+		}
+	})
+
+	t.Run("still reports results and returns the error when hostname fails", func(t *testing.T) {
+		expected := errors.New("unable to resolve host name")
+		utils.System.Hostname = func() (string, error) {
+			return "", expected
+		}
+		defer func() {
+			utils.System.Hostname = os.Hostname
+		}()
+
+		missing := filepath.Join(testutils.GetTempDir(t, ""), "missing")
+		defer testutils.MustRemoveAll(t, filepath.Dir(missing))
+
+		results, err := upgrade.DeleteDirectoriesWithResults([]string{missing}, nil, step.DevNullStream)
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+
+		expectedResults := []upgrade.DeleteResult{{Path: missing, Status: upgrade.DirectoryAlreadyDeleted}}
+		if !reflect.DeepEqual(results, expectedResults) {
+			t.Errorf("got results %+v want %+v", results, expectedResults)
+		}
+	})
+}
+
+func TestDeleteDirectoriesContext(t *testing.T) {
+	testlog.SetupLogger()
+
+	t.Run("stops deleting once the context is cancelled", func(t *testing.T) {
+		rootDir, directories := setupDirs(t, []string{"first", "second", "third"}, []string{"pg_file1"})
+		defer testutils.MustRemoveAll(t, rootDir)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		utils.System.RemoveAll = func(path string) error {
+			defer cancel()
+			return os.RemoveAll(path)
+		}
+		defer func() {
+			utils.System.RemoveAll = os.RemoveAll
+		}()
+
+		err := upgrade.DeleteDirectoriesContext(ctx, directories, []string{"pg_file1"}, step.DevNullStream)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got error %#v want %#v", err, context.Canceled)
+		}
+
+		if upgrade.PathExists(directories[0]) {
+			t.Errorf("expected directory %q to be deleted", directories[0])
+		}
+
+		for _, dir := range directories[1:] {
+			if !upgrade.PathExists(dir) {
+				t.Errorf("expected directory %q to not be deleted", dir)
+			}
+		}
+	})
+
+	t.Run("deletes nothing when the context is already cancelled", func(t *testing.T) {
+		rootDir, directories := setupDirs(t, []string{"first"}, []string{"pg_file1"})
+		defer testutils.MustRemoveAll(t, rootDir)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := upgrade.DeleteDirectoriesContext(ctx, directories, []string{"pg_file1"}, step.DevNullStream)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got error %#v want %#v", err, context.Canceled)
+		}
+
+		if !upgrade.PathExists(directories[0]) {
+			t.Errorf("expected directory %q to not be deleted", directories[0])
+		}
+	})
+}
+
+func TestDeleteDirectoriesToTrash(t *testing.T) {
+	testlog.SetupLogger()
+
+	t.Run("moves directories into the trash instead of removing them", func(t *testing.T) {
+		rootDir, directories := setupDirs(t, []string{"first", "second"}, []string{"pg_file1"})
+		defer testutils.MustRemoveAll(t, rootDir)
+
+		now := time.Date(2021, time.March, 4, 5, 6, 7, 0, time.UTC)
+		utils.System.Now = func() time.Time {
+			return now
+		}
+		defer func() {
+			utils.System.Now = time.Now
+		}()
+
+		// A previous trashing of the same directory at the same time must not
+		// be clobbered.
+		trashDir := filepath.Join(rootDir, "trash")
+		existing := filepath.Join(trashDir, "first.20210304T050607")
+		if err := os.MkdirAll(existing, 0700); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		var buf bytes.Buffer
+		devNull := testutils.DevNullSpy{
+			OutStream: &buf,
+		}
+
+		err := upgrade.DeleteDirectoriesToTrash(directories, []string{"pg_file1"}, trashDir, devNull)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		for _, dir := range directories {
+			if upgrade.PathExists(dir) {
+				t.Errorf("expected directory %q to be moved", dir)
+			}
+
+			if !strings.Contains(buf.String(), fmt.Sprintf("Moving to trash directory: %q", dir)) {
+				t.Errorf("expected stdout %q to contain %q", buf.String(), dir)
+			}
+		}
+
+		for _, trashed := range []string{existing + ".1", filepath.Join(trashDir, "second.20210304T050607")} {
+			if !upgrade.PathExists(filepath.Join(trashed, "pg_file1")) {
+				t.Errorf("expected %q to be in the trash", trashed)
+			}
+		}
+	})
+}
+
+func TestDeleteDirectoriesWithTablespaces(t *testing.T) {
+	testlog.SetupLogger()
+
+	// A 6X tablespace directory as linked from pg_tblspc.
+	tablespace6X := []string{"GPDB_6_301908232/16384/16386"}
+
+	// setup creates a data directory whose pg_tblspc links to a tablespace
+	// directory outside of it, and returns both. Each of targetPaths is
+	// created as a file within the tablespace directory.
+	setup := func(t *testing.T, targetPaths []string) (rootDir, dataDir, target string) {
+		rootDir, directories := setupDirs(t, []string{"datadir"}, []string{"pg_file1"})
+		dataDir = directories[0]
+		target = filepath.Join(rootDir, "tablespace")
+
+		if err := os.Mkdir(target, userRWX); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		for _, path := range targetPaths {
+			path = filepath.Join(target, path)
+			if err := os.MkdirAll(filepath.Dir(path), userRWX); err != nil {
+				t.Fatalf("unexpected error: %#v", err)
+			}
+
+			testutils.MustWriteToFile(t, path, "")
+		}
+
+		tblspc := filepath.Join(dataDir, "pg_tblspc")
+		if err := os.Mkdir(tblspc, userRWX); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if err := os.Symlink(target, filepath.Join(tblspc, "16385")); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		return rootDir, dataDir, target
+	}
+
+	t.Run("deletes the targets of tablespace symlinks", func(t *testing.T) {
+		rootDir, dataDir, target := setup(t, tablespace6X)
+		defer testutils.MustRemoveAll(t, rootDir)
+
+		// Symlinks not named by a tablespace OID are not followed.
+		other := createDataDir(t, "other", rootDir, []string{"pg_file1"})
+		if err := os.Symlink(other, filepath.Join(dataDir, "pg_tblspc", "notes")); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		var buf bytes.Buffer
+		devNull := testutils.DevNullSpy{
+			OutStream: &buf,
+		}
+
+		err := upgrade.DeleteDirectoriesWithTablespaces([]string{dataDir}, []string{"pg_file1"}, devNull)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		for _, dir := range []string{dataDir, target} {
+			if upgrade.PathExists(dir) {
+				t.Errorf("expected directory %q to be deleted", dir)
+			}
+		}
+
+		if !upgrade.PathExists(other) {
+			t.Errorf("expected directory %q to not be deleted", other)
+		}
+
+		expected := fmt.Sprintf("Deleting tablespace directory: %q linked from %q", target, dataDir)
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("expected stdout %q to contain %q", buf.String(), expected)
+		}
+	})
+
+	t.Run("DeleteDirectories does not delete the targets of tablespace symlinks", func(t *testing.T) {
+		rootDir, dataDir, target := setup(t, tablespace6X)
+		defer testutils.MustRemoveAll(t, rootDir)
+
+		err := upgrade.DeleteDirectories([]string{dataDir}, []string{"pg_file1"}, step.DevNullStream)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if upgrade.PathExists(dataDir) {
+			t.Errorf("expected directory %q to be deleted", dataDir)
+		}
+
+		if !upgrade.PathExists(filepath.Join(target, tablespace6X[0])) {
+			t.Errorf("expected tablespace directory %q to not be deleted", target)
+		}
+	})
+
+	t.Run("deletes the targets of 5X tablespace symlinks", func(t *testing.T) {
+		rootDir, dataDir, target := setup(t, []string{"16384/PG_VERSION", "16385/PG_VERSION"})
+		defer testutils.MustRemoveAll(t, rootDir)
+
+		err := upgrade.DeleteDirectoriesWithTablespaces([]string{dataDir}, []string{"pg_file1"}, step.DevNullStream)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		for _, dir := range []string{dataDir, target} {
+			if upgrade.PathExists(dir) {
+				t.Errorf("expected directory %q to be deleted", dir)
+			}
+		}
+	})
+
+	errCases := []struct {
+		name        string
+		targetPaths []string
+	}{
+		{"is empty", []string{}},
+		{"only contains the data directory's required paths", []string{"pg_file1"}},
+		{"has a dbOid directory without PG_VERSION", []string{"16384/PG_VERSION", "16385/pg_file1"}},
+	}
+
+	for _, c := range errCases {
+		t.Run(fmt.Sprintf("deletes nothing when a target %s", c.name), func(t *testing.T) {
+			rootDir, dataDir, target := setup(t, c.targetPaths)
+			defer testutils.MustRemoveAll(t, rootDir)
+
+			err := upgrade.DeleteDirectoriesWithTablespaces([]string{dataDir}, []string{"pg_file1"}, step.DevNullStream)
+			if !errors.Is(err, upgrade.ErrInvalidTablespaceDirectory) {
+				t.Errorf("got error %#v want %#v", err, upgrade.ErrInvalidTablespaceDirectory)
+			}
+
+			for _, dir := range []string{dataDir, target} {
+				if !upgrade.PathExists(dir) {
+					t.Errorf("expected directory %q to not be deleted", dir)
+				}
+			}
+		})
+	}
+
+	t.Run("skips targets that have already been deleted", func(t *testing.T) {
+		rootDir, dataDir, target := setup(t, tablespace6X)
+		defer testutils.MustRemoveAll(t, rootDir)
+
+		testutils.MustRemoveAll(t, target)
+
+		err := upgrade.DeleteDirectoriesWithTablespaces([]string{dataDir}, []string{"pg_file1"}, step.DevNullStream)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if upgrade.PathExists(dataDir) {
+			t.Errorf("expected directory %q to be deleted", dataDir)
+		}
+	})
+
+	t.Run("errors when a tablespace symlink cannot be read", func(t *testing.T) {
+		rootDir, dataDir, target := setup(t, tablespace6X)
+		defer testutils.MustRemoveAll(t, rootDir)
+
+		expected := errors.New("permission denied")
+		utils.System.Readlink = func(string) (string, error) {
+			return "", expected
+		}
+		defer func() {
+			utils.System.Readlink = os.Readlink
+		}()
+
+		err := upgrade.DeleteDirectoriesWithTablespaces([]string{dataDir}, []string{"pg_file1"}, step.DevNullStream)
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+
+		for _, dir := range []string{dataDir, target} {
+			if !upgrade.PathExists(dir) {
+				t.Errorf("expected directory %q to not be deleted", dir)
+			}
+		}
+	})
+}
+
+func TestDeleteDirectoriesPostmasterRunning(t *testing.T) {
+	testlog.SetupLogger()
+
+	t.Run("refuses to delete a directory whose postmaster is running", func(t *testing.T) {
+		rootDir, directories := setupDirs(t, []string{"running"}, []string{"pg_file1"})
+		defer testutils.MustRemoveAll(t, rootDir)
+
+		testutils.MustWriteToFile(t, filepath.Join(directories[0], "postmaster.pid"), "12345\n/data/running\n")
+
+		var pid int
+		utils.System.Kill = func(p int, sig syscall.Signal) error {
+			pid = p
+			if sig != 0 {
+				t.Errorf("got signal %d want 0", sig)
+			}
+
+			return nil
+		}
+		defer func() {
+			utils.System.Kill = syscall.Kill
+		}()
+
+		err := upgrade.DeleteDirectories(directories, []string{"pg_file1"}, step.DevNullStream)
+
+		var runningErr *upgrade.PostmasterRunningError
+		if !errors.As(err, &runningErr) {
+			t.Fatalf("got error %#v want type %T", err, runningErr)
+		}
+
+		if !errors.Is(err, upgrade.ErrPostmasterRunning) {
+			t.Errorf("got error %#v want %#v", err, upgrade.ErrPostmasterRunning)
+		}
+
+		expected := &upgrade.PostmasterRunningError{Dir: directories[0], PID: 12345}
+		if !reflect.DeepEqual(runningErr, expected) {
+			t.Errorf("got error %+v want %+v", runningErr, expected)
+		}
+
+		if pid != 12345 {
+			t.Errorf("got PID %d want %d", pid, 12345)
+		}
+
+		if !upgrade.PathExists(directories[0]) {
+			t.Errorf("expected directory %q to not be deleted", directories[0])
+		}
+	})
+
+	t.Run("refuses to delete when the postmaster belongs to another user", func(t *testing.T) {
+		rootDir, directories := setupDirs(t, []string{"running"}, []string{"pg_file1"})
+		defer testutils.MustRemoveAll(t, rootDir)
+
+		testutils.MustWriteToFile(t, filepath.Join(directories[0], "postmaster.pid"), "12345\n")
+
+		utils.System.Kill = func(int, syscall.Signal) error {
+			return syscall.EPERM
+		}
+		defer func() {
+			utils.System.Kill = syscall.Kill
+		}()
+
+		err := upgrade.DeleteDirectories(directories, []string{"pg_file1"}, step.DevNullStream)
+		if !errors.Is(err, upgrade.ErrPostmasterRunning) {
+			t.Errorf("got error %#v want %#v", err, upgrade.ErrPostmasterRunning)
+		}
+
+		if !upgrade.PathExists(directories[0]) {
+			t.Errorf("expected directory %q to not be deleted", directories[0])
+		}
+	})
+
+	t.Run("deletes a directory with a stale postmaster.pid", func(t *testing.T) {
+		rootDir, directories := setupDirs(t, []string{"stale"}, []string{"pg_file1"})
+		defer testutils.MustRemoveAll(t, rootDir)
+
+		testutils.MustWriteToFile(t, filepath.Join(directories[0], "postmaster.pid"), "12345\n")
+
+		utils.System.Kill = func(int, syscall.Signal) error {
+			return syscall.ESRCH
+		}
+		defer func() {
+			utils.System.Kill = syscall.Kill
+		}()
+
+		err := upgrade.DeleteDirectories(directories, []string{"pg_file1"}, step.DevNullStream)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if upgrade.PathExists(directories[0]) {
+			t.Errorf("expected directory %q to be deleted", directories[0])
+		}
+	})
+
+	t.Run("errors when postmaster.pid cannot be parsed", func(t *testing.T) {
+		rootDir, directories := setupDirs(t, []string{"corrupt"}, []string{"pg_file1"})
+		defer testutils.MustRemoveAll(t, rootDir)
+
+		testutils.MustWriteToFile(t, filepath.Join(directories[0], "postmaster.pid"), "not a pid\n")
+
+		err := upgrade.DeleteDirectories(directories, []string{"pg_file1"}, step.DevNullStream)
+
+		var numErr *strconv.NumError
+		if !errors.As(err, &numErr) {
+			t.Errorf("got error %#v want type %T", err, numErr)
+		}
+
+		if !upgrade.PathExists(directories[0]) {
+			t.Errorf("expected directory %q to not be deleted", directories[0])
+		}
+	})
+}
+
+func TestDeleteDirectoriesWithProgress(t *testing.T) {
+	testlog.SetupLogger()
+
+	utils.System.Hostname = func() (string, error) {
+		return "localhost.local", nil
+	}
+	defer func() {
+		utils.System.Hostname = os.Hostname
+	}()
+
+	progressLines := func(output string) []string {
+		var lines []string
+		for _, line := range strings.Split(output, "\n") {
+			if strings.HasPrefix(line, "Deleted ") {
+				lines = append(lines, line)
+			}
+		}
+
+		return lines
+	}
+
+	t.Run("reports progress every N directories counting those already deleted", func(t *testing.T) {
+		rootDir, directories := setupDirs(t, []string{"seg1", "seg2", "seg3"}, []string{"pg_file1"})
+		defer testutils.MustRemoveAll(t, rootDir)
+
+		// Directories removed by a previous run.
+		directories = append(directories, filepath.Join(rootDir, "seg4"), filepath.Join(rootDir, "seg5"))
+
+		var buf bytes.Buffer
+		err := upgrade.DeleteDirectoriesWithProgress(directories, []string{"pg_file1"}, testutils.DevNullSpy{OutStream: &buf},
+			upgrade.DeleteProgress{Every: 2})
+		if err != nil {
+			t.Errorf("unexpected error %+v", err)
+		}
+
+		expected := []string{
+			`Deleted 2/5 directories on host "localhost.local"`,
+			`Deleted 4/5 directories on host "localhost.local"`,
+			`Deleted 5/5 directories on host "localhost.local"`,
+		}
+
+		actual := progressLines(buf.String())
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("got progress %q want %q", actual, expected)
+		}
+	})
+
+	t.Run("does not count failed directories as deleted", func(t *testing.T) {
+		rootDir, directories := setupDirs(t, []string{"seg1", "seg2"}, []string{"pg_file1"})
+		defer testutils.MustRemoveAll(t, rootDir)
+
+		if err := os.Remove(filepath.Join(directories[1], "pg_file1")); err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+
+		var buf bytes.Buffer
+		err := upgrade.DeleteDirectoriesWithProgress(directories, []string{"pg_file1"}, testutils.DevNullSpy{OutStream: &buf},
+			upgrade.DeleteProgress{Every: 1})
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("got error %#v want %#v", err, os.ErrNotExist)
+		}
+
+		expected := []string{
+			`Deleted 1/2 directories on host "localhost.local"`,
+			`Deleted 1/2 directories on host "localhost.local"`,
+		}
+
+		actual := progressLines(buf.String())
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("got progress %q want %q", actual, expected)
+		}
+	})
+
+	t.Run("reports progress once the interval has passed", func(t *testing.T) {
+		rootDir, directories := setupDirs(t, []string{"seg1", "seg2", "seg3"}, []string{"pg_file1"})
+		defer testutils.MustRemoveAll(t, rootDir)
+
+		// Each call to Now advances the clock by a second.
+		now := time.Now()
+		utils.System.Now = func() time.Time {
+			now = now.Add(time.Second)
+			return now
+		}
+		defer func() {
+			utils.System.Now = time.Now
+		}()
+
+		var buf bytes.Buffer
+		err := upgrade.DeleteDirectoriesWithProgress(directories, []string{"pg_file1"}, testutils.DevNullSpy{OutStream: &buf},
+			upgrade.DeleteProgress{Interval: time.Second})
+		if err != nil {
+			t.Errorf("unexpected error %+v", err)
+		}
+
+		expected := []string{
+			`Deleted 1/3 directories on host "localhost.local"`,
+			`Deleted 2/3 directories on host "localhost.local"`,
+			`Deleted 3/3 directories on host "localhost.local"`,
+		}
+
+		actual := progressLines(buf.String())
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("got progress %q want %q", actual, expected)
+		}
+	})
+
+	t.Run("does not report progress when disabled", func(t *testing.T) {
+		rootDir, directories := setupDirs(t, []string{"seg1", "seg2"}, []string{"pg_file1"})
+		defer testutils.MustRemoveAll(t, rootDir)
+
+		var buf bytes.Buffer
+		err := upgrade.DeleteDirectoriesWithProgress(directories, []string{"pg_file1"}, testutils.DevNullSpy{OutStream: &buf},
+			upgrade.DeleteProgress{})
+		if err != nil {
+			t.Errorf("unexpected error %+v", err)
+		}
+
+		if lines := progressLines(buf.String()); len(lines) != 0 {
+			t.Errorf("got progress %q want none", lines)
+		}
+	})
+}
+
+func TestSummarizeDeleteResults(t *testing.T) {
+	cases := []struct {
+		name     string
+		results  []upgrade.DeleteResult
+		expected string
+	}{
+		{
+			name:     "no results",
+			expected: "0 deleted, 0 already removed, 0 failed",
+		},
+		{
+			name: "mixed results",
+			results: []upgrade.DeleteResult{
+				{Status: upgrade.DirectoryDeleted},
+				{Status: upgrade.DirectoryDeleted},
+				{Status: upgrade.DirectoryDeleted},
+				{Status: upgrade.DirectoryAlreadyDeleted},
+			},
+			expected: "3 deleted, 1 already removed, 0 failed",
+		},
+		{
+			name: "dry run results",
+			results: []upgrade.DeleteResult{
+				{Status: upgrade.DirectoryWouldBeDeleted},
+				{Status: upgrade.DirectoryDeleteFailed},
+			},
+			expected: "0 deleted, 0 already removed, 1 failed, 1 would be deleted",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual := upgrade.SummarizeDeleteResults(c.results)
+			if actual != c.expected {
+				t.Errorf("got %q want %q", actual, c.expected)
+			}
+		})
+	}
+}
+
+func TestTablespacePath(t *testing.T) {
+	t.Run("returns correct path", func(t *testing.T) {
+		path := upgrade.TablespacePath("/tmp/testfs/master/demoDataDir-1/16386", 1, 6, "301908232")
+		expected := "/tmp/testfs/master/demoDataDir-1/16386/1/GPDB_6_301908232"
+		if path != expected {
+			t.Errorf("got %q want %q", path, expected)
+		}
+	})
+}
+
+func TestRequiredPathsFor(t *testing.T) {
+	cases := []struct {
+		version  string
+		expected []string
+	}{
+		{"5.28.0", []string{"postgresql.conf", "PG_VERSION", "pg_xlog", "pg_clog"}},
+		{"6.20.0", []string{"postgresql.conf", "PG_VERSION", "pg_xlog", "pg_clog"}},
+		{"7.0.0", []string{"postgresql.conf", "PG_VERSION", "pg_wal", "pg_xact"}},
+		{"0.0.0", upgrade.PostgresFiles},
+	}
+
+	for _, c := range cases {
+		t.Run(c.version, func(t *testing.T) {
+			paths := upgrade.RequiredPathsFor(semver.MustParse(c.version))
+			if !reflect.DeepEqual(paths, c.expected) {
+				t.Errorf("got %q want %q", paths, c.expected)
+			}
+		})
+	}
+
+	t.Run("does not modify PostgresFiles", func(t *testing.T) {
+		expected := append([]string{}, upgrade.PostgresFiles...)
+		upgrade.RequiredPathsFor(semver.MustParse("7.0.0"))
+
+		if !reflect.DeepEqual(upgrade.PostgresFiles, expected) {
+			t.Errorf("got PostgresFiles %q want %q", upgrade.PostgresFiles, expected)
+		}
+	})
+}
+
+func TestEnsureEmptyTarget(t *testing.T) {
+	t.Run("succeeds for an empty directory", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		err := upgrade.EnsureEmptyTarget(dir)
+		if err != nil {
+			t.Errorf("unexpected error %#v", err)
+		}
+	})
+
+	t.Run("succeeds for a nonexistent directory", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		err := upgrade.EnsureEmptyTarget(filepath.Join(dir, "doesnotexist"))
+		if err != nil {
+			t.Errorf("unexpected error %#v", err)
+		}
+	})
+
+	t.Run("succeeds for a directory without postgres files", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		testutils.MustWriteToFile(t, filepath.Join(dir, "lost+found"), "")
+
+		err := upgrade.EnsureEmptyTarget(dir)
+		if err != nil {
+			t.Errorf("unexpected error %#v", err)
+		}
+	})
+
+	t.Run("errors for a directory that looks like a data directory", func(t *testing.T) {
+		_, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+
+		err := upgrade.EnsureEmptyTarget(target)
+
+		var notEmptyErr *upgrade.TargetNotEmptyError
+		if !errors.As(err, &notEmptyErr) {
+			t.Fatalf("got error %#v want type %T", err, notEmptyErr)
+		}
+
+		if !errors.Is(err, upgrade.ErrTargetNotEmpty) {
+			t.Errorf("got error %#v want %#v", err, upgrade.ErrTargetNotEmpty)
+		}
+
+		if !reflect.DeepEqual(notEmptyErr.Found, upgrade.PostgresFiles) {
+			t.Errorf("got found files %q want %q", notEmptyErr.Found, upgrade.PostgresFiles)
+		}
+	})
+
+	t.Run("errors when checking the directory fails", func(t *testing.T) {
+		expected := os.ErrPermission
+		utils.System.Stat = func(name string) (os.FileInfo, error) {
+			return nil, expected
+		}
+		defer func() {
+			utils.System = utils.InitializeSystemFunctions()
+		}()
+
+		err := upgrade.EnsureEmptyTarget("/data/target")
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+	})
+}
+
+func TestPathExist(t *testing.T) {
+	t.Run("path exists", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		doesExist, err := upgrade.PathExist(dir)
+		if err != nil {
+			t.Errorf("unexpected error %#v", err)
+		}
+
+		if !doesExist {
+			t.Errorf("expected path %q to exist", dir)
+		}
+	})
+
+	t.Run("path does not exists", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		path := filepath.Join(dir, "doesnotexist")
+		doesExist, err := upgrade.PathExist(path)
+		if err != nil {
+			t.Errorf("unexpected error %#v", err)
+		}
+
+		if doesExist {
+			t.Errorf("expected path %q to not exist", dir)
+		}
+	})
+
+	t.Run("returns error", func(t *testing.T) {
+		expected := os.ErrInvalid
+		utils.System.Stat = func(name string) (os.FileInfo, error) {
+			return nil, expected
+		}
+		defer func() {
+			utils.System = utils.InitializeSystemFunctions()
+		}()
+
+		doesExist, err := upgrade.PathExist("somepath")
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+
+		if doesExist {
+			t.Error("expected path to not exist")
+		}
+	})
+}
+
+func TestPathsExist(t *testing.T) {
+	t.Run("returns the missing paths", func(t *testing.T) {
 		dir := testutils.GetTempDir(t, "")
 		defer testutils.MustRemoveAll(t, dir)
 
-		doesExist, err := upgrade.PathExist(dir)
+		exists := filepath.Join(dir, "exists")
+		testutils.MustWriteToFile(t, exists, "")
+
+		missing1 := filepath.Join(dir, "missing1")
+		missing2 := filepath.Join(dir, "missing2")
+
+		missing, err := upgrade.PathsExist([]string{missing1, exists, missing2})
 		if err != nil {
 			t.Errorf("unexpected error %#v", err)
 		}
 
-		if !doesExist {
-			t.Errorf("expected path %q to exist", dir)
+		expected := []string{missing1, missing2}
+		if !reflect.DeepEqual(missing, expected) {
+			t.Errorf("got missing paths %q want %q", missing, expected)
 		}
 	})
 
-	t.Run("path does not exists", func(t *testing.T) {
+	t.Run("returns the unexpected stat errors along with the missing paths", func(t *testing.T) {
 		dir := testutils.GetTempDir(t, "")
 		defer testutils.MustRemoveAll(t, dir)
 
-		path := filepath.Join(dir, "doesnotexist")
-		doesExist, err := upgrade.PathExist(path)
-		if err != nil {
-			t.Errorf("unexpected error %#v", err)
-		}
+		exists := filepath.Join(dir, "exists")
+		testutils.MustWriteToFile(t, exists, "")
 
-		if doesExist {
-			t.Errorf("expected path %q to not exist", dir)
-		}
-	})
+		missingPath := filepath.Join(dir, "missing")
+		errPath := filepath.Join(dir, "error")
 
-	t.Run("returns error", func(t *testing.T) {
-		expected := os.ErrInvalid
+		expected := os.ErrPermission
 		utils.System.Stat = func(name string) (os.FileInfo, error) {
-			return nil, expected
+			if name == errPath {
+				return nil, expected
+			}
+
+			return os.Stat(name)
 		}
 		defer func() {
 			utils.System = utils.InitializeSystemFunctions()
 		}()
 
-		doesExist, err := upgrade.PathExist("somepath")
+		missing, err := upgrade.PathsExist([]string{exists, errPath, missingPath})
 		if !errors.Is(err, expected) {
 			t.Errorf("got error %#v want %#v", err, expected)
 		}
 
-		if doesExist {
-			t.Error("expected path to not exist")
+		if !reflect.DeepEqual(missing, []string{missingPath}) {
+			t.Errorf("got missing paths %q want %q", missing, []string{missingPath})
 		}
 	})
 }
@@ -545,6 +2367,67 @@ func TestDeleteNewTablespaceDirectories(t *testing.T) {
 		}
 	})
 
+	t.Run("logs the owner of each tablespace directory", func(t *testing.T) {
+		tablespaceDir, _, tsLocation := testutils.MustMakeTablespaceDir(t, 0)
+		defer testutils.MustRemoveAll(t, tsLocation)
+
+		info, err := os.Lstat(tablespaceDir)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		stat := info.Sys().(*syscall.Stat_t)
+
+		owner, err := user.LookupId(strconv.Itoa(int(stat.Uid)))
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		streams := new(step.BufferedStreams)
+		err = upgrade.DeleteNewTablespaceDirectories(streams, []string{tablespaceDir})
+		if err != nil {
+			t.Errorf("DeleteNewTablespaceDirectories returned error %+v", err)
+		}
+
+		expected := regexp.MustCompile(fmt.Sprintf(`Tablespace directory: %q owned by %s:\S+ \(%d:%d\)\n`,
+			tablespaceDir, regexp.QuoteMeta(owner.Username), stat.Uid, stat.Gid))
+		if !expected.MatchString(streams.StdoutBuf.String()) {
+			t.Errorf("expected stream output %q to match %q", streams.StdoutBuf.String(), expected)
+		}
+	})
+
+	t.Run("logs an unknown owner and still deletes when the directory cannot be statted", func(t *testing.T) {
+		tablespaceDir, dbIDDir, tsLocation := testutils.MustMakeTablespaceDir(t, 0)
+		defer testutils.MustRemoveAll(t, tsLocation)
+
+		utils.System.Lstat = func(name string) (os.FileInfo, error) {
+			if name == tablespaceDir {
+				return nil, os.ErrPermission
+			}
+
+			return os.Lstat(name)
+		}
+		defer func() {
+			utils.System.Lstat = os.Lstat
+		}()
+
+		streams := new(step.BufferedStreams)
+		err := upgrade.DeleteNewTablespaceDirectories(streams, []string{tablespaceDir})
+		if err != nil {
+			t.Errorf("DeleteNewTablespaceDirectories returned error %+v", err)
+		}
+
+		expected := fmt.Sprintf("Tablespace directory: %q owned by unknown\n", tablespaceDir)
+		if !strings.Contains(streams.StdoutBuf.String(), expected) {
+			t.Errorf("expected stream output %q to contain %q", streams.StdoutBuf.String(), expected)
+		}
+
+		for _, dir := range []string{tablespaceDir, dbIDDir} {
+			if upgrade.PathExists(dir) {
+				t.Errorf("expected directory %q to be deleted", dir)
+			}
+		}
+	})
+
 	t.Run("rerun of DeleteNewTablespaceDirectories after previous successful execution succeeds", func(t *testing.T) {
 		tablespaceDir, dbIdDir, tsLocation := testutils.MustMakeTablespaceDir(t, 0)
 		defer testutils.MustRemoveAll(t, tsLocation)
@@ -568,6 +2451,61 @@ func TestDeleteNewTablespaceDirectories(t *testing.T) {
 		}
 	})
 
+	t.Run("resumes from each partial state of a previous run", func(t *testing.T) {
+		cases := []struct {
+			name            string
+			tablespace      bool // whether the tablespace directory is present
+			parent          bool // whether the parent dbID directory is present
+			otherEntries    bool // whether the parent contains other entries
+			parentRemaining bool
+		}{
+			{name: "tablespace present, parent only contains it", tablespace: true, parent: true},
+			{name: "tablespace present, parent not empty", tablespace: true, parent: true, otherEntries: true, parentRemaining: true},
+			{name: "tablespace gone, parent still present but now empty", parent: true},
+			{name: "tablespace gone, parent not empty", parent: true, otherEntries: true, parentRemaining: true},
+			{name: "tablespace gone, parent gone"},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				tablespaceDir, dbIDDir, tsLocation := testutils.MustMakeTablespaceDir(t, 16392)
+				defer testutils.MustRemoveAll(t, tsLocation)
+
+				if c.otherEntries {
+					testutils.MustWriteToFile(t, filepath.Join(dbIDDir, "16389"), "")
+				}
+
+				if !c.tablespace {
+					testutils.MustRemoveAll(t, tablespaceDir)
+				}
+
+				if !c.parent {
+					testutils.MustRemoveAll(t, dbIDDir)
+				}
+
+				// Run twice to ensure the end state is idempotent.
+				for i := 0; i < 2; i++ {
+					err := upgrade.DeleteNewTablespaceDirectories(step.DevNullStream, []string{tablespaceDir})
+					if err != nil {
+						t.Errorf("run %d returned error %+v", i+1, err)
+					}
+				}
+
+				if upgrade.PathExists(tablespaceDir) {
+					t.Errorf("expected directory %q to be deleted", tablespaceDir)
+				}
+
+				if upgrade.PathExists(dbIDDir) != c.parentRemaining {
+					t.Errorf("got parent dbID directory %q exists %t want %t", dbIDDir, upgrade.PathExists(dbIDDir), c.parentRemaining)
+				}
+
+				if !upgrade.PathExists(tsLocation) {
+					t.Errorf("expected tablespace location %q to not be deleted", tsLocation)
+				}
+			})
+		}
+	})
+
 	t.Run("does not delete parent dbID directory when it's not empty", func(t *testing.T) {
 		tablespaceDir, dbIDDir, tsLocation := testutils.MustMakeTablespaceDir(t, 0)
 		defer testutils.MustRemoveAll(t, tsLocation)
@@ -589,6 +2527,89 @@ func TestDeleteNewTablespaceDirectories(t *testing.T) {
 		}
 	})
 
+	t.Run("retries reading the parent dbID directory after a transient error", func(t *testing.T) {
+		tablespaceDir, dbIDDir, tsLocation := testutils.MustMakeTablespaceDir(t, 0)
+		defer testutils.MustRemoveAll(t, tsLocation)
+
+		delay := upgrade.TablespaceRetryDelay
+		upgrade.TablespaceRetryDelay = time.Millisecond
+		defer func() { upgrade.TablespaceRetryDelay = delay }()
+
+		calls := 0
+		upgrade.SetReadDir(func(dir string) ([]os.FileInfo, error) {
+			calls++
+			if calls == 1 {
+				return nil, &os.PathError{Op: "open", Path: dir, Err: syscall.EAGAIN}
+			}
+
+			return ioutil.ReadDir(dir)
+		})
+		defer upgrade.ResetReadDir()
+
+		err := upgrade.DeleteNewTablespaceDirectories(step.DevNullStream, []string{tablespaceDir})
+		if err != nil {
+			t.Errorf("DeleteNewTablespaceDirectories returned error %+v", err)
+		}
+
+		if calls != 2 {
+			t.Errorf("got %d reads of the parent directory want 2", calls)
+		}
+
+		if upgrade.PathExists(dbIDDir) {
+			t.Errorf("expected parent dbID directory %q to be deleted", dbIDDir)
+		}
+	})
+
+	t.Run("gives up reading the parent dbID directory after repeated transient errors", func(t *testing.T) {
+		tablespaceDir, dbIDDir, tsLocation := testutils.MustMakeTablespaceDir(t, 0)
+		defer testutils.MustRemoveAll(t, tsLocation)
+
+		delay := upgrade.TablespaceRetryDelay
+		upgrade.TablespaceRetryDelay = time.Millisecond
+		defer func() { upgrade.TablespaceRetryDelay = delay }()
+
+		calls := 0
+		upgrade.SetReadDir(func(dir string) ([]os.FileInfo, error) {
+			calls++
+			return nil, &os.PathError{Op: "open", Path: dir, Err: syscall.EBUSY}
+		})
+		defer upgrade.ResetReadDir()
+
+		err := upgrade.DeleteNewTablespaceDirectories(step.DevNullStream, []string{tablespaceDir})
+		if !errors.Is(err, syscall.EBUSY) {
+			t.Errorf("got error %#v want %#v", err, syscall.EBUSY)
+		}
+
+		if calls != upgrade.TablespaceRetryAttempts {
+			t.Errorf("got %d reads of the parent directory want %d", calls, upgrade.TablespaceRetryAttempts)
+		}
+
+		if !upgrade.PathExists(dbIDDir) {
+			t.Errorf("expected parent dbID directory %q to not be deleted", dbIDDir)
+		}
+	})
+
+	t.Run("does not retry reading the parent dbID directory after a permission error", func(t *testing.T) {
+		tablespaceDir, _, tsLocation := testutils.MustMakeTablespaceDir(t, 0)
+		defer testutils.MustRemoveAll(t, tsLocation)
+
+		calls := 0
+		upgrade.SetReadDir(func(dir string) ([]os.FileInfo, error) {
+			calls++
+			return nil, &os.PathError{Op: "open", Path: dir, Err: syscall.EACCES}
+		})
+		defer upgrade.ResetReadDir()
+
+		err := upgrade.DeleteNewTablespaceDirectories(step.DevNullStream, []string{tablespaceDir})
+		if !errors.Is(err, os.ErrPermission) {
+			t.Errorf("got error %#v want %#v", err, os.ErrPermission)
+		}
+
+		if calls != 1 {
+			t.Errorf("got %d reads of the parent directory want 1", calls)
+		}
+	})
+
 	t.Run("deletes multiple tablespace directories including their parent dbID directory when empty", func(t *testing.T) {
 		type TablespaceDirs struct {
 			tablespaceDir string
@@ -622,6 +2643,71 @@ func TestDeleteNewTablespaceDirectories(t *testing.T) {
 		}
 	})
 
+	t.Run("deletes tablespace directories sharing a parent dbID directory in parallel", func(t *testing.T) {
+		workers := upgrade.TablespaceDeleteWorkers
+		upgrade.TablespaceDeleteWorkers = 4
+		defer func() {
+			upgrade.TablespaceDeleteWorkers = workers
+		}()
+
+		tablespaceDir, dbIDDir, tsLocation := testutils.MustMakeTablespaceDir(t, 16390)
+		defer testutils.MustRemoveAll(t, tsLocation)
+
+		tsDirs := []string{tablespaceDir}
+		for _, catalogVersion := range []string{"301908233", "301908234", "301908235", "301908236", "301908237"} {
+			dir := filepath.Join(dbIDDir, "GPDB_6_"+catalogVersion)
+			if err := os.Mkdir(dir, userRWX); err != nil {
+				t.Fatalf("creating tablespace directory: %v", err)
+			}
+
+			tsDirs = append(tsDirs, dir)
+		}
+
+		otherDir, otherDbIDDir, otherLocation := testutils.MustMakeTablespaceDir(t, 16391)
+		defer testutils.MustRemoveAll(t, otherLocation)
+		tsDirs = append(tsDirs, otherDir)
+
+		err := upgrade.DeleteNewTablespaceDirectories(step.DevNullStream, tsDirs)
+		if err != nil {
+			t.Errorf("DeleteNewTablespaceDirectories returned error %+v", err)
+		}
+
+		for _, dir := range tsDirs {
+			if upgrade.PathExists(dir) {
+				t.Errorf("expected directory %q to be deleted", dir)
+			}
+		}
+
+		for _, dir := range []string{dbIDDir, otherDbIDDir} {
+			if upgrade.PathExists(dir) {
+				t.Errorf("expected parent dbID directory %q to be deleted", dir)
+			}
+		}
+	})
+
+	t.Run("continues deleting other tablespace directories when one parent is not empty", func(t *testing.T) {
+		tablespaceDir, dbIDDir, tsLocation := testutils.MustMakeTablespaceDir(t, 16392)
+		defer testutils.MustRemoveAll(t, tsLocation)
+
+		testutils.MustWriteToFile(t, filepath.Join(dbIDDir, upgrade.PGVersion), "")
+
+		otherDir, otherDbIDDir, otherLocation := testutils.MustMakeTablespaceDir(t, 16393)
+		defer testutils.MustRemoveAll(t, otherLocation)
+
+		err := upgrade.DeleteNewTablespaceDirectories(step.DevNullStream, []string{tablespaceDir, otherDir})
+		if err != nil {
+			t.Errorf("DeleteNewTablespaceDirectories returned error %+v", err)
+		}
+
+		if !upgrade.PathExists(dbIDDir) {
+			t.Errorf("expected parent dbID directory %q to not be deleted", dbIDDir)
+		}
+
+		if upgrade.PathExists(otherDbIDDir) {
+			t.Errorf("expected parent dbID directory %q to be deleted", otherDbIDDir)
+		}
+	})
+
 	t.Run("errors when tablespace directory is invalid", func(t *testing.T) {
 		tablespaceDir, _, tsLocation := testutils.MustMakeTablespaceDir(t, 0)
 		defer testutils.MustRemoveAll(t, tsLocation)
@@ -649,22 +2735,16 @@ func TestDeleteNewTablespaceDirectories(t *testing.T) {
 
 	t.Run("errors when tablespace directory can't be deleted", func(t *testing.T) {
 		tablespaceDir, dbIDDir, tsLocation := testutils.MustMakeTablespaceDir(t, 0)
-		defer func() {
-			err := os.Chmod(dbIDDir, userRWX)
-			if err != nil {
-				t.Fatalf("making parent dbId directory writeable: %v", err)
-			}
-			testutils.MustRemoveAll(t, tsLocation)
-		}()
+		defer testutils.MustRemoveAll(t, tsLocation)
 
-		// Set parent dbID directory to read only so its children cannot be
-		// removed.
-		err := os.Chmod(dbIDDir, 0500)
-		if err != nil {
-			t.Fatalf("making parent dbID directory read only: %v", err)
+		utils.System.RemoveAll = func(path string) error {
+			return &os.PathError{Op: "unlinkat", Path: path, Err: os.ErrPermission}
 		}
+		defer func() {
+			utils.System.RemoveAll = os.RemoveAll
+		}()
 
-		err = upgrade.DeleteNewTablespaceDirectories(step.DevNullStream, []string{tablespaceDir})
+		err := upgrade.DeleteNewTablespaceDirectories(step.DevNullStream, []string{tablespaceDir})
 
 		if !errors.Is(err, os.ErrPermission) {
 			t.Errorf("got error %#v want %#v", err, os.ErrPermission)
@@ -712,22 +2792,16 @@ func TestDeleteNewTablespaceDirectories(t *testing.T) {
 
 	t.Run("errors when failing to remove parent dbID directory", func(t *testing.T) {
 		tablespaceDir, dbIDDir, tsLocation := testutils.MustMakeTablespaceDir(t, 0)
-		defer func() {
-			err := os.Chmod(tsLocation, userRWX)
-			if err != nil {
-				t.Fatalf("making tablespace location writeable: %v", err)
-			}
-			testutils.MustRemoveAll(t, tsLocation)
-		}()
+		defer testutils.MustRemoveAll(t, tsLocation)
 
-		// Set tablespace location to read and execute to allow its children
-		// to be removed.
-		err := os.Chmod(tsLocation, 0500)
-		if err != nil {
-			t.Fatalf("making tablespace location directory read only: %v", err)
+		utils.System.Remove = func(name string) error {
+			return &os.PathError{Op: "remove", Path: name, Err: os.ErrPermission}
 		}
+		defer func() {
+			utils.System.Remove = os.Remove
+		}()
 
-		err = upgrade.DeleteNewTablespaceDirectories(step.DevNullStream, []string{tablespaceDir})
+		err := upgrade.DeleteNewTablespaceDirectories(step.DevNullStream, []string{tablespaceDir})
 		if !errors.Is(err, os.ErrPermission) {
 			t.Errorf("got error %#v want %#v", err, os.ErrPermission)
 		}
@@ -780,6 +2854,81 @@ func TestDeleteNewTablespaceDirectories(t *testing.T) {
 	})
 }
 
+func TestVerifyTablespaceDirectories(t *testing.T) {
+	t.Run("succeeds for a valid 6X tablespace location", func(t *testing.T) {
+		_, _, tsLocation := testutils.MustMakeTablespaceDir(t, 16394)
+		defer testutils.MustRemoveAll(t, tsLocation)
+
+		err := upgrade.VerifyTablespaceDirectories([]string{tsLocation}, semver.MustParse("6.20.0"))
+		if err != nil {
+			t.Errorf("VerifyTablespaceDirectories returned error %+v", err)
+		}
+	})
+
+	t.Run("errors when a 6X dbID directory does not contain a GPDB directory", func(t *testing.T) {
+		tablespaceDir, _, tsLocation := testutils.MustMakeTablespaceDir(t, 16395)
+		defer testutils.MustRemoveAll(t, tsLocation)
+
+		testutils.MustRemoveAll(t, tablespaceDir)
+
+		err := upgrade.VerifyTablespaceDirectories([]string{tsLocation}, semver.MustParse("6.20.0"))
+		if !errors.Is(err, upgrade.ErrInvalidTablespaceDirectory) {
+			t.Errorf("got error %#v want %#v", err, upgrade.ErrInvalidTablespaceDirectory)
+		}
+	})
+
+	t.Run("errors when the 6X GPDB directory is for a different major version", func(t *testing.T) {
+		_, _, tsLocation := testutils.MustMakeTablespaceDir(t, 16396)
+		defer testutils.MustRemoveAll(t, tsLocation)
+
+		err := upgrade.VerifyTablespaceDirectories([]string{tsLocation}, semver.MustParse("7.0.0"))
+		if !errors.Is(err, upgrade.ErrInvalidTablespaceDirectory) {
+			t.Errorf("got error %#v want %#v", err, upgrade.ErrInvalidTablespaceDirectory)
+		}
+	})
+
+	t.Run("uses the 5X layout for 5X sources", func(t *testing.T) {
+		_, tsLocation := testutils.MustMake5XTablespaceDir(t, 16397)
+		defer testutils.MustRemoveAll(t, tsLocation)
+
+		err := upgrade.VerifyTablespaceDirectories([]string{tsLocation}, semver.MustParse("5.28.0"))
+		if err != nil {
+			t.Errorf("VerifyTablespaceDirectories returned error %+v", err)
+		}
+
+		err = upgrade.VerifyTablespaceDirectories([]string{tsLocation}, semver.MustParse("6.20.0"))
+		if !errors.Is(err, upgrade.ErrInvalidTablespaceDirectory) {
+			t.Errorf("got error %#v want %#v", err, upgrade.ErrInvalidTablespaceDirectory)
+		}
+	})
+
+	t.Run("stops at an unreadable location for both 5X and 6X sources", func(t *testing.T) {
+		for _, version := range []string{"5.28.0", "6.20.0"} {
+			_, _, tsLocation := testutils.MustMakeTablespaceDir(t, 16398)
+			defer testutils.MustRemoveAll(t, tsLocation)
+
+			nonexistent := filepath.Join(tsLocation, "does-not-exist")
+
+			err := upgrade.VerifyTablespaceDirectories([]string{nonexistent, tsLocation}, semver.MustParse(version))
+			if !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("%s: got error %#v want %#v", version, err, os.ErrNotExist)
+			}
+
+			var errs errorlist.Errors
+			if errors.As(err, &errs) {
+				t.Errorf("%s: expected a single error, got %v", version, errs)
+			}
+		}
+	})
+
+	t.Run("errors for an unsupported source version", func(t *testing.T) {
+		err := upgrade.VerifyTablespaceDirectories([]string{}, semver.MustParse("4.3.0"))
+		if err == nil {
+			t.Errorf("expected an error")
+		}
+	})
+}
+
 func TestVerify5XTablespaceDirectories(t *testing.T) {
 	t.Run("succeeds when given multiple 5X tablespace locations", func(t *testing.T) {
 		var dirs []string
@@ -849,6 +2998,56 @@ func TestVerify5XTablespaceDirectories(t *testing.T) {
 	})
 }
 
+func TestVerifyAll5XTablespaceDirectories(t *testing.T) {
+	t.Run("reports every invalid tablespace directory", func(t *testing.T) {
+		valid, validLocation := testutils.MustMake5XTablespaceDir(t, 16386)
+		defer testutils.MustRemoveAll(t, validLocation)
+
+		missing, missingLocation := testutils.MustMake5XTablespaceDir(t, 16387)
+		defer testutils.MustRemoveAll(t, missingLocation)
+
+		if err := os.Remove(filepath.Join(missing, upgrade.PGVersion)); err != nil {
+			t.Fatalf("removing PG_VERSION from %q: %v", missing, err)
+		}
+
+		nonexistent := filepath.Join(validLocation, "does-not-exist")
+		dirs := []string{nonexistent, validLocation, missingLocation}
+
+		err := upgrade.VerifyAll5XTablespaceDirectories(dirs)
+
+		var errs errorlist.Errors
+		if !errors.As(err, &errs) {
+			t.Fatalf("got error %#v want type %T", err, errs)
+		}
+
+		if len(errs) != 2 {
+			t.Fatalf("got %d errors want 2: %v", len(errs), errs)
+		}
+
+		if !errors.Is(errs[0], os.ErrNotExist) || !strings.Contains(errs[0].Error(), nonexistent) {
+			t.Errorf("got error %#v want %#v naming %q", errs[0], os.ErrNotExist, nonexistent)
+		}
+
+		if !errors.Is(errs[1], upgrade.ErrInvalidTablespaceDirectory) || !strings.Contains(errs[1].Error(), missing) {
+			t.Errorf("got error %#v want %#v naming %q", errs[1], upgrade.ErrInvalidTablespaceDirectory, missing)
+		}
+
+		if strings.Contains(err.Error(), valid) {
+			t.Errorf("expected error %q to not name the valid directory %q", err.Error(), valid)
+		}
+
+		// Verify5XTablespaceDirectories stops at a location it cannot read.
+		err = upgrade.Verify5XTablespaceDirectories(dirs)
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("got error %#v want %#v", err, os.ErrNotExist)
+		}
+
+		if strings.Contains(err.Error(), missing) {
+			t.Errorf("expected error %q to not name %q", err.Error(), missing)
+		}
+	})
+}
+
 func setupDirs(t *testing.T, subdirectories []string, requiredPaths []string) (tmpDir string, createdDirectories []string) {
 	var err error
 	tmpDir, err = ioutil.TempDir("", "")
@@ -878,3 +3077,32 @@ func createDataDir(t *testing.T, name, tmpDir string, requiredPaths []string) (d
 
 	return dirPath
 }
+
+// crossDeviceRename returns a rename function that fails with EXDEV when
+// renaming src to dst, as happens when they are on different filesystems.
+func crossDeviceRename(src, dst string) func(string, string) error {
+	return func(old, new string) error {
+		if old == src && new == dst {
+			return &os.LinkError{Op: "rename", Old: old, New: new, Err: syscall.EXDEV}
+		}
+
+		return os.Rename(old, new)
+	}
+}
+
+func verifyRestore(t *testing.T, source, target string) {
+	t.Helper()
+
+	if !upgrade.PathExists(source) {
+		t.Errorf("expected source %q to exist", source)
+	}
+
+	if !upgrade.PathExists(target) {
+		t.Errorf("expected target %q to exist", target)
+	}
+
+	archive := upgrade.ArchivePathFor(target)
+	if upgrade.PathExists(archive) {
+		t.Errorf("expected archive %q to not exist", archive)
+	}
+}
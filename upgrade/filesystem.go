@@ -0,0 +1,45 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade
+
+import (
+	"os"
+
+	"github.com/greenplum-db/gpupgrade/utils"
+)
+
+// FileSystem is the set of filesystem operations the upgrade package depends
+// on. Functions taking a FileSystem can be unit tested against an in-memory
+// implementation rather than real temporary directories. Functions without
+// one use OSFileSystem.
+//
+// Moving a directory across filesystems is done by copying, which always goes
+// through the operating system. Implementations other than OSFileSystem
+// should therefore not return EXDEV from Rename.
+type FileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+	Rename(oldpath, newpath string) error
+	RemoveAll(path string) error
+	MkdirAll(path string, perm os.FileMode) error
+}
+
+// OSFileSystem is the FileSystem of the operating system. It goes through
+// utils.System so that existing mocks continue to apply.
+type OSFileSystem struct{}
+
+func (OSFileSystem) Stat(name string) (os.FileInfo, error) {
+	return utils.System.Stat(name)
+}
+
+func (OSFileSystem) Rename(oldpath, newpath string) error {
+	return utils.System.Rename(oldpath, newpath)
+}
+
+func (OSFileSystem) RemoveAll(path string) error {
+	return utils.System.RemoveAll(path)
+}
+
+func (OSFileSystem) MkdirAll(path string, perm os.FileMode) error {
+	return utils.System.MkdirAll(path, perm)
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/greenplum-db/gpupgrade/testutils/memfs"
+	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+	"github.com/greenplum-db/gpupgrade/upgrade"
+)
+
+var _ upgrade.FileSystem = upgrade.OSFileSystem{}
+var _ upgrade.FileSystem = (*memfs.FS)(nil)
+
+// errFS fails every Stat with err.
+type errFS struct {
+	*memfs.FS
+	err error
+}
+
+func (e errFS) Stat(string) (os.FileInfo, error) {
+	return nil, e.err
+}
+
+func TestPathExistFS(t *testing.T) {
+	t.Run("reports whether the path exists", func(t *testing.T) {
+		fs := memfs.New()
+		mustMkdirAll(t, fs, "/data/dir")
+
+		exist, err := upgrade.PathExistFS(fs, "/data/dir")
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if !exist {
+			t.Errorf("expected %q to exist", "/data/dir")
+		}
+
+		exist, err = upgrade.PathExistFS(fs, "/data/missing")
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if exist {
+			t.Errorf("expected %q to not exist", "/data/missing")
+		}
+	})
+
+	t.Run("errors when failing to stat", func(t *testing.T) {
+		expected := os.ErrPermission
+		fs := errFS{FS: memfs.New(), err: expected}
+
+		exist, err := upgrade.PathExistFS(fs, "/data/dir")
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+
+		if exist {
+			t.Errorf("expected %q to not exist", "/data/dir")
+		}
+	})
+}
+
+func TestArchiveSourceFS(t *testing.T) {
+	testlog.SetupLogger()
+
+	source := "/data/qddir/demoDataDir-1"
+	target := "/data/qddir/demoDataDir.AAAAAAAAAAA.-1"
+	archive := upgrade.ArchivePathFor(target)
+
+	t.Run("archives the source and renames the target", func(t *testing.T) {
+		fs := memfs.New()
+		mustCreateDataDir(t, fs, source)
+		mustCreateDataDir(t, fs, target)
+		mustWriteFile(t, fs, filepath.Join(source, "source_file"))
+
+		status, err := upgrade.ArchiveSourceFS(fs, source, target, true)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if status != upgrade.Archived {
+			t.Errorf("got status %q want %q", status, upgrade.Archived)
+		}
+
+		expected := []string{
+			"/data",
+			"/data/qddir",
+			source,
+			filepath.Join(source, upgrade.PGVersion),
+			filepath.Join(source, "postgresql.conf"),
+			archive,
+			filepath.Join(archive, upgrade.PGVersion),
+			filepath.Join(archive, "postgresql.conf"),
+			filepath.Join(archive, "source_file"),
+		}
+		if !reflect.DeepEqual(fs.Paths(), expected) {
+			t.Errorf("got paths %q want %q", fs.Paths(), expected)
+		}
+	})
+
+	t.Run("only archives the source when not renaming the target", func(t *testing.T) {
+		fs := memfs.New()
+		mustCreateDataDir(t, fs, source)
+
+		status, err := upgrade.ArchiveSourceFS(fs, source, target, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if status != upgrade.Archived {
+			t.Errorf("got status %q want %q", status, upgrade.Archived)
+		}
+
+		if exist, _ := upgrade.PathExistFS(fs, source); exist {
+			t.Errorf("expected source %q to be archived", source)
+		}
+
+		if exist, _ := upgrade.PathExistFS(fs, archive); !exist {
+			t.Errorf("expected archive %q to exist", archive)
+		}
+	})
+
+	t.Run("reports when a previous run already archived", func(t *testing.T) {
+		fs := memfs.New()
+		mustCreateDataDir(t, fs, source)
+		mustCreateDataDir(t, fs, archive)
+
+		status, err := upgrade.ArchiveSourceFS(fs, source, target, true)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if status != upgrade.AlreadyArchived {
+			t.Errorf("got status %q want %q", status, upgrade.AlreadyArchived)
+		}
+	})
+
+	t.Run("renames the target when a previous run only archived the source", func(t *testing.T) {
+		fs := memfs.New()
+		mustCreateDataDir(t, fs, archive)
+		mustCreateDataDir(t, fs, target)
+
+		status, err := upgrade.ArchiveSourceFS(fs, source, target, true)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if status != upgrade.SourceMissing {
+			t.Errorf("got status %q want %q", status, upgrade.SourceMissing)
+		}
+
+		if exist, _ := upgrade.PathExistFS(fs, target); exist {
+			t.Errorf("expected target %q to be renamed", target)
+		}
+
+		if exist, _ := upgrade.PathExistFS(fs, source); !exist {
+			t.Errorf("expected source %q to exist", source)
+		}
+	})
+
+	t.Run("does not archive the source when the target is not a data directory", func(t *testing.T) {
+		fs := memfs.New()
+		mustCreateDataDir(t, fs, source)
+		mustMkdirAll(t, fs, target)
+
+		status, err := upgrade.ArchiveSourceFS(fs, source, target, true)
+		if !errors.Is(err, upgrade.ErrInvalidDataDirectory) {
+			t.Errorf("got error %#v want %#v", err, upgrade.ErrInvalidDataDirectory)
+		}
+
+		if status != upgrade.ArchiveUnknown {
+			t.Errorf("got status %q want %q", status, upgrade.ArchiveUnknown)
+		}
+
+		if exist, _ := upgrade.PathExistFS(fs, source); !exist {
+			t.Errorf("expected source %q to not be archived", source)
+		}
+	})
+}
+
+func mustMkdirAll(t *testing.T, fs *memfs.FS, path string) {
+	t.Helper()
+
+	if err := fs.MkdirAll(path, 0700); err != nil {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+}
+
+func mustWriteFile(t *testing.T, fs *memfs.FS, path string) {
+	t.Helper()
+
+	if err := fs.WriteFile(path, nil, 0600); err != nil {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+}
+
+func mustCreateDataDir(t *testing.T, fs *memfs.FS, dir string) {
+	t.Helper()
+
+	mustMkdirAll(t, fs, dir)
+	for _, file := range upgrade.PostgresFiles {
+		mustWriteFile(t, fs, filepath.Join(dir, file))
+	}
+}
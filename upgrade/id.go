@@ -7,8 +7,15 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"golang.org/x/xerrors"
 )
 
 // ID is a unique identifier for a cluster upgrade.
@@ -41,6 +48,61 @@ func NewID() ID {
 	}
 }
 
+// newID allows tests to control the IDs generated by NewUniqueID.
+var newID = NewID
+
+// maxUniqueIDAttempts is the number of IDs NewUniqueID generates before giving
+// up. A single collision is already unlikely, so repeated collisions indicate
+// something other than chance.
+const maxUniqueIDAttempts = 10
+
+// NewUniqueID creates a new ID that does not appear in the name of any entry
+// alongside the given data directories. Temporary data directories and
+// archives are created next to their data directories and embed the ID, so
+// this prevents an upgrade from confusing state left behind by a prior run.
+func NewUniqueID(dataDirs []string) (ID, error) {
+	for i := 0; i < maxUniqueIDAttempts; i++ {
+		id := newID()
+
+		collision, err := findID(id, dataDirs)
+		if err != nil {
+			return 0, err
+		}
+
+		if collision == "" {
+			return id, nil
+		}
+
+		gplog.Debug("upgrade ID %s is already used by %q. Generating a new ID.", id, collision)
+	}
+
+	return 0, xerrors.Errorf("failed to generate an upgrade ID not already used alongside data directories %q after %d attempts. "+
+		"Remove any temporary or archived data directories left over from previous upgrades.", dataDirs, maxUniqueIDAttempts)
+}
+
+// findID returns the path of the first entry alongside the data directories
+// whose name contains the ID, or an empty string if there is none.
+func findID(id ID, dataDirs []string) (string, error) {
+	for _, dataDir := range dataDirs {
+		parent := filepath.Dir(filepath.Clean(dataDir))
+
+		entries, err := ioutil.ReadDir(parent)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return "", xerrors.Errorf("checking for existing upgrade ID %s: %w", id, err)
+		}
+
+		for _, entry := range entries {
+			if strings.Contains(entry.Name(), id.String()) {
+				return filepath.Join(parent, entry.Name()), nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
 // String returns an unpadded, filesystem-safe base64 encoding of the
 // identifier.
 func (id ID) String() string {
@@ -51,3 +113,37 @@ func (id ID) String() string {
 	// filesystem-safe character set.
 	return base64.RawURLEncoding.EncodeToString(bytes[:])
 }
+
+// ErrInvalidID is returned by ParseID when the input is not the String form of
+// an ID.
+var ErrInvalidID = errors.New("invalid upgrade ID")
+
+// InvalidIDError is the backing error type for ErrInvalidID.
+type InvalidIDError struct {
+	input  string
+	reason string
+}
+
+func (i *InvalidIDError) Error() string {
+	return fmt.Sprintf("invalid upgrade ID %q: %s", i.input, i.reason)
+}
+
+func (i *InvalidIDError) Is(err error) bool {
+	return err == ErrInvalidID
+}
+
+// ParseID reverses ID.String, returning the ID encoded by s.
+func ParseID(s string) (ID, error) {
+	// Strict decoding rejects non-canonical encodings so that only the exact
+	// output of String is accepted.
+	bytes, err := base64.RawURLEncoding.Strict().DecodeString(s)
+	if err != nil {
+		return 0, &InvalidIDError{input: s, reason: err.Error()}
+	}
+
+	if len(bytes) != 8 {
+		return 0, &InvalidIDError{input: s, reason: fmt.Sprintf("decoded to %d bytes, want 8", len(bytes))}
+	}
+
+	return ID(binary.LittleEndian.Uint64(bytes)), nil
+}
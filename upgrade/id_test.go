@@ -4,12 +4,18 @@
 package upgrade_test
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"math/rand"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/testutils/testlog"
 	"github.com/greenplum-db/gpupgrade/upgrade"
 )
 
@@ -33,6 +39,154 @@ func TestID(t *testing.T) {
 	})
 }
 
+func TestParseID(t *testing.T) {
+	t.Run("round trips the String form of an ID", func(t *testing.T) {
+		ids := []upgrade.ID{0, 1, math.MaxUint64}
+		for i := 0; i < 10000; i++ {
+			ids = append(ids, upgrade.ID(rand.Uint64()))
+		}
+
+		for _, id := range ids {
+			parsed, err := upgrade.ParseID(id.String())
+			if err != nil {
+				t.Fatalf("ParseID(%q) returned error %+v", id.String(), err)
+			}
+
+			if parsed != id {
+				t.Fatalf("ParseID(%q) returned %d, want %d", id.String(), parsed, id)
+			}
+		}
+	})
+
+	errCases := []struct {
+		name  string
+		input string
+	}{
+		{name: "empty", input: ""},
+		{name: "too short", input: "AAAAAAAAAA"},
+		{name: "too long", input: "AAAAAAAAAAAA"},
+		{name: "invalid characters", input: "AAAAAAAAA+/"},
+		{name: "padded", input: "AAAAAAAAAAA="},
+		{name: "non-canonical trailing bits", input: "AAAAAAAAAAB"},
+	}
+
+	for _, c := range errCases {
+		t.Run(fmt.Sprintf("errors when input is %s", c.name), func(t *testing.T) {
+			_, err := upgrade.ParseID(c.input)
+
+			var invalidErr *upgrade.InvalidIDError
+			if !errors.As(err, &invalidErr) {
+				t.Errorf("got error %#v want type %T", err, invalidErr)
+			}
+
+			if !errors.Is(err, upgrade.ErrInvalidID) {
+				t.Errorf("got error %#v want %#v", err, upgrade.ErrInvalidID)
+			}
+		})
+	}
+}
+
+func TestNewUniqueID(t *testing.T) {
+	testlog.SetupLogger()
+
+	// sequence returns an ID generator yielding the given IDs in order.
+	sequence := func(ids ...upgrade.ID) func() upgrade.ID {
+		return func() upgrade.ID {
+			id := ids[0]
+			ids = ids[1:]
+			return id
+		}
+	}
+
+	t.Run("returns an ID not used alongside the data directories", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		dataDir := filepath.Join(dir, "demoDataDir0")
+
+		upgrade.SetNewID(sequence(1))
+		defer upgrade.ResetNewID()
+
+		id, err := upgrade.NewUniqueID([]string{dataDir})
+		if err != nil {
+			t.Errorf("unexpected error %+v", err)
+		}
+
+		if id != 1 {
+			t.Errorf("got ID %d want %d", id, 1)
+		}
+	})
+
+	t.Run("regenerates the ID when a temporary or archive directory uses it", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		dataDir := filepath.Join(dir, "demoDataDir0")
+
+		var temp, archive upgrade.ID = 1, 2
+		for _, path := range []string{
+			upgrade.TempDataDir(dataDir, "demoDataDir", temp),
+			upgrade.ArchivePathFor(upgrade.TempDataDir(dataDir, "demoDataDir", archive)),
+		} {
+			if err := os.Mkdir(path, 0700); err != nil {
+				t.Fatalf("unexpected error %+v", err)
+			}
+		}
+
+		upgrade.SetNewID(sequence(temp, archive, 3))
+		defer upgrade.ResetNewID()
+
+		id, err := upgrade.NewUniqueID([]string{dataDir})
+		if err != nil {
+			t.Errorf("unexpected error %+v", err)
+		}
+
+		if id != 3 {
+			t.Errorf("got ID %d want %d", id, 3)
+		}
+	})
+
+	t.Run("errors when every generated ID collides", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		dataDir := filepath.Join(dir, "demoDataDir0")
+
+		var id upgrade.ID = 1
+		if err := os.Mkdir(upgrade.TempDataDir(dataDir, "demoDataDir", id), 0700); err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+
+		upgrade.SetNewID(func() upgrade.ID { return id })
+		defer upgrade.ResetNewID()
+
+		_, err := upgrade.NewUniqueID([]string{dataDir})
+		if err == nil {
+			t.Errorf("expected an error")
+		}
+	})
+
+	t.Run("errors when the data directory parent cannot be read", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		// Allow access to children, but not listing the directory contents.
+		if err := os.Chmod(dir, 0300); err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		defer func() {
+			if err := os.Chmod(dir, 0700); err != nil {
+				t.Fatalf("unexpected error %+v", err)
+			}
+		}()
+
+		_, err := upgrade.NewUniqueID([]string{filepath.Join(dir, "demoDataDir0")})
+		if !errors.Is(err, os.ErrPermission) {
+			t.Errorf("got error %#v want %#v", err, os.ErrPermission)
+		}
+	})
+}
+
 // TestNewIDCrossProcess ensures that NewID returns different results across
 // invocations of an executable (i.e. that the ID source is seeded correctly).
 func TestNewIDCrossProcess(t *testing.T) {
@@ -0,0 +1,132 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/greenplum-db/gpupgrade/utils/errorlist"
+)
+
+// ClusterConfig is the segment layout of a cluster, as compared by
+// ReconcileClusterLayout.
+type ClusterConfig struct {
+	Segments []SegmentConfig
+}
+
+// SegmentConfig identifies a segment by its content ID and its role, which is
+// "p" for primaries and "m" for mirrors as in gp_segment_configuration.
+type SegmentConfig struct {
+	ContentID int
+	Role      string
+}
+
+// ErrLayoutMismatch is returned by ReconcileClusterLayout when the target
+// cluster's segments do not match the source cluster's.
+var ErrLayoutMismatch = errors.New("cluster layouts do not match")
+
+// LayoutMismatchError is the backing error type for ErrLayoutMismatch. It
+// describes a single content ID and role for which the number of segments
+// differs between the clusters.
+type LayoutMismatchError struct {
+	ContentID int
+	Role      string
+	Source    int
+	Target    int
+}
+
+func (l *LayoutMismatchError) Error() string {
+	return fmt.Sprintf("content %d has %d %s segment(s) in the source cluster but %d in the target cluster",
+		l.ContentID, l.Source, roleName(l.Role), l.Target)
+}
+
+func (l *LayoutMismatchError) Is(err error) bool {
+	return err == ErrLayoutMismatch
+}
+
+// SegmentCountError is returned within the errors of ReconcileClusterLayout
+// when the clusters have a different number of segments. It matches
+// ErrLayoutMismatch.
+type SegmentCountError struct {
+	Source int
+	Target int
+}
+
+func (s *SegmentCountError) Error() string {
+	return fmt.Sprintf("source cluster has %d segments but target cluster has %d", s.Source, s.Target)
+}
+
+func (s *SegmentCountError) Is(err error) bool {
+	return err == ErrLayoutMismatch
+}
+
+// ReconcileClusterLayout ensures that the target cluster has the same number
+// of segments as the source, with the same content IDs and roles, so that no
+// segment such as a mirror was lost during the upgrade. Every discrepancy is
+// returned in an errorlist.Errors, ordered by content ID and role.
+func ReconcileClusterLayout(source, target ClusterConfig) error {
+	var errs errorlist.Errors
+	if len(source.Segments) != len(target.Segments) {
+		errs = append(errs, &SegmentCountError{Source: len(source.Segments), Target: len(target.Segments)})
+	}
+
+	sourceCounts := source.countSegments()
+	targetCounts := target.countSegments()
+
+	var keys []SegmentConfig
+	for key := range sourceCounts {
+		keys = append(keys, key)
+	}
+	for key := range targetCounts {
+		if _, ok := sourceCounts[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].ContentID != keys[j].ContentID {
+			return keys[i].ContentID < keys[j].ContentID
+		}
+		return keys[i].Role > keys[j].Role // primaries before mirrors
+	})
+
+	for _, key := range keys {
+		if sourceCounts[key] != targetCounts[key] {
+			errs = append(errs, &LayoutMismatchError{
+				ContentID: key.ContentID,
+				Role:      key.Role,
+				Source:    sourceCounts[key],
+				Target:    targetCounts[key],
+			})
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+func (c ClusterConfig) countSegments() map[SegmentConfig]int {
+	counts := make(map[SegmentConfig]int)
+	for _, seg := range c.Segments {
+		counts[seg]++
+	}
+
+	return counts
+}
+
+func roleName(role string) string {
+	switch role {
+	case "p":
+		return "primary"
+	case "m":
+		return "mirror"
+	default:
+		return fmt.Sprintf("%q role", role)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/greenplum-db/gpupgrade/upgrade"
+	"github.com/greenplum-db/gpupgrade/utils/errorlist"
+)
+
+func TestReconcileClusterLayout(t *testing.T) {
+	source := upgrade.ClusterConfig{Segments: []upgrade.SegmentConfig{
+		{ContentID: -1, Role: "p"},
+		{ContentID: -1, Role: "m"},
+		{ContentID: 0, Role: "p"},
+		{ContentID: 0, Role: "m"},
+		{ContentID: 1, Role: "p"},
+		{ContentID: 1, Role: "m"},
+	}}
+
+	t.Run("succeeds when the layouts match regardless of order", func(t *testing.T) {
+		target := upgrade.ClusterConfig{Segments: []upgrade.SegmentConfig{
+			{ContentID: 1, Role: "m"},
+			{ContentID: 0, Role: "m"},
+			{ContentID: -1, Role: "m"},
+			{ContentID: 1, Role: "p"},
+			{ContentID: 0, Role: "p"},
+			{ContentID: -1, Role: "p"},
+		}}
+
+		err := upgrade.ReconcileClusterLayout(source, target)
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+	})
+
+	t.Run("returns every discrepancy", func(t *testing.T) {
+		target := upgrade.ClusterConfig{Segments: []upgrade.SegmentConfig{
+			{ContentID: -1, Role: "p"},
+			{ContentID: -1, Role: "m"},
+			{ContentID: 0, Role: "p"},
+			{ContentID: 1, Role: "p"},
+			{ContentID: 1, Role: "m"},
+			{ContentID: 1, Role: "m"},
+			{ContentID: 2, Role: "p"},
+		}}
+
+		err := upgrade.ReconcileClusterLayout(source, target)
+
+		var errs errorlist.Errors
+		if !errors.As(err, &errs) {
+			t.Fatalf("got error %#v want type %T", err, errs)
+		}
+
+		expected := errorlist.Errors{
+			&upgrade.SegmentCountError{Source: 6, Target: 7},
+			&upgrade.LayoutMismatchError{ContentID: 0, Role: "m", Source: 1, Target: 0},
+			&upgrade.LayoutMismatchError{ContentID: 1, Role: "m", Source: 1, Target: 2},
+			&upgrade.LayoutMismatchError{ContentID: 2, Role: "p", Source: 0, Target: 1},
+		}
+		if !reflect.DeepEqual(errs, expected) {
+			t.Errorf("got errors %v want %v", errs, expected)
+		}
+
+		for _, err := range errs {
+			if !errors.Is(err, upgrade.ErrLayoutMismatch) {
+				t.Errorf("expected error %#v to match %v", err, upgrade.ErrLayoutMismatch)
+			}
+		}
+	})
+
+	t.Run("detects a target that came up without a mirror", func(t *testing.T) {
+		target := upgrade.ClusterConfig{Segments: source.Segments[:len(source.Segments)-1]}
+
+		err := upgrade.ReconcileClusterLayout(source, target)
+
+		var errs errorlist.Errors
+		if !errors.As(err, &errs) {
+			t.Fatalf("got error %#v want type %T", err, errs)
+		}
+
+		expected := errorlist.Errors{
+			&upgrade.SegmentCountError{Source: 6, Target: 5},
+			&upgrade.LayoutMismatchError{ContentID: 1, Role: "m", Source: 1, Target: 0},
+		}
+		if !reflect.DeepEqual(errs, expected) {
+			t.Errorf("got errors %v want %v", errs, expected)
+		}
+
+		expectedMsg := "content 1 has 1 mirror segment(s) in the source cluster but 0 in the target cluster"
+		if errs[1].Error() != expectedMsg {
+			t.Errorf("got error %q want %q", errs[1].Error(), expectedMsg)
+		}
+	})
+}
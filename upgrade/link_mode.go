@@ -0,0 +1,70 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/utils"
+)
+
+// ErrCrossFilesystemLink is returned by VerifyLinkMode when the source and
+// target data directories are on different filesystems.
+var ErrCrossFilesystemLink = errors.New("link mode requires the source and target data directories to be on the same filesystem")
+
+// CrossFilesystemLinkError is the backing error type for
+// ErrCrossFilesystemLink.
+type CrossFilesystemLinkError struct {
+	Source       string
+	Target       string
+	SourceDevice uint64
+	TargetDevice uint64
+}
+
+func (c *CrossFilesystemLinkError) Error() string {
+	return fmt.Sprintf("cannot upgrade %q to %q in link mode: they are on different filesystems (devices %d and %d) and hard links cannot cross filesystems; use copy mode instead",
+		c.Source, c.Target, c.SourceDevice, c.TargetDevice)
+}
+
+func (c *CrossFilesystemLinkError) Is(err error) bool {
+	return err == ErrCrossFilesystemLink
+}
+
+// VerifyLinkMode ensures that pg_upgrade --link can hard link the files in
+// source into target, which requires both to be on the same filesystem.
+func VerifyLinkMode(source, target string) error {
+	sourceDevice, err := deviceID(source)
+	if err != nil {
+		return err
+	}
+
+	targetDevice, err := deviceID(target)
+	if err != nil {
+		return err
+	}
+
+	if sourceDevice != targetDevice {
+		return &CrossFilesystemLinkError{Source: source, Target: target, SourceDevice: sourceDevice, TargetDevice: targetDevice}
+	}
+
+	return nil
+}
+
+func deviceID(path string) (uint64, error) {
+	info, err := utils.System.Stat(path)
+	if err != nil {
+		return 0, xerrors.Errorf("checking filesystem of %q: %w", path, err)
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, xerrors.Errorf("checking filesystem of %q: no device ID available", path)
+	}
+
+	return uint64(stat.Dev), nil
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade_test
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/upgrade"
+	"github.com/greenplum-db/gpupgrade/utils"
+)
+
+// deviceInfo is an os.FileInfo reporting the given device ID.
+type deviceInfo struct {
+	dev uint64
+}
+
+func (d deviceInfo) Name() string       { return "" }
+func (d deviceInfo) Size() int64        { return 0 }
+func (d deviceInfo) Mode() os.FileMode  { return os.ModeDir }
+func (d deviceInfo) ModTime() time.Time { return time.Time{} }
+func (d deviceInfo) IsDir() bool        { return true }
+func (d deviceInfo) Sys() interface{}   { return &syscall.Stat_t{Dev: d.dev} }
+
+func TestVerifyLinkMode(t *testing.T) {
+	statDevices := func(devices map[string]uint64) func(string) (os.FileInfo, error) {
+		return func(name string) (os.FileInfo, error) {
+			return deviceInfo{dev: devices[name]}, nil
+		}
+	}
+
+	t.Run("succeeds when both directories are on the same device", func(t *testing.T) {
+		utils.System.Stat = statDevices(map[string]uint64{"/data/old": 42, "/data/new": 42})
+		defer func() {
+			utils.System.Stat = os.Stat
+		}()
+
+		err := upgrade.VerifyLinkMode("/data/old", "/data/new")
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+	})
+
+	t.Run("errors when the directories are on different devices", func(t *testing.T) {
+		utils.System.Stat = statDevices(map[string]uint64{"/data/old": 42, "/mnt/new": 43})
+		defer func() {
+			utils.System.Stat = os.Stat
+		}()
+
+		err := upgrade.VerifyLinkMode("/data/old", "/mnt/new")
+
+		var linkErr *upgrade.CrossFilesystemLinkError
+		if !errors.As(err, &linkErr) {
+			t.Fatalf("got error %#v want type %T", err, linkErr)
+		}
+
+		if !errors.Is(err, upgrade.ErrCrossFilesystemLink) {
+			t.Errorf("got error %#v want %#v", err, upgrade.ErrCrossFilesystemLink)
+		}
+
+		expected := &upgrade.CrossFilesystemLinkError{Source: "/data/old", Target: "/mnt/new", SourceDevice: 42, TargetDevice: 43}
+		if *linkErr != *expected {
+			t.Errorf("got %+v want %+v", linkErr, expected)
+		}
+	})
+
+	t.Run("errors when a directory cannot be statted", func(t *testing.T) {
+		err := upgrade.VerifyLinkMode("/does/not/exist", "/does/not/exist/either")
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("got error %#v want %#v", err, os.ErrNotExist)
+		}
+	})
+
+	t.Run("succeeds for real directories on the same filesystem", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+
+		err := upgrade.VerifyLinkMode(source, target)
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+	})
+}
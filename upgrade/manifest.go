@@ -0,0 +1,196 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/errorlist"
+)
+
+// ErrManifestMismatch is returned by VerifyManifest when a directory differs
+// from its manifest.
+var ErrManifestMismatch = errors.New("directory does not match its manifest")
+
+// ManifestMismatchError is the backing error type for ErrManifestMismatch.
+// Path is relative to the directory being verified.
+type ManifestMismatchError struct {
+	Path   string
+	Reason string
+}
+
+func (m *ManifestMismatchError) Error() string {
+	return fmt.Sprintf("%q does not match the manifest: %s", m.Path, m.Reason)
+}
+
+func (m *ManifestMismatchError) Is(err error) bool {
+	return err == ErrManifestMismatch
+}
+
+// manifestEntry records a single file, directory, or symlink in a manifest.
+type manifestEntry struct {
+	Path   string
+	Type   string
+	Size   int64  `json:",omitempty"`
+	SHA256 string `json:",omitempty"`
+	Link   string `json:",omitempty"`
+}
+
+// WriteManifest records the type, size, and checksum of every entry in dir to
+// manifestPath, such as before ArchiveSource moves a source data directory, so
+// that the directory can later be checked with VerifyManifest. Symlinks are
+// recorded by their targets rather than followed. The manifest is written
+// atomically, and is left out of the manifest if it is within dir.
+func WriteManifest(dir, manifestPath string) error {
+	entries, err := manifestEntries(dir, manifestPath)
+	if err != nil {
+		return xerrors.Errorf("writing manifest of %q: %w", dir, err)
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return xerrors.Errorf("writing manifest of %q: %w", dir, err)
+	}
+
+	if err := utils.AtomicWriteFile(manifestPath, data, 0600); err != nil {
+		return xerrors.Errorf("writing manifest of %q: %w", dir, err)
+	}
+
+	return nil
+}
+
+// VerifyManifest ensures that dir, such as a restored source data directory,
+// matches the manifest written by WriteManifest. Every difference is returned
+// as a ManifestMismatchError.
+func VerifyManifest(dir, manifestPath string) error {
+	data, err := utils.System.ReadFile(manifestPath)
+	if err != nil {
+		return xerrors.Errorf("reading manifest: %w", err)
+	}
+
+	var expected []manifestEntry
+	if err := json.Unmarshal(data, &expected); err != nil {
+		return xerrors.Errorf("parsing manifest %q: %w", manifestPath, err)
+	}
+
+	actual, err := manifestEntries(dir, manifestPath)
+	if err != nil {
+		return xerrors.Errorf("verifying %q against manifest: %w", dir, err)
+	}
+
+	found := make(map[string]manifestEntry, len(actual))
+	for _, entry := range actual {
+		found[entry.Path] = entry
+	}
+
+	var mErr error
+	for _, want := range expected {
+		got, ok := found[want.Path]
+		delete(found, want.Path)
+
+		if !ok {
+			mErr = errorlist.Append(mErr, &ManifestMismatchError{Path: want.Path, Reason: "missing"})
+			continue
+		}
+
+		if reason := compareManifestEntries(want, got); reason != "" {
+			mErr = errorlist.Append(mErr, &ManifestMismatchError{Path: want.Path, Reason: reason})
+		}
+	}
+
+	var extra []string
+	for path := range found {
+		extra = append(extra, path)
+	}
+	sort.Strings(extra)
+
+	for _, path := range extra {
+		mErr = errorlist.Append(mErr, &ManifestMismatchError{Path: path, Reason: "not in manifest"})
+	}
+
+	return mErr
+}
+
+func compareManifestEntries(want, got manifestEntry) string {
+	switch {
+	case want.Type != got.Type:
+		return fmt.Sprintf("%s is now a %s", want.Type, got.Type)
+	case want.Size != got.Size:
+		return fmt.Sprintf("size %d differs from %d", got.Size, want.Size)
+	case want.SHA256 != got.SHA256:
+		return "contents differ"
+	case want.Link != got.Link:
+		return fmt.Sprintf("symlink target %q differs from %q", got.Link, want.Link)
+	}
+
+	return ""
+}
+
+// manifestEntries returns the entries of dir in lexical order, excluding the
+// manifest itself.
+func manifestEntries(dir, manifestPath string) ([]manifestEntry, error) {
+	manifestPath, err := filepath.Abs(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []manifestEntry
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if abs, err := filepath.Abs(path); err == nil && abs == manifestPath {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		entry := manifestEntry{Path: rel}
+		switch {
+		case info.IsDir():
+			entry.Type = "directory"
+
+		case info.Mode()&os.ModeSymlink != 0:
+			entry.Type = "symlink"
+			entry.Link, err = utils.System.Readlink(path)
+			if err != nil {
+				return err
+			}
+
+		case info.Mode().IsRegular():
+			entry.Type = "file"
+			entry.Size = info.Size()
+
+			sum, err := checksum(path)
+			if err != nil {
+				return err
+			}
+			entry.SHA256 = hex.EncodeToString(sum)
+
+		default:
+			entry.Type = info.Mode().Type().String()
+		}
+
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/upgrade"
+	"github.com/greenplum-db/gpupgrade/utils/errorlist"
+)
+
+func TestManifest(t *testing.T) {
+	// tree creates a data directory with nested files and a symlink, and
+	// returns its path.
+	tree := func(t *testing.T, parent string) string {
+		t.Helper()
+
+		dir := filepath.Join(parent, "demoDataDir0")
+		if err := os.MkdirAll(filepath.Join(dir, "base", "1"), 0700); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		testutils.MustWriteToFile(t, filepath.Join(dir, "postgresql.conf"), "port = 15432")
+		testutils.MustWriteToFile(t, filepath.Join(dir, "base", "1", "16384"), "relation data")
+		if err := os.Symlink("/tmp/tablespace", filepath.Join(dir, "16386")); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		return dir
+	}
+
+	// mismatches returns the paths and reasons of each ManifestMismatchError
+	// in err.
+	mismatches := func(t *testing.T, err error) []upgrade.ManifestMismatchError {
+		t.Helper()
+
+		if !errors.Is(err, upgrade.ErrManifestMismatch) {
+			t.Fatalf("got error %#v want %#v", err, upgrade.ErrManifestMismatch)
+		}
+
+		errs := []error{err}
+		var list errorlist.Errors
+		if errors.As(err, &list) {
+			errs = list
+		}
+
+		var result []upgrade.ManifestMismatchError
+		for _, err := range errs {
+			var mismatchErr *upgrade.ManifestMismatchError
+			if !errors.As(err, &mismatchErr) {
+				t.Fatalf("got error %#v want type %T", err, mismatchErr)
+			}
+			result = append(result, *mismatchErr)
+		}
+
+		return result
+	}
+
+	t.Run("verifies an unchanged directory", func(t *testing.T) {
+		parent := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, parent)
+
+		dir := tree(t, parent)
+		manifest := filepath.Join(parent, "manifest.json")
+
+		if err := upgrade.WriteManifest(dir, manifest); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if err := upgrade.VerifyManifest(dir, manifest); err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+	})
+
+	t.Run("detects a file modified after the manifest was written", func(t *testing.T) {
+		parent := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, parent)
+
+		dir := tree(t, parent)
+		manifest := filepath.Join(parent, "manifest.json")
+
+		if err := upgrade.WriteManifest(dir, manifest); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		testutils.MustWriteToFile(t, filepath.Join(dir, "base", "1", "16384"), "relation dara")
+
+		err := upgrade.VerifyManifest(dir, manifest)
+		expected := []upgrade.ManifestMismatchError{
+			{Path: filepath.Join("base", "1", "16384"), Reason: "contents differ"},
+		}
+		if got := mismatches(t, err); !reflect.DeepEqual(got, expected) {
+			t.Errorf("got mismatches %+v want %+v", got, expected)
+		}
+	})
+
+	t.Run("reports every difference", func(t *testing.T) {
+		parent := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, parent)
+
+		dir := tree(t, parent)
+		manifest := filepath.Join(parent, "manifest.json")
+
+		if err := upgrade.WriteManifest(dir, manifest); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		testutils.MustWriteToFile(t, filepath.Join(dir, "postgresql.conf"), "port = 5432")
+		testutils.MustRemoveAll(t, filepath.Join(dir, "16386"))
+		testutils.MustWriteToFile(t, filepath.Join(dir, "postmaster.pid"), "1234")
+
+		err := upgrade.VerifyManifest(dir, manifest)
+		expected := []upgrade.ManifestMismatchError{
+			{Path: "16386", Reason: "missing"},
+			{Path: "postgresql.conf", Reason: "size 11 differs from 12"},
+			{Path: "postmaster.pid", Reason: "not in manifest"},
+		}
+		if got := mismatches(t, err); !reflect.DeepEqual(got, expected) {
+			t.Errorf("got mismatches %+v want %+v", got, expected)
+		}
+	})
+
+	t.Run("leaves a manifest within the directory out of the manifest", func(t *testing.T) {
+		parent := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, parent)
+
+		dir := tree(t, parent)
+		manifest := filepath.Join(dir, "manifest.json")
+
+		if err := upgrade.WriteManifest(dir, manifest); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if err := upgrade.VerifyManifest(dir, manifest); err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+	})
+
+	t.Run("errors when the manifest does not exist", func(t *testing.T) {
+		parent := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, parent)
+
+		dir := tree(t, parent)
+
+		err := upgrade.VerifyManifest(dir, filepath.Join(parent, "manifest.json"))
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("got error %#v want %#v", err, os.ErrNotExist)
+		}
+	})
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade
+
+import (
+	"errors"
+	"fmt"
+	"os/user"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/errorlist"
+)
+
+// ErrWrongOwner is returned by VerifyOwnership when a directory is not owned
+// by the expected user.
+var ErrWrongOwner = errors.New("directory is not owned by the expected user")
+
+// WrongOwnerError is the backing error type for ErrWrongOwner.
+type WrongOwnerError struct {
+	Dir          string
+	ExpectedUser string
+	ExpectedUID  uint32
+	UID          uint32
+}
+
+func (w *WrongOwnerError) Error() string {
+	return fmt.Sprintf("%q is owned by uid %d rather than %s (uid %d); change its owner with chown -R",
+		w.Dir, w.UID, w.ExpectedUser, w.ExpectedUID)
+}
+
+func (w *WrongOwnerError) Is(err error) bool {
+	return err == ErrWrongOwner
+}
+
+// VerifyOwnership ensures that each directory is owned by expectedUser, such
+// as gpadmin. Directories left owned by root after a manual operation
+// otherwise cause obscure failures partway through the upgrade. All
+// directories are checked, and every failure is returned.
+func VerifyOwnership(dirs []string, expectedUser string) error {
+	u, err := user.Lookup(expectedUser)
+	if err != nil {
+		return xerrors.Errorf("verifying ownership: looking up user %q: %w", expectedUser, err)
+	}
+
+	expectedUID, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return xerrors.Errorf("verifying ownership: parsing uid %q of user %q: %w", u.Uid, expectedUser, err)
+	}
+
+	var mErr error
+	for _, dir := range dirs {
+		uid, err := ownerUID(dir)
+		if err != nil {
+			mErr = errorlist.Append(mErr, err)
+			continue
+		}
+
+		if uid != uint32(expectedUID) {
+			mErr = errorlist.Append(mErr, &WrongOwnerError{Dir: dir, ExpectedUser: expectedUser, ExpectedUID: uint32(expectedUID), UID: uid})
+		}
+	}
+
+	return mErr
+}
+
+func ownerUID(path string) (uint32, error) {
+	info, err := utils.System.Stat(path)
+	if err != nil {
+		return 0, xerrors.Errorf("checking owner of %q: %w", path, err)
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, xerrors.Errorf("checking owner of %q: no owner available", path)
+	}
+
+	return stat.Uid, nil
+}
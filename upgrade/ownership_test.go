@@ -0,0 +1,115 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade_test
+
+import (
+	"errors"
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/greenplum-db/gpupgrade/upgrade"
+	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/errorlist"
+)
+
+// ownerInfo is an os.FileInfo reporting the given owner.
+type ownerInfo struct {
+	uid uint32
+}
+
+func (o ownerInfo) Name() string       { return "" }
+func (o ownerInfo) Size() int64        { return 0 }
+func (o ownerInfo) Mode() os.FileMode  { return os.ModeDir }
+func (o ownerInfo) ModTime() time.Time { return time.Time{} }
+func (o ownerInfo) IsDir() bool        { return true }
+func (o ownerInfo) Sys() interface{}   { return &syscall.Stat_t{Uid: o.uid} }
+
+func TestVerifyOwnership(t *testing.T) {
+	current, err := user.Current()
+	if err != nil {
+		t.Fatalf("looking up current user: %v", err)
+	}
+
+	uid, err := strconv.ParseUint(current.Uid, 10, 32)
+	if err != nil {
+		t.Fatalf("parsing uid: %v", err)
+	}
+
+	const wrongUID = 12345
+
+	owners := map[string]uint32{
+		"/data/dbfast1/demoDataDir0": uint32(uid),
+		"/data/dbfast2/demoDataDir1": wrongUID,
+		"/data/dbfast3/demoDataDir2": wrongUID,
+	}
+
+	utils.System.Stat = func(name string) (os.FileInfo, error) {
+		owner, ok := owners[name]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+
+		return ownerInfo{uid: owner}, nil
+	}
+	defer func() {
+		utils.System = utils.InitializeSystemFunctions()
+	}()
+
+	t.Run("succeeds when the directories are owned by the expected user", func(t *testing.T) {
+		err := upgrade.VerifyOwnership([]string{"/data/dbfast1/demoDataDir0"}, current.Username)
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+	})
+
+	t.Run("returns an error for each directory not owned by the expected user", func(t *testing.T) {
+		dirs := []string{"/data/dbfast1/demoDataDir0", "/data/dbfast2/demoDataDir1", "/data/dbfast3/demoDataDir2"}
+		err := upgrade.VerifyOwnership(dirs, current.Username)
+
+		var errs errorlist.Errors
+		if !errors.As(err, &errs) {
+			t.Fatalf("got error %#v want type %T", err, errs)
+		}
+
+		if len(errs) != 2 {
+			t.Fatalf("got %d errors want 2", len(errs))
+		}
+
+		for i, dir := range dirs[1:] {
+			var ownerErr *upgrade.WrongOwnerError
+			if !errors.As(errs[i], &ownerErr) {
+				t.Fatalf("got error %#v want type %T", errs[i], ownerErr)
+			}
+
+			if !errors.Is(errs[i], upgrade.ErrWrongOwner) {
+				t.Errorf("expected error %#v to match %v", errs[i], upgrade.ErrWrongOwner)
+			}
+
+			expected := upgrade.WrongOwnerError{Dir: dir, ExpectedUser: current.Username, ExpectedUID: uint32(uid), UID: wrongUID}
+			if *ownerErr != expected {
+				t.Errorf("got %+v want %+v", *ownerErr, expected)
+			}
+		}
+	})
+
+	t.Run("errors when a directory cannot be statted", func(t *testing.T) {
+		err := upgrade.VerifyOwnership([]string{"/does/not/exist"}, current.Username)
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("got error %#v want %#v", err, os.ErrNotExist)
+		}
+	})
+
+	t.Run("errors when the expected user cannot be resolved", func(t *testing.T) {
+		err := upgrade.VerifyOwnership([]string{"/data/dbfast1/demoDataDir0"}, "gpupgrade-no-such-user")
+
+		var unknown user.UnknownUserError
+		if !errors.As(err, &unknown) {
+			t.Errorf("got error %#v want type %T", err, unknown)
+		}
+	})
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade
+
+import (
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/errorlist"
+)
+
+// RenameAll renames each pair's source to its target in order. If a rename
+// fails, the renames already performed are undone in reverse order so that
+// the paths are left as they were found. Undoing is best-effort: any
+// failures are appended to the original error.
+func RenameAll(pairs [][2]string) error {
+	for i, pair := range pairs {
+		err := utils.System.Rename(pair[0], pair[1])
+		if err == nil {
+			continue
+		}
+
+		err = xerrors.Errorf("renaming %q to %q: %w", pair[0], pair[1], err)
+
+		for j := i - 1; j >= 0; j-- {
+			done := pairs[j]
+			gplog.Debug("rolling back rename of %q to %q", done[0], done[1])
+
+			if rErr := utils.System.Rename(done[1], done[0]); rErr != nil {
+				err = errorlist.Append(err, xerrors.Errorf("rolling back rename of %q to %q: %w", done[0], done[1], rErr))
+			}
+		}
+
+		return err
+	}
+
+	return nil
+}
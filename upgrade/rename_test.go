@@ -0,0 +1,127 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+	"github.com/greenplum-db/gpupgrade/upgrade"
+	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/errorlist"
+)
+
+func TestRenameAll(t *testing.T) {
+	testlog.SetupLogger()
+
+	// paths creates three files and returns rename pairs moving each to a new
+	// name.
+	paths := func(t *testing.T) ([][2]string, func(*testing.T)) {
+		t.Helper()
+
+		dir := testutils.GetTempDir(t, "")
+
+		var pairs [][2]string
+		for _, name := range []string{"a", "b", "c"} {
+			src := filepath.Join(dir, name)
+			testutils.MustWriteToFile(t, src, name)
+			pairs = append(pairs, [2]string{src, src + ".renamed"})
+		}
+
+		return pairs, func(t *testing.T) {
+			testutils.MustRemoveAll(t, dir)
+		}
+	}
+
+	t.Run("renames each pair", func(t *testing.T) {
+		pairs, cleanup := paths(t)
+		defer cleanup(t)
+
+		if err := upgrade.RenameAll(pairs); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		for _, pair := range pairs {
+			if upgrade.PathExists(pair[0]) || !upgrade.PathExists(pair[1]) {
+				t.Errorf("expected %q to be renamed to %q", pair[0], pair[1])
+			}
+		}
+	})
+
+	t.Run("rolls back earlier renames when one fails", func(t *testing.T) {
+		pairs, cleanup := paths(t)
+		defer cleanup(t)
+
+		expected := errors.New("permission denied")
+		utils.System.Rename = func(old, new string) error {
+			if old == pairs[1][0] {
+				return expected
+			}
+
+			return os.Rename(old, new)
+		}
+		defer func() {
+			utils.System.Rename = os.Rename
+		}()
+
+		err := upgrade.RenameAll(pairs)
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+
+		for _, pair := range pairs {
+			if !upgrade.PathExists(pair[0]) || upgrade.PathExists(pair[1]) {
+				t.Errorf("expected %q to not be renamed to %q", pair[0], pair[1])
+			}
+		}
+
+		if contents := testutils.MustReadFile(t, pairs[0][0]); contents != "a" {
+			t.Errorf("got contents %q want %q", contents, "a")
+		}
+	})
+
+	t.Run("reports rollback failures along with the original error", func(t *testing.T) {
+		pairs, cleanup := paths(t)
+		defer cleanup(t)
+
+		expected := errors.New("permission denied")
+		rollbackErr := errors.New("device busy")
+		utils.System.Rename = func(old, new string) error {
+			switch old {
+			case pairs[1][0]:
+				return expected
+			case pairs[0][1]:
+				return rollbackErr
+			}
+
+			return os.Rename(old, new)
+		}
+		defer func() {
+			utils.System.Rename = os.Rename
+		}()
+
+		err := upgrade.RenameAll(pairs)
+
+		var errs errorlist.Errors
+		if !errors.As(err, &errs) {
+			t.Fatalf("got error %#v want type %T", err, errs)
+		}
+
+		if len(errs) != 2 {
+			t.Fatalf("got %d errors want 2", len(errs))
+		}
+
+		if !errors.Is(errs[0], expected) {
+			t.Errorf("got error %#v want %#v", errs[0], expected)
+		}
+
+		if !errors.Is(errs[1], rollbackErr) {
+			t.Errorf("got error %#v want %#v", errs[1], rollbackErr)
+		}
+	})
+}
@@ -0,0 +1,205 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/step"
+	"github.com/greenplum-db/gpupgrade/utils/rsync"
+)
+
+// MasterBackupRsyncOptions returns the options with which the agents restore
+// the upgraded master backup into a primary's target data directory. Files
+// specific to the master are excluded.
+func MasterBackupRsyncOptions(backupDir, targetDataDir string) []rsync.Option {
+	return []rsync.Option{
+		rsync.WithSources(backupDir + string(os.PathSeparator)),
+		rsync.WithDestination(targetDataDir),
+		rsync.WithOptions("--archive", "--delete"),
+		rsync.WithExcludedFiles(
+			"internal.auto.conf",
+			"postgresql.conf",
+			"pg_hba.conf",
+			"postmaster.opts",
+			"gp_dbid",
+			"gpssh.conf",
+			"gpperfmon"),
+	}
+}
+
+// RsyncOptions controls how RsyncDir copies a directory.
+type RsyncOptions struct {
+	// DestinationHost is the host dst is on. When empty dst is local.
+	DestinationHost string
+
+	// Delete removes files from dst that are not in src.
+	Delete bool
+
+	// Checksum compares files by checksum rather than by size and
+	// modification time.
+	Checksum bool
+
+	// Compress compresses file data during the transfer, which is only
+	// worthwhile when DestinationHost is remote.
+	Compress bool
+
+	// Excludes are rsync patterns of files in src that are not copied, and
+	// that are kept in dst when Delete is set.
+	Excludes []string
+
+	// Streams receives the output of rsync. When nil the output is discarded,
+	// though stderr is still included in any returned RsyncError.
+	Streams step.OutStreams
+}
+
+// ErrRsyncPartialTransfer is matched by an RsyncError when some files were
+// not transferred.
+var ErrRsyncPartialTransfer = errors.New("rsync partial transfer")
+
+// ErrRsyncPermission is matched by an RsyncError when rsync was denied access
+// to a file or to the destination host.
+var ErrRsyncPermission = errors.New("rsync permission denied")
+
+// ErrRsyncProtocol is matched by an RsyncError when rsync could not
+// communicate with the rsync on the other host, typically because the versions
+// are incompatible.
+var ErrRsyncProtocol = errors.New("rsync protocol mismatch")
+
+// RsyncError is returned by RsyncDir when rsync exits unsuccessfully. It
+// matches ErrRsyncPartialTransfer, ErrRsyncPermission, or ErrRsyncProtocol
+// depending on the exit code and output of rsync.
+type RsyncError struct {
+	ExitCode int
+	Stderr   string
+	Err      error
+}
+
+func (r *RsyncError) Error() string {
+	msg := fmt.Sprintf("rsync failed with exit code %d (%s)", r.ExitCode, rsyncExitReason(r.ExitCode))
+	if stderr := strings.TrimSpace(r.Stderr); stderr != "" {
+		msg += ": " + stderr
+	}
+
+	return msg
+}
+
+func (r *RsyncError) Is(err error) bool {
+	switch err {
+	case ErrRsyncPartialTransfer:
+		return r.ExitCode == 23 || r.ExitCode == 24
+	case ErrRsyncPermission:
+		return strings.Contains(r.Stderr, "Permission denied")
+	case ErrRsyncProtocol:
+		return r.ExitCode == 2 || r.ExitCode == 5 || r.ExitCode == 12
+	}
+
+	return false
+}
+
+func (r *RsyncError) Unwrap() error {
+	return r.Err
+}
+
+// rsyncExitReason describes the exit codes documented by rsync(1).
+func rsyncExitReason(code int) string {
+	switch code {
+	case 1:
+		return "syntax or usage error"
+	case 2:
+		return "protocol incompatibility"
+	case 3:
+		return "errors selecting input/output files, dirs"
+	case 5:
+		return "error starting client-server protocol"
+	case 10:
+		return "error in socket I/O"
+	case 11:
+		return "error in file I/O"
+	case 12:
+		return "error in rsync protocol data stream"
+	case 23:
+		return "partial transfer due to error"
+	case 24:
+		return "partial transfer due to vanished source files"
+	case 30:
+		return "timeout in data send/receive"
+	case 255:
+		return "remote shell failed"
+	default:
+		return "unknown error"
+	}
+}
+
+// RsyncDir copies the contents of src into dst, which may be on another host,
+// preserving permissions, modification times, symlinks, and hard links. A
+// non-zero exit from rsync is returned as an RsyncError.
+func RsyncDir(src, dst string, opts RsyncOptions) error {
+	args := []string{"--archive", "--hard-links"}
+	if opts.Delete {
+		args = append(args, "--delete")
+	}
+	if opts.Checksum {
+		args = append(args, "--checksum")
+	}
+	if opts.Compress {
+		args = append(args, "--compress")
+	}
+
+	streams := &rsyncStreams{stdout: ioutil.Discard}
+	streams.stderr = &streams.stderrBuf
+	if opts.Streams != nil {
+		streams.stdout = opts.Streams.Stdout()
+		streams.stderr = io.MultiWriter(opts.Streams.Stderr(), &streams.stderrBuf)
+	}
+
+	options := []rsync.Option{
+		rsync.WithSources(strings.TrimRight(src, string(os.PathSeparator)) + string(os.PathSeparator)),
+		rsync.WithDestination(dst),
+		rsync.WithOptions(args...),
+		rsync.WithExcludedFiles(opts.Excludes...),
+		rsync.WithStream(streams),
+	}
+	if opts.DestinationHost != "" {
+		options = append(options, rsync.WithDestinationHost(opts.DestinationHost))
+	}
+
+	err := rsync.Rsync(options...)
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &RsyncError{ExitCode: exitErr.ExitCode(), Stderr: streams.stderrBuf.String(), Err: err}
+	}
+
+	if err != nil {
+		return xerrors.Errorf("rsync %q to %q: %w", src, dst, err)
+	}
+
+	return nil
+}
+
+// rsyncStreams forwards the output of rsync while capturing stderr for
+// RsyncError.
+type rsyncStreams struct {
+	stdout    io.Writer
+	stderr    io.Writer
+	stderrBuf bytes.Buffer
+}
+
+func (r *rsyncStreams) Stdout() io.Writer {
+	return r.stdout
+}
+
+func (r *rsyncStreams) Stderr() io.Writer {
+	return r.stderr
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/greenplum-db/gpupgrade/step"
+	"github.com/greenplum-db/gpupgrade/testutils/exectest"
+	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+	"github.com/greenplum-db/gpupgrade/upgrade"
+	"github.com/greenplum-db/gpupgrade/utils/rsync"
+)
+
+func RsyncPartialTransferMain() {
+	fmt.Fprint(os.Stderr, "rsync error: some files/attrs were not transferred (see previous errors) (code 23)")
+	os.Exit(23)
+}
+
+func RsyncPermissionDeniedMain() {
+	fmt.Fprintln(os.Stderr, `rsync: send_files failed to open "/data/qddir/demoDataDir-1/pg_hba.conf": Permission denied (13)`)
+	fmt.Fprint(os.Stderr, "rsync error: some files/attrs were not transferred (see previous errors) (code 23)")
+	os.Exit(23)
+}
+
+func RsyncProtocolMismatchMain() {
+	fmt.Fprint(os.Stderr, "protocol version mismatch -- is your shell clean?")
+	os.Exit(2)
+}
+
+func init() {
+	exectest.RegisterMains(
+		RsyncPartialTransferMain,
+		RsyncPermissionDeniedMain,
+		RsyncProtocolMismatchMain,
+	)
+}
+
+func TestRsyncDir(t *testing.T) {
+	testlog.SetupLogger()
+
+	t.Run("passes the requested options to rsync", func(t *testing.T) {
+		rsync.SetRsyncCommand(exectest.NewCommandWithVerifier(Success, func(utility string, args ...string) {
+			if utility != "rsync" {
+				t.Errorf("got %q want rsync", utility)
+			}
+
+			expected := []string{
+				"--archive", "--hard-links", "--delete", "--checksum", "--compress",
+				"/data/qddir/demoDataDir-1/", "sdw1:/data/qddir/demoDataDir-1",
+				"--exclude", "postmaster.pid",
+			}
+			if !reflect.DeepEqual(args, expected) {
+				t.Errorf("got args %q want %q", args, expected)
+			}
+		}))
+		defer rsync.ResetRsyncCommand()
+
+		opts := upgrade.RsyncOptions{
+			DestinationHost: "sdw1",
+			Delete:          true,
+			Checksum:        true,
+			Compress:        true,
+			Excludes:        []string{"postmaster.pid"},
+		}
+		err := upgrade.RsyncDir("/data/qddir/demoDataDir-1/", "/data/qddir/demoDataDir-1", opts)
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+	})
+
+	t.Run("writes the output of rsync to the streams", func(t *testing.T) {
+		rsync.SetRsyncCommand(exectest.NewCommand(RsyncPartialTransferMain))
+		defer rsync.ResetRsyncCommand()
+
+		streams := &step.BufferedStreams{}
+		err := upgrade.RsyncDir("/source", "/target", upgrade.RsyncOptions{Streams: streams})
+
+		var rsyncErr *upgrade.RsyncError
+		if !errors.As(err, &rsyncErr) {
+			t.Fatalf("got error %#v want type %T", err, rsyncErr)
+		}
+
+		expected := "some files/attrs were not transferred"
+		if !strings.Contains(streams.StderrBuf.String(), expected) {
+			t.Errorf("got stderr %q want it to contain %q", streams.StderrBuf.String(), expected)
+		}
+
+		if !strings.Contains(rsyncErr.Error(), expected) {
+			t.Errorf("got error %q want it to contain %q", rsyncErr.Error(), expected)
+		}
+	})
+
+	cases := []struct {
+		name     string
+		main     exectest.Main
+		code     int
+		expected []error
+		excluded []error
+	}{
+		{
+			name:     "maps exit code 23 to a partial transfer error",
+			main:     RsyncPartialTransferMain,
+			code:     23,
+			expected: []error{upgrade.ErrRsyncPartialTransfer},
+			excluded: []error{upgrade.ErrRsyncPermission, upgrade.ErrRsyncProtocol},
+		},
+		{
+			name:     "maps a permission denied error",
+			main:     RsyncPermissionDeniedMain,
+			code:     23,
+			expected: []error{upgrade.ErrRsyncPermission, upgrade.ErrRsyncPartialTransfer},
+			excluded: []error{upgrade.ErrRsyncProtocol},
+		},
+		{
+			name:     "maps exit code 2 to a protocol mismatch error",
+			main:     RsyncProtocolMismatchMain,
+			code:     2,
+			expected: []error{upgrade.ErrRsyncProtocol},
+			excluded: []error{upgrade.ErrRsyncPartialTransfer, upgrade.ErrRsyncPermission},
+		},
+		{
+			name:     "does not map other failures",
+			main:     Failure,
+			code:     1,
+			excluded: []error{upgrade.ErrRsyncPartialTransfer, upgrade.ErrRsyncPermission, upgrade.ErrRsyncProtocol},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rsync.SetRsyncCommand(exectest.NewCommand(c.main))
+			defer rsync.ResetRsyncCommand()
+
+			err := upgrade.RsyncDir("/source", "/target", upgrade.RsyncOptions{})
+
+			var rsyncErr *upgrade.RsyncError
+			if !errors.As(err, &rsyncErr) {
+				t.Fatalf("got error %#v want type %T", err, rsyncErr)
+			}
+
+			if rsyncErr.ExitCode != c.code {
+				t.Errorf("got exit code %d want %d", rsyncErr.ExitCode, c.code)
+			}
+
+			var exitErr *exec.ExitError
+			if !errors.As(err, &exitErr) {
+				t.Errorf("expected error %#v to wrap an ExitError", err)
+			}
+
+			for _, expected := range c.expected {
+				if !errors.Is(err, expected) {
+					t.Errorf("expected error %#v to match %v", err, expected)
+				}
+			}
+
+			for _, excluded := range c.excluded {
+				if errors.Is(err, excluded) {
+					t.Errorf("expected error %#v not to match %v", err, excluded)
+				}
+			}
+		})
+	}
+}
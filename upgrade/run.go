@@ -4,13 +4,19 @@
 package upgrade
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"os/exec"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/blang/semver/v4"
 	"github.com/greenplum-db/gp-common-go-libs/gplog"
+
+	"github.com/greenplum-db/gpupgrade/utils/command"
 )
 
 const DefaultHubPort = 7527
@@ -33,12 +39,88 @@ type SegmentPair struct {
 	Source, Target *Segment
 }
 
+// ErrPgUpgradeCancelled is matched by a PgUpgradeCancelledError.
+var ErrPgUpgradeCancelled = errors.New("pg_upgrade cancelled")
+
+// PgUpgradeCancelledError is returned by RunContext when pg_upgrade was
+// stopped because its context was done, rather than failing on its own. Err
+// is the error of the context.
+type PgUpgradeCancelledError struct {
+	Err error
+}
+
+func (p *PgUpgradeCancelledError) Error() string {
+	return fmt.Sprintf("pg_upgrade cancelled: %v", p.Err)
+}
+
+func (p *PgUpgradeCancelledError) Is(err error) bool {
+	return err == ErrPgUpgradeCancelled
+}
+
+func (p *PgUpgradeCancelledError) Unwrap() error {
+	return p.Err
+}
+
 // Run executes pg_upgrade for the given pair of Segments. By default, a
 // standard master upgrade is performed; this can be changed by passing various
 // Options.
 func Run(p SegmentPair, targetVersion semver.Version, options ...Option) error {
+	return RunContext(context.Background(), p, targetVersion, options...)
+}
+
+// RunContext is Run, but stops pg_upgrade once ctx is done. pg_upgrade is sent
+// SIGTERM so that it stops any clusters it started, and is killed if it has
+// not exited within the Runner's grace period. Run itself creates no state
+// needing cleanup. A PgUpgradeCancelledError is returned when pg_upgrade was
+// stopped, so that cancellation can be told apart from a pg_upgrade failure.
+func RunContext(ctx context.Context, p SegmentPair, targetVersion semver.Version, options ...Option) error {
 	opts := newOptionList(options)
+	path, args := opts.command(p, targetVersion)
+
+	// If the caller specified an explicit Runner use it. Otherwise run
+	// pg_upgrade with os/exec, getting our exec.Cmd from the explicit Command
+	// implementation if one was specified or our internal execCommand.
+	runner := opts.Runner
+	if runner == nil {
+		cmdFunc := execCommand
+		if opts.ExecCommandSet {
+			cmdFunc = opts.ExecCommand
+		}
+
+		runner = &command.Exec{
+			Dir:     opts.Dir,
+			Env:     pgUpgradeEnv(),
+			Stdout:  opts.Stdout,
+			Stderr:  opts.Stderr,
+			Command: cmdFunc,
+		}
+	}
+
+	gplog.Info(strings.Join(append([]string{path}, args...), " "))
+
+	stdout, stderr, err := runner.Run(ctx, path, args...)
+
+	// Exec writes to the streams as pg_upgrade runs. Other Runners only
+	// return the output once pg_upgrade exits.
+	if opts.Runner != nil {
+		writeOutput(opts.Stdout, stdout)
+		writeOutput(opts.Stderr, stderr)
+	}
+
+	if err != nil && ctx.Err() != nil {
+		return &PgUpgradeCancelledError{Err: ctx.Err()}
+	}
 
+	return err
+}
+
+// Command returns the path and arguments of the pg_upgrade that Run executes
+// for the given pair of Segments and Options, without running it.
+func Command(p SegmentPair, targetVersion semver.Version, options ...Option) (string, []string) {
+	return newOptionList(options).command(p, targetVersion)
+}
+
+func (opts *optionList) command(p SegmentPair, targetVersion semver.Version) (string, []string) {
 	mode := "dispatcher"
 	if opts.SegmentMode {
 		mode = "segment"
@@ -78,33 +160,54 @@ func Run(p SegmentPair, targetVersion semver.Version, options ...Option) error {
 		args = append(args, "--old-options", opts.OldOptions)
 	}
 
-	// If the caller specified an explicit Command implementation to use, get
-	// our exec.Cmd using that. Otherwise use our internal execCommand.
-	cmdFunc := execCommand
-	if opts.ExecCommandSet {
-		cmdFunc = opts.ExecCommand
+	return path, args
+}
+
+// PrimaryOptions returns the Options that configure pg_upgrade for a primary
+// segment. Callers using link mode should first check the data directories
+// with VerifyLinkMode.
+func PrimaryOptions(checkOnly, useLinkMode bool, tablespacesMappingFile string) []Option {
+	options := []Option{WithSegmentMode()}
+
+	if checkOnly {
+		options = append(options, WithCheckOnly())
+	} else {
+		// During gpupgrade execute, tablepace mapping file is copied after
+		// the master has been upgraded. So, don't pass this option during
+		// --check mode. There is no test in pg_upgrade which depends on the
+		// existence of this file.
+		options = append(options, WithTablespaceFile(tablespacesMappingFile))
+	}
+
+	if useLinkMode {
+		options = append(options, WithLinkMode())
 	}
-	cmd := cmdFunc(path, args...)
 
-	cmd.Dir = opts.Dir
-	cmd.Stdout = opts.Stdout
-	cmd.Stderr = opts.Stderr
+	return options
+}
 
+// pgUpgradeEnv returns the environment pg_upgrade is run with.
+func pgUpgradeEnv() []string {
 	// Explicitly clear the child environment. pg_upgrade shouldn't need things
 	// like PATH, and PGPORT et al are explicitly forbidden to be set.
-	cmd.Env = []string{}
-
+	//
 	// XXX Use this environment variable to tell pg_upgrade to include timing information
 	// in its returned output.  We would prefer to use the flag `--print-timing` but that
 	// would require coordination with a new release of GPDB-6 as well as a bump in the
 	// minimum version of GPDB-6 required for gpupgrade.  Once we have bumped the minimum
 	// version of GPDB-6 past a release that includes the new print-timing code, we can
 	// migrate to the flag.  See https://github.com/greenplum-db/gpdb/pull/10661.
-	cmd.Env = append(cmd.Env, "__GPDB_PGUPGRADE_PRINT_TIMING__=1")
+	return []string{"__GPDB_PGUPGRADE_PRINT_TIMING__=1"}
+}
 
-	gplog.Info(cmd.String())
+func writeOutput(w io.Writer, output []byte) {
+	if w == nil || len(output) == 0 {
+		return
+	}
 
-	return cmd.Run()
+	if _, err := w.Write(output); err != nil {
+		gplog.Warn("writing pg_upgrade output: %v", err)
+	}
 }
 
 // Option configures the way Run executes pg_upgrade.
@@ -162,6 +265,16 @@ func WithExecCommand(execCommand func(string, ...string) *exec.Cmd) Option {
 	}
 }
 
+// WithCommandRunner tells Run to execute pg_upgrade with the provided Runner,
+// so that callers may test pg_upgrade invocations without the real binary. The
+// Runner's output is written to the streams set by WithOutputStreams once
+// pg_upgrade exits. A nil Runner is ignored.
+func WithCommandRunner(runner command.Runner) Option {
+	return func(o *optionList) {
+		o.Runner = runner
+	}
+}
+
 // WithTablespaceFile configures the tablespace mapping file path passed to pg_upgrade
 // to perform the upgrade of the segment tablespaces.
 func WithTablespaceFile(filePath string) Option {
@@ -186,6 +299,7 @@ type optionList struct {
 	UseLinkMode        bool
 	ExecCommand        func(string, ...string) *exec.Cmd
 	ExecCommandSet     bool // was ExecCommand explicitly set?
+	Runner             command.Runner
 	SegmentMode        bool
 	Stdout, Stderr     io.Writer
 	TablespaceFilePath string
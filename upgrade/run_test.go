@@ -6,19 +6,23 @@ package upgrade_test
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/blang/semver/v4"
 
 	"github.com/greenplum-db/gpupgrade/testutils"
 	"github.com/greenplum-db/gpupgrade/testutils/exectest"
+	"github.com/greenplum-db/gpupgrade/testutils/fakerunner"
 	"github.com/greenplum-db/gpupgrade/testutils/testlog"
 	"github.com/greenplum-db/gpupgrade/upgrade"
 )
@@ -239,6 +243,45 @@ func TestRun(t *testing.T) {
 		}
 	})
 
+	t.Run("returns a cancellation error when pg_upgrade is stopped", func(t *testing.T) {
+		runner := fakerunner.New(fakerunner.Result{Err: errors.New("signal: terminated"), Block: true})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+		}()
+
+		err := upgrade.RunContext(ctx, pair, version, upgrade.WithCommandRunner(runner))
+
+		var cancelledErr *upgrade.PgUpgradeCancelledError
+		if !errors.As(err, &cancelledErr) {
+			t.Fatalf("got error %#v want type %T", err, cancelledErr)
+		}
+
+		if !errors.Is(err, upgrade.ErrPgUpgradeCancelled) {
+			t.Errorf("expected error %#v to match %v", err, upgrade.ErrPgUpgradeCancelled)
+		}
+
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected error %#v to match %v", err, context.Canceled)
+		}
+	})
+
+	t.Run("does not report a pg_upgrade failure as a cancellation", func(t *testing.T) {
+		expected := errors.New("exit status 1")
+		runner := fakerunner.New(fakerunner.Result{Err: expected})
+
+		err := upgrade.RunContext(context.Background(), pair, version, upgrade.WithCommandRunner(runner))
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+
+		if errors.Is(err, upgrade.ErrPgUpgradeCancelled) {
+			t.Errorf("expected error %#v not to match %v", err, upgrade.ErrPgUpgradeCancelled)
+		}
+	})
+
 	t.Run("calls pg_upgrade with the correct arguments for", func(t *testing.T) {
 		argsTest := func(t *testing.T, targetVersion semver.Version, opts ...upgrade.Option) {
 			t.Helper()
@@ -371,3 +414,49 @@ func TestRun(t *testing.T) {
 		}
 	})
 }
+
+func TestCommand(t *testing.T) {
+	pair := upgrade.SegmentPair{
+		Source: &upgrade.Segment{BinDir: "/usr/local/gpdb5/bin", DataDir: "/data/dbfast1/seg1", DBID: 2, Port: 25432},
+		Target: &upgrade.Segment{BinDir: "/usr/local/gpdb6/bin", DataDir: "/data/dbfast1/seg1_123ABC", DBID: 2, Port: 35432},
+	}
+
+	t.Run("returns the pg_upgrade that Run executes", func(t *testing.T) {
+		path, args := upgrade.Command(pair, semver.MustParse("6.15.0"), upgrade.PrimaryOptions(false, true, "/tmp/tablespaces.txt")...)
+
+		if path != "/usr/local/gpdb6/bin/pg_upgrade" {
+			t.Errorf("got path %q want %q", path, "/usr/local/gpdb6/bin/pg_upgrade")
+		}
+
+		expected := []string{
+			"--retain",
+			"--old-bindir", "/usr/local/gpdb5/bin",
+			"--new-bindir", "/usr/local/gpdb6/bin",
+			"--old-datadir", "/data/dbfast1/seg1",
+			"--new-datadir", "/data/dbfast1/seg1_123ABC",
+			"--old-port", "25432",
+			"--new-port", "35432",
+			"--mode", "segment",
+			"--old-gp-dbid", "2",
+			"--new-gp-dbid", "2",
+			"--link",
+			"--old-tablespaces-file", "/tmp/tablespaces.txt",
+		}
+		if !reflect.DeepEqual(args, expected) {
+			t.Errorf("got args %q want %q", args, expected)
+		}
+	})
+
+	t.Run("primaries are checked without the tablespace mapping file", func(t *testing.T) {
+		_, args := upgrade.Command(pair, semver.MustParse("6.15.0"), upgrade.PrimaryOptions(true, false, "/tmp/tablespaces.txt")...)
+
+		joined := strings.Join(args, " ")
+		if !strings.Contains(joined, "--check") {
+			t.Errorf("got args %q want --check", args)
+		}
+
+		if strings.Contains(joined, "--old-tablespaces-file") {
+			t.Errorf("got args %q want no --old-tablespaces-file", args)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/utils/errorlist"
+)
+
+// TablespaceMapping describes a single segment's tablespace as recorded in
+// the tablespace mapping file passed to pg_upgrade.
+type TablespaceMapping struct {
+	Oid  int
+	DbID int
+	Name string
+
+	// UserLocation is the tablespace location in the source cluster.
+	UserLocation string
+
+	// InPlace is true for the system tablespaces stored within the data
+	// directory, which have no separate target tablespace directory.
+	InPlace bool
+
+	// TargetPath is the tablespace directory created for the target cluster,
+	// as computed by TablespacePath. It is empty when InPlace is true.
+	TargetPath string
+}
+
+func NewTablespaceMapping(oid, dbID int, name, userLocation string, inPlace bool, majorVersion uint64, catalogVersion string) TablespaceMapping {
+	mapping := TablespaceMapping{
+		Oid:          oid,
+		DbID:         dbID,
+		Name:         name,
+		UserLocation: userLocation,
+		InPlace:      inPlace,
+	}
+
+	if !inPlace {
+		mapping.TargetPath = TablespacePath(userLocation, dbID, majorVersion, catalogVersion)
+	}
+
+	return mapping
+}
+
+// ParseTablespaceMappingFile parses the tablespace mapping file written by
+// greenplum.TablespaceTuples.Write. Each record is of the form
+// dbid,oid,name,location,userdefined.
+func ParseTablespaceMappingFile(r io.Reader, majorVersion uint64, catalogVersion string) ([]TablespaceMapping, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 5
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, xerrors.Errorf("reading tablespace mapping file: %w", err)
+	}
+
+	var mappings []TablespaceMapping
+	for _, record := range records {
+		dbID, err := strconv.Atoi(record[0])
+		if err != nil {
+			return nil, xerrors.Errorf("parsing dbid in tablespace mapping record %q: %w", record, err)
+		}
+
+		oid, err := strconv.Atoi(record[1])
+		if err != nil {
+			return nil, xerrors.Errorf("parsing oid in tablespace mapping record %q: %w", record, err)
+		}
+
+		userDefined, err := strconv.Atoi(record[4])
+		if err != nil {
+			return nil, xerrors.Errorf("parsing userdefined in tablespace mapping record %q: %w", record, err)
+		}
+
+		mappings = append(mappings, NewTablespaceMapping(oid, dbID, record[2], record[3], userDefined != 1, majorVersion, catalogVersion))
+	}
+
+	return mappings, nil
+}
+
+// TablespaceMappingFileName returns the name of the tablespace mapping file
+// holding the records for a single segment content ID, for clusters whose
+// mapping is split per content.
+func TablespaceMappingFileName(contentID int) string {
+	return fmt.Sprintf("tablespaces_%d.txt", contentID)
+}
+
+// LoadTablespaceMappings parses each per-content tablespace mapping file in
+// dir, as named by TablespaceMappingFileName, using
+// ParseTablespaceMappingFile. The mappings are keyed by content ID. Other
+// entries in dir are ignored. Errors name the file that could not be loaded,
+// and all such files are reported.
+func LoadTablespaceMappings(dir string, majorVersion uint64, catalogVersion string) (map[int][]TablespaceMapping, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tablespace mappings: %w", err)
+	}
+
+	mappings := make(map[int][]TablespaceMapping)
+	var mErr error
+	for _, entry := range entries {
+		contentID, ok := parseTablespaceMappingFileName(entry.Name())
+		if !ok || entry.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		contentMappings, err := loadTablespaceMappingFile(path, majorVersion, catalogVersion)
+		if err != nil {
+			mErr = errorlist.Append(mErr, xerrors.Errorf("loading tablespace mapping file %q: %w", path, err))
+			continue
+		}
+
+		mappings[contentID] = contentMappings
+	}
+
+	if mErr != nil {
+		return nil, mErr
+	}
+
+	return mappings, nil
+}
+
+func loadTablespaceMappingFile(path string, majorVersion uint64, catalogVersion string) ([]TablespaceMapping, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseTablespaceMappingFile(file, majorVersion, catalogVersion)
+}
+
+// parseTablespaceMappingFileName reverses TablespaceMappingFileName.
+func parseTablespaceMappingFileName(name string) (int, bool) {
+	const prefix, suffix = "tablespaces_", ".txt"
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+		return 0, false
+	}
+
+	contentID, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, prefix), suffix))
+	if err != nil {
+		return 0, false
+	}
+
+	return contentID, true
+}
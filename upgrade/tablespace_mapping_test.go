@@ -0,0 +1,144 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/upgrade"
+)
+
+func TestParseTablespaceMappingFile(t *testing.T) {
+	t.Run("parses a mapping file", func(t *testing.T) {
+		contents := `1,1663,pg_default,/data/qddir/demoDataDir-1,0
+1,16386,batch_tablespace,/tmp/batch tablespace/16386,1
+2,16386,batch_tablespace,/tmp/batch tablespace/16386,1
+2,1663,pg_default,/data/dbfast1/demoDataDir0,0
+`
+
+		mappings, err := upgrade.ParseTablespaceMappingFile(strings.NewReader(contents), 6, "301908232")
+		if err != nil {
+			t.Fatalf("unexpected error %#v", err)
+		}
+
+		expected := []upgrade.TablespaceMapping{
+			{Oid: 1663, DbID: 1, Name: "pg_default", UserLocation: "/data/qddir/demoDataDir-1", InPlace: true},
+			{Oid: 16386, DbID: 1, Name: "batch_tablespace", UserLocation: "/tmp/batch tablespace/16386", TargetPath: "/tmp/batch tablespace/16386/1/GPDB_6_301908232"},
+			{Oid: 16386, DbID: 2, Name: "batch_tablespace", UserLocation: "/tmp/batch tablespace/16386", TargetPath: "/tmp/batch tablespace/16386/2/GPDB_6_301908232"},
+			{Oid: 1663, DbID: 2, Name: "pg_default", UserLocation: "/data/dbfast1/demoDataDir0", InPlace: true},
+		}
+
+		if !reflect.DeepEqual(mappings, expected) {
+			t.Errorf("got %+v want %+v", mappings, expected)
+		}
+	})
+
+	t.Run("parses an empty mapping file", func(t *testing.T) {
+		mappings, err := upgrade.ParseTablespaceMappingFile(strings.NewReader(""), 6, "301908232")
+		if err != nil {
+			t.Errorf("unexpected error %#v", err)
+		}
+
+		if len(mappings) != 0 {
+			t.Errorf("got %+v want no mappings", mappings)
+		}
+	})
+
+	errCases := []struct {
+		name     string
+		contents string
+	}{
+		{name: "errors on a record with missing fields", contents: "1,16386,batch_tablespace,/tmp/ts\n"},
+		{name: "errors on a non-numeric dbid", contents: "one,16386,batch_tablespace,/tmp/ts,1\n"},
+		{name: "errors on a non-numeric oid", contents: "1,oid,batch_tablespace,/tmp/ts,1\n"},
+		{name: "errors on a non-numeric userdefined flag", contents: "1,16386,batch_tablespace,/tmp/ts,yes\n"},
+	}
+
+	for _, c := range errCases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := upgrade.ParseTablespaceMappingFile(strings.NewReader(c.contents), 6, "301908232")
+			if err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestLoadTablespaceMappings(t *testing.T) {
+	t.Run("loads the mapping file for each content", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		testutils.MustWriteToFile(t, filepath.Join(dir, upgrade.TablespaceMappingFileName(-1)),
+			"1,16386,batch_tablespace,/tmp/ts/16386,1\n")
+		testutils.MustWriteToFile(t, filepath.Join(dir, upgrade.TablespaceMappingFileName(0)),
+			"2,16386,batch_tablespace,/tmp/ts/16386,1\n2,1663,pg_default,/data/dbfast1/demoDataDir0,0\n")
+		testutils.MustWriteToFile(t, filepath.Join(dir, "tablespaces.txt"), "not,a,mapping\n")
+
+		mappings, err := upgrade.LoadTablespaceMappings(dir, 6, "301908232")
+		if err != nil {
+			t.Fatalf("unexpected error %#v", err)
+		}
+
+		expected := map[int][]upgrade.TablespaceMapping{
+			-1: {
+				{Oid: 16386, DbID: 1, Name: "batch_tablespace", UserLocation: "/tmp/ts/16386", TargetPath: "/tmp/ts/16386/1/GPDB_6_301908232"},
+			},
+			0: {
+				{Oid: 16386, DbID: 2, Name: "batch_tablespace", UserLocation: "/tmp/ts/16386", TargetPath: "/tmp/ts/16386/2/GPDB_6_301908232"},
+				{Oid: 1663, DbID: 2, Name: "pg_default", UserLocation: "/data/dbfast1/demoDataDir0", InPlace: true},
+			},
+		}
+
+		if !reflect.DeepEqual(mappings, expected) {
+			t.Errorf("got %+v want %+v", mappings, expected)
+		}
+	})
+
+	t.Run("names the malformed mapping file", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		testutils.MustWriteToFile(t, filepath.Join(dir, upgrade.TablespaceMappingFileName(-1)),
+			"1,16386,batch_tablespace,/tmp/ts/16386,1\n")
+		testutils.MustWriteToFile(t, filepath.Join(dir, upgrade.TablespaceMappingFileName(0)),
+			"2,16386,batch_tablespace,/tmp/ts/16386,1\n")
+		malformed := filepath.Join(dir, upgrade.TablespaceMappingFileName(1))
+		testutils.MustWriteToFile(t, malformed, "3,oid,batch_tablespace,/tmp/ts/16386,1\n")
+
+		mappings, err := upgrade.LoadTablespaceMappings(dir, 6, "301908232")
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+
+		if mappings != nil {
+			t.Errorf("got mappings %+v want nil", mappings)
+		}
+
+		if !strings.Contains(err.Error(), malformed) {
+			t.Errorf("got error %q want it to name %q", err.Error(), malformed)
+		}
+
+		for _, contentID := range []int{-1, 0} {
+			valid := upgrade.TablespaceMappingFileName(contentID)
+			if strings.Contains(err.Error(), valid) {
+				t.Errorf("got error %q want it not to name %q", err.Error(), valid)
+			}
+		}
+	})
+
+	t.Run("errors when the directory does not exist", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		_, err := upgrade.LoadTablespaceMappings(filepath.Join(dir, "missing"), 6, "301908232")
+		if err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
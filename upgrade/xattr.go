@@ -0,0 +1,95 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade
+
+import (
+	"bytes"
+	"errors"
+	"syscall"
+
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/utils/errorlist"
+)
+
+// copyXattrs copies the extended attributes of src to dst without following
+// symlinks. On Linux POSIX ACLs and SELinux labels are stored as extended
+// attributes, so they are copied as well. A src on a filesystem without
+// extended attributes has none to copy.
+func copyXattrs(src, dst string) error {
+	names, err := listXattrs(src)
+	if errors.Is(err, syscall.ENOTSUP) {
+		return nil
+	}
+
+	if err != nil {
+		return xerrors.Errorf("listing extended attributes of %q: %w", src, err)
+	}
+
+	var mErr error
+	for _, name := range names {
+		value, err := getXattr(src, name)
+		if err != nil {
+			mErr = errorlist.Append(mErr, xerrors.Errorf("reading extended attribute %q of %q: %w", name, src, err))
+			continue
+		}
+
+		if err := lsetxattr(dst, name, value, 0); err != nil {
+			mErr = errorlist.Append(mErr, xerrors.Errorf("setting extended attribute %q of %q: %w", name, dst, err))
+		}
+	}
+
+	return mErr
+}
+
+func listXattrs(path string) ([]string, error) {
+	buf, err := readXattr(func(dest []byte) (int, error) {
+		return llistxattr(path, dest)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, name := range bytes.Split(buf, []byte{0}) {
+		if len(name) > 0 {
+			names = append(names, string(name))
+		}
+	}
+
+	return names, nil
+}
+
+func getXattr(path, name string) ([]byte, error) {
+	return readXattr(func(dest []byte) (int, error) {
+		return lgetxattr(path, name, dest)
+	})
+}
+
+// readXattr calls read first to size the buffer and then to fill it,
+// retrying if the attributes grew in between.
+func readXattr(read func(dest []byte) (int, error)) ([]byte, error) {
+	for {
+		size, err := read(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if size == 0 {
+			return nil, nil
+		}
+
+		buf := make([]byte, size)
+		n, err := read(buf)
+		if errors.Is(err, syscall.ERANGE) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return buf[:n], nil
+	}
+}
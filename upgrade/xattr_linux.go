@@ -0,0 +1,14 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade
+
+import "golang.org/x/sys/unix"
+
+const xattrsSupported = true
+
+var (
+	llistxattr = unix.Llistxattr
+	lgetxattr  = unix.Lgetxattr
+	lsetxattr  = unix.Lsetxattr
+)
@@ -0,0 +1,123 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+	"github.com/greenplum-db/gpupgrade/upgrade"
+)
+
+func TestCopyDirXattrs(t *testing.T) {
+	t.Run("preserves extended attributes", func(t *testing.T) {
+		testlog.SetupLogger()
+
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		source := filepath.Join(dir, "source")
+		if err := os.Mkdir(source, 0700); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		// The file is read-only to ensure its attributes are set before its
+		// permissions are applied.
+		file := filepath.Join(source, "postgresql.conf")
+		testutils.MustWriteToFile(t, file, "port = 15432")
+
+		attrs := map[string]string{
+			source: "directory",
+			file:   "file",
+		}
+		for path, value := range attrs {
+			err := unix.Setxattr(path, "user.gpupgrade", []byte(value), 0)
+			if errors.Is(err, syscall.ENOTSUP) {
+				t.Skipf("extended attributes are not supported in %q", dir)
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %#v", err)
+			}
+		}
+
+		if err := os.Chmod(file, 0400); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		target := filepath.Join(dir, "target")
+		if err := upgrade.CopyDir(source, target, upgrade.CopyOptions{}); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		copies := map[string]string{
+			target:                                   "directory",
+			filepath.Join(target, "postgresql.conf"): "file",
+		}
+		for path, expected := range copies {
+			buf := make([]byte, 64)
+			n, err := unix.Getxattr(path, "user.gpupgrade", buf)
+			if err != nil {
+				t.Fatalf("getting extended attribute of %q: %v", path, err)
+			}
+
+			if string(buf[:n]) != expected {
+				t.Errorf("got extended attribute %q want %q for %q", buf[:n], expected, path)
+			}
+		}
+	})
+
+	t.Run("warns rather than errors when attributes cannot be preserved", func(t *testing.T) {
+		_, _, log := testlog.SetupLogger()
+
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		source := filepath.Join(dir, "source")
+		if err := os.Mkdir(source, 0700); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		testutils.MustWriteToFile(t, filepath.Join(source, "postgresql.conf"), "port = 15432")
+
+		// Report an attribute on every entry that the target's filesystem
+		// does not support.
+		names := "security.selinux\x00"
+		list := func(_ string, dest []byte) (int, error) {
+			if dest == nil {
+				return len(names), nil
+			}
+
+			return copy(dest, names), nil
+		}
+		get := func(_, _ string, dest []byte) (int, error) {
+			if dest == nil {
+				return 1, nil
+			}
+
+			return copy(dest, "x"), nil
+		}
+		set := func(string, string, []byte, int) error {
+			return syscall.ENOTSUP
+		}
+
+		upgrade.SetXattrFuncs(list, get, set)
+		defer upgrade.SetXattrFuncs(unix.Llistxattr, unix.Lgetxattr, unix.Lsetxattr)
+
+		target := filepath.Join(dir, "target")
+		if err := upgrade.CopyDir(source, target, upgrade.CopyOptions{}); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		testutils.MustReadFile(t, filepath.Join(target, "postgresql.conf"))
+		testlog.VerifyLogContains(t, log, "[WARNING]")
+		testlog.VerifyLogContains(t, log, "could not preserve the extended attributes or ACLs of 2 entries")
+	})
+}
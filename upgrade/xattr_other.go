@@ -0,0 +1,18 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+// +build !linux
+
+package upgrade
+
+import "syscall"
+
+// Extended attributes are only copied on Linux. Elsewhere copyTree warns that
+// they are not preserved.
+const xattrsSupported = false
+
+var (
+	llistxattr = func(string, []byte) (int, error) { return 0, syscall.ENOTSUP }
+	lgetxattr  = func(string, string, []byte) (int, error) { return 0, syscall.ENOTSUP }
+	lsetxattr  = func(string, string, []byte, int) error { return syscall.ENOTSUP }
+)
@@ -0,0 +1,137 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+// Package certs loads the certificates used to secure traffic between the hub
+// and its agents.
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"path/filepath"
+
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/greenplum-db/gpupgrade/utils"
+)
+
+// Dir is the directory within the state directory containing the default
+// certificates.
+const Dir = "tls"
+
+// Config locates the certificate, private key, and certificate authority used
+// for TLS.
+type Config struct {
+	CertFile string
+	KeyFile  string
+	CAFile   string
+
+	// VerifyClient enables mutual TLS: agents require the hub to present a
+	// certificate signed by CAFile.
+	VerifyClient bool
+}
+
+// StateDirConfig returns the Config for the default certificates in the given
+// state directory.
+func StateDirConfig(stateDir string) Config {
+	dir := filepath.Join(stateDir, Dir)
+
+	return Config{
+		CertFile: filepath.Join(dir, "cert.pem"),
+		KeyFile:  filepath.Join(dir, "key.pem"),
+		CAFile:   filepath.Join(dir, "ca.pem"),
+	}
+}
+
+// ServerCredentials returns the credentials an agent serves with. Clients
+// must present a certificate signed by CAFile when VerifyClient is set.
+func ServerCredentials(c Config) (credentials.TransportCredentials, error) {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, xerrors.Errorf("loading TLS certificate %q and key %q: %w", c.CertFile, c.KeyFile, err)
+	}
+
+	conf := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	if c.VerifyClient {
+		pool, err := loadCA(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		conf.ClientCAs = pool
+		conf.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return credentials.NewTLS(conf), nil
+}
+
+// ClientCredentials returns the credentials the hub dials agents with. The
+// agent's certificate must be signed by CAFile. The hub presents its own
+// certificate when CertFile is set, as required when agents verify clients.
+func ClientCredentials(c Config) (credentials.TransportCredentials, error) {
+	pool, err := loadCA(c.CAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := &tls.Config{RootCAs: pool}
+
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, xerrors.Errorf("loading TLS certificate %q and key %q: %w", c.CertFile, c.KeyFile, err)
+		}
+
+		conf.Certificates = []tls.Certificate{cert}
+	}
+
+	return credentials.NewTLS(conf), nil
+}
+
+// DialOption returns the grpc.DialOption for dialing agents. A nil Config
+// dials without TLS.
+func DialOption(c *Config) (grpc.DialOption, error) {
+	if c == nil {
+		return grpc.WithInsecure(), nil
+	}
+
+	creds, err := ClientCredentials(*c)
+	if err != nil {
+		return nil, err
+	}
+
+	return grpc.WithTransportCredentials(creds), nil
+}
+
+// AgentArgs returns the arguments that start an agent with TLS matching the
+// hub's Config. Agents use the default certificates in their state
+// directory. A nil Config returns no arguments.
+func AgentArgs(c *Config) string {
+	if c == nil {
+		return ""
+	}
+
+	args := " --tls"
+	if c.VerifyClient {
+		args += " --tls-verify-client"
+	}
+
+	return args
+}
+
+func loadCA(path string) (*x509.CertPool, error) {
+	contents, err := utils.System.ReadFile(path)
+	if err != nil {
+		return nil, xerrors.Errorf("loading TLS certificate authority: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(contents) {
+		return nil, xerrors.Errorf("loading TLS certificate authority %q: no PEM certificates found", path)
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package certs_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/utils/certs"
+)
+
+func TestStateDirConfig(t *testing.T) {
+	conf := certs.StateDirConfig("/home/gpadmin/.gpupgrade")
+
+	expected := certs.Config{
+		CertFile: "/home/gpadmin/.gpupgrade/tls/cert.pem",
+		KeyFile:  "/home/gpadmin/.gpupgrade/tls/key.pem",
+		CAFile:   "/home/gpadmin/.gpupgrade/tls/ca.pem",
+	}
+	if conf != expected {
+		t.Errorf("got %+v want %+v", conf, expected)
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	t.Run("loads server and client credentials", func(t *testing.T) {
+		stateDir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, stateDir)
+
+		conf := testutils.MustWriteCerts(t, stateDir)
+		conf.VerifyClient = true
+
+		if _, err := certs.ServerCredentials(conf); err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+
+		if _, err := certs.ClientCredentials(conf); err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+	})
+
+	t.Run("errors when files are missing", func(t *testing.T) {
+		stateDir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, stateDir)
+
+		conf := certs.StateDirConfig(stateDir)
+		conf.VerifyClient = true
+
+		if _, err := certs.ServerCredentials(conf); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("got error %#v want %#v", err, os.ErrNotExist)
+		}
+
+		if _, err := certs.ClientCredentials(conf); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("got error %#v want %#v", err, os.ErrNotExist)
+		}
+	})
+
+	t.Run("errors when the certificate authority contains no certificates", func(t *testing.T) {
+		stateDir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, stateDir)
+
+		conf := testutils.MustWriteCerts(t, stateDir)
+		conf.VerifyClient = true
+		testutils.MustWriteToFile(t, conf.CAFile, "not a certificate")
+
+		if _, err := certs.ServerCredentials(conf); err == nil {
+			t.Errorf("expected an error")
+		}
+
+		if _, err := certs.ClientCredentials(conf); err == nil {
+			t.Errorf("expected an error")
+		}
+	})
+
+	t.Run("does not require a certificate authority for servers that do not verify clients", func(t *testing.T) {
+		stateDir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, stateDir)
+
+		conf := testutils.MustWriteCerts(t, stateDir)
+		conf.CAFile = filepath.Join(stateDir, "does-not-exist")
+
+		if _, err := certs.ServerCredentials(conf); err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+	})
+}
+
+func TestAgentArgs(t *testing.T) {
+	cases := []struct {
+		name     string
+		conf     *certs.Config
+		expected string
+	}{
+		{"plaintext", nil, ""},
+		{"TLS", &certs.Config{}, " --tls"},
+		{"mutual TLS", &certs.Config{VerifyClient: true}, " --tls --tls-verify-client"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if args := certs.AgentArgs(c.conf); args != c.expected {
+				t.Errorf("got %q want %q", args, c.expected)
+			}
+		})
+	}
+}
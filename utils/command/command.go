@@ -0,0 +1,117 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+// Package command abstracts running external commands such as pg_upgrade so
+// that code shelling out to them can be unit tested without the real binaries.
+package command
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// DefaultGracePeriod is how long Exec waits for a command to exit after
+// asking it to terminate before killing it.
+const DefaultGracePeriod = 10 * time.Second
+
+// Runner runs the named command with the given arguments, returning its
+// output once it exits.
+type Runner interface {
+	Run(ctx context.Context, name string, args ...string) (stdout, stderr []byte, err error)
+}
+
+// Exec is a Runner that executes commands with os/exec. The zero value runs
+// commands in the current working directory with the current environment.
+type Exec struct {
+	// Dir is the working directory of the command. When empty the current
+	// working directory is used.
+	Dir string
+
+	// Env is the environment of the command. When nil the current environment
+	// is used.
+	Env []string
+
+	// Stdout and Stderr, when set, receive the output of the command as it
+	// runs in addition to it being returned by Run.
+	Stdout, Stderr io.Writer
+
+	// Command creates the exec.Cmd to run, allowing exectest.NewCommand to be
+	// used. When nil exec.Command is used.
+	Command func(name string, args ...string) *exec.Cmd
+
+	// GracePeriod is how long a command is given to exit after being sent
+	// SIGTERM, once the context is done, before it is sent SIGKILL. When zero
+	// DefaultGracePeriod is used.
+	GracePeriod time.Duration
+}
+
+// Run runs the command until it exits. If ctx is done first the command is
+// sent SIGTERM, so that it can clean up after itself, and then SIGKILL if it
+// has not exited within the grace period. The error is then that of the
+// stopped command, so callers should check ctx.Err() to tell whether it was
+// cancelled.
+func (e *Exec) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	var cmd *exec.Cmd
+	if e.Command != nil {
+		cmd = e.Command(name, args...)
+	} else {
+		cmd = exec.Command(name, args...)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = tee(&stdout, e.Stdout)
+	cmd.Stderr = tee(&stderr, e.Stderr)
+	cmd.Dir = e.Dir
+	cmd.Env = e.Env
+
+	if err := cmd.Start(); err != nil {
+		return stdout.Bytes(), stderr.Bytes(), err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = e.terminate(cmd, done)
+	}
+
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+// terminate stops the running cmd, returning the error from its Wait.
+func (e *Exec) terminate(cmd *exec.Cmd, done <-chan error) error {
+	grace := e.GracePeriod
+	if grace == 0 {
+		grace = DefaultGracePeriod
+	}
+
+	// Errors signalling are ignored since the process may have just exited,
+	// in which case done is ready.
+	_ = cmd.Process.Signal(syscall.SIGTERM)
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(grace):
+	}
+
+	_ = cmd.Process.Kill()
+	return <-done
+}
+
+func tee(buf *bytes.Buffer, w io.Writer) io.Writer {
+	if w == nil {
+		return buf
+	}
+
+	return io.MultiWriter(buf, w)
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package command_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/greenplum-db/gpupgrade/utils/command"
+)
+
+func TestExec(t *testing.T) {
+	t.Run("returns and streams the output of the command", func(t *testing.T) {
+		var streamed bytes.Buffer
+		runner := &command.Exec{Dir: "/", Env: []string{"GREETING=hello"}, Stdout: &streamed}
+
+		stdout, stderr, err := runner.Run(context.Background(), "bash", "-c", `echo "$GREETING from $PWD"; echo oops >&2`)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if string(stdout) != "hello from /\n" {
+			t.Errorf("got stdout %q want %q", stdout, "hello from /\n")
+		}
+
+		if string(stderr) != "oops\n" {
+			t.Errorf("got stderr %q want %q", stderr, "oops\n")
+		}
+
+		if streamed.String() != string(stdout) {
+			t.Errorf("got streamed stdout %q want %q", streamed.String(), stdout)
+		}
+	})
+
+	t.Run("returns the exit error of the command", func(t *testing.T) {
+		runner := &command.Exec{}
+
+		_, _, err := runner.Run(context.Background(), "false")
+
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			t.Errorf("got error %#v want type %T", err, exitErr)
+		}
+	})
+
+	t.Run("uses the provided Command", func(t *testing.T) {
+		var called []string
+		runner := &command.Exec{Command: func(name string, args ...string) *exec.Cmd {
+			called = append([]string{name}, args...)
+			return exec.Command("true")
+		}}
+
+		_, _, err := runner.Run(context.Background(), "pg_upgrade", "--check")
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if strings.Join(called, " ") != "pg_upgrade --check" {
+			t.Errorf("got command %q want %q", called, "pg_upgrade --check")
+		}
+	})
+
+	t.Run("terminates the command when the context is cancelled", func(t *testing.T) {
+		ready := newReadyWriter()
+		runner := &command.Exec{Stdout: ready}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go ready.cancelWhenReady(cancel)
+
+		stdout, _, err := runner.Run(ctx, "bash", "-c", `trap 'echo terminated; exit 3' TERM; echo ready; while true; do sleep 0.01; done`)
+
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			t.Fatalf("got error %#v want type %T", err, exitErr)
+		}
+
+		if exitErr.ExitCode() != 3 {
+			t.Errorf("got exit code %d want 3", exitErr.ExitCode())
+		}
+
+		if string(stdout) != "ready\nterminated\n" {
+			t.Errorf("got stdout %q want %q", stdout, "ready\nterminated\n")
+		}
+	})
+
+	t.Run("kills the command when it does not exit within the grace period", func(t *testing.T) {
+		ready := newReadyWriter()
+		runner := &command.Exec{Stdout: ready, GracePeriod: 10 * time.Millisecond}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go ready.cancelWhenReady(cancel)
+
+		_, _, err := runner.Run(ctx, "bash", "-c", `trap '' TERM; echo ready; while true; do :; done`)
+
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			t.Fatalf("got error %#v want type %T", err, exitErr)
+		}
+
+		status := exitErr.Sys().(syscall.WaitStatus)
+		if !status.Signaled() || status.Signal() != syscall.SIGKILL {
+			t.Errorf("got wait status %v want the command to be killed", status)
+		}
+	})
+}
+
+// readyWriter notes the first write, which the commands above make once
+// their signal handling is in place.
+type readyWriter struct {
+	ready chan struct{}
+	once  sync.Once
+}
+
+func newReadyWriter() *readyWriter {
+	return &readyWriter{ready: make(chan struct{})}
+}
+
+func (r *readyWriter) Write(p []byte) (int, error) {
+	r.once.Do(func() { close(r.ready) })
+	return len(p), nil
+}
+
+func (r *readyWriter) cancelWhenReady(cancel context.CancelFunc) {
+	<-r.ready
+	cancel()
+}
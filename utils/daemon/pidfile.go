@@ -0,0 +1,179 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/greenplum-db/gpupgrade/utils"
+)
+
+// PIDFileName is the name of the file within the state directory recording
+// the PID of a daemonized agent.
+const PIDFileName = "agent.pid"
+
+// ErrAlreadyRunning is returned by WritePIDFile when the PID file is owned by
+// a live process.
+var ErrAlreadyRunning = errors.New("already running")
+
+// AlreadyRunningError is the backing error type for ErrAlreadyRunning.
+type AlreadyRunningError struct {
+	Path string
+	PID  int
+}
+
+func (a *AlreadyRunningError) Error() string {
+	return fmt.Sprintf("process %d is already running according to %q", a.PID, a.Path)
+}
+
+func (a *AlreadyRunningError) Is(err error) bool {
+	return err == ErrAlreadyRunning
+}
+
+func pidFilePath(statedir string) string {
+	return filepath.Join(statedir, PIDFileName)
+}
+
+// ReadPIDFile returns the PID recorded in the PID file under statedir.
+func ReadPIDFile(statedir string) (int, error) {
+	path := pidFilePath(statedir)
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(contents)))
+	if err != nil {
+		return 0, fmt.Errorf("parsing PID file %q: %w", path, err)
+	}
+
+	return pid, nil
+}
+
+// RunningPID returns the PID recorded in the PID file under statedir if that
+// process is still running, and zero otherwise.
+func RunningPID(statedir string) int {
+	pid, err := ReadPIDFile(statedir)
+	if err != nil || !processExists(pid) {
+		return 0
+	}
+
+	return pid
+}
+
+// WritePIDFile records the current process in the PID file under statedir.
+// It returns an AlreadyRunningError if another live process owns the file. A
+// stale or unreadable PID file is overwritten.
+func WritePIDFile(statedir string) error {
+	path := pidFilePath(statedir)
+
+	pid, err := ReadPIDFile(statedir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) && !errors.Is(err, strconv.ErrSyntax) {
+		return err
+	}
+
+	if err == nil && pid != os.Getpid() && processExists(pid) {
+		return &AlreadyRunningError{Path: path, PID: pid}
+	}
+
+	// Write atomically so that a reader never sees a partially written PID.
+	return utils.AtomicWriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0600)
+}
+
+// RemovePIDFile removes the PID file under statedir if it is owned by the
+// current process.
+func RemovePIDFile(statedir string) error {
+	pid, err := ReadPIDFile(statedir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if pid != os.Getpid() {
+		return nil
+	}
+
+	return os.Remove(pidFilePath(statedir))
+}
+
+// ErrStopTimeout is returned by StopPIDFileProcess when the process does not
+// exit in time.
+var ErrStopTimeout = errors.New("timed out waiting for process to exit")
+
+// StopTimeoutError is the backing error type for ErrStopTimeout.
+type StopTimeoutError struct {
+	PID     int
+	Timeout time.Duration
+}
+
+func (s *StopTimeoutError) Error() string {
+	return fmt.Sprintf("process %d did not exit within %s after SIGTERM; it may be stuck and need to be killed manually", s.PID, s.Timeout)
+}
+
+func (s *StopTimeoutError) Is(err error) bool {
+	return err == ErrStopTimeout
+}
+
+// stopPollInterval is how often StopPIDFileProcess checks whether the process
+// has exited.
+var stopPollInterval = 100 * time.Millisecond
+
+// StopPIDFileProcess sends SIGTERM to the process recorded in the PID file
+// under statedir and waits up to timeout for it to exit. It returns the PID
+// that was stopped, or zero if no live process owns the PID file.
+func StopPIDFileProcess(statedir string, timeout time.Duration) (int, error) {
+	pid, err := ReadPIDFile(statedir)
+	if errors.Is(err, os.ErrNotExist) || errors.Is(err, strconv.ErrSyntax) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !processExists(pid) {
+		return 0, nil
+	}
+
+	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+		if errors.Is(err, syscall.ESRCH) {
+			return 0, nil
+		}
+
+		return 0, fmt.Errorf("stopping process %d: %w", pid, err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for processExists(pid) {
+		if time.Now().After(deadline) {
+			return pid, &StopTimeoutError{PID: pid, Timeout: timeout}
+		}
+
+		time.Sleep(stopPollInterval)
+	}
+
+	return pid, nil
+}
+
+// processExists returns whether pid refers to a live process. A process owned
+// by another user still exists even though it cannot be signaled.
+func processExists(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
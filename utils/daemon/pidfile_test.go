@@ -0,0 +1,315 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package daemon
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPIDFile(t *testing.T) {
+	tempDir := func(t *testing.T) string {
+		t.Helper()
+
+		dir, err := ioutil.TempDir("", "pidfile")
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		return dir
+	}
+
+	writePID := func(t *testing.T, dir string, pid int) {
+		t.Helper()
+
+		err := ioutil.WriteFile(filepath.Join(dir, PIDFileName), []byte(strconv.Itoa(pid)+"\n"), 0600)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	}
+
+	// exitedPID returns the PID of a process that has already exited.
+	exitedPID := func(t *testing.T) int {
+		t.Helper()
+
+		cmd := exec.Command("true")
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		return cmd.Process.Pid
+	}
+
+	assertPID := func(t *testing.T, dir string, expected int) {
+		t.Helper()
+
+		pid, err := ReadPIDFile(dir)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if pid != expected {
+			t.Errorf("got pid %d want %d", pid, expected)
+		}
+	}
+
+	t.Run("writes the current PID", func(t *testing.T) {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+
+		err := WritePIDFile(dir)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		assertPID(t, dir, os.Getpid())
+	})
+
+	t.Run("overwrites a stale PID file", func(t *testing.T) {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+
+		writePID(t, dir, exitedPID(t))
+
+		err := WritePIDFile(dir)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		assertPID(t, dir, os.Getpid())
+	})
+
+	t.Run("overwrites an unparseable PID file", func(t *testing.T) {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+
+		err := ioutil.WriteFile(filepath.Join(dir, PIDFileName), []byte("garbage"), 0600)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		err = WritePIDFile(dir)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		assertPID(t, dir, os.Getpid())
+	})
+
+	t.Run("refuses to start when a live process owns the PID file", func(t *testing.T) {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+
+		writePID(t, dir, os.Getppid())
+
+		err := WritePIDFile(dir)
+
+		var runningErr *AlreadyRunningError
+		if !errors.As(err, &runningErr) {
+			t.Fatalf("got error %#v want type %T", err, runningErr)
+		}
+
+		if !errors.Is(err, ErrAlreadyRunning) {
+			t.Errorf("got error %#v want %#v", err, ErrAlreadyRunning)
+		}
+
+		if runningErr.PID != os.Getppid() {
+			t.Errorf("got pid %d want %d", runningErr.PID, os.Getppid())
+		}
+
+		assertPID(t, dir, os.Getppid())
+	})
+
+	t.Run("ReadPIDFile errors when there is no PID file", func(t *testing.T) {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+
+		_, err := ReadPIDFile(dir)
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("got error %#v want %#v", err, os.ErrNotExist)
+		}
+	})
+
+	t.Run("RunningPID returns the PID of a live process", func(t *testing.T) {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+
+		writePID(t, dir, os.Getppid())
+
+		if pid := RunningPID(dir); pid != os.Getppid() {
+			t.Errorf("got pid %d want %d", pid, os.Getppid())
+		}
+	})
+
+	t.Run("RunningPID returns zero for a stale or missing PID file", func(t *testing.T) {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+
+		if pid := RunningPID(dir); pid != 0 {
+			t.Errorf("got pid %d want 0", pid)
+		}
+
+		writePID(t, dir, exitedPID(t))
+
+		if pid := RunningPID(dir); pid != 0 {
+			t.Errorf("got pid %d want 0", pid)
+		}
+	})
+
+	t.Run("RemovePIDFile removes a PID file owned by the current process", func(t *testing.T) {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+
+		writePID(t, dir, os.Getpid())
+
+		err := RemovePIDFile(dir)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		_, err = os.Stat(filepath.Join(dir, PIDFileName))
+		if !os.IsNotExist(err) {
+			t.Errorf("expected PID file to be removed, got %#v", err)
+		}
+	})
+
+	t.Run("RemovePIDFile leaves a PID file owned by another process", func(t *testing.T) {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+
+		writePID(t, dir, os.Getppid())
+
+		err := RemovePIDFile(dir)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		assertPID(t, dir, os.Getppid())
+	})
+
+	t.Run("RemovePIDFile succeeds when there is no PID file", func(t *testing.T) {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+
+		err := RemovePIDFile(dir)
+		if err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+	})
+
+	// startProcess starts a long running shell script and reaps it in the
+	// background so that it does not linger as a zombie once it exits.
+	startProcess := func(t *testing.T, script string) (*exec.Cmd, <-chan struct{}) {
+		t.Helper()
+
+		cmd := exec.Command("sh", "-c", script)
+		if err := cmd.Start(); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		done := make(chan struct{})
+		go func() {
+			_ = cmd.Wait()
+			close(done)
+		}()
+
+		return cmd, done
+	}
+
+	t.Run("StopPIDFileProcess stops nothing when there is no PID file", func(t *testing.T) {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+
+		pid, err := StopPIDFileProcess(dir, time.Second)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if pid != 0 {
+			t.Errorf("got pid %d want 0", pid)
+		}
+	})
+
+	t.Run("StopPIDFileProcess stops nothing when the PID file is stale", func(t *testing.T) {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+
+		writePID(t, dir, exitedPID(t))
+
+		pid, err := StopPIDFileProcess(dir, time.Second)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if pid != 0 {
+			t.Errorf("got pid %d want 0", pid)
+		}
+	})
+
+	t.Run("StopPIDFileProcess terminates the running process", func(t *testing.T) {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+
+		cmd, done := startProcess(t, "exec sleep 30")
+		writePID(t, dir, cmd.Process.Pid)
+
+		// Reaping happens in the background, so the process may briefly
+		// outlive the signal as a zombie.
+		pid, err := StopPIDFileProcess(dir, 5*time.Second)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if pid != cmd.Process.Pid {
+			t.Errorf("got pid %d want %d", pid, cmd.Process.Pid)
+		}
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Errorf("expected process %d to have exited", pid)
+		}
+	})
+
+	t.Run("StopPIDFileProcess times out when the process ignores SIGTERM", func(t *testing.T) {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+
+		cmd, done := startProcess(t, `trap "" TERM; while true; do sleep 1; done`)
+		defer func() {
+			_ = cmd.Process.Kill()
+			<-done
+		}()
+		writePID(t, dir, cmd.Process.Pid)
+
+		// Give the shell time to install its trap.
+		time.Sleep(100 * time.Millisecond)
+
+		timeout := 200 * time.Millisecond
+		pid, err := StopPIDFileProcess(dir, timeout)
+
+		var timeoutErr *StopTimeoutError
+		if !errors.As(err, &timeoutErr) {
+			t.Fatalf("got error %#v want type %T", err, timeoutErr)
+		}
+
+		if !errors.Is(err, ErrStopTimeout) {
+			t.Errorf("got error %#v want %#v", err, ErrStopTimeout)
+		}
+
+		if pid != cmd.Process.Pid || timeoutErr.PID != cmd.Process.Pid {
+			t.Errorf("got pid %d want %d", timeoutErr.PID, cmd.Process.Pid)
+		}
+
+		if timeoutErr.Timeout != timeout {
+			t.Errorf("got timeout %s want %s", timeoutErr.Timeout, timeout)
+		}
+	})
+}
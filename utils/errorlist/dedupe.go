@@ -0,0 +1,145 @@
+// Copyright (c) 2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package errorlist
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// HostError associates an error with the host it occurred on, so that
+// failures aggregated from many hosts can be grouped by host, and so that
+// Dedupe can report the hosts affected by a collapsed error.
+type HostError struct {
+	Host string
+	Err  error
+}
+
+func (h *HostError) Error() string {
+	return fmt.Sprintf("host %s: %s", h.Host, h.Err)
+}
+
+func (h *HostError) Unwrap() error {
+	return h.Err
+}
+
+// WithHost annotates err with the host it occurred on. Each entry of an Errors
+// list is annotated individually so the list can still be grouped by host. A
+// nil err is returned unchanged.
+func WithHost(host string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	errs, ok := err.(Errors)
+	if !ok {
+		return &HostError{Host: host, Err: err}
+	}
+
+	var result error
+	for _, e := range errs {
+		result = Append(result, &HostError{Host: host, Err: e})
+	}
+
+	return result
+}
+
+// DuplicateError is a single entry standing in for errors that Dedupe found
+// to be identical. Err is the first such error.
+type DuplicateError struct {
+	Err   error
+	Count int
+	Hosts []string
+}
+
+func (d *DuplicateError) Error() string {
+	msg := d.Err.Error()
+
+	var host *HostError
+	if errors.As(d.Err, &host) {
+		msg = host.Err.Error()
+	}
+
+	if len(d.Hosts) == 0 {
+		return fmt.Sprintf("%s (occurred %d times)", msg, d.Count)
+	}
+
+	return fmt.Sprintf("%s (occurred %d times on hosts %s)", msg, d.Count, strings.Join(d.Hosts, ", "))
+}
+
+func (d *DuplicateError) Unwrap() error {
+	return d.Err
+}
+
+// Dedupe collapses errors in an Errors list that share a key into a single
+// DuplicateError recording the number of occurrences and the hosts affected.
+// Entries appear in the order of their first occurrence. If key is nil errors
+// are keyed by their message, ignoring any HostError annotation. Errors that
+// are not an Errors list are returned unchanged.
+func Dedupe(err error, key func(error) string) error {
+	errs, ok := err.(Errors)
+	if !ok {
+		return err
+	}
+
+	if key == nil {
+		key = message
+	}
+
+	var keys []string
+	dupes := make(map[string]*DuplicateError)
+	for _, e := range errs {
+		k := key(e)
+
+		dupe, ok := dupes[k]
+		if !ok {
+			dupe = &DuplicateError{Err: e}
+			dupes[k] = dupe
+			keys = append(keys, k)
+		}
+
+		dupe.Count++
+
+		var host *HostError
+		if errors.As(e, &host) && !contains(dupe.Hosts, host.Host) {
+			dupe.Hosts = append(dupe.Hosts, host.Host)
+		}
+	}
+
+	var result error
+	for _, k := range keys {
+		dupe := dupes[k]
+		sort.Strings(dupe.Hosts)
+
+		if dupe.Count == 1 {
+			result = Append(result, dupe.Err)
+			continue
+		}
+
+		result = Append(result, dupe)
+	}
+
+	return result
+}
+
+func message(err error) string {
+	var host *HostError
+	if errors.As(err, &host) {
+		return host.Err.Error()
+	}
+
+	return err.Error()
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package errorlist_test
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/greenplum-db/gpupgrade/utils/errorlist"
+)
+
+func TestDedupe(t *testing.T) {
+	t.Run("returns errors that are not a list unchanged", func(t *testing.T) {
+		expected := errors.New("ahhh")
+
+		if err := errorlist.Dedupe(expected, nil); err != expected {
+			t.Errorf("got %#v want %#v", err, expected)
+		}
+
+		if err := errorlist.Dedupe(nil, nil); err != nil {
+			t.Errorf("got %#v want nil", err)
+		}
+	})
+
+	t.Run("leaves distinct errors alone", func(t *testing.T) {
+		expected := errorlist.Errors{errors.New("ahhh"), errors.New("it broke")}
+
+		err := errorlist.Dedupe(expected, nil)
+		if !reflect.DeepEqual(err, expected) {
+			t.Errorf("got %#v want %#v", err, expected)
+		}
+	})
+
+	t.Run("collapses identical errors and records the hosts affected", func(t *testing.T) {
+		denied := errors.New("permission denied")
+		broke := errors.New("it broke")
+
+		err := errorlist.Append(
+			&errorlist.HostError{Host: "sdw2", Err: denied},
+			broke,
+			&errorlist.HostError{Host: "sdw1", Err: errors.New("permission denied")},
+			&errorlist.HostError{Host: "sdw2", Err: denied},
+		)
+
+		actual := errorlist.Dedupe(err, nil)
+
+		var errs errorlist.Errors
+		if !errors.As(actual, &errs) {
+			t.Fatalf("got %#v want type %T", actual, errs)
+		}
+
+		if len(errs) != 2 {
+			t.Fatalf("got %d errors want 2: %v", len(errs), errs)
+		}
+
+		var dupe *errorlist.DuplicateError
+		if !errors.As(errs[0], &dupe) {
+			t.Fatalf("got %#v want type %T", errs[0], dupe)
+		}
+
+		if dupe.Count != 3 {
+			t.Errorf("got count %d want 3", dupe.Count)
+		}
+
+		if !reflect.DeepEqual(dupe.Hosts, []string{"sdw1", "sdw2"}) {
+			t.Errorf("got hosts %q want %q", dupe.Hosts, []string{"sdw1", "sdw2"})
+		}
+
+		expected := "permission denied (occurred 3 times on hosts sdw1, sdw2)"
+		if dupe.Error() != expected {
+			t.Errorf("got %q want %q", dupe.Error(), expected)
+		}
+
+		if errs[1] != broke {
+			t.Errorf("got %#v want %#v", errs[1], broke)
+		}
+
+		if !errors.Is(actual, denied) {
+			t.Errorf("expected %#v to match %#v", actual, denied)
+		}
+	})
+
+	t.Run("collapses a list of identical errors into a single error", func(t *testing.T) {
+		err := errorlist.Append(errors.New("ahhh"), errors.New("ahhh"))
+
+		actual := errorlist.Dedupe(err, nil)
+
+		var dupe *errorlist.DuplicateError
+		if !errors.As(actual, &dupe) {
+			t.Fatalf("got %#v want type %T", actual, dupe)
+		}
+
+		expected := "ahhh (occurred 2 times)"
+		if actual.Error() != expected {
+			t.Errorf("got %q want %q", actual.Error(), expected)
+		}
+	})
+
+	t.Run("uses the key function when provided", func(t *testing.T) {
+		err := errorlist.Append(
+			&os.PathError{Op: "remove", Path: "/data/dbfast1/seg1", Err: os.ErrPermission},
+			&os.PathError{Op: "remove", Path: "/data/dbfast2/seg2", Err: os.ErrPermission},
+		)
+
+		byCause := func(err error) string {
+			var pathErr *os.PathError
+			if errors.As(err, &pathErr) {
+				return pathErr.Op + ": " + pathErr.Err.Error()
+			}
+
+			return err.Error()
+		}
+
+		actual := errorlist.Dedupe(err, byCause)
+
+		var dupe *errorlist.DuplicateError
+		if !errors.As(actual, &dupe) {
+			t.Fatalf("got %#v want type %T", actual, dupe)
+		}
+
+		if dupe.Count != 2 {
+			t.Errorf("got count %d want 2", dupe.Count)
+		}
+
+		if !strings.Contains(actual.Error(), "/data/dbfast1/seg1") {
+			t.Errorf("expected %q to contain the first error", actual.Error())
+		}
+	})
+}
+
+func TestWithHost(t *testing.T) {
+	t.Run("returns nil for a nil error", func(t *testing.T) {
+		if err := errorlist.WithHost("sdw1", nil); err != nil {
+			t.Errorf("got error %#v want nil", err)
+		}
+	})
+
+	t.Run("annotates an error with the host", func(t *testing.T) {
+		err := errorlist.WithHost("sdw1", os.ErrPermission)
+
+		var host *errorlist.HostError
+		if !errors.As(err, &host) {
+			t.Fatalf("got error %#v want type %T", err, host)
+		}
+
+		if host.Host != "sdw1" {
+			t.Errorf("got host %q want %q", host.Host, "sdw1")
+		}
+
+		if !errors.Is(err, os.ErrPermission) {
+			t.Errorf("got error %#v want %#v", err, os.ErrPermission)
+		}
+	})
+
+	t.Run("annotates each entry of a list", func(t *testing.T) {
+		var errs error
+		errs = errorlist.Append(errs, os.ErrPermission)
+		errs = errorlist.Append(errs, os.ErrNotExist)
+
+		err := errorlist.WithHost("sdw1", errs)
+
+		var list errorlist.Errors
+		if !errors.As(err, &list) {
+			t.Fatalf("got error %#v want type %T", err, list)
+		}
+
+		expected := errorlist.Errors{
+			&errorlist.HostError{Host: "sdw1", Err: os.ErrPermission},
+			&errorlist.HostError{Host: "sdw1", Err: os.ErrNotExist},
+		}
+		if !reflect.DeepEqual(list, expected) {
+			t.Errorf("got %#v want %#v", list, expected)
+		}
+	})
+}
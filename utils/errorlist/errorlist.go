@@ -64,3 +64,9 @@ func (e Errors) Error() string {
 		"%d errors occurred:\n\t%s\n\n",
 		len(e), strings.Join(errors, "\n\t"))
 }
+
+// Unwrap returns the contained errors so that errors.Is and errors.As match
+// against any error in the list.
+func (e Errors) Unwrap() []error {
+	return e
+}
@@ -6,6 +6,7 @@ package errorlist_test
 import (
 	"errors"
 	"fmt"
+	"os"
 	"reflect"
 	"testing"
 
@@ -137,4 +138,53 @@ func TestErrors(t *testing.T) {
 			t.Errorf("Error() = %q, want %q", actual, expected)
 		}
 	})
+
+	t.Run("errors.Is matches any contained error", func(t *testing.T) {
+		first := errors.New("ahhh")
+		second := errors.New("it broke")
+
+		err := errorlist.Append(first, fmt.Errorf("context: %w", second))
+
+		for _, expected := range []error{first, second} {
+			if !errors.Is(err, expected) {
+				t.Errorf("errors.Is(%#v, %#v) = false, want true", err, expected)
+			}
+		}
+	})
+
+	t.Run("errors.Is does not match an error that is not contained", func(t *testing.T) {
+		err := errorlist.Append(errors.New("ahhh"), errors.New("it broke"))
+
+		other := errors.New("it broke")
+		if errors.Is(err, other) {
+			t.Errorf("errors.Is(%#v, %#v) = true, want false", err, other)
+		}
+	})
+
+	t.Run("errors.As finds a contained error of the target type", func(t *testing.T) {
+		expected := &os.PathError{Op: "remove", Path: "/data", Err: os.ErrPermission}
+		err := errorlist.Append(errors.New("ahhh"), expected)
+
+		var pathErr *os.PathError
+		if !errors.As(err, &pathErr) {
+			t.Fatalf("errors.As(%#v) = false, want true", err)
+		}
+
+		if pathErr != expected {
+			t.Errorf("got %#v want %#v", pathErr, expected)
+		}
+
+		if !errors.Is(err, os.ErrPermission) {
+			t.Errorf("errors.Is(%#v, %#v) = false, want true", err, os.ErrPermission)
+		}
+	})
+
+	t.Run("errors.As does not match when no contained error has the target type", func(t *testing.T) {
+		err := errorlist.Append(errors.New("ahhh"), errors.New("it broke"))
+
+		var pathErr *os.PathError
+		if errors.As(err, &pathErr) {
+			t.Errorf("errors.As(%#v) = true, want false", err)
+		}
+	})
 }
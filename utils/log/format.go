@@ -0,0 +1,139 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package log
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/utils"
+)
+
+const (
+	TextFormat = "text"
+	JSONFormat = "json"
+)
+
+// levelSeparator delimits the level from the message in the prefix used for
+// JSON logging, so that JSONWriter can tell them apart.
+const levelSeparator = "\x1f"
+
+// ValidateFormat returns an error if format is not a supported log format.
+func ValidateFormat(format string) error {
+	if format != TextFormat && format != JSONFormat {
+		return xerrors.Errorf("invalid log format %q: must be %q or %q", format, TextFormat, JSONFormat)
+	}
+
+	return nil
+}
+
+// Initialize sets up gplog for program, writing to a log file in logdir as
+// well as the standard streams. The text format is gplog's default, and the
+// json format writes one JSON object per line.
+func Initialize(program, logdir, format string) error {
+	return InitializeWithRotation(program, logdir, format, Rotation{})
+}
+
+// InitializeWithRotation is Initialize, but rotates and removes old log
+// files according to rotation.
+func InitializeWithRotation(program, logdir, format string, rotation Rotation) error {
+	if err := ValidateFormat(format); err != nil {
+		return err
+	}
+
+	if format == TextFormat && !rotation.enabled() {
+		utils.ReinitializeLogging(program, logdir)
+		return nil
+	}
+
+	if err := os.MkdirAll(logdir, 0755); err != nil {
+		return xerrors.Errorf("creating log directory: %w", err)
+	}
+
+	logfile := gplog.GenerateLogFileName(program, logdir)
+	file, err := OpenRotatingFile(logfile, rotation)
+	if err != nil {
+		return err
+	}
+
+	if format == TextFormat {
+		gplog.SetLogger(gplog.NewLogger(os.Stdout, os.Stderr, file, logfile, gplog.LOGINFO, program))
+		gplog.SetExitFunc(func() {
+			os.Exit(1)
+		})
+		return nil
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		return xerrors.Errorf("getting hostname: %w", err)
+	}
+
+	// The standard streams are wrapped rather than copied so that logging
+	// continues to the log file once they are closed by daemon.Daemonize().
+	logger := gplog.NewLogger(NewJSONWriter(os.Stdout, host), NewJSONWriter(os.Stderr, host), NewJSONWriter(file, host),
+		logfile, gplog.LOGINFO, program)
+	gplog.SetLogger(logger)
+	gplog.SetLogPrefixFunc(func(level string) string {
+		return level + levelSeparator
+	})
+	gplog.SetExitFunc(func() {
+		os.Exit(1)
+	})
+
+	return nil
+}
+
+type entry struct {
+	Timestamp string `json:"timestamp"`
+	Level     string `json:"level"`
+	Message   string `json:"message"`
+	Host      string `json:"host"`
+}
+
+// JSONWriter converts each line written by gplog into a JSON object.
+type JSONWriter struct {
+	w    io.Writer
+	host string
+}
+
+func NewJSONWriter(w io.Writer, host string) *JSONWriter {
+	return &JSONWriter{w: w, host: host}
+}
+
+// Write expects p to be a single log line as written by gplog using the
+// prefix configured by Initialize. Multi-line messages such as stack traces
+// are kept within a single object.
+func (j *JSONWriter) Write(p []byte) (int, error) {
+	line := strings.TrimSuffix(string(p), "\n")
+
+	var level string
+	message := line
+	if i := strings.Index(line, levelSeparator); i >= 0 {
+		level = line[:i]
+		message = line[i+len(levelSeparator):]
+	}
+
+	out, err := json.Marshal(entry{
+		Timestamp: time.Now().Format(time.RFC3339Nano),
+		Level:     level,
+		Message:   message,
+		Host:      j.host,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := j.w.Write(append(out, '\n')); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package log_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+	"github.com/greenplum-db/gpupgrade/utils/log"
+)
+
+type entry struct {
+	Timestamp string `json:"timestamp"`
+	Level     string `json:"level"`
+	Message   string `json:"message"`
+	Host      string `json:"host"`
+}
+
+func TestValidateFormat(t *testing.T) {
+	for _, format := range []string{log.TextFormat, log.JSONFormat} {
+		if err := log.ValidateFormat(format); err != nil {
+			t.Errorf("unexpected error for %q: %#v", format, err)
+		}
+	}
+
+	if err := log.ValidateFormat("xml"); err == nil {
+		t.Error("expected error for format \"xml\", got nil")
+	}
+}
+
+func TestJSONWriter(t *testing.T) {
+	t.Run("writes a JSON object for a line without a level", func(t *testing.T) {
+		var buf bytes.Buffer
+		writer := log.NewJSONWriter(&buf, "sdw1")
+
+		line := "some message\nwith a stack trace\n"
+		n, err := writer.Write([]byte(line))
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if n != len(line) {
+			t.Errorf("wrote %d bytes want %d", n, len(line))
+		}
+
+		if strings.Count(buf.String(), "\n") != 1 {
+			t.Errorf("expected a single line, got %q", buf.String())
+		}
+
+		var e entry
+		if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		expected := entry{Timestamp: e.Timestamp, Message: "some message\nwith a stack trace", Host: "sdw1"}
+		if e != expected {
+			t.Errorf("got %+v want %+v", e, expected)
+		}
+
+		if _, err := time.Parse(time.RFC3339Nano, e.Timestamp); err != nil {
+			t.Errorf("unexpected error parsing timestamp: %#v", err)
+		}
+	})
+}
+
+func TestInitialize(t *testing.T) {
+	defer testlog.SetupLogger()
+
+	t.Run("errors on an invalid format", func(t *testing.T) {
+		err := log.Initialize("gpupgrade_test", "/does/not/matter", "xml")
+		if err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+
+	t.Run("writes JSON objects to the log file", func(t *testing.T) {
+		logdir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, logdir)
+
+		err := log.Initialize("gpupgrade_test", logdir, log.JSONFormat)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		gplog.Info("starting %s", "agent")
+		gplog.Debug("debug message")
+
+		file, err := os.Open(gplog.GetLogFilePath())
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		defer file.Close()
+
+		host, err := os.Hostname()
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		var entries []entry
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var e entry
+			if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+				t.Fatalf("line %q is not JSON: %#v", scanner.Text(), err)
+			}
+
+			e.Timestamp = ""
+			entries = append(entries, e)
+		}
+
+		expected := []entry{
+			{Level: "INFO", Message: "starting agent", Host: host},
+			{Level: "DEBUG", Message: "debug message", Host: host},
+		}
+		if len(entries) != len(expected) {
+			t.Fatalf("got %+v want %+v", entries, expected)
+		}
+
+		for i := range expected {
+			if entries[i] != expected[i] {
+				t.Errorf("got %+v want %+v", entries[i], expected[i])
+			}
+		}
+	})
+}
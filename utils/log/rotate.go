@@ -0,0 +1,189 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/utils"
+)
+
+// Rotation controls rotation and retention of a log file. The zero value
+// disables both.
+type Rotation struct {
+	// MaxSize is the size in bytes past which the log file is rotated. Zero
+	// is unlimited.
+	MaxSize int64
+
+	// MaxAge is how long rotated log files, and those from previous days, are
+	// kept before being removed. Zero keeps them forever.
+	MaxAge time.Duration
+}
+
+// NewRotation returns the Rotation for the --log-max-size and --log-max-age
+// flags, given in megabytes and days.
+func NewRotation(maxSizeMB, maxAgeDays int) Rotation {
+	return Rotation{
+		MaxSize: int64(maxSizeMB) * megabyte,
+		MaxAge:  time.Duration(maxAgeDays) * day,
+	}
+}
+
+const (
+	megabyte = 1024 * 1024
+	day      = 24 * time.Hour
+)
+
+// Args returns the --log-max-size and --log-max-age flags that start another
+// gpupgrade process, such as a hub or agent, with this rotation. The zero
+// Rotation returns no flags.
+func (r Rotation) Args() []string {
+	var args []string
+	if r.MaxSize > 0 {
+		args = append(args, "--log-max-size", strconv.FormatInt(r.MaxSize/megabyte, 10))
+	}
+
+	if r.MaxAge > 0 {
+		args = append(args, "--log-max-age", strconv.FormatInt(int64(r.MaxAge/day), 10))
+	}
+
+	return args
+}
+
+func (r Rotation) enabled() bool {
+	return r.MaxSize > 0 || r.MaxAge > 0
+}
+
+// RotatingFile is an append-only log file that is renamed aside with a
+// timestamp suffix once it grows past Rotation.MaxSize, after which writing
+// continues to a new file of the original name. Whenever it is opened or
+// rotated, older log files in the same directory are removed according to
+// Rotation.MaxAge.
+type RotatingFile struct {
+	path     string
+	rotation Rotation
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// OpenRotatingFile opens path for appending, creating it if needed.
+func OpenRotatingFile(path string, rotation Rotation) (*RotatingFile, error) {
+	r := &RotatingFile{path: path, rotation: rotation}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+
+	r.removeExpired()
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	file, err := utils.System.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return xerrors.Errorf("opening log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return xerrors.Errorf("opening log file: %w", err)
+	}
+
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// A single write larger than MaxSize still goes to a file of its own
+	// rather than being split.
+	if r.rotation.MaxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.rotation.MaxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return xerrors.Errorf("closing log file: %w", err)
+	}
+
+	// Reopen even if the rename fails so that logging continues to the
+	// current file.
+	rotated := r.path + "." + utils.System.Now().Format("20060102T150405.000000000")
+	renameErr := utils.System.Rename(r.path, rotated)
+
+	if err := r.open(); err != nil {
+		return err
+	}
+
+	if renameErr != nil {
+		return xerrors.Errorf("rotating log file: %w", renameErr)
+	}
+
+	r.removeExpired()
+	return nil
+}
+
+// removeExpired removes log files for the same program that are older than
+// MaxAge. These are files rotated by RotatingFile as well as those gplog
+// names after previous days. Removal is best-effort: retention should never
+// stop logging, and failures cannot be logged since the log file is being
+// written to.
+func (r *RotatingFile) removeExpired() {
+	if r.rotation.MaxAge <= 0 {
+		return
+	}
+
+	// gplog names log files <program>_<date>.log, so the prefix matches all
+	// of the program's log files in the directory.
+	base := filepath.Base(r.path)
+	prefix := base
+	if i := strings.LastIndex(base, "_"); i >= 0 {
+		prefix = base[:i+1]
+	}
+
+	matches, err := utils.System.FilePathGlob(filepath.Join(filepath.Dir(r.path), prefix+"*.log*"))
+	if err != nil {
+		return
+	}
+
+	cutoff := utils.System.Now().Add(-r.rotation.MaxAge)
+	for _, match := range matches {
+		if match == r.path {
+			continue
+		}
+
+		info, err := utils.System.Stat(match)
+		if err != nil || info.IsDir() || !info.ModTime().Before(cutoff) {
+			continue
+		}
+
+		_ = utils.System.Remove(match)
+	}
+}
+
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.file.Close()
+}
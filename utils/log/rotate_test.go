@@ -0,0 +1,170 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package log_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/utils/log"
+)
+
+func TestRotatingFile(t *testing.T) {
+	t.Run("rotates once the file would exceed the maximum size", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		path := filepath.Join(dir, "gpupgrade_agent_20210304.log")
+		file, err := log.OpenRotatingFile(path, log.Rotation{MaxSize: 11})
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		defer file.Close()
+
+		for _, line := range []string{"12345\n", "6789\n", "abc\n"} {
+			if _, err := file.Write([]byte(line)); err != nil {
+				t.Fatalf("unexpected error: %#v", err)
+			}
+		}
+
+		if contents := testutils.MustReadFile(t, path); contents != "abc\n" {
+			t.Errorf("got current log %q want %q", contents, "abc\n")
+		}
+
+		rotated, err := filepath.Glob(path + ".*")
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if len(rotated) != 1 {
+			t.Fatalf("got rotated files %q want 1", rotated)
+		}
+
+		if contents := testutils.MustReadFile(t, rotated[0]); contents != "12345\n6789\n" {
+			t.Errorf("got rotated log %q want %q", contents, "12345\n6789\n")
+		}
+	})
+
+	t.Run("counts the existing contents of the file towards the maximum size", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		path := filepath.Join(dir, "gpupgrade_agent_20210304.log")
+		testutils.MustWriteToFile(t, path, "123456789\n")
+
+		file, err := log.OpenRotatingFile(path, log.Rotation{MaxSize: 10})
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		defer file.Close()
+
+		if _, err := file.Write([]byte("x\n")); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if contents := testutils.MustReadFile(t, path); contents != "x\n" {
+			t.Errorf("got current log %q want %q", contents, "x\n")
+		}
+	})
+
+	t.Run("removes log files older than the maximum age", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		old := time.Now().Add(-10 * 24 * time.Hour)
+		expired := []string{
+			filepath.Join(dir, "gpupgrade_agent_20210101.log"),
+			filepath.Join(dir, "gpupgrade_agent_20210101.log.20210101T120000.000000000"),
+		}
+		kept := []string{
+			filepath.Join(dir, "gpupgrade_agent_20210303.log"),
+			filepath.Join(dir, "gpupgrade_hub_20210101.log"),
+		}
+
+		for _, path := range append(expired, kept...) {
+			testutils.MustWriteToFile(t, path, "log\n")
+		}
+
+		for _, path := range append(expired, kept[1]) {
+			if err := os.Chtimes(path, old, old); err != nil {
+				t.Fatalf("unexpected error: %#v", err)
+			}
+		}
+
+		// The current log file is never removed, regardless of its age.
+		current := filepath.Join(dir, "gpupgrade_agent_20210304.log")
+		testutils.MustWriteToFile(t, current, "")
+		if err := os.Chtimes(current, old, old); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		file, err := log.OpenRotatingFile(current, log.Rotation{MaxAge: 7 * 24 * time.Hour})
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		defer file.Close()
+
+		for _, path := range expired {
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("expected %q to be removed", path)
+			}
+		}
+
+		for _, path := range append(kept, current) {
+			if _, err := os.Stat(path); err != nil {
+				t.Errorf("expected %q to be kept: %v", path, err)
+			}
+		}
+	})
+
+	t.Run("does not rotate or remove files when disabled", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		path := filepath.Join(dir, "gpupgrade_agent_20210304.log")
+		file, err := log.OpenRotatingFile(path, log.Rotation{})
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		defer file.Close()
+
+		line := strings.Repeat("a", 1024) + "\n"
+		for i := 0; i < 3; i++ {
+			if _, err := file.Write([]byte(line)); err != nil {
+				t.Fatalf("unexpected error: %#v", err)
+			}
+		}
+
+		if contents := testutils.MustReadFile(t, path); contents != strings.Repeat(line, 3) {
+			t.Errorf("expected all writes to be in the current log")
+		}
+	})
+}
+
+func TestRotationArgs(t *testing.T) {
+	cases := []struct {
+		name     string
+		rotation log.Rotation
+		expected []string
+	}{
+		{"no rotation", log.Rotation{}, nil},
+		{"size only", log.NewRotation(10, 0), []string{"--log-max-size", "10"}},
+		{"age only", log.NewRotation(0, 7), []string{"--log-max-age", "7"}},
+		{"size and age", log.NewRotation(10, 7), []string{"--log-max-size", "10", "--log-max-age", "7"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			args := c.rotation.Args()
+			if !reflect.DeepEqual(args, c.expected) {
+				t.Errorf("got args %q want %q", args, c.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package log
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/utils"
+)
+
+// Follow calls send with each line appended to the log file at path from now
+// on, in order and without the trailing newline, until ctx is done or send
+// returns an error. A trailing partial line is held back until it is
+// completed. When the file is rotated by RotatingFile, or is truncated, the
+// rest of the old file is read and then path is reopened from its start. The
+// file is checked for new lines every poll interval.
+func Follow(ctx context.Context, path string, poll time.Duration, send func(line string) error) error {
+	file, err := utils.System.Open(path)
+	if err != nil {
+		return xerrors.Errorf("following log file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return xerrors.Errorf("following log file: %w", err)
+	}
+
+	reader := bufio.NewReader(file)
+	var partial string
+
+	for {
+		chunk, err := reader.ReadString('\n')
+		offset += int64(len(chunk))
+		partial += chunk
+
+		if err == nil {
+			if err := send(strings.TrimSuffix(partial, "\n")); err != nil {
+				return err
+			}
+
+			partial = ""
+			continue
+		}
+
+		if err != io.EOF {
+			return xerrors.Errorf("following log file: %w", err)
+		}
+
+		// All lines written so far have been read, so if the file has been
+		// replaced or truncated nothing is lost by switching to the new one.
+		reopen, err := replaced(file, path, offset)
+		if err != nil {
+			return err
+		}
+
+		if reopen {
+			newFile, err := utils.System.Open(path)
+			if err != nil {
+				return xerrors.Errorf("reopening log file: %w", err)
+			}
+
+			_ = file.Close()
+			file = newFile
+			reader.Reset(file)
+			offset = 0
+			partial = ""
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(poll):
+		}
+	}
+}
+
+// replaced returns whether path no longer refers to the open file, or whether
+// the file has been truncated below offset. A missing path, as between a
+// rotation's rename and the creation of the new file, is not yet a
+// replacement.
+func replaced(file *os.File, path string, offset int64) (bool, error) {
+	current, err := utils.System.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, xerrors.Errorf("following log file: %w", err)
+	}
+
+	opened, err := file.Stat()
+	if err != nil {
+		return false, xerrors.Errorf("following log file: %w", err)
+	}
+
+	return !os.SameFile(opened, current) || current.Size() < offset, nil
+}
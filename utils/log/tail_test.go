@@ -0,0 +1,118 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package log_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/log"
+)
+
+func TestFollow(t *testing.T) {
+	// follow starts following path, returning once Follow has read to the
+	// end of the file and first checked it for replacement.
+	follow := func(t *testing.T, ctx context.Context, path string, send func(string) error) chan error {
+		t.Helper()
+
+		following := make(chan struct{})
+		var once sync.Once
+		utils.System.Stat = func(name string) (os.FileInfo, error) {
+			once.Do(func() { close(following) })
+			return os.Stat(name)
+		}
+
+		errs := make(chan error, 1)
+		go func() {
+			errs <- log.Follow(ctx, path, time.Millisecond, send)
+		}()
+
+		select {
+		case <-following:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting to follow the log file")
+		}
+
+		return errs
+	}
+	defer func() {
+		utils.System.Stat = os.Stat
+	}()
+
+	t.Run("reopens a truncated file from its start", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		path := filepath.Join(dir, "gpupgrade_hub_20210304.log")
+		testutils.MustWriteToFile(t, path, "some earlier output\n")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		lines := make(chan string)
+		errs := follow(t, ctx, path, func(line string) error {
+			lines <- line
+			return nil
+		})
+
+		testutils.MustWriteToFile(t, path, "new\n")
+
+		select {
+		case line := <-lines:
+			if line != "new" {
+				t.Errorf("got line %q want %q", line, "new")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a line")
+		}
+
+		cancel()
+		if err := <-errs; err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+	})
+
+	t.Run("returns the error from send", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		path := filepath.Join(dir, "gpupgrade_hub_20210304.log")
+		testutils.MustWriteToFile(t, path, "")
+
+		expected := errors.New("disconnected")
+		errs := follow(t, context.Background(), path, func(line string) error {
+			return expected
+		})
+
+		testutils.MustWriteToFile(t, path, "line\n")
+
+		select {
+		case err := <-errs:
+			if !errors.Is(err, expected) {
+				t.Errorf("got error %#v want %#v", err, expected)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for Follow to return")
+		}
+	})
+
+	t.Run("errors when the file does not exist", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		err := log.Follow(context.Background(), filepath.Join(dir, "missing.log"), time.Millisecond, func(string) error {
+			return nil
+		})
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("got error %#v want %#v", err, os.ErrNotExist)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"io"
+	"os"
+	"sync"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"github.com/greenplum-db/gp-common-go-libs/operating"
+)
+
+var (
+	logFileMu sync.Mutex
+	logFile   io.Closer // the file opened by the last ReinitializeLogging
+)
+
+// ReinitializeLogging sets up gplog for program, logging to a file in logdir,
+// whether or not logging has already been initialized. Once a logger exists
+// gplog.InitializeLogging is a no-op, so without this the hub and agent would
+// keep logging to the file opened by the CLI before it dispatched to them.
+// The file opened by a previous call is closed once the new logger is in
+// place.
+func ReinitializeLogging(program, logdir string) {
+	logFileMu.Lock()
+	defer logFileMu.Unlock()
+
+	if gplog.GetLogger() != nil {
+		gplog.SetLogger(nil)
+	}
+
+	// gplog does not expose the file it opens, so record it as it is opened.
+	previous := logFile
+	openFile := operating.System.OpenFileWrite
+	operating.System.OpenFileWrite = func(name string, flag int, perm os.FileMode) (io.WriteCloser, error) {
+		file, err := openFile(name, flag, perm)
+		if err == nil {
+			logFile = file
+		}
+		return file, err
+	}
+	defer func() {
+		operating.System.OpenFileWrite = openFile
+	}()
+
+	gplog.InitializeLogging(program, logdir)
+
+	if previous != nil && previous != logFile {
+		if err := previous.Close(); err != nil {
+			gplog.Warn("closing previous log file: %v", err)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package utils_test
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"github.com/greenplum-db/gp-common-go-libs/operating"
+
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/testutils/testlog"
+	"github.com/greenplum-db/gpupgrade/utils"
+)
+
+func TestReinitializeLogging(t *testing.T) {
+	defer testlog.SetupLogger()
+
+	readLog := func(t *testing.T, path string) string {
+		t.Helper()
+
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		return string(contents)
+	}
+
+	t.Run("initializes logging when there is no logger", func(t *testing.T) {
+		logdir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, logdir)
+
+		gplog.SetLogger(nil)
+		utils.ReinitializeLogging("gpupgrade_test", logdir)
+
+		if gplog.GetLogger() == nil {
+			t.Fatal("expected a logger")
+		}
+
+		path := gplog.GetLogFilePath()
+		if filepath.Dir(path) != logdir || !strings.HasPrefix(filepath.Base(path), "gpupgrade_test_") {
+			t.Errorf("got log file %q want one for gpupgrade_test in %q", path, logdir)
+		}
+	})
+
+	t.Run("replaces an existing logger when called twice", func(t *testing.T) {
+		logdir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, logdir)
+
+		utils.ReinitializeLogging("gpupgrade_cli", logdir)
+		first := gplog.GetLogFilePath()
+
+		utils.ReinitializeLogging("gpupgrade_agent", logdir)
+		second := gplog.GetLogFilePath()
+
+		if first == second {
+			t.Fatalf("expected log file to change from %q", first)
+		}
+
+		gplog.Debug("reinitialized")
+
+		if strings.Contains(readLog(t, first), "reinitialized") {
+			t.Errorf("expected %q to no longer be logged to", first)
+		}
+
+		if count := strings.Count(readLog(t, second), "reinitialized"); count != 1 {
+			t.Errorf("got %d log lines in %q want 1", count, second)
+		}
+	})
+
+	t.Run("closes the log file opened by the previous call", func(t *testing.T) {
+		logdir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, logdir)
+
+		var files []io.WriteCloser
+		openFile := operating.System.OpenFileWrite
+		operating.System.OpenFileWrite = func(name string, flag int, perm os.FileMode) (io.WriteCloser, error) {
+			file, err := openFile(name, flag, perm)
+			files = append(files, file)
+			return file, err
+		}
+		defer func() {
+			operating.System.OpenFileWrite = openFile
+		}()
+
+		utils.ReinitializeLogging("gpupgrade_cli", logdir)
+		utils.ReinitializeLogging("gpupgrade_agent", logdir)
+
+		if len(files) != 2 {
+			t.Fatalf("got %d opened log files want 2", len(files))
+		}
+
+		if _, err := files[0].Write([]byte("closed?")); !errors.Is(err, os.ErrClosed) {
+			t.Errorf("got error %#v writing to the previous log file want %#v", err, os.ErrClosed)
+		}
+
+		if _, err := files[1].Write([]byte("open?")); err != nil {
+			t.Errorf("unexpected error writing to the current log file: %#v", err)
+		}
+	})
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+// Package metrics records how long each step takes and whether it succeeded,
+// and serves the results in the Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"golang.org/x/xerrors"
+)
+
+// DurationBuckets are the upper bounds, in seconds, of the step duration
+// histogram. Steps range from milliseconds to hours.
+var DurationBuckets = []float64{0.1, 0.5, 1, 5, 15, 60, 300, 900, 3600, 10800}
+
+// Registry holds the metrics of each step by name. It is safe for concurrent
+// use, and is an http.Handler serving the metrics.
+type Registry struct {
+	mu    sync.Mutex
+	steps map[string]*stepMetrics
+}
+
+type stepMetrics struct {
+	buckets  []uint64 // non-cumulative counts per DurationBuckets entry
+	count    uint64
+	sum      float64
+	success  uint64
+	failures uint64
+}
+
+func NewRegistry() *Registry {
+	return &Registry{steps: make(map[string]*stepMetrics)}
+}
+
+// Default is the Registry that ObserveStep records to and Handler serves.
+var Default = NewRegistry()
+
+// ObserveStep records a step that took d to Default. The step is counted as a
+// failure when err is non-nil.
+func ObserveStep(name string, d time.Duration, err error) {
+	Default.ObserveStep(name, d, err)
+}
+
+// Handler returns an http.Handler serving the metrics in Default.
+func Handler() http.Handler {
+	return Default
+}
+
+func (r *Registry) ObserveStep(name string, d time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.steps[name]
+	if !ok {
+		m = &stepMetrics{buckets: make([]uint64, len(DurationBuckets))}
+		r.steps[name] = m
+	}
+
+	seconds := d.Seconds()
+	for i, bound := range DurationBuckets {
+		if seconds <= bound {
+			m.buckets[i]++
+			break
+		}
+	}
+
+	m.count++
+	m.sum += seconds
+
+	if err != nil {
+		m.failures++
+	} else {
+		m.success++
+	}
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	out := bufio.NewWriter(w)
+	r.write(out)
+	if err := out.Flush(); err != nil {
+		gplog.Debug("writing metrics: %v", err)
+	}
+}
+
+func (r *Registry) write(w *bufio.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.steps))
+	for name := range r.steps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "# HELP gpupgrade_step_duration_seconds How long each step took.")
+	fmt.Fprintln(w, "# TYPE gpupgrade_step_duration_seconds histogram")
+	for _, name := range names {
+		m := r.steps[name]
+		step := escapeLabel(name)
+
+		var cumulative uint64
+		for i, bound := range DurationBuckets {
+			cumulative += m.buckets[i]
+			fmt.Fprintf(w, "gpupgrade_step_duration_seconds_bucket{step=\"%s\",le=\"%s\"} %d\n", step, formatFloat(bound), cumulative)
+		}
+		fmt.Fprintf(w, "gpupgrade_step_duration_seconds_bucket{step=\"%s\",le=\"+Inf\"} %d\n", step, m.count)
+		fmt.Fprintf(w, "gpupgrade_step_duration_seconds_sum{step=\"%s\"} %s\n", step, formatFloat(m.sum))
+		fmt.Fprintf(w, "gpupgrade_step_duration_seconds_count{step=\"%s\"} %d\n", step, m.count)
+	}
+
+	fmt.Fprintln(w, "# HELP gpupgrade_step_total How many times each step succeeded or failed.")
+	fmt.Fprintln(w, "# TYPE gpupgrade_step_total counter")
+	for _, name := range names {
+		m := r.steps[name]
+		step := escapeLabel(name)
+
+		fmt.Fprintf(w, "gpupgrade_step_total{step=\"%s\",outcome=\"success\"} %d\n", step, m.success)
+		fmt.Fprintf(w, "gpupgrade_step_total{step=\"%s\",outcome=\"failure\"} %d\n", step, m.failures)
+	}
+}
+
+// Serve listens on addr and serves the metrics in Default at /metrics in the
+// background. Failing to listen is returned immediately so that a bad
+// --metrics-addr is reported at startup. The returned server's Addr is the
+// address actually listened on.
+func Serve(addr string) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, xerrors.Errorf("listening for metrics on %q: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	server := &http.Server{Addr: listener.Addr().String(), Handler: mux}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			gplog.Error("serving metrics on %q: %v", addr, err)
+		}
+	}()
+
+	return server, nil
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2017-2021 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/greenplum-db/gpupgrade/utils/metrics"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Run("reports step durations and outcomes in the Prometheus text format", func(t *testing.T) {
+		registry := metrics.NewRegistry()
+		registry.ObserveStep("initialize", 2*time.Second, nil)
+		registry.ObserveStep("initialize", 20*time.Second, errors.New("permission denied"))
+
+		body := serve(t, registry)
+
+		expected := []string{
+			"# TYPE gpupgrade_step_duration_seconds histogram",
+			`gpupgrade_step_duration_seconds_bucket{step="initialize",le="1"} 0`,
+			`gpupgrade_step_duration_seconds_bucket{step="initialize",le="5"} 1`,
+			`gpupgrade_step_duration_seconds_bucket{step="initialize",le="60"} 2`,
+			`gpupgrade_step_duration_seconds_bucket{step="initialize",le="+Inf"} 2`,
+			`gpupgrade_step_duration_seconds_sum{step="initialize"} 22`,
+			`gpupgrade_step_duration_seconds_count{step="initialize"} 2`,
+			"# TYPE gpupgrade_step_total counter",
+			`gpupgrade_step_total{step="initialize",outcome="success"} 1`,
+			`gpupgrade_step_total{step="initialize",outcome="failure"} 1`,
+		}
+		for _, line := range expected {
+			if !strings.Contains(body, line+"\n") {
+				t.Errorf("metrics %q do not contain %q", body, line)
+			}
+		}
+	})
+
+	t.Run("escapes step names", func(t *testing.T) {
+		registry := metrics.NewRegistry()
+		registry.ObserveStep(`copying "master"`+"\n", time.Second, nil)
+
+		body := serve(t, registry)
+
+		expected := `gpupgrade_step_duration_seconds_count{step="copying \"master\"\n"} 1`
+		if !strings.Contains(body, expected) {
+			t.Errorf("metrics %q do not contain %q", body, expected)
+		}
+	})
+}
+
+func TestServe(t *testing.T) {
+	t.Run("returns an error when it cannot listen", func(t *testing.T) {
+		_, err := metrics.Serve("not an address")
+		if err == nil {
+			t.Errorf("expected an error")
+		}
+	})
+
+	t.Run("serves the default metrics at /metrics", func(t *testing.T) {
+		metrics.ObserveStep("serve test", time.Second, nil)
+
+		server, err := metrics.Serve("127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		defer server.Close()
+
+		resp, err := http.Get("http://" + server.Addr + "/metrics")
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		defer resp.Body.Close()
+
+		contents, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("reading metrics: %v", err)
+		}
+		body := string(contents)
+
+		expected := `gpupgrade_step_duration_seconds_count{step="serve test"} 1`
+		if !strings.Contains(body, expected) {
+			t.Errorf("metrics %q do not contain %q", body, expected)
+		}
+	})
+}
+
+func serve(t *testing.T, handler http.Handler) string {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, err := ioutil.ReadAll(recorder.Result().Body)
+	if err != nil {
+		t.Fatalf("reading metrics: %v", err)
+	}
+
+	return string(body)
+}
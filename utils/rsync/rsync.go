@@ -29,27 +29,11 @@ var ErrInvalidRsyncSourcePath = errors.New("multiple remote source path passed")
 func Rsync(options ...Option) error {
 	opts := newOptionList(options...)
 
-	dstPath := opts.destination
-	if opts.hasDestinationHost {
-		dstPath = opts.destinationHost + ":" + opts.destination
-	}
-
-	srcPath := opts.sources
-	if opts.hasSourceHost {
-		// can't make an assumption what is required here
-		// i.e host:path1 path2 or host:path1 host:path2
-		if len(opts.sources) != 1 {
-			return ErrInvalidRsyncSourcePath
-		}
-		srcPath = []string{opts.sourceHost + ":" + opts.sources[0]}
+	args, err := opts.args()
+	if err != nil {
+		return err
 	}
 
-	var args []string
-	args = append(args, opts.options...)
-	args = append(args, srcPath...)
-	args = append(args, dstPath)
-	args = append(args, opts.excludedFiles...)
-
 	cmd := rsyncCommand("rsync", args...)
 
 	// when no streams are specified, capture stderr for the error message
@@ -62,7 +46,7 @@ func Rsync(options ...Option) error {
 
 	gplog.Info("running Rsync as %s", cmd.String())
 
-	err := cmd.Run()
+	err = cmd.Run()
 	if err != nil {
 		errorText := err.Error()
 
@@ -77,6 +61,37 @@ func Rsync(options ...Option) error {
 	return nil
 }
 
+// Args returns the arguments Rsync passes to "rsync" for the given options,
+// without running it.
+func Args(options ...Option) ([]string, error) {
+	return newOptionList(options...).args()
+}
+
+func (opts *optionList) args() ([]string, error) {
+	dstPath := opts.destination
+	if opts.hasDestinationHost {
+		dstPath = opts.destinationHost + ":" + opts.destination
+	}
+
+	srcPath := opts.sources
+	if opts.hasSourceHost {
+		// can't make an assumption what is required here
+		// i.e host:path1 path2 or host:path1 host:path2
+		if len(opts.sources) != 1 {
+			return nil, ErrInvalidRsyncSourcePath
+		}
+		srcPath = []string{opts.sourceHost + ":" + opts.sources[0]}
+	}
+
+	var args []string
+	args = append(args, opts.options...)
+	args = append(args, srcPath...)
+	args = append(args, dstPath)
+	args = append(args, opts.excludedFiles...)
+
+	return args, nil
+}
+
 // XXX: for internal testing only
 func SetRsyncCommand(command exectest.Command) {
 	rsyncCommand = command
@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -396,6 +397,37 @@ func TestRsync(t *testing.T) {
 	})
 }
 
+func TestArgs(t *testing.T) {
+	t.Run("returns the arguments passed to rsync", func(t *testing.T) {
+		args, err := rsync.Args(
+			rsync.WithSources("/data/qddir/seg-1/"),
+			rsync.WithDestinationHost("sdw1"),
+			rsync.WithDestination("/data/backup"),
+			rsync.WithOptions("--archive", "--delete"),
+			rsync.WithExcludedFiles("pg_log/*"),
+		)
+		if err != nil {
+			t.Errorf("unexpected error %#v", err)
+		}
+
+		expected := []string{"--archive", "--delete", "/data/qddir/seg-1/", "sdw1:/data/backup", "--exclude", "pg_log/*"}
+		if !reflect.DeepEqual(args, expected) {
+			t.Errorf("got args %q want %q", args, expected)
+		}
+	})
+
+	t.Run("errors for multiple sources on a remote host", func(t *testing.T) {
+		_, err := rsync.Args(
+			rsync.WithSources("/data/qddir/seg-1/", "/data/qddir/seg-2/"),
+			rsync.WithSourceHost("sdw1"),
+			rsync.WithDestination("/tmp/"),
+		)
+		if !errors.Is(err, rsync.ErrInvalidRsyncSourcePath) {
+			t.Errorf("got error %#v want %#v", err, rsync.ErrInvalidRsyncSourcePath)
+		}
+	})
+}
+
 func pathExists(path string) bool {
 	_, err := utils.System.Stat(path)
 	return err == nil
@@ -33,6 +33,11 @@ func (s *Stopwatch) Stop() *Stopwatch {
 	return s
 }
 
+// Elapsed returns the time between starting and stopping the Stopwatch.
+func (s *Stopwatch) Elapsed() time.Duration {
+	return s.elapsedTime
+}
+
 func (s *Stopwatch) String() string {
 	return round(s.elapsedTime).String()
 }
@@ -5,6 +5,8 @@ package utils
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
@@ -12,7 +14,8 @@ import (
 	"path/filepath"
 	"time"
 
-	"github.com/google/renameio"
+	"golang.org/x/sys/unix"
+	"golang.org/x/xerrors"
 
 	"github.com/greenplum-db/gpupgrade/utils/errorlist"
 )
@@ -30,11 +33,13 @@ var (
  */
 
 type SystemFunctions struct {
+	Chmod        func(name string, mode os.FileMode) error
 	CurrentUser  func() (*user.User, error)
 	Getenv       func(key string) string
 	Getpid       func() int
 	Hostname     func() (string, error)
 	IsNotExist   func(err error) bool
+	Kill         func(pid int, sig unix.Signal) error
 	MkdirAll     func(path string, perm os.FileMode) error
 	Now          func() time.Time
 	Open         func(name string) (*os.File, error)
@@ -50,16 +55,20 @@ type SystemFunctions struct {
 	Mkdir        func(name string, perm os.FileMode) error
 	SqlOpen      func(driverName, dataSourceName string) (*sql.DB, error)
 	Symlink      func(oldname, newname string) error
+	Readlink     func(name string) (string, error)
 	Lstat        func(name string) (os.FileInfo, error)
+	Statfs       func(path string, buf *unix.Statfs_t) error
 }
 
 func InitializeSystemFunctions() *SystemFunctions {
 	return &SystemFunctions{
+		Chmod:        os.Chmod,
 		CurrentUser:  user.Current,
 		Getenv:       os.Getenv,
 		Getpid:       os.Getpid,
 		Hostname:     os.Hostname,
 		IsNotExist:   os.IsNotExist,
+		Kill:         unix.Kill,
 		MkdirAll:     os.MkdirAll,
 		Now:          time.Now,
 		Open:         os.Open,
@@ -75,12 +84,17 @@ func InitializeSystemFunctions() *SystemFunctions {
 		Mkdir:        os.Mkdir,
 		SqlOpen:      sql.Open,
 		Symlink:      os.Symlink,
+		Readlink:     os.Readlink,
 		Lstat:        os.Lstat,
+		Statfs:       unix.Statfs,
 	}
 }
 
+// StateDirEnv overrides the default state directory of $HOME/.gpupgrade.
+const StateDirEnv = "GPUPGRADE_HOME"
+
 func GetStateDir() string {
-	stateDir := os.Getenv("GPUPGRADE_HOME")
+	stateDir := os.Getenv(StateDirEnv)
 	if stateDir == "" {
 		stateDir = filepath.Join(os.Getenv("HOME"), ".gpupgrade")
 	}
@@ -88,6 +102,85 @@ func GetStateDir() string {
 	return stateDir
 }
 
+// ValidateStateDir returns an error if stateDir is not an absolute path. The
+// hub and agents resolve the state directory on different hosts and from
+// different working directories, so a relative path would not refer to the
+// same location.
+func ValidateStateDir(stateDir string) error {
+	if !filepath.IsAbs(stateDir) {
+		return xerrors.Errorf("state directory %q must be an absolute path; check %s", stateDir, StateDirEnv)
+	}
+
+	return nil
+}
+
+// StateDirMode is the permissions of the state directory. It holds
+// credentials and cluster configuration, so only its owner has access.
+const StateDirMode os.FileMode = 0700
+
+// ErrStateDirMode is returned by EnsureStateDir when the state directory
+// already exists and other users can write to it.
+var ErrStateDirMode = errors.New("state directory has incorrect permissions")
+
+// StateDirModeError is the backing error type for ErrStateDirMode.
+type StateDirModeError struct {
+	Dir  string
+	Mode os.FileMode
+}
+
+func (s *StateDirModeError) Error() string {
+	return fmt.Sprintf("state directory %q has permissions %04o which allow other users to write to it; run chmod %04o %q",
+		s.Dir, s.Mode.Perm(), StateDirMode, s.Dir)
+}
+
+func (s *StateDirModeError) Is(err error) bool {
+	return err == ErrStateDirMode
+}
+
+// EnsureStateDir creates the state directory returned by GetStateDir if it
+// does not exist, and returns its path. See EnsureStateDirAt.
+func EnsureStateDir() (string, error) {
+	stateDir := GetStateDir()
+	return stateDir, EnsureStateDirAt(stateDir)
+}
+
+// EnsureStateDirAt creates stateDir with StateDirMode if it does not exist.
+// If it already exists it must be a directory that only its owner can write
+// to, whose permissions are then tightened to StateDirMode. It is safe to call
+// repeatedly.
+func EnsureStateDirAt(stateDir string) error {
+	info, err := System.Stat(stateDir)
+	if System.IsNotExist(err) {
+		if err := System.MkdirAll(stateDir, StateDirMode); err != nil {
+			return xerrors.Errorf("creating state directory: %w", err)
+		}
+
+		return nil
+	}
+
+	if err != nil {
+		return xerrors.Errorf("checking state directory: %w", err)
+	}
+
+	if !info.IsDir() {
+		return xerrors.Errorf("state directory %q is not a directory", stateDir)
+	}
+
+	// A directory others can write to may already have been tampered with,
+	// so rather than fixing it the user must check it.
+	if info.Mode().Perm()&0022 != 0 {
+		return &StateDirModeError{Dir: stateDir, Mode: info.Mode()}
+	}
+
+	if info.Mode().Perm() != StateDirMode {
+		if err := System.Chmod(stateDir, StateDirMode); err != nil {
+			return xerrors.Errorf("restricting state directory permissions: %w", err)
+		}
+	}
+
+	return nil
+}
+
 func GetLogDir() (string, error) {
 	currentUser, err := System.CurrentUser()
 	if err != nil {
@@ -152,23 +245,71 @@ func Move(src string, dst string) error {
 	return err
 }
 
-func AtomicallyWrite(path string, data []byte) (err error) {
-	// Use renameio to atomically write the file located at path.
-	var file *renameio.PendingFile
-	file, err = renameio.TempFile("", path)
+// AtomicWriteFile replaces the file at path with data, so that a crash leaves
+// either the old or the new contents but never a partial file. The data is
+// written to a temporary file in the same directory, synced to disk, and
+// renamed over path. The directory is then synced so that the rename itself
+// survives a crash.
+func AtomicWriteFile(path string, data []byte, mode os.FileMode) (err error) {
+	dir := filepath.Dir(path)
+
+	file, err := ioutil.TempFile(dir, "."+filepath.Base(path)+".tmp")
 	if err != nil {
-		return err
+		return xerrors.Errorf("writing %q: %w", path, err)
 	}
+
+	renamed := false
 	defer func() {
-		if cErr := file.Cleanup(); cErr != nil {
-			err = errorlist.Append(err, cErr)
+		if renamed {
+			return
+		}
+
+		// Close again in case an earlier step failed before closing, and
+		// ignore the error from closing twice.
+		_ = file.Close()
+		if rErr := System.Remove(file.Name()); rErr != nil && !os.IsNotExist(rErr) {
+			err = errorlist.Append(err, rErr)
 		}
 	}()
 
-	_, err = file.Write(data)
+	if err := file.Chmod(mode); err != nil {
+		return xerrors.Errorf("writing %q: %w", path, err)
+	}
+
+	if _, err := file.Write(data); err != nil {
+		return xerrors.Errorf("writing %q: %w", path, err)
+	}
+
+	if err := file.Sync(); err != nil {
+		return xerrors.Errorf("syncing %q: %w", path, err)
+	}
+
+	if err := file.Close(); err != nil {
+		return xerrors.Errorf("writing %q: %w", path, err)
+	}
+
+	if err := System.Rename(file.Name(), path); err != nil {
+		return xerrors.Errorf("replacing %q: %w", path, err)
+	}
+	renamed = true
+
+	return syncDir(dir)
+}
+
+func syncDir(dir string) (err error) {
+	d, err := System.Open(dir)
 	if err != nil {
-		return err
+		return xerrors.Errorf("syncing directory %q: %w", dir, err)
+	}
+	defer func() {
+		if cErr := d.Close(); cErr != nil {
+			err = errorlist.Append(err, xerrors.Errorf("syncing directory %q: %w", dir, cErr))
+		}
+	}()
+
+	if err := d.Sync(); err != nil {
+		return xerrors.Errorf("syncing directory %q: %w", dir, err)
 	}
 
-	return file.CloseAtomicallyReplace()
+	return nil
 }
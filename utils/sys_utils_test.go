@@ -2,10 +2,12 @@ package utils_test
 
 import (
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/greenplum-db/gpupgrade/testutils"
@@ -108,7 +110,7 @@ func TestMove(t *testing.T) {
 	})
 }
 
-func TestAtomicallyWrite(t *testing.T) {
+func TestAtomicWriteFile(t *testing.T) {
 	t.Run("successfully writes", func(t *testing.T) {
 		dir := testutils.GetTempDir(t, "")
 		defer testutils.MustRemoveAll(t, dir)
@@ -116,20 +118,99 @@ func TestAtomicallyWrite(t *testing.T) {
 		path := filepath.Join(dir, upgrade.ConfigFileName)
 
 		expected := "testing writing to a file"
-		if err := utils.AtomicallyWrite(path, []byte(expected)); err != nil {
-			t.Errorf("AtomicallyWrite returned error %+v", err)
+		if err := utils.AtomicWriteFile(path, []byte(expected), 0640); err != nil {
+			t.Errorf("AtomicWriteFile returned error %+v", err)
 		}
 
 		contents := testutils.MustReadFile(t, path)
 		if contents != expected {
 			t.Errorf("wrote %#q want %q", contents, expected)
 		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("unexpected error %#v", err)
+		}
+
+		if info.Mode().Perm() != 0640 {
+			t.Errorf("got mode %s want %s", info.Mode().Perm(), os.FileMode(0640))
+		}
+
+		verifyOnlyFiles(t, dir, upgrade.ConfigFileName)
+	})
+
+	t.Run("replaces an existing file", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		path := filepath.Join(dir, upgrade.ConfigFileName)
+		testutils.MustWriteToFile(t, path, "old contents that are longer than the new")
+
+		expected := "new contents"
+		if err := utils.AtomicWriteFile(path, []byte(expected), 0600); err != nil {
+			t.Errorf("AtomicWriteFile returned error %+v", err)
+		}
+
+		contents := testutils.MustReadFile(t, path)
+		if contents != expected {
+			t.Errorf("wrote %#q want %q", contents, expected)
+		}
+	})
+
+	t.Run("an interrupted write leaves the existing file intact", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		path := filepath.Join(dir, upgrade.ConfigFileName)
+		expected := "old contents"
+		testutils.MustWriteToFile(t, path, expected)
+
+		// Fail at the point of replacing the file, after the new contents
+		// have been fully written elsewhere.
+		interrupted := errors.New("interrupted")
+		utils.System.Rename = func(oldpath, newpath string) error {
+			return interrupted
+		}
+		defer func() {
+			utils.System = utils.InitializeSystemFunctions()
+		}()
+
+		err := utils.AtomicWriteFile(path, []byte("new contents"), 0600)
+		if !errors.Is(err, interrupted) {
+			t.Errorf("got error %#v want %#v", err, interrupted)
+		}
+
+		contents := testutils.MustReadFile(t, path)
+		if contents != expected {
+			t.Errorf("got contents %#q want %q", contents, expected)
+		}
+
+		verifyOnlyFiles(t, dir, upgrade.ConfigFileName)
+	})
+
+	t.Run("an interrupted write does not create the file", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		utils.System.Rename = func(oldpath, newpath string) error {
+			return errors.New("interrupted")
+		}
+		defer func() {
+			utils.System = utils.InitializeSystemFunctions()
+		}()
+
+		path := filepath.Join(dir, upgrade.ConfigFileName)
+		if err := utils.AtomicWriteFile(path, []byte("new contents"), 0600); err == nil {
+			t.Errorf("expected an error")
+		}
+
+		verifyOnlyFiles(t, dir)
 	})
 
 	t.Run("errors when directory does not exist", func(t *testing.T) {
 		path := "/does/not/exist"
 
-		err := utils.AtomicallyWrite(path, []byte{})
+		err := utils.AtomicWriteFile(path, []byte{}, 0600)
 		var expected *os.PathError
 		if !errors.As(err, &expected) {
 			t.Errorf("returned error type %T want %T", err, expected)
@@ -140,3 +221,211 @@ func TestAtomicallyWrite(t *testing.T) {
 		}
 	})
 }
+
+// verifyOnlyFiles checks that dir contains exactly the named files, so that no
+// temporary files were left behind.
+func verifyOnlyFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("reading %q: %v", dir, err)
+	}
+
+	var actual []string
+	for _, entry := range entries {
+		actual = append(actual, entry.Name())
+	}
+
+	if !reflect.DeepEqual(actual, names) {
+		t.Errorf("got files %q want %q", actual, names)
+	}
+}
+
+func TestGetStateDir(t *testing.T) {
+	t.Run("defaults to .gpupgrade in the home directory", func(t *testing.T) {
+		resetEnv := testutils.SetEnv(t, utils.StateDirEnv, "")
+		defer resetEnv()
+
+		expected := filepath.Join(os.Getenv("HOME"), ".gpupgrade")
+		if actual := utils.GetStateDir(); actual != expected {
+			t.Errorf("got %q want %q", actual, expected)
+		}
+	})
+
+	t.Run("honors the environment variable when set", func(t *testing.T) {
+		expected := "/var/lib/gpupgrade"
+		resetEnv := testutils.SetEnv(t, utils.StateDirEnv, expected)
+		defer resetEnv()
+
+		actual := utils.GetStateDir()
+		if actual != expected {
+			t.Errorf("got %q want %q", actual, expected)
+		}
+
+		if err := utils.ValidateStateDir(actual); err != nil {
+			t.Errorf("unexpected error %#v", err)
+		}
+	})
+
+	t.Run("rejects a relative override", func(t *testing.T) {
+		resetEnv := testutils.SetEnv(t, utils.StateDirEnv, "gpupgrade")
+		defer resetEnv()
+
+		actual := utils.GetStateDir()
+		if actual != "gpupgrade" {
+			t.Errorf("got %q want %q", actual, "gpupgrade")
+		}
+
+		if err := utils.ValidateStateDir(actual); err == nil {
+			t.Errorf("expected error for relative state directory %q", actual)
+		}
+	})
+}
+
+func TestEnsureStateDir(t *testing.T) {
+	t.Run("creates the state directory when it does not exist", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		stateDir := filepath.Join(dir, ".gpupgrade")
+		resetEnv := testutils.SetEnv(t, utils.StateDirEnv, stateDir)
+		defer resetEnv()
+
+		var perm os.FileMode
+		utils.System.MkdirAll = func(path string, mode os.FileMode) error {
+			perm = mode
+			return os.MkdirAll(path, mode)
+		}
+		defer func() {
+			utils.System.MkdirAll = os.MkdirAll
+		}()
+
+		actual, err := utils.EnsureStateDir()
+		if err != nil {
+			t.Fatalf("unexpected error %#v", err)
+		}
+
+		if actual != stateDir {
+			t.Errorf("got %q want %q", actual, stateDir)
+		}
+
+		if perm != utils.StateDirMode {
+			t.Errorf("created with mode %04o want %04o", perm, utils.StateDirMode)
+		}
+
+		info, err := os.Stat(stateDir)
+		if err != nil {
+			t.Fatalf("unexpected error %#v", err)
+		}
+
+		if !info.IsDir() {
+			t.Errorf("expected %q to be a directory", stateDir)
+		}
+	})
+
+	t.Run("succeeds when the state directory already exists with the correct mode", func(t *testing.T) {
+		stateDir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, stateDir)
+
+		if err := os.Chmod(stateDir, utils.StateDirMode); err != nil {
+			t.Fatalf("unexpected error %#v", err)
+		}
+
+		utils.System.MkdirAll = func(path string, mode os.FileMode) error {
+			t.Errorf("unexpected call to MkdirAll(%q)", path)
+			return nil
+		}
+		defer func() {
+			utils.System.MkdirAll = os.MkdirAll
+		}()
+
+		if err := utils.EnsureStateDirAt(stateDir); err != nil {
+			t.Errorf("unexpected error %#v", err)
+		}
+	})
+
+	t.Run("restricts the permissions of an existing state directory only its owner can write to", func(t *testing.T) {
+		stateDir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, stateDir)
+
+		if err := os.Chmod(stateDir, 0755); err != nil {
+			t.Fatalf("unexpected error %#v", err)
+		}
+
+		if err := utils.EnsureStateDirAt(stateDir); err != nil {
+			t.Fatalf("unexpected error %#v", err)
+		}
+
+		info, err := os.Stat(stateDir)
+		if err != nil {
+			t.Fatalf("unexpected error %#v", err)
+		}
+
+		if info.Mode().Perm() != utils.StateDirMode {
+			t.Errorf("got mode %04o want %04o", info.Mode().Perm(), utils.StateDirMode)
+		}
+	})
+
+	t.Run("errors when permissions of the state directory cannot be restricted", func(t *testing.T) {
+		stateDir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, stateDir)
+
+		if err := os.Chmod(stateDir, 0750); err != nil {
+			t.Fatalf("unexpected error %#v", err)
+		}
+
+		expected := os.ErrPermission
+		utils.System.Chmod = func(name string, mode os.FileMode) error {
+			return expected
+		}
+		defer func() {
+			utils.System.Chmod = os.Chmod
+		}()
+
+		err := utils.EnsureStateDirAt(stateDir)
+		if !errors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+	})
+
+	for _, mode := range []os.FileMode{0770, 0702, 0777} {
+		mode := mode
+
+		t.Run(fmt.Sprintf("errors when others can write to the state directory with mode %04o", mode), func(t *testing.T) {
+			stateDir := testutils.GetTempDir(t, "")
+			defer testutils.MustRemoveAll(t, stateDir)
+
+			if err := os.Chmod(stateDir, mode); err != nil {
+				t.Fatalf("unexpected error %#v", err)
+			}
+
+			err := utils.EnsureStateDirAt(stateDir)
+
+			var modeErr *utils.StateDirModeError
+			if !errors.As(err, &modeErr) {
+				t.Fatalf("got error %#v want type %T", err, modeErr)
+			}
+
+			if !errors.Is(err, utils.ErrStateDirMode) {
+				t.Errorf("got error %#v want %#v", err, utils.ErrStateDirMode)
+			}
+
+			if modeErr.Mode.Perm() != mode {
+				t.Errorf("got mode %04o want %04o", modeErr.Mode.Perm(), mode)
+			}
+		})
+	}
+
+	t.Run("errors when the state directory is a file", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		stateDir := filepath.Join(dir, ".gpupgrade")
+		testutils.MustWriteToFile(t, stateDir, "")
+
+		if err := utils.EnsureStateDirAt(stateDir); err == nil {
+			t.Errorf("expected error for state directory %q that is a file", stateDir)
+		}
+	})
+}